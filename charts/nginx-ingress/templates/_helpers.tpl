@@ -286,7 +286,19 @@ Build the args for the service binary.
 {{- end }}
 - -enable-cert-manager={{ .Values.controller.enableCertManager }}
 - -enable-oidc={{ .Values.controller.enableOIDC }}
+{{- if and .Values.controller.enableOIDC .Values.controller.defaultOIDCPolicy }}
+- -default-oidc-policy={{ .Values.controller.defaultOIDCPolicy }}
+{{- end }}
+{{- if .Values.controller.enableOIDC }}
+- -oidc-fips-mode={{ .Values.controller.oidcFIPSMode }}
+{{- end }}
 - -enable-external-dns={{ .Values.controller.enableExternalDNS }}
+{{- if and .Values.controller.enableExternalDNS .Values.controller.externalDNSProvider }}
+- -external-dns-provider={{ .Values.controller.externalDNSProvider }}
+{{- end }}
+{{- if and .Values.controller.enableExternalDNS .Values.controller.externalDNSOwnerID }}
+- -external-dns-owner-id={{ .Values.controller.externalDNSOwnerID }}
+{{- end }}
 - -default-http-listener-port={{ .Values.controller.defaultHTTPListenerPort}}
 - -default-https-listener-port={{ .Values.controller.defaultHTTPSListenerPort}}
 {{- if .Values.controller.globalConfiguration.create }}
@@ -10,9 +10,12 @@ import (
 	"strings"
 
 	"github.com/golang/glog"
+	extdns_v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/externaldns/v1"
+	extdns_validation "github.com/nginxinc/kubernetes-ingress/pkg/apis/externaldns/validation"
 	api_v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
 const (
@@ -161,12 +164,39 @@ var (
 	serviceInsightListenPort = flag.Int("service-insight-listen-port", 9114,
 		"Set the port where the Service Insight stats are exposed. Requires -nginx-plus. [1024 - 65535]")
 
+	enablePolicyWebhook = flag.Bool("enable-policy-webhook", false,
+		`Enable the validating admission webhook for Policies, and for DNSEndpoints if -enable-external-dns is set. Requires -enable-custom-resources and -policy-webhook-tls-secret`)
+
+	policyWebhookTLSSecretName = flag.String("policy-webhook-tls-secret", "",
+		`A Secret with a TLS certificate and key for TLS termination of the policy webhook.`)
+
+	policyWebhookListenPort = flag.Int("policy-webhook-listen-port", 8443,
+		"Set the port where the policy webhook is exposed. [1024 - 65535]")
+
+	enablePolicyDryRun = flag.Bool("enable-policy-dry-run", false,
+		`Enable the policy dry-run endpoint on localhost, which renders the NGINX configuration of a VirtualServer with a Policy without applying it. Requires -enable-custom-resources`)
+
+	policyDryRunListenPort = flag.Int("policy-dry-run-listen-port", 9116,
+		"Set the localhost port where the policy dry-run endpoint is exposed. [1024 - 65535]")
+
+	enableOIDCSessionAdmin = flag.Bool("enable-oidc-session-admin", false,
+		`Enable the OIDC session administration endpoint on localhost, which lists and revokes the sessions of the OIDC policies. Requires -enable-oidc`)
+
+	oidcSessionAdminListenPort = flag.Int("oidc-session-admin-listen-port", 9117,
+		"Set the localhost port where the OIDC session administration endpoint is exposed. [1024 - 65535]")
+
 	enableCustomResources = flag.Bool("enable-custom-resources", true,
 		"Enable custom resources")
 
 	enableOIDC = flag.Bool("enable-oidc", false,
 		"Enable OIDC Policies.")
 
+	oidcFIPSMode = flag.Bool("oidc-fips-mode", false,
+		"Restrict the OIDC Policies to the FIPS 140-3 approved algorithms. Requires -enable-oidc.")
+
+	defaultOIDCPolicy = flag.String("default-oidc-policy", "",
+		`The namespace/name of the OIDC Policy applied to the VirtualServers that don't reference an OIDC Policy. Requires -enable-oidc. Format: <namespace>/<name>`)
+
 	enableSnippets = flag.Bool("enable-snippets", false,
 		"Enable custom NGINX configuration snippets in Ingress, VirtualServer, VirtualServerRoute and TransportServer resources.")
 
@@ -187,6 +217,9 @@ var (
 
 	readyStatus = flag.Bool("ready-status", true, "Enables the readiness endpoint '/nginx-ready'. The endpoint returns a success code when NGINX has loaded all the config after the startup")
 
+	readyStatusOIDCProviders = flag.Bool("ready-status-oidc-providers", false,
+		`Fail the readiness endpoint when the providers of all the OIDC Policies are unreachable. Requires -enable-oidc and -ready-status.`)
+
 	readyStatusPort = flag.Int("ready-status-port", 8081, "Set the port where the readiness endpoint is exposed. [1024 - 65535]")
 
 	enableLatencyMetrics = flag.Bool("enable-latency-metrics", false,
@@ -198,6 +231,21 @@ var (
 	enableExternalDNS = flag.Bool("enable-external-dns", false,
 		"Enable external-dns controller for VirtualServer resources. Requires -enable-custom-resources")
 
+	externalDNSProvider = flag.String("external-dns-provider", "",
+		"The DNS provider of external-dns, e.g. cloudflare. Sets the default TTL bounds and the allowed provider specific properties of the records of the VirtualServer resources. Requires -enable-external-dns")
+
+	externalDNSMinTTL = flag.Int64("external-dns-min-ttl", 0,
+		"The minimum TTL of the records of the VirtualServer resources. The default is the minimum TTL of the -external-dns-provider. Requires -enable-external-dns")
+
+	externalDNSMaxTTL = flag.Int64("external-dns-max-ttl", 0,
+		"The maximum TTL of the records of the VirtualServer resources. The default is the maximum TTL of the -external-dns-provider. Requires -enable-external-dns")
+
+	externalDNSOwnerID = flag.String("external-dns-owner-id", "",
+		"The owner label of the records of the DNSEndpoints created for the VirtualServer resources, the owner ID of the external-dns instance that manages them. Requires -enable-external-dns")
+
+	externalDNSDefaultTTL = flag.Int64("external-dns-default-ttl", 0,
+		"The TTL set by the DNSEndpoint defaulting webhook on the records without a TTL. The default 0 keeps the default TTL of the -external-dns-provider. Requires -enable-external-dns")
+
 	includeYearInLogs = flag.Bool("include-year", false,
 		"Option to include the year in the log header")
 
@@ -277,6 +325,30 @@ func parseFlags() {
 		*enableServiceInsight = false
 	}
 
+	if *enablePolicyWebhook && !*enableCustomResources {
+		glog.Fatal("enable-policy-webhook flag requires -enable-custom-resources")
+	}
+
+	if *enablePolicyWebhook && *policyWebhookTLSSecretName == "" {
+		glog.Fatal("enable-policy-webhook flag requires -policy-webhook-tls-secret")
+	}
+
+	if *enablePolicyDryRun && !*enableCustomResources {
+		glog.Fatal("enable-policy-dry-run flag requires -enable-custom-resources")
+	}
+
+	if *enableOIDCSessionAdmin && !*enableOIDC {
+		glog.Fatal("enable-oidc-session-admin flag requires -enable-oidc")
+	}
+
+	if *oidcFIPSMode && !*enableOIDC {
+		glog.Fatal("oidc-fips-mode flag requires -enable-oidc")
+	}
+
+	if *readyStatusOIDCProviders && (!*enableOIDC || !*readyStatus) {
+		glog.Fatal("ready-status-oidc-providers flag requires -enable-oidc and -ready-status")
+	}
+
 	if *enableCertManager && !*enableCustomResources {
 		glog.Fatal("enable-cert-manager flag requires -enable-custom-resources")
 	}
@@ -285,6 +357,24 @@ func parseFlags() {
 		glog.Fatal("enable-external-dns flag requires -enable-custom-resources")
 	}
 
+	if (*externalDNSProvider != "" || *externalDNSMinTTL != 0 || *externalDNSMaxTTL != 0 || *externalDNSDefaultTTL != 0 || *externalDNSOwnerID != "") && !*enableExternalDNS {
+		glog.Fatal("external-dns-provider, external-dns-min-ttl, external-dns-max-ttl, external-dns-default-ttl and external-dns-owner-id flags require -enable-external-dns")
+	}
+
+	if *externalDNSOwnerID != "" {
+		if errs := extdns_validation.ValidateLabels(extdns_v1.Labels{extdns_validation.OwnerLabel: *externalDNSOwnerID}, field.NewPath("external-dns-owner-id")); len(errs) > 0 {
+			glog.Fatalf("Invalid value for external-dns-owner-id: %v", errs.ToAggregate())
+		}
+	}
+
+	if *externalDNSMinTTL < 0 || *externalDNSMaxTTL < 0 || (*externalDNSMaxTTL != 0 && *externalDNSMinTTL > *externalDNSMaxTTL) {
+		glog.Fatalf("Invalid values for external-dns-min-ttl %v and external-dns-max-ttl %v: must be positive, and the minimum must not exceed the maximum", *externalDNSMinTTL, *externalDNSMaxTTL)
+	}
+
+	if errs := extdns_validation.ValidateTTL(extdns_v1.TTL(*externalDNSDefaultTTL), field.NewPath("external-dns-default-ttl"), externalDNSValidationOptions()...); len(errs) > 0 {
+		glog.Fatalf("Invalid value for external-dns-default-ttl: %v", errs.ToAggregate())
+	}
+
 	if *ingressLink != "" && *externalService != "" {
 		glog.Fatal("ingresslink and external-service cannot both be set")
 	}
@@ -394,6 +484,21 @@ func validationChecks() {
 		glog.Fatalf("Invalid value for service-insight-listen-port: %v", metricsPortValidationError)
 	}
 
+	policyWebhookPortValidationError := validatePort(*policyWebhookListenPort)
+	if policyWebhookPortValidationError != nil {
+		glog.Fatalf("Invalid value for policy-webhook-listen-port: %v", policyWebhookPortValidationError)
+	}
+
+	policyDryRunPortValidationError := validatePort(*policyDryRunListenPort)
+	if policyDryRunPortValidationError != nil {
+		glog.Fatalf("Invalid value for policy-dry-run-listen-port: %v", policyDryRunPortValidationError)
+	}
+
+	oidcSessionAdminPortValidationError := validatePort(*oidcSessionAdminListenPort)
+	if oidcSessionAdminPortValidationError != nil {
+		glog.Fatalf("Invalid value for oidc-session-admin-listen-port: %v", oidcSessionAdminPortValidationError)
+	}
+
 	var err error
 	allowedCIDRs, err = parseNginxStatusAllowCIDRs(*nginxStatusAllowCIDRs)
 	if err != nil {
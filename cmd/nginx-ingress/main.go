@@ -23,7 +23,10 @@ import (
 	"github.com/nginxinc/kubernetes-ingress/internal/metrics"
 	"github.com/nginxinc/kubernetes-ingress/internal/metrics/collectors"
 	"github.com/nginxinc/kubernetes-ingress/internal/nginx"
+	"github.com/nginxinc/kubernetes-ingress/internal/webhook"
 	cr_validation "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/validation"
+	extdns_v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/externaldns/v1"
+	extdns_validation "github.com/nginxinc/kubernetes-ingress/pkg/apis/externaldns/validation"
 	k8s_nginx "github.com/nginxinc/kubernetes-ingress/pkg/client/clientset/versioned"
 	conf_scheme "github.com/nginxinc/kubernetes-ingress/pkg/client/clientset/versioned/scheme"
 	"github.com/nginxinc/nginx-plus-go-client/client"
@@ -112,6 +115,8 @@ func main() {
 
 	processGlobalConfiguration()
 
+	processDefaultOIDCPolicy()
+
 	cfgParams := configs.NewDefaultConfigParams(*nginxPlus)
 	cfgParams = processConfigMaps(kubeClient, cfgParams, nginxManager, templateExecutor)
 
@@ -135,6 +140,7 @@ func main() {
 		MainAppProtectV5EnforcerAddr:   *appProtectEnforcerAddress,
 		EnableLatencyMetrics:           *enableLatencyMetrics,
 		EnableOIDC:                     *enableOIDC,
+		OIDCFIPSMode:                   *oidcFIPSMode,
 		SSLRejectHandshake:             sslRejectHandshake,
 		EnableCertManager:              *enableCertManager,
 		DynamicSSLReload:               *enableDynamicSSLReload,
@@ -181,12 +187,17 @@ func main() {
 		cr_validation.IsDosEnabled(*appProtectDos),
 		cr_validation.IsCertManagerEnabled(*enableCertManager),
 		cr_validation.IsExternalDNSEnabled(*enableExternalDNS),
+		cr_validation.ExternalDNSOptions(externalDNSValidationOptions()...),
 	)
 
 	if *enableServiceInsight {
 		createHealthProbeEndpoint(kubeClient, plusClient, cnf)
 	}
 
+	if *enablePolicyWebhook {
+		createPolicyWebhook(kubeClient)
+	}
+
 	lbcInput := k8s.NewLoadBalancerControllerInput{
 		KubeClient:                   kubeClient,
 		ConfClient:                   confClient,
@@ -213,6 +224,11 @@ func main() {
 		GlobalConfiguration:          *globalConfiguration,
 		AreCustomResourcesEnabled:    *enableCustomResources,
 		EnableOIDC:                   *enableOIDC,
+		OIDCFIPSMode:                 *oidcFIPSMode,
+		DefaultOIDCPolicy:            *defaultOIDCPolicy,
+		PolicyDryRunListenPort:       policyDryRunPort(),
+		OIDCSessionAdminListenPort:   oidcSessionAdminPort(),
+		OIDCProviderReadiness:        *readyStatusOIDCProviders,
 		MetricsCollector:             controllerCollector,
 		GlobalConfigurationValidator: globalConfigurationValidator,
 		TransportServerValidator:     transportServerValidator,
@@ -226,6 +242,8 @@ func main() {
 		SnippetsEnabled:              *enableSnippets,
 		CertManagerEnabled:           *enableCertManager,
 		ExternalDNSEnabled:           *enableExternalDNS,
+		ExternalDNSOwnerID:           *externalDNSOwnerID,
+		ExternalDNSValidationOptions: externalDNSValidationOptions(),
 		IsIPV6Disabled:               *disableIPV6,
 		WatchNamespaceLabel:          *watchNamespaceLabel,
 		EnableTelemetryReporting:     *enableTelemetryReporting,
@@ -652,7 +670,7 @@ func handleTermination(lbc *k8s.LoadBalancerController, nginxManager nginx.Manag
 
 func ready(lbc *k8s.LoadBalancerController) http.HandlerFunc {
 	return func(w http.ResponseWriter, _ *http.Request) {
-		if !lbc.IsNginxReady() {
+		if !lbc.IsNginxReady() || !lbc.AreOIDCProvidersReady() {
 			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
 			return
 		}
@@ -781,6 +799,32 @@ func createHealthProbeEndpoint(kubeClient *kubernetes.Clientset, plusClient *cli
 	go healthcheck.RunHealthCheck(*serviceInsightListenPort, plusClient, cnf, serviceInsightSecret)
 }
 
+func createPolicyWebhook(kubeClient *kubernetes.Clientset) {
+	secret, err := getAndValidateSecret(kubeClient, *policyWebhookTLSSecretName)
+	if err != nil {
+		glog.Fatalf("Error trying to get the policy webhook TLS secret %v: %v", *policyWebhookTLSSecretName, err)
+	}
+	getSecret := func(ctx context.Context, namespace, name string) (*api_v1.Secret, error) {
+		return kubeClient.CoreV1().Secrets(namespace).Get(ctx, name, meta_v1.GetOptions{})
+	}
+	validator := webhook.NewPolicyValidator(getSecret, *nginxPlus, *enableOIDC, *oidcFIPSMode, *appProtect)
+	var dnsEndpointValidator *webhook.DNSEndpointValidator
+	var dnsEndpointDefaulter *webhook.DNSEndpointDefaulter
+	if *enableExternalDNS {
+		dnsEndpointValidator = webhook.NewDNSEndpointValidator(externalDNSValidationOptions()...)
+		dnsEndpointDefaulter = webhook.NewDNSEndpointDefaulter(extdns_v1.TTL(*externalDNSDefaultTTL))
+	}
+	go webhook.RunPolicyWebhook(*policyWebhookListenPort, validator, dnsEndpointValidator, dnsEndpointDefaulter, secret)
+}
+
+// externalDNSValidationOptions returns the options of the validation of the DNS records for the DNS provider.
+func externalDNSValidationOptions() []extdns_validation.Option {
+	return []extdns_validation.Option{
+		extdns_validation.Provider(*externalDNSProvider),
+		extdns_validation.TTLBounds(extdns_v1.TTL(*externalDNSMinTTL), extdns_v1.TTL(*externalDNSMaxTTL)),
+	}
+}
+
 func processGlobalConfiguration() {
 	if *globalConfiguration != "" {
 		_, _, err := k8s.ParseNamespaceName(*globalConfiguration)
@@ -794,6 +838,35 @@ func processGlobalConfiguration() {
 	}
 }
 
+// policyDryRunPort returns the port of the policy dry-run endpoint, or 0 if the endpoint is disabled.
+func policyDryRunPort() int {
+	if !*enablePolicyDryRun {
+		return 0
+	}
+	return *policyDryRunListenPort
+}
+
+// oidcSessionAdminPort returns the port of the OIDC session administration endpoint, or 0 if the endpoint is disabled.
+func oidcSessionAdminPort() int {
+	if !*enableOIDCSessionAdmin {
+		return 0
+	}
+	return *oidcSessionAdminListenPort
+}
+
+func processDefaultOIDCPolicy() {
+	if *defaultOIDCPolicy != "" {
+		_, _, err := k8s.ParseNamespaceName(*defaultOIDCPolicy)
+		if err != nil {
+			glog.Fatalf("Error parsing the default-oidc-policy argument: %v", err)
+		}
+
+		if !*enableOIDC {
+			glog.Fatal("default-oidc-policy flag requires -enable-oidc")
+		}
+	}
+}
+
 func processConfigMaps(kubeClient *kubernetes.Clientset, cfgParams *configs.ConfigParams, nginxManager nginx.Manager, templateExecutor *version1.TemplateExecutor) *configs.ConfigParams {
 	if *nginxConfigMaps != "" {
 		ns, name, err := k8s.ParseNamespaceName(*nginxConfigMaps)
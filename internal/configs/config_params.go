@@ -36,6 +36,9 @@ type ConfigParams struct {
 	MainOpenTracingLoadModule              bool
 	MainOpenTracingTracer                  string
 	MainOpenTracingTracerConfig            string
+	MainOIDCAuditLog                       string
+	MainOIDCWebhookURL                     string
+	MainOIDCWebhookLoginFailures           int
 	MainServerNamesHashBucketSize          string
 	MainServerNamesHashMaxSize             string
 	MainStreamLogFormat                    []string
@@ -102,6 +105,7 @@ type ConfigParams struct {
 	IngressTemplate       *string
 	VirtualServerTemplate *string
 	MainTemplate          *string
+	OIDCTemplate          *string
 
 	JWTKey      string
 	JWTLoginURL string
@@ -151,6 +155,7 @@ type StaticConfigParams struct {
 	InternalRouteServerName        string
 	EnableLatencyMetrics           bool
 	EnableOIDC                     bool
+	OIDCFIPSMode                   bool
 	SSLRejectHandshake             bool
 	EnableCertManager              bool
 	DynamicSSLReload               bool
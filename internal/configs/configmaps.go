@@ -1,12 +1,17 @@
 package configs
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/golang/glog"
 	v1 "k8s.io/api/core/v1"
 
 	"github.com/nginxinc/kubernetes-ingress/internal/configs/version1"
+	"github.com/nginxinc/kubernetes-ingress/internal/oidc/audit"
+	"github.com/nginxinc/kubernetes-ingress/internal/oidc/session"
+	"github.com/nginxinc/kubernetes-ingress/internal/oidc/webhook"
 )
 
 // ParseConfigMap parses ConfigMap into ConfigParams.
@@ -345,6 +350,14 @@ func ParseConfigMap(cfgm *v1.ConfigMap, nginxPlus bool, hasAppProtect bool, hasA
 		cfgParams.VirtualServerTemplate = &virtualServerTemplate
 	}
 
+	if oidcTemplate, exists := cfgm.Data["oidc-template"]; exists {
+		if nginxPlus {
+			cfgParams.OIDCTemplate = &oidcTemplate
+		} else {
+			glog.Warning("ConfigMap key 'oidc-template' requires NGINX Plus")
+		}
+	}
+
 	if mainStreamSnippets, exists := GetMapKeyAsStringSlice(cfgm.Data, "stream-snippets", cfgm, "\n"); exists {
 		cfgParams.MainStreamSnippets = mainStreamSnippets
 	}
@@ -437,6 +450,40 @@ func ParseConfigMap(cfgm *v1.ConfigMap, nginxPlus bool, hasAppProtect bool, hasA
 		}
 	}
 
+	if oidcAuditLog, exists := cfgm.Data["oidc-audit-log"]; exists {
+		if nginxPlus {
+			if err := audit.ValidateDestination(oidcAuditLog); err != nil {
+				glog.Errorf("Configmap %s/%s: Invalid value for the oidc-audit-log key: %v", cfgm.GetNamespace(), cfgm.GetName(), err)
+			} else {
+				cfgParams.MainOIDCAuditLog = oidcAuditLog
+			}
+		} else {
+			glog.Warning("ConfigMap key 'oidc-audit-log' requires NGINX Plus")
+		}
+	}
+
+	if oidcWebhookURL, exists := cfgm.Data["oidc-webhook-url"]; exists {
+		if nginxPlus {
+			if err := webhook.ValidateURL(oidcWebhookURL); err != nil {
+				glog.Errorf("Configmap %s/%s: Invalid value for the oidc-webhook-url key: %v", cfgm.GetNamespace(), cfgm.GetName(), err)
+			} else {
+				cfgParams.MainOIDCWebhookURL = oidcWebhookURL
+			}
+		} else {
+			glog.Warning("ConfigMap key 'oidc-webhook-url' requires NGINX Plus")
+		}
+	}
+
+	if loginFailures, exists, err := GetMapKeyAsInt(cfgm.Data, "oidc-webhook-login-failures", cfgm); exists {
+		if err != nil {
+			glog.Error(err)
+		} else if loginFailures < 1 {
+			glog.Errorf("Configmap %s/%s: Invalid value for the oidc-webhook-login-failures key: must be greater than 0, got %d", cfgm.GetNamespace(), cfgm.GetName(), loginFailures)
+		} else {
+			cfgParams.MainOIDCWebhookLoginFailures = loginFailures
+		}
+	}
+
 	if hasAppProtect {
 		if appProtectFailureModeAction, exists := cfgm.Data["app-protect-failure-mode-action"]; exists {
 			if appProtectFailureModeAction == "pass" || appProtectFailureModeAction == "drop" {
@@ -581,9 +628,41 @@ func GenerateNginxMainConfig(staticCfgParams *StaticConfigParams, config *Config
 		InternalRouteServerName:            staticCfgParams.InternalRouteServerName,
 		LatencyMetrics:                     staticCfgParams.EnableLatencyMetrics,
 		OIDC:                               staticCfgParams.EnableOIDC,
+		OIDCFIPSMode:                       staticCfgParams.OIDCFIPSMode,
+		OIDCAuditLog:                       config.MainOIDCAuditLog,
+		OIDCWebhook:                        config.MainOIDCWebhookURL != "",
+		OIDCKeyvalZones:                    generateOIDCKeyvalZones(staticCfgParams.EnableOIDC),
 		DynamicSSLReloadEnabled:            staticCfgParams.DynamicSSLReload,
 		StaticSSLPath:                      staticCfgParams.StaticSSLPath,
 		NginxVersion:                       staticCfgParams.NginxVersion,
 	}
 	return nginxCfg
 }
+
+// generateOIDCKeyvalZones returns the keyval zones of the OIDC policies, which NGINX shares between the servers
+// of the policies and the Ingress Controller reads, or none when OIDC is disabled.
+func generateOIDCKeyvalZones(enableOIDC bool) []version1.OIDCKeyvalZone {
+	if !enableOIDC {
+		return nil
+	}
+	zones := make([]version1.OIDCKeyvalZone, 0, len(session.ZoneConfigs))
+	for _, zone := range session.ZoneConfigs {
+		zones = append(zones, version1.OIDCKeyvalZone{
+			Name:    zone.Name,
+			Size:    zone.Size,
+			Timeout: generateNginxTime(zone.Timeout),
+		})
+	}
+	return zones
+}
+
+// generateNginxTime returns a duration in the time format of NGINX, in the largest unit that expresses it, e.g. 8h.
+func generateNginxTime(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	return fmt.Sprintf("%ds", d/time.Second)
+}
@@ -2,8 +2,12 @@ package configs
 
 import (
 	"testing"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
+
+	"github.com/nginxinc/kubernetes-ingress/internal/configs/version1"
+	"github.com/nginxinc/kubernetes-ingress/internal/oidc/session"
 )
 
 func TestParseConfigMapWithAppProtectCompressedRequestsAction(t *testing.T) {
@@ -203,3 +207,142 @@ func TestParseConfigMapWithoutTLSPassthroughProxyProtocol(t *testing.T) {
 		})
 	}
 }
+
+func TestParseConfigMapWithOIDCAuditLog(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		auditLog  string
+		nginxPlus bool
+		want      string
+		msg       string
+	}{
+		{
+			auditLog:  "syslog:server=localhost:514",
+			nginxPlus: true,
+			want:      "syslog:server=localhost:514",
+			msg:       "valid syslog server",
+		},
+		{
+			auditLog:  "/var/log/nginx/oidc-audit.log",
+			nginxPlus: true,
+			want:      "/var/log/nginx/oidc-audit.log",
+			msg:       "valid file",
+		},
+		{
+			auditLog:  "syslog:server=localhost",
+			nginxPlus: true,
+			want:      "",
+			msg:       "invalid syslog server without a port",
+		},
+		{
+			auditLog:  "stderr",
+			nginxPlus: false,
+			want:      "",
+			msg:       "ignored without NGINX Plus",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.msg, func(t *testing.T) {
+			cm := &v1.ConfigMap{
+				Data: map[string]string{
+					"oidc-audit-log": test.auditLog,
+				},
+			}
+			result := ParseConfigMap(cm, test.nginxPlus, false, false, false)
+			if result.MainOIDCAuditLog != test.want {
+				t.Errorf("want %q, got %q", test.want, result.MainOIDCAuditLog)
+			}
+		})
+	}
+}
+
+func TestParseConfigMapWithOIDCWebhook(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		webhookURL        string
+		loginFailures     string
+		nginxPlus         bool
+		wantURL           string
+		wantLoginFailures int
+		msg               string
+	}{
+		{
+			webhookURL:        "https://siem.example.com/hooks/oidc",
+			loginFailures:     "10",
+			nginxPlus:         true,
+			wantURL:           "https://siem.example.com/hooks/oidc",
+			wantLoginFailures: 10,
+			msg:               "valid webhook",
+		},
+		{
+			webhookURL:        "siem.example.com/hooks/oidc",
+			loginFailures:     "0",
+			nginxPlus:         true,
+			wantURL:           "",
+			wantLoginFailures: 0,
+			msg:               "invalid URL and number of failed logins",
+		},
+		{
+			webhookURL:        "https://siem.example.com/hooks/oidc",
+			loginFailures:     "10",
+			nginxPlus:         false,
+			wantURL:           "",
+			wantLoginFailures: 10,
+			msg:               "URL ignored without NGINX Plus",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.msg, func(t *testing.T) {
+			cm := &v1.ConfigMap{
+				Data: map[string]string{
+					"oidc-webhook-url":            test.webhookURL,
+					"oidc-webhook-login-failures": test.loginFailures,
+				},
+			}
+			result := ParseConfigMap(cm, test.nginxPlus, false, false, false)
+			if result.MainOIDCWebhookURL != test.wantURL || result.MainOIDCWebhookLoginFailures != test.wantLoginFailures {
+				t.Errorf("want %q and %d, got %q and %d", test.wantURL, test.wantLoginFailures, result.MainOIDCWebhookURL, result.MainOIDCWebhookLoginFailures)
+			}
+		})
+	}
+}
+
+func TestGenerateNginxMainConfigWithOIDCKeyvalZones(t *testing.T) {
+	t.Parallel()
+	cfg := GenerateNginxMainConfig(&StaticConfigParams{EnableOIDC: true}, NewDefaultConfigParams(true))
+	if len(cfg.OIDCKeyvalZones) != len(session.ZoneConfigs) {
+		t.Fatalf("want %d keyval zones, got %d", len(session.ZoneConfigs), len(cfg.OIDCKeyvalZones))
+	}
+	want := map[string]version1.OIDCKeyvalZone{
+		"oidc_id_tokens":  {Name: "oidc_id_tokens", Size: "1M", Timeout: "1h"},
+		"refresh_tokens":  {Name: "refresh_tokens", Size: "1M", Timeout: "8h"},
+		"oidc_refreshing": {Name: "oidc_refreshing", Size: "128K", Timeout: "30s"},
+	}
+	for _, zone := range cfg.OIDCKeyvalZones {
+		if w, ok := want[zone.Name]; ok && zone != w {
+			t.Errorf("want keyval zone %+v, got %+v", w, zone)
+		}
+	}
+
+	cfg = GenerateNginxMainConfig(&StaticConfigParams{}, NewDefaultConfigParams(true))
+	if len(cfg.OIDCKeyvalZones) != 0 {
+		t.Errorf("want no keyval zones without OIDC, got %+v", cfg.OIDCKeyvalZones)
+	}
+}
+
+func TestGenerateNginxTime(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{d: 8 * time.Hour, want: "8h"},
+		{d: 90 * time.Minute, want: "90m"},
+		{d: 30 * time.Second, want: "30s"},
+	}
+	for _, test := range tests {
+		if got := generateNginxTime(test.d); got != test.want {
+			t.Errorf("generateNginxTime(%v) returned %q, want %q", test.d, got, test.want)
+		}
+	}
+}
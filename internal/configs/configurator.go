@@ -2,10 +2,13 @@ package configs
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/nginxinc/kubernetes-ingress/pkg/apis/dos/v1beta1"
 
@@ -22,6 +25,8 @@ import (
 
 	"github.com/nginxinc/kubernetes-ingress/internal/configs/version1"
 	"github.com/nginxinc/kubernetes-ingress/internal/nginx"
+	"github.com/nginxinc/kubernetes-ingress/internal/oidc/audit"
+	"github.com/nginxinc/kubernetes-ingress/internal/oidc/session"
 	conf_v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -66,6 +71,9 @@ const CACrlKey = "ca.crl"
 // ClientSecretKey is the key of the data field of a Secret where the OIDC client secret must be stored.
 const ClientSecretKey = "client-secret"
 
+// OIDCStateKey is the key of the data field of a Secret where the key that signs the OIDC state can be stored.
+const OIDCStateKey = "state-key"
+
 // SPIFFE filenames and modes
 const (
 	spiffeCertFileName   = "spiffe_cert.pem"
@@ -124,6 +132,7 @@ type Configurator struct {
 	minions                   map[string]map[string]bool
 	mergeableIngresses        map[string]*MergeableIngresses
 	virtualServers            map[string]*VirtualServerEx
+	oidcLocations             map[string]string
 	transportServers          map[string]*TransportServerEx
 	tlsPassthroughPairs       map[string]tlsPassthroughPair
 	isWildcardEnabled         bool
@@ -136,6 +145,7 @@ type Configurator struct {
 	isReloadsEnabled          bool
 	isDynamicSSLReloadEnabled bool
 	ingressControllerReplicas int
+	oidcAuditLog              audit.Logger
 }
 
 // ConfiguratorParams is a collection of parameters used for the
@@ -177,6 +187,7 @@ func NewConfigurator(p ConfiguratorParams) *Configurator {
 		cfgParams:                 p.Config,
 		ingresses:                 make(map[string]*IngressEx),
 		virtualServers:            make(map[string]*VirtualServerEx),
+		oidcLocations:             make(map[string]string),
 		transportServers:          make(map[string]*TransportServerEx),
 		templateExecutor:          p.TemplateExecutor,
 		templateExecutorV2:        p.TemplateExecutorV2,
@@ -615,6 +626,13 @@ func (cnf *Configurator) addOrUpdateVirtualServer(virtualServerEx *VirtualServer
 	if err != nil {
 		return false, warnings, weightUpdates, fmt.Errorf("error generating VirtualServer config: %v: %w", name, err)
 	}
+	if oidc := vsCfg.Server.OIDC; oidc != nil && oidc.LocationsFile != "" {
+		if err := cnf.addOrUpdateOIDCLocations(name, oidc.PathPrefix, oidc.Tracing); err != nil {
+			return false, warnings, weightUpdates, fmt.Errorf("error generating the OIDC locations of VirtualServer config: %v: %w", name, err)
+		}
+	} else {
+		cnf.deleteOIDCLocations(name)
+	}
 	changed := cnf.nginxManager.CreateConfig(name, content)
 
 	cnf.virtualServers[name] = virtualServerEx
@@ -636,6 +654,67 @@ func (cnf *Configurator) addOrUpdateVirtualServer(virtualServerEx *VirtualServer
 	return changed, warnings, weightUpdates, nil
 }
 
+// addOrUpdateOIDCLocations writes the copy of oidc.conf with the OIDC locations under the path prefix and with
+// tracing, which the servers of the OIDC policies with the prefix and tracing include instead of oidc.conf.
+// The copy that the VirtualServer config included before is deleted if no other VirtualServer includes it.
+func (cnf *Configurator) addOrUpdateOIDCLocations(vsName string, pathPrefix string, tracing bool) error {
+	content, err := cnf.nginxManager.ReadOIDCConfig()
+	if err != nil {
+		return err
+	}
+	name := OIDCLocationsConfigName(pathPrefix, tracing)
+	cnf.nginxManager.CreateOIDCConfig(name, generateOIDCLocations(content, pathPrefix, tracing))
+	if previous, exists := cnf.oidcLocations[vsName]; exists && previous != name {
+		cnf.deleteOIDCLocations(vsName)
+	}
+	cnf.oidcLocations[vsName] = name
+	return nil
+}
+
+// deleteOIDCLocations deletes the copy of oidc.conf that the VirtualServer config includes, unless another
+// VirtualServer config includes it too.
+func (cnf *Configurator) deleteOIDCLocations(vsName string) {
+	name, exists := cnf.oidcLocations[vsName]
+	if !exists {
+		return
+	}
+	delete(cnf.oidcLocations, vsName)
+	for _, n := range cnf.oidcLocations {
+		if n == name {
+			return
+		}
+	}
+	cnf.nginxManager.DeleteOIDCConfig(name)
+}
+
+// RenderVirtualServer generates the NGINX configuration of a VirtualServer resource without applying it.
+// The App Protect resources of the VirtualServer are referenced by their file names but not written.
+func (cnf *Configurator) RenderVirtualServer(virtualServerEx *VirtualServerEx) ([]byte, Warnings, error) {
+	apResources := newAppProtectVSResourcesForVS()
+	for apPolKey, apPol := range virtualServerEx.ApPolRefs {
+		apResources.Policies[apPolKey] = appProtectPolicyFileNameFromUnstruct(apPol)
+	}
+	for logConfKey, logConf := range virtualServerEx.LogConfRefs {
+		apResources.LogConfs[logConfKey] = appProtectLogConfFileNameFromUnstruct(logConf)
+	}
+	dosResources := map[string]*appProtectDosResource{}
+	for k, v := range virtualServerEx.DosProtectedEx {
+		dosRes := getAppProtectDosResource(v)
+		if dosRes != nil {
+			dosResources[k] = dosRes
+		}
+	}
+
+	vsc := newVirtualServerConfigurator(cnf.cfgParams, cnf.isPlus, cnf.IsResolverConfigured(), cnf.staticCfgParams, cnf.isWildcardEnabled, nil)
+	vsc.IngressControllerReplicas = cnf.ingressControllerReplicas
+	vsCfg, warnings := vsc.GenerateVirtualServerConfig(virtualServerEx, apResources, dosResources)
+	content, err := cnf.templateExecutorV2.ExecuteVirtualServerTemplate(&vsCfg)
+	if err != nil {
+		return nil, warnings, fmt.Errorf("error generating VirtualServer config: %v: %w", getFileNameForVirtualServer(virtualServerEx.VirtualServer), err)
+	}
+	return content, warnings, nil
+}
+
 // AddOrUpdateVirtualServers adds or updates NGINX configuration for multiple VirtualServer resources.
 func (cnf *Configurator) AddOrUpdateVirtualServers(virtualServerExes []*VirtualServerEx) (Warnings, error) {
 	allWarnings := newWarnings()
@@ -988,6 +1067,7 @@ func (cnf *Configurator) DeleteVirtualServer(key string, skipReload bool) error
 	}
 
 	delete(cnf.virtualServers, name)
+	cnf.deleteOIDCLocations(name)
 	if (cnf.isPlus && cnf.isPrometheusEnabled) || cnf.isLatencyMetricsEnabled {
 		cnf.deleteVirtualServerMetricsLabels(key)
 	}
@@ -1319,6 +1399,13 @@ func (cnf *Configurator) UpdateConfig(cfgParams *ConfigParams, resources Extende
 		}
 	}
 
+	if cfgParams.OIDCTemplate != nil {
+		err := cnf.templateExecutorV2.UpdateOIDCTemplate(cfgParams.OIDCTemplate)
+		if err != nil {
+			return allWarnings, fmt.Errorf("error when parsing the OIDC template: %w", err)
+		}
+	}
+
 	mainCfg := GenerateNginxMainConfig(cnf.staticCfgParams, cfgParams)
 	mainCfgContent, err := cnf.templateExecutor.ExecuteMainConfigTemplate(mainCfg)
 	if err != nil {
@@ -1896,6 +1983,12 @@ func (cnf *Configurator) AddOrUpdateSecret(secret *api_v1.Secret) string {
 	case secrets.SecretTypeAPIKey:
 		// APIKey ClientSecret is not required on the filesystem, it is written directly to the config file.
 		return ""
+	case secrets.SecretTypeOIDCSessionKey:
+		// The OIDC session keys are not required on the filesystem, they are written directly to the config file.
+		return ""
+	case api_v1.SecretTypeBasicAuth:
+		// The credentials of a session store are used by the Ingress Controller, not by NGINX.
+		return ""
 	default:
 		return cnf.addOrUpdateTLSSecret(secret)
 	}
@@ -1916,6 +2009,124 @@ func (cnf *Configurator) UpsertSplitClientsKeyVal(zoneName, key, value string) {
 	cnf.nginxManager.UpsertSplitClientsKeyVal(zoneName, key, value)
 }
 
+// DeleteOIDCSessions deletes the sessions of the OIDC policy with the key from the keyval zones,
+// so that they can't be used after the policy was removed. The sessions of other policies with the same
+// client ID are kept.
+func (cnf *Configurator) DeleteOIDCSessions(policy string) {
+	if !cnf.isPlus {
+		return
+	}
+	deleted, err := cnf.oidcKeyValStore(policy).DeleteAll(context.Background())
+	if err != nil {
+		glog.Warningf("Failed to delete the OIDC sessions of policy %v: %v", policy, err)
+		return
+	}
+	if deleted > 0 {
+		glog.Infof("Deleted %v OIDC sessions of policy %v", deleted, policy)
+	}
+}
+
+// ListOIDCSessions returns the sessions of the OIDC policy with the key in the keyval zones.
+func (cnf *Configurator) ListOIDCSessions(policy string) ([]session.Info, error) {
+	if !cnf.isPlus {
+		return nil, nil
+	}
+	return cnf.oidcKeyValStore(policy).List(context.Background())
+}
+
+// RevokeOIDCSession deletes the session with the ID of the OIDC policy with the key from the keyval zones, marks
+// it as logged out and returns it. Returns session.ErrNotFound if the session doesn't exist or belongs to another policy.
+func (cnf *Configurator) RevokeOIDCSession(policy string, id string) (session.Session, error) {
+	if !cnf.isPlus {
+		return session.Session{}, session.ErrNotFound
+	}
+	return cnf.oidcKeyValStore(policy).Revoke(context.Background(), id)
+}
+
+// AuditOIDCEvent writes the event to the OIDC audit log of the ConfigMap, if it has one.
+func (cnf *Configurator) AuditOIDCEvent(e audit.Event) {
+	if cnf.cfgParams.MainOIDCAuditLog == "" {
+		return
+	}
+	if err := cnf.oidcAuditLog.Log(cnf.cfgParams.MainOIDCAuditLog, e); err != nil {
+		glog.Warningf("Failed to write the OIDC %v event to the audit log: %v", e.Event, err)
+	}
+}
+
+// OIDCWebhook returns the URL of the webhook of the OIDC anomalies and the number of failed logins from one client IP
+// to notify, from the ConfigMap. The URL is empty if the ConfigMap has no webhook.
+func (cnf *Configurator) OIDCWebhook() (string, int) {
+	return cnf.cfgParams.MainOIDCWebhookURL, cnf.cfgParams.MainOIDCWebhookLoginFailures
+}
+
+// SweepOIDCSessions deletes the entries of the OIDC keyval zones that NGINX can't use anymore, like the tokens
+// of expired and logged out sessions, and returns the number of deleted entries of every zone.
+func (cnf *Configurator) SweepOIDCSessions() (map[string]int, error) {
+	if !cnf.isPlus {
+		return nil, nil
+	}
+	return session.Sweep(cnf.nginxManager, time.Now())
+}
+
+// CountOIDCSessions counts the entries of the OIDC keyval zones, and the sessions and the clients with failed logins
+// of the OIDC policies, given by the client ID of every policy, and returns the memory utilization of the zones.
+func (cnf *Configurator) CountOIDCSessions(clientIDs map[string]string) (session.Usage, map[string]float64, error) {
+	if !cnf.isPlus {
+		return session.Usage{}, nil, nil
+	}
+	policies := make(map[string]func(idToken string) bool)
+	for key, clientID := range clientIDs {
+		policies[key] = func(idToken string) bool {
+			return idTokenHasAudience(idToken, clientID)
+		}
+	}
+	usage, err := session.Count(cnf.nginxManager, policies)
+	if err != nil {
+		return session.Usage{}, nil, err
+	}
+	usage.LoginLockouts, err = session.CountLoginLockouts(cnf.nginxManager, clientIDs, time.Now())
+	if err != nil {
+		return session.Usage{}, nil, err
+	}
+	utilization, err := cnf.nginxManager.GetZoneMemoryUtilization(session.Zones)
+	return usage, utilization, err
+}
+
+// oidcKeyValStore returns the store of the sessions of the OIDC policy with the key in the keyval zones.
+func (cnf *Configurator) oidcKeyValStore(policy string) *session.KeyValStore {
+	return session.NewKeyValStore(cnf.nginxManager, policy)
+}
+
+// idTokenHasAudience checks if the audience of the ID token includes the client ID.
+// The signature of the token is not verified, NGINX already did that when it stored the token.
+func idTokenHasAudience(idToken string, clientID string) bool {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return false
+	}
+	var claims struct {
+		Aud interface{} `json:"aud"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return false
+	}
+	switch aud := claims.Aud.(type) {
+	case string:
+		return aud == clientID
+	case []interface{}:
+		for _, a := range aud {
+			if a == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // GetIngressControllerReplicas returns the number of ingresscontroller-replicas (previously stored via SetIngressControllerReplicas)
 func (cnf *Configurator) GetIngressControllerReplicas() int {
 	return cnf.ingressControllerReplicas
@@ -1,8 +1,10 @@
 package configs
 
 import (
+	"encoding/base64"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -745,6 +747,37 @@ func TestUpdateIngressMetricsLabels(t *testing.T) {
 	}
 }
 
+func TestRenderVirtualServer(t *testing.T) {
+	t.Parallel()
+	cnf := createTestConfigurator(t)
+
+	vsEx := &VirtualServerEx{
+		VirtualServer: &conf_v1.VirtualServer{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      "test-vs",
+				Namespace: "default",
+			},
+			Spec: conf_v1.VirtualServerSpec{
+				Host: "example.com",
+			},
+		},
+	}
+
+	content, warnings, err := cnf.RenderVirtualServer(vsEx)
+	if err != nil {
+		t.Fatalf("RenderVirtualServer() returned unexpected error: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("RenderVirtualServer() returned unexpected warnings: %v", warnings)
+	}
+	if !strings.Contains(string(content), "server_name example.com;") {
+		t.Errorf("RenderVirtualServer() returned config without the server_name of the host:\n%s", content)
+	}
+	if _, exists := cnf.virtualServers["vs_default_test-vs"]; exists {
+		t.Error("RenderVirtualServer() applied the VirtualServer")
+	}
+}
+
 func TestUpdateVirtualServerMetricsLabels(t *testing.T) {
 	t.Parallel()
 	cnf := createTestConfigurator(t)
@@ -1603,6 +1636,52 @@ func TestGetVitualServerCountsNotExistingVS(t *testing.T) {
 	}
 }
 
+func TestIDTokenHasAudience(t *testing.T) {
+	t.Parallel()
+
+	token := func(payload string) string {
+		return "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".c2lnbmF0dXJl"
+	}
+	tests := []struct {
+		idToken  string
+		expected bool
+		msg      string
+	}{
+		{
+			idToken:  token(`{"sub":"user","aud":"nginx-plus"}`),
+			expected: true,
+			msg:      "audience string",
+		},
+		{
+			idToken:  token(`{"sub":"user","aud":["other","nginx-plus"]}`),
+			expected: true,
+			msg:      "audience array",
+		},
+		{
+			idToken:  token(`{"sub":"user","aud":"other"}`),
+			expected: false,
+			msg:      "other audience",
+		},
+		{
+			idToken:  token(`{"sub":"user"}`),
+			expected: false,
+			msg:      "no audience",
+		},
+		{
+			idToken:  "not-a-jwt",
+			expected: false,
+			msg:      "invalid token",
+		},
+	}
+
+	for _, test := range tests {
+		result := idTokenHasAudience(test.idToken, "nginx-plus")
+		if result != test.expected {
+			t.Errorf("idTokenHasAudience() returned %v but expected %v for the case of %s", result, test.expected, test.msg)
+		}
+	}
+}
+
 var (
 	invalidVirtualServerEx = &VirtualServerEx{
 		VirtualServer: &conf_v1.VirtualServer{},
@@ -1651,3 +1730,34 @@ var (
 		},
 	}
 )
+
+func TestOIDCLocationsAreTrackedPerVirtualServer(t *testing.T) {
+	t.Parallel()
+	cnf := createTestConfigurator(t)
+
+	for _, vsName := range []string{"vs_default_cafe", "vs_default_tea"} {
+		if err := cnf.addOrUpdateOIDCLocations(vsName, "/oidc", false); err != nil {
+			t.Fatalf("addOrUpdateOIDCLocations() returned unexpected error %v", err)
+		}
+	}
+	if err := cnf.addOrUpdateOIDCLocations("vs_default_cafe", "/auth", false); err != nil {
+		t.Fatalf("addOrUpdateOIDCLocations() returned unexpected error %v", err)
+	}
+	cnf.deleteOIDCLocations("vs_default_coffee")
+
+	expected := map[string]string{
+		"vs_default_cafe": OIDCLocationsConfigName("/auth", false),
+		"vs_default_tea":  OIDCLocationsConfigName("/oidc", false),
+	}
+	if !cmp.Equal(expected, cnf.oidcLocations) {
+		t.Errorf("addOrUpdateOIDCLocations() tracked unexpected copies of oidc.conf: %v", cmp.Diff(expected, cnf.oidcLocations))
+	}
+
+	cnf.deleteOIDCLocations("vs_default_tea")
+	expected = map[string]string{
+		"vs_default_cafe": OIDCLocationsConfigName("/auth", false),
+	}
+	if !cmp.Equal(expected, cnf.oidcLocations) {
+		t.Errorf("deleteOIDCLocations() tracked unexpected copies of oidc.conf: %v", cmp.Diff(expected, cnf.oidcLocations))
+	}
+}
@@ -0,0 +1,40 @@
+// Reads the sub and iss claims of the JWT or of the ID token of the OIDC session of a request for the
+// $identity_subject and $identity_issuer variables.
+// The claims are read when the variables are used, so that they are also available before the request is
+// authenticated, e.g. in the key of a rate limit, which NGINX applies before the auth policies.
+
+function token(r) {
+    switch (r.variables.identity_auth_method) {
+    case "oidc":
+        return r.variables.session_jwt;
+    case "jwt":
+        // The token variable of the JWT policy, by default the Authorization header
+        return (r.variables.identity_jwt_token || "").replace(/^Bearer\s+/i, "");
+    }
+    return "";
+}
+
+function payload(r) {
+    var jwt = token(r);
+    if (!jwt || jwt == "-") {
+        return null;
+    }
+    try {
+        var claims = JSON.parse(Buffer.from(jwt.split(".")[1], "base64url").toString());
+        return typeof claims == "object" && claims !== null ? claims : null;
+    } catch (e) {
+        return null;
+    }
+}
+
+function subject(r) {
+    var claims = payload(r);
+    return claims && claims.sub ? String(claims.sub) : "";
+}
+
+function issuer(r) {
+    var claims = payload(r);
+    return claims && claims.iss ? String(claims.iss) : "";
+}
+
+export default {subject, issuer};
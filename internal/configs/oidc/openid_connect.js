@@ -3,9 +3,17 @@
  *
  * Copyright (C) 2020 Nginx, Inc.
  */
-var newSession = false; // Used by oidcAuth() and validateIdToken()
+var tokenRenewLeeway = 30; // Seconds before expiry a cached client credentials or exchanged token is renewed
+var stateLifetime = 600;   // Seconds the IdP has to redirect the client back with the state of a login
+var sessionCookieChunk = 3800; // Characters of an encrypted session per cookie, below the 4096 bytes browsers accept
+var sessionCookieChunks = 4;   // Cookies an encrypted session can span
 
-export default {auth, codeExchange, validateIdToken, logout};
+export default {auth, startLogin, sessionInfo, userinfo, renew, streamFilter, codeExchange, validateIdToken, validateJarm, logout, loggedOut, retryUnauthorized, sessionActive, claimsAllowed, refreshAhead, stepUpSatisfied, idpAvailable, idpBound, refreshSessionAhead, revokeSessions, deviceAuthorize, deviceToken, clientCredentials, exchangeToken, dpopProof, oauth2SessionJwks};
+
+// Returns the path of an OIDC location under the pathPrefix of the policy, e.g. /oidc/_token.
+function oidcPath(r, path) {
+    return r.variables.oidc_path_prefix + path;
+}
 
 function retryOriginalRequest(r) {
     delete r.headersOut["WWW-Authenticate"]; // Remove evidence of original failed auth_jwt
@@ -15,7 +23,7 @@ function retryOriginalRequest(r) {
 // If the ID token has not been synced yet, poll the variable every 100ms until
 // get a value or after a timeout.
 function waitForSessionSync(r, timeLeft) {
-    if (r.variables.session_jwt) {
+    if (r.variables.session_jwt && r.variables.oidc_session_policy) {
         retryOriginalRequest(r);
     } else if (timeLeft > 0) {
         setTimeout(waitForSessionSync, 100, r, timeLeft - 100);
@@ -24,289 +32,2733 @@ function waitForSessionSync(r, timeLeft) {
     }
 }
 
-function auth(r, afterSyncCheck) {
-    // If a cookie was sent but the ID token is not in the key-value database, wait for the token to be in sync.
-    if (r.variables.cookie_auth_token && !r.variables.session_jwt && !afterSyncCheck && r.variables.zone_sync_leeway > 0) {
-        waitForSessionSync(r, r.variables.zone_sync_leeway);
-        return;
-    }
-
-    if (!r.variables.refresh_token || r.variables.refresh_token == "-") {
-        newSession = true;
-
-        // Check we have all necessary configuration variables (referenced only by njs)
-        var oidcConfigurables = ["authz_endpoint", "scopes", "hmac_key", "cookie_flags"];
-        var missingConfig = [];
-        for (var i in oidcConfigurables) {
-            if (!r.variables["oidc_" + oidcConfigurables[i]] || r.variables["oidc_" + oidcConfigurables[i]] == "") {
-                missingConfig.push(oidcConfigurables[i]);
+// Loads the session of the cookie from the session store of the policy, or from the encrypted session cookie,
+// into the key-value database and retries the original request. Continues the login when there is no session,
+// or calls onMissing.
+function loadSession(r, onMissing) {
+    onMissing = onMissing || function() { auth(r, true, true); };
+    if (r.variables.oidc_session_cookie_keys) {
+        readSessionCookie(r)
+        .then(function(cookie) {
+            if (!cookie || !cookie.session.id_token) {
+                onMissing();
+                return;
             }
-        }
-        if (missingConfig.length) {
-            r.error("OIDC missing configuration variables: $oidc_" + missingConfig.join(" $oidc_"));
-            r.return(500, r.variables.internal_error_message);
-            return;
-        }
-        // Redirect the client to the IdP login page with the cookies we need for state
-        r.return(302, r.variables.oidc_authz_endpoint + getAuthZArgs(r));
+            restoreSession(r, cookie.session);
+            logDebug(r, "OIDC session " + r.variables.oidc_session_id + " loaded from the session cookie");
+            if (!cookie.rotated) {
+                retryOriginalRequest(r);
+                return;
+            }
+            // The cookie was encrypted with the previous key, encrypt it again with the current key
+            saveSession(r, r.variables.oidc_session_id, cookie.session, cookie.session.dpop_key)
+            .then(function() {
+                retryOriginalRequest(r);
+            });
+        })
+        .catch(function(e) {
+            logWarn(r, "OIDC invalid session cookie for " + r.variables.oidc_session_id + ": " + e);
+            onMissing();
+        });
         return;
     }
 
-    // Pass the refresh token to the /_refresh location so that it can be
-    // proxied to the IdP in exchange for a new id_token
-    r.subrequest("/_refresh", "token=" + r.variables.refresh_token,
+    r.subrequest(oidcPath(r, "/_session_store"), {method: "GET", args: "id=" + r.variables.oidc_session_id},
         function(reply) {
             if (reply.status != 200) {
-                // Refresh request failed, log the reason
-                var error_log = "OIDC refresh failure";
-                if (reply.status == 504) {
-                    error_log += ", timeout waiting for IdP";
-                } else if (reply.status == 400) {
-                    try {
-                        var errorset = JSON.parse(reply.responseText);
-                        error_log += ": " + errorset.error + " " + errorset.error_description;
-                    } catch (e) {
-                        error_log += ": " + reply.responseText;
-                    }
-                } else {
-                    error_log += " "  + reply.status;
+                if (reply.status != 404) {
+                    logWarn(r, "OIDC session store failure " + reply.status + " for " + r.variables.oidc_session_id);
                 }
-                r.error(error_log);
-
-                // Clear the refresh token, try again
-                r.variables.refresh_token = "-";
-                r.return(302, r.variables.request_uri);
+                onMissing();
                 return;
             }
-
-            // Refresh request returned 200, check response
             try {
-                var tokenset = JSON.parse(reply.responseText);
-                if (!tokenset.id_token) {
-                    r.error("OIDC refresh response did not include id_token");
-                    if (tokenset.error) {
-                        r.error("OIDC " + tokenset.error + " " + tokenset.error_description);
-                    }
-                    r.variables.refresh_token = "-";
-                    r.return(302, r.variables.request_uri);
-                    return;
-                }
-
-                // Send the new ID Token to auth_jwt location for validation
-                r.subrequest("/_id_token_validation", "token=" + tokenset.id_token,
-                    function(reply) {
-                        if (reply.status != 204) {
-                            r.variables.refresh_token = "-";
-                            r.return(302, r.variables.request_uri);
-                            return;
-                        }
-
-                        // ID Token is valid, update keyval
-                        r.log("OIDC refresh success, updating id_token for " + r.variables.cookie_auth_token);
-                        r.variables.session_jwt = tokenset.id_token; // Update key-value store
-                        if (tokenset.access_token) {
-                            r.variables.access_token = tokenset.access_token;
-                        } else {
-                            r.variables.access_token = "";
-                        }
-
-                        // Update refresh token (if we got a new one)
-                        if (r.variables.refresh_token != tokenset.refresh_token) {
-                            r.log("OIDC replacing previous refresh token (" + r.variables.refresh_token + ") with new value: " + tokenset.refresh_token);
-                            r.variables.refresh_token = tokenset.refresh_token; // Update key-value store
-                        }
-
-                        retryOriginalRequest(r); // Continue processing original request
-                    }
-                );
+                restoreSession(r, JSON.parse(reply.responseText));
             } catch (e) {
-                r.variables.refresh_token = "-";
-                r.return(302, r.variables.request_uri);
+                logError(r, "OIDC invalid session in the session store for " + r.variables.oidc_session_id);
+                onMissing();
                 return;
             }
+            logDebug(r, "OIDC session " + r.variables.oidc_session_id + " loaded from the session store");
+            retryOriginalRequest(r);
         }
     );
 }
 
-function codeExchange(r) {
-    // First check that we received an authorization code from the IdP
-    if (r.variables.arg_code == undefined || r.variables.arg_code.length == 0) {
-        if (r.variables.arg_error) {
-            r.error("OIDC error receiving authorization code from IdP: " + r.variables.arg_error_description);
-        } else {
-            r.error("OIDC expected authorization code from IdP but received: " + r.uri);
-        }
-        r.return(502);
-        return;
+// Stores a loaded session in the key-value database of this instance.
+function restoreSession(r, session) {
+    addUserSession(r, session.id_token, r.variables.oidc_session_id);
+    r.variables.session_jwt   = session.id_token; // Update key-value store
+    r.variables.access_token  = session.access_token || "";
+    r.variables.refresh_token = session.refresh_token || "-";
+    if (session.dpop_key) {
+        r.variables.oidc_dpop_key = session.dpop_key;
+    }
+    if (session.groups) {
+        r.variables.oidc_session_groups = session.groups;
     }
+    if (session.idp) {
+        r.variables.oidc_session_idp = session.idp;
+    }
+    r.variables.oidc_session_policy = r.variables.oidc_policy; // The session store is of the policy
+}
 
-    // Pass the authorization code to the /_token location so that it can be
-    // proxied to the IdP in exchange for a JWT
-    r.subrequest("/_token",idpClientAuth(r), function(reply) {
-            if (reply.status == 504) {
-                r.error("OIDC timeout connecting to IdP when sending authorization code");
-                r.return(504);
-                return;
-            }
+// Saves the session to the session store of the policy, without waiting for the response, or to the
+// encrypted session cookie. The returned promise is resolved when the session cookie is set.
+function saveSession(r, id, tokenset, dpopKey) {
+    var session = {id_token: tokenset.id_token, access_token: tokenset.access_token, refresh_token: tokenset.refresh_token};
+    if (dpopKey) {
+        session.dpop_key = dpopKey;
+    }
+    if (tokenset.groups) {
+        session.groups = tokenset.groups;
+    }
+    if (r.variables.oidc_idps) {
+        session.idp = selectedIdP(r);
+    }
+    if (r.variables.oidc_session_cookie_keys) {
+        return writeSessionCookie(r, id, session)
+        .catch(function(e) {
+            logError(r, "OIDC failed to encrypt the session cookie of " + id + ": " + e);
+        });
+    }
+    if (r.variables.oidc_session_store) {
+        r.subrequest(oidcPath(r, "/_session_store"), {method: "PUT", args: "id=" + id, body: JSON.stringify(session), detached: true});
+    }
+    return Promise.resolve();
+}
 
-            if (reply.status != 200) {
-                try {
-                    var errorset = JSON.parse(reply.responseText);
-                    if (errorset.error) {
-                        r.error("OIDC error from IdP when sending authorization code: " + errorset.error + ", " + errorset.error_description);
-                    } else {
-                        r.error("OIDC unexpected response from IdP when sending authorization code (HTTP " + reply.status + "). " + reply.responseText);
-                    }
-                } catch (e) {
-                    r.error("OIDC unexpected response from IdP when sending authorization code (HTTP " + reply.status + "). " + reply.responseText);
-                }
-                r.return(502);
-                return;
+// Deletes the session of the cookie from the session store of the policy, or clears the session cookies.
+function deleteSession(r) {
+    if (r.variables.oidc_session_cookie_keys) {
+        var cookies = [];
+        for (var i = 0; i < sessionCookieChunks; i++) {
+            if (r.variables["cookie_" + sessionCookieName(r, i)]) {
+                cookies.push(sessionCookieName(r, i) + "=; Max-Age=0; " + r.variables.oidc_cookie_flags);
             }
+        }
+        addCookies(r, cookies);
+        return;
+    }
+    if (!r.variables.oidc_session_store || !r.variables.oidc_session_id) {
+        return;
+    }
+    r.subrequest(oidcPath(r, "/_session_store"), {method: "DELETE", args: "id=" + r.variables.oidc_session_id, detached: true});
+}
 
-            // Code exchange returned 200, check for errors
-            try {
-                var tokenset = JSON.parse(reply.responseText);
-                if (tokenset.error) {
-                    r.error("OIDC " + tokenset.error + " " + tokenset.error_description);
-                    r.return(500);
-                    return;
-                }
+// The encrypted session is split into cookies named auth_session, auth_session_1, auth_session_2, ... The cookies
+// of the policy of a route have its cookie suffix, e.g. auth_session_1a2b3c4d_1.
+function sessionCookieName(r, i) {
+    var name = "auth_session" + r.variables.oidc_cookie_suffix;
+    return i ? name + "_" + i : name;
+}
 
-                // Send the ID Token to auth_jwt location for validation
-                r.subrequest("/_id_token_validation", "token=" + tokenset.id_token,
-                    function(reply) {
-                        if (reply.status != 204) {
-                            r.return(500); // validateIdToken() will log errors
-                            return;
-                        }
+function addCookies(r, cookies) {
+    r.headersOut["Set-Cookie"] = (r.headersOut["Set-Cookie"] || []).concat(cookies);
+}
 
-                        // If the response includes a refresh token then store it
-                        if (tokenset.refresh_token) {
-                            r.variables.new_refresh = tokenset.refresh_token; // Create key-value store entry
-                            r.log("OIDC refresh token stored");
-                        } else {
-                            r.warn("OIDC no refresh token");
-                        }
+// Imports a key of $oidc_session_cookie_keys, the current key is first and the previous key second. The previous key
+// decrypts the session cookies until $oidc_session_cookie_previous_key_expiry, if set.
+function importSessionCookieKey(hex) {
+    return crypto.subtle.importKey("raw", Buffer.from(hex, "hex"), {name: "AES-GCM"}, false, ["encrypt", "decrypt"]);
+}
 
-                        // Add opaque token to keyval session store
-                        r.log("OIDC success, creating session " + r.variables.request_id);
-                        r.variables.new_session = tokenset.id_token; // Create key-value store entry
-                        if (tokenset.access_token) {
-                            r.variables.new_access_token = tokenset.access_token;
-                        } else {
-                            r.variables.new_access_token = "";
-                        }
-                        r.headersOut["Set-Cookie"] = "auth_token=" + r.variables.request_id + "; " + r.variables.oidc_cookie_flags;
-                        r.return(302, r.variables.redirect_base + r.variables.cookie_auth_redir);
-                   }
-                );
-            } catch (e) {
-                r.error("OIDC authorization code sent but token response is not JSON. " + reply.responseText);
-                r.return(502);
+// Encrypts the session with AES-GCM and the current key, and sets the session cookies. The ID of the session is
+// authenticated with the session, so that the session cookies can't be used with the cookie of another session.
+// The session cookies of a previous session that are not replaced are cleared.
+function writeSessionCookie(r, id, session) {
+    var iv = crypto.getRandomValues(new Uint8Array(12));
+    return importSessionCookieKey(r.variables.oidc_session_cookie_keys.split(" ")[0])
+    .then(function(key) {
+        return crypto.subtle.encrypt({name: "AES-GCM", iv: iv, additionalData: Buffer.from(id)}, key,
+                                     Buffer.from(JSON.stringify(session)));
+    })
+    .then(function(ciphertext) {
+        var value = Buffer.concat([Buffer.from(iv), Buffer.from(ciphertext)]).toString("base64url");
+        if (value.length > sessionCookieChunk * sessionCookieChunks) {
+            throw Error("the encrypted session of " + value.length + " characters does not fit in the session cookies");
+        }
+        var cookies = [];
+        for (var i = 0; i < sessionCookieChunks; i++) {
+            var chunk = value.substring(i * sessionCookieChunk, (i + 1) * sessionCookieChunk);
+            if (chunk) {
+                cookies.push(sessionCookieName(r, i) + "=" + chunk + "; " + sessionCookieFlags(r));
+            } else if (r.variables["cookie_" + sessionCookieName(r, i)]) {
+                cookies.push(sessionCookieName(r, i) + "=; Max-Age=0; " + r.variables.oidc_cookie_flags);
             }
         }
-    );
+        addCookies(r, cookies);
+    });
 }
 
-function validateIdToken(r) {
-    // Check mandatory claims
-    var required_claims = ["iat", "iss", "sub"]; // aud is checked separately
-    var missing_claims = [];
-    for (var i in required_claims) {
-        if (r.variables["jwt_claim_" + required_claims[i]].length == 0 ) {
-            missing_claims.push(required_claims[i]);
+// Returns the flags of the cookies of a new session. Remembered sessions have persistent cookies.
+function sessionCookieFlags(r) {
+    var maxAge = Number(r.variables.oidc_remember_me_max_age);
+    return (maxAge ? "Max-Age=" + maxAge + "; " : "") + r.variables.oidc_cookie_flags;
+}
+
+// Decrypts the session cookies with the current key, or with the previous key after a key rotation.
+// Resolves with the session and whether it was encrypted with the previous key, or with null without a session cookie.
+function readSessionCookie(r) {
+    var value = "";
+    for (var i = 0; i < sessionCookieChunks; i++) {
+        var chunk = r.variables["cookie_" + sessionCookieName(r, i)];
+        if (!chunk) {
+            break;
         }
+        value += chunk;
     }
-    if (r.variables.jwt_audience.length == 0) missing_claims.push("aud");
-    if (missing_claims.length) {
-        r.error("OIDC ID Token validation error: missing claim(s) " + missing_claims.join(" "));
-        r.return(403);
+    if (!value) {
+        return Promise.resolve(null);
+    }
+
+    var data = Buffer.from(value, "base64url");
+    var params = {name: "AES-GCM", iv: data.subarray(0, 12), additionalData: Buffer.from(r.variables.oidc_session_id)};
+    var keys = r.variables.oidc_session_cookie_keys.split(" ");
+    var expiry = Number(r.variables.oidc_session_cookie_previous_key_expiry);
+    if (expiry && Date.now() / 1000 > expiry) {
+        keys = keys.slice(0, 1);
+    }
+    function decrypt(i) {
+        return importSessionCookieKey(keys[i])
+        .then(function(key) {
+            return crypto.subtle.decrypt(params, key, data.subarray(12));
+        })
+        .then(function(plaintext) {
+            return {session: JSON.parse(Buffer.from(plaintext).toString()), rotated: i > 0};
+        }, function(e) {
+            if (i + 1 < keys.length) {
+                return decrypt(i + 1);
+            }
+            throw e;
+        });
+    }
+    return decrypt(0);
+}
+
+function auth(r, afterSyncCheck, afterStoreCheck) {
+    // The upstream rejected a request that passed auth_jwt, hand it over to the retry logic.
+    if (r.variables.oidc_retry_unauthorized == 1 && upstreamStatus(r) == "401") {
+        r.internalRedirect("@oidc_upstream_unauthorized");
         return;
     }
-    var validToken = true;
 
-    // Check iat is a positive integer
-    var iat = Math.floor(Number(r.variables.jwt_claim_iat));
-    if (String(iat) != r.variables.jwt_claim_iat || iat < 1) {
-        r.error("OIDC ID Token validation error: iat claim is not a valid number");
-        validToken = false;
+    // An API client without a session sent an opaque access token, which the Ingress Controller introspects.
+    if (r.variables.oidc_introspection_enable == 1 && !r.variables.oidc_session_id && bearerToken(r)) {
+        introspect(r);
+        return;
     }
 
-    // Audience matching
-    var aud = r.variables.jwt_audience.split(",");
-    if (!aud.includes(r.variables.oidc_client)) {
-        r.error("OIDC ID Token validation error: aud claim (" + r.variables.jwt_audience + ") does not include configured $oidc_client (" + r.variables.oidc_client + ")");
-        validToken = false;
+    // If a cookie was sent but the ID token or its policy is not in the key-value database, wait for the session to
+    // be in sync. The sessions of encrypted session cookies are not synced.
+    if (r.variables.oidc_session_id && (!r.variables.session_jwt || !r.variables.oidc_session_policy) && !afterSyncCheck &&
+        r.variables.zone_sync_leeway > 0 && !r.variables.oidc_session_cookie_keys) {
+        waitForSessionSync(r, r.variables.zone_sync_leeway);
+        return;
     }
 
-    // If we receive a nonce in the ID Token then we will use the auth_nonce cookies
-    // to check that the JWT can be validated as being directly related to the
-    // original request by this client. This mitigates against token replay attacks.
-    if (newSession) {
-        var client_nonce_hash = "";
-        if (r.variables.cookie_auth_nonce) {
-            var c = require('crypto');
-            var h = c.createHmac('sha256', r.variables.oidc_hmac_key).update(r.variables.cookie_auth_nonce);
-            client_nonce_hash = h.digest('base64url');
+    // If the ID token is still not in the key-value database, the session can be in the session store or the session cookie.
+    if (r.variables.oidc_session_id && !r.variables.session_jwt && !afterStoreCheck &&
+        (r.variables.oidc_session_store || r.variables.oidc_session_cookie_keys)) {
+        loadSession(r);
+        return;
+    }
+
+    // The session of the cookie was created by another policy, e.g. the cookie of another VirtualServer with the same
+    // cookieDomain. Its tokens are neither used nor refreshed, the user logs in with the policy.
+    if (foreignSession(r)) {
+        logWarn(r, "OIDC session " + r.variables.oidc_session_id + " was not created by " + r.variables.oidc_policy);
+        loginOrUnauthorized(r, r.variables.oidc_step_up == 1);
+        return;
+    }
+
+    // The route has another OIDC policy than the one whose variables were set in the server context, or the session
+    // of the cookie of the policy was created by another policy. The user logs in with the policy of the route.
+    var routeIdP = r.variables.oidc_route_idp;
+    if (routeIdP && (r.variables.oidc_session_idp || "default") != routeIdP) {
+        // The variables of the IdP are set in the server context, which the internal redirect runs again
+        if (selectedIdP(r) != routeIdP) {
+            retryOriginalRequest(r);
+            return;
         }
-        if (r.variables.jwt_claim_nonce != client_nonce_hash) {
-            r.error("OIDC ID Token validation error: nonce from token (" + r.variables.jwt_claim_nonce + ") does not match client (" + client_nonce_hash + ")");
-            validToken = false;
+        loginOrUnauthorized(r, r.variables.oidc_step_up == 1);
+        return;
+    }
+
+    // Do not refresh a session that was revoked by a logout from all sessions.
+    var claims = sessionClaims(r);
+    if (claims && subjectRevoked(r, claims.iss, claims.sub, claims.iat)) {
+        logInfo(r, "OIDC session " + r.variables.oidc_session_id + " was revoked for " + claims.sub);
+        revokeTokens(r, r.variables.access_token, r.variables.refresh_token);
+        r.variables.session_jwt   = "-";
+        r.variables.access_token  = "-";
+        r.variables.refresh_token = "-";
+        deleteSession(r);
+    }
+
+    // The IdP of a policy with idpOutageBehavior is down: neither a login nor a refresh can succeed until it is back.
+    if (idpOutage(r)) {
+        logWarn(r, "OIDC IdP of " + r.variables.oidc_client + " is unavailable, not sending the client to the IdP");
+        loginError(r, "idp_unreachable", 502);
+        return;
+    }
+
+    // A route that requires step-up authentication was requested with a session that authenticated too long ago.
+    // Refreshing the tokens doesn't authenticate the user again, the user has to log in at the IdP.
+    var stepUp = r.variables.oidc_step_up == 1;
+    if (stepUp && claims && r.variables.session_jwt != "-" && !authenticatedSince(claims, r.variables.oidc_step_up_max_age)) {
+        logInfo(r, "OIDC step-up authentication required for " + claims.sub);
+        loginOrUnauthorized(r, true);
+        return;
+    }
+
+    if (!r.variables.refresh_token || r.variables.refresh_token == "-") {
+        loginOrUnauthorized(r, stepUp);
+        return;
+    }
+
+    refreshSession(r, function() {
+        if (apiRequest(r)) {
+            unauthorized(r, stepUp);
+            return;
+        }
+        r.return(302, r.variables.request_uri);
+    });
+}
+
+// Returns the bearer token of the Authorization header of the request, if it has one.
+function bearerToken(r) {
+    var m = (r.headersIn["Authorization"] || "").match(/^Bearer\s+(\S+)\s*$/i);
+    return m ? m[1] : "";
+}
+
+// Introspects the bearer token of an API client and retries the original request without auth_jwt if the token is
+// active. The Ingress Controller caches the results, so that the IdP isn't requested on every request.
+function introspect(r) {
+    // The upstream rejected a request whose token was active, don't introspect it again
+    if (r.variables.oidc_introspected == 1) {
+        invalidToken(r);
+        return;
+    }
+    r.subrequest(oidcPath(r, "/_introspect"), {method: "GET"}, function(reply) {
+        if (reply.status == 204) {
+            r.variables.oidc_introspected = 1; // Persists across the internal redirect, turns auth_jwt off
+            retryOriginalRequest(r);
+            return;
+        }
+        if (reply.status == 401) {
+            logInfo(r, "OIDC inactive bearer token of an API client of " + r.variables.oidc_policy);
+            audit(r, "introspection", "failure", "", "inactive_token");
+            invalidToken(r);
+            return;
         }
+        logError(r, "OIDC token introspection failure " + reply.status + " for " + r.variables.oidc_policy);
+        r.return(502);
+    });
+}
+
+// Responds with 401 and the error of RFC 6750 to a request with an inactive bearer token:
+//  https://www.rfc-editor.org/rfc/rfc6750#section-3.1
+function invalidToken(r) {
+    if (grpcRequest(r)) {
+        r.internalRedirect("@grpc_unauthenticated");
+        return;
     }
+    r.headersOut["WWW-Authenticate"] = 'Bearer realm="' + r.variables.host + '", error="invalid_token"';
+    r.headersOut["Content-Type"] = "application/json";
+    r.return(401, JSON.stringify({error: "invalid_token"}) + "\n");
+}
 
-    if (validToken) {
-        r.return(204);
-    } else {
-        r.return(403);
+// Redirects the client to the IdP login page, or responds with 401 to the requests of API clients, which can't
+// follow a redirect to the IdP.
+function loginOrUnauthorized(r, stepUp) {
+    if (apiRequest(r)) {
+        unauthorized(r, stepUp);
+        return;
     }
+    login(r, stepUp);
 }
 
-function logout(r) {
-    r.log("OIDC logout for " + r.variables.cookie_auth_token);
-    r.variables.session_jwt   = "-";
-    r.variables.access_token  = "-";
-    r.variables.refresh_token = "-";
-    r.return(302, r.variables.oidc_logout_redirect);
+// Returns whether the request is from an API client as per the unauthorizedBehavior of the policy: the request
+// accepts JSON, or its URI starts with one of the paths of $oidc_unauthorized_paths. WebSocket, Server-Sent
+// Events and gRPC requests are always from API clients.
+function apiRequest(r) {
+    var accept = r.headersIn["Accept"] || "";
+    // WebSocket, Server-Sent Events and gRPC clients can't follow a redirect either
+    if (r.headersIn["Upgrade"] || accept.indexOf("text/event-stream") != -1 || grpcRequest(r)) {
+        return true;
+    }
+    if (r.variables.oidc_unauthorized_accept_json == 1 && accept.indexOf("application/json") != -1) {
+        return true;
+    }
+    return r.variables.oidc_unauthorized_paths.split(" ").some(function(prefix) {
+        return prefix && r.uri.startsWith(prefix);
+    });
 }
 
-function getAuthZArgs(r) {
-    // Choose a nonce for this flow for the client, and hash it for the IdP
-    var noncePlain = r.variables.request_id;
-    var c = require('crypto');
-    var h = c.createHmac('sha256', r.variables.oidc_hmac_key).update(noncePlain);
-    var nonceHash = h.digest('base64url');
-    var authZArgs = "?response_type=code&scope=" + r.variables.oidc_scopes + "&client_id=" + r.variables.oidc_client + "&redirect_uri="+ r.variables.redirect_base + r.variables.redir_location + "&nonce=" + nonceHash;
+// Responds with 401 and a JSON body that tells the client to start a login with the /login location. A request
+// that requires step-up authentication gets the error of RFC 9470:
+//  https://www.rfc-editor.org/rfc/rfc9470
+function unauthorized(r, stepUp) {
+    if (grpcRequest(r)) {
+        r.internalRedirect("@grpc_unauthenticated");
+        return;
+    }
+    var error = stepUp ? "insufficient_user_authentication" : "login_required";
+    var challenge = 'Bearer realm="' + r.variables.host + '", error="' + error + '"';
+    if (stepUp) {
+        challenge += ", max_age=" + r.variables.oidc_step_up_max_age;
+    }
+    r.headersOut["WWW-Authenticate"] = challenge;
+    r.headersOut["Content-Type"] = "application/json";
+    r.return(401, JSON.stringify({error: error, login_uri: oidcPath(r, "/login")}) + "\n");
+}
 
-    if (r.variables.oidc_authz_extra_args) {
-        authZArgs += "&" + r.variables.oidc_authz_extra_args;
+// Returns whether the request is to a gRPC location, where errors are responded with the grpc-status header
+// by the @grpc_ locations of the server.
+function grpcRequest(r) {
+    return r.variables.oidc_grpc == 1;
+}
+
+// Starts a login requested by the application, e.g. from the login button of a single-page application, instead
+// of a request without a session. The client is sent back to the rd parameter after the login, or to / without it.
+function startLogin(r) {
+    addSecurityHeaders(r);
+    var returnTo = r.args.rd || "/";
+    if (!loginRedirectAllowed(r, returnTo)) {
+        logWarn(r, "OIDC login redirect to " + returnTo + " is not allowed");
+        r.return(400, "Invalid rd parameter\n");
+        return;
+    }
+    var extraArgs = idpLoginArgs(r);
+    if (extraArgs == null) {
+        r.return(400, "Invalid login parameter\n");
+        return;
     }
 
-    r.headersOut['Set-Cookie'] = [
-        "auth_redir=" + r.variables.request_uri + "; " + r.variables.oidc_cookie_flags,
-        "auth_nonce=" + noncePlain + "; " + r.variables.oidc_cookie_flags
-    ];
+    // A client with an active session doesn't need to log in again, unless it starts a Keycloak action.
+    if (r.variables.session_jwt && r.variables.session_jwt != "-" && !r.args.kc_action) {
+        r.return(302, redirectTarget(r, returnTo));
+        return;
+    }
 
-    if ( r.variables.oidc_pkce_enable == 1 ) {
-        var pkce_code_verifier = c.createHmac('sha256', r.variables.oidc_hmac_key).update(String(Math.random())).digest('hex');
-        r.variables.pkce_id = c.createHash('sha256').update(String(Math.random())).digest('base64url');
-        var pkce_code_challenge = c.createHash('sha256').update(pkce_code_verifier).digest('base64url');
-        r.variables.pkce_code_verifier = pkce_code_verifier;
+    // The IdP chosen in the IdP picker is selected by the auth_idp cookie, in the login started by the redirect.
+    if (r.args.idp !== undefined && r.variables.oidc_idps) {
+        if (r.variables.oidc_idps.split(" ").indexOf(r.args.idp) == -1) {
+            logWarn(r, "OIDC invalid idp parameter " + r.args.idp);
+            r.return(400, "Invalid idp parameter\n");
+            return;
+        }
+        chooseIdP(r, r.args.idp, returnTo);
+        return;
+    }
 
-        authZArgs += "&code_challenge_method=S256&code_challenge=" + pkce_code_challenge + "&state=" + r.variables.pkce_id;
-    } else {
-        authZArgs += "&state=0";
+    // Home-realm discovery: the IdP of the login is the IdP of the domain of the email address of the user.
+    if (r.args.email !== undefined && r.variables.oidc_idp_selection == "email") {
+        var idp = emailIdP(r, r.args.email);
+        if (idp == null) {
+            logWarn(r, "OIDC invalid email parameter");
+            r.return(400, "Invalid email parameter\n");
+            return;
+        }
+        chooseIdP(r, idp, returnTo);
+        return;
     }
-    return authZArgs;
+
+    if (idpOutage(r)) {
+        logWarn(r, "OIDC IdP of " + r.variables.oidc_client + " is unavailable, not sending the client to the IdP");
+        loginError(r, "idp_unreachable", 502);
+        return;
+    }
+    login(r, false, returnTo, false, extraArgs);
 }
 
-function idpClientAuth(r) {
-    // If PKCE is enabled we have to use the code_verifier
-    if ( r.variables.oidc_pkce_enable == 1 ) {
-        r.variables.pkce_id = r.variables.arg_state;
-        return "code=" + r.variables.arg_code + "&code_verifier=" + r.variables.pkce_code_verifier;
-    } else {
-        return "code=" + r.variables.arg_code + "&client_secret=" + r.variables.oidc_client_secret;
+// The parameters of /login that are passed to the authorization request of the providers of an idpType.
+// Keycloak: kc_idp_hint logs the user in with an identity provider brokered by the realm, without the login page
+// of the realm, kc_action starts an application initiated action, e.g. UPDATE_PASSWORD or CONFIGURE_TOTP, which
+// Keycloak runs after authenticating the user again. Okta: sessionToken logs in the user authenticated by the
+// application with the Authentication API of Okta, without the login page of Okta. Auth0: organization logs in the
+// user to an organization of the tenant, by ID or name, connection with a connection of the tenant, without the
+// login page of Auth0. PingFederate: pfidpadapterid authenticates the user with an IdP adapter of the server,
+// acr_values requests an authentication policy. ForgeRock AM: service authenticates the user with an
+// authentication tree or chain of the realm, acr_values requests one by its mapped ACR.
+var idpLoginArgNames = {
+    keycloak: ["kc_idp_hint", "kc_action"],
+    okta: ["sessionToken"],
+    auth0: ["organization", "connection"],
+    pingfederate: ["pfidpadapterid", "acr_values"],
+    forgerock: ["service", "acr_values"]
+};
+
+// The values of the parameters: the alias of an identity provider, the name of an action, a session token, the ID or
+// the name of an organization or a connection, the ID of an adapter, the name of a tree, or an ACR
+var idpLoginArgValues = /^[\w.:-]{1,256}$/;
+
+// Returns the parameters of /login for the authorization request of the policy, or null if they are invalid.
+function idpLoginArgs(r) {
+    var names = idpLoginArgNames[r.variables.oidc_idp_type] || [];
+    var args = "";
+    for (var i = 0; i < names.length; i++) {
+        var value = r.args[names[i]];
+        if (value === undefined) {
+            continue;
+        }
+        if (!idpLoginArgValues.test(value)) {
+            logWarn(r, "OIDC invalid " + names[i] + " parameter " + value);
+            return null;
+        }
+        args += "&" + names[i] + "=" + value;
+    }
+    return args;
+}
+
+// Responds with the claims of the user from the userinfo endpoint of the IdP, called with the access token of the
+// session, so that the application gets the claims that are not in the ID token without handling the tokens.
+function userinfo(r) {
+    r.headersOut["Cache-Control"] = "no-store";
+    if (!r.variables.oidc_userinfo_endpoint) {
+        r.return(404);
+        return;
     }
+    if (!sessionValid(r) || !r.variables.access_token || r.variables.access_token == "-") {
+        r.return(401);
+        return;
+    }
+    r.subrequest(oidcPath(r, "/_userinfo"), "token=" + r.variables.access_token, function(reply) {
+        logTrace(r, "OIDC userinfo response (HTTP " + reply.status + "): " + reply.responseText);
+        if (reply.status != 200) {
+            logWarn(r, "OIDC userinfo failure (HTTP " + reply.status + ") for " + r.variables.oidc_session_id);
+            r.return(reply.status == 401 ? 401 : 502);
+            return;
+        }
+        r.return(200, reply.responseText);
+    });
+}
+
+// Responds with the state of the session of the client, so that the application can show it without reading the
+// tokens: whether the client is authenticated, the claims of $oidc_session_info_claims of the ID token, when the
+// ID token expires and in how many seconds NGINX refreshes the tokens of the session.
+function sessionInfo(r) {
+    r.headersOut["Cache-Control"] = "no-store";
+    if (routePolicyRedirect(r)) {
+        return;
+    }
+    var unauthenticated = function() {
+        r.return(200, JSON.stringify({authenticated: false}) + "\n");
+    };
+    if (r.variables.oidc_session_id && !r.variables.session_jwt &&
+        (r.variables.oidc_session_store || r.variables.oidc_session_cookie_keys)) {
+        loadSession(r, unauthenticated);
+        return;
+    }
+
+    if (!sessionValid(r)) {
+        unauthenticated();
+        return;
+    }
+    var claims = sessionClaims(r);
+    var now = Math.floor(Date.now() / 1000);
+    var refreshable = r.variables.refresh_token && r.variables.refresh_token != "-";
+
+    var info = {authenticated: true, claims: {}, expires_at: claims.exp};
+    if (r.variables.oidc_logout_csrf_enable == 1) {
+        info.logout_token = logoutToken(r.variables.oidc_state_key, r.variables.oidc_session_id);
+    }
+    r.variables.oidc_session_info_claims.split(" ").forEach(function(name) {
+        if (name && claims[name] !== undefined) {
+            info.claims[name] = claims[name];
+        }
+    });
+    if (refreshable) {
+        // The tokens are refreshed when the first of them expires, refreshAheadSeconds earlier with refresh-ahead
+        var refreshAt = claims.exp;
+        var accessTokenExpiresAt = Number(r.variables.access_token_expires_at);
+        if (accessTokenExpiresAt && accessTokenExpiresAt < refreshAt) {
+            refreshAt = accessTokenExpiresAt;
+        }
+        refreshAt -= Number(r.variables.oidc_refresh_ahead_seconds);
+        info.refresh_in = Math.max(refreshAt - now, 0);
+    }
+    r.return(200, JSON.stringify(info) + "\n");
+}
+
+// Returns whether the client has a session that wasn't revoked, and whose ID token didn't expire or can be refreshed.
+function sessionValid(r) {
+    if (foreignSession(r)) {
+        return false;
+    }
+    var claims = sessionClaims(r);
+    if (!claims || subjectRevoked(r, claims.iss, claims.sub, claims.iat)) {
+        return false;
+    }
+    var refreshable = r.variables.refresh_token && r.variables.refresh_token != "-";
+    return refreshable || claims.exp > Math.floor(Date.now() / 1000);
+}
+
+// Body filter of the locations of a policy with terminateOnSessionEnd. Ends a Server-Sent Events stream at the
+// next event after the session of the client ended: the user logged out, the session was revoked or evicted, or
+// its ID token expired without a refresh token. The events after the end are dropped, the other responses are
+// passed on.
+function streamFilter(r, data, flags) {
+    if (r.variables.oidc_stream_ended) {
+        return;
+    }
+    if (String(r.headersOut["Content-Type"]).startsWith("text/event-stream") && !sessionValid(r)) {
+        logInfo(r, "OIDC session " + r.variables.oidc_session_id + " ended, ending the event stream");
+        r.variables.oidc_stream_ended = "1";
+        r.sendBuffer("", {last: true});
+        return;
+    }
+    r.sendBuffer(data, flags);
+}
+
+// Renews the session of the client without user interaction, so that single-page applications can keep their
+// sessions alive: responds with 204 once the tokens are refreshed, or with 401 when the user has to log in.
+// Without a refresh token, the session is renewed with a silent login, which needs a navigation, e.g. in an
+// iframe. The other requests, e.g. of fetch(), can't follow the redirect to the IdP and get 401.
+function renew(r) {
+    if (r.variables.oidc_session_id && !r.variables.session_jwt &&
+        (r.variables.oidc_session_store || r.variables.oidc_session_cookie_keys)) {
+        loadSession(r, function() { silentLogin(r); });
+        return;
+    }
+
+    var foreign = foreignSession(r);
+    var claims = foreign ? null : sessionClaims(r);
+    var revoked = claims && subjectRevoked(r, claims.iss, claims.sub, claims.iat);
+    if (r.args.renewed == 1) {
+        // Back from a silent login
+        r.return(claims && !revoked ? 204 : 401);
+        return;
+    }
+    if (!foreign && !revoked && r.variables.refresh_token && r.variables.refresh_token != "-") {
+        refreshSession(r, function() { silentLogin(r); }, function() { r.return(204); });
+        return;
+    }
+    silentLogin(r);
+}
+
+function silentLogin(r) {
+    if (r.headersIn["Sec-Fetch-Mode"] && r.headersIn["Sec-Fetch-Mode"] != "navigate") {
+        r.return(401);
+        return;
+    }
+    if (idpOutage(r)) {
+        logWarn(r, "OIDC IdP of " + r.variables.oidc_client + " is unavailable, not sending the client to the IdP");
+        r.return(502);
+        return;
+    }
+    login(r, false, oidcPath(r, "/renew?renewed=1"), true);
+}
+
+// Returns whether the client can be sent to target after a login or a logout: target must be a path of the host
+// that starts with one of the paths of $oidc_login_redirect_paths. With the redirectAllowlist of the policy, the path
+// must start with one of the paths of $oidc_redirect_allowlist instead, and target can also be a URL with the origin
+// of one of its URLs and a path that starts with the path of the URL. The target is also the value of a cookie, so
+// it can only have the characters of a cookie value.
+function loginRedirectAllowed(r, target) {
+    if (!/^[\x21\x23-\x2b\x2d-\x3a\x3c-\x5b\x5d-\x7e]+$/.test(target)) {
+        return false;
+    }
+    var allowlist = r.variables.oidc_redirect_allowlist;
+    if (target.charAt(0) == "/") {
+        if (target.startsWith("//")) {
+            return false;
+        }
+        return (allowlist || r.variables.oidc_login_redirect_paths).split(" ").some(function(prefix) {
+            return prefix.charAt(0) == "/" && target.startsWith(prefix);
+        });
+    }
+    // The host can't have user info, the browser would go to the host after the @
+    var url = /^(https?:\/\/[^\/?#@]+)(\/.*)?$/i.exec(target);
+    if (!allowlist || !url) {
+        return false;
+    }
+    var origin = url[1].toLowerCase();
+    var path = url[2] || "/";
+    return allowlist.split(" ").some(function(entry) {
+        var allowed = /^(https?:\/\/[^\/]+)(\/.*)?$/.exec(entry); // Lowercased by the Ingress Controller
+        return allowed && allowed[1] == origin && path.startsWith(allowed[2] || "/");
+    });
+}
+
+// Returns the URL of the redirect to target, a path of the host or a URL of the redirectAllowlist of the policy.
+function redirectTarget(r, target) {
+    return target.charAt(0) == "/" ? r.variables.redirect_base + target : target;
+}
+
+// Returns the URI the client is sent back to after a login started by a request without a session: the URI of
+// the request if it is a GET or HEAD request that $oidc_login_redirect_paths allows, and / otherwise. The requests
+// with other methods can't be repeated by the redirect after the login.
+function deepLink(r) {
+    if ((r.method == "GET" || r.method == "HEAD") && loginRedirectAllowed(r, r.variables.request_uri)) {
+        return r.variables.request_uri;
+    }
+    return "/";
+}
+
+// Redirects the client to the IdP login page. A step-up login makes the IdP authenticate the user again,
+// a silent login only succeeds without the login page. The client is sent back to returnTo after the login,
+// or to the deep link of the request without it.
+function login(r, stepUp, returnTo, silent, extraArgs, afterLockoutCheck) {
+    // A policy with an IdP picker or home-realm discovery lets the user choose the IdP, unless the user chose one or
+    // has a session of one.
+    var selection = r.variables.oidc_idp_selection;
+    if ((selection == "picker" || selection == "email") && !silent && !r.variables.cookie_auth_idp && !r.variables.oidc_session_idp) {
+        idpPicker(r, returnTo || deepLink(r));
+        return;
+    }
+
+    // Check we have all necessary configuration variables (referenced only by njs)
+    var oidcConfigurables = ["authz_endpoint", "scopes", "hmac_key", "cookie_flags"];
+    if (r.variables.oidc_oauth2_user_endpoint) {
+        // Plain OAuth 2.0 providers use their default scope if none is configured
+        oidcConfigurables = oidcConfigurables.filter(function(v) { return v != "scopes"; });
+    }
+    var missingConfig = [];
+    for (var i in oidcConfigurables) {
+        if (!r.variables["oidc_" + oidcConfigurables[i]] || r.variables["oidc_" + oidcConfigurables[i]] == "") {
+            missingConfig.push(oidcConfigurables[i]);
+        }
+    }
+    if (missingConfig.length) {
+        logError(r, "OIDC missing configuration variables: $oidc_" + missingConfig.join(" $oidc_"));
+        r.return(500, r.variables.internal_error_message);
+        return;
+    }
+    if (!afterLockoutCheck && loginLockedOut(r, function() {
+        login(r, stepUp, returnTo, silent, extraArgs, true);
+    })) {
+        return;
+    }
+    // Redirect the client to the IdP login page with the cookies we need for state
+    r.variables.oidc_request_id = ingressRequestId(r);
+    logInfo(r, "OIDC login redirect to the IdP");
+    var authZArgs = getAuthZArgs(r, stepUp, returnTo || deepLink(r), silent, extraArgs);
+    if (r.variables.oidc_jar_key_file) {
+        signAuthZRequest(r, authZArgs)
+        .then(function(request) {
+            r.return(302, r.variables.oidc_authz_endpoint + "?response_type=code&scope=" + r.variables.oidc_scopes + "&client_id=" + r.variables.oidc_client + "&request=" + request);
+        })
+        .catch(function(e) {
+            logError(r, "OIDC failed to sign the authorization request: " + e);
+            r.return(500, r.variables.internal_error_message);
+        });
+        return;
+    }
+    r.return(302, r.variables.oidc_authz_endpoint + authZArgs);
+}
+
+// Responds with the page of the IdP picker, with a link to /login for every IdP of the policy, or with the form of
+// the email address for home-realm discovery. The names of the IdPs are DNS labels, which don't need to be escaped
+// in HTML.
+function idpPicker(r, returnTo) {
+    logInfo(r, "OIDC IdP picker");
+    addSecurityHeaders(r);
+    var body;
+    if (r.variables.oidc_idp_selection == "email") {
+        body = '<h1>Sign in</h1><form method="get" action="' + oidcPath(r, "/login") + '"><input type="hidden" name="rd" value="' + htmlEscape(returnTo) + '">' +
+               '<input type="email" name="email" placeholder="Email address" required autofocus> <button type="submit">Continue</button></form>';
+    } else {
+        body = '<h1>Sign in with</h1><ul>' + r.variables.oidc_idps.split(" ").map(function(idp) {
+            return '<li><a href="' + oidcPath(r, "/login?idp=") + idp + '&amp;rd=' + encodeURIComponent(returnTo) + '">' + idp + '</a></li>';
+        }).join("") + '</ul>';
+    }
+    r.headersOut["Content-Type"] = "text/html; charset=utf-8";
+    r.headersOut["Cache-Control"] = "no-store";
+    r.return(200, '<!DOCTYPE html>\n<html><head><title>Sign in</title></head><body>' + body + '</body></html>\n');
+}
+
+// Selects the IdP of the login with the auth_idp cookie and starts the login with a redirect to /login, whose
+// endpoints are the endpoints of the IdP.
+function chooseIdP(r, idp, returnTo) {
+    logInfo(r, "OIDC login with the IdP " + idp);
+    r.headersOut["Set-Cookie"] = ["auth_idp=" + idp + "; Max-Age=" + stateLifetime + "; " + r.variables.oidc_cookie_flags];
+    r.return(302, r.variables.redirect_base + oidcPath(r, "/login?rd=") + encodeURIComponent(returnTo));
+}
+
+// The email addresses of home-realm discovery, with the domain in the first group.
+var emailAddresses = /^[^@\s]{1,64}@([a-z0-9.-]{1,253})$/;
+
+// Returns the IdP of the domain of the email address as per $oidc_idp_email_domains, the IdP of the policy for the
+// other domains, or null if the email address is invalid.
+function emailIdP(r, email) {
+    var m = emailAddresses.exec(email.toLowerCase());
+    if (!m) {
+        return null;
+    }
+    var domains = r.variables.oidc_idp_email_domains.split(" ");
+    for (var i = 0; i < domains.length; i++) {
+        var mapping = domains[i].split("=");
+        if (mapping[0] == m[1]) {
+            return mapping[1];
+        }
+    }
+    return "default";
+}
+
+// Escapes the characters of a text that HTML interprets in an attribute value.
+function htmlEscape(text) {
+    return text.replace(/&/g, "&amp;").replace(/"/g, "&quot;").replace(/</g, "&lt;").replace(/>/g, "&gt;");
+}
+
+// Adds the securityHeaders of the policy to a page or an error of the login or the logout. The headers of the
+// policy without securityHeaders are empty and not sent. /renew doesn't send them, as it runs in a hidden iframe.
+function addSecurityHeaders(r) {
+    [["Content-Security-Policy", r.variables.oidc_content_security_policy],
+     ["X-Frame-Options", r.variables.oidc_frame_options],
+     ["Referrer-Policy", r.variables.oidc_referrer_policy]].forEach(function(header) {
+        if (header[1]) {
+            r.headersOut[header[0]] = header[1];
+        }
+    });
+}
+
+// Returns the IdP of the request of a policy with several IdPs: the IdP of the login in progress, else the IdP of
+// the session, else the IdP selected as per $oidc_idp_selection. An unknown IdP is the IdP of the policy, whose
+// endpoints are not replaced by the IdPs of the policy.
+function selectedIdP(r) {
+    var idp = r.variables.oidc_idp;
+    return r.variables.oidc_idps.split(" ").indexOf(idp) == -1 ? "default" : idp;
+}
+
+// The OIDC locations use the policy of the last login, the idp parameter selects the policy of a route, e.g.
+// /logout?idp=default/customers. The variables of the policy are set in the server context, which the internal
+// redirect runs again. Returns whether the request was redirected.
+function routePolicyRedirect(r) {
+    var idp = r.args.idp;
+    if (!idp || !r.variables.oidc_idps || r.variables.oidc_route_idp || idp == selectedIdP(r) ||
+        r.variables.oidc_idps.split(" ").indexOf(idp) == -1) {
+        return false;
+    }
+    r.variables.oidc_route_idp = idp;
+    retryOriginalRequest(r);
+    return true;
+}
+
+// Exchanges the refresh token for a new token set and retries the original request, or calls onSuccess.
+// onFailure is called after the refresh token has been cleared. While the IdP of a policy with idpOutageBehavior
+// is down, the refresh isn't attempted and the refresh token is kept for the refresh after the outage.
+function refreshSession(r, onFailure, onSuccess) {
+    if (idpOutage(r)) {
+        onFailure();
+        return;
+    }
+
+    // A DPoP-bound refresh token is only accepted with a proof signed with the key of the session
+    var dpopKey;
+    try {
+        dpopKey = sessionDpopKey(r);
+    } catch (e) {
+        logError(r, "OIDC invalid DPoP key of " + r.variables.oidc_session_id + ": " + e.message);
+    }
+    setTokenRequestProof(r, dpopKey)
+    .then(function() {
+        sendRefreshRequest(r, onFailure, onSuccess || retryOriginalRequest);
+    })
+    .catch(function(e) {
+        logError(r, "OIDC failed to create the DPoP proof of the refresh request: " + e);
+        r.variables.refresh_token = "-";
+        onFailure();
+    });
+}
+
+function sendRefreshRequest(r, onFailure, onSuccess) {
+    // Pass the refresh token to the /_refresh location so that it can be
+    // proxied to the IdP in exchange for a new id_token
+    r.subrequest(oidcPath(r, "/_refresh"), "token=" + r.variables.refresh_token,
+        function(reply) {
+            logTrace(r, "OIDC refresh response from IdP (HTTP " + reply.status + "): " + reply.responseText);
+            if (reply.status != 200) {
+                // Refresh request failed, log the reason
+                var error_log = "OIDC refresh failure";
+                var reason = "idp_error_" + reply.status;
+                if (reply.status == 504) {
+                    error_log += ", timeout waiting for IdP";
+                } else if (reply.status == 400) {
+                    try {
+                        var errorset = JSON.parse(reply.responseText);
+                        error_log += ": " + errorset.error + " " + errorset.error_description;
+                        // The IdPs that rotate the refresh tokens reject a reused refresh token with invalid_grant
+                        if (errorset.error == "invalid_grant") {
+                            reason = "refresh_token_reuse";
+                        }
+                    } catch (e) {
+                        error_log += ": " + reply.responseText;
+                    }
+                } else {
+                    error_log += " "  + reply.status;
+                }
+                logError(r, error_log);
+                audit(r, "refresh", "failure", sessionSubject(r), reason);
+
+                // Keep the refresh token if the IdP is down and the sessions outlive the outage
+                markIdpOutage(r, reply.status);
+                if (idpOutage(r) && r.variables.oidc_idp_outage_behavior == "allowExistingSessions") {
+                    onFailure();
+                    return;
+                }
+
+                // Clear the refresh token, try again
+                r.variables.refresh_token = "-";
+                onFailure();
+                return;
+            }
+
+            // Refresh request returned 200, check response
+            try {
+                var tokenset = JSON.parse(reply.responseText);
+                if (!tokenset.id_token && !r.variables.oidc_oauth2_user_endpoint) {
+                    logError(r, "OIDC refresh response did not include id_token");
+                    if (tokenset.error) {
+                        logError(r, "OIDC " + tokenset.error + " " + tokenset.error_description);
+                    }
+                    audit(r, "refresh", "failure", sessionSubject(r), "missing_id_token");
+                    r.variables.refresh_token = "-";
+                    onFailure();
+                    return;
+                }
+
+                // Send the new ID Token to auth_jwt location for validation
+                validateTokenset(r, tokenset,
+                    function(reply) {
+                        if (reply.status != 204 ||
+                            (r.variables.oidc_strict == 1 && !sameAuthentication(r, sessionClaims(r), idTokenClaims(tokenset.id_token)))) {
+                            audit(r, "token_validation", "failure", sessionSubject(r), "refresh");
+                            r.variables.refresh_token = "-";
+                            onFailure();
+                            return;
+                        }
+
+                        // ID Token is valid, update keyval
+                        logInfo(r, "OIDC refresh success, updating id_token for " + r.variables.oidc_session_id);
+                        audit(r, "refresh", "success", (idTokenClaims(tokenset.id_token) || {}).sub);
+                        r.variables.session_jwt = tokenset.id_token; // Update key-value store
+                        r.variables.oidc_session_policy = r.variables.oidc_policy; // Expires with the session
+                        if (tokenset.access_token) {
+                            r.variables.access_token = tokenset.access_token;
+                        } else {
+                            r.variables.access_token = "";
+                        }
+                        r.variables.access_token_expires_at = accessTokenExpiresAt(tokenset);
+
+                        // Update refresh token (if we got a new one)
+                        if (r.variables.refresh_token != tokenset.refresh_token) {
+                            logDebug(r, "OIDC replacing the refresh token of " + r.variables.oidc_session_id);
+                            r.variables.refresh_token = tokenset.refresh_token; // Update key-value store
+                        }
+                        saveSession(r, r.variables.oidc_session_id,
+                            {id_token: tokenset.id_token, access_token: tokenset.access_token, refresh_token: r.variables.refresh_token,
+                             groups: r.variables.oidc_session_groups},
+                            r.variables.oidc_dpop_key)
+                        .then(function() {
+                            onSuccess(r); // Continue processing original request
+                        });
+                    }
+                );
+            } catch (e) {
+                r.variables.refresh_token = "-";
+                onFailure();
+                return;
+            }
+        }
+    );
+}
+
+function codeExchange(r, afterLockoutCheck) {
+    if (!afterLockoutCheck && loginLockedOut(r, function() { codeExchange(r, true); })) {
+        return;
+    }
+    // The log lines of the login carry the ID of the request that started it, which the client sends back in a cookie
+    var requestId = r.variables.cookie_auth_request_id;
+    r.variables.oidc_request_id = requestIds.test(requestId || "") ? requestId : generateRequestId();
+    logInfo(r, "OIDC authorization response received");
+    var authResponse = getAuthResponse(r);
+    if (r.variables.oidc_jarm_enable != 1) {
+        exchangeCode(r, authResponse);
+        return;
+    }
+
+    // The authorization response is a JWT, validate it before using the parameters it carries
+    if (!authResponse.response) {
+        logError(r, "OIDC expected JWT secured authorization response from IdP but received: " + r.uri);
+        r.return(502);
+        return;
+    }
+    r.subrequest(oidcPath(r, "/_jarm_validation"), "token=" + authResponse.response,
+        function(reply) {
+            if (reply.status != 200) {
+                r.return(502); // validateJarm() will log errors
+                return;
+            }
+            var claims = JSON.parse(reply.responseText);
+            exchangeCode(r, {
+                code: claims.code ? encodeURIComponent(claims.code) : undefined,
+                state: claims.state,
+                error: claims.error,
+                error_description: claims.error_description
+            });
+        }
+    );
+}
+
+function exchangeCode(r, authResponse) {
+    // A silent login fails with login_required, interaction_required or consent_required when the user has to
+    // log in, which isn't an error of NGINX or of the IdP.
+    if (authResponse.error && r.variables.cookie_auth_silent == 1) {
+        logInfo(r, "OIDC silent login failed with " + authResponse.error);
+        addCookies(r, ["auth_silent=; Max-Age=0; " + r.variables.oidc_cookie_flags]);
+        r.return(401);
+        return;
+    }
+
+    // Keycloak reports the result of the action of a login started with kc_action. The user is authenticated
+    // even if the action was cancelled.
+    if (authResponse.kc_action_status) {
+        logInfo(r, "OIDC Keycloak action " + authResponse.kc_action_status);
+    }
+
+    // First check that we received an authorization code from the IdP
+    if (authResponse.code == undefined || authResponse.code.length == 0) {
+        if (authResponse.error) {
+            logError(r, "OIDC error receiving authorization code from IdP: " + authResponse.error_description);
+            audit(r, "login", "failure", undefined, authResponse.error);
+        } else {
+            logError(r, "OIDC expected authorization code from IdP but received: " + r.uri);
+        }
+        r.return(502);
+        return;
+    }
+
+    // Reject forged and stale states, a forged state indicates CSRF
+    if (r.variables.oidc_pkce_enable != 1) {
+        var stateError = verifyState(r, authResponse.state);
+        if (stateError) {
+            logError(r, "OIDC invalid state: " + stateError);
+            recordLoginFailure(r);
+            loginError(r, "invalid_state", 403);
+            return;
+        }
+    }
+
+    newDpopKey(r)
+    .then(function(dpopKey) {
+        return setTokenRequestProof(r, dpopKey).then(function() {
+            sendTokenRequest(r, authResponse, dpopKey);
+        });
+    })
+    .catch(function(e) {
+        logError(r, "OIDC failed to create the DPoP proof of the token request: " + e);
+        r.return(500);
+    });
+}
+
+function sendTokenRequest(r, authResponse, dpopKey) {
+    // Pass the authorization code to the /_token location so that it can be
+    // proxied to the IdP in exchange for a JWT
+    r.subrequest(oidcPath(r, "/_token"),idpClientAuth(r, authResponse), function(reply) {
+            logDebug(r, "OIDC code exchange with IdP completed (HTTP " + reply.status + ")");
+            logTrace(r, "OIDC token response from IdP (HTTP " + reply.status + "): " + reply.responseText);
+            markIdpOutage(r, reply.status);
+            if (reply.status == 504) {
+                logError(r, "OIDC timeout connecting to IdP when sending authorization code");
+                loginError(r, "idp_unreachable", 504);
+                return;
+            }
+
+            if (reply.status != 200) {
+                try {
+                    var errorset = JSON.parse(reply.responseText);
+                    if (errorset.error) {
+                        logError(r, "OIDC error from IdP when sending authorization code: " + errorset.error + ", " + errorset.error_description);
+                    } else {
+                        logError(r, "OIDC unexpected response from IdP when sending authorization code (HTTP " + reply.status + "). " + reply.responseText);
+                    }
+                } catch (e) {
+                    logError(r, "OIDC unexpected response from IdP when sending authorization code (HTTP " + reply.status + "). " + reply.responseText);
+                }
+                if (reply.status >= 400 && reply.status < 500) {
+                    recordLoginFailure(r); // The IdP rejected the authorization code
+                }
+                if (reply.status == 502) {
+                    loginError(r, "idp_unreachable", 502);
+                    return;
+                }
+                r.return(502);
+                return;
+            }
+
+            // Code exchange returned 200, check for errors
+            try {
+                var tokenset = JSON.parse(reply.responseText);
+                if (tokenset.error) {
+                    logError(r, "OIDC " + tokenset.error + " " + tokenset.error_description);
+                    r.return(500);
+                    return;
+                }
+
+                // Send the ID Token to auth_jwt location for validation
+                validateTokenset(r, tokenset,
+                    function(reply) {
+                        if (reply.status != 204) {
+                            loginError(r, "token_validation_failure", 500); // validateIdToken() will log errors
+                            return;
+                        }
+
+                        if (r.variables.cookie_auth_step_up == 1 &&
+                            !authenticatedSince(idTokenClaims(tokenset.id_token), r.variables.oidc_step_up_max_age)) {
+                            logError(r, "OIDC step-up login did not authenticate the user again");
+                            loginError(r, "step_up_failure", 403);
+                            return;
+                        }
+
+                        var organization = r.variables.cookie_auth_organization;
+                        if (organization && !organizationLogin(idTokenClaims(tokenset.id_token), organization)) {
+                            logError(r, "OIDC ID Token validation error: the login is not to the organization " + organization);
+                            loginError(r, "token_validation_failure", 403);
+                            return;
+                        }
+
+                        // The auth_redir cookie is checked again, it could have been set by another site
+                        var returnTo = r.variables.cookie_auth_redir;
+                        if (!loginRedirectAllowed(r, returnTo)) {
+                            logWarn(r, "OIDC login redirect to " + returnTo + " is not allowed, redirecting to /");
+                            returnTo = "/";
+                        }
+                        resolveGroups(r, tokenset, function(resolved) {
+                            if (!resolved) {
+                                loginError(r, "groups_failure", 502); // resolveGroups() will log errors
+                                return;
+                            }
+                            createSession(r, tokenset, dpopKey)
+                            .then(function() {
+                                r.return(302, redirectTarget(r, returnTo));
+                            })
+                            .catch(function() {
+                                loginError(r, "session_limit", 403); // limitUserSessions() will log errors
+                            });
+                        });
+                   }, true, authResponse.code
+                );
+            } catch (e) {
+                logError(r, "OIDC authorization code sent but token response is not JSON. " + reply.responseText);
+                r.return(502);
+            }
+        }
+    );
+}
+
+// Responds to a failed login with the error page of the policy, if the policy has one for the error,
+// and with the status otherwise. The requests to gRPC locations get the gRPC status of the error.
+function loginError(r, error, status) {
+    audit(r, error == "token_validation_failure" ? "token_validation" : "login", "failure", undefined, error);
+    if (grpcRequest(r)) {
+        r.internalRedirect(status == 403 ? "@grpc_permission_denied" : status == 502 ? "@grpc_unavailable" : "@grpc_internal");
+        return;
+    }
+    if (r.variables.oidc_error_pages.split(" ").indexOf(error) != -1) {
+        r.internalRedirect("@oidc_error_" + error);
+        return;
+    }
+    addSecurityHeaders(r);
+    r.return(status);
+}
+
+// Records an authentication event for the audit log of the oidc-audit-log ConfigMap key, which logs
+// $oidc_audit_event as a JSON line after the response. The subject is hashed like by HashSub() of the
+// Ingress Controller, which logs the session revocations of its API with the same fields. A request
+// records a single event, the last one.
+function audit(r, event, result, sub, reason) {
+    r.variables.oidc_audit_event = JSON.stringify({
+        time: new Date().toISOString(),
+        event: event,
+        result: result,
+        policy: r.variables.oidc_policy || undefined,
+        sub_hash: sub ? require('crypto').createHash('sha256').update(sub).digest('hex') : undefined,
+        client_ip: r.variables.remote_addr,
+        request_id: r.variables.oidc_request_id || undefined,
+        reason: reason
+    });
+    // The Ingress Controller detects the anomalies in the failures for the webhook of the ConfigMap
+    if (result == "failure" && r.variables.oidc_webhook_enable == 1) {
+        r.subrequest(oidcPath(r, "/_oidc_event"), {method: "POST", body: r.variables.oidc_audit_event, detached: true});
+    }
+}
+
+function sessionSubject(r) {
+    var claims = sessionClaims(r);
+    return claims ? claims.sub : undefined;
+}
+
+// Validates the ID token of the token set with the /_id_token_validation location and calls back
+// with the reply. The nonce is checked for the ID token of a new login, the hashes of the access token
+// and of the authorization code of the login, if any. An encrypted ID token is replaced with the signed
+// ID token it encloses. Plain OAuth 2.0 providers don't issue ID tokens, the session is created from
+// the user API of the provider instead.
+function validateTokenset(r, tokenset, callback, checkNonce, code) {
+    if (r.variables.oidc_access_token_audience) {
+        var idTokenValidated = callback;
+        callback = function(reply) {
+            idTokenValidated(reply.status == 204 && !validAccessToken(r, tokenset) ? {status: 403} : reply);
+        };
+    }
+    if (r.variables.oidc_oauth2_user_endpoint) {
+        oauth2Session(r, tokenset, callback);
+        return;
+    }
+    var args = "token=" + tokenset.id_token + (checkNonce ? "&nonce_check=1" : "");
+    if (tokenset.access_token) {
+        args += "&access_token=" + encodeURIComponent(tokenset.access_token); // For the at_hash claim
+    }
+    if (code) {
+        args += "&code=" + encodeURIComponent(code); // For the c_hash claim
+    }
+    if (r.variables.oidc_jwe_enable == 1) {
+        // Encrypted ID tokens are decrypted with the key of the policy, the session keeps the signed ID token
+        r.subrequest(oidcPath(r, "/_jwe_id_token_validation"), args, function(reply) {
+            if (reply.status == 204) {
+                tokenset.id_token = r.variables.oidc_signed_id_token;
+            }
+            callback(reply);
+        });
+        return;
+    }
+    r.subrequest(oidcPath(r, "/_id_token_validation"), args, callback);
+}
+
+// The access tokens of an Okta custom authorization server are JWTs for the APIs of the accessTokenAudience of the
+// policy, unlike the ID token, which is issued for the client. The APIs validate the access tokens, NGINX only checks
+// that the authorization server of the policy issued them for the audience. The access token was received from the
+// token endpoint, so its signature is not checked.
+function validAccessToken(r, tokenset) {
+    var claims = idTokenClaims(tokenset.access_token);
+    if (!claims) {
+        logError(r, "OIDC access token validation error: the access token is not a JWT");
+        return false;
+    }
+    if (!validIssuer(r, "access token", claims.iss, claims.tid)) {
+        return false;
+    }
+    var aud = Array.isArray(claims.aud) ? claims.aud : [claims.aud];
+    if (aud.indexOf(r.variables.oidc_access_token_audience) == -1) {
+        logError(r, "OIDC access token validation error: aud claim (" + aud.join(",") + ") does not include the audience " + r.variables.oidc_access_token_audience);
+        return false;
+    }
+    return true;
+}
+
+// Calls the user API of a plain OAuth 2.0 provider, e.g. GitHub or GitLab, with the access token
+// and sets the ID token of the token set to a JWT with the identity of the user. The JWT is signed
+// with the key returned by oauth2SessionJwks() so that the sessions are validated by auth_jwt.
+function oauth2Session(r, tokenset, callback) {
+    if (!tokenset.access_token) {
+        logError(r, "OIDC OAuth 2.0 token response did not include access_token");
+        callback({status: 502});
+        return;
+    }
+    r.subrequest(oidcPath(r, "/_oauth2_user"), "token=" + tokenset.access_token, function(reply) {
+        logTrace(r, "OIDC OAuth 2.0 user response (HTTP " + reply.status + "): " + reply.responseText);
+        var user;
+        try {
+            if (reply.status != 200) {
+                throw new Error("unexpected response from the user API (HTTP " + reply.status + "). " + reply.responseText);
+            }
+            user = JSON.parse(reply.responseText);
+            if (user.sub == undefined && user.id == undefined) {
+                throw new Error("the user API response did not include sub or id");
+            }
+        } catch (e) {
+            logError(r, "OIDC OAuth 2.0 user failure: " + e.message);
+            callback({status: 502});
+            return;
+        }
+
+        var now = Math.floor(Date.now() / 1000);
+        var claims = {
+            iss: r.variables.oidc_oauth2_user_endpoint,
+            aud: r.variables.oidc_client,
+            sub: String(user.sub != undefined ? user.sub : user.id),
+            iat: now,
+            exp: now + (Number(tokenset.expires_in) || 3600)
+        };
+        var username = user.preferred_username || user.login || user.username; // OIDC, GitHub, GitLab
+        if (typeof username == "string") {
+            claims.preferred_username = username;
+        }
+        if (typeof user.name == "string") {
+            claims.name = user.name;
+        }
+        if (typeof user.email == "string") {
+            claims.email = user.email;
+        }
+
+        var signingInput = Buffer.from(JSON.stringify({alg: "HS256", typ: "JWT"})).toString("base64url") + "." +
+                           Buffer.from(JSON.stringify(claims)).toString("base64url");
+        var signature = require('crypto').createHmac('sha256', oauth2SessionKey(r)).update(signingInput).digest('base64url');
+        tokenset.id_token = signingInput + "." + signature;
+        logInfo(r, "OIDC OAuth 2.0 user " + claims.sub + " identified by " + r.variables.oidc_oauth2_user_endpoint);
+        callback({status: 204});
+    });
+}
+
+// Azure AD replaces the groups claim of the ID token with a groups overage claim when the user is a member of
+// too many groups, as per:
+//  https://learn.microsoft.com/en-us/entra/identity-platform/id-token-claims-reference#groups-overage-claim
+// The groups of the user are then read from Microsoft Graph with the access token, and the token set gets them
+// as a JSON array for the session, where claimsAllowed() finds them. Calls back with false on failure.
+function resolveGroups(r, tokenset, callback) {
+    var claims = idTokenClaims(tokenset.id_token);
+    if (r.variables.oidc_idp_type != "azuread" || !claims || !claims._claim_names || !claims._claim_names.groups) {
+        callback(true);
+        return;
+    }
+    if (!tokenset.access_token) {
+        logError(r, "OIDC groups overage of " + claims.sub + " but the token response did not include access_token");
+        callback(false);
+        return;
+    }
+    r.subrequest(oidcPath(r, "/_azuread_groups"), "token=" + tokenset.access_token, function(reply) {
+        logTrace(r, "OIDC Microsoft Graph groups response (HTTP " + reply.status + "): " + reply.responseText);
+        var groups;
+        try {
+            if (reply.status != 200) {
+                throw new Error("unexpected response from Microsoft Graph (HTTP " + reply.status + "). " + reply.responseText);
+            }
+            groups = JSON.parse(reply.responseText).value;
+            if (!Array.isArray(groups)) {
+                throw new Error("the Microsoft Graph response did not include value");
+            }
+        } catch (e) {
+            logError(r, "OIDC groups overage failure: " + e.message);
+            callback(false);
+            return;
+        }
+        tokenset.groups = JSON.stringify(groups);
+        logDebug(r, "OIDC groups overage of " + claims.sub + " resolved to " + groups.length + " groups");
+        callback(true);
+    });
+}
+
+// Returns the groups of the session that were read from Microsoft Graph because of a groups overage claim.
+function sessionGroups(r, claims) {
+    if (!claims._claim_names || !claims._claim_names.groups || !r.variables.oidc_session_groups) {
+        return undefined;
+    }
+    try {
+        return JSON.parse(r.variables.oidc_session_groups);
+    } catch (e) {
+        return undefined;
+    }
+}
+
+// Returns the JWK Set with the key of the sessions of a plain OAuth 2.0 provider to auth_jwt.
+function oauth2SessionJwks(r) {
+    r.headersOut["Content-Type"] = "application/json";
+    r.return(200, JSON.stringify({keys: [{kty: "oct", alg: "HS256", k: oauth2SessionKey(r).toString("base64url")}]}));
+}
+
+// The key is derived from the client secret, so that all Ingress Controller pods validate the sessions.
+function oauth2SessionKey(r) {
+    return require('crypto').createHmac('sha256', r.variables.oidc_client_secret).update("oauth2-session").digest();
+}
+
+// Stores the validated token set in the keyval session store and sets the session cookie.
+// The DPoP key is stored with the session when the IdP has bound the tokens to it.
+// The returned promise is resolved when the session is saved to the session cookies, and rejected when
+// the user has reached the session limit of the policy. The new session replaces the session of the client,
+// like after a step-up login.
+function createSession(r, tokenset, dpopKey) {
+    if (r.variables.oidc_session_id) {
+        evictSession(r, r.variables.oidc_session_id);
+    }
+    if (!limitUserSessions(r, tokenset.id_token, r.variables.request_id)) {
+        return Promise.reject(new Error("session limit"));
+    }
+
+    // If the response includes a refresh token then store it
+    if (tokenset.refresh_token) {
+        r.variables.new_refresh = tokenset.refresh_token; // Create key-value store entry
+        logDebug(r, "OIDC refresh token stored");
+    } else {
+        logWarn(r, "OIDC no refresh token");
+    }
+
+    // Add opaque token to keyval session store
+    logInfo(r, "OIDC success, creating session " + r.variables.request_id);
+    audit(r, "login", "success", (idTokenClaims(tokenset.id_token) || {}).sub);
+    r.variables.new_session = tokenset.id_token; // Create key-value store entry
+    if (tokenset.access_token) {
+        r.variables.new_access_token = tokenset.access_token;
+    } else {
+        r.variables.new_access_token = "";
+    }
+    r.variables.new_access_token_expires_at = accessTokenExpiresAt(tokenset);
+    if (dpopKey && String(tokenset.token_type).toLowerCase() == "dpop") {
+        r.variables.new_dpop_key = JSON.stringify(dpopKey);
+    }
+    if (tokenset.groups) {
+        r.variables.new_oidc_groups = tokenset.groups;
+    }
+    if (r.variables.oidc_idps) {
+        r.variables.new_oidc_idp = selectedIdP(r);
+    }
+    r.variables.new_oidc_policy = r.variables.oidc_policy; // Deletes the session with the policy
+    r.headersOut["Set-Cookie"] = [
+        "auth_token" + r.variables.oidc_cookie_suffix + "=" + r.variables.request_id + "; " + sessionCookieFlags(r),
+        "auth_nonce=; " + r.variables.oidc_cookie_flags, // The nonce of a login is used once
+        "auth_request_id=; Max-Age=0; " + r.variables.oidc_cookie_flags
+    ];
+    if (r.variables.cookie_auth_step_up) {
+        addCookies(r, ["auth_step_up=; Max-Age=0; " + r.variables.oidc_cookie_flags]);
+    }
+    if (r.variables.cookie_auth_silent) {
+        addCookies(r, ["auth_silent=; Max-Age=0; " + r.variables.oidc_cookie_flags]);
+    }
+    if (r.variables.cookie_auth_organization) {
+        addCookies(r, ["auth_organization=; Max-Age=0; " + r.variables.oidc_cookie_flags]);
+    }
+    if (r.variables.cookie_auth_idp) {
+        addCookies(r, ["auth_idp=; Max-Age=0; " + r.variables.oidc_cookie_flags]);
+    }
+    return saveSession(r, r.variables.request_id, tokenset, r.variables.new_dpop_key);
+}
+
+// Starts the device authorization grant for clients without a browser, as per:
+//  https://www.rfc-editor.org/rfc/rfc8628
+// The response of the IdP (device_code, user_code, verification_uri, ...) is passed to the client.
+function deviceAuthorize(r) {
+    if (!r.variables.oidc_device_authz_endpoint) {
+        r.return(404);
+        return;
+    }
+    if (r.method != "POST") {
+        r.return(405);
+        return;
+    }
+    r.headersOut["Content-Type"] = "application/json";
+
+    r.subrequest(oidcPath(r, "/_device_authz"), function(reply) {
+        if (reply.status == 504) {
+            logError(r, "OIDC timeout connecting to IdP when requesting device authorization");
+            r.return(504);
+            return;
+        }
+        if (reply.status != 200 && reply.status != 400) {
+            logError(r, "OIDC unexpected response from IdP when requesting device authorization (HTTP " + reply.status + "). " + reply.responseText);
+            r.return(502);
+            return;
+        }
+        r.return(reply.status, reply.responseText);
+    });
+}
+
+// Polls the token endpoint with the device code sent by the client. The client keeps
+// polling while the IdP responds with authorization_pending or slow_down. When the user
+// has completed the login, a session is created and its cookie is returned to the client.
+function deviceToken(r) {
+    if (!r.variables.oidc_device_authz_endpoint) {
+        r.return(404);
+        return;
+    }
+    if (r.method != "POST") {
+        r.return(405);
+        return;
+    }
+    r.headersOut["Content-Type"] = "application/json";
+    var deviceCode = require('querystring').parse(r.requestText || "").device_code || r.args.device_code;
+    if (!deviceCode) {
+        r.return(400, JSON.stringify({error: "invalid_request", error_description: "missing device_code"}));
+        return;
+    }
+
+    newDpopKey(r)
+    .then(function(dpopKey) {
+        return setTokenRequestProof(r, dpopKey).then(function() {
+            pollDeviceToken(r, deviceCode, dpopKey);
+        });
+    })
+    .catch(function(e) {
+        logError(r, "OIDC failed to create the DPoP proof of the device token request: " + e);
+        r.return(500);
+    });
+}
+
+function pollDeviceToken(r, deviceCode, dpopKey) {
+    r.subrequest(oidcPath(r, "/_device_token"), "device_code=" + encodeURIComponent(deviceCode), function(reply) {
+        if (reply.status == 504) {
+            logError(r, "OIDC timeout connecting to IdP when polling the device code");
+            r.return(504);
+            return;
+        }
+        if (reply.status == 400 || reply.status == 401) {
+            // authorization_pending, slow_down, access_denied, expired_token, ... are passed to the client
+            r.return(reply.status, reply.responseText);
+            return;
+        }
+        if (reply.status != 200) {
+            logError(r, "OIDC unexpected response from IdP when polling the device code (HTTP " + reply.status + "). " + reply.responseText);
+            r.return(502);
+            return;
+        }
+
+        var tokenset;
+        try {
+            tokenset = JSON.parse(reply.responseText);
+        } catch (e) {
+            logError(r, "OIDC device code sent but token response is not JSON. " + reply.responseText);
+            r.return(502);
+            return;
+        }
+        if (!tokenset.id_token) {
+            logError(r, "OIDC device token response did not include id_token");
+            r.return(502);
+            return;
+        }
+
+        // Send the ID Token to auth_jwt location for validation. There is no nonce in the device flow.
+        validateTokenset(r, tokenset,
+            function(reply) {
+                if (reply.status != 204) {
+                    r.return(500); // validateIdToken() will log errors
+                    return;
+                }
+                createSession(r, tokenset, dpopKey)
+                .then(function() {
+                    r.return(200, JSON.stringify({auth_token: r.variables.request_id}));
+                })
+                .catch(function() {
+                    r.return(403, JSON.stringify({error: "session_limit"}));
+                });
+            }
+        );
+    });
+}
+
+// Called by auth_request in the locations with a Client Credentials policy. The access token is
+// cached in the key-value store and renewed shortly before it expires, as per:
+//  https://www.rfc-editor.org/rfc/rfc6749#section-4.4
+function clientCredentials(r) {
+    var now = Math.floor(Date.now() / 1000);
+    var expiresAt = Number(r.variables.client_credentials_expires_at);
+    var cached = r.variables.client_credentials_access_token && expiresAt > now;
+    if (cached && expiresAt - tokenRenewLeeway > now) {
+        r.return(204);
+        return;
+    }
+
+    var args = r.variables.client_credentials_scope ? "scope=" + r.variables.client_credentials_scope : "";
+    r.subrequest("/_client_credentials_token", args, function(reply) {
+        var tokenset;
+        try {
+            if (reply.status != 200) {
+                throw new Error("unexpected response from IdP (HTTP " + reply.status + "). " + reply.responseText);
+            }
+            tokenset = JSON.parse(reply.responseText);
+            if (!tokenset.access_token) {
+                throw new Error("token response did not include access_token");
+            }
+        } catch (e) {
+            logError(r, "OIDC client credentials failure for " + r.variables.client_credentials_key + ": " + e.message);
+            if (cached) {
+                logWarn(r, "OIDC using the cached access token of " + r.variables.client_credentials_key + " until it expires");
+                r.return(204);
+            } else {
+                r.return(500);
+            }
+            return;
+        }
+
+        logDebug(r, "OIDC client credentials token obtained for " + r.variables.client_credentials_key);
+        r.variables.client_credentials_access_token = tokenset.access_token; // Update key-value store
+        r.variables.client_credentials_expires_at = String(now + (Number(tokenset.expires_in) || 300));
+        r.return(204);
+    });
+}
+
+// Called by auth_request in the locations with a token exchange. The access token of the session is
+// exchanged for a token of the audience of the location, as per:
+//  https://www.rfc-editor.org/rfc/rfc8693
+// The exchanged tokens are cached per session and audience and renewed shortly before they expire.
+function exchangeToken(r) {
+    if (!r.variables.access_token || r.variables.access_token == "-") {
+        if (r.variables.session_jwt && r.variables.session_jwt != "-") {
+            logError(r, "OIDC token exchange requires an access token but the IdP did not issue one for " + r.variables.oidc_session_id);
+            r.return(500);
+            return;
+        }
+        r.return(401); // No session, start the OIDC flow
+        return;
+    }
+
+    var audience = r.variables.oidc_token_exchange_audience;
+    r.variables.oidc_token_exchange_key = r.variables.oidc_session_id + ":" + audience;
+    var now = Math.floor(Date.now() / 1000);
+    if (r.variables.oidc_exchanged_token && Number(r.variables.oidc_exchanged_token_expires_at) - tokenRenewLeeway > now) {
+        r.return(204);
+        return;
+    }
+
+    var args = "subject_token=" + encodeURIComponent(r.variables.access_token) + "&audience=" + encodeURIComponent(audience);
+    r.subrequest(oidcPath(r, "/_token_exchange"), args, function(reply) {
+        if (reply.status == 504) {
+            logError(r, "OIDC timeout connecting to IdP when exchanging the access token for " + audience);
+            r.return(500);
+            return;
+        }
+        if (reply.status != 200) {
+            // The IdP refuses to issue a token of the audience to the user, e.g. invalid_target
+            logError(r, "OIDC token exchange for " + audience + " failed (HTTP " + reply.status + "). " + reply.responseText);
+            r.return(reply.status == 400 ? 403 : 500);
+            return;
+        }
+
+        var tokenset;
+        try {
+            tokenset = JSON.parse(reply.responseText);
+        } catch (e) {
+            logError(r, "OIDC token exchange response is not JSON. " + reply.responseText);
+            r.return(500);
+            return;
+        }
+        if (!tokenset.access_token) {
+            logError(r, "OIDC token exchange response did not include access_token");
+            r.return(500);
+            return;
+        }
+
+        logDebug(r, "OIDC access token of " + r.variables.oidc_session_id + " exchanged for " + audience);
+        r.variables.oidc_exchanged_token = tokenset.access_token; // Update key-value store
+        r.variables.oidc_exchanged_token_expires_at = String(now + (Number(tokenset.expires_in) || 300));
+        r.return(204);
+    });
+}
+
+// Called by auth_request in the locations that pass the access token of a DPoP-enabled OIDC policy.
+// The proof of the upstream request is signed with the key of the session, as per:
+//  https://www.rfc-editor.org/rfc/rfc9449#section-7
+// The access token of a session without a key, e.g. created before DPoP was enabled, is a bearer token.
+function dpopProof(r) {
+    var dpopKey;
+    try {
+        dpopKey = sessionDpopKey(r);
+    } catch (e) {
+        logError(r, "OIDC invalid DPoP key of " + r.variables.oidc_session_id + ": " + e.message);
+        r.return(500);
+        return;
+    }
+    if (!dpopKey || !r.variables.access_token) {
+        r.variables.oidc_access_token_type = "Bearer";
+        r.return(204);
+        return;
+    }
+
+    // The htu claim is the URI of the request without the query, as seen by the client
+    var htu = r.variables.redirect_base + r.variables.request_uri.split("?")[0];
+    createDpopProof(dpopKey, r.variables.request_method, htu, r.variables.access_token)
+    .then(function(proof) {
+        r.variables.oidc_access_token_type = "DPoP";
+        r.variables.oidc_dpop_proof = proof;
+        r.return(204);
+    })
+    .catch(function(e) {
+        logError(r, "OIDC failed to create the DPoP proof of the upstream request: " + e);
+        r.return(500);
+    });
+}
+
+function validateIdToken(r) {
+    // Check mandatory claims
+    var required_claims = ["iat", "iss", "sub"]; // aud is checked separately
+    var missing_claims = [];
+    for (var i in required_claims) {
+        if (r.variables["jwt_claim_" + required_claims[i]].length == 0 ) {
+            missing_claims.push(required_claims[i]);
+        }
+    }
+    if (r.variables.jwt_audience.length == 0) missing_claims.push("aud");
+    if (missing_claims.length) {
+        logError(r, "OIDC ID Token validation error: missing claim(s) " + missing_claims.join(" "));
+        r.return(403);
+        return;
+    }
+    var validToken = true;
+
+    // Check iat is a positive integer
+    var iat = Math.floor(Number(r.variables.jwt_claim_iat));
+    if (String(iat) != r.variables.jwt_claim_iat || iat < 1) {
+        logError(r, "OIDC ID Token validation error: iat claim is not a valid number");
+        validToken = false;
+    }
+
+    // Audience matching
+    var aud = r.variables.jwt_audience.split(",");
+    if (!aud.includes(r.variables.oidc_client)) {
+        logError(r, "OIDC ID Token validation error: aud claim (" + r.variables.jwt_audience + ") does not include configured $oidc_client (" + r.variables.oidc_client + ")");
+        validToken = false;
+    }
+
+    if (r.variables.oidc_idp_type == "azuread" && !validAzureAdIssuer(r)) {
+        validToken = false; // validAzureAdIssuer() will log errors
+    }
+
+    // The issuer of the policy, e.g. the Okta authorization server of the policy, as the Okta org authorization
+    // server and the custom authorization servers have different issuers
+    if (r.variables.oidc_issuer && !validIssuer(r, "ID Token", r.variables.jwt_claim_iss, r.variables.jwt_claim_tid)) {
+        validToken = false; // validIssuer() will log errors
+    }
+
+    // Any Google account can log in to a Google client, the hd claim of the users of the Workspace domain of the
+    // policy must be checked as the hd parameter of the authorization request can be removed by the user
+    if (r.variables.oidc_google_hosted_domain && r.variables.jwt_claim_hd != r.variables.oidc_google_hosted_domain) {
+        logError(r, "OIDC ID Token validation error: hd claim (" + r.variables.jwt_claim_hd + ") is not the hosted domain " + r.variables.oidc_google_hosted_domain);
+        validToken = false;
+    }
+
+    // The nonce of the ID Token of a new login must match the hash of the auth_nonce cookie,
+    // to check that the JWT can be validated as being directly related to the original
+    // request by this client. This mitigates against token replay attacks.
+    // The previous $oidc_hmac_key hashed the nonces of the logins started before the key was rotated.
+    if (r.variables.arg_nonce_check == 1 && r.variables.oidc_nonce_enforce == 1) {
+        var client_nonce_hash = "";
+        if (r.variables.cookie_auth_nonce) {
+            var c = require('crypto');
+            var keys = [r.variables.oidc_hmac_key, r.variables.oidc_previous_hmac_key].filter(Boolean);
+            var hashes = keys.map(function(key) {
+                return c.createHmac('sha256', key).update(r.variables.cookie_auth_nonce).digest('base64url');
+            });
+            client_nonce_hash = hashes.find(function(hash) { return hash == r.variables.jwt_claim_nonce; }) || hashes[0];
+        }
+        if (!client_nonce_hash || r.variables.jwt_claim_nonce != client_nonce_hash) {
+            logError(r, "OIDC ID Token validation error: nonce from token (" + r.variables.jwt_claim_nonce + ") does not match client (" + client_nonce_hash + ")");
+            validToken = false;
+        }
+    }
+
+    if (r.variables.oidc_strict == 1 && !strictIdTokenValid(r)) {
+        validToken = false; // strictIdTokenValid() will log errors
+    }
+
+    if (r.variables.oidc_fips_mode == 1 && !fipsTokenAlgs.includes(r.variables.jwt_header_alg)) {
+        logError(r, "OIDC ID Token validation error: the " + r.variables.jwt_header_alg + " algorithm is not FIPS 140-3 approved");
+        validToken = false;
+    }
+
+    if (!validToken) {
+        r.return(403);
+        return;
+    }
+    tokenHashesValid(r)
+    .then(function(valid) {
+        if (!valid) {
+            r.return(403); // tokenHashesValid() will log errors
+            return;
+        }
+        if (r.variables.oidc_jwe_enable == 1) {
+            r.variables.oidc_signed_id_token = r.variables.jwt_payload; // The JWS enclosed in the JWE
+        }
+        r.return(204);
+    });
+}
+
+// Checks the claims of the ID token that OIDC Core requires a client to check, beyond the checks of every
+// policy, for the policies with strictSpecCompliance, as per:
+//  https://openid.net/specs/openid-connect-core-1_0.html#IDTokenValidation
+// The exp claim is required, the ID token of several audiences must be issued to the client by the azp claim,
+// and the ID token of a login with max_age, a step-up login, must have the auth_time claim.
+function strictIdTokenValid(r) {
+    var valid = true;
+    if (r.variables.jwt_claim_exp.length == 0) {
+        logError(r, "OIDC ID Token validation error: missing claim(s) exp");
+        valid = false;
+    }
+    var azp = r.variables.jwt_claim_azp;
+    if (r.variables.jwt_audience.split(",").length > 1 && !azp) {
+        logError(r, "OIDC ID Token validation error: aud claim (" + r.variables.jwt_audience + ") has several audiences without the azp claim");
+        valid = false;
+    }
+    if (azp && azp != r.variables.oidc_client) {
+        logError(r, "OIDC ID Token validation error: azp claim (" + azp + ") is not the configured $oidc_client (" + r.variables.oidc_client + ")");
+        valid = false;
+    }
+    if (r.variables.arg_nonce_check == 1 && r.variables.cookie_auth_step_up == 1 && !/^\d+$/.test(r.variables.jwt_claim_auth_time)) {
+        logError(r, "OIDC ID Token validation error: the login with max_age has no valid auth_time claim");
+        valid = false;
+    }
+    return valid;
+}
+
+// The algorithms of the ID tokens that are FIPS 140-3 approved, in the FIPS mode of the OIDC policies: the RSA and
+// ECDSA signatures, and the RSA-OAEP and ECDH-ES key management of the encrypted ID tokens. The HMAC signatures with
+// the client secret and the RSA1_5 key management are rejected.
+var fipsTokenAlgs = ["RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512",
+                     "RSA-OAEP", "RSA-OAEP-256", "ECDH-ES", "ECDH-ES+A128KW", "ECDH-ES+A192KW", "ECDH-ES+A256KW"];
+
+// The hash functions of the at_hash and c_hash claims, by the hash of the signature algorithm of the ID token.
+// EdDSA hashes with SHA-512.
+var tokenHashAlgs = {"256": "SHA-256", "384": "SHA-384", "512": "SHA-512", "dsa": "SHA-512"};
+
+// Resolves to whether the at_hash and c_hash claims of the ID token, if any, are the hashes of the access token
+// of the token set and of the authorization code of the login, as per:
+//  https://openid.net/specs/openid-connect-core-1_0.html#HybridIDToken
+// An ID token of another login or token response, substituted by an attacker, is rejected. The claims are optional
+// in the authorization code flow, the policies with tokenHashesRequired require both for the logins.
+function tokenHashesValid(r) {
+    var required = r.variables.oidc_token_hashes_required == 1 && !!r.args.code;
+    return Promise.all([
+        tokenHashValid(r, "at_hash", "access token", r.args.access_token, required),
+        tokenHashValid(r, "c_hash", "authorization code", r.args.code, required)
+    ])
+    .then(function(valid) {
+        return valid[0] && valid[1];
+    });
+}
+
+// Resolves to whether the claim of the ID token is the left half of the hash of the value with the hash of
+// the algorithm of the ID token. A missing claim is only valid if it is not required.
+function tokenHashValid(r, claim, name, value, required) {
+    var claimHash = r.variables["jwt_claim_" + claim];
+    if (!claimHash) {
+        if (required) {
+            logError(r, "OIDC ID Token validation error: missing claim(s) " + claim);
+        }
+        return Promise.resolve(!required);
+    }
+    var alg = r.variables.jwt_header_alg || "";
+    var hash = tokenHashAlgs[alg.slice(-3)];
+    if (!hash || !value) {
+        logError(r, "OIDC ID Token validation error: " + claim + " claim can't be checked " +
+                 (value ? "for the " + alg + " algorithm" : "without an " + name));
+        return Promise.resolve(false);
+    }
+    return crypto.subtle.digest(hash, Buffer.from(value))
+    .then(function(digest) {
+        var buf = Buffer.from(digest);
+        if (buf.subarray(0, buf.length / 2).toString("base64url") != claimHash) {
+            logError(r, "OIDC ID Token validation error: " + claim + " claim (" + claimHash + ") does not match the " + name);
+            return false;
+        }
+        return true;
+    });
+}
+
+// Checks that the ID token of a refresh is for the authentication of the ID token of the session, as per:
+//  https://openid.net/specs/openid-connect-core-1_0.html#RefreshTokenResponse
+// The iss, sub and aud claims must be the same, and the auth_time claim, if any.
+function sameAuthentication(r, original, refreshed) {
+    if (!original || !refreshed) {
+        logError(r, "OIDC refresh validation error: the ID tokens can't be compared");
+        return false;
+    }
+    var names = ["iss", "sub", "aud"];
+    if (refreshed.auth_time !== undefined) {
+        names.push("auth_time");
+    }
+    for (var i = 0; i < names.length; i++) {
+        if (JSON.stringify(original[names[i]]) != JSON.stringify(refreshed[names[i]])) {
+            logError(r, "OIDC refresh validation error: " + names[i] + " claim (" + JSON.stringify(refreshed[names[i]]) +
+                     ") is not the one of the session (" + JSON.stringify(original[names[i]]) + ")");
+            return false;
+        }
+    }
+    return true;
+}
+
+// The v2.0 issuers of the Azure AD tenants, in the global and the national clouds
+var azureAdIssuers = /^https:\/\/login\.(microsoftonline\.com|microsoftonline\.us|partner\.microsoftonline\.cn)\/([0-9a-fA-F-]{36})\/v2\.0$/;
+
+// The multi-tenant endpoints of Azure AD (common, organizations) issue the ID tokens of every tenant, so the
+// issuer is checked against the tenant of the token, like the {tenantid} issuer of their discovery document:
+// it must be the v2.0 issuer of the tid claim. The tenant must be one of the allowed tenants of the policy, if any.
+function validAzureAdIssuer(r) {
+    var tid = r.variables.jwt_claim_tid;
+    var m = r.variables.jwt_claim_iss.match(azureAdIssuers);
+    if (!m || m[2] != tid) {
+        logError(r, "OIDC ID Token validation error: iss claim (" + r.variables.jwt_claim_iss + ") is not the Azure AD v2.0 issuer of tenant " + (tid || "(missing tid claim)"));
+        return false;
+    }
+    if (!tenantAllowed(r, tid)) {
+        logError(r, "OIDC ID Token validation error: tenant " + tid + " is not allowed");
+        return false;
+    }
+    return true;
+}
+
+// Returns whether the tenant is one of the allowed tenants of the policy, if any.
+function tenantAllowed(r, tid) {
+    var allowedTenants = r.variables.oidc_allowed_tenants;
+    return !allowedTenants || allowedTenants.toLowerCase().split(" ").indexOf(String(tid).toLowerCase()) != -1;
+}
+
+// Checks the iss claim of a token against $oidc_issuer. The {tenantid} of the issuer of a multi-tenant application
+// is the tenant of the token, its tid claim, which must be one of the allowed tenants of the policy, if any.
+function validIssuer(r, token, iss, tid) {
+    var issuer = r.variables.oidc_issuer;
+    if (issuer.indexOf("{tenantid}") != -1) {
+        if (!tid || !tenantAllowed(r, tid)) {
+            logError(r, "OIDC " + token + " validation error: tenant " + (tid || "(missing tid claim)") + " is not allowed");
+            return false;
+        }
+        issuer = issuer.split("{tenantid}").join(tid);
+    }
+    if (iss != issuer) {
+        logError(r, "OIDC " + token + " validation error: iss claim (" + iss + ") is not the issuer " + issuer);
+        return false;
+    }
+    return true;
+}
+
+function validateJarm(r) {
+    // The signature and exp claim are validated by auth_jwt, check the issuer and audience
+    if (r.variables.jwt_claim_iss.length == 0) {
+        logError(r, "OIDC JARM validation error: missing claim iss");
+        r.return(403);
+        return;
+    }
+    var aud = r.variables.jwt_audience.split(",");
+    if (!aud.includes(r.variables.oidc_client)) {
+        logError(r, "OIDC JARM validation error: aud claim (" + r.variables.jwt_audience + ") does not include configured $oidc_client (" + r.variables.oidc_client + ")");
+        r.return(403);
+        return;
+    }
+
+    // Pass the authorization response parameters carried by the JWT back to oidcCodeExchange()
+    r.headersOut["Content-Type"] = "application/json";
+    r.return(200, JSON.stringify({
+        code: r.variables.jwt_claim_code,
+        state: r.variables.jwt_claim_state,
+        error: r.variables.jwt_claim_error,
+        error_description: r.variables.jwt_claim_error_description
+    }));
+}
+
+// Called when the upstream responds with 401 to a request carrying a valid session,
+// e.g. because of clock skew or a key rotation race at the IdP. The tokens are
+// refreshed once and the request is retried, otherwise the 401 is returned to the client.
+function retryUnauthorized(r) {
+    if (r.variables.oidc_upstream_retried == 1) {
+        logWarn(r, "OIDC upstream responded with 401 after token refresh, giving up");
+        r.return(401);
+        return;
+    }
+    if (["GET", "HEAD", "OPTIONS"].indexOf(r.method) == -1) {
+        logWarn(r, "OIDC upstream responded with 401, not retrying non-idempotent " + r.method + " request");
+        r.return(401);
+        return;
+    }
+    if (!r.variables.refresh_token || r.variables.refresh_token == "-") {
+        logWarn(r, "OIDC upstream responded with 401, no refresh token to retry with");
+        r.return(401);
+        return;
+    }
+
+    r.variables.oidc_upstream_retried = 1; // Persists across the internal redirect
+    logInfo(r, "OIDC upstream responded with 401, refreshing tokens and retrying for " + r.variables.oidc_session_id);
+    refreshSession(r, function() {
+        r.return(401);
+    });
+}
+
+function upstreamStatus(r) {
+    // $upstream_status holds one status per contacted server, the last one is the response.
+    var statuses = (r.variables.upstream_status || "").split(/[,:] /);
+    return statuses[statuses.length - 1];
+}
+
+// Evaluated by auth_jwt_require after the ID token is validated. Sessions of another policy and sessions issued
+// before the subject's sessions were revoked are rejected.
+function sessionActive(r) {
+    if (foreignSession(r)) {
+        return "0";
+    }
+    return subjectRevoked(r, r.variables.jwt_claim_iss, r.variables.jwt_claim_sub, r.variables.jwt_claim_iat) ? "0" : "1";
+}
+
+// Returns whether the session of the cookie was created by another policy than the policy of the request. The
+// sessions of all policies are in the same keyval zones, so the session ID of a policy must not give access to
+// the routes of another policy, whose IdP, client and claim rules can differ.
+function foreignSession(r) {
+    return Boolean(r.variables.session_jwt) && r.variables.session_jwt != "-" &&
+        r.variables.oidc_session_policy != r.variables.oidc_policy;
+}
+
+// Evaluated by auth_jwt_require when the policy has refreshAheadSeconds. When the ID token or the access token
+// of the session expires within refreshAheadSeconds, the tokens are refreshed in a detached subrequest, so that
+// neither this request nor the requests after the expiry wait for the IdP. The request is always allowed.
+function refreshAhead(r) {
+    var expiresAt = Number(r.variables.jwt_claim_exp);
+    var accessTokenExpiresAt = Number(r.variables.access_token_expires_at);
+    if (accessTokenExpiresAt && accessTokenExpiresAt < expiresAt) {
+        expiresAt = accessTokenExpiresAt;
+    }
+    if (expiresAt - Math.floor(Date.now() / 1000) > Number(r.variables.oidc_refresh_ahead_seconds) ||
+        !r.variables.refresh_token || r.variables.refresh_token == "-" || r.variables.oidc_refreshing || idpOutage(r) ||
+        foreignSession(r)) {
+        return "1";
+    }
+    r.variables.oidc_refreshing = "1"; // Synced to all replicas, so that only one request refreshes the tokens
+    r.subrequest(oidcPath(r, "/_refresh_ahead"), {detached: true});
+    return "1";
+}
+
+// Evaluated by auth_jwt_require in the routes of a VirtualServer whose routes are bound to the IdPs of different
+// OIDC policies. A session is only valid in the routes of the policy that created it.
+function idpBound(r) {
+    return (r.variables.oidc_session_idp || "default") == r.variables.oidc_route_idp ? "1" : "0";
+}
+
+// Evaluated by auth_jwt_require when the idpOutageBehavior of the policy is denyAll. While the IdP is down,
+// every request is rejected, and auth() responds with the idp_unreachable error.
+function idpAvailable(r) {
+    return idpOutage(r) ? "0" : "1";
+}
+
+// Returns true while the IdP of a policy with idpOutageBehavior is considered down. The outage ends with the
+// timeout of the oidc_idp_outages zone, and the next login or refresh tries the IdP again.
+function idpOutage(r) {
+    return r.variables.oidc_idp_outage_behavior && r.variables.oidc_idp_outage == 1;
+}
+
+// Records an outage of the IdP when a token request of a policy with idpOutageBehavior failed because the IdP
+// is unreachable, timed out or failed with a server error.
+function markIdpOutage(r, status) {
+    if (r.variables.oidc_idp_outage_behavior && status >= 500) {
+        logWarn(r, "OIDC IdP of " + r.variables.oidc_client + " failed with " + status + ", considering it unavailable");
+        r.variables.oidc_idp_outage = "1";
+    }
+}
+
+// Returns true when the client IP address is locked out of the logins by the loginLockout of the policy, after
+// handling the request: the request is rejected, or with a tarpit delay, next is called after the delay.
+function loginLockedOut(r, next) {
+    if (r.variables.oidc_login_lockout_max_failures == 0) {
+        return false;
+    }
+    if (loginFailures(r).lockedUntil <= Math.floor(Date.now() / 1000)) {
+        return false;
+    }
+    var delay = Number(r.variables.oidc_login_lockout_tarpit_delay);
+    if (delay > 0) {
+        logWarn(r, "OIDC delaying the login of the locked out client " + r.variables.remote_addr + " by " + delay + " seconds");
+        setTimeout(next, delay * 1000);
+        return true;
+    }
+    logWarn(r, "OIDC rejecting the login of the locked out client " + r.variables.remote_addr);
+    audit(r, "login", "failure", undefined, "locked_out");
+    r.internalRedirect("@oidc_login_locked_out");
+    return true;
+}
+
+// Returns the failed logins of the client IP address, which the oidc_login_failures zone keeps as
+// "<failures> <time of the first failure> <locked out until>", the times in seconds.
+function loginFailures(r) {
+    r.variables.oidc_login_lockout_key = r.variables.oidc_client + " " + r.variables.remote_addr;
+    var fields = (r.variables.oidc_login_failures || "").split(" ").map(Number);
+    return {count: fields[0] || 0, since: fields[1] || 0, lockedUntil: fields[2] || 0};
+}
+
+// Records a failed login of the client IP address: an invalid state, or an authorization code that the IdP
+// rejected. The client is locked out after the maxFailures of the loginLockout of the policy within its window.
+function recordLoginFailure(r) {
+    if (r.variables.oidc_login_lockout_max_failures == 0) {
+        return;
+    }
+    var now = Math.floor(Date.now() / 1000);
+    var failures = loginFailures(r);
+    if (failures.since + Number(r.variables.oidc_login_lockout_window) <= now) {
+        failures = {count: 0, since: now, lockedUntil: failures.lockedUntil};
+    }
+    failures.count++;
+    if (failures.count >= Number(r.variables.oidc_login_lockout_max_failures)) {
+        logWarn(r, "OIDC locking out the client " + r.variables.remote_addr + " after " + failures.count + " failed logins");
+        failures = {count: 0, since: now, lockedUntil: now + Number(r.variables.oidc_login_lockout_duration)};
+    }
+    r.variables.oidc_login_failures = [failures.count, failures.since, failures.lockedUntil].join(" ");
+}
+
+// Refreshes the tokens of the session in the subrequest of refreshAhead().
+function refreshSessionAhead(r) {
+    logDebug(r, "OIDC refreshing tokens ahead of expiry for " + r.variables.oidc_session_id);
+    refreshSession(r, function() {
+        r.return(502);
+    }, function() {
+        r.return(204);
+    });
+}
+
+// Returns the time the access token of the token set expires, if the IdP returned its lifetime.
+function accessTokenExpiresAt(tokenset) {
+    if (!tokenset.expires_in) {
+        return "";
+    }
+    return String(Math.floor(Date.now() / 1000) + Number(tokenset.expires_in));
+}
+
+// Evaluated by auth_jwt_require in the locations of the routes with stepUpRequired. The user must have
+// authenticated at the IdP within the stepUpMaxAge of the policy.
+function stepUpSatisfied(r) {
+    return authenticatedSince(sessionClaims(r), r.variables.oidc_step_up_max_age) ? "1" : "0";
+}
+
+// Returns whether the ID token of an Auth0 login is issued for the organization, by ID or name, of the login.
+// The names of the organizations are lowercase in the org_name claim.
+function organizationLogin(claims, organization) {
+    if (!claims) {
+        return false;
+    }
+    return claims.org_id == organization || (!!claims.org_name && claims.org_name == organization.toLowerCase());
+}
+
+// Checks that the ID token claims were issued for an authentication within maxAge seconds. IdPs only
+// have to issue the auth_time claim for logins with max_age, the time of the token is used otherwise.
+function authenticatedSince(claims, maxAge) {
+    if (!claims) {
+        return false;
+    }
+    var authTime = Number(claims.auth_time || claims.iat);
+    return Math.floor(Date.now() / 1000) - authTime <= Number(maxAge);
+}
+
+// Evaluated by auth_jwt_require when the policy has claim rules. Every rule must match:
+// the claim, or one of its elements if it is an array, must equal one of the values.
+function claimsAllowed(r) {
+    var claims = sessionClaims(r);
+    if (!claims) {
+        return "0";
+    }
+    var rules = JSON.parse(Buffer.from(r.variables.oidc_claim_rules, "base64").toString());
+    for (var i = 0; i < rules.length; i++) {
+        var claim = claims[rules[i].claim];
+        if (claim === undefined && rules[i].claim == "groups") {
+            claim = sessionGroups(r, claims);
+        }
+        var values = Array.isArray(claim) ? claim : [claim];
+        var matched = values.some(function(v) {
+            return v !== undefined && v !== null && rules[i].values.indexOf(String(v)) != -1;
+        });
+        if (!matched) {
+            logInfo(r, "OIDC claim " + rules[i].claim + " of " + claims.sub + " does not match the claim rules");
+            return "0";
+        }
+    }
+    return "1";
+}
+
+// Admin endpoint that revokes every session of the subject given in the sub argument, of the IdP given in the
+// iss argument.
+function revokeSessions(r) {
+    if (r.method != "POST") {
+        r.return(405);
+        return;
+    }
+    if (!r.args.iss) {
+        r.return(400, "missing iss argument\n");
+        return;
+    }
+    if (!r.args.sub) {
+        r.return(400, "missing sub argument\n");
+        return;
+    }
+    revokeSubject(r, r.args.iss, r.args.sub);
+    audit(r, "session_revocation", "success", r.args.sub, "all_sessions");
+    r.return(204);
+}
+
+// The sub claim is only unique for an issuer, so the revocations are keyed by both: revoking a user of one IdP
+// doesn't revoke the user with the same sub of another IdP.
+function revokedSubjectKey(iss, sub) {
+    return iss + " " + sub;
+}
+
+function revokeSubject(r, iss, sub) {
+    r.variables.oidc_revoked_subject_key = revokedSubjectKey(iss, sub);
+    r.variables.oidc_sub_revoked_at = String(Date.now()); // Synced to all replicas
+    logInfo(r, "OIDC revoked all sessions for " + sub + " of " + iss);
+}
+
+// Returns whether the subject was revoked after its ID token was issued at iat. The iat claim has a precision of
+// seconds and the revocation of milliseconds, so an ID token issued in the second of the revocation is revoked:
+// it may have been issued before the revocation.
+function subjectRevoked(r, iss, sub, iat) {
+    if (!iss || !sub) {
+        return false;
+    }
+    r.variables.oidc_revoked_subject_key = revokedSubjectKey(iss, sub);
+    var revokedAt = Number(r.variables.oidc_sub_revoked_at);
+    return revokedAt > 0 && Number(iat) * 1000 <= revokedAt;
+}
+
+// Returns the claims of the ID token stored for the session. The token was validated
+// when it was stored, so the signature is not checked again.
+function sessionClaims(r) {
+    return idTokenClaims(r.variables.session_jwt);
+}
+
+function idTokenClaims(jwt) {
+    if (!jwt || jwt == "-") {
+        return null;
+    }
+    try {
+        return JSON.parse(Buffer.from(jwt.split(".")[1], "base64url").toString());
+    } catch (e) {
+        return null;
+    }
+}
+
+// Enforces the maxSessionsPerUser of the policy before the session id of a user is created. The sessions
+// of each user are indexed in the oidc_user_sessions zone, oldest first. When the user has reached the limit,
+// the login is rejected or the oldest sessions are evicted. Returns false when the login is rejected.
+function limitUserSessions(r, idToken, id) {
+    var max = Number(r.variables.oidc_max_sessions_per_user);
+    var claims = idTokenClaims(idToken);
+    if (!max || !claims || !claims.sub) {
+        return true;
+    }
+    r.variables.oidc_user_sessions_key = r.variables.oidc_policy + ":" + claims.sub;
+    var sessions = activeUserSessions(r);
+    if (sessions.length >= max) {
+        if (r.variables.oidc_session_limit_action == "reject") {
+            logWarn(r, "OIDC login rejected, " + claims.sub + " has reached the limit of " + max + " sessions");
+            return false;
+        }
+        sessions.splice(0, sessions.length - max + 1).forEach(function(evicted) {
+            evictSession(r, evicted);
+            logInfo(r, "OIDC session " + evicted + " of " + claims.sub + " evicted by a new login");
+        });
+    }
+    sessions.push(id);
+    r.variables.oidc_user_sessions = sessions.join(" "); // Synced to all replicas
+    return true;
+}
+
+// Adds a session that was loaded from the session store or the session cookie to the sessions of its user,
+// so that it counts towards the limit of the policy.
+function addUserSession(r, idToken, id) {
+    var claims = idTokenClaims(idToken);
+    if (!Number(r.variables.oidc_max_sessions_per_user) || !claims || !claims.sub) {
+        return;
+    }
+    r.variables.oidc_user_sessions_key = r.variables.oidc_policy + ":" + claims.sub;
+    var sessions = activeUserSessions(r);
+    if (sessions.indexOf(id) == -1) {
+        sessions.push(id);
+        r.variables.oidc_user_sessions = sessions.join(" ");
+    }
+}
+
+// Returns the sessions of $oidc_user_sessions_key that didn't log out, expire or get evicted.
+function activeUserSessions(r) {
+    return (r.variables.oidc_user_sessions || "").split(" ").filter(function(id) {
+        if (!id) {
+            return false;
+        }
+        r.variables.oidc_user_session = id;
+        var jwt = r.variables.oidc_user_session_jwt;
+        return jwt && jwt != "-";
+    });
+}
+
+// Logs out another session of the user, like logout() does for the session of the cookie. The session
+// is not loaded again from the session store or an encrypted session cookie.
+function evictSession(r, id) {
+    r.variables.oidc_user_session = id;
+    revokeTokens(r, r.variables.oidc_user_session_access, r.variables.oidc_user_session_refresh);
+    r.variables.oidc_user_session_jwt     = "-";
+    r.variables.oidc_user_session_access  = "-";
+    r.variables.oidc_user_session_refresh = "-";
+    if (r.variables.oidc_session_store) {
+        r.subrequest(oidcPath(r, "/_session_store"), {method: "DELETE", args: "id=" + id, detached: true});
+    }
+}
+
+function logout(r) {
+    addSecurityHeaders(r);
+    if (routePolicyRedirect(r)) {
+        return;
+    }
+    // With logoutCSRFEnable, a logout is a POST with the logout token of the session, so that another site
+    // can't log the user out with a link or an image.
+    if (r.variables.oidc_logout_csrf_enable == 1) {
+        if (r.method != "POST") {
+            r.headersOut["Allow"] = "POST";
+            r.return(405);
+            return;
+        }
+        var token = r.headersIn["X-CSRF-Token"] || require('querystring').parse(r.requestText || "").csrf_token;
+        var keys = [r.variables.oidc_state_key, r.variables.oidc_previous_state_key].filter(Boolean);
+        if (!r.variables.oidc_session_id || !keys.some(function(key) { return logoutToken(key, r.variables.oidc_session_id) == token; })) {
+            logWarn(r, "OIDC logout without a valid logout token for " + r.variables.oidc_session_id);
+            audit(r, "logout", "failure", sessionSubject(r), "invalid_logout_token");
+            r.return(403);
+            return;
+        }
+    }
+
+    // With the redirectAllowlist of the policy, the client is sent to the rd parameter after the logout
+    var returnTo = r.variables.oidc_redirect_allowlist ? r.args.rd : undefined;
+    if (returnTo !== undefined && !loginRedirectAllowed(r, returnTo)) {
+        logWarn(r, "OIDC logout redirect to " + returnTo + " is not allowed");
+        r.return(400, "Invalid rd parameter\n");
+        return;
+    }
+
+    // The session of another policy is left to the logout of that policy
+    if (foreignSession(r)) {
+        logWarn(r, "OIDC logout with session " + r.variables.oidc_session_id + " that was not created by " + r.variables.oidc_policy);
+        r.return(302, r.variables.oidc_logout_redirect);
+        return;
+    }
+
+    logInfo(r, "OIDC logout for " + r.variables.oidc_session_id);
+    var claims = sessionClaims(r);
+    if (r.args.all == "true" && claims && claims.sub) {
+        revokeSubject(r, claims.iss, claims.sub);
+    }
+    audit(r, "logout", "success", claims && claims.sub, r.args.all == "true" ? "all_sessions" : undefined);
+    revokeTokens(r, r.variables.access_token, r.variables.refresh_token);
+    var idToken = r.variables.session_jwt;
+    r.variables.session_jwt   = "-";
+    r.variables.access_token  = "-";
+    r.variables.refresh_token = "-";
+    if (r.variables.oidc_idps) {
+        r.variables.oidc_session_idp = ""; // The next login shows the IdP picker again
+    }
+    deleteSession(r);
+    var endSession = endSessionRedirect(r, idToken);
+    if (returnTo !== undefined) {
+        if (!endSession) {
+            r.return(302, redirectTarget(r, returnTo));
+            return;
+        }
+        // The IdP sends the client back to $oidc_logout_redirect, which redirects to the auth_logout_redir cookie
+        addCookies(r, ["auth_logout_redir=" + returnTo + "; " + r.variables.oidc_cookie_flags]);
+    }
+    r.return(302, endSession || r.variables.oidc_logout_redirect);
+}
+
+// Responds to the client sent back to $oidc_logout_redirect after a logout. The client is sent to the rd
+// parameter of the logout, kept in the auth_logout_redir cookie, which is checked again because it could have been
+// set by another site.
+function loggedOut(r) {
+    addSecurityHeaders(r);
+    var returnTo = r.variables.cookie_auth_logout_redir;
+    if (returnTo) {
+        addCookies(r, ["auth_logout_redir=; Max-Age=0; " + r.variables.oidc_cookie_flags]);
+        if (r.variables.oidc_redirect_allowlist && loginRedirectAllowed(r, returnTo)) {
+            r.return(302, redirectTarget(r, returnTo));
+            return;
+        }
+        logWarn(r, "OIDC logout redirect to " + returnTo + " is not allowed");
+    }
+    r.return(200, "Logged out\n");
+}
+
+// Returns the URL of the end session endpoint of the IdP, e.g. the logout endpoint of a Keycloak realm, which ends
+// the session of the user at the IdP too and sends the client back to $oidc_logout_redirect, as per:
+//  https://openid.net/specs/openid-connect-rpinitiated-1_0.html
+// Returns an empty string if the policy has no end session endpoint.
+function endSessionRedirect(r, idToken) {
+    var endpoint = r.variables.oidc_end_session_endpoint;
+    if (!endpoint) {
+        return "";
+    }
+    var postLogoutRedirect = r.variables.oidc_logout_redirect;
+    if (postLogoutRedirect.charAt(0) == "/") {
+        postLogoutRedirect = r.variables.redirect_base + postLogoutRedirect;
+    }
+    // The logout endpoint of Cognito is not RP-Initiated Logout: the redirect is the logout_uri parameter, which must
+    // be a sign out URL of the app client, and the ID token isn't accepted
+    if (r.variables.oidc_idp_type == "cognito") {
+        return endpoint + "?client_id=" + encodeURIComponent(r.variables.oidc_client) +
+               "&logout_uri=" + encodeURIComponent(postLogoutRedirect);
+    }
+    // Neither is the /v2/logout endpoint of Auth0, the redirect is the returnTo parameter, which must be an allowed
+    // logout URL of the application
+    if (r.variables.oidc_idp_type == "auth0") {
+        return endpoint + "?client_id=" + encodeURIComponent(r.variables.oidc_client) +
+               "&returnTo=" + encodeURIComponent(postLogoutRedirect);
+    }
+    // Nor is the startSLO.ping endpoint of PingFederate, which ends the session of the user at the server and its
+    // SLO partners, the redirect is the TargetResource parameter, which must be an allowed redirect of the server
+    if (r.variables.oidc_idp_type == "pingfederate" && /\/idp\/startSLO\.ping$/.test(endpoint)) {
+        return endpoint + "?TargetResource=" + encodeURIComponent(postLogoutRedirect);
+    }
+    var url = endpoint + (endpoint.indexOf("?") == -1 ? "?" : "&") + "client_id=" + encodeURIComponent(r.variables.oidc_client) +
+              "&post_logout_redirect_uri=" + encodeURIComponent(postLogoutRedirect);
+    if (idToken && idToken != "-") {
+        url += "&id_token_hint=" + idToken;
+    }
+    return url;
+}
+
+// Returns the logout token of the session id, signed with the state key, which is returned by /session.
+function logoutToken(key, id) {
+    var c = require('crypto');
+    return c.createHmac('sha256', key).update("logout." + id).digest('base64url');
+}
+
+// Revokes the tokens of a terminated session at the revocation endpoint of the policy, as per:
+//  https://www.rfc-editor.org/rfc/rfc7009
+// The refresh token is revoked first, because providers may also revoke the access tokens issued with it.
+// The revocations are detached subrequests, so that the client doesn't wait for the IdP.
+function revokeTokens(r, accessToken, refreshToken) {
+    if (!r.variables.oidc_revocation_endpoint) {
+        return;
+    }
+    [[refreshToken, "refresh_token"], [accessToken, "access_token"]].forEach(function(token) {
+        if (token[0] && token[0] != "-") {
+            r.subrequest(oidcPath(r, "/_revoke"), {args: "token=" + encodeURIComponent(token[0]) + "&hint=" + token[1], detached: true});
+        }
+    });
+}
+
+function getAuthZArgs(r, stepUp, returnTo, silent, extraArgs) {
+    // Choose a nonce for this flow for the client, and hash it for the IdP
+    var noncePlain = r.variables.request_id;
+    var c = require('crypto');
+    var h = c.createHmac('sha256', r.variables.oidc_hmac_key).update(noncePlain);
+    var nonceHash = h.digest('base64url');
+    var authZArgs = "?response_type=code&scope=" + r.variables.oidc_scopes + "&client_id=" + r.variables.oidc_client + "&redirect_uri="+ r.variables.redirect_base + r.variables.redir_location + "&nonce=" + nonceHash;
+
+    if (r.variables.oidc_authz_extra_args) {
+        authZArgs += "&" + r.variables.oidc_authz_extra_args;
+    }
+    authZArgs += r.variables.oidc_resource_args; // Resource indicators, as per RFC 8707
+    authZArgs += extraArgs || "";
+
+    var responseMode = r.variables.oidc_response_mode;
+    if (r.variables.oidc_jarm_enable == 1) {
+        responseMode = (responseMode == "form_post" ? "form_post" : "query") + ".jwt";
+    }
+
+    // The form_post response is a cross-site POST, the browser only sends the cookies with SameSite=None.
+    var cookieFlags = r.variables.oidc_cookie_flags;
+    if (responseMode) {
+        authZArgs += "&response_mode=" + responseMode;
+        if (r.variables.oidc_response_mode == "form_post") {
+            cookieFlags = "Path=/; SameSite=None; HttpOnly; Secure;";
+        }
+    }
+
+    r.headersOut['Set-Cookie'] = [
+        "auth_redir=" + returnTo + "; " + cookieFlags,
+        "auth_nonce=" + noncePlain + "; " + cookieFlags,
+        "auth_request_id=" + r.variables.oidc_request_id + "; " + cookieFlags
+    ];
+
+    // A step-up login makes the IdP authenticate the user again, the cookie marks the login for sendTokenRequest()
+    if (stepUp) {
+        authZArgs += "&prompt=login&max_age=" + r.variables.oidc_step_up_max_age;
+        addCookies(r, ["auth_step_up=1; " + cookieFlags]);
+    } else if (r.variables.cookie_auth_step_up) {
+        addCookies(r, ["auth_step_up=; Max-Age=0; " + cookieFlags]);
+    }
+
+    // A silent login doesn't show the login page, the IdP responds with an error when the user has to log in.
+    // The cookie marks the login for exchangeCode().
+    if (silent) {
+        authZArgs += "&prompt=none";
+        addCookies(r, ["auth_silent=1; " + cookieFlags]);
+    } else if (r.variables.cookie_auth_silent) {
+        addCookies(r, ["auth_silent=; Max-Age=0; " + cookieFlags]);
+    }
+
+    // The organization of an Auth0 login must be checked in the ID token, the cookie marks the login for
+    // sendTokenRequest()
+    if (r.variables.oidc_idp_type == "auth0" && extraArgs && r.args.organization) {
+        addCookies(r, ["auth_organization=" + r.args.organization + "; " + cookieFlags]);
+    } else if (r.variables.cookie_auth_organization) {
+        addCookies(r, ["auth_organization=; Max-Age=0; " + cookieFlags]);
+    }
+
+    // The IdP of a policy with several IdPs is selected by the cookie in the callback and the token request, which
+    // don't have the header, the cookie or the choice in the picker that selected the IdP of the login.
+    if (r.variables.oidc_idps) {
+        addCookies(r, ["auth_idp=" + selectedIdP(r) + "; Max-Age=" + stateLifetime + "; " + cookieFlags]);
+    }
+
+    if ( r.variables.oidc_pkce_enable == 1 ) {
+        // Math.random() is not an approved random bit generator in the FIPS mode
+        var random = function() {
+            return r.variables.oidc_fips_mode == 1 ? Buffer.from(crypto.getRandomValues(new Uint8Array(32))).toString('hex') : String(Math.random());
+        };
+        var pkce_code_verifier = c.createHmac('sha256', r.variables.oidc_hmac_key).update(random()).digest('hex');
+        r.variables.pkce_id = c.createHash('sha256').update(random()).digest('base64url');
+        var pkce_code_challenge = c.createHash('sha256').update(pkce_code_verifier).digest('base64url');
+        r.variables.pkce_code_verifier = pkce_code_verifier;
+
+        authZArgs += "&code_challenge_method=S256&code_challenge=" + pkce_code_challenge + "&state=" + r.variables.pkce_id;
+    } else {
+        authZArgs += "&state=" + signState(r.variables.oidc_state_key, Math.floor(Date.now() / 1000), noncePlain);
+    }
+    return authZArgs;
+}
+
+// Returns the state of a login started at the time iat, signed with $oidc_state_key. The signature
+// covers the nonce of the login, so that the state is only accepted from the client that started it.
+function signState(key, iat, nonce) {
+    var c = require('crypto');
+    return iat + "." + c.createHmac('sha256', key).update(iat + "." + nonce).digest('base64url');
+}
+
+// Returns why the state is not valid for the auth_nonce cookie of the client, or an empty string.
+function verifyState(r, state) {
+    var parts = String(state).split(".");
+    var iat = Number(parts[0]);
+    if (parts.length != 2 || !Number.isInteger(iat)) {
+        return "malformed state " + state;
+    }
+    // The previous key is set for the lifetime of a state after the key was rotated
+    var nonce = r.variables.cookie_auth_nonce;
+    var keys = [r.variables.oidc_state_key, r.variables.oidc_previous_state_key].filter(Boolean);
+    if (!nonce || !keys.some(function(key) { return signState(key, iat, nonce) == state; })) {
+        return "signature mismatch";
+    }
+    var age = Math.floor(Date.now() / 1000) - iat;
+    if (age > stateLifetime || age < -60) {
+        return "state issued " + age + " seconds ago";
+    }
+    return "";
+}
+
+// Returns the authorization response of the IdP. It is received in the query string,
+// or in the body of a POST request when response_mode=form_post is configured.
+// The code is returned URL encoded, as it is passed on in the token request.
+function getAuthResponse(r) {
+    if (r.variables.oidc_response_mode == "form_post" && r.method == "POST") {
+        var args = require('querystring').parse(r.requestText || "");
+        return {
+            code: args.code ? encodeURIComponent(args.code) : undefined,
+            state: args.state,
+            error: args.error,
+            error_description: args.error_description,
+            response: args.response,
+            kc_action_status: args.kc_action_status
+        };
+    }
+    return {
+        code: r.variables.arg_code,
+        state: r.variables.arg_state,
+        error: r.variables.arg_error,
+        error_description: r.variables.arg_error_description,
+        response: r.variables.arg_response,
+        kc_action_status: r.variables.arg_kc_action_status
+    };
+}
+
+// Signs the authorization request arguments as a request object (JAR), as per:
+//  https://www.rfc-editor.org/rfc/rfc9101.html
+// The private key is the JWK (or the first private key of the JWK Set) in $oidc_jar_key_file.
+function signAuthZRequest(r, authZArgs) {
+    var jwk, alg;
+    try {
+        jwk = readPrivateJwk(r.variables.oidc_jar_key_file);
+        alg = jwsAlgorithm(jwk);
+        // FIPS 140-3 approves the RSA signatures with keys of at least 2048 bits
+        if (r.variables.oidc_fips_mode == 1 && jwk.kty == "RSA" && Buffer.from(jwk.n, "base64url").length < 256) {
+            throw new Error("the RSA key of the request objects is shorter than 2048 bits");
+        }
+    } catch (e) {
+        return Promise.reject(e);
+    }
+
+    var now = Math.floor(Date.now() / 1000);
+    var claims = {
+        iss: r.variables.oidc_client,
+        aud: r.variables.oidc_authz_endpoint,
+        iat: now,
+        exp: now + 300,
+        jti: r.variables.request_id
+    };
+    authZArgs.substring(1).split("&").forEach(function(arg) {
+        var i = arg.indexOf("=");
+        if (i > 0) {
+            var name = arg.substring(0, i);
+            var value = decodeURIComponent(arg.substring(i + 1).replace(/\+/g, " "));
+            if (name == "resource") {
+                // Several resource indicators are an array of strings
+                claims.resource = (claims.resource || []).concat(value);
+            } else {
+                claims[name] = value;
+            }
+        }
+    });
+
+    var header = {alg: alg.name, typ: "oauth-authz-req+jwt"};
+    if (jwk.kid) {
+        header.kid = jwk.kid;
+    }
+    return signJwt(jwk, alg, header, claims);
+}
+
+// Generates the key of a new session when DPoP is enabled. Resolves to the private JWK,
+// or to undefined when DPoP is disabled.
+function newDpopKey(r) {
+    if (r.variables.oidc_dpop_enable != 1) {
+        return Promise.resolve();
+    }
+    return crypto.subtle.generateKey({name: "ECDSA", namedCurve: "P-256"}, true, ["sign", "verify"])
+        .then(function(pair) {
+            return crypto.subtle.exportKey("jwk", pair.privateKey);
+        });
+}
+
+function sessionDpopKey(r) {
+    return r.variables.oidc_dpop_key ? JSON.parse(r.variables.oidc_dpop_key) : undefined;
+}
+
+// Sets $oidc_dpop_proof to the proof of a request to the token endpoint, signed with the DPoP key.
+// The proof is sent in the DPoP header of the token requests, as per:
+//  https://www.rfc-editor.org/rfc/rfc9449#section-5
+function setTokenRequestProof(r, dpopKey) {
+    if (!dpopKey) {
+        return Promise.resolve();
+    }
+    return createDpopProof(dpopKey, "POST", r.variables.oidc_token_endpoint)
+        .then(function(proof) {
+            r.variables.oidc_dpop_proof = proof;
+        });
+}
+
+function createDpopProof(jwk, method, url, accessToken) {
+    var claims = {
+        jti: Buffer.from(crypto.getRandomValues(new Uint8Array(16))).toString("hex"),
+        htm: method,
+        htu: url,
+        iat: Math.floor(Date.now() / 1000)
+    };
+    if (accessToken) {
+        claims.ath = require('crypto').createHash('sha256').update(accessToken).digest('base64url');
+    }
+    var header = {typ: "dpop+jwt", alg: "ES256", jwk: {kty: jwk.kty, crv: jwk.crv, x: jwk.x, y: jwk.y}};
+    try {
+        return signJwt(jwk, jwsAlgorithm(jwk), header, claims);
+    } catch (e) {
+        return Promise.reject(e);
+    }
+}
+
+function signJwt(jwk, alg, header, claims) {
+    var signingInput = Buffer.from(JSON.stringify(header)).toString("base64url") + "." +
+                       Buffer.from(JSON.stringify(claims)).toString("base64url");
+
+    return crypto.subtle.importKey("jwk", jwk, alg.importParams, false, ["sign"])
+        .then(function(key) {
+            return crypto.subtle.sign(alg.signParams, key, Buffer.from(signingInput));
+        })
+        .then(function(signature) {
+            return signingInput + "." + Buffer.from(signature).toString("base64url");
+        });
+}
+
+function readPrivateJwk(file) {
+    var data = JSON.parse(require('fs').readFileSync(file).toString());
+    var keys = data.keys || [data];
+    for (var i = 0; i < keys.length; i++) {
+        if (keys[i].d) {
+            return keys[i];
+        }
+    }
+    throw new Error("no private key in " + file);
+}
+
+function jwsAlgorithm(jwk) {
+    if (jwk.kty == "RSA") {
+        return {
+            name: "RS256",
+            importParams: {name: "RSASSA-PKCS1-v1_5", hash: "SHA-256"},
+            signParams: {name: "RSASSA-PKCS1-v1_5"}
+        };
+    }
+    if (jwk.kty == "EC" && jwk.crv == "P-256") {
+        return {
+            name: "ES256",
+            importParams: {name: "ECDSA", namedCurve: "P-256"},
+            signParams: {name: "ECDSA", hash: "SHA-256"}
+        };
+    }
+    throw new Error("unsupported key type " + jwk.kty + (jwk.crv ? " " + jwk.crv : "") + ", must be RSA or EC P-256");
+}
+
+function idpClientAuth(r, authResponse) {
+    // If PKCE is enabled we have to use the code_verifier
+    if ( r.variables.oidc_pkce_enable == 1 ) {
+        r.variables.pkce_id = authResponse.state;
+        return "code=" + authResponse.code + "&code_verifier=" + r.variables.pkce_code_verifier;
+    } else {
+        return "code=" + authResponse.code + "&client_secret=" + r.variables.oidc_client_secret;
+    }
+}
+
+// The messages of a level are logged when the logLevel of the policy is at least that level. The warnings are
+// logged with the info messages. The locations without a policy log like the default level, info.
+var logLevels = {error: 0, info: 1, debug: 2, trace: 3};
+
+// The request IDs of the X-Request-ID header that are propagated, they are sent back in a cookie and logged
+var requestIds = /^[\w.-]{1,128}$/;
+
+// Returns the ID of the request from its X-Request-ID header if a proxy in front of NGINX set one, or generates one.
+// $request_id isn't used, it is the nonce of a login and the ID of a session, which aren't logged.
+function ingressRequestId(r) {
+    var requestId = r.headersIn["X-Request-ID"];
+    return requestIds.test(requestId || "") ? requestId : generateRequestId();
+}
+
+function generateRequestId() {
+    return Buffer.from(crypto.getRandomValues(new Uint8Array(8))).toString("hex");
+}
+
+// Returns the message redacted, with the ID of the request that started the login when it is logged during a login.
+function logMessage(r, message) {
+    var requestId = r.variables.oidc_request_id;
+    return redact(message) + (requestId ? " (request ID " + requestId + ")" : "");
+}
+
+function logEnabled(r, level) {
+    var policyLevel = logLevels[r.variables.oidc_log_level];
+    return logLevels[level] <= (policyLevel != undefined ? policyLevel : logLevels.info);
+}
+
+function logError(r, message) {
+    r.error(logMessage(r, message));
+}
+
+function logWarn(r, message) {
+    if (logEnabled(r, "info")) {
+        r.warn(logMessage(r, message));
+    }
+}
+
+function logInfo(r, message) {
+    if (logEnabled(r, "info")) {
+        r.log(logMessage(r, message));
+    }
+}
+
+function logDebug(r, message) {
+    if (logEnabled(r, "debug")) {
+        r.log(logMessage(r, message));
+    }
+}
+
+// Logs the responses of the IdP, which are only redacted, for the troubleshooting of an IdP
+function logTrace(r, message) {
+    if (logEnabled(r, "trace")) {
+        r.log(logMessage(r, message));
+    }
+}
+
+// Redacts the credentials at every log level: JWTs, the tokens and the codes of the JSON responses of the IdP and
+// of the query strings, and the session IDs, which are the values of the session cookies. A session ID keeps its
+// first 8 characters so that the messages of a session can be correlated.
+var redactedJSONFields = /("(?:access_token|refresh_token|id_token|code|device_code|client_secret|logout_token)"\s*:\s*")[^"]*"/g;
+var redactedArgs = /([?&](?:code|token|access_token|refresh_token|id_token|client_secret|code_verifier|sessionToken)=)[^&\s]*/g;
+var jwts = /eyJ[\w-]*(?:\.[\w-]*){2,4}/g;
+var sessionIds = /\b([0-9a-f]{8})[0-9a-f]{24}\b/g;
+
+function redact(message) {
+    return String(message)
+        .replace(redactedJSONFields, '$1[redacted]"')
+        .replace(redactedArgs, '$1[redacted]')
+        .replace(jwts, '[redacted JWT]')
+        .replace(sessionIds, '$1...');
 }
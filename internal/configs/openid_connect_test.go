@@ -0,0 +1,725 @@
+package configs
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// njsRunner runs a handler of an njs module for a sequence of requests with a mock of the njs request object,
+// and prints how each request ended, or the value returned by the handler of a js_set variable. The variables of
+// the keyval zones of the case are shared by the requests, keyed by the value of their key variable. The
+// subrequests aren't answered, so a request that sends one ends when the handler waits for the reply.
+const njsRunner = `import {createRequire} from 'module';
+import {readFileSync} from 'fs';
+globalThis.require = createRequire(import.meta.url);
+const handlers = (await import('./module.mjs')).default;
+
+const tc = JSON.parse(readFileSync(0, 'utf8'));
+const zones = {};
+const results = [];
+for (const req of tc.requests) {
+    results.push(await run(req));
+}
+process.stdout.write(JSON.stringify(results));
+
+function run(req) {
+    return new Promise(function(resolve) {
+        const res = {status: 0, redirect: "", value: "", headers: {}, subrequests: []};
+        let done = false;
+        const finish = function() {
+            if (!done) {
+                done = true;
+                resolve(res);
+            }
+        };
+        const keyval = tc.keyval || {};
+        const variables = new Proxy(Object.assign({}, tc.variables, req.variables), {
+            get(vars, name) {
+                if (keyval[name]) {
+                    return (zones[name] || {})[vars[keyval[name]]] || "";
+                }
+                return name in vars ? vars[name] : "";
+            },
+            set(vars, name, value) {
+                if (keyval[name]) {
+                    zones[name] = zones[name] || {};
+                    zones[name][vars[keyval[name]]] = String(value);
+                    return true;
+                }
+                vars[name] = String(value);
+                return true;
+            },
+        });
+        const r = {
+            method: req.method || "GET",
+            uri: req.uri || "/",
+            args: req.args || {},
+            headersIn: req.headers || {},
+            headersOut: res.headers,
+            requestText: req.body || "",
+            variables: variables,
+            log() {},
+            warn() {},
+            error() {},
+            return(status) {
+                res.status = status;
+                finish();
+            },
+            internalRedirect(uri) {
+                res.redirect = uri;
+                finish();
+            },
+            subrequest(uri, options, callback) {
+                res.subrequests.push(uri);
+                if (!callback && typeof options != "function") {
+                    return new Promise(function() {});
+                }
+            },
+        };
+        const value = handlers[req.handler || tc.handler](r);
+        if (typeof value == "string") {
+            res.value = value;
+            finish();
+        }
+        setTimeout(finish, 200);
+    });
+}
+`
+
+type njsRequest struct {
+	Handler   string            `json:"handler,omitempty"`
+	Method    string            `json:"method,omitempty"`
+	Args      map[string]string `json:"args,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Body      string            `json:"body,omitempty"`
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+type njsCase struct {
+	Handler   string            `json:"handler"`
+	Variables map[string]string `json:"variables"`
+	Keyval    map[string]string `json:"keyval,omitempty"`
+	Requests  []njsRequest      `json:"requests"`
+}
+
+type njsResult struct {
+	Status      int                        `json:"status"`
+	Redirect    string                     `json:"redirect"`
+	Value       string                     `json:"value"`
+	Headers     map[string]json.RawMessage `json:"headers"`
+	Subrequests []string                   `json:"subrequests"`
+}
+
+// runOpenIDConnectJS runs the requests of the case with openid_connect.js.
+func runOpenIDConnectJS(t *testing.T, c njsCase) []njsResult {
+	t.Helper()
+	return runNJS(t, "oidc/openid_connect.js", c)
+}
+
+// runNJS runs the requests of the case with the njs module in Node.js, which has the crypto, Buffer and
+// querystring APIs of njs that the handlers use.
+func runNJS(t *testing.T, module string, c njsCase) []njsResult {
+	t.Helper()
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is not installed")
+	}
+	js, err := os.ReadFile(module)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "module.mjs"), js, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "runner.mjs"), []byte(njsRunner), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	input, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(node, filepath.Join(dir, "runner.mjs"))
+	cmd.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("failed to run %v: %v: %s", module, err, stderr.String())
+	}
+	var results []njsResult
+	if err := json.Unmarshal(output, &results); err != nil {
+		t.Fatalf("failed to parse the results of %v %q: %v", module, output, err)
+	}
+	return results
+}
+
+func hmacBase64URL(key string, message string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(message))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signedState returns the state of a login started at iat, like signState() in openid_connect.js.
+func signedState(key string, iat int64, nonce string) string {
+	return fmt.Sprintf("%d.%s", iat, hmacBase64URL(key, fmt.Sprintf("%d.%s", iat, nonce)))
+}
+
+// tokenHash returns the at_hash or c_hash claim of the value: the left half of its hash, base64url encoded.
+func tokenHash(h func() hash.Hash, value string) string {
+	d := h()
+	d.Write([]byte(value))
+	sum := d.Sum(nil)
+	return base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2])
+}
+
+func mergeVariables(base map[string]string, overrides map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
+}
+
+func TestOpenIDConnectJSCodeExchangeState(t *testing.T) {
+	t.Parallel()
+	const nonce = "5d5a8f3e2b8c4a6f9e1d7c3b0a2f4e6d"
+	now := time.Now().Unix()
+	variables := map[string]string{
+		"oidc_state_key":                  "state-key",
+		"oidc_client":                     "client",
+		"oidc_token_endpoint":             "https://idp.example.com/token",
+		"oidc_login_lockout_max_failures": "0",
+		"cookie_auth_nonce":               nonce,
+		"arg_code":                        "code",
+		"remote_addr":                     "10.0.0.1",
+	}
+
+	tests := []struct {
+		variables map[string]string
+		accepted  bool
+		msg       string
+	}{
+		{
+			variables: map[string]string{"arg_state": signedState("state-key", now, nonce)},
+			accepted:  true,
+			msg:       "state signed with the state key",
+		},
+		{
+			variables: map[string]string{
+				"arg_state":               signedState("previous-state-key", now, nonce),
+				"oidc_previous_state_key": "previous-state-key",
+			},
+			accepted: true,
+			msg:      "state signed with the previous state key",
+		},
+		{
+			variables: map[string]string{"arg_state": signedState("previous-state-key", now, nonce)},
+			msg:       "state signed with a key that is no longer accepted",
+		},
+		{
+			variables: map[string]string{"arg_state": signedState("state-key", now, "another-nonce")},
+			msg:       "state of the login of another client",
+		},
+		{
+			variables: map[string]string{"arg_state": signedState("state-key", now, nonce), "cookie_auth_nonce": ""},
+			msg:       "state without the auth_nonce cookie",
+		},
+		{
+			variables: map[string]string{"arg_state": signedState("state-key", now-601, nonce)},
+			msg:       "expired state",
+		},
+		{
+			variables: map[string]string{"arg_state": strconv.FormatInt(now, 10) + ".forged"},
+			msg:       "forged state",
+		},
+		{
+			variables: map[string]string{"arg_state": "state"},
+			msg:       "malformed state",
+		},
+	}
+	for _, test := range tests {
+		results := runOpenIDConnectJS(t, njsCase{
+			Handler:   "codeExchange",
+			Variables: mergeVariables(variables, test.variables),
+			Requests:  []njsRequest{{}},
+		})
+		res := results[0]
+		exchanged := len(res.Subrequests) == 1 && res.Subrequests[0] == "/_token"
+		if test.accepted && (!exchanged || res.Status != 0) {
+			t.Errorf("codeExchange() returned %d and sent %v for %s, want the token request", res.Status, res.Subrequests, test.msg)
+		}
+		if !test.accepted && (exchanged || res.Status != 403) {
+			t.Errorf("codeExchange() returned %d and sent %v for %s, want 403 without a token request", res.Status, res.Subrequests, test.msg)
+		}
+	}
+}
+
+func TestOpenIDConnectJSLoginLockout(t *testing.T) {
+	t.Parallel()
+	const nonce = "5d5a8f3e2b8c4a6f9e1d7c3b0a2f4e6d"
+	forged := njsRequest{Variables: map[string]string{"arg_state": strconv.FormatInt(time.Now().Unix(), 10) + ".forged"}}
+	valid := njsRequest{Variables: map[string]string{"arg_state": signedState("state-key", time.Now().Unix(), nonce)}}
+	otherClient := njsRequest{Variables: map[string]string{"arg_state": forged.Variables["arg_state"], "remote_addr": "10.0.0.2"}}
+
+	results := runOpenIDConnectJS(t, njsCase{
+		Handler: "codeExchange",
+		Variables: map[string]string{
+			"oidc_state_key":                  "state-key",
+			"oidc_client":                     "client",
+			"oidc_login_lockout_max_failures": "3",
+			"oidc_login_lockout_window":       "600",
+			"oidc_login_lockout_duration":     "900",
+			"cookie_auth_nonce":               nonce,
+			"arg_code":                        "code",
+			"remote_addr":                     "10.0.0.1",
+		},
+		Keyval:   map[string]string{"oidc_login_failures": "oidc_login_lockout_key"},
+		Requests: []njsRequest{forged, forged, forged, forged, valid, otherClient},
+	})
+
+	expected := []struct {
+		status   int
+		redirect string
+		msg      string
+	}{
+		{status: 403, msg: "the first failed login"},
+		{status: 403, msg: "the second failed login"},
+		{status: 403, msg: "the failed login that locks the client out"},
+		{redirect: "@oidc_login_locked_out", msg: "a failed login of the locked out client"},
+		{redirect: "@oidc_login_locked_out", msg: "a valid login of the locked out client"},
+		{status: 403, msg: "a failed login of another client"},
+	}
+	for i, want := range expected {
+		if results[i].Status != want.status || results[i].Redirect != want.redirect {
+			t.Errorf("codeExchange() returned %d and redirected to %q for %s, want %d and %q",
+				results[i].Status, results[i].Redirect, want.msg, want.status, want.redirect)
+		}
+	}
+}
+
+func TestOpenIDConnectJSSessionRevocation(t *testing.T) {
+	t.Parallel()
+	const issuer = "https://idp.example.com"
+	now := time.Now().Unix()
+	active := func(iss string, sub string, iat int64) njsRequest {
+		return njsRequest{Variables: map[string]string{
+			"jwt_claim_iss": iss,
+			"jwt_claim_sub": sub,
+			"jwt_claim_iat": strconv.FormatInt(iat, 10),
+		}}
+	}
+
+	results := runOpenIDConnectJS(t, njsCase{
+		Handler: "sessionActive",
+		Keyval:  map[string]string{"oidc_sub_revoked_at": "oidc_revoked_subject_key"},
+		Requests: []njsRequest{
+			active(issuer, "alice", now-10),
+			{Handler: "revokeSessions", Method: "POST", Args: map[string]string{"iss": issuer, "sub": "alice"}},
+			{Handler: "revokeSessions", Method: "POST", Args: map[string]string{"sub": "alice"}},
+			active(issuer, "alice", now-10),
+			active(issuer, "alice", now),
+			active(issuer, "alice", now+2),
+			active("https://other-idp.example.com", "alice", now-10),
+			active(issuer, "bob", now-10),
+		},
+	})
+
+	if results[1].Status != 204 {
+		t.Errorf("revokeSessions() returned %d for the revocation of a subject, want 204", results[1].Status)
+	}
+	if results[2].Status != 400 {
+		t.Errorf("revokeSessions() returned %d for a revocation without an issuer, want 400", results[2].Status)
+	}
+	expected := []struct {
+		index  int
+		active string
+		msg    string
+	}{
+		{index: 0, active: "1", msg: "a session before the revocation"},
+		{index: 3, active: "0", msg: "a session issued before the revocation"},
+		{index: 4, active: "0", msg: "a session issued in the second of the revocation"},
+		{index: 5, active: "1", msg: "a session issued after the revocation"},
+		{index: 6, active: "1", msg: "a session of the same subject of another issuer"},
+		{index: 7, active: "1", msg: "a session of another subject"},
+	}
+	for _, want := range expected {
+		if results[want.index].Value != want.active {
+			t.Errorf("sessionActive() returned %q for %s, want %q", results[want.index].Value, want.msg, want.active)
+		}
+	}
+}
+
+func TestOpenIDConnectJSForeignSession(t *testing.T) {
+	t.Parallel()
+	variables := map[string]string{
+		"oidc_policy":              "default/oidc-policy",
+		"oidc_session_id":          "session-1",
+		"session_jwt":              "header.eyJzdWIiOiJhbGljZSJ9.signature",
+		"access_token":             "access-token",
+		"refresh_token":            "refresh-token",
+		"oidc_revocation_endpoint": "https://idp.example.com/revoke",
+		"oidc_logout_redirect":     "/_logout",
+		"jwt_claim_sub":            "alice",
+	}
+
+	tests := []struct {
+		policy  string
+		foreign bool
+		msg     string
+	}{
+		{policy: "default/oidc-policy", msg: "session of the policy"},
+		{policy: "default/other-policy", foreign: true, msg: "session of another policy"},
+		{policy: "", foreign: true, msg: "session without a policy"},
+	}
+	for _, test := range tests {
+		vars := mergeVariables(variables, map[string]string{"oidc_session_policy": test.policy})
+		active := runOpenIDConnectJS(t, njsCase{Handler: "sessionActive", Variables: vars, Requests: []njsRequest{{}}})
+		if want := map[bool]string{false: "1", true: "0"}[test.foreign]; active[0].Value != want {
+			t.Errorf("sessionActive() returned %q for %s, want %q", active[0].Value, test.msg, want)
+		}
+
+		logout := runOpenIDConnectJS(t, njsCase{Handler: "logout", Variables: vars, Requests: []njsRequest{{}}})
+		if logout[0].Status != 302 {
+			t.Errorf("logout() returned %d for %s, want 302", logout[0].Status, test.msg)
+		}
+		if revoked := len(logout[0].Subrequests) > 0; revoked == test.foreign {
+			t.Errorf("logout() sent the revocations %v for %s, want them only for the session of the policy", logout[0].Subrequests, test.msg)
+		}
+	}
+}
+
+func TestOpenIDConnectJSValidateIdTokenNonce(t *testing.T) {
+	t.Parallel()
+	const nonce = "5d5a8f3e2b8c4a6f9e1d7c3b0a2f4e6d"
+	variables := map[string]string{
+		"jwt_claim_iat":      "1700000000",
+		"jwt_claim_iss":      "https://idp.example.com",
+		"jwt_claim_sub":      "alice",
+		"jwt_audience":       "client",
+		"jwt_header_alg":     "RS256",
+		"oidc_client":        "client",
+		"oidc_hmac_key":      "hmac-key",
+		"oidc_nonce_enforce": "1",
+		"arg_nonce_check":    "1",
+		"cookie_auth_nonce":  nonce,
+	}
+
+	tests := []struct {
+		variables map[string]string
+		expected  int
+		msg       string
+	}{
+		{
+			variables: map[string]string{"jwt_claim_nonce": hmacBase64URL("hmac-key", nonce)},
+			expected:  204,
+			msg:       "nonce of the login of the client",
+		},
+		{
+			variables: map[string]string{
+				"jwt_claim_nonce":        hmacBase64URL("previous-hmac-key", nonce),
+				"oidc_previous_hmac_key": "previous-hmac-key",
+			},
+			expected: 204,
+			msg:      "nonce hashed with the previous key",
+		},
+		{
+			variables: map[string]string{"jwt_claim_nonce": hmacBase64URL("hmac-key", "another-nonce")},
+			expected:  403,
+			msg:       "nonce of the login of another client",
+		},
+		{
+			variables: map[string]string{"jwt_claim_nonce": nonce},
+			expected:  403,
+			msg:       "unhashed nonce",
+		},
+		{
+			variables: map[string]string{"jwt_claim_nonce": hmacBase64URL("hmac-key", nonce), "cookie_auth_nonce": ""},
+			expected:  403,
+			msg:       "ID token without the auth_nonce cookie",
+		},
+		{
+			variables: map[string]string{},
+			expected:  403,
+			msg:       "ID token without a nonce",
+		},
+		{
+			variables: map[string]string{"jwt_claim_nonce": "other", "oidc_nonce_enforce": "0"},
+			expected:  204,
+			msg:       "nonce that isn't enforced",
+		},
+		{
+			variables: map[string]string{"jwt_claim_nonce": "other", "arg_nonce_check": "0"},
+			expected:  204,
+			msg:       "ID token of a refresh",
+		},
+		{
+			variables: map[string]string{"jwt_claim_nonce": hmacBase64URL("hmac-key", nonce), "jwt_audience": "other-client"},
+			expected:  403,
+			msg:       "ID token of another client",
+		},
+	}
+	for _, test := range tests {
+		results := runOpenIDConnectJS(t, njsCase{
+			Handler:   "validateIdToken",
+			Variables: mergeVariables(variables, test.variables),
+			Requests:  []njsRequest{{}},
+		})
+		if results[0].Status != test.expected {
+			t.Errorf("validateIdToken() returned %d for %s, want %d", results[0].Status, test.msg, test.expected)
+		}
+	}
+}
+
+func TestOpenIDConnectJSValidateIdTokenHashes(t *testing.T) {
+	t.Parallel()
+	const accessToken = "SlAV32hkKG"
+	const code = "Qcb0Orv1zh30vL1MPRsbm-diHiMwcLyZvn1arpZv-Jxf_11jnpEX3Tgfvk"
+	variables := map[string]string{
+		"jwt_claim_iat":  "1700000000",
+		"jwt_claim_iss":  "https://idp.example.com",
+		"jwt_claim_sub":  "alice",
+		"jwt_audience":   "client",
+		"jwt_header_alg": "RS256",
+		"oidc_client":    "client",
+	}
+
+	tests := []struct {
+		variables map[string]string
+		args      map[string]string
+		expected  int
+		msg       string
+	}{
+		{
+			variables: map[string]string{"jwt_claim_at_hash": tokenHash(sha256.New, accessToken)},
+			args:      map[string]string{"access_token": accessToken},
+			expected:  204,
+			msg:       "at_hash of the access token",
+		},
+		{
+			variables: map[string]string{"jwt_claim_at_hash": tokenHash(sha512.New384, accessToken), "jwt_header_alg": "ES384"},
+			args:      map[string]string{"access_token": accessToken},
+			expected:  204,
+			msg:       "at_hash of the access token with SHA-384",
+		},
+		{
+			variables: map[string]string{"jwt_claim_at_hash": tokenHash(sha256.New, accessToken), "jwt_header_alg": "ES384"},
+			args:      map[string]string{"access_token": accessToken},
+			expected:  403,
+			msg:       "at_hash with another hash than the algorithm of the ID token",
+		},
+		{
+			variables: map[string]string{"jwt_claim_at_hash": tokenHash(sha256.New, "another-access-token")},
+			args:      map[string]string{"access_token": accessToken},
+			expected:  403,
+			msg:       "at_hash of another access token",
+		},
+		{
+			variables: map[string]string{"jwt_claim_at_hash": tokenHash(sha256.New, accessToken)},
+			expected:  403,
+			msg:       "at_hash without an access token",
+		},
+		{
+			variables: map[string]string{"jwt_claim_at_hash": tokenHash(sha256.New, accessToken), "jwt_header_alg": "none"},
+			args:      map[string]string{"access_token": accessToken},
+			expected:  403,
+			msg:       "at_hash of an ID token without a hash algorithm",
+		},
+		{
+			args:     map[string]string{"access_token": accessToken, "code": code},
+			expected: 204,
+			msg:      "ID token without hashes",
+		},
+		{
+			variables: map[string]string{"oidc_token_hashes_required": "1"},
+			args:      map[string]string{"access_token": accessToken, "code": code},
+			expected:  403,
+			msg:       "login without the required hashes",
+		},
+		{
+			variables: map[string]string{
+				"oidc_token_hashes_required": "1",
+				"jwt_claim_at_hash":          tokenHash(sha256.New, accessToken),
+				"jwt_claim_c_hash":           tokenHash(sha256.New, code),
+			},
+			args:     map[string]string{"access_token": accessToken, "code": code},
+			expected: 204,
+			msg:      "login with the required hashes",
+		},
+		{
+			variables: map[string]string{
+				"oidc_token_hashes_required": "1",
+				"jwt_claim_at_hash":          tokenHash(sha256.New, accessToken),
+				"jwt_claim_c_hash":           tokenHash(sha256.New, "another-code"),
+			},
+			args:     map[string]string{"access_token": accessToken, "code": code},
+			expected: 403,
+			msg:      "c_hash of another authorization code",
+		},
+		{
+			variables: map[string]string{"oidc_token_hashes_required": "1"},
+			args:      map[string]string{"access_token": accessToken},
+			expected:  204,
+			msg:       "refresh without hashes",
+		},
+	}
+	for _, test := range tests {
+		results := runOpenIDConnectJS(t, njsCase{
+			Handler:   "validateIdToken",
+			Variables: mergeVariables(variables, test.variables),
+			Requests:  []njsRequest{{Args: test.args}},
+		})
+		if results[0].Status != test.expected {
+			t.Errorf("validateIdToken() returned %d for %s, want %d", results[0].Status, test.msg, test.expected)
+		}
+	}
+}
+
+func TestOpenIDConnectJSLogoutCSRF(t *testing.T) {
+	t.Parallel()
+	variables := map[string]string{
+		"oidc_logout_csrf_enable": "1",
+		"oidc_state_key":          "state-key",
+		"oidc_session_id":         "session-1",
+		"oidc_logout_redirect":    "/_logout",
+		"oidc_cookie_flags":       "Path=/; SameSite=Lax;",
+	}
+	token := hmacBase64URL("state-key", "logout.session-1")
+
+	tests := []struct {
+		variables map[string]string
+		request   njsRequest
+		expected  int
+		msg       string
+	}{
+		{
+			request:  njsRequest{Method: "POST", Headers: map[string]string{"X-CSRF-Token": token}},
+			expected: 302,
+			msg:      "logout with the logout token in the header",
+		},
+		{
+			request:  njsRequest{Method: "POST", Body: "csrf_token=" + token},
+			expected: 302,
+			msg:      "logout with the logout token in the form",
+		},
+		{
+			variables: map[string]string{"oidc_state_key": "new-state-key", "oidc_previous_state_key": "state-key"},
+			request:   njsRequest{Method: "POST", Headers: map[string]string{"X-CSRF-Token": token}},
+			expected:  302,
+			msg:       "logout with the logout token of the previous state key",
+		},
+		{
+			request:  njsRequest{Method: "GET", Headers: map[string]string{"X-CSRF-Token": token}},
+			expected: 405,
+			msg:      "logout with a GET request",
+		},
+		{
+			request:  njsRequest{Method: "POST"},
+			expected: 403,
+			msg:      "logout without a logout token",
+		},
+		{
+			request:  njsRequest{Method: "POST", Headers: map[string]string{"X-CSRF-Token": hmacBase64URL("state-key", "logout.session-2")}},
+			expected: 403,
+			msg:      "logout with the logout token of another session",
+		},
+		{
+			variables: map[string]string{"oidc_session_id": ""},
+			request:   njsRequest{Method: "POST", Headers: map[string]string{"X-CSRF-Token": hmacBase64URL("state-key", "logout.")}},
+			expected:  403,
+			msg:       "logout without a session",
+		},
+		{
+			variables: map[string]string{"oidc_logout_csrf_enable": "0"},
+			request:   njsRequest{Method: "GET"},
+			expected:  302,
+			msg:       "logout without logout protection",
+		},
+	}
+	for _, test := range tests {
+		results := runOpenIDConnectJS(t, njsCase{
+			Handler:   "logout",
+			Variables: mergeVariables(variables, test.variables),
+			Requests:  []njsRequest{test.request},
+		})
+		if results[0].Status != test.expected {
+			t.Errorf("logout() returned %d for %s, want %d", results[0].Status, test.msg, test.expected)
+		}
+	}
+}
+
+func TestIdentityJS(t *testing.T) {
+	t.Parallel()
+	jwt := func(claims string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256"}`)) + "." +
+			base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".signature"
+	}
+	token := jwt(`{"iss":"https://idp.example.com","sub":"alice"}`)
+
+	tests := []struct {
+		variables map[string]string
+		subject   string
+		issuer    string
+		msg       string
+	}{
+		{
+			variables: map[string]string{"identity_auth_method": "jwt", "identity_jwt_token": "Bearer " + token},
+			subject:   "alice",
+			issuer:    "https://idp.example.com",
+			msg:       "JWT in the Authorization header",
+		},
+		{
+			variables: map[string]string{"identity_auth_method": "jwt", "identity_jwt_token": token},
+			subject:   "alice",
+			issuer:    "https://idp.example.com",
+			msg:       "JWT in the token variable of the policy",
+		},
+		{
+			variables: map[string]string{"identity_auth_method": "oidc", "session_jwt": token},
+			subject:   "alice",
+			issuer:    "https://idp.example.com",
+			msg:       "ID token of an OIDC session",
+		},
+		{
+			variables: map[string]string{"identity_auth_method": "oidc", "session_jwt": "-"},
+			msg:       "OIDC session that was logged out",
+		},
+		{
+			variables: map[string]string{"identity_auth_method": "jwt", "identity_jwt_token": "Bearer token"},
+			msg:       "malformed JWT",
+		},
+		{
+			variables: map[string]string{"identity_auth_method": "jwt", "identity_jwt_token": "Bearer " + jwt(`"alice"`)},
+			msg:       "JWT without claims",
+		},
+		{
+			variables: map[string]string{"identity_auth_method": "basic", "session_jwt": token},
+			msg:       "another authentication method",
+		},
+	}
+	for _, test := range tests {
+		results := runNJS(t, "njs/identity.js", njsCase{
+			Variables: test.variables,
+			Requests:  []njsRequest{{Handler: "subject"}, {Handler: "issuer"}},
+		})
+		if results[0].Value != test.subject || results[1].Value != test.issuer {
+			t.Errorf("identity.js returned subject %q and issuer %q for %s, want %q and %q",
+				results[0].Value, results[1].Value, test.msg, test.subject, test.issuer)
+		}
+	}
+}
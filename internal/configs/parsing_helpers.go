@@ -211,6 +211,30 @@ func ParseTime(s string) (string, error) {
 	return fmt.Sprintf("%s%s%s%s%s%s%s%s", years, months, weeks, days, hours, mins, secs, millis), nil
 }
 
+// timeUnitSeconds are the seconds of the units of the NGINX time syntax, in the order of timeRegexp.
+var timeUnitSeconds = []int{365 * 24 * 3600, 30 * 24 * 3600, 7 * 24 * 3600, 24 * 3600, 3600, 60, 1}
+
+// ParseTimeSeconds returns the seconds of a valid NGINX time. The milliseconds are truncated.
+func ParseTimeSeconds(s string) (int, error) {
+	if _, err := ParseTime(s); err != nil {
+		return 0, err
+	}
+	units := timeRegexp.FindStringSubmatch(s)
+	seconds := 0
+	for i, unitSeconds := range timeUnitSeconds {
+		value := strings.TrimRight(units[i+1], "yMwdhms")
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, err
+		}
+		seconds += n * unitSeconds
+	}
+	return seconds, nil
+}
+
 // OffsetFmt http://nginx.org/en/docs/syntax.html
 const OffsetFmt = `\d+[kKmMgG]?`
 
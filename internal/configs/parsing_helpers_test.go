@@ -411,6 +411,39 @@ func TestParseTime(t *testing.T) {
 	}
 }
 
+func TestParseTimeSeconds(t *testing.T) {
+	t.Parallel()
+	testsWithValidInput := []struct {
+		input    string
+		expected int
+	}{
+		{"1h30m 5 100ms", 5405},
+		{"10ms", 0},
+		{"600", 600},
+		{"30d", 2592000},
+		{"2w", 1209600},
+		{"1M", 2592000},
+		{"1y", 31536000},
+	}
+	invalidInput := []string{"5s 5s", "", "1L", "-5s"}
+
+	for _, test := range testsWithValidInput {
+		result, err := ParseTimeSeconds(test.input)
+		if err != nil {
+			t.Errorf("ParseTimeSeconds(%q) returned an error for valid input", test.input)
+		}
+		if result != test.expected {
+			t.Errorf("ParseTimeSeconds(%q) returned %d expected %d", test.input, result, test.expected)
+		}
+	}
+
+	for _, test := range invalidInput {
+		if result, err := ParseTimeSeconds(test); err == nil {
+			t.Errorf("ParseTimeSeconds(%q) didn't return error. Returned: %d", test, result)
+		}
+	}
+}
+
 func TestParseOffset(t *testing.T) {
 	t.Parallel()
 	testsWithValidInput := []string{"1", "2k", "2K", "3m", "3M", "4g", "4G"}
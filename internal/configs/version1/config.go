@@ -188,6 +188,13 @@ type Location struct {
 	MinionIngress *Ingress
 }
 
+// OIDCKeyvalZone describes a keyval zone of the OIDC policies.
+type OIDCKeyvalZone struct {
+	Name    string
+	Size    string
+	Timeout string
+}
+
 // MainConfig describe the main NGINX configuration file.
 type MainConfig struct {
 	AccessLogOff                       bool
@@ -261,6 +268,10 @@ type MainConfig struct {
 	InternalRouteServerName            string
 	LatencyMetrics                     bool
 	OIDC                               bool
+	OIDCFIPSMode                       bool
+	OIDCAuditLog                       string
+	OIDCWebhook                        bool
+	OIDCKeyvalZones                    []OIDCKeyvalZone
 	DynamicSSLReloadEnabled            bool
 	StaticSSLPath                      string
 	NginxVersion                       nginx.Version
@@ -49,6 +49,88 @@ func TestExecuteMainTemplateForNGINXPlusR31(t *testing.T) {
 	t.Log(buf.String())
 }
 
+func TestExecuteMainTemplateForNGINXPlusWithOIDC(t *testing.T) {
+	t.Parallel()
+
+	tmpl := newNGINXPlusMainTmpl(t)
+	buf := &bytes.Buffer{}
+
+	cfg := mainCfg
+	cfg.OIDC = true
+	cfg.OIDCKeyvalZones = []OIDCKeyvalZone{
+		{Name: "oidc_id_tokens", Size: "1M", Timeout: "1h"},
+		{Name: "refresh_tokens", Size: "1M", Timeout: "8h"},
+	}
+	err := tmpl.Execute(buf, cfg)
+	t.Log(buf.String())
+	if err != nil {
+		t.Fatalf("Failed to write template %v", err)
+	}
+
+	wantDirectives := []string{
+		"keyval_zone zone=oidc_id_tokens:1M timeout=1h sync;",
+		"keyval_zone zone=refresh_tokens:1M timeout=8h sync;",
+		"js_import oidc from oidc/openid_connect.js;",
+		"location = /oidc/revoke-sessions {",
+		"js_content oidc.revokeSessions;",
+	}
+
+	mainConf := buf.String()
+	for _, want := range wantDirectives {
+		if !strings.Contains(mainConf, want) {
+			t.Errorf("want %q in generated config", want)
+		}
+	}
+	snaps.MatchSnapshot(t, mainConf)
+}
+
+func TestExecuteMainTemplateForNGINXPlusWithOIDCAuditLog(t *testing.T) {
+	t.Parallel()
+
+	tmpl := newNGINXPlusMainTmpl(t)
+	buf := &bytes.Buffer{}
+
+	cfg := mainCfg
+	cfg.OIDC = true
+	cfg.OIDCAuditLog = "syslog:server=localhost:514"
+	cfg.AccessLogOff = true
+	err := tmpl.Execute(buf, cfg)
+	t.Log(buf.String())
+	if err != nil {
+		t.Fatalf("Failed to write template %v", err)
+	}
+
+	mainConf := buf.String()
+	want := "access_log syslog:server=localhost:514 oidc_audit if=$oidc_audit_event;"
+	if n := strings.Count(mainConf, want); n != 2 {
+		t.Errorf("want %q in the http context and the revoke-sessions location, got it %d times", want, n)
+	}
+	if strings.Contains(mainConf, "\n    access_log off;") {
+		t.Errorf("want no access_log off in the http context, it would turn the audit log off")
+	}
+}
+
+func TestExecuteMainTemplateForNGINXPlusWithOIDCWebhook(t *testing.T) {
+	t.Parallel()
+
+	tmpl := newNGINXPlusMainTmpl(t)
+	buf := &bytes.Buffer{}
+
+	cfg := mainCfg
+	cfg.OIDC = true
+	cfg.OIDCWebhook = true
+	err := tmpl.Execute(buf, cfg)
+	t.Log(buf.String())
+	if err != nil {
+		t.Fatalf("Failed to write template %v", err)
+	}
+
+	want := "js_var $oidc_webhook_enable 1;"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("want %q in generated config", want)
+	}
+}
+
 func TestExecuteMainTemplateForNGINX(t *testing.T) {
 	t.Parallel()
 
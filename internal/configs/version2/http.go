@@ -84,8 +84,10 @@ type Server struct {
 	IngressMTLS               *IngressMTLS
 	EgressMTLS                *EgressMTLS
 	OIDC                      *OIDC
+	IdentityAuthMethod        string
 	APIKey                    *APIKey
 	APIKeyEnabled             bool
+	ClientCredentialsEnabled  bool
 	WAF                       *WAF
 	Dos                       *Dos
 	PoliciesErrorReturn       *Return
@@ -127,16 +129,127 @@ type EgressMTLS struct {
 
 // OIDC holds OIDC configuration data.
 type OIDC struct {
-	AuthEndpoint      string
-	ClientID          string
-	ClientSecret      string
-	JwksURI           string
-	Scope             string
-	TokenEndpoint     string
-	RedirectURI       string
-	ZoneSyncLeeway    int
-	AuthExtraArgs     string
-	AccessTokenEnable bool
+	AuthEndpoint           string
+	ClientID               string
+	ClientSecret           string
+	JwksURI                string
+	JwksFile               string
+	Scope                  string
+	TokenEndpoint          string
+	RedirectURI            string
+	ZoneSyncLeeway         int
+	AuthExtraArgs          string
+	AccessTokenEnable      bool
+	RetryOnUnauthorized    bool
+	ResponseMode           string
+	JARKeyFile             string
+	JARMEnable             bool
+	DeviceAuthEndpoint     string
+	DPoPEnable             bool
+	OAuth2UserEndpoint     string
+	JWEKeyFile             string
+	NonceEnforce           bool
+	StateKey               string
+	PreviousStateKey       string
+	HMACKey                string
+	PreviousHMACKey        string
+	ResourceArgs           string
+	ErrorPages             []OIDCErrorPage
+	CookieSameSite         string
+	CookieDomain           string
+	ClaimRules             string
+	SessionStore           string
+	SessionCookieKeys      string
+	PreviousKeyExpiry      int64
+	MaxSessionsPerUser     int
+	SessionLimitAction     string
+	RefreshAheadSeconds    int
+	RevocationEndpoint     string
+	RememberMeMaxAge       int
+	StepUpMaxAge           int
+	IdPOutageBehavior      string
+	LoginRedirectPaths     string
+	UnauthorizedAcceptJSON bool
+	UnauthorizedPaths      string
+	SessionInfoClaims      string
+	LogoutCSRFEnable       bool
+	IntrospectionEnable    bool
+	TerminateOnSessionEnd  bool
+	Tracing                bool
+	Policy                 string
+	LogLevel               string
+	IdPType                string
+	AllowedTenants         string
+	EndSessionEndpoint     string
+	UserinfoEndpoint       string
+	Issuer                 string
+	AccessTokenAudience    string
+	GoogleHostedDomain     string
+	IdPs                   []OIDCIdP
+	IdPSelection           string
+	IdPSelectionVariable   string
+	IdPEmailDomains        string
+	StrictSpecCompliance   bool
+	TokenHashesRequired    bool
+	RoutePolicies          []*OIDC
+	CookieSuffix           string
+	Resolver               string
+	HTTPProxy              string
+	NoProxy                string
+	RequestHeaders         []Header
+	PathPrefix             string
+	LocationsFile          string
+	RateLimit              *LimitReq
+	LoginLockout           *OIDCLoginLockout
+	RedirectAllowlist      string
+	SecurityHeaders        OIDCSecurityHeaders
+}
+
+// OIDCIdP holds an additional IdP of an OIDC policy, which replaces the endpoints and the client of the policy
+// for the logins and the sessions of the IdP.
+type OIDCIdP struct {
+	Name               string
+	AuthEndpoint       string
+	TokenEndpoint      string
+	JwksURI            string
+	ClientID           string
+	ClientSecret       string
+	Scope              string
+	EndSessionEndpoint string
+	Hosts              []string
+}
+
+// OIDCErrorPage holds the HTML page of an OIDC login error.
+type OIDCErrorPage struct {
+	Name string
+	Code int
+	Body string
+}
+
+// OIDCLoginLockout holds the lockout of the client IP addresses with repeated failed logins, with the times in
+// seconds. With a TarpitDelay, the logins of a locked out client are delayed instead of rejected.
+type OIDCLoginLockout struct {
+	MaxFailures int
+	Window      int
+	Duration    int
+	TarpitDelay int
+}
+
+// OIDCSecurityHeaders holds the security headers of the pages and the errors of the login and the logout. The
+// empty headers aren't sent.
+type OIDCSecurityHeaders struct {
+	ContentSecurityPolicy string
+	FrameOptions          string
+	ReferrerPolicy        string
+}
+
+// ClientCredentials holds the configuration of the client credentials grant of a location.
+type ClientCredentials struct {
+	Key           string
+	TokenEndpoint string
+	ClientID      string
+	ClientSecret  string
+	Scope         string
 }
 
 // APIKey holds API key configuration.
@@ -206,7 +319,13 @@ type Location struct {
 	BasicAuth                *BasicAuth
 	EgressMTLS               *EgressMTLS
 	OIDC                     bool
+	IdentityAuthMethod       string
 	APIKey                   *APIKey
+	ClientCredentials        *ClientCredentials
+	TokenExchangeAudience    string
+	DPoP                     bool
+	OIDCStepUp               bool
+	OIDCRoute                *OIDC
 	WAF                      *WAF
 	Dos                      *Dos
 	PoliciesErrorReturn      *Return
@@ -380,12 +499,13 @@ func (rl LimitReqOptions) String() string {
 
 // JWTAuth holds JWT authentication configuration.
 type JWTAuth struct {
-	Key      string
-	Secret   string
-	Realm    string
-	Token    string
-	KeyCache string
-	JwksURI  JwksURI
+	Key        string
+	Secret     string
+	Realm      string
+	Token      string
+	KeyCache   string
+	JwksURI    JwksURI
+	ClaimRules []string
 }
 
 // JwksURI defines the components of a JwksURI
@@ -2,7 +2,9 @@ package version2
 
 import (
 	"bytes"
+	"fmt"
 	"path"
+	"regexp"
 	"text/template"
 )
 
@@ -13,11 +15,35 @@ const tlsPassthroughHostsTemplateString = `# mapping between TLS Passthrough hos
 {{ end }}
 `
 
+// oidcTemplateName is the name of the template of the OIDC locations of the servers with an OIDC policy, which the
+// VirtualServer template defines and executes with the server.
+const oidcTemplateName = "oidc"
+
+// requiredOIDCLocations are the internal locations of the OIDC flow that an OIDC template must keep, by including
+// oidc/oidc.conf or with its own locations.
+var requiredOIDCLocations = []string{"/_codexch", "/_token", "/_refresh"}
+
+// oidcTemplateTestServer is the server that a new OIDC template is executed with before it is used, with the
+// default locations of oidc/oidc.conf.
+var oidcTemplateTestServer = &Server{
+	VSName:      "oidc-template-test",
+	VSNamespace: "default",
+	OIDC:        &OIDC{ClientID: "oidc-template-test", RedirectURI: "/_codexch"},
+}
+
+// oidcLocationsInclude matches the include of the OIDC locations of the test server.
+var oidcLocationsInclude = regexp.MustCompile(`(?m)(^|[;{}])\s*include\s+"?oidc/oidc\.conf"?\s*;`)
+
+// nginxComment matches a comment of the NGINX configuration, which starts with # at the beginning of a line or after
+// a blank.
+var nginxComment = regexp.MustCompile(`(?m)(^|\s)#.*$`)
+
 // TemplateExecutor executes NGINX configuration templates.
 type TemplateExecutor struct {
 	virtualServerTemplate       *template.Template
 	transportServerTemplate     *template.Template
 	tlsPassthroughHostsTemplate *template.Template
+	oidcTemplate                *template.Template
 }
 
 // NewTemplateExecutor creates a TemplateExecutor.
@@ -52,11 +78,59 @@ func (te *TemplateExecutor) UpdateVirtualServerTemplate(templateString *string)
 	if err != nil {
 		return err
 	}
+	if te.oidcTemplate != nil {
+		if _, err := newTemplate.AddParseTree(oidcTemplateName, te.oidcTemplate.Tree); err != nil {
+			return err
+		}
+	}
 	te.virtualServerTemplate = newTemplate
 
 	return nil
 }
 
+// UpdateOIDCTemplate updates the template of the OIDC locations, which replaces the include of oidc.conf in the
+// servers with an OIDC policy. The template is executed with a test server, whose configuration must either include
+// oidc/oidc.conf, e.g. include {{ with .OIDC.LocationsFile }}{{ . }}{{ else }}oidc/oidc.conf{{ end }}, or have the
+// internal locations of the OIDC flow, e.g. location = {{ .OIDC.PathPrefix }}/_token, but not both.
+func (te *TemplateExecutor) UpdateOIDCTemplate(templateString *string) error {
+	newTemplate, err := template.New(oidcTemplateName).Funcs(helperFunctions).Parse(*templateString)
+	if err != nil {
+		return err
+	}
+	var configBuffer bytes.Buffer
+	if err := newTemplate.Execute(&configBuffer, oidcTemplateTestServer); err != nil {
+		return fmt.Errorf("failed to execute the OIDC template: %w", err)
+	}
+	if err := validateOIDCLocations(configBuffer.String()); err != nil {
+		return err
+	}
+	if _, err := te.virtualServerTemplate.AddParseTree(oidcTemplateName, newTemplate.Tree); err != nil {
+		return err
+	}
+	te.oidcTemplate = newTemplate
+
+	return nil
+}
+
+// validateOIDCLocations validates the configuration generated by the OIDC template for the test server. The
+// comments of the configuration are ignored.
+func validateOIDCLocations(config string) error {
+	config = nginxComment.ReplaceAllString(config, "$1")
+	included := oidcLocationsInclude.MatchString(config)
+	for _, location := range requiredOIDCLocations {
+		count := len(regexp.MustCompile(`location\s*=\s*"?`+regexp.QuoteMeta(location)+`"?\s*\{`).FindAllStringIndex(config, -1))
+		switch {
+		case included && count > 0:
+			return fmt.Errorf("the OIDC template has the location = %s of the included oidc/oidc.conf", location)
+		case !included && count == 0:
+			return fmt.Errorf("the OIDC template must include oidc/oidc.conf or have the location = %s", location)
+		case count > 1:
+			return fmt.Errorf("the OIDC template has the location = %s more than once", location)
+		}
+	}
+	return nil
+}
+
 // ExecuteVirtualServerTemplate generates the content of an NGINX configuration file for a VirtualServer resource.
 func (te *TemplateExecutor) ExecuteVirtualServerTemplate(cfg *VirtualServerConfig) ([]byte, error) {
 	var configBuffer bytes.Buffer
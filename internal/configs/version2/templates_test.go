@@ -4,6 +4,11 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/gkampitakis/go-snaps/snaps"
@@ -501,6 +506,1535 @@ func TestExecuteVirtualServerTemplateWithJWKSWithoutToken(t *testing.T) {
 	t.Log(string(got))
 }
 
+func TestExecuteVirtualServerTemplateWithOIDCRetryOnUnauthorized(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:        "https://idp.example.com/auth",
+		TokenEndpoint:       "https://idp.example.com/token",
+		JwksURI:             "https://idp.example.com/certs",
+		ClientID:            "client",
+		ClientSecret:        "secret",
+		RedirectURI:         "/_codexch",
+		Scope:               "openid",
+		RetryOnUnauthorized: true,
+	}
+	vscfg.Server.Locations = []Location{
+		{
+			Path:      "/",
+			ProxyPass: "http://test-upstream",
+			OIDC:      true,
+		},
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+
+	wantDirectives := []string{
+		"set $oidc_retry_unauthorized 1;",
+		"auth_jwt_require $oidc_session_active;",
+		"proxy_intercept_errors on;",
+	}
+	for _, want := range wantDirectives {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template", want)
+		}
+	}
+	t.Log(string(got))
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCTokenExchange(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:      "https://idp.example.com/auth",
+		TokenEndpoint:     "https://idp.example.com/token",
+		JwksURI:           "https://idp.example.com/certs",
+		ClientID:          "client",
+		ClientSecret:      "secret",
+		RedirectURI:       "/_codexch",
+		Scope:             "openid",
+		AccessTokenEnable: true,
+	}
+	vscfg.Server.Locations = []Location{
+		{
+			Path:                  "/orders",
+			ProxyPass:             "http://test-upstream",
+			OIDC:                  true,
+			TokenExchangeAudience: "orders-api",
+		},
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+
+	wantDirectives := []string{
+		`set $oidc_token_exchange_audience "orders-api";`,
+		"auth_request /_oidc_token_exchange;",
+		`proxy_set_header Authorization "Bearer $oidc_exchanged_token";`,
+	}
+	for _, want := range wantDirectives {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template", want)
+		}
+	}
+	if bytes.Contains(got, []byte(`proxy_set_header Authorization "Bearer $access_token";`)) {
+		t.Errorf("want the access token of the session replaced by the exchanged token")
+	}
+	t.Log(string(got))
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCNonceEnforce(t *testing.T) {
+	t.Parallel()
+
+	for _, enforce := range []bool{true, false} {
+		vscfg := vsConfig()
+		vscfg.Server.OIDC = &OIDC{
+			AuthEndpoint:  "https://idp.example.com/auth",
+			TokenEndpoint: "https://idp.example.com/token",
+			JwksURI:       "https://idp.example.com/certs",
+			ClientID:      "client",
+			ClientSecret:  "secret",
+			RedirectURI:   "/_codexch",
+			Scope:         "openid",
+			NonceEnforce:  enforce,
+		}
+
+		e := newTmplExecutorNGINXPlus(t)
+		got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+		if err != nil {
+			t.Error(err)
+		}
+
+		want := "set $oidc_nonce_enforce 0;"
+		if enforce {
+			want = "set $oidc_nonce_enforce 1;"
+		}
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template", want)
+		}
+	}
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCTemplate(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:  "https://idp.example.com/auth",
+		TokenEndpoint: "https://idp.example.com/token",
+		JwksURI:       "https://idp.example.com/certs",
+		ClientID:      "client",
+		ClientSecret:  "secret",
+		RedirectURI:   "/_codexch",
+		Scope:         "openid",
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(got, []byte("include oidc/oidc.conf;")) {
+		t.Errorf("want the include of oidc.conf without an OIDC template")
+	}
+
+	oidcTemplate := `
+    include {{ with .OIDC.LocationsFile }}{{ . }}{{ else }}oidc/oidc.conf{{ end }};
+    # OIDC locations of {{ .VSName }} for {{ .OIDC.ClientID }}`
+	if err := e.UpdateOIDCTemplate(&oidcTemplate); err != nil {
+		t.Fatal(err)
+	}
+	// The OIDC template is kept when the VirtualServer template is updated
+	vsTemplate, err := os.ReadFile("nginx-plus.virtualserver.tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vsTemplateString := string(vsTemplate)
+	if err := e.UpdateVirtualServerTemplate(&vsTemplateString); err != nil {
+		t.Fatal(err)
+	}
+	got, err = e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# OIDC locations of " + vscfg.Server.VSName + " for client"
+	if !bytes.Contains(got, []byte(want)) {
+		t.Errorf("want %q in generated template", want)
+	}
+
+	for _, tc := range []struct {
+		template string
+		valid    bool
+		msg      string
+	}{
+		{
+			template: "include oidc/oidc.conf;\n",
+			valid:    true,
+			msg:      "include of oidc.conf",
+		},
+		{
+			template: "location = {{ .OIDC.PathPrefix }}/_codexch {}\nlocation = {{ .OIDC.PathPrefix }}/_token {}\nlocation = {{ .OIDC.PathPrefix }}/_refresh {}\n",
+			valid:    true,
+			msg:      "locations under the path prefix",
+		},
+		{
+			template: "location = /_codexch {}\nlocation = /_token {}\n",
+			msg:      "template without the /_refresh location",
+		},
+		{
+			template: "location = /_codexch {}\nlocation = /_token {}\n# location = /_refresh {}\n",
+			msg:      "template with the /_refresh location in a comment",
+		},
+		{
+			template: "include oidc/oidc.conf;\nlocation = /_token {}\n",
+			msg:      "template with a location of the included oidc.conf",
+		},
+		{
+			template: "location = /_codexch {}\nlocation = /_token {}\nlocation = /_refresh {}\nlocation = /_token {}\n",
+			msg:      "template with the /_token location twice",
+		},
+		{
+			template: "# include oidc/oidc.conf;\n",
+			msg:      "template with the include of oidc.conf in a comment",
+		},
+		{
+			template: "include oidc/oidc.conf;\n{{ .OIDC.Unknown }}",
+			msg:      "template that fails to execute",
+		},
+	} {
+		err := e.UpdateOIDCTemplate(&tc.template)
+		if tc.valid && err != nil {
+			t.Errorf("UpdateOIDCTemplate() returned an error for a %s: %v", tc.msg, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("UpdateOIDCTemplate() returned no error for a %s", tc.msg)
+		}
+	}
+}
+
+// TestOIDCTemplateNginxConfig tests the configuration generated by the OIDC templates with nginx -t. The OIDC
+// locations require NGINX Plus, the test is skipped without it.
+func TestOIDCTemplateNginxConfig(t *testing.T) {
+	t.Parallel()
+
+	nginx, err := exec.LookPath("nginx")
+	if err != nil {
+		t.Skip("nginx is not installed")
+	}
+	version, err := exec.Command(nginx, "-V").CombinedOutput()
+	if err != nil || !bytes.Contains(version, []byte("nginx-plus")) {
+		t.Skip("NGINX Plus is not installed")
+	}
+	modulesPath := "/usr/lib/nginx/modules"
+	if m := regexp.MustCompile(`--modules-path=(\S+)`).FindSubmatch(version); m != nil {
+		modulesPath = string(m[1])
+	}
+
+	dir := t.TempDir()
+	for _, d := range []string{"oidc", "logs"} {
+		if err := os.Mkdir(filepath.Join(dir, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	oidcConf, err := os.ReadFile("../oidc/oidc.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	js, err := os.ReadFile("../oidc/openid_connect.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string][]byte{"oidc.conf": oidcConf, "openid_connect.js": js} {
+		if err := os.WriteFile(filepath.Join(dir, "oidc", name), content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, oidcTemplate := range []string{
+		"include oidc/oidc.conf;\n",
+		string(oidcConf),
+	} {
+		e := newTmplExecutorNGINXPlus(t)
+		if err := e.UpdateOIDCTemplate(&oidcTemplate); err != nil {
+			t.Fatal(err)
+		}
+		var locations bytes.Buffer
+		if err := e.virtualServerTemplate.ExecuteTemplate(&locations, oidcTemplateName, oidcTemplateTestServer); err != nil {
+			t.Fatal(err)
+		}
+
+		// The variables of the OIDC policy are set by the server, and by the http context of the main template
+		var variables []string
+		for _, v := range regexp.MustCompile(`\$(oidc_\w+|pkce_id|redir_location|redirect_base|internal_error_message)`).FindAllString(locations.String()+string(oidcConf), -1) {
+			if !slices.Contains(variables, v) {
+				variables = append(variables, v)
+			}
+		}
+		var config strings.Builder
+		fmt.Fprintf(&config, "load_module %s/ngx_http_js_module.so;\nerror_log stderr;\nevents {}\nhttp {\n", modulesPath)
+		fmt.Fprintf(&config, "js_import oidc from %s;\n", filepath.Join(dir, "oidc", "openid_connect.js"))
+		fmt.Fprintf(&config, "proxy_cache_path %s levels=1 keys_zone=jwk:64k max_size=1m;\n", filepath.Join(dir, "jwk"))
+		for _, v := range variables {
+			fmt.Fprintf(&config, "map \"\" %s { default \"\"; }\n", v)
+		}
+		fmt.Fprintf(&config, "server {\nlisten 127.0.0.1:8080;\n%s\n}\n}\n", locations.String())
+		confFile := filepath.Join(dir, "nginx.conf")
+		if err := os.WriteFile(confFile, []byte(config.String()), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if out, err := exec.Command(nginx, "-t", "-p", dir, "-c", confFile).CombinedOutput(); err != nil {
+			t.Errorf("nginx -t failed for the OIDC template %q: %v\n%s", oidcTemplate, err, out)
+		}
+	}
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCPathPrefix(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:   "https://idp.example.com/auth",
+		TokenEndpoint:  "https://idp.example.com/token",
+		JwksURI:        "https://idp.example.com/certs",
+		ClientID:       "client",
+		ClientSecret:   "secret",
+		RedirectURI:    "/oidc/_codexch",
+		Scope:          "openid",
+		CookieSameSite: "Lax",
+		JWEKeyFile:     "/etc/nginx/secrets/default-jwe-key",
+		PathPrefix:     "/oidc",
+		LocationsFile:  "oidc/oidc-0123456789abcdef.conf",
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+	for _, want := range []string{
+		"include oidc/oidc-0123456789abcdef.conf;",
+		`set $oidc_path_prefix "/oidc";`,
+		`set $oidc_logout_redirect "/oidc/_logout";`,
+		"location = /oidc/_jwe_id_token_validation {",
+		"auth_jwt_key_request /oidc/_jwks_uri;",
+	} {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template", want)
+		}
+	}
+	if bytes.Contains(got, []byte("include oidc/oidc.conf;")) {
+		t.Errorf("want no include of oidc.conf with a path prefix")
+	}
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCRateLimit(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.LimitReqZones = []LimitReqZone{
+		{ZoneName: "oidc_rl_default_oidc-policy_default_cafe", Key: "$oidc_rate_limit_key", ZoneSize: "10m", Rate: "10r/m"},
+	}
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:   "https://idp.example.com/auth",
+		TokenEndpoint:  "https://idp.example.com/token",
+		JwksURI:        "https://idp.example.com/certs",
+		ClientID:       "client",
+		ClientSecret:   "secret",
+		RedirectURI:    "/_codexch",
+		Scope:          "openid",
+		CookieSameSite: "Lax",
+		RateLimit:      &LimitReq{ZoneName: "oidc_rl_default_oidc-policy_default_cafe", Burst: 5, NoDelay: true},
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+	for _, want := range []string{
+		"limit_req_zone $oidc_rate_limit_key zone=oidc_rl_default_oidc-policy_default_cafe:10m rate=10r/m;",
+		"limit_req zone=oidc_rl_default_oidc-policy_default_cafe burst=5 nodelay;",
+		"set $oidc_rate_limited 0;",
+	} {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template", want)
+		}
+	}
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCLoginLockout(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:   "https://idp.example.com/auth",
+		TokenEndpoint:  "https://idp.example.com/token",
+		JwksURI:        "https://idp.example.com/certs",
+		ClientID:       "client",
+		ClientSecret:   "secret",
+		RedirectURI:    "/_codexch",
+		Scope:          "openid",
+		CookieSameSite: "Lax",
+		LoginLockout:   &OIDCLoginLockout{MaxFailures: 5, Window: 600, Duration: 900, TarpitDelay: 10},
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+	for _, want := range []string{
+		"set $oidc_login_lockout_max_failures 5;",
+		"set $oidc_login_lockout_window 600;",
+		"set $oidc_login_lockout_duration 900;",
+		"set $oidc_login_lockout_tarpit_delay 10;",
+	} {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template", want)
+		}
+	}
+
+	vscfg.Server.OIDC.LoginLockout = nil
+	got, err = e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+	if !bytes.Contains(got, []byte("set $oidc_login_lockout_max_failures 0;")) {
+		t.Errorf("want the login lockout disabled without a lockout")
+	}
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCRedirectAllowlist(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:       "https://idp.example.com/auth",
+		TokenEndpoint:      "https://idp.example.com/token",
+		JwksURI:            "https://idp.example.com/certs",
+		ClientID:           "client",
+		ClientSecret:       "secret",
+		RedirectURI:        "/_codexch",
+		Scope:              "openid",
+		CookieSameSite:     "Lax",
+		LoginRedirectPaths: "/",
+		RedirectAllowlist:  "/app/ https://portal.example.com/",
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+	want := `set $oidc_redirect_allowlist "/app/ https://portal.example.com/";`
+	if !bytes.Contains(got, []byte(want)) {
+		t.Errorf("want %q in generated template", want)
+	}
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCSecurityHeaders(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:   "https://idp.example.com/auth",
+		TokenEndpoint:  "https://idp.example.com/token",
+		JwksURI:        "https://idp.example.com/certs",
+		ClientID:       "client",
+		ClientSecret:   "secret",
+		RedirectURI:    "/_codexch",
+		Scope:          "openid",
+		CookieSameSite: "Lax",
+		ErrorPages:     []OIDCErrorPage{{Name: "invalid_state", Code: 400, Body: "Invalid state"}},
+		SecurityHeaders: OIDCSecurityHeaders{
+			ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+			FrameOptions:          "DENY",
+			ReferrerPolicy:        "no-referrer",
+		},
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+	for _, want := range []string{
+		`set $oidc_content_security_policy "default-src 'none'; frame-ancestors 'none'";`,
+		`set $oidc_frame_options "DENY";`,
+		`set $oidc_referrer_policy "no-referrer";`,
+		"add_header Content-Security-Policy $oidc_content_security_policy always;",
+	} {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template", want)
+		}
+	}
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCJwksFile(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:  "https://idp.example.com/auth",
+		TokenEndpoint: "https://idp.example.com/token",
+		JwksURI:       "https://idp.example.com/certs",
+		JwksFile:      "/var/lib/nginx/oidc/jwks/default_oidc-policy.json",
+		ClientID:      "client",
+		ClientSecret:  "secret",
+		RedirectURI:   "/_codexch",
+		Scope:         "openid",
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+
+	want := `set $oidc_jwks_file "/var/lib/nginx/oidc/jwks/default_oidc-policy.json";`
+	if !bytes.Contains(got, []byte(want)) {
+		t.Errorf("want %q in generated template", want)
+	}
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCPreviousStateKey(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:     "https://idp.example.com/auth",
+		TokenEndpoint:    "https://idp.example.com/token",
+		JwksURI:          "https://idp.example.com/certs",
+		ClientID:         "client",
+		ClientSecret:     "secret",
+		RedirectURI:      "/_codexch",
+		Scope:            "openid",
+		StateKey:         "current",
+		PreviousStateKey: "previous",
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+
+	wantDirectives := []string{
+		`set $oidc_state_key "current";`,
+		`set $oidc_previous_state_key "previous";`,
+	}
+	for _, want := range wantDirectives {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template", want)
+		}
+	}
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCJWE(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:  "https://idp.example.com/auth",
+		TokenEndpoint: "https://idp.example.com/token",
+		JwksURI:       "https://idp.example.com/certs",
+		ClientID:      "client",
+		ClientSecret:  "secret",
+		RedirectURI:   "/_codexch",
+		Scope:         "openid",
+		JWEKeyFile:    "/etc/nginx/secrets/default-jwe-key-secret",
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+
+	wantDirectives := []string{
+		"set $oidc_jwe_enable 1;",
+		"location = /_jwe_id_token_validation {",
+		"auth_jwt_type nested;",
+		"auth_jwt_key_file /etc/nginx/secrets/default-jwe-key-secret;",
+	}
+	for _, want := range wantDirectives {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template", want)
+		}
+	}
+	t.Log(string(got))
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCOAuth2Adapter(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:       "https://github.com/login/oauth/authorize",
+		TokenEndpoint:      "https://github.com/login/oauth/access_token",
+		ClientID:           "client",
+		ClientSecret:       "secret",
+		RedirectURI:        "/_codexch",
+		OAuth2UserEndpoint: "https://api.github.com/user",
+	}
+	vscfg.Server.Locations = []Location{
+		{
+			Path:      "/",
+			ProxyPass: "http://test-upstream",
+			OIDC:      true,
+		},
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+
+	wantDirectives := []string{
+		`set $oidc_oauth2_user_endpoint "https://api.github.com/user";`,
+		"auth_jwt_key_request /_oauth2_session_jwks;",
+		"proxy_set_header X-Forwarded-User $jwt_claim_preferred_username;",
+		"proxy_set_header X-Forwarded-Email $jwt_claim_email;",
+	}
+	for _, want := range wantDirectives {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template", want)
+		}
+	}
+	if bytes.Contains(got, []byte("auth_jwt_key_request /_jwks_uri;")) {
+		t.Errorf("want the sessions validated with the key of the OAuth 2.0 adapter")
+	}
+	t.Log(string(got))
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCDPoP(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:      "https://idp.example.com/auth",
+		TokenEndpoint:     "https://idp.example.com/token",
+		JwksURI:           "https://idp.example.com/certs",
+		ClientID:          "client",
+		ClientSecret:      "secret",
+		RedirectURI:       "/_codexch",
+		Scope:             "openid",
+		AccessTokenEnable: true,
+		DPoPEnable:        true,
+	}
+	vscfg.Server.Locations = []Location{
+		{
+			Path:      "/",
+			ProxyPass: "http://test-upstream",
+			OIDC:      true,
+			DPoP:      true,
+		},
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+
+	wantDirectives := []string{
+		"set $oidc_dpop_enable 1;",
+		"auth_request /_oidc_dpop_proof;",
+		`proxy_set_header Authorization "$oidc_access_token_type $access_token";`,
+		"proxy_set_header DPoP $oidc_dpop_proof;",
+	}
+	for _, want := range wantDirectives {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template", want)
+		}
+	}
+	if bytes.Contains(got, []byte(`proxy_set_header Authorization "Bearer $access_token";`)) {
+		t.Errorf("want the access token passed with the DPoP proof")
+	}
+	t.Log(string(got))
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCErrorPages(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:  "https://idp.example.com/auth",
+		TokenEndpoint: "https://idp.example.com/token",
+		JwksURI:       "https://idp.example.com/certs",
+		ClientID:      "client",
+		ClientSecret:  "secret",
+		RedirectURI:   "/_codexch",
+		Scope:         "openid",
+		ErrorPages: []OIDCErrorPage{
+			{Name: "idp_unreachable", Code: 502, Body: `<p class=\"error\">Try again later ($request_id)</p>`},
+			{Name: "invalid_state", Code: 403, Body: "<p>Start the login again</p>"},
+		},
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+
+	wantDirectives := []string{
+		`set $oidc_error_pages "idp_unreachable invalid_state";`,
+		"location @oidc_error_idp_unreachable {",
+		`return 502 "<p class=\"error\">Try again later ($request_id)</p>";`,
+		"location @oidc_error_invalid_state {",
+		`return 403 "<p>Start the login again</p>";`,
+	}
+	for _, want := range wantDirectives {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template", want)
+		}
+	}
+	if bytes.Contains(got, []byte("location @oidc_error_token_validation_failure")) {
+		t.Errorf("want no error page for token validation failures")
+	}
+	t.Log(string(got))
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCCookieAndClaimRules(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:   "https://idp.example.com/auth",
+		TokenEndpoint:  "https://idp.example.com/token",
+		JwksURI:        "https://idp.example.com/certs",
+		ClientID:       "client",
+		ClientSecret:   "secret",
+		RedirectURI:    "/_codexch",
+		Scope:          "openid",
+		CookieSameSite: "Strict",
+		CookieDomain:   "example.com",
+		ClaimRules:     "W3siY2xhaW0iOiJncm91cHMiLCJ2YWx1ZXMiOlsiYWRtaW5zIl19XQ==",
+		SessionStore:   "default/oidc-policy",
+	}
+	vscfg.Server.Locations = []Location{
+		{
+			Path:      "/",
+			ProxyPass: "http://test-upstream",
+			OIDC:      true,
+		},
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+
+	wantDirectives := []string{
+		`set $oidc_cookie_flags "Path=/; Domain=example.com; SameSite=Strict;$oidc_cookie_secure_flags";`,
+		`set $oidc_claim_rules "W3siY2xhaW0iOiJncm91cHMiLCJ2YWx1ZXMiOlsiYWRtaW5zIl19XQ==";`,
+		`set $oidc_session_store "default/oidc-policy";`,
+		"auth_jwt_require $oidc_claims_allowed error=403;",
+	}
+	for _, want := range wantDirectives {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template", want)
+		}
+	}
+	t.Log(string(got))
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCSessionCookies(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:      "https://idp.example.com/auth",
+		TokenEndpoint:     "https://idp.example.com/token",
+		JwksURI:           "https://idp.example.com/certs",
+		ClientID:          "client",
+		ClientSecret:      "secret",
+		RedirectURI:       "/_codexch",
+		Scope:             "openid",
+		CookieSameSite:    "Lax",
+		SessionCookieKeys: "6b6579310000000000000000000000000000000000000000000000000000000a 6b6579320000000000000000000000000000000000000000000000000000000a",
+		PreviousKeyExpiry: 1704153600,
+	}
+	vscfg.Server.Locations = []Location{
+		{
+			Path:      "/",
+			ProxyPass: "http://test-upstream",
+			OIDC:      true,
+		},
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+
+	wantDirectives := []string{
+		`set $oidc_session_store "";`,
+		`set $oidc_session_cookie_keys "6b6579310000000000000000000000000000000000000000000000000000000a 6b6579320000000000000000000000000000000000000000000000000000000a";`,
+		`set $oidc_session_cookie_previous_key_expiry 1704153600;`,
+	}
+	for _, want := range wantDirectives {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template", want)
+		}
+	}
+	t.Log(string(got))
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCSessionLimit(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:       "https://idp.example.com/auth",
+		TokenEndpoint:      "https://idp.example.com/token",
+		JwksURI:            "https://idp.example.com/certs",
+		ClientID:           "client",
+		ClientSecret:       "secret",
+		RedirectURI:        "/_codexch",
+		Scope:              "openid",
+		CookieSameSite:     "Lax",
+		MaxSessionsPerUser: 2,
+		SessionLimitAction: "reject",
+	}
+	vscfg.Server.Locations = []Location{
+		{
+			Path:      "/",
+			ProxyPass: "http://test-upstream",
+			OIDC:      true,
+		},
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+
+	wantDirectives := []string{
+		`set $oidc_max_sessions_per_user 2;`,
+		`set $oidc_session_limit_action "reject";`,
+	}
+	for _, want := range wantDirectives {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template", want)
+		}
+	}
+	t.Log(string(got))
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCRefreshAhead(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:        "https://idp.example.com/auth",
+		TokenEndpoint:       "https://idp.example.com/token",
+		JwksURI:             "https://idp.example.com/certs",
+		ClientID:            "client",
+		ClientSecret:        "secret",
+		RedirectURI:         "/_codexch",
+		Scope:               "openid",
+		CookieSameSite:      "Lax",
+		RefreshAheadSeconds: 60,
+	}
+	vscfg.Server.Locations = []Location{
+		{
+			Path:      "/",
+			ProxyPass: "http://test-upstream",
+			OIDC:      true,
+		},
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+
+	wantDirectives := []string{
+		`set $oidc_refresh_ahead_seconds 60;`,
+		`auth_jwt_require $oidc_refresh_ahead;`,
+	}
+	for _, want := range wantDirectives {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template", want)
+		}
+	}
+
+	vscfg.Server.OIDC.RefreshAheadSeconds = 0
+	got, err = e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+	if bytes.Contains(got, []byte("auth_jwt_require $oidc_refresh_ahead;")) {
+		t.Error("want no refresh ahead in generated template when refreshAheadSeconds is 0")
+	}
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCStepUp(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:     "https://idp.example.com/auth",
+		TokenEndpoint:    "https://idp.example.com/token",
+		JwksURI:          "https://idp.example.com/certs",
+		ClientID:         "client",
+		ClientSecret:     "secret",
+		RedirectURI:      "/_codexch",
+		Scope:            "openid",
+		CookieSameSite:   "Lax",
+		RememberMeMaxAge: 2592000,
+		StepUpMaxAge:     300,
+	}
+	vscfg.Server.Locations = []Location{
+		{
+			Path:      "/",
+			ProxyPass: "http://test-upstream",
+			OIDC:      true,
+		},
+		{
+			Path:       "/payments",
+			ProxyPass:  "http://test-upstream",
+			OIDC:       true,
+			OIDCStepUp: true,
+		},
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+
+	wantDirectives := []string{
+		`set $oidc_remember_me_max_age 2592000;`,
+		`set $oidc_step_up_max_age 300;`,
+		`set $oidc_step_up 1;`,
+		`auth_jwt_require $oidc_step_up_satisfied;`,
+	}
+	for _, want := range wantDirectives {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template", want)
+		}
+	}
+	if n := bytes.Count(got, []byte("auth_jwt_require $oidc_step_up_satisfied;")); n != 1 {
+		t.Errorf("want step-up authentication only in the location with step-up, got it in %d locations", n)
+	}
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCIdPOutageBehavior(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:      "https://idp.example.com/auth",
+		TokenEndpoint:     "https://idp.example.com/token",
+		JwksURI:           "https://idp.example.com/certs",
+		ClientID:          "client",
+		ClientSecret:      "secret",
+		RedirectURI:       "/_codexch",
+		Scope:             "openid",
+		CookieSameSite:    "Lax",
+		IdPOutageBehavior: "denyAll",
+	}
+	vscfg.Server.Locations = []Location{
+		{
+			Path:      "/",
+			ProxyPass: "http://test-upstream",
+			OIDC:      true,
+		},
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+	for _, want := range []string{
+		`set $oidc_idp_outage_behavior "denyAll";`,
+		`auth_jwt_require $oidc_idp_available;`,
+	} {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template", want)
+		}
+	}
+
+	vscfg.Server.OIDC.IdPOutageBehavior = "allowExistingSessions"
+	got, err = e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+	if bytes.Contains(got, []byte("auth_jwt_require $oidc_idp_available;")) {
+		t.Error("want no check of the IdP availability in the locations of a policy that allows existing sessions")
+	}
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCLoginRedirectPaths(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:       "https://idp.example.com/auth",
+		TokenEndpoint:      "https://idp.example.com/token",
+		JwksURI:            "https://idp.example.com/certs",
+		ClientID:           "client",
+		ClientSecret:       "secret",
+		RedirectURI:        "/_codexch",
+		Scope:              "openid",
+		CookieSameSite:     "Lax",
+		LoginRedirectPaths: "/app/ /account",
+	}
+	vscfg.Server.Locations = []Location{
+		{
+			Path:      "/",
+			ProxyPass: "http://test-upstream",
+			OIDC:      true,
+		},
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+	for _, want := range []string{
+		`set $oidc_login_redirect_paths "/app/ /account";`,
+		"include oidc/oidc.conf;",
+	} {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template", want)
+		}
+	}
+
+	vscfg.Server.OIDC.LoginRedirectPaths = "/"
+	got, err = e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+	if !bytes.Contains(got, []byte(`set $oidc_login_redirect_paths "/";`)) {
+		t.Error("want the default redirect path of the logins started at /login in the generated template")
+	}
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCIntrospection(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:        "https://idp.example.com/auth",
+		TokenEndpoint:       "https://idp.example.com/token",
+		JwksURI:             "https://idp.example.com/certs",
+		ClientID:            "client",
+		ClientSecret:        "secret",
+		RedirectURI:         "/_codexch",
+		Scope:               "openid",
+		CookieSameSite:      "Lax",
+		AccessTokenEnable:   true,
+		IntrospectionEnable: true,
+	}
+	vscfg.Server.Locations = []Location{
+		{
+			Path:      "/",
+			ProxyPass: "http://test-upstream",
+			OIDC:      true,
+		},
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+	for _, want := range []string{
+		`set $oidc_introspection_enable 1;`,
+		`auth_jwt $oidc_jwt_realm token=$session_jwt;`,
+		`proxy_set_header Authorization $oidc_bearer_authorization;`,
+	} {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template", want)
+		}
+	}
+
+	vscfg.Server.OIDC.IntrospectionEnable = false
+	got, err = e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+	for _, want := range []string{
+		`set $oidc_introspection_enable 0;`,
+		`auth_jwt "" token=$session_jwt;`,
+		`proxy_set_header Authorization "Bearer $access_token";`,
+	} {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template without introspection", want)
+		}
+	}
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCForADFS(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:   "https://adfs.example.com/adfs/oauth2/authorize/",
+		TokenEndpoint:  "https://adfs.example.com/adfs/oauth2/token/",
+		JwksURI:        "https://adfs.example.com/adfs/discovery/keys",
+		ClientID:       "client",
+		ClientSecret:   "secret",
+		RedirectURI:    "/_codexch",
+		Scope:          "openid",
+		CookieSameSite: "Lax",
+		IdPType:        "adfs",
+	}
+	vscfg.Server.Locations = []Location{
+		{
+			Path:      "/",
+			ProxyPass: "http://test-upstream",
+			OIDC:      true,
+		},
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+	for _, want := range []string{
+		`proxy_set_header X-Forwarded-User $jwt_claim_upn;`,
+		`proxy_set_header X-Forwarded-User-SID $jwt_claim_primarysid;`,
+	} {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template", want)
+		}
+	}
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCIdPs(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:   "https://idp.example.com/auth",
+		TokenEndpoint:  "https://idp.example.com/token",
+		JwksURI:        "https://idp.example.com/certs",
+		ClientID:       "client",
+		ClientSecret:   "secret",
+		RedirectURI:    "/_codexch",
+		Scope:          "openid",
+		CookieSameSite: "Lax",
+		IdPs: []OIDCIdP{
+			{
+				Name:          "partners",
+				AuthEndpoint:  "https://partners.example.com/auth",
+				TokenEndpoint: "https://partners.example.com/token",
+				JwksURI:       "https://partners.example.com/keys",
+				ClientID:      "partners-client",
+				ClientSecret:  "partners-secret",
+				Scope:         "openid+email",
+				Hosts:         []string{"partners.example.com"},
+			},
+		},
+		IdPSelection: "hostname",
+	}
+	vscfg.Server.Locations = []Location{
+		{
+			Path:      "/",
+			ProxyPass: "http://test-upstream",
+			OIDC:      true,
+		},
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+	for _, want := range []string{
+		`set $oidc_idps "default partners";`,
+		`set $oidc_idp_selection "hostname";`,
+		`set $oidc_idp_email_domains "";`,
+		"if ($host = \"partners.example.com\") {\n        set $oidc_idp \"partners\";",
+		"if ($cookie_auth_idp) {\n        set $oidc_idp $cookie_auth_idp;",
+		`if ($oidc_idp = "partners") {`,
+		`set $oidc_token_endpoint "https://partners.example.com/token";`,
+		`set $oidc_jwt_keyfile "https://partners.example.com/keys";`,
+		`set $oidc_client "partners-client";`,
+		`set $oidc_client_secret "partners-secret";`,
+		`set $oidc_scopes "openid+email";`,
+	} {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template", want)
+		}
+	}
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCRoutePolicies(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:   "https://login.example.com/auth",
+		TokenEndpoint:  "https://login.example.com/token",
+		JwksURI:        "https://login.example.com/keys",
+		ClientID:       "employees",
+		ClientSecret:   "secret",
+		RedirectURI:    "/_codexch",
+		Scope:          "openid",
+		CookieSameSite: "Lax",
+		Policy:         "default/employees",
+	}
+	customers := &OIDC{
+		AuthEndpoint:      "https://customers.example.com/auth",
+		TokenEndpoint:     "https://customers.example.com/token",
+		JwksURI:           "https://customers.example.com/keys",
+		ClientID:          "portal",
+		ClientSecret:      "portal-secret",
+		RedirectURI:       "/_codexch",
+		Scope:             "openid+profile",
+		CookieSameSite:    "Strict",
+		Policy:            "default/customers",
+		CookieSuffix:      "_6e2f0c1a",
+		AccessTokenEnable: true,
+	}
+	vscfg.Server.OIDC.RoutePolicies = []*OIDC{customers}
+	vscfg.Server.Locations = []Location{
+		{
+			Path:      "/admin",
+			ProxyPass: "http://test-upstream",
+			OIDC:      true,
+		},
+		{
+			Path:      "/portal",
+			ProxyPass: "http://test-upstream",
+			OIDC:      true,
+			OIDCRoute: customers,
+		},
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+	for _, want := range []string{
+		`set $oidc_idps "default default/customers";`,
+		`if ($oidc_idp = "default/customers") {`,
+		`set $oidc_client "portal";`,
+		`set $oidc_scopes "openid+profile";`,
+		`set $oidc_cookie_suffix "_6e2f0c1a";`,
+		"set $oidc_route_idp \"default\";\n        set $oidc_cookie_suffix \"\";\n        auth_jwt_require $oidc_idp_bound;",
+		"set $oidc_route_idp \"default/customers\";\n        set $oidc_cookie_suffix \"_6e2f0c1a\";\n        auth_jwt_require $oidc_idp_bound;",
+	} {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template", want)
+		}
+	}
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCForAPIClients(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:           "https://idp.example.com/auth",
+		TokenEndpoint:          "https://idp.example.com/token",
+		JwksURI:                "https://idp.example.com/certs",
+		ClientID:               "client",
+		ClientSecret:           "secret",
+		RedirectURI:            "/_codexch",
+		Scope:                  "openid",
+		CookieSameSite:         "Lax",
+		UnauthorizedAcceptJSON: true,
+		UnauthorizedPaths:      "/api/ /graphql",
+		SessionInfoClaims:      "sub email",
+		LogoutCSRFEnable:       true,
+		TerminateOnSessionEnd:  true,
+		LogLevel:               "debug",
+		IdPType:                "azuread",
+		AllowedTenants:         "9188040d-6c67-4c5b-b112-36a304b66dad",
+	}
+	vscfg.Server.Locations = []Location{
+		{
+			Path:      "/",
+			ProxyPass: "http://test-upstream",
+			OIDC:      true,
+		},
+		{
+			Path:     "/helloworld.Greeter",
+			GRPCPass: "grpc://test-upstream",
+			OIDC:     true,
+		},
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+	for _, want := range []string{
+		`set $oidc_unauthorized_accept_json 1;`,
+		`set $oidc_unauthorized_paths "/api/ /graphql";`,
+		`set $oidc_session_info_claims "sub email";`,
+		`set $oidc_logout_csrf_enable 1;`,
+		`js_body_filter oidc.streamFilter buffer_type=buffer;`,
+		`set $oidc_log_level "debug";`,
+		`set $oidc_idp_type "azuread";`,
+		`set $oidc_allowed_tenants "9188040d-6c67-4c5b-b112-36a304b66dad";`,
+		`set $oidc_end_session_endpoint "";`,
+		`set $oidc_issuer "";`,
+		`set $oidc_google_hosted_domain "";`,
+		`set $oidc_strict 0;`,
+		`set $oidc_token_hashes_required 0;`,
+	} {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template", want)
+		}
+	}
+	if n := bytes.Count(got, []byte("set $oidc_grpc 1;")); n != 1 {
+		t.Errorf("want the gRPC mode only in the gRPC location, got it in %d locations", n)
+	}
+	if bytes.Contains(got, []byte("$oidc_idps")) {
+		t.Errorf("want no IdP selection in the generated template of a policy with one IdP")
+	}
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCResolver(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:   "https://idp.corp.example.com/auth",
+		TokenEndpoint:  "https://idp.corp.example.com/token",
+		JwksURI:        "https://idp.corp.example.com/certs",
+		ClientID:       "client",
+		ClientSecret:   "secret",
+		RedirectURI:    "/_codexch",
+		Scope:          "openid",
+		CookieSameSite: "Lax",
+		Resolver:       "10.0.0.10 [fd00::10]:5353 valid=30s ipv6=off",
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+	want := "include oidc/oidc.conf;\n    resolver 10.0.0.10 [fd00::10]:5353 valid=30s ipv6=off;"
+	if !bytes.Contains(got, []byte(want)) {
+		t.Errorf("want %q in generated template", want)
+	}
+
+	vscfg.Server.OIDC.Resolver = ""
+	got, err = e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+	if bytes.Contains(got, []byte("resolver ")) {
+		t.Errorf("want no resolver in the generated template of a policy without a resolver")
+	}
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCManagedKeys(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:    "https://idp.example.com/auth",
+		TokenEndpoint:   "https://idp.example.com/token",
+		JwksURI:         "https://idp.example.com/certs",
+		ClientID:        "client",
+		ClientSecret:    "secret",
+		RedirectURI:     "/_codexch",
+		Scope:           "openid",
+		CookieSameSite:  "Lax",
+		Policy:          "default/oidc-policy",
+		HMACKey:         "hmac-key",
+		PreviousHMACKey: "previous-hmac-key",
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+	for _, want := range []string{
+		`set $oidc_hmac_key "hmac-key";`,
+		`set $oidc_previous_hmac_key "previous-hmac-key";`,
+	} {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template", want)
+		}
+	}
+
+	// the nonces of the logins started before the switch to the managed keys are hashed with the server name
+	vscfg.Server.OIDC.PreviousHMACKey = ""
+	got, err = e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+	want := fmt.Sprintf(`set $oidc_previous_hmac_key "%s";`, vscfg.Server.VSName)
+	if !bytes.Contains(got, []byte(want)) {
+		t.Errorf("want %q in the generated template of managed keys without previous keys", want)
+	}
+
+	vscfg.Server.OIDC.HMACKey = ""
+	got, err = e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+	for _, want := range []string{
+		fmt.Sprintf(`set $oidc_hmac_key "%s";`, vscfg.Server.VSName),
+		`set $oidc_previous_hmac_key "";`,
+	} {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in the generated template of a policy without managed keys", want)
+		}
+	}
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCHTTPProxy(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:   "https://idp.example.com/auth",
+		TokenEndpoint:  "https://idp.example.com/token",
+		JwksURI:        "https://idp.example.com/certs",
+		ClientID:       "client",
+		ClientSecret:   "secret",
+		RedirectURI:    "/_codexch",
+		Scope:          "openid",
+		CookieSameSite: "Lax",
+		Policy:         "default/oidc-policy",
+		HTTPProxy:      "http://proxy.corp.example.com:3128",
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+	want := `set $oidc_egress_prefix "http://oidc_session_store/egress/default/oidc-policy/";`
+	if !bytes.Contains(got, []byte(want)) {
+		t.Errorf("want %q in generated template", want)
+	}
+	if bytes.Contains(got, []byte("proxy.corp.example.com")) {
+		t.Errorf("want no forward proxy in the generated template")
+	}
+
+	vscfg.Server.OIDC.HTTPProxy = ""
+	got, err = e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+	want = `set $oidc_egress_prefix "";`
+	if !bytes.Contains(got, []byte(want)) {
+		t.Errorf("want %q in the generated template of a policy without a forward proxy", want)
+	}
+
+	vscfg.Server.OIDC.RequestHeaders = []Header{{Name: "X-API-Key", Value: "api-key"}}
+	got, err = e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+	want = `set $oidc_egress_prefix "http://oidc_session_store/egress/default/oidc-policy/";`
+	if !bytes.Contains(got, []byte(want)) {
+		t.Errorf("want %q in the generated template of a policy with request headers", want)
+	}
+	if bytes.Contains(got, []byte("api-key")) {
+		t.Errorf("want no request headers in the generated template")
+	}
+}
+
+func TestExecuteVirtualServerTemplateWithOIDCTracing(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.OIDC = &OIDC{
+		AuthEndpoint:   "https://idp.example.com/auth",
+		TokenEndpoint:  "https://idp.example.com/token",
+		JwksURI:        "https://idp.example.com/certs",
+		ClientID:       "client",
+		ClientSecret:   "secret",
+		RedirectURI:    "/_codexch",
+		Scope:          "openid",
+		CookieSameSite: "Lax",
+		Tracing:        true,
+		LocationsFile:  "oidc/oidc-tracing.conf",
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+	// the spans are tagged in the locations of the copy of oidc.conf, not in the server
+	if bytes.Contains(got, []byte("opentracing_tag")) {
+		t.Errorf("want no opentracing_tag in the server of generated template")
+	}
+	want := []byte("include oidc/oidc-tracing.conf;")
+	if !bytes.Contains(got, want) {
+		t.Errorf("want %q in generated template", want)
+	}
+}
+
+func TestExecuteVirtualServerTemplateWithClientCredentials(t *testing.T) {
+	t.Parallel()
+
+	vscfg := vsConfig()
+	vscfg.Server.ClientCredentialsEnabled = true
+	vscfg.Server.Locations = []Location{
+		{
+			Path:      "/",
+			ProxyPass: "http://test-upstream",
+			ClientCredentials: &ClientCredentials{
+				Key:           "default/client-credentials-policy",
+				TokenEndpoint: "https://idp.example.com/token",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				Scope:         "orders.read",
+			},
+		},
+	}
+
+	e := newTmplExecutorNGINXPlus(t)
+	got, err := e.ExecuteVirtualServerTemplate(&vscfg)
+	if err != nil {
+		t.Error(err)
+	}
+
+	wantDirectives := []string{
+		"include oidc/client_credentials.conf;",
+		`set $client_credentials_key "default/client-credentials-policy";`,
+		`set $client_credentials_token_endpoint "https://idp.example.com/token";`,
+		`set $client_credentials_scope "orders.read";`,
+		"auth_request /_client_credentials;",
+		`proxy_set_header Authorization "Bearer $client_credentials_access_token";`,
+	}
+	for _, want := range wantDirectives {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("want %q in generated template", want)
+		}
+	}
+	t.Log(string(got))
+}
+
 func TestExecuteVirtualServerTemplateWithBackupServerNGINXPlus(t *testing.T) {
 	t.Parallel()
 
@@ -1,15 +1,21 @@
 package configs
 
 import (
+	"crypto/hmac"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"os"
 	"path"
+	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/golang/glog"
 	"github.com/nginxinc/kubernetes-ingress/internal/configs/version2"
@@ -91,14 +97,34 @@ type VirtualServerEx struct {
 	VirtualServerRoutes []*conf_v1.VirtualServerRoute
 	ExternalNameSvcs    map[string]bool
 	Policies            map[string]*conf_v1.Policy
+	SelectedPolicies    []conf_v1.PolicyReference
 	PodsByIP            map[string]PodInfo
 	SecretRefs          map[string]*secrets.SecretReference
+	ConfigMapRefs       map[string]*api_v1.ConfigMap
+	OIDCProviders       map[string]*OIDCProvider
 	ApPolRefs           map[string]*unstructured.Unstructured
 	LogConfRefs         map[string]*unstructured.Unstructured
 	DosProtectedRefs    map[string]*unstructured.Unstructured
 	DosProtectedEx      map[string]*DosEx
 }
 
+// OIDCProvider holds the state of an OIDC policy kept by the Ingress Controller.
+type OIDCProvider struct {
+	// JwksURI is the jwks_uri of the discovery document.
+	JwksURI string
+	// RevocationEndpoint is the revocation_endpoint of the discovery document.
+	RevocationEndpoint string
+	// EndSessionEndpoint is the end_session_endpoint of the discovery document.
+	EndSessionEndpoint string
+	// UserinfoEndpoint is the userinfo_endpoint of the discovery document.
+	UserinfoEndpoint string
+	// JwksFile is the file where the Ingress Controller writes the JWK Set.
+	JwksFile string
+	// PreviousSecret is the previous version of the rotated client secret, until the logins started before
+	// the rotation expired.
+	PreviousSecret *api_v1.Secret
+}
+
 func (vsx *VirtualServerEx) String() string {
 	if vsx == nil {
 		return "<nil>"
@@ -289,8 +315,9 @@ type virtualServerConfigurator struct {
 }
 
 type oidcPolicyCfg struct {
-	oidc *version2.OIDC
-	key  string
+	oidc        *version2.OIDC
+	key         string
+	openTracing bool
 }
 
 func (vsc *virtualServerConfigurator) addWarningf(obj runtime.Object, msgFmt string, args ...interface{}) {
@@ -329,7 +356,7 @@ func newVirtualServerConfigurator(
 		warnings:                   make(map[runtime.Object][]string),
 		spiffeCerts:                staticParams.NginxServiceMesh,
 		enableInternalRoutes:       staticParams.EnableInternalRoutes,
-		oidcPolCfg:                 &oidcPolicyCfg{},
+		oidcPolCfg:                 &oidcPolicyCfg{openTracing: cfgParams.MainOpenTracingEnabled},
 		isIPV6Disabled:             staticParams.DisableIPV6,
 		DynamicSSLReloadEnabled:    staticParams.DynamicSSLReload,
 		StaticSSLPath:              staticParams.StaticSSLPath,
@@ -404,9 +431,12 @@ func (vsc *virtualServerConfigurator) GenerateVirtualServerConfig(
 	tlsRedirectConfig := generateTLSRedirectConfig(vsEx.VirtualServer.Spec.TLS)
 
 	policyOpts := policyOptions{
-		tls:         sslConfig != nil,
-		secretRefs:  vsEx.SecretRefs,
-		apResources: apResources,
+		tls:           sslConfig != nil,
+		secretRefs:    vsEx.SecretRefs,
+		configMapRefs: vsEx.ConfigMapRefs,
+		oidcProviders: vsEx.OIDCProviders,
+		apResources:   apResources,
+		routePaths:    virtualServerRoutePaths(vsEx),
 	}
 
 	ownerDetails := policyOwnerDetails{
@@ -415,7 +445,9 @@ func (vsc *virtualServerConfigurator) GenerateVirtualServerConfig(
 		vsNamespace:    vsEx.VirtualServer.Namespace,
 		vsName:         vsEx.VirtualServer.Name,
 	}
-	policiesCfg := vsc.generatePolicies(ownerDetails, vsEx.VirtualServer.Spec.Policies, vsEx.Policies, specContext, policyOpts)
+	// the policies that select the VirtualServer by its labels apply like the policies referenced in the spec
+	specPolicies := slices.Concat(vsEx.VirtualServer.Spec.Policies, vsEx.SelectedPolicies)
+	policiesCfg := vsc.generatePolicies(ownerDetails, specPolicies, vsEx.Policies, specContext, policyOpts)
 
 	if policiesCfg.JWKSAuthEnabled {
 		jwtAuthKey := policiesCfg.JWTAuth.Key
@@ -576,6 +608,10 @@ func (vsc *virtualServerConfigurator) GenerateVirtualServerConfig(
 		if policiesCfg.OIDC {
 			routePoliciesCfg.OIDC = policiesCfg.OIDC
 		}
+		vsc.addClientCredentialsToRoute(ownerDetails.owner, &policiesCfg, &routePoliciesCfg)
+		vsc.addTokenExchangeToRoute(ownerDetails.owner, r, &policiesCfg, &routePoliciesCfg)
+		vsc.addStepUpToRoute(ownerDetails.owner, r, &routePoliciesCfg)
+		vsc.addDPoPToRoute(ownerDetails.owner, r.Path, &policiesCfg, &routePoliciesCfg)
 		if routePoliciesCfg.JWKSAuthEnabled {
 			policiesCfg.JWKSAuthEnabled = routePoliciesCfg.JWKSAuthEnabled
 
@@ -598,6 +634,12 @@ func (vsc *virtualServerConfigurator) GenerateVirtualServerConfig(
 				policiesCfg.APIKeyClientMap[apiMapName] = routePoliciesCfg.APIKeyClients
 			}
 		}
+		for variable, m := range routePoliciesCfg.JWTClaimRuleMaps {
+			if policiesCfg.JWTClaimRuleMaps == nil {
+				policiesCfg.JWTClaimRuleMaps = make(map[string]version2.Map)
+			}
+			policiesCfg.JWTClaimRuleMaps[variable] = m
+		}
 		limitReqZones = append(limitReqZones, routePoliciesCfg.LimitReqZones...)
 
 		dosRouteCfg := generateDosCfg(dosResources[r.Path])
@@ -716,6 +758,10 @@ func (vsc *virtualServerConfigurator) GenerateVirtualServerConfig(
 			if policiesCfg.OIDC {
 				routePoliciesCfg.OIDC = policiesCfg.OIDC
 			}
+			vsc.addClientCredentialsToRoute(ownerDetails.owner, &policiesCfg, &routePoliciesCfg)
+			vsc.addTokenExchangeToRoute(ownerDetails.owner, r, &policiesCfg, &routePoliciesCfg)
+			vsc.addStepUpToRoute(ownerDetails.owner, r, &routePoliciesCfg)
+			vsc.addDPoPToRoute(ownerDetails.owner, r.Path, &policiesCfg, &routePoliciesCfg)
 			if routePoliciesCfg.JWKSAuthEnabled {
 				policiesCfg.JWKSAuthEnabled = routePoliciesCfg.JWKSAuthEnabled
 
@@ -738,6 +784,12 @@ func (vsc *virtualServerConfigurator) GenerateVirtualServerConfig(
 					policiesCfg.APIKeyClientMap[apiMapName] = routePoliciesCfg.APIKeyClients
 				}
 			}
+			for variable, m := range routePoliciesCfg.JWTClaimRuleMaps {
+				if policiesCfg.JWTClaimRuleMaps == nil {
+					policiesCfg.JWTClaimRuleMaps = make(map[string]version2.Map)
+				}
+				policiesCfg.JWTClaimRuleMaps[variable] = m
+			}
 
 			limitReqZones = append(limitReqZones, routePoliciesCfg.LimitReqZones...)
 
@@ -810,6 +862,15 @@ func (vsc *virtualServerConfigurator) GenerateVirtualServerConfig(
 		maps = append(maps, *generateAPIKeyClientMap(mapName, apiKeyClients))
 	}
 
+	claimRuleVariables := make([]string, 0, len(policiesCfg.JWTClaimRuleMaps))
+	for variable := range policiesCfg.JWTClaimRuleMaps {
+		claimRuleVariables = append(claimRuleVariables, variable)
+	}
+	sort.Strings(claimRuleVariables)
+	for _, variable := range claimRuleVariables {
+		maps = append(maps, policiesCfg.JWTClaimRuleMaps[variable])
+	}
+
 	httpSnippets := generateSnippets(vsc.enableSnippets, vsEx.VirtualServer.Spec.HTTPSnippets, []string{})
 	serverSnippets := generateSnippets(
 		vsc.enableSnippets,
@@ -861,7 +922,9 @@ func (vsc *virtualServerConfigurator) GenerateVirtualServerConfig(
 			EgressMTLS:                policiesCfg.EgressMTLS,
 			APIKey:                    policiesCfg.APIKey,
 			APIKeyEnabled:             policiesCfg.APIKeyEnabled,
+			ClientCredentialsEnabled:  policiesCfg.ClientCredentialsEnabled,
 			OIDC:                      vsc.oidcPolCfg.oidc,
+			IdentityAuthMethod:        identityAuthMethod(policiesCfg),
 			WAF:                       policiesCfg.WAF,
 			Dos:                       dosCfg,
 			PoliciesErrorReturn:       policiesCfg.ErrorReturn,
@@ -882,25 +945,32 @@ func (vsc *virtualServerConfigurator) GenerateVirtualServerConfig(
 }
 
 type policiesCfg struct {
-	Allow           []string
-	Deny            []string
-	LimitReqOptions version2.LimitReqOptions
-	LimitReqZones   []version2.LimitReqZone
-	LimitReqs       []version2.LimitReq
-	JWTAuth         *version2.JWTAuth
-	JWTAuthList     map[string]*version2.JWTAuth
-	JWKSAuthEnabled bool
-	BasicAuth       *version2.BasicAuth
-	IngressMTLS     *version2.IngressMTLS
-	EgressMTLS      *version2.EgressMTLS
-	OIDC            bool
-	APIKeyEnabled   bool
-	APIKey          *version2.APIKey
-	APIKeyClients   []apiKeyClient
-	APIKeyClientMap map[string][]apiKeyClient
-	WAF             *version2.WAF
-	ErrorReturn     *version2.Return
-	BundleValidator bundleValidator
+	Allow                    []string
+	Deny                     []string
+	LimitReqOptions          version2.LimitReqOptions
+	LimitReqZones            []version2.LimitReqZone
+	LimitReqs                []version2.LimitReq
+	JWTAuth                  *version2.JWTAuth
+	JWTAuthList              map[string]*version2.JWTAuth
+	JWKSAuthEnabled          bool
+	BasicAuth                *version2.BasicAuth
+	IngressMTLS              *version2.IngressMTLS
+	EgressMTLS               *version2.EgressMTLS
+	OIDC                     bool
+	APIKeyEnabled            bool
+	APIKey                   *version2.APIKey
+	APIKeyClients            []apiKeyClient
+	APIKeyClientMap          map[string][]apiKeyClient
+	JWTClaimRuleMaps         map[string]version2.Map
+	ClientCredentials        *version2.ClientCredentials
+	ClientCredentialsEnabled bool
+	TokenExchangeAudience    string
+	DPoP                     bool
+	OIDCStepUp               bool
+	OIDCRoute                *version2.OIDC
+	WAF                      *version2.WAF
+	ErrorReturn              *version2.Return
+	BundleValidator          bundleValidator
 }
 
 type bundleValidator interface {
@@ -943,9 +1013,12 @@ type policyOwnerDetails struct {
 }
 
 type policyOptions struct {
-	tls         bool
-	secretRefs  map[string]*secrets.SecretReference
-	apResources *appProtectResourcesForVS
+	tls           bool
+	secretRefs    map[string]*secrets.SecretReference
+	configMapRefs map[string]*api_v1.ConfigMap
+	oidcProviders map[string]*OIDCProvider
+	apResources   *appProtectResourcesForVS
+	routePaths    []string
 }
 
 type validationResults struct {
@@ -1042,6 +1115,8 @@ func (p *policiesCfg) addJWTAuthConfig(
 	jwtAuth *conf_v1.JWTAuth,
 	polKey string,
 	polNamespace string,
+	vsNamespace string,
+	vsName string,
 	secretRefs map[string]*secrets.SecretReference,
 ) *validationResults {
 	res := newValidationResults()
@@ -1049,6 +1124,7 @@ func (p *policiesCfg) addJWTAuthConfig(
 		res.addWarningf("Multiple jwt policies in the same context is not valid. JWT policy %s will be ignored", polKey)
 		return res
 	}
+	claimRules := p.addJWTClaimRuleMaps(jwtAuth.ClaimRules, polKey, vsNamespace, vsName)
 	if jwtAuth.Secret != "" {
 		jwtSecretKey := fmt.Sprintf("%v/%v", polNamespace, jwtAuth.Secret)
 		secretRef := secretRefs[jwtSecretKey]
@@ -1067,9 +1143,10 @@ func (p *policiesCfg) addJWTAuthConfig(
 		}
 
 		p.JWTAuth = &version2.JWTAuth{
-			Secret: secretRef.Path,
-			Realm:  jwtAuth.Realm,
-			Token:  jwtAuth.Token,
+			Secret:     secretRef.Path,
+			Realm:      jwtAuth.Realm,
+			Token:      jwtAuth.Token,
+			ClaimRules: claimRules,
 		}
 		return res
 	} else if jwtAuth.JwksURI != "" {
@@ -1083,11 +1160,12 @@ func (p *policiesCfg) addJWTAuthConfig(
 		}
 
 		p.JWTAuth = &version2.JWTAuth{
-			Key:      polKey,
-			JwksURI:  *JwksURI,
-			Realm:    jwtAuth.Realm,
-			Token:    jwtAuth.Token,
-			KeyCache: jwtAuth.KeyCache,
+			Key:        polKey,
+			JwksURI:    *JwksURI,
+			Realm:      jwtAuth.Realm,
+			Token:      jwtAuth.Token,
+			KeyCache:   jwtAuth.KeyCache,
+			ClaimRules: claimRules,
 		}
 		p.JWKSAuthEnabled = true
 		return res
@@ -1095,6 +1173,34 @@ func (p *policiesCfg) addJWTAuthConfig(
 	return res
 }
 
+// addJWTClaimRuleMaps adds a map per claim rule of the JWT policy that evaluates to 1 when the claim of the JWT has
+// one of the values of the rule, and returns the variables of the maps for the auth_jwt_require directives.
+func (p *policiesCfg) addJWTClaimRuleMaps(rules []conf_v1.ClaimRule, polKey string, vsNamespace string, vsName string) []string {
+	var variables []string
+	for i, rule := range rules {
+		variable := fmt.Sprintf("jwt_claim_rule_%s_%s_%s_%d",
+			rfc1123ToSnake(vsNamespace), rfc1123ToSnake(vsName), rfc1123ToSnake(strings.ReplaceAll(polKey, "/", "_")), i)
+		values := make([]string, len(rule.Values))
+		for j, v := range rule.Values {
+			values[j] = regexp.QuoteMeta(v)
+		}
+		if p.JWTClaimRuleMaps == nil {
+			p.JWTClaimRuleMaps = make(map[string]version2.Map)
+		}
+		// the claims with an array value are comma-separated in the $jwt_claim_ variables
+		p.JWTClaimRuleMaps[variable] = version2.Map{
+			Source:   "$jwt_claim_" + rule.Claim,
+			Variable: "$" + variable,
+			Parameters: []version2.Parameter{
+				{Value: "default", Result: `""`},
+				{Value: fmt.Sprintf(`"~(^|,)(%s)(,|$)"`, strings.Join(values, "|")), Result: "1"},
+			},
+		}
+		variables = append(variables, variable)
+	}
+	return variables
+}
+
 func (p *policiesCfg) addIngressMTLSConfig(
 	ingressMTLS *conf_v1.IngressMTLS,
 	polKey string,
@@ -1260,6 +1366,8 @@ func (p *policiesCfg) addOIDCConfig(
 	polKey string,
 	polNamespace string,
 	secretRefs map[string]*secrets.SecretReference,
+	configMapRefs map[string]*api_v1.ConfigMap,
+	oidcProviders map[string]*OIDCProvider,
 	oidcPolCfg *oidcPolicyCfg,
 ) *validationResults {
 	res := newValidationResults()
@@ -1273,16 +1381,10 @@ func (p *policiesCfg) addOIDCConfig(
 
 	if oidcPolCfg.oidc != nil {
 		if oidcPolCfg.key != polKey {
-			res.addWarningf(
-				"Only one oidc policy is allowed in a VirtualServer and its VirtualServerRoutes. Can't use %s. Use %s",
-				polKey,
-				oidcPolCfg.key,
-			)
-			res.isError = true
-			return res
+			return p.addRouteOIDCPolicy(oidc, polKey, polNamespace, secretRefs, configMapRefs, oidcProviders, oidcPolCfg)
 		}
 	} else {
-		secretKey := fmt.Sprintf("%v/%v", polNamespace, oidc.ClientSecret)
+		secretKey := secrets.GetReferenceKey(polNamespace, oidc.ClientSecret)
 		secretRef := secretRefs[secretKey]
 
 		var secretType api_v1.SecretType
@@ -1298,33 +1400,359 @@ func (p *policiesCfg) addOIDCConfig(
 			res.isError = true
 			return res
 		}
+		if strings.Contains(oidc.ClientSecret, "/") {
+			if err := secrets.ValidateReferenceGrant(secretRef.Secret, polNamespace); err != nil {
+				res.addWarningf("OIDC policy %s can't reference the secret %s: %v", polKey, secretKey, err)
+				res.isError = true
+				return res
+			}
+		}
 
 		clientSecret := secretRef.Secret.Data[ClientSecretKey]
 
+		var idps []version2.OIDCIdP
+		var idpEmailDomains []string
+		for _, idp := range oidc.IdPs {
+			idpSecretKey := secrets.GetReferenceKey(polNamespace, idp.ClientSecret)
+			idpSecretRef := secretRefs[idpSecretKey]
+
+			var idpSecretType api_v1.SecretType
+			if idpSecretRef.Secret != nil {
+				idpSecretType = idpSecretRef.Secret.Type
+			}
+			if idpSecretType != "" && idpSecretType != secrets.SecretTypeOIDC {
+				res.addWarningf("OIDC policy %s references a secret %s of IdP %s of a wrong type '%s', must be '%s'", polKey, idpSecretKey, idp.Name, idpSecretType, secrets.SecretTypeOIDC)
+				res.isError = true
+				return res
+			} else if idpSecretRef.Error != nil {
+				res.addWarningf("OIDC policy %s references an invalid secret %s of IdP %s: %v", polKey, idpSecretKey, idp.Name, idpSecretRef.Error)
+				res.isError = true
+				return res
+			}
+			if strings.Contains(idp.ClientSecret, "/") {
+				if err := secrets.ValidateReferenceGrant(idpSecretRef.Secret, polNamespace); err != nil {
+					res.addWarningf("OIDC policy %s can't reference the secret %s of IdP %s: %v", polKey, idpSecretKey, idp.Name, err)
+					res.isError = true
+					return res
+				}
+			}
+			idps = append(idps, version2.OIDCIdP{
+				Name:               idp.Name,
+				AuthEndpoint:       idp.AuthEndpoint,
+				TokenEndpoint:      idp.TokenEndpoint,
+				JwksURI:            idp.JWKSURI,
+				ClientID:           idp.ClientID,
+				ClientSecret:       string(idpSecretRef.Secret.Data[ClientSecretKey]),
+				Scope:              generateString(idp.Scope, "openid"),
+				EndSessionEndpoint: idp.EndSessionEndpoint,
+				Hosts:              idp.Hosts,
+			})
+			for _, domain := range idp.EmailDomains {
+				idpEmailDomains = append(idpEmailDomains, domain+"="+idp.Name)
+			}
+		}
+		idpSelection, idpSelectionVariable := generateOIDCIdPSelection(oidc)
+
+		var jarKeyFile string
+		if oidc.JAREnable {
+			jarSecretKey := fmt.Sprintf("%v/%v", polNamespace, oidc.JARKeySecret)
+			jarSecretRef := secretRefs[jarSecretKey]
+
+			var jarSecretType api_v1.SecretType
+			if jarSecretRef.Secret != nil {
+				jarSecretType = jarSecretRef.Secret.Type
+			}
+			if jarSecretType != "" && jarSecretType != secrets.SecretTypeJWK {
+				res.addWarningf("OIDC policy %s references a JAR key secret %s of a wrong type '%s', must be '%s'", polKey, jarSecretKey, jarSecretType, secrets.SecretTypeJWK)
+				res.isError = true
+				return res
+			} else if jarSecretRef.Error != nil {
+				res.addWarningf("OIDC policy %s references an invalid JAR key secret %s: %v", polKey, jarSecretKey, jarSecretRef.Error)
+				res.isError = true
+				return res
+			}
+			jarKeyFile = jarSecretRef.Path
+		}
+
+		var jweKeyFile string
+		if oidc.JWEKeySecret != "" {
+			jweSecretKey := fmt.Sprintf("%v/%v", polNamespace, oidc.JWEKeySecret)
+			jweSecretRef := secretRefs[jweSecretKey]
+
+			var jweSecretType api_v1.SecretType
+			if jweSecretRef.Secret != nil {
+				jweSecretType = jweSecretRef.Secret.Type
+			}
+			if jweSecretType != "" && jweSecretType != secrets.SecretTypeJWK {
+				res.addWarningf("OIDC policy %s references a JWE key secret %s of a wrong type '%s', must be '%s'", polKey, jweSecretKey, jweSecretType, secrets.SecretTypeJWK)
+				res.isError = true
+				return res
+			} else if jweSecretRef.Error != nil {
+				res.addWarningf("OIDC policy %s references an invalid JWE key secret %s: %v", polKey, jweSecretKey, jweSecretRef.Error)
+				res.isError = true
+				return res
+			}
+			jweKeyFile = jweSecretRef.Path
+		}
+
+		managedKeys := oidcManagedKeys(oidc, polKey, secretRefs)
+		var sessionCookieKeys string
+		var sessionKeySecret *api_v1.Secret
+		if oidc.SessionStore != nil && oidc.SessionStore.Type == "cookie" && (oidc.SessionStore.Cookie == nil || oidc.SessionStore.Cookie.KeySecret == "") {
+			if managedKeys == nil {
+				res.addWarningf("OIDC policy %s waits for the Ingress Controller to generate its managed keys", polKey)
+				res.isError = true
+				return res
+			}
+			sessionCookieKeys = generateOIDCSessionCookieKeys(managedKeys)
+			sessionKeySecret = managedKeys
+		} else if oidc.SessionStore != nil && oidc.SessionStore.Type == "cookie" {
+			keySecretKey := fmt.Sprintf("%v/%v", polNamespace, oidc.SessionStore.Cookie.KeySecret)
+			keySecretRef := secretRefs[keySecretKey]
+
+			var keySecretType api_v1.SecretType
+			if keySecretRef.Secret != nil {
+				keySecretType = keySecretRef.Secret.Type
+			}
+			if keySecretType != "" && keySecretType != secrets.SecretTypeOIDCSessionKey {
+				res.addWarningf("OIDC policy %s references a session key secret %s of a wrong type '%s', must be '%s'", polKey, keySecretKey, keySecretType, secrets.SecretTypeOIDCSessionKey)
+				res.isError = true
+				return res
+			} else if keySecretRef.Error != nil {
+				res.addWarningf("OIDC policy %s references an invalid session key secret %s: %v", polKey, keySecretKey, keySecretRef.Error)
+				res.isError = true
+				return res
+			}
+			sessionCookieKeys = generateOIDCSessionCookieKeys(keySecretRef.Secret)
+			sessionKeySecret = keySecretRef.Secret
+		}
+		var previousSessionKeyExpiry int64
+		if sessionKeySecret != nil && oidc.SessionStore.Cookie != nil && oidc.SessionStore.Cookie.PreviousKeyLifetime != "" {
+			var ok bool
+			previousSessionKeyExpiry, ok = generateOIDCPreviousSessionKeyExpiry(sessionKeySecret, oidc.SessionStore.Cookie.PreviousKeyLifetime)
+			if !ok {
+				res.addWarningf("OIDC policy %s has a previousKeyLifetime, but the session key secret %s/%s has no valid %s annotation, the previous key decrypts the session cookies until it is removed",
+					polKey, sessionKeySecret.Namespace, sessionKeySecret.Name, secrets.OIDCKeysRotatedAtAnnotation)
+			}
+		}
+
+		var errorPages []version2.OIDCErrorPage
+		if oidc.ErrorPages != "" {
+			configMapKey := fmt.Sprintf("%v/%v", polNamespace, oidc.ErrorPages)
+			if configMap, exists := configMapRefs[configMapKey]; exists {
+				errorPages = generateOIDCErrorPages(configMap)
+			} else {
+				res.addWarningf("OIDC policy %s references a ConfigMap %s that does not exist, the default error pages are used", polKey, configMapKey)
+			}
+		}
+
+		jwksURI := oidc.JWKSURI
+		revocationEndpoint := oidc.RevocationEndpoint
+		var endSessionEndpoint, userinfoEndpoint string
+		if realm := KeycloakRealm(oidc); realm != "" {
+			if jwksURI == "" {
+				jwksURI = realm + "/protocol/openid-connect/certs"
+			}
+			endSessionEndpoint = realm + "/protocol/openid-connect/logout"
+			userinfoEndpoint = realm + "/protocol/openid-connect/userinfo"
+		}
+		issuer := OktaIssuer(oidc)
+		if oidc.Issuer != "" {
+			issuer = oidc.Issuer
+		}
+		if issuer != "" && jwksURI == "" {
+			jwksURI = strings.TrimSuffix(oidc.AuthEndpoint, "/authorize") + "/keys"
+		}
+		if base := ADFSBase(oidc); base != "" {
+			if jwksURI == "" {
+				jwksURI = base + "/discovery/keys"
+			}
+			endSessionEndpoint = base + "/oauth2/logout"
+			userinfoEndpoint = base + "/userinfo"
+		}
+		if base := PingFederateBase(oidc); base != "" {
+			if jwksURI == "" {
+				jwksURI = base + "/pf/JWKS"
+			}
+			endSessionEndpoint = base + "/idp/startSLO.ping"
+			userinfoEndpoint = base + "/idp/userinfo.openid"
+		}
+		if realm := ForgeRockRealm(oidc); realm != "" {
+			if jwksURI == "" {
+				jwksURI = realm + "/connect/jwk_uri"
+			}
+			endSessionEndpoint = realm + "/connect/endSession"
+			userinfoEndpoint = realm + "/userinfo"
+		}
+		cognitoDomain := CognitoDomain(oidc)
+		if cognitoDomain != "" {
+			userinfoEndpoint = cognitoDomain + "/oauth2/userInfo"
+		}
+		auth0Domain := Auth0Domain(oidc)
+		if auth0Domain != "" {
+			if jwksURI == "" {
+				jwksURI = auth0Domain + "/.well-known/jwks.json"
+			}
+			userinfoEndpoint = auth0Domain + "/userinfo"
+		}
+		stateKey := generateOIDCStateKey(secretRef.Secret)
+		var jwksFile, previousStateKey string
+		if provider, exists := oidcProviders[polKey]; exists {
+			if provider.JwksURI != "" {
+				jwksURI = provider.JwksURI
+			}
+			if revocationEndpoint == "" {
+				revocationEndpoint = provider.RevocationEndpoint
+			}
+			if endSessionEndpoint != "" && provider.EndSessionEndpoint != "" {
+				endSessionEndpoint = provider.EndSessionEndpoint
+			}
+			if userinfoEndpoint != "" && provider.UserinfoEndpoint != "" {
+				userinfoEndpoint = provider.UserinfoEndpoint
+			}
+			jwksFile = provider.JwksFile
+			if provider.PreviousSecret != nil {
+				previousStateKey = generateOIDCStateKey(provider.PreviousSecret)
+			}
+		}
+		var hmacKey, previousHMACKey string
+		if managedKeys != nil {
+			// The logins started with the derived state key complete after the switch to the managed keys
+			if previous, exists := managedKeys.Data[secrets.OIDCPreviousStateKey]; exists {
+				previousStateKey = string(previous)
+			} else {
+				previousStateKey = stateKey
+			}
+			stateKey = string(managedKeys.Data[secrets.OIDCStateKey])
+			hmacKey = string(managedKeys.Data[secrets.OIDCHMACKey])
+			previousHMACKey = string(managedKeys.Data[secrets.OIDCPreviousHMACKey])
+		}
+		if previousStateKey == stateKey {
+			previousStateKey = ""
+		}
+		// The logout endpoints of Cognito and Auth0 aren't RP-Initiated Logout endpoints: the discovery document of
+		// a user pool has no end_session_endpoint, the one of an Auth0 tenant is the endpoint of another logout
+		if cognitoDomain != "" {
+			endSessionEndpoint = cognitoDomain + "/logout"
+		}
+		if auth0Domain != "" {
+			endSessionEndpoint = auth0Domain + "/v2/logout"
+		}
+
 		redirectURI := oidc.RedirectURI
 		if redirectURI == "" {
-			redirectURI = "/_codexch"
+			redirectURI = oidc.PathPrefix + "/_codexch"
 		}
 		scope := oidc.Scope
-		if scope == "" {
+		if scope == "" && oidc.OAuth2UserEndpoint == "" {
 			scope = "openid"
 		}
 		authExtraArgs := ""
 		if oidc.AuthExtraArgs != nil {
 			authExtraArgs = strings.Join(oidc.AuthExtraArgs, "&")
 		}
+		// hd only preselects the accounts of the domain at Google, the hd claim of the ID tokens is checked too
+		googleHostedDomain := ""
+		if oidc.Google != nil && oidc.Google.HostedDomain != "" {
+			googleHostedDomain = oidc.Google.HostedDomain
+			if authExtraArgs != "" {
+				authExtraArgs += "&"
+			}
+			authExtraArgs += "hd=" + googleHostedDomain
+		}
+		sessionStore := ""
+		if oidc.SessionStore != nil && oidc.SessionStore.Type == "redis" {
+			sessionStore = polKey
+		}
+		sessionInfoClaims := "sub name email"
+		if len(oidc.SessionInfoClaims) > 0 {
+			sessionInfoClaims = strings.Join(oidc.SessionInfoClaims, " ")
+		}
+		loginRedirectPaths := "/"
+		if len(oidc.LoginRedirectPaths) > 0 {
+			loginRedirectPaths = strings.Join(oidc.LoginRedirectPaths, " ")
+		}
+		sessionLimitAction := ""
+		if oidc.MaxSessionsPerUser > 0 {
+			sessionLimitAction = generateString(oidc.SessionLimitAction, "evict")
+		}
 
 		oidcPolCfg.oidc = &version2.OIDC{
-			AuthEndpoint:      oidc.AuthEndpoint,
-			AuthExtraArgs:     authExtraArgs,
-			TokenEndpoint:     oidc.TokenEndpoint,
-			JwksURI:           oidc.JWKSURI,
-			ClientID:          oidc.ClientID,
-			ClientSecret:      string(clientSecret),
-			Scope:             scope,
-			RedirectURI:       redirectURI,
-			ZoneSyncLeeway:    generateIntFromPointer(oidc.ZoneSyncLeeway, 200),
-			AccessTokenEnable: oidc.AccessTokenEnable,
+			AuthEndpoint:          oidc.AuthEndpoint,
+			AuthExtraArgs:         authExtraArgs,
+			TokenEndpoint:         oidc.TokenEndpoint,
+			JwksURI:               jwksURI,
+			JwksFile:              jwksFile,
+			ClientID:              oidc.ClientID,
+			ClientSecret:          string(clientSecret),
+			Scope:                 scope,
+			RedirectURI:           redirectURI,
+			ZoneSyncLeeway:        generateIntFromPointer(oidc.ZoneSyncLeeway, 200),
+			AccessTokenEnable:     oidc.AccessTokenEnable,
+			RetryOnUnauthorized:   oidc.RetryOnUnauthorized,
+			ResponseMode:          oidc.ResponseMode,
+			JARKeyFile:            jarKeyFile,
+			JARMEnable:            oidc.JARMEnable,
+			DeviceAuthEndpoint:    oidc.DeviceAuthEndpoint,
+			DPoPEnable:            oidc.DPoPEnable,
+			OAuth2UserEndpoint:    oidc.OAuth2UserEndpoint,
+			JWEKeyFile:            jweKeyFile,
+			NonceEnforce:          generateBool(oidc.NonceEnforce, true),
+			StateKey:              stateKey,
+			PreviousStateKey:      previousStateKey,
+			HMACKey:               hmacKey,
+			PreviousHMACKey:       previousHMACKey,
+			ResourceArgs:          generateOIDCResourceArgs(oidc.Resources),
+			ErrorPages:            errorPages,
+			CookieSameSite:        generateString(oidc.CookieSameSite, "Lax"),
+			CookieDomain:          oidc.CookieDomain,
+			ClaimRules:            generateOIDCClaimRules(oidc.ClaimRules),
+			SessionStore:          sessionStore,
+			SessionCookieKeys:     sessionCookieKeys,
+			PreviousKeyExpiry:     previousSessionKeyExpiry,
+			MaxSessionsPerUser:    oidc.MaxSessionsPerUser,
+			SessionLimitAction:    sessionLimitAction,
+			RefreshAheadSeconds:   oidc.RefreshAheadSeconds,
+			RevocationEndpoint:    revocationEndpoint,
+			RememberMeMaxAge:      OIDCRememberMeSeconds(oidc.RememberMe),
+			StepUpMaxAge:          generateIntFromPointer(oidc.StepUpMaxAge, 300),
+			IdPOutageBehavior:     oidc.IdPOutageBehavior,
+			LoginRedirectPaths:    loginRedirectPaths,
+			SessionInfoClaims:     sessionInfoClaims,
+			LogoutCSRFEnable:      oidc.LogoutCSRFEnable,
+			IntrospectionEnable:   oidc.Introspection != nil && oidc.Introspection.Enable,
+			TerminateOnSessionEnd: oidc.TerminateOnSessionEnd,
+			Tracing:               oidc.TracingEnable && oidcPolCfg.openTracing,
+			Policy:                polKey,
+			LogLevel:              generateString(oidc.LogLevel, "info"),
+			IdPType:               oidc.IdPType,
+			AllowedTenants:        strings.Join(oidc.AllowedTenants, " "),
+			EndSessionEndpoint:    endSessionEndpoint,
+			UserinfoEndpoint:      userinfoEndpoint,
+			Issuer:                issuer,
+			AccessTokenAudience:   oidc.AccessTokenAudience,
+			GoogleHostedDomain:    googleHostedDomain,
+			IdPs:                  idps,
+			IdPSelection:          idpSelection,
+			IdPSelectionVariable:  idpSelectionVariable,
+			IdPEmailDomains:       strings.Join(idpEmailDomains, " "),
+			StrictSpecCompliance:  oidc.StrictSpecCompliance,
+			TokenHashesRequired:   oidc.TokenHashesRequired,
+			Resolver:              generateOIDCResolver(oidc.Resolver),
+			HTTPProxy:             oidc.HTTPProxy,
+			NoProxy:               strings.Join(oidc.NoProxy, " "),
+			RequestHeaders:        generateOIDCRequestHeaders(oidc.RequestHeaders),
+			PathPrefix:            oidc.PathPrefix,
+			LoginLockout:          generateOIDCLoginLockout(oidc.LoginLockout),
+			RedirectAllowlist:     generateOIDCRedirectAllowlist(oidc.RedirectAllowlist),
+			SecurityHeaders:       generateOIDCSecurityHeaders(oidc.SecurityHeaders),
+		}
+		if oidc.PathPrefix != "" || oidcPolCfg.oidc.Tracing {
+			oidcPolCfg.oidc.LocationsFile = "oidc/" + OIDCLocationsConfigName(oidc.PathPrefix, oidcPolCfg.oidc.Tracing) + ".conf"
+		}
+		if ub := oidc.UnauthorizedBehavior; ub != nil {
+			oidcPolCfg.oidc.UnauthorizedAcceptJSON = ub.AcceptJSON
+			oidcPolCfg.oidc.UnauthorizedPaths = strings.Join(ub.Paths, " ")
 		}
 		oidcPolCfg.key = polKey
 	}
@@ -1334,6 +1762,561 @@ func (p *policiesCfg) addOIDCConfig(
 	return res
 }
 
+// addOIDCRateLimitConfig adds the zone of the rate limit of the OIDC policy of the VirtualServer, which counts the
+// requests of a client IP address to the OIDC locations that start a login, exchange an authorization code or
+// refresh the tokens.
+func (p *policiesCfg) addOIDCRateLimitConfig(
+	rateLimit *conf_v1.OIDCRateLimit,
+	polNamespace string,
+	polName string,
+	vsNamespace string,
+	vsName string,
+	oidcPolCfg *oidcPolicyCfg,
+) {
+	zoneName := fmt.Sprintf("oidc_rl_%v_%v_%v_%v", polNamespace, polName, vsNamespace, vsName)
+	p.LimitReqZones = append(p.LimitReqZones, version2.LimitReqZone{
+		ZoneName: zoneName,
+		Key:      "$oidc_rate_limit_key",
+		ZoneSize: generateString(rateLimit.ZoneSize, "10m"),
+		Rate:     rateLimit.Rate,
+	})
+	oidcPolCfg.oidc.RateLimit = &version2.LimitReq{
+		ZoneName: zoneName,
+		Burst:    generateIntFromPointer(rateLimit.Burst, 0),
+		NoDelay:  true,
+	}
+}
+
+// addRouteOIDCPolicy binds the routes of another OIDC policy than the one of the VirtualServer to that policy.
+// The server context sets the variables of the policy for the requests of its routes, and the sessions of the
+// policy have their own session cookie. The settings that configure the server, like the OIDC locations, are
+// shared, so they must be the same, and neither policy can have idps.
+func (p *policiesCfg) addRouteOIDCPolicy(
+	oidc *conf_v1.OIDC,
+	polKey string,
+	polNamespace string,
+	secretRefs map[string]*secrets.SecretReference,
+	configMapRefs map[string]*api_v1.ConfigMap,
+	oidcProviders map[string]*OIDCProvider,
+	oidcPolCfg *oidcPolicyCfg,
+) *validationResults {
+	primary := oidcPolCfg.oidc
+	if i := slices.IndexFunc(primary.RoutePolicies, func(route *version2.OIDC) bool { return route.Policy == polKey }); i != -1 {
+		p.OIDC = true
+		p.OIDCRoute = primary.RoutePolicies[i]
+		return newValidationResults()
+	}
+
+	routePolCfg := &oidcPolicyCfg{openTracing: oidcPolCfg.openTracing}
+	res := (&policiesCfg{}).addOIDCConfig(oidc, polKey, polNamespace, secretRefs, configMapRefs, oidcProviders, routePolCfg)
+	if res.isError {
+		return res
+	}
+
+	route := routePolCfg.oidc
+	if len(primary.IdPs) > 0 || len(route.IdPs) > 0 || !sameOIDCServerSettings(primary, route) {
+		res.addWarningf(
+			"Only one oidc policy is allowed in a VirtualServer and its VirtualServerRoutes, unless the policies of the routes have the same pathPrefix, resolver, errorPages and jweKeySecret as the policy %s and no idps. Can't use %s",
+			oidcPolCfg.key,
+			polKey,
+		)
+		res.isError = true
+		return res
+	}
+	if oidc.RateLimit != nil {
+		res.addWarningf("The rateLimit of OIDC policy %s is ignored, the logins of its routes are limited by the rateLimit of OIDC policy %s", polKey, oidcPolCfg.key)
+	}
+
+	sum := sha256.Sum256([]byte(polKey))
+	route.CookieSuffix = "_" + hex.EncodeToString(sum[:4])
+	primary.RoutePolicies = append(primary.RoutePolicies, route)
+
+	p.OIDC = true
+	p.OIDCRoute = route
+
+	return res
+}
+
+// sameOIDCServerSettings returns whether two OIDC configurations have the same settings that configure the server
+// instead of the variables of the policy: the OIDC locations, the resolver, the error pages and the validation of
+// the encrypted ID tokens.
+func sameOIDCServerSettings(a, b *version2.OIDC) bool {
+	return a.PathPrefix == b.PathPrefix && a.LocationsFile == b.LocationsFile && a.Resolver == b.Resolver &&
+		a.JWEKeyFile == b.JWEKeyFile && slices.Equal(a.ErrorPages, b.ErrorPages)
+}
+
+// OIDCRememberMeSeconds returns the lifetime of the persistent session cookies of an OIDC policy,
+// or 0 if the policy doesn't remember the sessions.
+func OIDCRememberMeSeconds(rememberMe *conf_v1.OIDCRememberMe) int {
+	if rememberMe == nil || !rememberMe.Enable {
+		return 0
+	}
+	// the duration is validated in the policy
+	seconds, _ := ParseTimeSeconds(generateString(rememberMe.Duration, "30d"))
+	return seconds
+}
+
+// generateOIDCIdPSelection returns how an OIDC policy with several IdPs selects the IdP of a login, picker without
+// an idpSelection, and the NGINX variable of the header or the cookie with the name of the IdP.
+func generateOIDCIdPSelection(oidc *conf_v1.OIDC) (string, string) {
+	if len(oidc.IdPs) == 0 {
+		return "", ""
+	}
+	if oidc.IdPSelection == nil {
+		return "picker", ""
+	}
+	switch oidc.IdPSelection.Type {
+	case "header":
+		return "header", "$http_" + strings.ReplaceAll(strings.ToLower(oidc.IdPSelection.Header), "-", "_")
+	case "cookie":
+		return "cookie", "$cookie_" + oidc.IdPSelection.Cookie
+	}
+	return generateString(oidc.IdPSelection.Type, "picker"), ""
+}
+
+// keycloakRealmRegexp matches the endpoints of a Keycloak realm, with the URL of the realm in the first group.
+var keycloakRealmRegexp = regexp.MustCompile(`^(https?://[^/?#]+(?:/[^?#]*)?/realms/[^/?#]+)/protocol/openid-connect/[^?#]*$`)
+
+// KeycloakRealm returns the URL of the Keycloak realm of an OIDC policy with the keycloak idpType, e.g.
+// https://keycloak.example.com/realms/apps, from its authorization endpoint, or an empty string.
+func KeycloakRealm(oidc *conf_v1.OIDC) string {
+	if oidc.IdPType != "keycloak" {
+		return ""
+	}
+	m := keycloakRealmRegexp.FindStringSubmatch(oidc.AuthEndpoint)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// oktaAuthEndpointRegexp matches the authorization endpoints of the Okta org authorization server, e.g.
+// https://example.okta.com/oauth2/v1/authorize, with the org in the first group, and of the custom authorization
+// servers, e.g. https://example.okta.com/oauth2/default/v1/authorize, with the ID of the server in the second group.
+var oktaAuthEndpointRegexp = regexp.MustCompile(`^(https://[^/?#]+)/oauth2(?:/([^/?#]+))?/v1/authorize$`)
+
+// OktaIssuer returns the issuer of the Okta authorization server of an OIDC policy with the okta idpType, from its
+// authorization endpoint, or an empty string. The issuer of the org authorization server is the URL of the org, the
+// issuer of a custom authorization server is the URL of the server, e.g. https://example.okta.com/oauth2/default.
+func OktaIssuer(oidc *conf_v1.OIDC) string {
+	if oidc.IdPType != "okta" {
+		return ""
+	}
+	m := oktaAuthEndpointRegexp.FindStringSubmatch(oidc.AuthEndpoint)
+	if m == nil {
+		return ""
+	}
+	if m[2] == "" {
+		return m[1]
+	}
+	return m[1] + "/oauth2/" + m[2]
+}
+
+// cognitoAuthEndpointRegexp matches the authorization endpoints of the hosted UI domains of Cognito, e.g.
+// https://example.auth.us-east-1.amazoncognito.com/oauth2/authorize, with the domain in the first group.
+var cognitoAuthEndpointRegexp = regexp.MustCompile(`^(https://[^/?#]+)/oauth2/authorize$`)
+
+// CognitoDomain returns the hosted UI domain of the Cognito user pool of an OIDC policy with the cognito idpType,
+// e.g. https://example.auth.us-east-1.amazoncognito.com, from its authorization endpoint, or an empty string.
+func CognitoDomain(oidc *conf_v1.OIDC) string {
+	if oidc.IdPType != "cognito" {
+		return ""
+	}
+	m := cognitoAuthEndpointRegexp.FindStringSubmatch(oidc.AuthEndpoint)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// auth0AuthEndpointRegexp matches the authorization endpoints of the Auth0 tenants, e.g.
+// https://example.us.auth0.com/authorize, with the domain of the tenant in the first group.
+var auth0AuthEndpointRegexp = regexp.MustCompile(`^(https://[^/?#]+)/authorize$`)
+
+// Auth0Domain returns the domain of the Auth0 tenant of an OIDC policy with the auth0 idpType, e.g.
+// https://example.us.auth0.com, from its authorization endpoint, or an empty string.
+func Auth0Domain(oidc *conf_v1.OIDC) string {
+	if oidc.IdPType != "auth0" {
+		return ""
+	}
+	m := auth0AuthEndpointRegexp.FindStringSubmatch(oidc.AuthEndpoint)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// adfsAuthEndpointRegexp matches the authorization endpoints of ADFS, e.g.
+// https://adfs.example.com/adfs/oauth2/authorize/, with the URL of ADFS in the first group.
+var adfsAuthEndpointRegexp = regexp.MustCompile(`^(https://[^/?#]+/adfs)/oauth2/authorize/?$`)
+
+// ADFSBase returns the URL of the ADFS of an OIDC policy with the adfs idpType, e.g. https://adfs.example.com/adfs,
+// from its authorization endpoint, or an empty string.
+func ADFSBase(oidc *conf_v1.OIDC) string {
+	if oidc.IdPType != "adfs" {
+		return ""
+	}
+	m := adfsAuthEndpointRegexp.FindStringSubmatch(oidc.AuthEndpoint)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// pingFederateAuthEndpointRegexp matches the authorization endpoint of PingFederate, e.g.
+// https://sso.example.com/as/authorization.oauth2, with the URL of the server in the first group.
+var pingFederateAuthEndpointRegexp = regexp.MustCompile(`^(https://[^/?#]+)/as/authorization\.oauth2$`)
+
+// PingFederateBase returns the URL of the PingFederate server of an OIDC policy with the pingfederate idpType, e.g.
+// https://sso.example.com, from its authorization endpoint, or an empty string.
+func PingFederateBase(oidc *conf_v1.OIDC) string {
+	if oidc.IdPType != "pingfederate" {
+		return ""
+	}
+	m := pingFederateAuthEndpointRegexp.FindStringSubmatch(oidc.AuthEndpoint)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// forgeRockAuthEndpointRegexp matches the authorization endpoints of the realms of ForgeRock AM, e.g.
+// https://am.example.com/am/oauth2/realms/root/realms/alpha/authorize, with the URL of the realm in the first group.
+var forgeRockAuthEndpointRegexp = regexp.MustCompile(`^(https://[^/?#]+(?:/[^?#]*)?/oauth2(?:/realms/[^/?#]+)*)/authorize$`)
+
+// ForgeRockRealm returns the URL of the OAuth 2.0 provider of the ForgeRock AM realm of an OIDC policy with the
+// forgerock idpType, e.g. https://am.example.com/am/oauth2/realms/root/realms/alpha, from its authorization endpoint,
+// or an empty string.
+func ForgeRockRealm(oidc *conf_v1.OIDC) string {
+	if oidc.IdPType != "forgerock" {
+		return ""
+	}
+	m := forgeRockAuthEndpointRegexp.FindStringSubmatch(oidc.AuthEndpoint)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// OIDCDiscoveryEndpoint returns the discovery endpoint of an OIDC policy. A Keycloak policy without one discovers
+// the endpoints of its realm, an Okta policy the endpoints of its authorization server, an Auth0 policy the
+// endpoints of its tenant, an ADFS policy the endpoints of its ADFS, a PingFederate policy the endpoints of its
+// server, a ForgeRock policy the endpoints of its realm.
+func OIDCDiscoveryEndpoint(oidc *conf_v1.OIDC) string {
+	if oidc.DiscoveryEndpoint != "" {
+		return oidc.DiscoveryEndpoint
+	}
+	if realm := KeycloakRealm(oidc); realm != "" {
+		return realm + "/.well-known/openid-configuration"
+	}
+	if issuer := OktaIssuer(oidc); issuer != "" {
+		return issuer + "/.well-known/openid-configuration"
+	}
+	if domain := Auth0Domain(oidc); domain != "" {
+		return domain + "/.well-known/openid-configuration"
+	}
+	if base := ADFSBase(oidc); base != "" {
+		return base + "/.well-known/openid-configuration"
+	}
+	if base := PingFederateBase(oidc); base != "" {
+		return base + "/.well-known/openid-configuration"
+	}
+	if realm := ForgeRockRealm(oidc); realm != "" {
+		return realm + "/.well-known/openid-configuration"
+	}
+	return ""
+}
+
+// generateOIDCStateKey returns the key that signs the state of the OIDC logins. Unless the secret of the
+// policy stores a state key, the key is derived from the client secret, so that all pods use the same key.
+func generateOIDCStateKey(secret *api_v1.Secret) string {
+	if stateKey, exists := secret.Data[OIDCStateKey]; exists {
+		return string(stateKey)
+	}
+	mac := hmac.New(sha256.New, secret.Data[ClientSecretKey])
+	mac.Write([]byte("oidc-state"))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// OIDCManagedKeysSecretName returns the name of the Secret where the Ingress Controller stores the managed keys of
+// the OIDC policy, in the namespace of the policy.
+func OIDCManagedKeysSecretName(policyName string) string {
+	return policyName + "-oidc-keys"
+}
+
+// oidcLocations are the paths of the locations of oidc.conf and of the OIDC locations of the VirtualServer template,
+// which an OIDC policy with a pathPrefix moves under the prefix.
+var oidcLocations = []string{
+	"/_jwks_uri", "/_session_store", "/_oidc_event", "/_introspect", "/_codexch", "/_token", "/_refresh", "/_revoke",
+	"/_refresh_ahead", "/device/authorize", "/device/token", "/_device_authz", "/_device_token", "/_oidc_dpop_proof",
+	"/_oidc_token_exchange", "/_token_exchange", "/_id_token_validation", "/_jwe_id_token_validation",
+	"/_oauth2_user", "/_azuread_groups", "/_oauth2_session_jwks", "/_jarm_validation", "/login", "/session",
+	"/userinfo", "/_userinfo", "/renew", "/logout", "/_logout",
+}
+
+// OIDCLocationsConfigName returns the name of the copy of oidc.conf with the locations under the path prefix of an
+// OIDC policy, and with the tags of the spans of the requests to the IdP if the policy is traced. The policies with
+// the same prefix and tracing share the copy.
+func OIDCLocationsConfigName(pathPrefix string, tracing bool) string {
+	name := "oidc"
+	if pathPrefix != "" {
+		sum := sha256.Sum256([]byte(pathPrefix))
+		name += "-" + hex.EncodeToString(sum[:8])
+	}
+	if tracing {
+		name += "-tracing"
+	}
+	return name
+}
+
+var (
+	oidcLocationRegexp   = regexp.MustCompile(`(?m)^(\s*location\s*=\s*)/`)
+	oidcKeyRequestRegexp = regexp.MustCompile(`(?m)^(\s*auth_jwt_key_request\s+)/`)
+	oidcTraceStepRegexp  = regexp.MustCompile(`(?m)^([ \t]*)set\s+\$oidc_trace_step\s[^\n]*\n`)
+)
+
+// generateOIDCLocations returns the content of the copy of oidc.conf of an OIDC policy with a path prefix or with
+// tracing.
+func generateOIDCLocations(content []byte, pathPrefix string, tracing bool) []byte {
+	if pathPrefix != "" {
+		content = prefixOIDCLocations(content, pathPrefix)
+	}
+	if tracing {
+		content = traceOIDCLocations(content)
+	}
+	return content
+}
+
+// traceOIDCLocations returns the content of oidc.conf with the tags of the spans in the locations that send the
+// requests to the IdP, which set the step of the authentication flow.
+func traceOIDCLocations(content []byte) []byte {
+	return oidcTraceStepRegexp.ReplaceAll(content, []byte("${0}"+
+		"${1}opentracing_tag oidc.step $$oidc_trace_step;\n"+
+		"${1}opentracing_tag oidc.idp_endpoint $$oidc_trace_endpoint;\n"+
+		"${1}opentracing_tag oidc.idp_status $$upstream_status;\n"))
+}
+
+// prefixOIDCLocations returns the content of oidc.conf with the exact locations, and the subrequests to them, under
+// the path prefix, which is validated in the policy.
+func prefixOIDCLocations(content []byte, pathPrefix string) []byte {
+	content = oidcLocationRegexp.ReplaceAll(content, []byte("${1}"+pathPrefix+"/"))
+	return oidcKeyRequestRegexp.ReplaceAll(content, []byte("${1}"+pathPrefix+"/"))
+}
+
+// oidcLocationConflict returns the route path that is also the path of an OIDC location under the path prefix of
+// the OIDC policy, or an empty string. NGINX would pick the exact OIDC location instead of the location of a
+// prefix route, and fail with the location of an exact route. Regex routes are not checked.
+func oidcLocationConflict(oidc *conf_v1.OIDC, routePaths []string) string {
+	for _, routePath := range routePaths {
+		if strings.HasPrefix(routePath, "~") {
+			continue
+		}
+		p := strings.TrimPrefix(routePath, "=")
+		if !strings.HasPrefix(p, oidc.PathPrefix+"/") {
+			continue
+		}
+		if slices.Contains(oidcLocations, strings.TrimPrefix(p, oidc.PathPrefix)) {
+			return routePath
+		}
+	}
+	return ""
+}
+
+// virtualServerRoutePaths returns the paths of the routes of the VirtualServer and of its VirtualServerRoutes.
+func virtualServerRoutePaths(vsEx *VirtualServerEx) []string {
+	var paths []string
+	for _, r := range vsEx.VirtualServer.Spec.Routes {
+		if r.Route == "" {
+			paths = append(paths, r.Path)
+		}
+	}
+	for _, vsr := range vsEx.VirtualServerRoutes {
+		for _, r := range vsr.Spec.Subroutes {
+			paths = append(paths, r.Path)
+		}
+	}
+	return paths
+}
+
+// oidcManagedKeys returns the Secret of the managed keys of the OIDC policy, or nil if the policy doesn't manage its
+// keys or the Ingress Controller hasn't generated them yet.
+func oidcManagedKeys(oidc *conf_v1.OIDC, polKey string, secretRefs map[string]*secrets.SecretReference) *api_v1.Secret {
+	if oidc.ManagedKeys == nil || !oidc.ManagedKeys.Enable {
+		return nil
+	}
+	namespace, name, _ := strings.Cut(polKey, "/")
+	secretRef, exists := secretRefs[namespace+"/"+OIDCManagedKeysSecretName(name)]
+	if !exists || secretRef.Error != nil || secretRef.Secret == nil || secretRef.Secret.Type != secrets.SecretTypeOIDCSessionKey {
+		return nil
+	}
+	if len(secretRef.Secret.Data[secrets.OIDCStateKey]) == 0 || len(secretRef.Secret.Data[secrets.OIDCHMACKey]) == 0 {
+		return nil
+	}
+	return secretRef.Secret
+}
+
+// generateOIDCSessionCookieKeys returns the AES-256 keys, hex-encoded and separated by spaces, that encrypt and
+// decrypt the session cookies. The first key encrypts the cookies, the previous key of the secret only decrypts the
+// cookies encrypted before the key was rotated. The keys are derived from the secret, which can hold any random string.
+func generateOIDCSessionCookieKeys(secret *api_v1.Secret) string {
+	keys := make([]string, 0, 2)
+	for _, field := range []string{secrets.OIDCSessionKey, secrets.OIDCPreviousSessionKey} {
+		key, exists := secret.Data[field]
+		if !exists {
+			continue
+		}
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte("oidc-session-cookie"))
+		keys = append(keys, hex.EncodeToString(mac.Sum(nil)))
+	}
+	return strings.Join(keys, " ")
+}
+
+// generateOIDCPreviousSessionKeyExpiry returns the Unix time when the previous key of the secret stops decrypting
+// the session cookies: the lifetime after the time of the OIDCKeysRotatedAtAnnotation of the secret. It returns 0
+// without a previous key, and false if the secret has a previous key but no valid annotation.
+func generateOIDCPreviousSessionKeyExpiry(secret *api_v1.Secret, lifetime string) (int64, bool) {
+	if _, exists := secret.Data[secrets.OIDCPreviousSessionKey]; !exists {
+		return 0, true
+	}
+	rotatedAt, err := time.Parse(time.RFC3339, secret.Annotations[secrets.OIDCKeysRotatedAtAnnotation])
+	if err != nil {
+		return 0, false
+	}
+	// The lifetime is validated in the policy
+	seconds, _ := ParseTimeSeconds(lifetime)
+	return rotatedAt.Unix() + int64(seconds), true
+}
+
+// oidcErrorPages are the login errors of the OIDC policy that can be replaced by the pages of a ConfigMap.
+var oidcErrorPages = []struct {
+	key  string
+	name string
+	code int
+}{
+	{key: "groups-failure.html", name: "groups_failure", code: 502},
+	{key: "idp-unreachable.html", name: "idp_unreachable", code: 502},
+	{key: "invalid-state.html", name: "invalid_state", code: 403},
+	{key: "session-limit.html", name: "session_limit", code: 403},
+	{key: "step-up-failure.html", name: "step_up_failure", code: 403},
+	{key: "token-validation-failure.html", name: "token_validation_failure", code: 500},
+}
+
+// generateOIDCErrorPages returns the error pages stored in the ConfigMap of the OIDC policy. The pages are
+// quoted NGINX strings, so they can reference NGINX variables like $request_id.
+func generateOIDCErrorPages(configMap *api_v1.ConfigMap) []version2.OIDCErrorPage {
+	var pages []version2.OIDCErrorPage
+	quoter := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	for _, p := range oidcErrorPages {
+		body, exists := configMap.Data[p.key]
+		if !exists {
+			continue
+		}
+		pages = append(pages, version2.OIDCErrorPage{
+			Name: p.name,
+			Code: p.code,
+			Body: quoter.Replace(body),
+		})
+	}
+	return pages
+}
+
+// generateOIDCClaimRules returns the claim rules of the OIDC policy as base64 encoded JSON,
+// so that the claims and values don't need to be escaped in the NGINX config.
+func generateOIDCClaimRules(rules []conf_v1.ClaimRule) string {
+	if len(rules) == 0 {
+		return ""
+	}
+	b, _ := json.Marshal(rules) // Structs of strings always marshal
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// generateOIDCResourceArgs returns the resource parameters (RFC 8707) that are appended to the
+// authorization and token requests of the OIDC policy.
+func generateOIDCResourceArgs(resources []string) string {
+	var args strings.Builder
+	for _, resource := range resources {
+		args.WriteString("&resource=")
+		args.WriteString(url.QueryEscape(resource))
+	}
+	return args.String()
+}
+
+// generateOIDCRequestHeaders returns the headers of the requests to the provider of an OIDC policy, which the
+// Ingress Controller adds when it relays the requests of NGINX.
+func generateOIDCRequestHeaders(headers []conf_v1.Header) []version2.Header {
+	var result []version2.Header
+	for _, h := range headers {
+		result = append(result, version2.Header{Name: h.Name, Value: h.Value})
+	}
+	return result
+}
+
+// generateOIDCResolver returns the parameters of the resolver directive that resolves the hostnames of the
+// IdP endpoints of the OIDC policy, or an empty string for the resolver of the ConfigMap.
+func generateOIDCResolver(resolver *conf_v1.OIDCResolver) string {
+	if resolver == nil || len(resolver.Addresses) == 0 {
+		return ""
+	}
+	params := strings.Join(resolver.Addresses, " ")
+	if resolver.Valid != "" {
+		params += " valid=" + resolver.Valid
+	}
+	if !generateBool(resolver.IPv6, true) {
+		params += " ipv6=off"
+	}
+	return params
+}
+
+// generateOIDCLoginLockout returns the login lockout of the OIDC policy, by default 15 minutes after the failures
+// within 10 minutes, and a tarpit delay of 10 seconds.
+func generateOIDCLoginLockout(lockout *conf_v1.OIDCLoginLockout) *version2.OIDCLoginLockout {
+	if lockout == nil {
+		return nil
+	}
+	// The times are validated in the policy
+	window, _ := ParseTimeSeconds(generateString(lockout.Window, "10m"))
+	duration, _ := ParseTimeSeconds(generateString(lockout.Duration, "15m"))
+	cfg := &version2.OIDCLoginLockout{
+		MaxFailures: lockout.MaxFailures,
+		Window:      window,
+		Duration:    duration,
+	}
+	if lockout.Action == "tarpit" {
+		cfg.TarpitDelay, _ = ParseTimeSeconds(generateString(lockout.TarpitDelay, "10s"))
+	}
+	return cfg
+}
+
+// generateOIDCRedirectAllowlist returns the redirectAllowlist of the OIDC policy, with the schemes and the hosts of
+// its URLs in lowercase, like the origins of the redirects that the OIDC JavaScript compares to them.
+func generateOIDCRedirectAllowlist(allowlist []string) string {
+	entries := make([]string, 0, len(allowlist))
+	for _, entry := range allowlist {
+		// The URLs are validated in the policy
+		if scheme, rest, found := strings.Cut(entry, "://"); found {
+			host, path, _ := strings.Cut(rest, "/")
+			entry = strings.ToLower(scheme) + "://" + strings.ToLower(host) + "/" + path
+		}
+		entries = append(entries, entry)
+	}
+	return strings.Join(entries, " ")
+}
+
+// generateOIDCSecurityHeaders returns the security headers of the OIDC policy, by default a Content-Security-Policy
+// that only allows the pages without their resources and frames, X-Frame-Options DENY and Referrer-Policy
+// no-referrer. Without securityHeaders, no headers are sent.
+func generateOIDCSecurityHeaders(headers *conf_v1.OIDCSecurityHeaders) version2.OIDCSecurityHeaders {
+	if headers == nil {
+		return version2.OIDCSecurityHeaders{}
+	}
+	return version2.OIDCSecurityHeaders{
+		ContentSecurityPolicy: generateString(headers.ContentSecurityPolicy, "default-src 'none'; frame-ancestors 'none'"),
+		FrameOptions:          generateString(headers.FrameOptions, "DENY"),
+		ReferrerPolicy:        generateString(headers.ReferrerPolicy, "no-referrer"),
+	}
+}
+
 func (p *policiesCfg) addAPIKeyConfig(
 	apiKey *conf_v1.APIKey,
 	polKey string,
@@ -1385,6 +2368,120 @@ func (p *policiesCfg) addAPIKeyConfig(
 	return res
 }
 
+func (p *policiesCfg) addClientCredentialsConfig(
+	clientCredentials *conf_v1.ClientCredentials,
+	polKey string,
+	polNamespace string,
+	secretRefs map[string]*secrets.SecretReference,
+) *validationResults {
+	res := newValidationResults()
+	if p.ClientCredentials != nil {
+		res.addWarningf(
+			"Multiple Client Credentials policies in the same context is not valid. Client Credentials policy %s will be ignored",
+			polKey,
+		)
+		return res
+	}
+
+	secretKey := fmt.Sprintf("%v/%v", polNamespace, clientCredentials.ClientSecret)
+	secretRef := secretRefs[secretKey]
+	var secretType api_v1.SecretType
+	if secretRef.Secret != nil {
+		secretType = secretRef.Secret.Type
+	}
+	if secretType != "" && secretType != secrets.SecretTypeOIDC {
+		res.addWarningf("Client Credentials policy %s references a secret %s of a wrong type '%s', must be '%s'", polKey, secretKey, secretType, secrets.SecretTypeOIDC)
+		res.isError = true
+		return res
+	} else if secretRef.Error != nil {
+		res.addWarningf("Client Credentials policy %s references an invalid secret %s: %v", polKey, secretKey, secretRef.Error)
+		res.isError = true
+		return res
+	}
+
+	p.ClientCredentials = &version2.ClientCredentials{
+		Key:           polKey,
+		TokenEndpoint: clientCredentials.TokenEndpoint,
+		ClientID:      clientCredentials.ClientID,
+		ClientSecret:  string(secretRef.Secret.Data[ClientSecretKey]),
+		Scope:         clientCredentials.Scope,
+	}
+	p.ClientCredentialsEnabled = true
+	return res
+}
+
+// addClientCredentialsToRoute applies the Client Credentials policy of the VirtualServer to a route
+// that doesn't reference one. The policy obtains the token with auth_request, so it can't be combined
+// with an API Key policy in the same location.
+func (vsc *virtualServerConfigurator) addClientCredentialsToRoute(owner runtime.Object, serverCfg *policiesCfg, routeCfg *policiesCfg) {
+	if routeCfg.ErrorReturn != nil {
+		return
+	}
+	if routeCfg.ClientCredentials == nil {
+		routeCfg.ClientCredentials = serverCfg.ClientCredentials
+	}
+	if routeCfg.ClientCredentials == nil {
+		return
+	}
+	if routeCfg.APIKey != nil || serverCfg.APIKey != nil {
+		vsc.addWarningf(owner, "Client Credentials policy %s can't be combined with an API Key policy", routeCfg.ClientCredentials.Key)
+		routeCfg.ErrorReturn = &version2.Return{Code: 500}
+		return
+	}
+	serverCfg.ClientCredentialsEnabled = true
+}
+
+// addTokenExchangeToRoute configures the exchange of the access token of the OIDC session for a token
+// of the audience of the route. Like the Client Credentials policy, it uses auth_request and replaces
+// the Authorization header, so the two can't be combined, nor with an API Key policy.
+func (vsc *virtualServerConfigurator) addTokenExchangeToRoute(owner runtime.Object, route conf_v1.Route, serverCfg *policiesCfg, routeCfg *policiesCfg) {
+	if route.TokenExchange == nil || routeCfg.ErrorReturn != nil {
+		return
+	}
+	if !routeCfg.OIDC {
+		vsc.addWarningf(owner, "Token exchange of route %s requires an OIDC policy and will be ignored", route.Path)
+		return
+	}
+	if routeCfg.APIKey != nil || serverCfg.APIKey != nil || routeCfg.ClientCredentials != nil {
+		vsc.addWarningf(owner, "Token exchange of route %s can't be combined with an API Key or Client Credentials policy", route.Path)
+		routeCfg.ErrorReturn = &version2.Return{Code: 500}
+		return
+	}
+	routeCfg.TokenExchangeAudience = route.TokenExchange.Audience
+}
+
+// addStepUpToRoute requires a recent authentication at the IdP for the route. Sessions that authenticated
+// longer than the stepUpMaxAge of the OIDC policy ago are sent to the IdP to log in again.
+func (vsc *virtualServerConfigurator) addStepUpToRoute(owner runtime.Object, route conf_v1.Route, routeCfg *policiesCfg) {
+	if !route.StepUpRequired || routeCfg.ErrorReturn != nil {
+		return
+	}
+	if !routeCfg.OIDC {
+		vsc.addWarningf(owner, "Step-up authentication of route %s requires an OIDC policy and will be ignored", route.Path)
+		return
+	}
+	routeCfg.OIDCStepUp = true
+}
+
+// addDPoPToRoute configures the DPoP proof of the access token of the OIDC session that is passed to the
+// upstream of a route. The proof is created with auth_request, so it can't be combined with an API Key or
+// Client Credentials policy. A token exchange replaces the access token and doesn't need the proof.
+func (vsc *virtualServerConfigurator) addDPoPToRoute(owner runtime.Object, path string, serverCfg *policiesCfg, routeCfg *policiesCfg) {
+	oidc := vsc.oidcPolCfg.oidc
+	if oidc == nil || !oidc.DPoPEnable || !oidc.AccessTokenEnable {
+		return
+	}
+	if !routeCfg.OIDC || routeCfg.TokenExchangeAudience != "" || routeCfg.ErrorReturn != nil {
+		return
+	}
+	if routeCfg.APIKey != nil || serverCfg.APIKey != nil || routeCfg.ClientCredentials != nil {
+		vsc.addWarningf(owner, "DPoP of OIDC policy %s can't be combined with an API Key or Client Credentials policy in route %s", vsc.oidcPolCfg.key, path)
+		routeCfg.ErrorReturn = &version2.Return{Code: 500}
+		return
+	}
+	routeCfg.DPoP = true
+}
+
 func rfc1123ToSnake(rfc1123String string) string {
 	return strings.Replace(rfc1123String, "-", "_", -1)
 }
@@ -1538,7 +2635,8 @@ func (vsc *virtualServerConfigurator) generatePolicies(
 					vsc.IngressControllerReplicas,
 				)
 			case pol.Spec.JWTAuth != nil:
-				res = config.addJWTAuthConfig(pol.Spec.JWTAuth, key, polNamespace, policyOpts.secretRefs)
+				res = config.addJWTAuthConfig(pol.Spec.JWTAuth, key, polNamespace, ownerDetails.vsNamespace,
+					ownerDetails.vsName, policyOpts.secretRefs)
 			case pol.Spec.BasicAuth != nil:
 				res = config.addBasicAuthConfig(pol.Spec.BasicAuth, key, polNamespace, policyOpts.secretRefs)
 			case pol.Spec.IngressMTLS != nil:
@@ -1553,10 +2651,21 @@ func (vsc *virtualServerConfigurator) generatePolicies(
 			case pol.Spec.EgressMTLS != nil:
 				res = config.addEgressMTLSConfig(pol.Spec.EgressMTLS, key, polNamespace, policyOpts.secretRefs)
 			case pol.Spec.OIDC != nil:
-				res = config.addOIDCConfig(pol.Spec.OIDC, key, polNamespace, policyOpts.secretRefs, vsc.oidcPolCfg)
+				if routePath := oidcLocationConflict(pol.Spec.OIDC, policyOpts.routePaths); routePath != "" {
+					res = newValidationResults()
+					res.addWarningf("OIDC policy %s has a location at the path of route %s, set a pathPrefix in the policy", key, routePath)
+					res.isError = true
+				} else {
+					res = config.addOIDCConfig(pol.Spec.OIDC, key, polNamespace, policyOpts.secretRefs, policyOpts.configMapRefs, policyOpts.oidcProviders, vsc.oidcPolCfg)
+					if !res.isError && pol.Spec.OIDC.RateLimit != nil && vsc.oidcPolCfg.key == key {
+						config.addOIDCRateLimitConfig(pol.Spec.OIDC.RateLimit, polNamespace, p.Name, ownerDetails.vsNamespace, ownerDetails.vsName, vsc.oidcPolCfg)
+					}
+				}
 			case pol.Spec.APIKey != nil:
 				res = config.addAPIKeyConfig(pol.Spec.APIKey, key, polNamespace, ownerDetails.vsNamespace,
 					ownerDetails.vsName, policyOpts.secretRefs)
+			case pol.Spec.ClientCredentials != nil:
+				res = config.addClientCredentialsConfig(pol.Spec.ClientCredentials, key, polNamespace, policyOpts.secretRefs)
 			case pol.Spec.WAF != nil:
 				res = config.addWAFConfig(pol.Spec.WAF, key, polNamespace, policyOpts.apResources)
 			default:
@@ -1643,11 +2752,33 @@ func addPoliciesCfgToLocation(cfg policiesCfg, location *version2.Location) {
 	location.BasicAuth = cfg.BasicAuth
 	location.EgressMTLS = cfg.EgressMTLS
 	location.OIDC = cfg.OIDC
+	location.IdentityAuthMethod = identityAuthMethod(cfg)
 	location.WAF = cfg.WAF
 	location.APIKey = cfg.APIKey
+	location.ClientCredentials = cfg.ClientCredentials
+	location.TokenExchangeAudience = cfg.TokenExchangeAudience
+	location.OIDCStepUp = cfg.OIDCStepUp
+	location.OIDCRoute = cfg.OIDCRoute
+	location.DPoP = cfg.DPoP
 	location.PoliciesErrorReturn = cfg.ErrorReturn
 }
 
+// identityAuthMethod returns the authentication method that populates the $identity_* variables.
+// When several auth policies apply, the one carrying the most information about the user wins.
+func identityAuthMethod(cfg policiesCfg) string {
+	switch {
+	case cfg.OIDC:
+		return "oidc"
+	case cfg.JWTAuth != nil:
+		return "jwt"
+	case cfg.BasicAuth != nil:
+		return "basic"
+	case cfg.IngressMTLS != nil:
+		return "mtls"
+	}
+	return ""
+}
+
 func addPoliciesCfgToLocations(cfg policiesCfg, locations []version2.Location) {
 	for i := range locations {
 		addPoliciesCfgToLocation(cfg, &locations[i])
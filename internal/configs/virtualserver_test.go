@@ -1,6 +1,7 @@
 package configs
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"reflect"
@@ -5560,18 +5561,19 @@ func TestGenerateVirtualServerConfigJWKSPolicy(t *testing.T) {
 					JwksPath:   "/spec-keys",
 				},
 			},
-			JWKSAuthEnabled: true,
-			ServerName:      "cafe.example.com",
-			StatusZone:      "cafe.example.com",
-			ProxyProtocol:   true,
-			ServerTokens:    "off",
-			RealIPHeader:    "X-Real-IP",
-			SetRealIPFrom:   []string{"0.0.0.0/0"},
-			RealIPRecursive: true,
-			Snippets:        []string{"# server snippet"},
-			TLSPassthrough:  true,
-			VSNamespace:     "default",
-			VSName:          "cafe",
+			JWKSAuthEnabled:    true,
+			IdentityAuthMethod: "jwt",
+			ServerName:         "cafe.example.com",
+			StatusZone:         "cafe.example.com",
+			ProxyProtocol:      true,
+			ServerTokens:       "off",
+			RealIPHeader:       "X-Real-IP",
+			SetRealIPFrom:      []string{"0.0.0.0/0"},
+			RealIPRecursive:    true,
+			Snippets:           []string{"# server snippet"},
+			TLSPassthrough:     true,
+			VSNamespace:        "default",
+			VSName:             "cafe",
 			Locations: []version2.Location{
 				{
 					Path:                     "/tea",
@@ -5584,6 +5586,7 @@ func TestGenerateVirtualServerConfigJWKSPolicy(t *testing.T) {
 					ProxyPassRequestHeaders:  true,
 					ProxySetHeaders:          []version2.Header{{Name: "Host", Value: "$host"}},
 					ServiceName:              "tea-svc",
+					IdentityAuthMethod:       "jwt",
 					JWTAuth: &version2.JWTAuth{
 						Key:      "default/jwt-policy-route",
 						Realm:    "Route Realm API",
@@ -5607,6 +5610,7 @@ func TestGenerateVirtualServerConfigJWKSPolicy(t *testing.T) {
 					ProxyPassRequestHeaders:  true,
 					ProxySetHeaders:          []version2.Header{{Name: "Host", Value: "$host"}},
 					ServiceName:              "coffee-svc",
+					IdentityAuthMethod:       "jwt",
 					JWTAuth: &version2.JWTAuth{
 						Key:      "default/jwt-policy-route",
 						Realm:    "Route Realm API",
@@ -6947,6 +6951,41 @@ func TestGeneratePolicies(t *testing.T) {
 			},
 			msg: "api key same secrets for different policies",
 		},
+		{
+			policyRefs: []conf_v1.PolicyReference{
+				{
+					Name:      "client-credentials-policy",
+					Namespace: "default",
+				},
+			},
+			policies: map[string]*conf_v1.Policy{
+				"default/client-credentials-policy": {
+					ObjectMeta: meta_v1.ObjectMeta{
+						Name:      "client-credentials-policy",
+						Namespace: "default",
+					},
+					Spec: conf_v1.PolicySpec{
+						ClientCredentials: &conf_v1.ClientCredentials{
+							TokenEndpoint: "https://idp.example.com/token",
+							ClientID:      "client",
+							ClientSecret:  "oidc-secret",
+							Scope:         "orders.read",
+						},
+					},
+				},
+			},
+			expected: policiesCfg{
+				ClientCredentials: &version2.ClientCredentials{
+					Key:           "default/client-credentials-policy",
+					TokenEndpoint: "https://idp.example.com/token",
+					ClientID:      "client",
+					ClientSecret:  "super_secret_123",
+					Scope:         "orders.read",
+				},
+				ClientCredentialsEnabled: true,
+			},
+			msg: "client credentials reference",
+		},
 		{
 			policyRefs: []conf_v1.PolicyReference{
 				{
@@ -8135,6 +8174,43 @@ func TestGeneratePoliciesFails(t *testing.T) {
 			expectedOidc: &oidcPolicyCfg{},
 			msg:          "oidc referencing missing oidc secret",
 		},
+		{
+			policyRefs: []conf_v1.PolicyReference{
+				{
+					Name:      "oidc-policy",
+					Namespace: "default",
+				},
+			},
+			policies: map[string]*conf_v1.Policy{
+				"default/oidc-policy": {
+					ObjectMeta: meta_v1.ObjectMeta{
+						Name:      "oidc-policy",
+						Namespace: "default",
+					},
+					Spec: conf_v1.PolicySpec{
+						OIDC: &conf_v1.OIDC{
+							ClientSecret: "oidc-secret",
+						},
+					},
+				},
+			},
+			policyOpts: policyOptions{
+				routePaths: []string{"/", "/logout"},
+			},
+			context: "spec",
+			expected: policiesCfg{
+				ErrorReturn: &version2.Return{
+					Code: 500,
+				},
+			},
+			expectedWarnings: Warnings{
+				nil: {
+					`OIDC policy default/oidc-policy has a location at the path of route /logout, set a pathPrefix in the policy`,
+				},
+			},
+			expectedOidc: &oidcPolicyCfg{},
+			msg:          "oidc with a location at the path of a route",
+		},
 		{
 			policyRefs: []conf_v1.PolicyReference{
 				{
@@ -8182,6 +8258,61 @@ func TestGeneratePoliciesFails(t *testing.T) {
 			expectedOidc: &oidcPolicyCfg{},
 			msg:          "oidc secret referencing wrong secret type",
 		},
+		{
+			policyRefs: []conf_v1.PolicyReference{
+				{
+					Name:      "oidc-policy",
+					Namespace: "default",
+				},
+			},
+			policies: map[string]*conf_v1.Policy{
+				"default/oidc-policy": {
+					ObjectMeta: meta_v1.ObjectMeta{
+						Name:      "oidc-policy",
+						Namespace: "default",
+					},
+					Spec: conf_v1.PolicySpec{
+						OIDC: &conf_v1.OIDC{
+							ClientSecret:      "idp/oidc-secret",
+							AuthEndpoint:      "http://foo.com/bar",
+							TokenEndpoint:     "http://foo.com/bar",
+							JWKSURI:           "http://foo.com/bar",
+							AccessTokenEnable: true,
+						},
+					},
+				},
+			},
+			policyOpts: policyOptions{
+				secretRefs: map[string]*secrets.SecretReference{
+					"idp/oidc-secret": {
+						Secret: &api_v1.Secret{
+							ObjectMeta: meta_v1.ObjectMeta{
+								Name:        "oidc-secret",
+								Namespace:   "idp",
+								Annotations: map[string]string{secrets.AllowedNamespacesAnnotation: "other"},
+							},
+							Type: secrets.SecretTypeOIDC,
+							Data: map[string][]byte{
+								"client-secret": []byte("super_secret_123"),
+							},
+						},
+					},
+				},
+			},
+			context: "spec",
+			expected: policiesCfg{
+				ErrorReturn: &version2.Return{
+					Code: 500,
+				},
+			},
+			expectedWarnings: Warnings{
+				nil: {
+					`OIDC policy default/oidc-policy can't reference the secret idp/oidc-secret: secret idp/oidc-secret doesn't allow references from the namespace default, the namespace must be listed in the annotation nginx.org/allowed-namespaces`,
+				},
+			},
+			expectedOidc: &oidcPolicyCfg{},
+			msg:          "oidc secret in another namespace that doesn't allow the namespace",
+		},
 		{
 			policyRefs: []conf_v1.PolicyReference{
 				{
@@ -8219,6 +8350,7 @@ func TestGeneratePoliciesFails(t *testing.T) {
 							TokenEndpoint:     "https://bar.com/token",
 							JWKSURI:           "https://bar.com/certs",
 							AccessTokenEnable: true,
+							PathPrefix:        "/bar",
 						},
 					},
 				},
@@ -8257,7 +8389,7 @@ func TestGeneratePoliciesFails(t *testing.T) {
 			},
 			expectedWarnings: Warnings{
 				nil: {
-					`Only one oidc policy is allowed in a VirtualServer and its VirtualServerRoutes. Can't use default/oidc-policy-2. Use default/oidc-policy-1`,
+					`Only one oidc policy is allowed in a VirtualServer and its VirtualServerRoutes, unless the policies of the routes have the same pathPrefix, resolver, errorPages and jweKeySecret as the policy default/oidc-policy-1 and no idps. Can't use default/oidc-policy-2`,
 				},
 			},
 			expectedOidc: &oidcPolicyCfg{
@@ -8300,6 +8432,8 @@ func TestGeneratePoliciesFails(t *testing.T) {
 							JWKSURI:           "https://foo.com/certs",
 							ClientID:          "foo",
 							AccessTokenEnable: true,
+							IdPType:           "azuread",
+							AllowedTenants:    []string{"9188040d-6c67-4c5b-b112-36a304b66dad", "72f988bf-86f1-41af-91ab-2d7cd011db47"},
 						},
 					},
 				},
@@ -8342,18 +8476,28 @@ func TestGeneratePoliciesFails(t *testing.T) {
 				},
 			},
 			expectedOidc: &oidcPolicyCfg{
-				&version2.OIDC{
-					AuthEndpoint:      "https://foo.com/auth",
-					TokenEndpoint:     "https://foo.com/token",
-					JwksURI:           "https://foo.com/certs",
-					ClientID:          "foo",
-					ClientSecret:      "super_secret_123",
-					RedirectURI:       "/_codexch",
-					Scope:             "openid",
-					ZoneSyncLeeway:    200,
-					AccessTokenEnable: true,
-				},
-				"default/oidc-policy",
+				oidc: &version2.OIDC{
+					AuthEndpoint:       "https://foo.com/auth",
+					TokenEndpoint:      "https://foo.com/token",
+					JwksURI:            "https://foo.com/certs",
+					ClientID:           "foo",
+					ClientSecret:       "super_secret_123",
+					RedirectURI:        "/_codexch",
+					Scope:              "openid",
+					ZoneSyncLeeway:     200,
+					AccessTokenEnable:  true,
+					NonceEnforce:       true,
+					StateKey:           "22a3746d9d89136cfc5360f7dbda631ea08b4b32e9446bb3abdf568cfc432143",
+					CookieSameSite:     "Lax",
+					StepUpMaxAge:       300,
+					LoginRedirectPaths: "/",
+					SessionInfoClaims:  "sub name email",
+					Policy:             "default/oidc-policy",
+					LogLevel:           "info",
+					IdPType:            "azuread",
+					AllowedTenants:     "9188040d-6c67-4c5b-b112-36a304b66dad 72f988bf-86f1-41af-91ab-2d7cd011db47",
+				},
+				key: "default/oidc-policy",
 			},
 			msg: "multi oidc",
 		},
@@ -8493,6 +8637,50 @@ func TestGeneratePoliciesFails(t *testing.T) {
 			expectedOidc: &oidcPolicyCfg{},
 			msg:          "api key referencing wrong secret type",
 		},
+		{
+			policyRefs: []conf_v1.PolicyReference{
+				{
+					Name:      "client-credentials-policy",
+					Namespace: "default",
+				},
+			},
+			policies: map[string]*conf_v1.Policy{
+				"default/client-credentials-policy": {
+					ObjectMeta: meta_v1.ObjectMeta{
+						Name:      "client-credentials-policy",
+						Namespace: "default",
+					},
+					Spec: conf_v1.PolicySpec{
+						ClientCredentials: &conf_v1.ClientCredentials{
+							TokenEndpoint: "https://idp.example.com/token",
+							ClientID:      "client",
+							ClientSecret:  "client-credentials-secret",
+						},
+					},
+				},
+			},
+			policyOpts: policyOptions{
+				secretRefs: map[string]*secrets.SecretReference{
+					"default/client-credentials-secret": {
+						Secret: &api_v1.Secret{
+							Type: secrets.SecretTypeJWK,
+						},
+					},
+				},
+			},
+			expected: policiesCfg{
+				ErrorReturn: &version2.Return{
+					Code: 500,
+				},
+			},
+			expectedWarnings: Warnings{
+				nil: {
+					`Client Credentials policy default/client-credentials-policy references a secret default/client-credentials-secret of a wrong type 'nginx.org/jwk', must be 'nginx.org/oidc'`,
+				},
+			},
+			expectedOidc: &oidcPolicyCfg{},
+			msg:          "client credentials referencing wrong secret type",
+		},
 		{
 			policyRefs: []conf_v1.PolicyReference{
 				{
@@ -8733,6 +8921,1668 @@ func TestAddPoliciesCfgToLocations(t *testing.T) {
 	}
 }
 
+func TestAddClientCredentialsToRoute(t *testing.T) {
+	t.Parallel()
+	clientCredentials := &version2.ClientCredentials{Key: "default/client-credentials-policy"}
+	tests := []struct {
+		serverCfg        policiesCfg
+		routeCfg         policiesCfg
+		expectedRouteCfg policiesCfg
+		expectedEnabled  bool
+		expectedWarnings int
+		msg              string
+	}{
+		{
+			serverCfg:        policiesCfg{ClientCredentials: clientCredentials},
+			routeCfg:         policiesCfg{},
+			expectedRouteCfg: policiesCfg{ClientCredentials: clientCredentials},
+			expectedEnabled:  true,
+			msg:              "route inherits the policy of the VirtualServer",
+		},
+		{
+			serverCfg:        policiesCfg{},
+			routeCfg:         policiesCfg{ClientCredentials: clientCredentials},
+			expectedRouteCfg: policiesCfg{ClientCredentials: clientCredentials},
+			expectedEnabled:  true,
+			msg:              "route policy",
+		},
+		{
+			serverCfg:        policiesCfg{ClientCredentials: clientCredentials},
+			routeCfg:         policiesCfg{ErrorReturn: &version2.Return{Code: 500}},
+			expectedRouteCfg: policiesCfg{ErrorReturn: &version2.Return{Code: 500}},
+			msg:              "route with invalid policies",
+		},
+		{
+			serverCfg: policiesCfg{APIKey: &version2.APIKey{}},
+			routeCfg:  policiesCfg{ClientCredentials: clientCredentials},
+			expectedRouteCfg: policiesCfg{
+				ClientCredentials: clientCredentials,
+				ErrorReturn:       &version2.Return{Code: 500},
+			},
+			expectedWarnings: 1,
+			msg:              "api key policy of the VirtualServer",
+		},
+	}
+
+	for _, test := range tests {
+		vsc := &virtualServerConfigurator{warnings: newWarnings()}
+		vsc.addClientCredentialsToRoute(nil, &test.serverCfg, &test.routeCfg)
+		if diff := cmp.Diff(test.expectedRouteCfg, test.routeCfg); diff != "" {
+			t.Errorf("addClientCredentialsToRoute() '%s' mismatch (-want +got):\n%s", test.msg, diff)
+		}
+		if test.serverCfg.ClientCredentialsEnabled != test.expectedEnabled {
+			t.Errorf("addClientCredentialsToRoute() '%s' set ClientCredentialsEnabled to %v, want %v", test.msg, test.serverCfg.ClientCredentialsEnabled, test.expectedEnabled)
+		}
+		if len(vsc.warnings[nil]) != test.expectedWarnings {
+			t.Errorf("addClientCredentialsToRoute() '%s' returned warnings %v, want %d", test.msg, vsc.warnings, test.expectedWarnings)
+		}
+	}
+}
+
+func TestAddTokenExchangeToRoute(t *testing.T) {
+	t.Parallel()
+	route := conf_v1.Route{
+		Path: "/orders",
+		TokenExchange: &conf_v1.TokenExchange{
+			Audience: "orders-api",
+		},
+	}
+	tests := []struct {
+		route            conf_v1.Route
+		serverCfg        policiesCfg
+		routeCfg         policiesCfg
+		expectedRouteCfg policiesCfg
+		expectedWarnings int
+		msg              string
+	}{
+		{
+			route:            conf_v1.Route{Path: "/orders"},
+			routeCfg:         policiesCfg{OIDC: true},
+			expectedRouteCfg: policiesCfg{OIDC: true},
+			msg:              "no token exchange",
+		},
+		{
+			route:            route,
+			routeCfg:         policiesCfg{OIDC: true},
+			expectedRouteCfg: policiesCfg{OIDC: true, TokenExchangeAudience: "orders-api"},
+			msg:              "token exchange",
+		},
+		{
+			route:            route,
+			routeCfg:         policiesCfg{},
+			expectedRouteCfg: policiesCfg{},
+			expectedWarnings: 1,
+			msg:              "token exchange without oidc policy",
+		},
+		{
+			route:     route,
+			serverCfg: policiesCfg{APIKey: &version2.APIKey{}},
+			routeCfg:  policiesCfg{OIDC: true},
+			expectedRouteCfg: policiesCfg{
+				OIDC:        true,
+				ErrorReturn: &version2.Return{Code: 500},
+			},
+			expectedWarnings: 1,
+			msg:              "token exchange with api key policy",
+		},
+		{
+			route:    route,
+			routeCfg: policiesCfg{OIDC: true, ClientCredentials: &version2.ClientCredentials{}},
+			expectedRouteCfg: policiesCfg{
+				OIDC:              true,
+				ClientCredentials: &version2.ClientCredentials{},
+				ErrorReturn:       &version2.Return{Code: 500},
+			},
+			expectedWarnings: 1,
+			msg:              "token exchange with client credentials policy",
+		},
+	}
+
+	for _, test := range tests {
+		vsc := &virtualServerConfigurator{warnings: newWarnings()}
+		vsc.addTokenExchangeToRoute(nil, test.route, &test.serverCfg, &test.routeCfg)
+		if diff := cmp.Diff(test.expectedRouteCfg, test.routeCfg); diff != "" {
+			t.Errorf("addTokenExchangeToRoute() '%s' mismatch (-want +got):\n%s", test.msg, diff)
+		}
+		if len(vsc.warnings[nil]) != test.expectedWarnings {
+			t.Errorf("addTokenExchangeToRoute() '%s' returned warnings %v, want %d", test.msg, vsc.warnings, test.expectedWarnings)
+		}
+	}
+}
+
+func TestAddStepUpToRoute(t *testing.T) {
+	t.Parallel()
+	route := conf_v1.Route{Path: "/payments", StepUpRequired: true}
+	tests := []struct {
+		route            conf_v1.Route
+		routeCfg         policiesCfg
+		expectedRouteCfg policiesCfg
+		expectedWarnings int
+		msg              string
+	}{
+		{
+			route:            conf_v1.Route{Path: "/payments"},
+			routeCfg:         policiesCfg{OIDC: true},
+			expectedRouteCfg: policiesCfg{OIDC: true},
+			msg:              "no step-up",
+		},
+		{
+			route:            route,
+			routeCfg:         policiesCfg{OIDC: true},
+			expectedRouteCfg: policiesCfg{OIDC: true, OIDCStepUp: true},
+			msg:              "step-up",
+		},
+		{
+			route:            route,
+			routeCfg:         policiesCfg{},
+			expectedRouteCfg: policiesCfg{},
+			expectedWarnings: 1,
+			msg:              "step-up without oidc policy",
+		},
+	}
+
+	for _, test := range tests {
+		vsc := &virtualServerConfigurator{warnings: newWarnings()}
+		vsc.addStepUpToRoute(nil, test.route, &test.routeCfg)
+		if diff := cmp.Diff(test.expectedRouteCfg, test.routeCfg); diff != "" {
+			t.Errorf("addStepUpToRoute() '%s' mismatch (-want +got):\n%s", test.msg, diff)
+		}
+		if len(vsc.warnings[nil]) != test.expectedWarnings {
+			t.Errorf("addStepUpToRoute() '%s' returned warnings %v, want %d", test.msg, vsc.warnings, test.expectedWarnings)
+		}
+	}
+}
+
+func TestOIDCRememberMeSeconds(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		rememberMe *conf_v1.OIDCRememberMe
+		expected   int
+	}{
+		{rememberMe: nil, expected: 0},
+		{rememberMe: &conf_v1.OIDCRememberMe{Duration: "7d"}, expected: 0},
+		{rememberMe: &conf_v1.OIDCRememberMe{Enable: true}, expected: 2592000},
+		{rememberMe: &conf_v1.OIDCRememberMe{Enable: true, Duration: "7d"}, expected: 604800},
+	}
+	for _, test := range tests {
+		if got := OIDCRememberMeSeconds(test.rememberMe); got != test.expected {
+			t.Errorf("OIDCRememberMeSeconds(%+v) returned %d, want %d", test.rememberMe, got, test.expected)
+		}
+	}
+}
+
+func TestGenerateOIDCStateKey(t *testing.T) {
+	t.Parallel()
+	secret := &api_v1.Secret{
+		Data: map[string][]byte{
+			ClientSecretKey: []byte("super_secret_123"),
+		},
+	}
+	derived := generateOIDCStateKey(secret)
+	if len(derived) != 64 {
+		t.Errorf("generateOIDCStateKey() returned %q, want a hex encoded SHA-256 HMAC", derived)
+	}
+	if derived == "super_secret_123" || derived != generateOIDCStateKey(secret) {
+		t.Errorf("generateOIDCStateKey() returned %q, want a stable key different from the client secret", derived)
+	}
+
+	secret.Data[OIDCStateKey] = []byte("state_secret")
+	if got := generateOIDCStateKey(secret); got != "state_secret" {
+		t.Errorf("generateOIDCStateKey() returned %q, want %q", got, "state_secret")
+	}
+}
+
+func TestGenerateOIDCSessionCookieKeys(t *testing.T) {
+	t.Parallel()
+	secret := &api_v1.Secret{
+		Data: map[string][]byte{
+			secrets.OIDCSessionKey: []byte("Nw2Xy7w3RbRzP3o0k4Gq8Tt5Qd9Vv1Ls"),
+		},
+	}
+	current := generateOIDCSessionCookieKeys(secret)
+	if len(current) != 64 {
+		t.Errorf("generateOIDCSessionCookieKeys() returned %q, want a hex encoded AES-256 key", current)
+	}
+
+	secret.Data[secrets.OIDCPreviousSessionKey] = []byte("Kc8Jm2Pq5Rs7Tu9Vw1Xy3Za5Bc7De9Fg")
+	keys := strings.Fields(generateOIDCSessionCookieKeys(secret))
+	if len(keys) != 2 || keys[0] != current || keys[1] == current {
+		t.Errorf("generateOIDCSessionCookieKeys() returned %v, want the current key followed by the previous key", keys)
+	}
+}
+
+func TestGenerateOIDCPreviousSessionKeyExpiry(t *testing.T) {
+	t.Parallel()
+	secret := &api_v1.Secret{
+		Data: map[string][]byte{
+			secrets.OIDCSessionKey: []byte("Nw2Xy7w3RbRzP3o0k4Gq8Tt5Qd9Vv1Ls"),
+		},
+	}
+	if expiry, ok := generateOIDCPreviousSessionKeyExpiry(secret, "24h"); expiry != 0 || !ok {
+		t.Errorf("generateOIDCPreviousSessionKeyExpiry() returned %v, %v without a previous key, want 0, true", expiry, ok)
+	}
+
+	secret.Data[secrets.OIDCPreviousSessionKey] = []byte("Kc8Jm2Pq5Rs7Tu9Vw1Xy3Za5Bc7De9Fg")
+	if expiry, ok := generateOIDCPreviousSessionKeyExpiry(secret, "24h"); expiry != 0 || ok {
+		t.Errorf("generateOIDCPreviousSessionKeyExpiry() returned %v, %v without the rotation annotation, want 0, false", expiry, ok)
+	}
+
+	secret.Annotations = map[string]string{secrets.OIDCKeysRotatedAtAnnotation: "2024-01-01T00:00:00Z"}
+	if expiry, ok := generateOIDCPreviousSessionKeyExpiry(secret, "24h"); expiry != 1704153600 || !ok {
+		t.Errorf("generateOIDCPreviousSessionKeyExpiry() returned %v, %v, want 1704153600, true", expiry, ok)
+	}
+}
+
+func TestGenerateOIDCResourceArgs(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		resources []string
+		expected  string
+	}{
+		{
+			resources: nil,
+			expected:  "",
+		},
+		{
+			resources: []string{"https://api.example.com/orders", "urn:example:inventory"},
+			expected:  "&resource=https%3A%2F%2Fapi.example.com%2Forders&resource=urn%3Aexample%3Ainventory",
+		},
+	}
+	for _, test := range tests {
+		if got := generateOIDCResourceArgs(test.resources); got != test.expected {
+			t.Errorf("generateOIDCResourceArgs(%v) returned %q, want %q", test.resources, got, test.expected)
+		}
+	}
+}
+
+func TestGenerateOIDCResolver(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		resolver *conf_v1.OIDCResolver
+		expected string
+	}{
+		{
+			resolver: nil,
+			expected: "",
+		},
+		{
+			resolver: &conf_v1.OIDCResolver{Addresses: []string{"10.0.0.10"}},
+			expected: "10.0.0.10",
+		},
+		{
+			resolver: &conf_v1.OIDCResolver{
+				Addresses: []string{"10.0.0.10", "[fd00::10]:5353"},
+				Valid:     "30s",
+				IPv6:      createPointerFromBool(false),
+			},
+			expected: "10.0.0.10 [fd00::10]:5353 valid=30s ipv6=off",
+		},
+	}
+	for _, test := range tests {
+		if got := generateOIDCResolver(test.resolver); got != test.expected {
+			t.Errorf("generateOIDCResolver(%+v) returned %q, want %q", test.resolver, got, test.expected)
+		}
+	}
+}
+
+func TestGenerateOIDCClaimRules(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		rules    []conf_v1.ClaimRule
+		expected string
+	}{
+		{
+			rules:    nil,
+			expected: "",
+		},
+		{
+			rules:    []conf_v1.ClaimRule{{Claim: "groups", Values: []string{"admins"}}},
+			expected: base64.StdEncoding.EncodeToString([]byte(`[{"claim":"groups","values":["admins"]}]`)),
+		},
+	}
+	for _, test := range tests {
+		if got := generateOIDCClaimRules(test.rules); got != test.expected {
+			t.Errorf("generateOIDCClaimRules(%v) returned %q, want %q", test.rules, got, test.expected)
+		}
+	}
+}
+
+func TestAddJWTAuthConfigWithClaimRules(t *testing.T) {
+	t.Parallel()
+	jwtAuth := &conf_v1.JWTAuth{
+		Realm:   "My API",
+		JwksURI: "https://idp.example.com/keys",
+		ClaimRules: []conf_v1.ClaimRule{
+			{Claim: "groups", Values: []string{"admins", "ops.team"}},
+			{Claim: "tenant_id", Values: []string{"tenant-1"}},
+		},
+	}
+	var cfg policiesCfg
+	res := cfg.addJWTAuthConfig(jwtAuth, "default/jwt-policy", "default", "default", "cafe", nil)
+	if res.isError || len(res.warnings) > 0 {
+		t.Fatalf("addJWTAuthConfig() returned unexpected results: %v", res)
+	}
+
+	expectedClaimRules := []string{
+		"jwt_claim_rule_default_cafe_default_jwt_policy_0",
+		"jwt_claim_rule_default_cafe_default_jwt_policy_1",
+	}
+	if !cmp.Equal(expectedClaimRules, cfg.JWTAuth.ClaimRules) {
+		t.Errorf("addJWTAuthConfig() returned unexpected claim rules: %v", cmp.Diff(expectedClaimRules, cfg.JWTAuth.ClaimRules))
+	}
+
+	expectedMaps := map[string]version2.Map{
+		"jwt_claim_rule_default_cafe_default_jwt_policy_0": {
+			Source:   "$jwt_claim_groups",
+			Variable: "$jwt_claim_rule_default_cafe_default_jwt_policy_0",
+			Parameters: []version2.Parameter{
+				{Value: "default", Result: `""`},
+				{Value: `"~(^|,)(admins|ops\.team)(,|$)"`, Result: "1"},
+			},
+		},
+		"jwt_claim_rule_default_cafe_default_jwt_policy_1": {
+			Source:   "$jwt_claim_tenant_id",
+			Variable: "$jwt_claim_rule_default_cafe_default_jwt_policy_1",
+			Parameters: []version2.Parameter{
+				{Value: "default", Result: `""`},
+				{Value: `"~(^|,)(tenant-1)(,|$)"`, Result: "1"},
+			},
+		},
+	}
+	if !cmp.Equal(expectedMaps, cfg.JWTClaimRuleMaps) {
+		t.Errorf("addJWTAuthConfig() returned unexpected maps: %v", cmp.Diff(expectedMaps, cfg.JWTClaimRuleMaps))
+	}
+}
+
+func TestAddOIDCConfigWithProvider(t *testing.T) {
+	t.Parallel()
+	oidc := &conf_v1.OIDC{
+		AuthEndpoint:      "https://idp.example.com/auth",
+		TokenEndpoint:     "https://idp.example.com/token",
+		DiscoveryEndpoint: "https://idp.example.com/.well-known/openid-configuration",
+		ClientID:          "client",
+		ClientSecret:      "oidc-secret",
+	}
+	secretRefs := map[string]*secrets.SecretReference{
+		"default/oidc-secret": {
+			Secret: &api_v1.Secret{
+				Type: secrets.SecretTypeOIDC,
+				Data: map[string][]byte{
+					"client-secret": []byte("super_secret_123"),
+				},
+			},
+		},
+	}
+	previousSecret := &api_v1.Secret{
+		Type: secrets.SecretTypeOIDC,
+		Data: map[string][]byte{
+			"client-secret": []byte("previous_secret_123"),
+		},
+	}
+	oidcProviders := map[string]*OIDCProvider{
+		"default/oidc-policy": {
+			JwksURI:            "https://idp.example.com/discovered-certs",
+			RevocationEndpoint: "https://idp.example.com/revoke",
+			EndSessionEndpoint: "https://idp.example.com/logout",
+			JwksFile:           "/var/lib/nginx/oidc/jwks/default_oidc-policy.json",
+			PreviousSecret:     previousSecret,
+		},
+	}
+
+	p := &policiesCfg{}
+	oidcPolCfg := &oidcPolicyCfg{}
+	res := p.addOIDCConfig(oidc, "default/oidc-policy", "default", secretRefs, nil, oidcProviders, oidcPolCfg)
+	if res.isError || len(res.warnings) != 0 {
+		t.Fatalf("addOIDCConfig() returned unexpected result %+v", res)
+	}
+	if oidcPolCfg.oidc.JwksURI != "https://idp.example.com/discovered-certs" {
+		t.Errorf("addOIDCConfig() set JwksURI %q, want the jwks_uri of the discovery document", oidcPolCfg.oidc.JwksURI)
+	}
+	if oidcPolCfg.oidc.JwksFile != "/var/lib/nginx/oidc/jwks/default_oidc-policy.json" {
+		t.Errorf("addOIDCConfig() set JwksFile %q, want the file of the provider", oidcPolCfg.oidc.JwksFile)
+	}
+	if oidcPolCfg.oidc.PreviousStateKey != generateOIDCStateKey(previousSecret) {
+		t.Errorf("addOIDCConfig() set PreviousStateKey %q, want the state key of the previous secret", oidcPolCfg.oidc.PreviousStateKey)
+	}
+	if oidcPolCfg.oidc.RevocationEndpoint != "https://idp.example.com/revoke" {
+		t.Errorf("addOIDCConfig() set RevocationEndpoint %q, want the revocation_endpoint of the discovery document", oidcPolCfg.oidc.RevocationEndpoint)
+	}
+	if oidcPolCfg.oidc.EndSessionEndpoint != "" {
+		t.Errorf("addOIDCConfig() set EndSessionEndpoint %q, want none without idpType keycloak", oidcPolCfg.oidc.EndSessionEndpoint)
+	}
+
+	// the revocationEndpoint of the policy takes precedence
+	oidc.RevocationEndpoint = "https://idp.example.com/oauth2/revoke"
+	oidcPolCfg = &oidcPolicyCfg{}
+	p = &policiesCfg{}
+	p.addOIDCConfig(oidc, "default/oidc-policy", "default", secretRefs, nil, oidcProviders, oidcPolCfg)
+	if oidcPolCfg.oidc.RevocationEndpoint != "https://idp.example.com/oauth2/revoke" {
+		t.Errorf("addOIDCConfig() set RevocationEndpoint %q, want the revocationEndpoint of the policy", oidcPolCfg.oidc.RevocationEndpoint)
+	}
+}
+
+func TestAddOIDCConfigWithPathPrefix(t *testing.T) {
+	t.Parallel()
+	oidc := &conf_v1.OIDC{
+		AuthEndpoint:  "https://idp.example.com/auth",
+		TokenEndpoint: "https://idp.example.com/token",
+		JWKSURI:       "https://idp.example.com/certs",
+		ClientID:      "client",
+		ClientSecret:  "oidc-secret",
+		PathPrefix:    "/oidc",
+	}
+	secretRefs := map[string]*secrets.SecretReference{
+		"default/oidc-secret": {
+			Secret: &api_v1.Secret{
+				Type: secrets.SecretTypeOIDC,
+				Data: map[string][]byte{
+					"client-secret": []byte("super_secret_123"),
+				},
+			},
+		},
+	}
+
+	p := &policiesCfg{}
+	oidcPolCfg := &oidcPolicyCfg{}
+	res := p.addOIDCConfig(oidc, "default/oidc-policy", "default", secretRefs, nil, nil, oidcPolCfg)
+	if res.isError || len(res.warnings) != 0 {
+		t.Fatalf("addOIDCConfig() returned unexpected result %+v", res)
+	}
+	if oidcPolCfg.oidc.RedirectURI != "/oidc/_codexch" {
+		t.Errorf("addOIDCConfig() set RedirectURI %q, want %q", oidcPolCfg.oidc.RedirectURI, "/oidc/_codexch")
+	}
+	if want := "oidc/" + OIDCLocationsConfigName("/oidc", false) + ".conf"; oidcPolCfg.oidc.LocationsFile != want {
+		t.Errorf("addOIDCConfig() set LocationsFile %q, want %q", oidcPolCfg.oidc.LocationsFile, want)
+	}
+}
+
+func TestAddOIDCRateLimitConfig(t *testing.T) {
+	t.Parallel()
+	oidc := &conf_v1.OIDC{
+		AuthEndpoint:  "https://idp.example.com/auth",
+		TokenEndpoint: "https://idp.example.com/token",
+		JWKSURI:       "https://idp.example.com/certs",
+		ClientID:      "client",
+		ClientSecret:  "oidc-secret",
+		RateLimit:     &conf_v1.OIDCRateLimit{Rate: "10r/m", Burst: createPointerFromInt(5)},
+	}
+	secretRefs := map[string]*secrets.SecretReference{
+		"default/oidc-secret": {
+			Secret: &api_v1.Secret{
+				Type: secrets.SecretTypeOIDC,
+				Data: map[string][]byte{
+					"client-secret": []byte("super_secret_123"),
+				},
+			},
+		},
+	}
+
+	p := &policiesCfg{}
+	oidcPolCfg := &oidcPolicyCfg{}
+	res := p.addOIDCConfig(oidc, "default/oidc-policy", "default", secretRefs, nil, nil, oidcPolCfg)
+	if res.isError || len(res.warnings) != 0 {
+		t.Fatalf("addOIDCConfig() returned unexpected result %+v", res)
+	}
+	p.addOIDCRateLimitConfig(oidc.RateLimit, "default", "oidc-policy", "default", "cafe", oidcPolCfg)
+
+	wantZones := []version2.LimitReqZone{
+		{
+			ZoneName: "oidc_rl_default_oidc-policy_default_cafe",
+			Key:      "$oidc_rate_limit_key",
+			ZoneSize: "10m",
+			Rate:     "10r/m",
+		},
+	}
+	if !cmp.Equal(wantZones, p.LimitReqZones) {
+		t.Errorf("addOIDCRateLimitConfig() set LimitReqZones %v", cmp.Diff(wantZones, p.LimitReqZones))
+	}
+	wantLimitReq := &version2.LimitReq{ZoneName: "oidc_rl_default_oidc-policy_default_cafe", Burst: 5, NoDelay: true}
+	if !cmp.Equal(wantLimitReq, oidcPolCfg.oidc.RateLimit) {
+		t.Errorf("addOIDCRateLimitConfig() set RateLimit %v", cmp.Diff(wantLimitReq, oidcPolCfg.oidc.RateLimit))
+	}
+	if len(p.LimitReqs) != 0 {
+		t.Errorf("addOIDCRateLimitConfig() set LimitReqs %v, want none", p.LimitReqs)
+	}
+}
+
+func TestGenerateOIDCLoginLockout(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		lockout  *conf_v1.OIDCLoginLockout
+		expected *version2.OIDCLoginLockout
+		msg      string
+	}{
+		{
+			lockout:  nil,
+			expected: nil,
+			msg:      "no lockout",
+		},
+		{
+			lockout:  &conf_v1.OIDCLoginLockout{MaxFailures: 5},
+			expected: &version2.OIDCLoginLockout{MaxFailures: 5, Window: 600, Duration: 900},
+			msg:      "default window and duration",
+		},
+		{
+			lockout:  &conf_v1.OIDCLoginLockout{MaxFailures: 3, Window: "1m", Duration: "1h", Action: "block"},
+			expected: &version2.OIDCLoginLockout{MaxFailures: 3, Window: 60, Duration: 3600},
+			msg:      "block",
+		},
+		{
+			lockout:  &conf_v1.OIDCLoginLockout{MaxFailures: 3, Action: "tarpit"},
+			expected: &version2.OIDCLoginLockout{MaxFailures: 3, Window: 600, Duration: 900, TarpitDelay: 10},
+			msg:      "tarpit with the default delay",
+		},
+		{
+			lockout:  &conf_v1.OIDCLoginLockout{MaxFailures: 3, Action: "tarpit", TarpitDelay: "30s"},
+			expected: &version2.OIDCLoginLockout{MaxFailures: 3, Window: 600, Duration: 900, TarpitDelay: 30},
+			msg:      "tarpit",
+		},
+	}
+	for _, test := range tests {
+		result := generateOIDCLoginLockout(test.lockout)
+		if !cmp.Equal(test.expected, result) {
+			t.Errorf("generateOIDCLoginLockout() returned unexpected result for the case of %s: %v", test.msg, cmp.Diff(test.expected, result))
+		}
+	}
+}
+
+func TestGenerateOIDCRedirectAllowlist(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		allowlist []string
+		expected  string
+		msg       string
+	}{
+		{
+			allowlist: nil,
+			expected:  "",
+			msg:       "no allowlist",
+		},
+		{
+			allowlist: []string{"/app/", "/Account"},
+			expected:  "/app/ /Account",
+			msg:       "paths",
+		},
+		{
+			allowlist: []string{"HTTPS://App.Example.com", "http://portal.example.com:8080/Home/"},
+			expected:  "https://app.example.com/ http://portal.example.com:8080/Home/",
+			msg:       "URLs",
+		},
+	}
+	for _, test := range tests {
+		result := generateOIDCRedirectAllowlist(test.allowlist)
+		if result != test.expected {
+			t.Errorf("generateOIDCRedirectAllowlist() returned %q for the case of %s, want %q", result, test.msg, test.expected)
+		}
+	}
+}
+
+func TestGenerateOIDCSecurityHeaders(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		headers  *conf_v1.OIDCSecurityHeaders
+		expected version2.OIDCSecurityHeaders
+		msg      string
+	}{
+		{
+			headers:  nil,
+			expected: version2.OIDCSecurityHeaders{},
+			msg:      "no security headers",
+		},
+		{
+			headers: &conf_v1.OIDCSecurityHeaders{},
+			expected: version2.OIDCSecurityHeaders{
+				ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+				FrameOptions:          "DENY",
+				ReferrerPolicy:        "no-referrer",
+			},
+			msg: "default security headers",
+		},
+		{
+			headers: &conf_v1.OIDCSecurityHeaders{
+				ContentSecurityPolicy: "default-src 'none'; style-src https://cdn.example.com",
+				FrameOptions:          "SAMEORIGIN",
+				ReferrerPolicy:        "same-origin",
+			},
+			expected: version2.OIDCSecurityHeaders{
+				ContentSecurityPolicy: "default-src 'none'; style-src https://cdn.example.com",
+				FrameOptions:          "SAMEORIGIN",
+				ReferrerPolicy:        "same-origin",
+			},
+			msg: "custom security headers",
+		},
+	}
+	for _, test := range tests {
+		result := generateOIDCSecurityHeaders(test.headers)
+		if result != test.expected {
+			t.Errorf("generateOIDCSecurityHeaders() returned %+v for the case of %s, want %+v", result, test.msg, test.expected)
+		}
+	}
+}
+
+func TestPrefixOIDCLocations(t *testing.T) {
+	t.Parallel()
+	content := `    location = /_jwks_uri {
+        internal;
+    }
+
+    location @do_oidc_flow {
+        js_content oidc.auth;
+    }
+
+    location = /logout {
+        js_content oidc.logout;
+    }
+
+    location = /_jarm_validation {
+        auth_jwt_key_request /_jwks_uri;
+    }
+`
+	want := `    location = /auth/oidc/_jwks_uri {
+        internal;
+    }
+
+    location @do_oidc_flow {
+        js_content oidc.auth;
+    }
+
+    location = /auth/oidc/logout {
+        js_content oidc.logout;
+    }
+
+    location = /auth/oidc/_jarm_validation {
+        auth_jwt_key_request /auth/oidc/_jwks_uri;
+    }
+`
+	if got := string(prefixOIDCLocations([]byte(content), "/auth/oidc")); got != want {
+		t.Errorf("prefixOIDCLocations() returned %q, want %q", got, want)
+	}
+}
+
+func TestTraceOIDCLocations(t *testing.T) {
+	t.Parallel()
+	content := `    location = /_token {
+        internal;
+        set                   $oidc_trace_step "code_exchange"; # Tagged on the span when tracing is enabled
+        proxy_pass            $oidc_token_endpoint;
+    }
+
+    location = /_id_token_validation {
+        internal;
+    }
+`
+	want := `    location = /_token {
+        internal;
+        set                   $oidc_trace_step "code_exchange"; # Tagged on the span when tracing is enabled
+        opentracing_tag oidc.step $oidc_trace_step;
+        opentracing_tag oidc.idp_endpoint $oidc_trace_endpoint;
+        opentracing_tag oidc.idp_status $upstream_status;
+        proxy_pass            $oidc_token_endpoint;
+    }
+
+    location = /_id_token_validation {
+        internal;
+    }
+`
+	if got := string(traceOIDCLocations([]byte(content))); got != want {
+		t.Errorf("traceOIDCLocations() returned %q, want %q", got, want)
+	}
+}
+
+func TestOIDCLocationsConfigName(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		pathPrefix string
+		tracing    bool
+		expected   string
+	}{
+		{
+			pathPrefix: "/oidc",
+			tracing:    false,
+			expected:   "oidc-e659d3880c441c04",
+		},
+		{
+			pathPrefix: "/oidc",
+			tracing:    true,
+			expected:   "oidc-e659d3880c441c04-tracing",
+		},
+		{
+			pathPrefix: "",
+			tracing:    true,
+			expected:   "oidc-tracing",
+		},
+	}
+	for _, test := range tests {
+		if got := OIDCLocationsConfigName(test.pathPrefix, test.tracing); got != test.expected {
+			t.Errorf("OIDCLocationsConfigName(%q, %v) returned %q, want %q", test.pathPrefix, test.tracing, got, test.expected)
+		}
+	}
+}
+
+func TestOIDCLocationConflict(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		pathPrefix string
+		routePaths []string
+		expected   string
+	}{
+		{
+			routePaths: []string{"/", "/api", "~ ^/logout", "/logout/all"},
+			expected:   "",
+		},
+		{
+			routePaths: []string{"/", "/logout"},
+			expected:   "/logout",
+		},
+		{
+			routePaths: []string{"=/_codexch"},
+			expected:   "=/_codexch",
+		},
+		{
+			pathPrefix: "/oidc",
+			routePaths: []string{"/logout", "/userinfo", "/oidc"},
+			expected:   "",
+		},
+		{
+			pathPrefix: "/oidc",
+			routePaths: []string{"/oidc/logout"},
+			expected:   "/oidc/logout",
+		},
+	}
+	for _, test := range tests {
+		oidc := &conf_v1.OIDC{PathPrefix: test.pathPrefix}
+		if got := oidcLocationConflict(oidc, test.routePaths); got != test.expected {
+			t.Errorf("oidcLocationConflict(%q, %v) returned %q, want %q", test.pathPrefix, test.routePaths, got, test.expected)
+		}
+	}
+}
+
+func TestAddOIDCConfigWithManagedKeys(t *testing.T) {
+	t.Parallel()
+	oidc := &conf_v1.OIDC{
+		AuthEndpoint:  "https://idp.example.com/auth",
+		TokenEndpoint: "https://idp.example.com/token",
+		JWKSURI:       "https://idp.example.com/certs",
+		ClientID:      "client",
+		ClientSecret:  "oidc-secret",
+		SessionStore:  &conf_v1.OIDCSessionStore{Type: "cookie"},
+		ManagedKeys:   &conf_v1.OIDCManagedKeys{Enable: true, RotationInterval: "24h"},
+	}
+	clientSecret := &api_v1.Secret{
+		Type: secrets.SecretTypeOIDC,
+		Data: map[string][]byte{
+			"client-secret": []byte("super_secret_123"),
+		},
+	}
+	secretRefs := map[string]*secrets.SecretReference{
+		"default/oidc-secret": {Secret: clientSecret},
+	}
+
+	// the policy waits until the Ingress Controller generates the keys
+	p := &policiesCfg{}
+	oidcPolCfg := &oidcPolicyCfg{}
+	res := p.addOIDCConfig(oidc, "default/oidc-policy", "default", secretRefs, nil, nil, oidcPolCfg)
+	if !res.isError || len(res.warnings) != 1 {
+		t.Fatalf("addOIDCConfig() returned %+v without the Secret of the managed keys, want an error", res)
+	}
+
+	managedKeys := &api_v1.Secret{
+		Type: secrets.SecretTypeOIDCSessionKey,
+		Data: map[string][]byte{
+			secrets.OIDCSessionKey: []byte("Nw2Xy7w3RbRzP3o0k4Gq8Tt5Qd9Vv1Ls"),
+			secrets.OIDCStateKey:   []byte("Kc8Jm2Pq5Rs7Tu9Vw1Xy3Za5Bc7De9Fg"),
+			secrets.OIDCHMACKey:    []byte("Hm4Ac2Kd6Ey8Fz0Gb1Ic3Jd5Ke7Lf9Mg"),
+		},
+	}
+	secretRefs["default/oidc-policy-oidc-keys"] = &secrets.SecretReference{Secret: managedKeys}
+	p = &policiesCfg{}
+	oidcPolCfg = &oidcPolicyCfg{}
+	res = p.addOIDCConfig(oidc, "default/oidc-policy", "default", secretRefs, nil, nil, oidcPolCfg)
+	if res.isError || len(res.warnings) != 0 {
+		t.Fatalf("addOIDCConfig() returned unexpected result %+v", res)
+	}
+	if oidcPolCfg.oidc.SessionCookieKeys != generateOIDCSessionCookieKeys(managedKeys) {
+		t.Errorf("addOIDCConfig() set SessionCookieKeys %q, want the keys of the managed Secret", oidcPolCfg.oidc.SessionCookieKeys)
+	}
+	if oidcPolCfg.oidc.StateKey != "Kc8Jm2Pq5Rs7Tu9Vw1Xy3Za5Bc7De9Fg" || oidcPolCfg.oidc.HMACKey != "Hm4Ac2Kd6Ey8Fz0Gb1Ic3Jd5Ke7Lf9Mg" {
+		t.Errorf("addOIDCConfig() set StateKey %q and HMACKey %q, want the keys of the managed Secret", oidcPolCfg.oidc.StateKey, oidcPolCfg.oidc.HMACKey)
+	}
+	// the logins started before the switch to the managed keys complete
+	if oidcPolCfg.oidc.PreviousStateKey != generateOIDCStateKey(clientSecret) || oidcPolCfg.oidc.PreviousHMACKey != "" {
+		t.Errorf("addOIDCConfig() set PreviousStateKey %q and PreviousHMACKey %q, want the derived state key and no HMAC key",
+			oidcPolCfg.oidc.PreviousStateKey, oidcPolCfg.oidc.PreviousHMACKey)
+	}
+
+	managedKeys.Data[secrets.OIDCPreviousStateKey] = []byte("Pv1Ab2Cd3Ef4Gh5Ij6Kl7Mn8Op9Qr0St")
+	managedKeys.Data[secrets.OIDCPreviousHMACKey] = []byte("Ph1Ab2Cd3Ef4Gh5Ij6Kl7Mn8Op9Qr0St")
+	p = &policiesCfg{}
+	oidcPolCfg = &oidcPolicyCfg{}
+	p.addOIDCConfig(oidc, "default/oidc-policy", "default", secretRefs, nil, nil, oidcPolCfg)
+	if oidcPolCfg.oidc.PreviousStateKey != "Pv1Ab2Cd3Ef4Gh5Ij6Kl7Mn8Op9Qr0St" || oidcPolCfg.oidc.PreviousHMACKey != "Ph1Ab2Cd3Ef4Gh5Ij6Kl7Mn8Op9Qr0St" {
+		t.Errorf("addOIDCConfig() set PreviousStateKey %q and PreviousHMACKey %q, want the previous keys of the managed Secret",
+			oidcPolCfg.oidc.PreviousStateKey, oidcPolCfg.oidc.PreviousHMACKey)
+	}
+}
+
+func TestAddOIDCConfigWithKeycloak(t *testing.T) {
+	t.Parallel()
+	oidc := &conf_v1.OIDC{
+		IdPType:       "keycloak",
+		AuthEndpoint:  "https://keycloak.example.com/realms/apps/protocol/openid-connect/auth",
+		TokenEndpoint: "https://keycloak.example.com/realms/apps/protocol/openid-connect/token",
+		ClientID:      "client",
+		ClientSecret:  "oidc-secret",
+	}
+	secretRefs := map[string]*secrets.SecretReference{
+		"default/oidc-secret": {
+			Secret: &api_v1.Secret{
+				Type: secrets.SecretTypeOIDC,
+				Data: map[string][]byte{
+					"client-secret": []byte("super_secret_123"),
+				},
+			},
+		},
+	}
+
+	// the endpoints of the realm are used until the discovery document is fetched
+	p := &policiesCfg{}
+	oidcPolCfg := &oidcPolicyCfg{}
+	res := p.addOIDCConfig(oidc, "default/oidc-policy", "default", secretRefs, nil, nil, oidcPolCfg)
+	if res.isError || len(res.warnings) != 0 {
+		t.Fatalf("addOIDCConfig() returned unexpected result %+v", res)
+	}
+	if oidcPolCfg.oidc.JwksURI != "https://keycloak.example.com/realms/apps/protocol/openid-connect/certs" {
+		t.Errorf("addOIDCConfig() set JwksURI %q, want the certs endpoint of the realm", oidcPolCfg.oidc.JwksURI)
+	}
+	if oidcPolCfg.oidc.EndSessionEndpoint != "https://keycloak.example.com/realms/apps/protocol/openid-connect/logout" {
+		t.Errorf("addOIDCConfig() set EndSessionEndpoint %q, want the logout endpoint of the realm", oidcPolCfg.oidc.EndSessionEndpoint)
+	}
+	if oidcPolCfg.oidc.UserinfoEndpoint != "https://keycloak.example.com/realms/apps/protocol/openid-connect/userinfo" {
+		t.Errorf("addOIDCConfig() set UserinfoEndpoint %q, want the userinfo endpoint of the realm", oidcPolCfg.oidc.UserinfoEndpoint)
+	}
+
+	oidcProviders := map[string]*OIDCProvider{
+		"default/oidc-policy": {
+			JwksURI:            "https://sso.example.com/realms/apps/protocol/openid-connect/certs",
+			EndSessionEndpoint: "https://sso.example.com/realms/apps/protocol/openid-connect/logout",
+			UserinfoEndpoint:   "https://sso.example.com/realms/apps/protocol/openid-connect/userinfo",
+		},
+	}
+	p = &policiesCfg{}
+	oidcPolCfg = &oidcPolicyCfg{}
+	p.addOIDCConfig(oidc, "default/oidc-policy", "default", secretRefs, nil, oidcProviders, oidcPolCfg)
+	if oidcPolCfg.oidc.JwksURI != "https://sso.example.com/realms/apps/protocol/openid-connect/certs" {
+		t.Errorf("addOIDCConfig() set JwksURI %q, want the jwks_uri of the discovery document", oidcPolCfg.oidc.JwksURI)
+	}
+	if oidcPolCfg.oidc.EndSessionEndpoint != "https://sso.example.com/realms/apps/protocol/openid-connect/logout" {
+		t.Errorf("addOIDCConfig() set EndSessionEndpoint %q, want the end_session_endpoint of the discovery document", oidcPolCfg.oidc.EndSessionEndpoint)
+	}
+	if oidcPolCfg.oidc.UserinfoEndpoint != "https://sso.example.com/realms/apps/protocol/openid-connect/userinfo" {
+		t.Errorf("addOIDCConfig() set UserinfoEndpoint %q, want the userinfo_endpoint of the discovery document", oidcPolCfg.oidc.UserinfoEndpoint)
+	}
+}
+
+func TestAddOIDCConfigWithOkta(t *testing.T) {
+	t.Parallel()
+	secretRefs := map[string]*secrets.SecretReference{
+		"default/oidc-secret": {
+			Secret: &api_v1.Secret{
+				Type: secrets.SecretTypeOIDC,
+				Data: map[string][]byte{
+					"client-secret": []byte("super_secret_123"),
+				},
+			},
+		},
+	}
+	tests := []struct {
+		authEndpoint    string
+		audience        string
+		expectedIssuer  string
+		expectedJwksURI string
+		msg             string
+	}{
+		{
+			authEndpoint:    "https://example.okta.com/oauth2/v1/authorize",
+			expectedIssuer:  "https://example.okta.com",
+			expectedJwksURI: "https://example.okta.com/oauth2/v1/keys",
+			msg:             "org authorization server",
+		},
+		{
+			authEndpoint:    "https://example.okta.com/oauth2/default/v1/authorize",
+			audience:        "api://default",
+			expectedIssuer:  "https://example.okta.com/oauth2/default",
+			expectedJwksURI: "https://example.okta.com/oauth2/default/v1/keys",
+			msg:             "custom authorization server",
+		},
+	}
+	for _, test := range tests {
+		oidc := &conf_v1.OIDC{
+			IdPType:             "okta",
+			AuthEndpoint:        test.authEndpoint,
+			TokenEndpoint:       strings.TrimSuffix(test.authEndpoint, "authorize") + "token",
+			ClientID:            "client",
+			ClientSecret:        "oidc-secret",
+			AccessTokenAudience: test.audience,
+		}
+		p := &policiesCfg{}
+		oidcPolCfg := &oidcPolicyCfg{}
+		res := p.addOIDCConfig(oidc, "default/oidc-policy", "default", secretRefs, nil, nil, oidcPolCfg)
+		if res.isError || len(res.warnings) != 0 {
+			t.Fatalf("addOIDCConfig() returned unexpected result %+v for the case of %s", res, test.msg)
+		}
+		if oidcPolCfg.oidc.Issuer != test.expectedIssuer || oidcPolCfg.oidc.JwksURI != test.expectedJwksURI {
+			t.Errorf("addOIDCConfig() set Issuer %q and JwksURI %q, want %q and %q for the case of %s",
+				oidcPolCfg.oidc.Issuer, oidcPolCfg.oidc.JwksURI, test.expectedIssuer, test.expectedJwksURI, test.msg)
+		}
+		if oidcPolCfg.oidc.AccessTokenAudience != test.audience {
+			t.Errorf("addOIDCConfig() set AccessTokenAudience %q, want %q for the case of %s", oidcPolCfg.oidc.AccessTokenAudience, test.audience, test.msg)
+		}
+	}
+}
+
+func TestAddOIDCConfigWithCognito(t *testing.T) {
+	t.Parallel()
+	oidc := &conf_v1.OIDC{
+		IdPType:           "cognito",
+		AuthEndpoint:      "https://example.auth.us-east-1.amazoncognito.com/oauth2/authorize",
+		TokenEndpoint:     "https://example.auth.us-east-1.amazoncognito.com/oauth2/token",
+		DiscoveryEndpoint: "https://cognito-idp.us-east-1.amazonaws.com/us-east-1_Example/.well-known/openid-configuration",
+		ClientID:          "client",
+		ClientSecret:      "oidc-secret",
+	}
+	secretRefs := map[string]*secrets.SecretReference{
+		"default/oidc-secret": {
+			Secret: &api_v1.Secret{
+				Type: secrets.SecretTypeOIDC,
+				Data: map[string][]byte{
+					"client-secret": []byte("super_secret_123"),
+				},
+			},
+		},
+	}
+	// the discovery document of a user pool has no end_session_endpoint
+	oidcProviders := map[string]*OIDCProvider{
+		"default/oidc-policy": {
+			JwksURI: "https://cognito-idp.us-east-1.amazonaws.com/us-east-1_Example/.well-known/jwks.json",
+		},
+	}
+
+	p := &policiesCfg{}
+	oidcPolCfg := &oidcPolicyCfg{}
+	res := p.addOIDCConfig(oidc, "default/oidc-policy", "default", secretRefs, nil, oidcProviders, oidcPolCfg)
+	if res.isError || len(res.warnings) != 0 {
+		t.Fatalf("addOIDCConfig() returned unexpected result %+v", res)
+	}
+	if oidcPolCfg.oidc.EndSessionEndpoint != "https://example.auth.us-east-1.amazoncognito.com/logout" {
+		t.Errorf("addOIDCConfig() set EndSessionEndpoint %q, want the logout endpoint of the domain", oidcPolCfg.oidc.EndSessionEndpoint)
+	}
+	if oidcPolCfg.oidc.UserinfoEndpoint != "https://example.auth.us-east-1.amazoncognito.com/oauth2/userInfo" {
+		t.Errorf("addOIDCConfig() set UserinfoEndpoint %q, want the userInfo endpoint of the domain", oidcPolCfg.oidc.UserinfoEndpoint)
+	}
+}
+
+func TestAddOIDCConfigWithAuth0(t *testing.T) {
+	t.Parallel()
+	oidc := &conf_v1.OIDC{
+		IdPType:       "auth0",
+		AuthEndpoint:  "https://example.us.auth0.com/authorize",
+		TokenEndpoint: "https://example.us.auth0.com/oauth/token",
+		ClientID:      "client",
+		ClientSecret:  "oidc-secret",
+	}
+	secretRefs := map[string]*secrets.SecretReference{
+		"default/oidc-secret": {
+			Secret: &api_v1.Secret{
+				Type: secrets.SecretTypeOIDC,
+				Data: map[string][]byte{
+					"client-secret": []byte("super_secret_123"),
+				},
+			},
+		},
+	}
+	// the end_session_endpoint of the discovery document of a tenant with RP-Initiated Logout is not used
+	oidcProviders := map[string]*OIDCProvider{
+		"default/oidc-policy": {
+			JwksURI:            "https://example.us.auth0.com/.well-known/jwks.json",
+			EndSessionEndpoint: "https://example.us.auth0.com/oidc/logout",
+		},
+	}
+
+	p := &policiesCfg{}
+	oidcPolCfg := &oidcPolicyCfg{}
+	res := p.addOIDCConfig(oidc, "default/oidc-policy", "default", secretRefs, nil, nil, oidcPolCfg)
+	if res.isError || len(res.warnings) != 0 {
+		t.Fatalf("addOIDCConfig() returned unexpected result %+v", res)
+	}
+	if oidcPolCfg.oidc.JwksURI != "https://example.us.auth0.com/.well-known/jwks.json" {
+		t.Errorf("addOIDCConfig() set JwksURI %q, want the JWK Set of the tenant", oidcPolCfg.oidc.JwksURI)
+	}
+	if oidcPolCfg.oidc.UserinfoEndpoint != "https://example.us.auth0.com/userinfo" {
+		t.Errorf("addOIDCConfig() set UserinfoEndpoint %q, want the userinfo endpoint of the tenant", oidcPolCfg.oidc.UserinfoEndpoint)
+	}
+
+	p = &policiesCfg{}
+	oidcPolCfg = &oidcPolicyCfg{}
+	p.addOIDCConfig(oidc, "default/oidc-policy", "default", secretRefs, nil, oidcProviders, oidcPolCfg)
+	if oidcPolCfg.oidc.EndSessionEndpoint != "https://example.us.auth0.com/v2/logout" {
+		t.Errorf("addOIDCConfig() set EndSessionEndpoint %q, want the v2 logout endpoint of the tenant", oidcPolCfg.oidc.EndSessionEndpoint)
+	}
+}
+
+func TestAddOIDCConfigWithADFS(t *testing.T) {
+	t.Parallel()
+	oidc := &conf_v1.OIDC{
+		IdPType:       "adfs",
+		AuthEndpoint:  "https://adfs.example.com/adfs/oauth2/authorize/",
+		TokenEndpoint: "https://adfs.example.com/adfs/oauth2/token/",
+		ClientID:      "client",
+		ClientSecret:  "oidc-secret",
+		Resources:     []string{"https://api.example.com"},
+	}
+	secretRefs := map[string]*secrets.SecretReference{
+		"default/oidc-secret": {
+			Secret: &api_v1.Secret{
+				Type: secrets.SecretTypeOIDC,
+				Data: map[string][]byte{
+					"client-secret": []byte("super_secret_123"),
+				},
+			},
+		},
+	}
+
+	p := &policiesCfg{}
+	oidcPolCfg := &oidcPolicyCfg{}
+	res := p.addOIDCConfig(oidc, "default/oidc-policy", "default", secretRefs, nil, nil, oidcPolCfg)
+	if res.isError || len(res.warnings) != 0 {
+		t.Fatalf("addOIDCConfig() returned unexpected result %+v", res)
+	}
+	if oidcPolCfg.oidc.JwksURI != "https://adfs.example.com/adfs/discovery/keys" {
+		t.Errorf("addOIDCConfig() set JwksURI %q, want the keys of ADFS", oidcPolCfg.oidc.JwksURI)
+	}
+	if oidcPolCfg.oidc.EndSessionEndpoint != "https://adfs.example.com/adfs/oauth2/logout" {
+		t.Errorf("addOIDCConfig() set EndSessionEndpoint %q, want the logout endpoint of ADFS", oidcPolCfg.oidc.EndSessionEndpoint)
+	}
+	if oidcPolCfg.oidc.ResourceArgs != "&resource=https%3A%2F%2Fapi.example.com" {
+		t.Errorf("addOIDCConfig() set ResourceArgs %q, want the resource of the relying party trust", oidcPolCfg.oidc.ResourceArgs)
+	}
+}
+
+func TestAddOIDCConfigWithPingFederateAndForgeRock(t *testing.T) {
+	t.Parallel()
+	secretRefs := map[string]*secrets.SecretReference{
+		"default/oidc-secret": {
+			Secret: &api_v1.Secret{
+				Type: secrets.SecretTypeOIDC,
+				Data: map[string][]byte{
+					"client-secret": []byte("super_secret_123"),
+				},
+			},
+		},
+	}
+	tests := []struct {
+		oidc               *conf_v1.OIDC
+		expectedJwksURI    string
+		expectedEndSession string
+		expectedUserinfo   string
+		msg                string
+	}{
+		{
+			oidc: &conf_v1.OIDC{
+				IdPType:       "pingfederate",
+				AuthEndpoint:  "https://sso.example.com/as/authorization.oauth2",
+				TokenEndpoint: "https://sso.example.com/as/token.oauth2",
+			},
+			expectedJwksURI:    "https://sso.example.com/pf/JWKS",
+			expectedEndSession: "https://sso.example.com/idp/startSLO.ping",
+			expectedUserinfo:   "https://sso.example.com/idp/userinfo.openid",
+			msg:                "pingfederate",
+		},
+		{
+			oidc: &conf_v1.OIDC{
+				IdPType:       "forgerock",
+				AuthEndpoint:  "https://am.example.com/am/oauth2/realms/root/realms/alpha/authorize",
+				TokenEndpoint: "https://am.example.com/am/oauth2/realms/root/realms/alpha/access_token",
+			},
+			expectedJwksURI:    "https://am.example.com/am/oauth2/realms/root/realms/alpha/connect/jwk_uri",
+			expectedEndSession: "https://am.example.com/am/oauth2/realms/root/realms/alpha/connect/endSession",
+			expectedUserinfo:   "https://am.example.com/am/oauth2/realms/root/realms/alpha/userinfo",
+			msg:                "forgerock",
+		},
+		{
+			oidc: &conf_v1.OIDC{
+				IdPType:       "forgerock",
+				AuthEndpoint:  "https://am.example.com/am/oauth2/authorize",
+				TokenEndpoint: "https://am.example.com/am/oauth2/access_token",
+				JWKSURI:       "https://keys.example.com/jwks",
+			},
+			expectedJwksURI:    "https://keys.example.com/jwks",
+			expectedEndSession: "https://am.example.com/am/oauth2/connect/endSession",
+			expectedUserinfo:   "https://am.example.com/am/oauth2/userinfo",
+			msg:                "forgerock root realm with jwks uri",
+		},
+	}
+	for _, test := range tests {
+		test.oidc.ClientID = "client"
+		test.oidc.ClientSecret = "oidc-secret"
+		p := &policiesCfg{}
+		oidcPolCfg := &oidcPolicyCfg{}
+		res := p.addOIDCConfig(test.oidc, "default/oidc-policy", "default", secretRefs, nil, nil, oidcPolCfg)
+		if res.isError || len(res.warnings) != 0 {
+			t.Fatalf("addOIDCConfig() returned unexpected result %+v for the case of %s", res, test.msg)
+		}
+		if oidcPolCfg.oidc.JwksURI != test.expectedJwksURI {
+			t.Errorf("addOIDCConfig() set JwksURI %q, want %q for the case of %s", oidcPolCfg.oidc.JwksURI, test.expectedJwksURI, test.msg)
+		}
+		if oidcPolCfg.oidc.EndSessionEndpoint != test.expectedEndSession {
+			t.Errorf("addOIDCConfig() set EndSessionEndpoint %q, want %q for the case of %s", oidcPolCfg.oidc.EndSessionEndpoint, test.expectedEndSession, test.msg)
+		}
+		if oidcPolCfg.oidc.UserinfoEndpoint != test.expectedUserinfo {
+			t.Errorf("addOIDCConfig() set UserinfoEndpoint %q, want %q for the case of %s", oidcPolCfg.oidc.UserinfoEndpoint, test.expectedUserinfo, test.msg)
+		}
+	}
+}
+
+func TestAddOIDCConfigWithIssuer(t *testing.T) {
+	t.Parallel()
+	secretRefs := map[string]*secrets.SecretReference{
+		"default/oidc-secret": {
+			Secret: &api_v1.Secret{
+				Type: secrets.SecretTypeOIDC,
+				Data: map[string][]byte{
+					"client-secret": []byte("super_secret_123"),
+				},
+			},
+		},
+	}
+	tests := []struct {
+		oidc           *conf_v1.OIDC
+		expectedIssuer string
+		msg            string
+	}{
+		{
+			oidc: &conf_v1.OIDC{
+				AuthEndpoint:   "https://login.microsoftonline.com/organizations/oauth2/v2.0/authorize",
+				TokenEndpoint:  "https://login.microsoftonline.com/organizations/oauth2/v2.0/token",
+				JWKSURI:        "https://login.microsoftonline.com/organizations/discovery/v2.0/keys",
+				Issuer:         "https://login.microsoftonline.com/{tenantid}/v2.0",
+				AllowedTenants: []string{"9188040d-6c67-4c5b-b112-36a304b66dad", "72f988bf-86f1-41af-91ab-2d7cd011db47"},
+			},
+			expectedIssuer: "https://login.microsoftonline.com/{tenantid}/v2.0",
+			msg:            "issuer template",
+		},
+		{
+			oidc: &conf_v1.OIDC{
+				IdPType:       "okta",
+				AuthEndpoint:  "https://example.okta.com/oauth2/default/v1/authorize",
+				TokenEndpoint: "https://example.okta.com/oauth2/default/v1/token",
+				Issuer:        "https://login.example.com/oauth2/default",
+			},
+			expectedIssuer: "https://login.example.com/oauth2/default",
+			msg:            "issuer of an okta custom domain",
+		},
+	}
+	for _, test := range tests {
+		test.oidc.ClientID = "client"
+		test.oidc.ClientSecret = "oidc-secret"
+		p := &policiesCfg{}
+		oidcPolCfg := &oidcPolicyCfg{}
+		res := p.addOIDCConfig(test.oidc, "default/oidc-policy", "default", secretRefs, nil, nil, oidcPolCfg)
+		if res.isError || len(res.warnings) != 0 {
+			t.Fatalf("addOIDCConfig() returned unexpected result %+v for the case of %s", res, test.msg)
+		}
+		if oidcPolCfg.oidc.Issuer != test.expectedIssuer {
+			t.Errorf("addOIDCConfig() set Issuer %q, want %q for the case of %s", oidcPolCfg.oidc.Issuer, test.expectedIssuer, test.msg)
+		}
+	}
+}
+
+func TestAddOIDCConfigWithGoogleHostedDomain(t *testing.T) {
+	t.Parallel()
+	oidc := &conf_v1.OIDC{
+		AuthEndpoint:  "https://accounts.google.com/o/oauth2/v2/auth",
+		TokenEndpoint: "https://oauth2.googleapis.com/token",
+		JWKSURI:       "https://www.googleapis.com/oauth2/v3/certs",
+		ClientID:      "client",
+		ClientSecret:  "oidc-secret",
+		AuthExtraArgs: []string{"prompt=select_account"},
+		Google:        &conf_v1.OIDCGoogle{HostedDomain: "example.com"},
+	}
+	secretRefs := map[string]*secrets.SecretReference{
+		"default/oidc-secret": {
+			Secret: &api_v1.Secret{
+				Type: secrets.SecretTypeOIDC,
+				Data: map[string][]byte{
+					"client-secret": []byte("super_secret_123"),
+				},
+			},
+		},
+	}
+
+	p := &policiesCfg{}
+	oidcPolCfg := &oidcPolicyCfg{}
+	res := p.addOIDCConfig(oidc, "default/oidc-policy", "default", secretRefs, nil, nil, oidcPolCfg)
+	if res.isError || len(res.warnings) != 0 {
+		t.Fatalf("addOIDCConfig() returned unexpected result %+v", res)
+	}
+	if oidcPolCfg.oidc.AuthExtraArgs != "prompt=select_account&hd=example.com" {
+		t.Errorf("addOIDCConfig() set AuthExtraArgs %q, want the hd argument after the extra arguments", oidcPolCfg.oidc.AuthExtraArgs)
+	}
+	if oidcPolCfg.oidc.GoogleHostedDomain != "example.com" {
+		t.Errorf("addOIDCConfig() set GoogleHostedDomain %q, want %q", oidcPolCfg.oidc.GoogleHostedDomain, "example.com")
+	}
+}
+
+func TestAddOIDCConfigWithIdPs(t *testing.T) {
+	t.Parallel()
+	oidc := &conf_v1.OIDC{
+		AuthEndpoint:  "https://idp.example.com/auth",
+		TokenEndpoint: "https://idp.example.com/token",
+		JWKSURI:       "https://idp.example.com/keys",
+		ClientID:      "client",
+		ClientSecret:  "oidc-secret",
+		IdPs: []conf_v1.OIDCIdP{
+			{
+				Name:               "partners",
+				AuthEndpoint:       "https://partners.example.com/auth",
+				TokenEndpoint:      "https://partners.example.com/token",
+				JWKSURI:            "https://partners.example.com/keys",
+				ClientID:           "partners-client",
+				ClientSecret:       "identity/partners-secret",
+				EndSessionEndpoint: "https://partners.example.com/logout",
+			},
+		},
+		IdPSelection: &conf_v1.OIDCIdPSelection{Type: "header", Header: "X-Login-IdP"},
+	}
+	secretRefs := map[string]*secrets.SecretReference{
+		"default/oidc-secret": {
+			Secret: &api_v1.Secret{
+				Type: secrets.SecretTypeOIDC,
+				Data: map[string][]byte{
+					"client-secret": []byte("super_secret_123"),
+				},
+			},
+		},
+		"identity/partners-secret": {
+			Secret: &api_v1.Secret{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Namespace:   "identity",
+					Annotations: map[string]string{secrets.AllowedNamespacesAnnotation: "default"},
+				},
+				Type: secrets.SecretTypeOIDC,
+				Data: map[string][]byte{
+					"client-secret": []byte("partners_secret_456"),
+				},
+			},
+		},
+	}
+
+	p := &policiesCfg{}
+	oidcPolCfg := &oidcPolicyCfg{}
+	res := p.addOIDCConfig(oidc, "default/oidc-policy", "default", secretRefs, nil, nil, oidcPolCfg)
+	if res.isError || len(res.warnings) != 0 {
+		t.Fatalf("addOIDCConfig() returned unexpected result %+v", res)
+	}
+	expectedIdPs := []version2.OIDCIdP{
+		{
+			Name:               "partners",
+			AuthEndpoint:       "https://partners.example.com/auth",
+			TokenEndpoint:      "https://partners.example.com/token",
+			JwksURI:            "https://partners.example.com/keys",
+			ClientID:           "partners-client",
+			ClientSecret:       "partners_secret_456",
+			Scope:              "openid",
+			EndSessionEndpoint: "https://partners.example.com/logout",
+		},
+	}
+	if !cmp.Equal(oidcPolCfg.oidc.IdPs, expectedIdPs) {
+		t.Error(cmp.Diff(expectedIdPs, oidcPolCfg.oidc.IdPs))
+	}
+	if oidcPolCfg.oidc.IdPSelection != "header" || oidcPolCfg.oidc.IdPSelectionVariable != "$http_x_login_idp" {
+		t.Errorf("addOIDCConfig() set IdPSelection %q and IdPSelectionVariable %q, want %q and %q",
+			oidcPolCfg.oidc.IdPSelection, oidcPolCfg.oidc.IdPSelectionVariable, "header", "$http_x_login_idp")
+	}
+
+	// The secret of an IdP that doesn't allow the namespace of the policy can't be referenced
+	secretRefs["identity/partners-secret"].Secret.Annotations = nil
+	p = &policiesCfg{}
+	oidcPolCfg = &oidcPolicyCfg{}
+	res = p.addOIDCConfig(oidc, "default/oidc-policy", "default", secretRefs, nil, nil, oidcPolCfg)
+	if !res.isError {
+		t.Errorf("addOIDCConfig() returned %+v for an IdP secret of another namespace, want an error", res)
+	}
+}
+
+func TestAddOIDCConfigWithIdPEmailDomains(t *testing.T) {
+	t.Parallel()
+	oidc := &conf_v1.OIDC{
+		AuthEndpoint:  "https://idp.example.com/auth",
+		TokenEndpoint: "https://idp.example.com/token",
+		JWKSURI:       "https://idp.example.com/keys",
+		ClientID:      "client",
+		ClientSecret:  "oidc-secret",
+		IdPs: []conf_v1.OIDCIdP{
+			{
+				Name:          "partners",
+				AuthEndpoint:  "https://partners.example.com/auth",
+				TokenEndpoint: "https://partners.example.com/token",
+				JWKSURI:       "https://partners.example.com/keys",
+				ClientID:      "partners-client",
+				ClientSecret:  "partners-secret",
+				EmailDomains:  []string{"partner.com", "partner.org"},
+			},
+			{
+				Name:          "contractors",
+				AuthEndpoint:  "https://contractors.example.com/auth",
+				TokenEndpoint: "https://contractors.example.com/token",
+				JWKSURI:       "https://contractors.example.com/keys",
+				ClientID:      "contractors-client",
+				ClientSecret:  "partners-secret",
+				EmailDomains:  []string{"contractor.com"},
+			},
+		},
+		IdPSelection: &conf_v1.OIDCIdPSelection{Type: "email"},
+	}
+	secretRefs := map[string]*secrets.SecretReference{}
+	for _, name := range []string{"default/oidc-secret", "default/partners-secret"} {
+		secretRefs[name] = &secrets.SecretReference{
+			Secret: &api_v1.Secret{
+				Type: secrets.SecretTypeOIDC,
+				Data: map[string][]byte{
+					"client-secret": []byte("super_secret_123"),
+				},
+			},
+		}
+	}
+
+	p := &policiesCfg{}
+	oidcPolCfg := &oidcPolicyCfg{}
+	res := p.addOIDCConfig(oidc, "default/oidc-policy", "default", secretRefs, nil, nil, oidcPolCfg)
+	if res.isError || len(res.warnings) != 0 {
+		t.Fatalf("addOIDCConfig() returned unexpected result %+v", res)
+	}
+	expected := "partner.com=partners partner.org=partners contractor.com=contractors"
+	if oidcPolCfg.oidc.IdPSelection != "email" || oidcPolCfg.oidc.IdPEmailDomains != expected {
+		t.Errorf("addOIDCConfig() set IdPSelection %q and IdPEmailDomains %q, want %q and %q",
+			oidcPolCfg.oidc.IdPSelection, oidcPolCfg.oidc.IdPEmailDomains, "email", expected)
+	}
+}
+
+func TestAddOIDCConfigWithRoutePolicies(t *testing.T) {
+	t.Parallel()
+	employees := &conf_v1.OIDC{
+		AuthEndpoint:  "https://login.example.com/auth",
+		TokenEndpoint: "https://login.example.com/token",
+		JWKSURI:       "https://login.example.com/keys",
+		ClientID:      "employees",
+		ClientSecret:  "oidc-secret",
+	}
+	customers := &conf_v1.OIDC{
+		AuthEndpoint:  "https://customers.example.com/auth",
+		TokenEndpoint: "https://customers.example.com/token",
+		JWKSURI:       "https://customers.example.com/keys",
+		ClientID:      "portal",
+		ClientSecret:  "oidc-secret",
+		Scope:         "openid profile",
+	}
+	secretRefs := map[string]*secrets.SecretReference{
+		"default/oidc-secret": {
+			Secret: &api_v1.Secret{
+				Type: secrets.SecretTypeOIDC,
+				Data: map[string][]byte{
+					"client-secret": []byte("super_secret_123"),
+				},
+			},
+		},
+	}
+
+	oidcPolCfg := &oidcPolicyCfg{}
+	res := (&policiesCfg{}).addOIDCConfig(employees, "default/employees", "default", secretRefs, nil, nil, oidcPolCfg)
+	if res.isError || len(res.warnings) != 0 {
+		t.Fatalf("addOIDCConfig() returned unexpected result %+v", res)
+	}
+	// The policies of the routes can have other settings than the policy of the VirtualServer
+	customers.AccessTokenEnable = true
+	customers.HTTPProxy = "http://proxy.corp.example.com:3128"
+	for i := 0; i < 2; i++ {
+		p := &policiesCfg{}
+		res = p.addOIDCConfig(customers, "default/customers", "default", secretRefs, nil, nil, oidcPolCfg)
+		if res.isError || len(res.warnings) != 0 {
+			t.Fatalf("addOIDCConfig() returned unexpected result %+v for the policy of a route", res)
+		}
+		if !p.OIDC || p.OIDCRoute == nil || p.OIDCRoute.Policy != "default/customers" {
+			t.Fatalf("addOIDCConfig() set OIDC %v and OIDCRoute %+v, want the route bound to the policy %q", p.OIDC, p.OIDCRoute, "default/customers")
+		}
+	}
+
+	if len(oidcPolCfg.oidc.RoutePolicies) != 1 || oidcPolCfg.key != "default/employees" {
+		t.Fatalf("addOIDCConfig() returned %+v, want one policy of the routes of the policy default/employees", oidcPolCfg)
+	}
+	route := oidcPolCfg.oidc.RoutePolicies[0]
+	if route.ClientID != "portal" || route.Scope != "openid profile" || !route.AccessTokenEnable || route.HTTPProxy != "http://proxy.corp.example.com:3128" {
+		t.Errorf("addOIDCConfig() returned the policy of the route %+v, want the settings of the policy default/customers", route)
+	}
+	if route.CookieSuffix == "" || oidcPolCfg.oidc.CookieSuffix != "" {
+		t.Errorf("addOIDCConfig() set the cookie suffixes %q and %q, want a session cookie for the policy of the route", oidcPolCfg.oidc.CookieSuffix, route.CookieSuffix)
+	}
+
+	// The OIDC locations of the server are shared, a policy with another pathPrefix can't be bound to a route
+	partners := customers.DeepCopy()
+	partners.PathPrefix = "/partners"
+	p := &policiesCfg{}
+	res = p.addOIDCConfig(partners, "default/partners", "default", secretRefs, nil, nil, oidcPolCfg)
+	if !res.isError || p.OIDC {
+		t.Errorf("addOIDCConfig() returned %+v for a policy with another pathPrefix, want an error", res)
+	}
+}
+
+func TestOIDCDiscoveryEndpoint(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		oidc     *conf_v1.OIDC
+		expected string
+	}{
+		{
+			oidc:     &conf_v1.OIDC{AuthEndpoint: "https://keycloak.example.com/realms/apps/protocol/openid-connect/auth"},
+			expected: "",
+		},
+		{
+			oidc: &conf_v1.OIDC{
+				IdPType:           "keycloak",
+				AuthEndpoint:      "https://keycloak.example.com/realms/apps/protocol/openid-connect/auth",
+				DiscoveryEndpoint: "https://keycloak.example.com/realms/apps/.well-known/openid-configuration",
+			},
+			expected: "https://keycloak.example.com/realms/apps/.well-known/openid-configuration",
+		},
+		{
+			oidc:     &conf_v1.OIDC{IdPType: "keycloak", AuthEndpoint: "https://keycloak.example.com/realms/apps/protocol/openid-connect/auth"},
+			expected: "https://keycloak.example.com/realms/apps/.well-known/openid-configuration",
+		},
+		{
+			oidc:     &conf_v1.OIDC{IdPType: "keycloak", AuthEndpoint: "https://example.com/auth/realms/apps/protocol/openid-connect/auth"},
+			expected: "https://example.com/auth/realms/apps/.well-known/openid-configuration",
+		},
+		{
+			oidc:     &conf_v1.OIDC{IdPType: "keycloak", AuthEndpoint: "https://keycloak.example.com/oauth2/authorize"},
+			expected: "",
+		},
+		{
+			oidc:     &conf_v1.OIDC{IdPType: "okta", AuthEndpoint: "https://example.okta.com/oauth2/v1/authorize"},
+			expected: "https://example.okta.com/.well-known/openid-configuration",
+		},
+		{
+			oidc:     &conf_v1.OIDC{IdPType: "okta", AuthEndpoint: "https://example.okta.com/oauth2/default/v1/authorize"},
+			expected: "https://example.okta.com/oauth2/default/.well-known/openid-configuration",
+		},
+		{
+			oidc:     &conf_v1.OIDC{IdPType: "auth0", AuthEndpoint: "https://example.us.auth0.com/authorize"},
+			expected: "https://example.us.auth0.com/.well-known/openid-configuration",
+		},
+		{
+			oidc:     &conf_v1.OIDC{IdPType: "adfs", AuthEndpoint: "https://adfs.example.com/adfs/oauth2/authorize/"},
+			expected: "https://adfs.example.com/adfs/.well-known/openid-configuration",
+		},
+		{
+			oidc:     &conf_v1.OIDC{IdPType: "pingfederate", AuthEndpoint: "https://sso.example.com/as/authorization.oauth2"},
+			expected: "https://sso.example.com/.well-known/openid-configuration",
+		},
+		{
+			oidc:     &conf_v1.OIDC{IdPType: "pingfederate", AuthEndpoint: "https://sso.example.com/oauth2/authorize"},
+			expected: "",
+		},
+		{
+			oidc:     &conf_v1.OIDC{IdPType: "forgerock", AuthEndpoint: "https://am.example.com/am/oauth2/realms/root/realms/alpha/authorize"},
+			expected: "https://am.example.com/am/oauth2/realms/root/realms/alpha/.well-known/openid-configuration",
+		},
+		{
+			oidc:     &conf_v1.OIDC{IdPType: "forgerock", AuthEndpoint: "https://openam.example.com/oauth2/authorize"},
+			expected: "https://openam.example.com/oauth2/.well-known/openid-configuration",
+		},
+	}
+	for _, test := range tests {
+		if got := OIDCDiscoveryEndpoint(test.oidc); got != test.expected {
+			t.Errorf("OIDCDiscoveryEndpoint(%+v) returned %q, want %q", test.oidc, got, test.expected)
+		}
+	}
+}
+
+func TestGenerateOIDCErrorPages(t *testing.T) {
+	t.Parallel()
+	configMap := &api_v1.ConfigMap{
+		Data: map[string]string{
+			"idp-unreachable.html":          `<p class="error">Try again later</p>`,
+			"session-limit.html":            `<p>Sign out of another device</p>`,
+			"token-validation-failure.html": `<p>C:\login failed</p>`,
+			"unknown.html":                  "<p>unused</p>",
+		},
+	}
+	expected := []version2.OIDCErrorPage{
+		{Name: "idp_unreachable", Code: 502, Body: `<p class=\"error\">Try again later</p>`},
+		{Name: "session_limit", Code: 403, Body: `<p>Sign out of another device</p>`},
+		{Name: "token_validation_failure", Code: 500, Body: `<p>C:\\login failed</p>`},
+	}
+
+	got := generateOIDCErrorPages(configMap)
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("generateOIDCErrorPages() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAddDPoPToRoute(t *testing.T) {
+	t.Parallel()
+	dpopOIDC := &version2.OIDC{AccessTokenEnable: true, DPoPEnable: true}
+	tests := []struct {
+		oidc             *version2.OIDC
+		serverCfg        policiesCfg
+		routeCfg         policiesCfg
+		expectedRouteCfg policiesCfg
+		expectedWarnings int
+		msg              string
+	}{
+		{
+			oidc:             dpopOIDC,
+			routeCfg:         policiesCfg{OIDC: true},
+			expectedRouteCfg: policiesCfg{OIDC: true, DPoP: true},
+			msg:              "dpop",
+		},
+		{
+			oidc:             &version2.OIDC{AccessTokenEnable: true},
+			routeCfg:         policiesCfg{OIDC: true},
+			expectedRouteCfg: policiesCfg{OIDC: true},
+			msg:              "dpop disabled",
+		},
+		{
+			oidc:             dpopOIDC,
+			routeCfg:         policiesCfg{},
+			expectedRouteCfg: policiesCfg{},
+			msg:              "route without oidc policy",
+		},
+		{
+			oidc:             dpopOIDC,
+			routeCfg:         policiesCfg{OIDC: true, TokenExchangeAudience: "orders-api"},
+			expectedRouteCfg: policiesCfg{OIDC: true, TokenExchangeAudience: "orders-api"},
+			msg:              "route with token exchange",
+		},
+		{
+			oidc:      dpopOIDC,
+			serverCfg: policiesCfg{APIKey: &version2.APIKey{}},
+			routeCfg:  policiesCfg{OIDC: true},
+			expectedRouteCfg: policiesCfg{
+				OIDC:        true,
+				ErrorReturn: &version2.Return{Code: 500},
+			},
+			expectedWarnings: 1,
+			msg:              "dpop with api key policy",
+		},
+		{
+			oidc:     dpopOIDC,
+			routeCfg: policiesCfg{OIDC: true, ClientCredentials: &version2.ClientCredentials{}},
+			expectedRouteCfg: policiesCfg{
+				OIDC:              true,
+				ClientCredentials: &version2.ClientCredentials{},
+				ErrorReturn:       &version2.Return{Code: 500},
+			},
+			expectedWarnings: 1,
+			msg:              "dpop with client credentials policy",
+		},
+	}
+
+	for _, test := range tests {
+		vsc := &virtualServerConfigurator{
+			warnings:   newWarnings(),
+			oidcPolCfg: &oidcPolicyCfg{oidc: test.oidc, key: "default/oidc-policy"},
+		}
+		vsc.addDPoPToRoute(nil, "/", &test.serverCfg, &test.routeCfg)
+		if diff := cmp.Diff(test.expectedRouteCfg, test.routeCfg); diff != "" {
+			t.Errorf("addDPoPToRoute() '%s' mismatch (-want +got):\n%s", test.msg, diff)
+		}
+		if len(vsc.warnings[nil]) != test.expectedWarnings {
+			t.Errorf("addDPoPToRoute() '%s' returned warnings %v, want %d", test.msg, vsc.warnings, test.expectedWarnings)
+		}
+	}
+}
+
+func TestIdentityAuthMethod(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		cfg      policiesCfg
+		expected string
+		msg      string
+	}{
+		{
+			cfg:      policiesCfg{},
+			expected: "",
+			msg:      "no auth policies",
+		},
+		{
+			cfg:      policiesCfg{IngressMTLS: &version2.IngressMTLS{}},
+			expected: "mtls",
+			msg:      "ingress mtls",
+		},
+		{
+			cfg:      policiesCfg{BasicAuth: &version2.BasicAuth{}, IngressMTLS: &version2.IngressMTLS{}},
+			expected: "basic",
+			msg:      "basic auth takes precedence over ingress mtls",
+		},
+		{
+			cfg:      policiesCfg{JWTAuth: &version2.JWTAuth{}, BasicAuth: &version2.BasicAuth{}},
+			expected: "jwt",
+			msg:      "jwt takes precedence over basic auth",
+		},
+		{
+			cfg:      policiesCfg{OIDC: true, IngressMTLS: &version2.IngressMTLS{}},
+			expected: "oidc",
+			msg:      "oidc takes precedence over ingress mtls",
+		},
+	}
+
+	for _, test := range tests {
+		result := identityAuthMethod(test.cfg)
+		if result != test.expected {
+			t.Errorf("identityAuthMethod() returned %q but expected %q for the case of %s", result, test.expected, test.msg)
+		}
+	}
+}
+
 func TestGenerateUpstream(t *testing.T) {
 	t.Parallel()
 	name := "test-upstream"
@@ -9,6 +9,7 @@ import (
 	"github.com/golang/glog"
 	conf_v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1"
 	extdns_v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/externaldns/v1"
+	extdnsvalidation "github.com/nginxinc/kubernetes-ingress/pkg/apis/externaldns/validation"
 	k8s_nginx "github.com/nginxinc/kubernetes-ingress/pkg/client/clientset/versioned"
 	listersV1 "github.com/nginxinc/kubernetes-ingress/pkg/client/listers/configuration/v1"
 	extdnslisters "github.com/nginxinc/kubernetes-ingress/pkg/client/listers/externaldns/v1"
@@ -55,6 +56,8 @@ type ExtDNSOpts struct {
 	client        k8s_nginx.Interface
 	resyncPeriod  time.Duration
 	isDynamicNs   bool
+	ownerID       string
+	validation    []extdnsvalidation.Option
 }
 
 // NewController takes external dns config and return a new External DNS Controller.
@@ -77,7 +80,7 @@ func NewController(opts *ExtDNSOpts) *ExtDNSController {
 		c.newNamespacedInformer(ns)
 	}
 
-	c.sync = SyncFnFor(c.recorder, c.client, c.informerGroup)
+	c.sync = SyncFnFor(c.recorder, c.client, c.informerGroup, opts.ownerID, opts.validation...)
 	return c
 }
 
@@ -194,7 +197,14 @@ func (c *ExtDNSController) processItem(ctx context.Context, key string) error {
 		return err
 	}
 	glog.V(3).Infof("processing virtual server resource")
-	return c.sync(ctx, vs)
+	if err := c.sync(ctx, vs); err != nil {
+		return err
+	}
+	// The external endpoints are checked again after the interval of the health check
+	if hc := healthCheckEnabled(vs); hc != nil {
+		c.queue.AddAfter(key, healthCheckDuration(hc.Interval, defaultHealthCheckInterval))
+	}
+	return nil
 }
 
 func externalDNSHandler(queue workqueue.RateLimitingInterface) func(obj interface{}) {
@@ -223,8 +233,9 @@ func externalDNSHandler(queue workqueue.RateLimitingInterface) func(obj interfac
 	}
 }
 
-// BuildOpts builds the externalDNS controller options
-func BuildOpts(ctx context.Context, ns []string, rdr record.EventRecorder, client k8s_nginx.Interface, resync time.Duration, idn bool) *ExtDNSOpts {
+// BuildOpts builds the externalDNS controller options. The records of the DNSEndpoints are validated with the
+// validation options, like in the admission of the DNSEndpoints and the VirtualServers.
+func BuildOpts(ctx context.Context, ns []string, rdr record.EventRecorder, client k8s_nginx.Interface, resync time.Duration, idn bool, ownerID string, validation ...extdnsvalidation.Option) *ExtDNSOpts {
 	return &ExtDNSOpts{
 		context:       ctx,
 		namespace:     ns,
@@ -232,6 +243,8 @@ func BuildOpts(ctx context.Context, ns []string, rdr record.EventRecorder, clien
 		client:        client,
 		resyncPeriod:  resync,
 		isDynamicNs:   idn,
+		ownerID:       ownerID,
+		validation:    validation,
 	}
 }
 
@@ -0,0 +1,117 @@
+package externaldns
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/nginxinc/kubernetes-ingress/internal/configs"
+	vsapi "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1"
+)
+
+const (
+	defaultHealthCheckPort     = 80
+	defaultHealthCheckTimeout  = 5 * time.Second
+	defaultHealthCheckInterval = 30 * time.Second
+)
+
+// healthCheckClient doesn't follow the redirects, a redirect is a healthy response.
+var healthCheckClient = &http.Client{
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// probeFn checks the health of the address of an external endpoint of the virtual server with the host.
+type probeFn func(ctx context.Context, host string, address string, hc *vsapi.ExternalDNSHealthCheck) bool
+
+// healthCheckEnabled returns the health check of the external endpoints of the virtual server, or nil if the health
+// check is not enabled.
+func healthCheckEnabled(vs *vsapi.VirtualServer) *vsapi.ExternalDNSHealthCheck {
+	if hc := vs.Spec.ExternalDNS.HealthCheck; vs.Spec.ExternalDNS.Enable && hc != nil && hc.Enable {
+		return hc
+	}
+	return nil
+}
+
+// healthyEndpoints checks the external endpoints concurrently and returns the ones that pass the health check.
+func healthyEndpoints(ctx context.Context, host string, endpoints []vsapi.ExternalEndpoint, hc *vsapi.ExternalDNSHealthCheck, probe probeFn) []vsapi.ExternalEndpoint {
+	healthy := make([]bool, len(endpoints))
+	var wg sync.WaitGroup
+	for i, e := range endpoints {
+		address := endpointAddress(e)
+		if address == "" {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			healthy[i] = probe(ctx, host, address, hc)
+		}()
+	}
+	wg.Wait()
+
+	var result []vsapi.ExternalEndpoint
+	for i, e := range endpoints {
+		if !healthy[i] {
+			glog.V(3).Infof("External endpoint %q of VirtualServer host %s failed the health check", endpointAddress(e), host)
+			continue
+		}
+		result = append(result, e)
+	}
+	return result
+}
+
+func endpointAddress(e vsapi.ExternalEndpoint) string {
+	if e.IP != "" {
+		return e.IP
+	}
+	return e.Hostname
+}
+
+// probeEndpoint opens a TCP connection to the address, or sends an HTTP request with the host if the health check
+// has a path. A response with a 2xx or 3xx status code is healthy.
+func probeEndpoint(ctx context.Context, host string, address string, hc *vsapi.ExternalDNSHealthCheck) bool {
+	port := hc.Port
+	if port == 0 {
+		port = defaultHealthCheckPort
+	}
+	ctx, cancel := context.WithTimeout(ctx, healthCheckDuration(hc.Timeout, defaultHealthCheckTimeout))
+	defer cancel()
+	addr := net.JoinHostPort(address, strconv.Itoa(port))
+
+	if hc.Path == "" {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return false
+		}
+		conn.Close() //nolint:errcheck
+		return true
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+hc.Path, nil)
+	if err != nil {
+		return false
+	}
+	req.Host = host
+	resp, err := healthCheckClient.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close() //nolint:errcheck
+	return resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusBadRequest
+}
+
+// healthCheckDuration returns the duration of an NGINX time, or the default duration if the time is not set.
+func healthCheckDuration(t string, defaultDuration time.Duration) time.Duration {
+	seconds, err := configs.ParseTimeSeconds(t)
+	if err != nil || seconds == 0 {
+		return defaultDuration
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,110 @@
+package externaldns
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	vsapi "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1"
+)
+
+func TestHealthyEndpoints(t *testing.T) {
+	t.Parallel()
+	endpoints := []vsapi.ExternalEndpoint{
+		{IP: "10.0.0.1"},
+		{IP: "10.0.0.2"},
+		{Hostname: "lb.example.com"},
+		{},
+	}
+	probe := func(_ context.Context, host string, address string, _ *vsapi.ExternalDNSHealthCheck) bool {
+		if host != "cafe.example.com" {
+			t.Errorf("probe() called with host %q, want cafe.example.com", host)
+		}
+		return address != "10.0.0.2"
+	}
+
+	got := healthyEndpoints(context.Background(), "cafe.example.com", endpoints, &vsapi.ExternalDNSHealthCheck{Enable: true}, probe)
+	want := []vsapi.ExternalEndpoint{{IP: "10.0.0.1"}, {Hostname: "lb.example.com"}}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestProbeEndpoint(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != "cafe.example.com" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.URL.Path {
+		case "/healthz":
+			w.WriteHeader(http.StatusOK)
+		case "/login":
+			http.Redirect(w, r, "/", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a port without a listener
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedPort := l.Addr().(*net.TCPAddr).Port
+	l.Close() //nolint:errcheck
+
+	tests := []struct {
+		hc       vsapi.ExternalDNSHealthCheck
+		expected bool
+		msg      string
+	}{
+		{
+			hc:       vsapi.ExternalDNSHealthCheck{Port: port},
+			expected: true,
+			msg:      "TCP health check of a listening port",
+		},
+		{
+			hc:       vsapi.ExternalDNSHealthCheck{Port: closedPort, Timeout: "1s"},
+			expected: false,
+			msg:      "TCP health check of a closed port",
+		},
+		{
+			hc:       vsapi.ExternalDNSHealthCheck{Port: port, Path: "/healthz"},
+			expected: true,
+			msg:      "HTTP health check with a 200 response",
+		},
+		{
+			hc:       vsapi.ExternalDNSHealthCheck{Port: port, Path: "/login"},
+			expected: true,
+			msg:      "HTTP health check with a redirect",
+		},
+		{
+			hc:       vsapi.ExternalDNSHealthCheck{Port: port, Path: "/unavailable"},
+			expected: false,
+			msg:      "HTTP health check with a 503 response",
+		},
+	}
+	for _, test := range tests {
+		hc := test.hc
+		hc.Enable = true
+		if got := probeEndpoint(context.Background(), "cafe.example.com", u.Hostname(), &hc); got != test.expected {
+			t.Errorf("probeEndpoint() returned %v for the case of %s, want %v", got, test.msg, test.expected)
+		}
+	}
+}
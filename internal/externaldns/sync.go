@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/golang/glog"
 	"github.com/google/go-cmp/cmp"
 	vsapi "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1"
 	extdnsapi "github.com/nginxinc/kubernetes-ingress/pkg/apis/externaldns/v1"
+	extdnsvalidation "github.com/nginxinc/kubernetes-ingress/pkg/apis/externaldns/validation"
 	clientset "github.com/nginxinc/kubernetes-ingress/pkg/client/clientset/versioned"
 	extdnslisters "github.com/nginxinc/kubernetes-ingress/pkg/client/listers/externaldns/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -17,18 +19,24 @@ import (
 	"k8s.io/apimachinery/pkg/util/validation/field"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/record"
 	netutils "k8s.io/utils/net"
 )
 
 const (
-	reasonBadConfig         = "BadConfig"
-	reasonCreateDNSEndpoint = "CreateDNSEndpoint"
-	reasonUpdateDNSEndpoint = "UpdateDNSEndpoint"
-	recordTypeA             = "A"
-	recordTypeAAAA          = "AAAA"
-	recordTypeCNAME         = "CNAME"
+	reasonBadConfig          = "BadConfig"
+	reasonCreateDNSEndpoint  = "CreateDNSEndpoint"
+	reasonUpdateDNSEndpoint  = "UpdateDNSEndpoint"
+	reasonValid              = "Valid"
+	reasonInvalid            = "Invalid"
+	reasonSynced             = "Synced"
+	reasonPending            = "Pending"
+	reasonUnhealthyEndpoints = "UnhealthyEndpoints"
+	recordTypeA              = "A"
+	recordTypeAAAA           = "AAAA"
+	recordTypeCNAME          = "CNAME"
 )
 
 var vsGVK = vsapi.SchemeGroupVersion.WithKind("VirtualServer")
@@ -36,8 +44,10 @@ var vsGVK = vsapi.SchemeGroupVersion.WithKind("VirtualServer")
 // SyncFn is the reconciliation function passed to externaldns controller.
 type SyncFn func(context.Context, *vsapi.VirtualServer) error
 
-// SyncFnFor knows how to reconcile VirtualServer DNSEndpoint object.
-func SyncFnFor(rec record.EventRecorder, client clientset.Interface, ig map[string]*namespacedInformer) SyncFn {
+// SyncFnFor knows how to reconcile VirtualServer DNSEndpoint object. The records of the DNSEndpoints have the owner
+// label of ownerID, unless it is empty or the VirtualServer sets another owner. The records are validated with the
+// validation options of the DNS provider.
+func SyncFnFor(rec record.EventRecorder, client clientset.Interface, ig map[string]*namespacedInformer, ownerID string, opts ...extdnsvalidation.Option) SyncFn {
 	return func(ctx context.Context, vs *vsapi.VirtualServer) error {
 		// Do nothing if ExternalDNS is not present (nil) in VS or is not enabled.
 		if !vs.Spec.ExternalDNS.Enable {
@@ -50,7 +60,18 @@ func SyncFnFor(rec record.EventRecorder, client clientset.Interface, ig map[stri
 			return fmt.Errorf("failed to determine external endpoints")
 		}
 
-		targets, recordType, err := getValidTargets(vs.Status.ExternalEndpoints)
+		endpoints := vs.Status.ExternalEndpoints
+		if hc := healthCheckEnabled(vs); hc != nil {
+			endpoints = healthyEndpoints(ctx, vs.Spec.Host, endpoints, hc, probeEndpoint)
+			if len(endpoints) == 0 {
+				// The records are kept, publishing no targets would take the host down in DNS
+				glog.Warningf("No external endpoint of VirtualServer %s/%s passed the health check", vs.Namespace, vs.Name)
+				rec.Eventf(vs, corev1.EventTypeWarning, reasonUnhealthyEndpoints, "No external endpoint passed the health check, the DNS records are not updated")
+				return errors.New("no healthy external endpoints")
+			}
+		}
+
+		targets, recordType, err := getValidTargets(endpoints)
 		if err != nil {
 			glog.Error("Invalid external endpoint")
 			rec.Eventf(vs, corev1.EventTypeWarning, reasonBadConfig, "Invalid external endpoint")
@@ -59,7 +80,7 @@ func SyncFnFor(rec record.EventRecorder, client clientset.Interface, ig map[stri
 
 		nsi := getNamespacedInformer(vs.Namespace, ig)
 
-		newDNSEndpoint, updateDNSEndpoint, err := buildDNSEndpoint(nsi.extdnslister, vs, targets, recordType)
+		newDNSEndpoint, updateDNSEndpoint, err := buildDNSEndpoint(nsi.extdnslister, vs, targets, recordType, ownerID)
 		if err != nil {
 			glog.Errorf("incorrect DNSEndpoint config for VirtualServer resource: %s", err)
 			rec.Eventf(vs, corev1.EventTypeWarning, reasonBadConfig, "Incorrect DNSEndpoint config for VirtualServer resource: %s", err)
@@ -98,10 +119,124 @@ func SyncFnFor(rec record.EventRecorder, client clientset.Interface, ig map[stri
 			rec.Eventf(vs, corev1.EventTypeNormal, reasonUpdateDNSEndpoint, "Successfully updated DNSEndpoint %q", updateDNSEndpoint.Name)
 			rec.Eventf(dep, corev1.EventTypeNormal, reasonUpdateDNSEndpoint, "Successfully updated DNSEndpoint for VirtualServer %q", vs.Name)
 		}
+
+		// Update the status of the DNSEndpoint with the sync state reported by external-dns
+		if dep == nil {
+			dep, err = nsi.extdnslister.DNSEndpoints(vs.Namespace).Get(vs.Name)
+			if err != nil {
+				return err
+			}
+		}
+		if !metav1.IsControlledBy(dep, vs) {
+			return nil
+		}
+		status, errs := buildDNSEndpointStatus(dep, opts...)
+		if cmp.Equal(dep.Status, status) {
+			return nil
+		}
+		// The errors are reported when the status changes, not on every resync
+		reportValidationErrors(rec, vs, dep, errs)
+		dep = dep.DeepCopy()
+		dep.Status = status
+		if _, err = client.ExternaldnsV1().DNSEndpoints(dep.Namespace).UpdateStatus(ctx, dep, metav1.UpdateOptions{}); err != nil {
+			glog.Errorf("Error updating the status of DNSEndpoint for VirtualServer resource: %v", err)
+			return err
+		}
 		return nil
 	}
 }
 
+// buildDNSEndpointStatus returns the status of the DNSEndpoint with the conditions Valid, whether the records of the
+// DNSEndpoint are valid, and Programmed, whether external-dns synced the records of the current generation, along with
+// the validation errors of the records. The message of the Valid condition lists the field path and the value of
+// every validation error, and the records carry the sync state of every record.
+func buildDNSEndpointStatus(dep *extdnsapi.DNSEndpoint, opts ...extdnsvalidation.Option) (extdnsapi.DNSEndpointStatus, field.ErrorList) {
+	status := *dep.Status.DeepCopy()
+
+	valid := metav1.Condition{
+		Type:               extdnsapi.ConditionValid,
+		Status:             metav1.ConditionTrue,
+		Reason:             reasonValid,
+		Message:            "The records are valid",
+		ObservedGeneration: dep.Generation,
+	}
+	errs := extdnsvalidation.ValidateDNSEndpoint(dep, opts...)
+	if len(errs) > 0 {
+		msgs := make([]string, 0, len(errs))
+		for _, err := range errs {
+			msgs = append(msgs, err.Error())
+		}
+		valid.Status = metav1.ConditionFalse
+		valid.Reason = reasonInvalid
+		valid.Message = strings.Join(msgs, "; ")
+	}
+	meta.SetStatusCondition(&status.Conditions, valid)
+
+	programmed := metav1.Condition{
+		Type:               extdnsapi.ConditionProgrammed,
+		Status:             metav1.ConditionTrue,
+		Reason:             reasonSynced,
+		Message:            "The records are synced to the DNS provider",
+		ObservedGeneration: dep.Generation,
+	}
+	switch {
+	case valid.Status == metav1.ConditionFalse:
+		programmed.Status = metav1.ConditionFalse
+		programmed.Reason = reasonInvalid
+		programmed.Message = "The records are invalid"
+	case status.ObservedGeneration < dep.Generation:
+		programmed.Status = metav1.ConditionFalse
+		programmed.Reason = reasonPending
+		programmed.Message = "The records are not synced to the DNS provider yet"
+	}
+	meta.SetStatusCondition(&status.Conditions, programmed)
+	status.Records = buildRecordStatuses(dep, status.ObservedGeneration, errs)
+	return status, errs
+}
+
+// buildRecordStatuses returns the sync state of every record of the DNSEndpoint. External-dns skips the invalid
+// records and syncs the others, so a record is Invalid only for the validation errors under its own field path.
+func buildRecordStatuses(dep *extdnsapi.DNSEndpoint, observedGeneration int64, errs field.ErrorList) []extdnsapi.RecordStatus {
+	var records []extdnsapi.RecordStatus
+	for i, ep := range dep.Spec.Endpoints {
+		if ep == nil {
+			continue
+		}
+		rs := extdnsapi.RecordStatus{
+			DNSName:       ep.DNSName,
+			RecordType:    ep.RecordType,
+			SetIdentifier: ep.SetIdentifier,
+			State:         extdnsapi.RecordStateSynced,
+		}
+		path := field.NewPath("spec", "endpoints").Index(i).String()
+		var msgs []string
+		for _, err := range errs {
+			if err.Field == path || strings.HasPrefix(err.Field, path+".") {
+				msgs = append(msgs, err.Error())
+			}
+		}
+		switch {
+		case len(msgs) > 0:
+			rs.State = extdnsapi.RecordStateInvalid
+			rs.Message = strings.Join(msgs, "; ")
+		case observedGeneration < dep.Generation:
+			rs.State = extdnsapi.RecordStatePending
+		}
+		records = append(records, rs)
+	}
+	return records
+}
+
+// reportValidationErrors emits a warning event with the field path and the value of every validation error of the
+// DNSEndpoint on the DNSEndpoint and on its VirtualServer.
+func reportValidationErrors(rec record.EventRecorder, vs *vsapi.VirtualServer, dep *extdnsapi.DNSEndpoint, errs field.ErrorList) {
+	for _, err := range errs {
+		glog.Warningf("Invalid DNSEndpoint %s/%s: %v", dep.Namespace, dep.Name, err)
+		rec.Eventf(dep, corev1.EventTypeWarning, reasonInvalid, "Invalid record: %v", err)
+		rec.Eventf(vs, corev1.EventTypeWarning, reasonInvalid, "Invalid record in DNSEndpoint %q: %v", dep.Name, err)
+	}
+}
+
 func getValidTargets(endpoints []vsapi.ExternalEndpoint) (extdnsapi.Targets, string, error) {
 	var targets extdnsapi.Targets
 	var recordType string
@@ -144,7 +279,7 @@ func getValidTargets(endpoints []vsapi.ExternalEndpoint) (extdnsapi.Targets, str
 	return targets, recordType, err
 }
 
-func buildDNSEndpoint(extdnsLister extdnslisters.DNSEndpointLister, vs *vsapi.VirtualServer, targets extdnsapi.Targets, recordType string) (*extdnsapi.DNSEndpoint, *extdnsapi.DNSEndpoint, error) {
+func buildDNSEndpoint(extdnsLister extdnslisters.DNSEndpointLister, vs *vsapi.VirtualServer, targets extdnsapi.Targets, recordType string, ownerID string) (*extdnsapi.DNSEndpoint, *extdnsapi.DNSEndpoint, error) {
 	var updateDNSEndpoint *extdnsapi.DNSEndpoint
 	var newDNSEndpoint *extdnsapi.DNSEndpoint
 	var existingDNSEndpoint *extdnsapi.DNSEndpoint
@@ -174,7 +309,8 @@ func buildDNSEndpoint(extdnsLister extdnslisters.DNSEndpointLister, vs *vsapi.Vi
 					Targets:          targets,
 					RecordType:       buildRecordType(vs.Spec.ExternalDNS, recordType),
 					RecordTTL:        buildTTL(vs.Spec.ExternalDNS),
-					Labels:           buildLabels(vs.Spec.ExternalDNS),
+					SetIdentifier:    vs.Spec.ExternalDNS.SetIdentifier,
+					Labels:           buildLabels(vs.Spec.ExternalDNS, ownerID),
 					ProviderSpecific: buildProviderSpecificProperties(vs.Spec.ExternalDNS),
 				},
 			},
@@ -219,11 +355,15 @@ func buildRecordType(extdnsSpec vsapi.ExternalDNS, recordType string) string {
 	return extdnsSpec.RecordType
 }
 
-func buildLabels(extdnsSpec vsapi.ExternalDNS) extdnsapi.Labels {
-	if extdnsSpec.Labels == nil {
+func buildLabels(extdnsSpec vsapi.ExternalDNS, ownerID string) extdnsapi.Labels {
+	if extdnsSpec.Labels == nil && ownerID == "" {
 		return nil
 	}
 	labels := make(extdnsapi.Labels)
+	if ownerID != "" {
+		labels[extdnsvalidation.OwnerLabel] = ownerID
+	}
+	// The labels of the VirtualServer override the owner
 	for k, v := range extdnsSpec.Labels {
 		labels[k] = v
 	}
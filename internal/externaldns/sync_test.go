@@ -3,15 +3,19 @@ package externaldns
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	vsapi "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1"
 	extdnsapi "github.com/nginxinc/kubernetes-ingress/pkg/apis/externaldns/v1"
+	extdnsvalidation "github.com/nginxinc/kubernetes-ingress/pkg/apis/externaldns/validation"
 	extdnsclient "github.com/nginxinc/kubernetes-ingress/pkg/client/listers/externaldns/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 )
 
 // EventRecorder implements EventRecorder interface.
@@ -100,7 +104,7 @@ func TestSync_NotRunningOnExternalDNSDisabled(t *testing.T) {
 			},
 		},
 	}
-	fn := SyncFnFor(nil, nil, nil)
+	fn := SyncFnFor(nil, nil, nil, "")
 	err := fn(context.TODO(), vs)
 	if err != nil {
 		t.Errorf("want nil got %v", err)
@@ -119,7 +123,7 @@ func TestSync_ReturnsErrorOnNilExternalEndpoints(t *testing.T) {
 	}
 
 	rec := EventRecorder{}
-	fn := SyncFnFor(rec, nil, nil)
+	fn := SyncFnFor(rec, nil, nil, "")
 	err := fn(context.TODO(), vs)
 	if err == nil {
 		t.Errorf("want error got nil")
@@ -207,7 +211,7 @@ func TestSync_ReturnsErrorOnInvalidTargetsInExternalEndpoints(t *testing.T) {
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
 			rec := EventRecorder{}
-			fn := SyncFnFor(rec, nil, nil)
+			fn := SyncFnFor(rec, nil, nil, "")
 			err := fn(context.TODO(), tc.input)
 			if err == nil {
 				t.Error("want error, got nil")
@@ -279,7 +283,7 @@ func TestSync_ReturnsErrorOnFailure(t *testing.T) {
 			ig := make(map[string]*namespacedInformer)
 			nsi := namespacedInformer{extdnslister: DNSEPLister{}}
 			ig[""] = &nsi
-			fn := SyncFnFor(rec, nil, ig)
+			fn := SyncFnFor(rec, nil, ig, "")
 			err := fn(context.TODO(), tc.input)
 			if err == nil {
 				t.Error("want error, got nil")
@@ -287,3 +291,235 @@ func TestSync_ReturnsErrorOnFailure(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildDNSEndpointStatus(t *testing.T) {
+	t.Parallel()
+	spec := extdnsapi.DNSEndpointSpec{
+		Endpoints: []*extdnsapi.Endpoint{
+			{
+				DNSName:    "example.com",
+				Targets:    extdnsapi.Targets{"10.2.2.3"},
+				RecordType: "A",
+			},
+		},
+	}
+	tt := []struct {
+		name           string
+		spec           extdnsapi.DNSEndpointSpec
+		status         extdnsapi.DNSEndpointStatus
+		wantValid      v1.ConditionStatus
+		wantProgrammed string
+	}{
+		{
+			name:           "synced by external-dns",
+			spec:           spec,
+			status:         extdnsapi.DNSEndpointStatus{ObservedGeneration: 2},
+			wantValid:      v1.ConditionTrue,
+			wantProgrammed: reasonSynced,
+		},
+		{
+			name:           "with a generation not observed by external-dns",
+			spec:           spec,
+			status:         extdnsapi.DNSEndpointStatus{ObservedGeneration: 1},
+			wantValid:      v1.ConditionTrue,
+			wantProgrammed: reasonPending,
+		},
+		{
+			name:           "with invalid records",
+			spec:           extdnsapi.DNSEndpointSpec{},
+			status:         extdnsapi.DNSEndpointStatus{ObservedGeneration: 2},
+			wantValid:      v1.ConditionFalse,
+			wantProgrammed: reasonInvalid,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			dep := &extdnsapi.DNSEndpoint{
+				ObjectMeta: v1.ObjectMeta{Generation: 2},
+				Spec:       tc.spec,
+				Status:     tc.status,
+			}
+			status, errs := buildDNSEndpointStatus(dep)
+
+			if status.ObservedGeneration != tc.status.ObservedGeneration {
+				t.Errorf("want the generation observed by external-dns to be kept, got %v", status)
+			}
+			if len(dep.Status.Conditions) != 0 {
+				t.Errorf("want the status of the DNSEndpoint unchanged, got %v", dep.Status)
+			}
+			valid := meta.FindStatusCondition(status.Conditions, extdnsapi.ConditionValid)
+			if valid == nil || valid.Status != tc.wantValid || valid.ObservedGeneration != 2 {
+				t.Errorf("want condition Valid with status %s, got %v", tc.wantValid, valid)
+			}
+			if (len(errs) == 0) != (tc.wantValid == v1.ConditionTrue) {
+				t.Errorf("want validation errors only for the condition Valid with status False, got %v", errs)
+			}
+			programmed := meta.FindStatusCondition(status.Conditions, extdnsapi.ConditionProgrammed)
+			if programmed == nil || programmed.Reason != tc.wantProgrammed || meta.IsStatusConditionTrue(status.Conditions, extdnsapi.ConditionProgrammed) != (tc.wantProgrammed == reasonSynced) {
+				t.Errorf("want condition Programmed with reason %s, got %v", tc.wantProgrammed, programmed)
+			}
+		})
+	}
+}
+
+func TestBuildDNSEndpointStatusReportsEveryRecord(t *testing.T) {
+	t.Parallel()
+	spec := extdnsapi.DNSEndpointSpec{
+		Endpoints: []*extdnsapi.Endpoint{
+			{
+				DNSName:    "cafe.example.com",
+				Targets:    extdnsapi.Targets{"10.2.2.3"},
+				RecordType: "A",
+			},
+			{
+				DNSName:    "tea.example.com",
+				Targets:    extdnsapi.Targets{"10.2.2.4"},
+				RecordType: "A",
+				RecordTTL:  -1,
+			},
+		},
+	}
+	tt := []struct {
+		name   string
+		status extdnsapi.DNSEndpointStatus
+		want   []string
+	}{
+		{
+			name:   "synced by external-dns",
+			status: extdnsapi.DNSEndpointStatus{ObservedGeneration: 2},
+			want:   []string{extdnsapi.RecordStateSynced, extdnsapi.RecordStateInvalid},
+		},
+		{
+			name:   "with a generation not observed by external-dns",
+			status: extdnsapi.DNSEndpointStatus{ObservedGeneration: 1},
+			want:   []string{extdnsapi.RecordStatePending, extdnsapi.RecordStateInvalid},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			dep := &extdnsapi.DNSEndpoint{
+				ObjectMeta: v1.ObjectMeta{Generation: 2},
+				Spec:       spec,
+				Status:     tc.status,
+			}
+			status, _ := buildDNSEndpointStatus(dep)
+
+			if len(status.Records) != len(tc.want) {
+				t.Fatalf("want a status for every record, got %v", status.Records)
+			}
+			for i, rs := range status.Records {
+				if rs.DNSName != spec.Endpoints[i].DNSName || rs.RecordType != "A" || rs.State != tc.want[i] {
+					t.Errorf("want record %s in state %s, got %v", spec.Endpoints[i].DNSName, tc.want[i], rs)
+				}
+			}
+			if msg := status.Records[0].Message; msg != "" {
+				t.Errorf("want no message for the valid record, got %q", msg)
+			}
+			if msg := status.Records[1].Message; !strings.Contains(msg, "spec.endpoints[1].recordTTL") {
+				t.Errorf("want the validation error of the invalid record, got %q", msg)
+			}
+		})
+	}
+}
+
+func TestBuildDNSEndpointStatus_ReportsFieldPathAndValue(t *testing.T) {
+	t.Parallel()
+	dep := &extdnsapi.DNSEndpoint{
+		ObjectMeta: v1.ObjectMeta{Name: "cafe", Namespace: "default", Generation: 1},
+		Spec: extdnsapi.DNSEndpointSpec{
+			Endpoints: []*extdnsapi.Endpoint{
+				{
+					DNSName:    "cafe.example.com",
+					Targets:    extdnsapi.Targets{"10.2.2.3"},
+					RecordType: "A",
+					RecordTTL:  -1,
+				},
+			},
+		},
+	}
+	status, errs := buildDNSEndpointStatus(dep)
+	if len(errs) != 1 {
+		t.Fatalf("want 1 validation error, got %v", errs)
+	}
+	valid := meta.FindStatusCondition(status.Conditions, extdnsapi.ConditionValid)
+	if valid == nil || !strings.Contains(valid.Message, "spec.endpoints[0].recordTTL") || !strings.Contains(valid.Message, "-1") {
+		t.Errorf("want condition Valid with the field path and the value of the error, got %v", valid)
+	}
+
+	rec := record.NewFakeRecorder(2)
+	reportValidationErrors(rec, &vsapi.VirtualServer{}, dep, errs)
+	close(rec.Events)
+	var events []string
+	for event := range rec.Events {
+		events = append(events, event)
+	}
+	if len(events) != 2 {
+		t.Fatalf("want an event on the DNSEndpoint and on the VirtualServer, got %v", events)
+	}
+	for _, event := range events {
+		if !strings.HasPrefix(event, "Warning "+reasonInvalid) || !strings.Contains(event, "spec.endpoints[0].recordTTL") {
+			t.Errorf("want a warning event with the field path of the error, got %q", event)
+		}
+	}
+}
+
+func TestBuildDNSEndpointStatusWithValidationOptions(t *testing.T) {
+	t.Parallel()
+	dep := &extdnsapi.DNSEndpoint{
+		ObjectMeta: v1.ObjectMeta{Name: "cafe", Namespace: "default", Generation: 1},
+		Spec: extdnsapi.DNSEndpointSpec{
+			Endpoints: []*extdnsapi.Endpoint{
+				{
+					DNSName:    "cafe.example.com",
+					Targets:    extdnsapi.Targets{"10.2.2.3"},
+					RecordType: "A",
+					RecordTTL:  30,
+				},
+			},
+		},
+	}
+	if _, errs := buildDNSEndpointStatus(dep); len(errs) != 0 {
+		t.Fatalf("want no validation errors without options, got %v", errs)
+	}
+	status, errs := buildDNSEndpointStatus(dep, extdnsvalidation.TTLBounds(60, 3600))
+	if len(errs) != 1 {
+		t.Fatalf("want 1 validation error with the TTL bounds of the provider, got %v", errs)
+	}
+	if valid := meta.FindStatusCondition(status.Conditions, extdnsapi.ConditionValid); valid == nil || valid.Status != v1.ConditionFalse {
+		t.Errorf("want condition Valid False, got %v", valid)
+	}
+}
+
+func TestBuildLabels(t *testing.T) {
+	t.Parallel()
+	tt := []struct {
+		name    string
+		labels  map[string]string
+		ownerID string
+		want    extdnsapi.Labels
+	}{
+		{
+			name: "without labels and owner",
+		},
+		{
+			name:    "with the owner of the controller",
+			labels:  map[string]string{"team": "cafe"},
+			ownerID: "cluster-1",
+			want:    extdnsapi.Labels{"owner": "cluster-1", "team": "cafe"},
+		},
+		{
+			name:    "with the owner of the VirtualServer",
+			labels:  map[string]string{"owner": "cluster-2"},
+			ownerID: "cluster-1",
+			want:    extdnsapi.Labels{"owner": "cluster-2"},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got := buildLabels(vsapi.ExternalDNS{Labels: tc.labels}, tc.ownerID)
+			if !cmp.Equal(tc.want, got) {
+				t.Error(cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
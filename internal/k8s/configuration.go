@@ -11,6 +11,7 @@ import (
 	conf_v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1"
 	"github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/validation"
 	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -240,7 +241,12 @@ func (vsc *VirtualServerConfiguration) IsEqual(resource Resource) bool {
 		return false
 	}
 
-	if !compareObjectMetas(&vsc.VirtualServer.ObjectMeta, &vsConfig.VirtualServer.ObjectMeta) {
+	if !compareObjectMetasWithAnnotations(&vsc.VirtualServer.ObjectMeta, &vsConfig.VirtualServer.ObjectMeta) {
+		return false
+	}
+
+	// Labels decide which OIDC policies select the VirtualServer
+	if !reflect.DeepEqual(vsc.VirtualServer.Labels, vsConfig.VirtualServer.Labels) {
 		return false
 	}
 
@@ -902,6 +908,23 @@ func (c *Configuration) FindResourcesForPolicy(policyNamespace string, policyNam
 	return c.findResourcesForResourceReference(policyNamespace, policyName, c.policyReferenceChecker)
 }
 
+// FindVirtualServersForSelector finds VirtualServers whose labels match the selector.
+func (c *Configuration) FindVirtualServersForSelector(selector labels.Selector) []Resource {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	var result []Resource
+
+	for _, h := range getSortedResourceKeys(c.hosts) {
+		vsc, ok := c.hosts[h].(*VirtualServerConfiguration)
+		if ok && selector.Matches(labels.Set(vsc.VirtualServer.Labels)) {
+			result = append(result, vsc)
+		}
+	}
+
+	return result
+}
+
 // FindResourcesForAppProtectPolicyAnnotation finds resources that reference the specified AppProtect policy via annotation.
 func (c *Configuration) FindResourcesForAppProtectPolicyAnnotation(policyNamespace string, policyName string) []Resource {
 	return c.findResourcesForResourceReference(policyNamespace, policyName, c.appPolicyReferenceChecker)
@@ -18,9 +18,16 @@ package k8s
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
+	"reflect"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -51,19 +58,25 @@ import (
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/leaderelection"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 
 	cm_controller "github.com/nginxinc/kubernetes-ingress/internal/certmanager"
 	"github.com/nginxinc/kubernetes-ingress/internal/configs"
 	ed_controller "github.com/nginxinc/kubernetes-ingress/internal/externaldns"
 	"github.com/nginxinc/kubernetes-ingress/internal/metrics/collectors"
+	"github.com/nginxinc/kubernetes-ingress/internal/oidc"
+	"github.com/nginxinc/kubernetes-ingress/internal/oidc/session"
+	"github.com/nginxinc/kubernetes-ingress/internal/oidc/webhook"
 
 	api_v1 "k8s.io/api/core/v1"
 	discovery_v1 "k8s.io/api/discovery/v1"
 	networking "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	conf_v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1"
 	"github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/validation"
+	extdns_validation "github.com/nginxinc/kubernetes-ingress/pkg/apis/externaldns/validation"
 	k8s_nginx "github.com/nginxinc/kubernetes-ingress/pkg/client/clientset/versioned"
 	k8s_nginx_informers "github.com/nginxinc/kubernetes-ingress/pkg/client/informers/externalversions"
 
@@ -144,6 +157,7 @@ type LoadBalancerController struct {
 	wildcardTLSSecret             string
 	areCustomResourcesEnabled     bool
 	enableOIDC                    bool
+	oidcFIPSMode                  bool
 	metricsCollector              collectors.ControllerCollector
 	globalConfigurationValidator  *validation.GlobalConfigurationValidator
 	transportServerValidator      *validation.TransportServerValidator
@@ -160,6 +174,20 @@ type LoadBalancerController struct {
 	configMap                     *api_v1.ConfigMap
 	certManagerController         *cm_controller.CmController
 	externalDNSController         *ed_controller.ExtDNSController
+	oidcRefresher                 *oidc.Refresher
+	oidcHealthChecker             *oidc.HealthChecker
+	oidcWebhook                   *webhook.Notifier
+	oidcProviderReadiness         bool
+	oidcSessionServer             *session.Server
+	oidcProxies                   *oidc.Proxies
+	oidcIntrospector              *oidc.Introspector
+	oidcProvidersLister           cache.Store
+	oidcProvidersController       cache.Controller
+	oidcPreviousSecrets           map[string]previousSecret
+	oidcPolicySelectors           map[string]labels.Selector
+	defaultOIDCPolicy             string
+	policyDryRunPort              int
+	oidcSessionAdminPort          int
 	batchSyncEnabled              bool
 	updateAllConfigsOnBatch       bool
 	enableBatchReload             bool
@@ -172,6 +200,27 @@ type LoadBalancerController struct {
 
 var keyFunc = cache.DeletionHandlingMetaNamespaceKeyFunc
 
+// oidcStateLifetime is the lifetime of the state of an OIDC login, see stateLifetime in openid_connect.js.
+const oidcStateLifetime = 10 * time.Minute
+
+// oidcSessionTTL is how long a session is kept in a session store after it was last refreshed, like the refresh tokens in the keyval zone.
+const oidcSessionTTL = session.Lifetime
+
+// oidcSessionSweepInterval is how often the entries of the OIDC keyval zones that NGINX can't use anymore are deleted.
+const oidcSessionSweepInterval = 5 * time.Minute
+
+// oidcPolicyFinalizer is the finalizer of OIDC policies. It makes the controller delete the sessions of a policy before the policy is removed.
+const oidcPolicyFinalizer = "k8s.nginx.org/oidc-cleanup"
+
+// disableDefaultOIDCPolicyAnnotation opts a VirtualServer out of the default OIDC policy when set to "true".
+const disableDefaultOIDCPolicyAnnotation = "nginx.org/disable-default-oidc-policy"
+
+// previousSecret is the previous version of a rotated secret.
+type previousSecret struct {
+	secret *api_v1.Secret
+	expiry time.Time
+}
+
 // NewLoadBalancerControllerInput holds the input needed to call NewLoadBalancerController.
 type NewLoadBalancerControllerInput struct {
 	KubeClient                   kubernetes.Interface
@@ -199,6 +248,11 @@ type NewLoadBalancerControllerInput struct {
 	GlobalConfiguration          string
 	AreCustomResourcesEnabled    bool
 	EnableOIDC                   bool
+	OIDCFIPSMode                 bool
+	DefaultOIDCPolicy            string
+	PolicyDryRunListenPort       int
+	OIDCSessionAdminListenPort   int
+	OIDCProviderReadiness        bool
 	MetricsCollector             collectors.ControllerCollector
 	GlobalConfigurationValidator *validation.GlobalConfigurationValidator
 	TransportServerValidator     *validation.TransportServerValidator
@@ -212,6 +266,8 @@ type NewLoadBalancerControllerInput struct {
 	SnippetsEnabled              bool
 	CertManagerEnabled           bool
 	ExternalDNSEnabled           bool
+	ExternalDNSOwnerID           string
+	ExternalDNSValidationOptions []extdns_validation.Option
 	IsIPV6Disabled               bool
 	WatchNamespaceLabel          string
 	EnableTelemetryReporting     bool
@@ -244,6 +300,11 @@ func NewLoadBalancerController(input NewLoadBalancerControllerInput) *LoadBalanc
 		wildcardTLSSecret:            input.WildcardTLSSecret,
 		areCustomResourcesEnabled:    input.AreCustomResourcesEnabled,
 		enableOIDC:                   input.EnableOIDC,
+		oidcFIPSMode:                 input.OIDCFIPSMode,
+		defaultOIDCPolicy:            input.DefaultOIDCPolicy,
+		policyDryRunPort:             input.PolicyDryRunListenPort,
+		oidcSessionAdminPort:         input.OIDCSessionAdminListenPort,
+		oidcProviderReadiness:        input.OIDCProviderReadiness,
 		metricsCollector:             input.MetricsCollector,
 		globalConfigurationValidator: input.GlobalConfigurationValidator,
 		transportServerValidator:     input.TransportServerValidator,
@@ -281,7 +342,22 @@ func NewLoadBalancerController(input NewLoadBalancerControllerInput) *LoadBalanc
 	}
 
 	if input.ExternalDNSEnabled {
-		lbc.externalDNSController = ed_controller.NewController(ed_controller.BuildOpts(context.TODO(), lbc.namespaceList, lbc.recorder, lbc.confClient, input.ResyncPeriod, isDynamicNs))
+		lbc.externalDNSController = ed_controller.NewController(ed_controller.BuildOpts(context.TODO(), lbc.namespaceList, lbc.recorder, lbc.confClient, input.ResyncPeriod, isDynamicNs, input.ExternalDNSOwnerID, input.ExternalDNSValidationOptions...))
+	}
+
+	if input.EnableOIDC {
+		lbc.oidcPreviousSecrets = make(map[string]previousSecret)
+		lbc.oidcPolicySelectors = make(map[string]labels.Selector)
+		lbc.oidcProxies = oidc.NewProxies()
+		lbc.oidcRefresher = oidc.NewRefresher(&http.Client{Timeout: 10 * time.Second, Transport: lbc.oidcProxies.Transport()}, oidc.DefaultJWKSDir, lbc.syncOIDCPolicy, lbc.reportOIDCProviderError)
+		lbc.oidcHealthChecker = oidc.NewHealthChecker(&http.Client{Timeout: 10 * time.Second, Transport: lbc.oidcProxies.Transport()}, oidc.DefaultHealthCheckInterval, lbc.reportOIDCProviderHealth)
+		lbc.oidcSessionServer = session.NewServer()
+		lbc.oidcSessionServer.SetEgressHandler(lbc.oidcProxies)
+		lbc.oidcIntrospector = oidc.NewIntrospector(lbc.oidcRefresher)
+		lbc.oidcSessionServer.SetIntrospectionHandler(lbc.oidcIntrospector)
+		lbc.oidcWebhook = webhook.NewNotifier(&http.Client{Timeout: 10 * time.Second}, lbc.configurator.OIDCWebhook)
+		lbc.oidcSessionServer.SetEventHandler(lbc.oidcWebhook.Event)
+		lbc.oidcRefresher.SetRotationHandler(lbc.notifyOIDCKeyRotation)
 	}
 
 	glog.V(3).Infof("Nginx Ingress Controller has class: %v", input.IngressClass)
@@ -320,6 +396,11 @@ func NewLoadBalancerController(input NewLoadBalancerControllerInput) *LoadBalanc
 
 	if input.IsLeaderElectionEnabled {
 		lbc.addLeaderHandler(createLeaderHandler(lbc))
+
+		if lbc.oidcRefresher != nil {
+			lbc.oidcRefresher.EnableDistribution(lbc.publishOIDCProviderDocuments)
+			lbc.addOIDCProvidersHandler(createOIDCProvidersHandlers(lbc))
+		}
 	}
 
 	lbc.statusUpdater = &statusUpdater{
@@ -404,6 +485,7 @@ type namespacedInformer struct {
 	endpointSliceLister          storeToEndpointSliceLister
 	podLister                    indexerToPodLister
 	secretLister                 cache.Store
+	configMapLister              cache.Store
 	virtualServerLister          cache.Store
 	virtualServerRouteLister     cache.Store
 	appProtectPolicyLister       cache.Store
@@ -466,6 +548,9 @@ func (lbc *LoadBalancerController) newNamespacedInformer(ns string) *namespacedI
 		nsi.addTransportServerHandler(createTransportServerHandlers(lbc))
 		nsi.addPolicyHandler(createPolicyHandlers(lbc))
 
+		if lbc.enableOIDC {
+			nsi.addConfigMapHandler(createOIDCConfigMapHandlers(lbc))
+		}
 	}
 
 	if lbc.appProtectEnabled || lbc.appProtectDosEnabled {
@@ -566,6 +651,15 @@ func (nsi *namespacedInformer) addSecretHandler(handlers cache.ResourceEventHand
 	nsi.cacheSyncs = append(nsi.cacheSyncs, informer.HasSynced)
 }
 
+// addConfigMapHandler adds the handler for the ConfigMaps referenced by policies to the controller
+func (nsi *namespacedInformer) addConfigMapHandler(handlers cache.ResourceEventHandlerFuncs) {
+	informer := nsi.sharedInformerFactory.Core().V1().ConfigMaps().Informer()
+	informer.AddEventHandler(handlers)
+	nsi.configMapLister = informer.GetStore()
+
+	nsi.cacheSyncs = append(nsi.cacheSyncs, informer.HasSynced)
+}
+
 // addServiceHandler adds the handler for services to the controller
 func (nsi *namespacedInformer) addServiceHandler(handlers cache.ResourceEventHandlerFuncs) {
 	informer := nsi.sharedInformerFactory.Core().V1().Services().Informer()
@@ -641,6 +735,21 @@ func (nsi *namespacedInformer) addPolicyHandler(handlers cache.ResourceEventHand
 	nsi.cacheSyncs = append(nsi.cacheSyncs, informer.HasSynced)
 }
 
+// addOIDCProvidersHandler watches the ConfigMap where the leader publishes the provider documents of the OIDC policies.
+func (lbc *LoadBalancerController) addOIDCProvidersHandler(handlers cache.ResourceEventHandlerFuncs) {
+	lbc.oidcProvidersLister, lbc.oidcProvidersController = cache.NewInformer(
+		cache.NewListWatchFromClient(
+			lbc.client.CoreV1().RESTClient(),
+			"configmaps",
+			lbc.controllerNamespace,
+			fields.Set{"metadata.name": lbc.oidcProvidersConfigMapName()}.AsSelector()),
+		&api_v1.ConfigMap{},
+		lbc.resync,
+		handlers,
+	)
+	lbc.cacheSyncs = append(lbc.cacheSyncs, lbc.oidcProvidersController.HasSynced)
+}
+
 func (lbc *LoadBalancerController) addGlobalConfigurationHandler(handlers cache.ResourceEventHandlerFuncs, namespace string, name string) {
 	lbc.globalConfigurationLister, lbc.globalConfigurationController = cache.NewInformer(
 		cache.NewListWatchFromClient(
@@ -734,6 +843,32 @@ func (lbc *LoadBalancerController) Run() {
 	if lbc.externalDNSController != nil {
 		go lbc.externalDNSController.Run(lbc.ctx.Done())
 	}
+	if lbc.oidcRefresher != nil {
+		go lbc.oidcRefresher.Run(lbc.ctx.Done())
+	}
+	if lbc.oidcHealthChecker != nil {
+		go lbc.oidcHealthChecker.Run(lbc.ctx.Done())
+	}
+	if lbc.oidcWebhook != nil {
+		go lbc.oidcWebhook.Run(lbc.ctx.Done())
+	}
+	if lbc.oidcSessionServer != nil {
+		go func() {
+			glog.Fatal(lbc.oidcSessionServer.ListenAndServe(session.DefaultSocket))
+		}()
+	}
+	if lbc.oidcRefresher != nil && lbc.isNginxPlus {
+		go lbc.runOIDCSessionSweeper(lbc.ctx.Done())
+	}
+	if lbc.oidcRefresher != nil {
+		go lbc.runOIDCKeyRotation(lbc.ctx.Done())
+	}
+	if lbc.policyDryRunPort != 0 {
+		go lbc.runPolicyDryRun()
+	}
+	if lbc.oidcSessionAdminPort != 0 {
+		go lbc.runOIDCSessionAdmin()
+	}
 
 	if lbc.leaderElector != nil {
 		go lbc.leaderElector.Run(lbc.ctx)
@@ -761,6 +896,9 @@ func (lbc *LoadBalancerController) Run() {
 	if lbc.watchGlobalConfiguration {
 		go lbc.globalConfigurationController.Run(lbc.ctx.Done())
 	}
+	if lbc.oidcProvidersController != nil {
+		go lbc.oidcProvidersController.Run(lbc.ctx.Done())
+	}
 	if lbc.watchIngressLink {
 		go lbc.ingressLinkInformer.Run(lbc.ctx.Done())
 	}
@@ -1179,7 +1317,7 @@ func (lbc *LoadBalancerController) sync(task task) {
 		glog.V(3).Infof("Batch processing %v items", lbc.syncQueue.Len())
 	}
 	glog.V(3).Infof("Syncing %v", task.Key)
-	if lbc.spiffeCertFetcher != nil {
+	if lbc.spiffeCertFetcher != nil || lbc.policyDryRunPort != 0 {
 		lbc.syncLock.Lock()
 		defer lbc.syncLock.Unlock()
 	}
@@ -1545,9 +1683,14 @@ func (lbc *LoadBalancerController) syncPolicy(task task) {
 
 	glog.V(2).Infof("Adding, Updating or Deleting Policy: %v\n", key)
 
-	if polExists && lbc.HasCorrectIngressClass(obj) {
+	refreshOIDC := false
+	var deletedPol, validPol *conf_v1.Policy
+	if polExists && obj.(*conf_v1.Policy).DeletionTimestamp != nil && lbc.HasCorrectIngressClass(obj) {
+		// The policy is removed from the configuration like a deleted policy, but it is only gone after the finalizer is removed.
+		deletedPol = obj.(*conf_v1.Policy)
+	} else if polExists && lbc.HasCorrectIngressClass(obj) {
 		pol := obj.(*conf_v1.Policy)
-		err := validation.ValidatePolicy(pol, lbc.isNginxPlus, lbc.enableOIDC, lbc.appProtectEnabled)
+		err := lbc.validatePolicy(pol)
 		if err != nil {
 			msg := fmt.Sprintf("Policy %v/%v is invalid and was rejected: %v", pol.Namespace, pol.Name, err)
 			lbc.recorder.Eventf(pol, api_v1.EventTypeWarning, "Rejected", msg)
@@ -1561,6 +1704,35 @@ func (lbc *LoadBalancerController) syncPolicy(task task) {
 		} else {
 			msg := fmt.Sprintf("Policy %v/%v was added or updated", pol.Namespace, pol.Name)
 			lbc.recorder.Eventf(pol, api_v1.EventTypeNormal, "AddedOrUpdated", msg)
+			validPol = pol
+
+			if key == lbc.defaultOIDCPolicy {
+				if err := validateDefaultOIDCPolicy(pol); err != nil {
+					msg := fmt.Sprintf("Policy %v/%v can't be the default OIDC policy: %v", pol.Namespace, pol.Name, err)
+					lbc.recorder.Eventf(pol, api_v1.EventTypeWarning, "DefaultPolicyConflict", msg)
+				}
+			}
+
+			if pol.Spec.OIDC != nil && lbc.oidcRefresher != nil {
+				lbc.updateOIDCProxy(key, pol)
+				lbc.updateOIDCIntrospection(key, pol)
+				// The metadata of ADFS deviates from the spec
+				lbc.oidcRefresher.Update(key, configs.OIDCDiscoveryEndpoint(pol.Spec.OIDC), pol.Spec.OIDC.JWKSURI, pol.Spec.OIDC.TokenEndpoint,
+					pol.Spec.OIDC.IdPType == "adfs")
+				lbc.applyOIDCProviderDocuments(key)
+				lbc.updateOIDCHealthCheck(key, pol)
+				refreshOIDC = true
+			}
+
+			lbc.reportOIDCSecretErrors(pol)
+
+			if pol.Spec.OIDC != nil && lbc.enableOIDC && lbc.reportCustomResourceStatusEnabled() {
+				lbc.addOIDCPolicyFinalizer(pol)
+			}
+
+			if oidcManagedKeysSecret(pol) != "" && lbc.enableOIDC {
+				lbc.updateOIDCManagedKeys(pol)
+			}
 
 			if lbc.reportCustomResourceStatusEnabled() {
 				err = lbc.statusUpdater.UpdatePolicyStatus(pol, conf_v1.StateValid, "AddedOrUpdated", msg)
@@ -1571,21 +1743,630 @@ func (lbc *LoadBalancerController) syncPolicy(task task) {
 		}
 	}
 
+	if !refreshOIDC && lbc.oidcRefresher != nil {
+		lbc.oidcRefresher.Remove(key)
+		lbc.unpublishOIDCProviderDocuments(key)
+		lbc.oidcHealthChecker.Remove(key)
+		lbc.oidcProxies.Remove(key)
+		lbc.metricsCollector.DeleteOIDCProvider(key)
+		lbc.oidcIntrospector.Remove(key)
+		lbc.metricsCollector.DeleteOIDCSessions(key)
+	}
+	lbc.updateOIDCSessionStore(key, validPol)
+
 	// it is safe to ignore the error
 	namespace, name, _ := ParseNamespaceName(key)
 
 	resources := lbc.configuration.FindResourcesForPolicy(namespace, name)
+	resources = append(resources, lbc.updateOIDCPolicySelector(key, validPol)...)
+	resources = removeDuplicateResources(resources)
 	resourceExes := lbc.createExtendedResources(resources)
 
 	// Only VirtualServers support policies
-	if len(resourceExes.VirtualServerExes) == 0 {
+	if len(resourceExes.VirtualServerExes) > 0 {
+		warnings, updateErr := lbc.configurator.AddOrUpdateVirtualServers(resourceExes.VirtualServerExes)
+		lbc.updateResourcesStatusAndEvents(resources, warnings, updateErr)
+	}
+
+	// Note: updating the status of a policy based on a reload is not needed.
+
+	if deletedPol != nil && slices.Contains(deletedPol.Finalizers, oidcPolicyFinalizer) && lbc.enableOIDC {
+		if err := lbc.finalizeOIDCPolicy(deletedPol); err != nil {
+			lbc.syncQueue.Requeue(task, err)
+		}
+	} else if !polExists && lbc.enableOIDC {
+		// Another replica can remove the finalizer before this one finalized the policy.
+		lbc.configurator.DeleteOIDCSessions(key)
+	}
+}
+
+// updateOIDCPolicySelector keeps the virtualServerSelector of the valid OIDC policy with the key, and returns
+// the VirtualServers that were selected by the previous selector or are selected by the current one.
+func (lbc *LoadBalancerController) updateOIDCPolicySelector(key string, pol *conf_v1.Policy) []Resource {
+	if lbc.oidcPolicySelectors == nil {
+		return nil
+	}
+
+	resources := lbc.findVirtualServersSelectedByPolicy(key)
+	delete(lbc.oidcPolicySelectors, key)
+
+	if pol == nil || pol.Spec.OIDC == nil || pol.Spec.OIDC.VirtualServerSelector == nil {
+		return resources
+	}
+	selector, err := meta_v1.LabelSelectorAsSelector(pol.Spec.OIDC.VirtualServerSelector)
+	if err != nil {
+		glog.Warningf("Invalid virtualServerSelector of Policy %v: %v", key, err)
+		return resources
+	}
+	lbc.oidcPolicySelectors[key] = selector
+	return append(resources, lbc.configuration.FindVirtualServersForSelector(selector)...)
+}
+
+// findVirtualServersSelectedByPolicy finds the VirtualServers selected by the virtualServerSelector of the OIDC policy with the key,
+// or all VirtualServers if it is the default OIDC policy.
+func (lbc *LoadBalancerController) findVirtualServersSelectedByPolicy(key string) []Resource {
+	if key == lbc.defaultOIDCPolicy {
+		return lbc.configuration.FindVirtualServersForSelector(labels.Everything())
+	}
+	selector, exists := lbc.oidcPolicySelectors[key]
+	if !exists {
+		return nil
+	}
+	return lbc.configuration.FindVirtualServersForSelector(selector)
+}
+
+// getImplicitOIDCPolicy returns the OIDC policy that applies to the VirtualServer without a reference, unless the
+// VirtualServer and its VirtualServerRoutes already reference an OIDC policy. A policy that selects the VirtualServer
+// with its virtualServerSelector takes precedence over the default OIDC policy.
+func (lbc *LoadBalancerController) getImplicitOIDCPolicy(vs *conf_v1.VirtualServer, policies []*conf_v1.Policy) *conf_v1.Policy {
+	for _, pol := range policies {
+		if pol.Spec.OIDC != nil {
+			return nil
+		}
+	}
+
+	if pol := lbc.getSelectingOIDCPolicy(vs); pol != nil {
+		return pol
+	}
+	return lbc.getDefaultOIDCPolicy(vs)
+}
+
+// getSelectingOIDCPolicy returns the OIDC policy that selects the VirtualServer with its virtualServerSelector.
+// When several policies select the VirtualServer, the first one by namespace and name is used.
+func (lbc *LoadBalancerController) getSelectingOIDCPolicy(vs *conf_v1.VirtualServer) *conf_v1.Policy {
+	if len(lbc.oidcPolicySelectors) == 0 {
+		return nil
+	}
+
+	var selected []string
+	for key, selector := range lbc.oidcPolicySelectors {
+		if selector.Matches(labels.Set(vs.Labels)) {
+			selected = append(selected, key)
+		}
+	}
+	sort.Strings(selected)
+
+	for i, key := range selected {
+		ns, _, _ := cache.SplitMetaNamespaceKey(key)
+		obj, exists, err := lbc.getNamespacedInformer(ns).policyLister.GetByKey(key)
+		if err != nil || !exists {
+			continue
+		}
+		if i < len(selected)-1 {
+			glog.Warningf("VirtualServer %v/%v is selected by the OIDC policies %v, using %v", vs.Namespace, vs.Name, selected, key)
+		}
+		return obj.(*conf_v1.Policy)
+	}
+	return nil
+}
+
+// getDefaultOIDCPolicy returns the default OIDC policy if it is valid and the VirtualServer doesn't opt out of it.
+func (lbc *LoadBalancerController) getDefaultOIDCPolicy(vs *conf_v1.VirtualServer) *conf_v1.Policy {
+	if lbc.defaultOIDCPolicy == "" || vs.Annotations[disableDefaultOIDCPolicyAnnotation] == "true" {
+		return nil
+	}
+
+	ns, name, _ := cache.SplitMetaNamespaceKey(lbc.defaultOIDCPolicy)
+	policies, errs := lbc.getPolicies([]conf_v1.PolicyReference{{Name: name, Namespace: ns}}, ns)
+	if len(errs) > 0 {
+		glog.V(3).Infof("Default OIDC policy is not applied to VirtualServer %v/%v: %v", vs.Namespace, vs.Name, errs)
+		return nil
+	}
+	if err := validateDefaultOIDCPolicy(policies[0]); err != nil {
+		glog.V(3).Infof("Default OIDC policy is not applied to VirtualServer %v/%v: %v", vs.Namespace, vs.Name, err)
+		return nil
+	}
+	return policies[0]
+}
+
+// validateDefaultOIDCPolicy checks that the policy can be the default OIDC policy.
+func validateDefaultOIDCPolicy(pol *conf_v1.Policy) error {
+	if pol.Spec.OIDC == nil {
+		return errors.New("the policy is not an OIDC policy")
+	}
+	if pol.Spec.OIDC.VirtualServerSelector != nil {
+		return errors.New("the default OIDC policy applies to all VirtualServers and can't have a virtualServerSelector")
+	}
+	return nil
+}
+
+// addOIDCPolicyFinalizer adds the finalizer that cleans up the sessions of an OIDC policy to the policy.
+func (lbc *LoadBalancerController) addOIDCPolicyFinalizer(pol *conf_v1.Policy) {
+	if slices.Contains(pol.Finalizers, oidcPolicyFinalizer) {
 		return
 	}
+	polCopy := pol.DeepCopy()
+	polCopy.Finalizers = append(polCopy.Finalizers, oidcPolicyFinalizer)
+	_, err := lbc.confClient.K8sV1().Policies(polCopy.Namespace).Update(context.TODO(), polCopy, meta_v1.UpdateOptions{})
+	if err != nil {
+		glog.Warningf("Failed to add the finalizer to Policy %v/%v: %v", pol.Namespace, pol.Name, err)
+	}
+}
 
-	warnings, updateErr := lbc.configurator.AddOrUpdateVirtualServers(resourceExes.VirtualServerExes)
-	lbc.updateResourcesStatusAndEvents(resources, warnings, updateErr)
+// finalizeOIDCPolicy deletes the sessions of an OIDC policy that is being deleted from the keyval zones of NGINX
+// and from its session store, and removes the finalizer, so that the policy is removed.
+// Every replica of the Ingress Controller that handles the policy finalizes it, the first one removes the finalizer.
+func (lbc *LoadBalancerController) finalizeOIDCPolicy(pol *conf_v1.Policy) error {
+	lbc.configurator.DeleteOIDCSessions(pol.Namespace + "/" + pol.Name)
+	if err := lbc.deleteOIDCSessionStoreSessions(pol); err != nil {
+		return err
+	}
 
-	// Note: updating the status of a policy based on a reload is not needed.
+	polCopy := pol.DeepCopy()
+	polCopy.Finalizers = slices.DeleteFunc(polCopy.Finalizers, func(f string) bool { return f == oidcPolicyFinalizer })
+	_, err := lbc.confClient.K8sV1().Policies(polCopy.Namespace).Update(context.TODO(), polCopy, meta_v1.UpdateOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to remove the finalizer from Policy %v/%v: %w", pol.Namespace, pol.Name, err)
+	}
+	lbc.recorder.Eventf(pol, api_v1.EventTypeNormal, "Finalized", "The OIDC sessions of Policy %v/%v were deleted", pol.Namespace, pol.Name)
+	return nil
+}
+
+// deleteOIDCSessionStoreSessions deletes the sessions of an OIDC policy from its Redis session store.
+func (lbc *LoadBalancerController) deleteOIDCSessionStoreSessions(pol *conf_v1.Policy) error {
+	if !hasOIDCSessionStore(pol) {
+		return nil
+	}
+	store, err := lbc.createOIDCSessionStore(pol)
+	if err != nil {
+		return fmt.Errorf("failed to create the session store of Policy %v/%v: %w", pol.Namespace, pol.Name, err)
+	}
+	defer store.Close() //nolint:errcheck
+
+	ctx, cancel := context.WithTimeout(lbc.ctx, time.Minute)
+	defer cancel()
+	deleted, err := store.DeleteAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete the sessions of Policy %v/%v from the session store: %w", pol.Namespace, pol.Name, err)
+	}
+	glog.Infof("Deleted %v OIDC sessions of Policy %v/%v from the session store", deleted, pol.Namespace, pol.Name)
+	return nil
+}
+
+// updateOIDCSessionStore sets the Redis session store of a valid OIDC policy in the session server,
+// or removes the store of the policy if it has none.
+func (lbc *LoadBalancerController) updateOIDCSessionStore(key string, pol *conf_v1.Policy) {
+	if lbc.oidcSessionServer == nil {
+		return
+	}
+	if pol == nil || !hasOIDCSessionStore(pol) {
+		lbc.oidcSessionServer.Remove(key)
+		return
+	}
+	store, err := lbc.createOIDCSessionStore(pol)
+	if err != nil {
+		glog.Warningf("Failed to create the session store of Policy %v: %v", key, err)
+		lbc.oidcSessionServer.Remove(key)
+		return
+	}
+	lbc.oidcSessionServer.Set(key, store)
+}
+
+// refreshOIDCSessionStores recreates the session stores of the valid policies, after a secret of their stores changed.
+func (lbc *LoadBalancerController) refreshOIDCSessionStores(pols []*conf_v1.Policy) {
+	for _, pol := range pols {
+		if !hasOIDCSessionStore(pol) {
+			continue
+		}
+		if err := lbc.validatePolicy(pol); err != nil {
+			continue
+		}
+		lbc.updateOIDCSessionStore(getResourceKey(&pol.ObjectMeta), pol)
+	}
+}
+
+// refreshOIDCIntrospection updates the token introspection of the valid policies, after their client secret changed.
+func (lbc *LoadBalancerController) refreshOIDCIntrospection(pols []*conf_v1.Policy) {
+	if lbc.oidcIntrospector == nil {
+		return
+	}
+	for _, pol := range pols {
+		if pol.Spec.OIDC == nil || pol.Spec.OIDC.Introspection == nil {
+			continue
+		}
+		if err := lbc.validatePolicy(pol); err != nil {
+			continue
+		}
+		lbc.updateOIDCIntrospection(getResourceKey(&pol.ObjectMeta), pol)
+	}
+}
+
+// updateOIDCIntrospection sets the token introspection of the OIDC policy with the client secret of the policy, or
+// removes it when the policy doesn't enable it.
+func (lbc *LoadBalancerController) updateOIDCIntrospection(key string, pol *conf_v1.Policy) {
+	introspection := pol.Spec.OIDC.Introspection
+	if introspection == nil || !introspection.Enable {
+		lbc.oidcIntrospector.Remove(key)
+		return
+	}
+	secretRef := lbc.secretStore.GetSecret(secrets.GetReferenceKey(pol.Namespace, pol.Spec.OIDC.ClientSecret))
+	if secretRef.Error != nil {
+		glog.Warningf("Failed to set the token introspection of OIDC policy %v: client secret: %v", key, secretRef.Error)
+		lbc.oidcIntrospector.Remove(key)
+		return
+	}
+	settings := oidc.Introspection{
+		Endpoint:     introspection.Endpoint,
+		ClientID:     pol.Spec.OIDC.ClientID,
+		ClientSecret: string(secretRef.Secret.Data[secrets.ClientSecretKey]),
+	}
+	// the cache ttl and the max entries are validated in the policy
+	if introspection.CacheTTL != "" {
+		seconds, _ := configs.ParseTimeSeconds(introspection.CacheTTL)
+		settings.CacheTTL = time.Duration(seconds) * time.Second
+	}
+	if introspection.CacheMaxEntries != nil {
+		settings.CacheMaxEntries = *introspection.CacheMaxEntries
+	}
+	lbc.oidcIntrospector.Set(key, settings)
+}
+
+// runOIDCSessionSweeper periodically deletes the entries of the OIDC keyval zones that NGINX can't use anymore,
+// so that the zones don't run out of memory. Every pod sweeps the zones of its NGINX.
+func (lbc *LoadBalancerController) runOIDCSessionSweeper(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(oidcSessionSweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			lbc.sweepOIDCSessions()
+			lbc.updateOIDCSessionMetrics()
+		}
+	}
+}
+
+func (lbc *LoadBalancerController) sweepOIDCSessions() {
+	swept, err := lbc.configurator.SweepOIDCSessions()
+	total := 0
+	for zone, count := range swept {
+		lbc.metricsCollector.AddOIDCSessionEntriesSwept(zone, count)
+		total += count
+	}
+	if err != nil {
+		glog.Warningf("Failed to sweep the OIDC keyval zones: %v", err)
+	}
+	if total > 0 {
+		glog.V(3).Infof("Deleted %v expired entries of the OIDC keyval zones", total)
+	}
+}
+
+// updateOIDCSessionMetrics updates the metrics of the sessions of the OIDC policies and of the OIDC keyval zones,
+// so that the zones can be alerted on before they run out of memory.
+func (lbc *LoadBalancerController) updateOIDCSessionMetrics() {
+	clientIDs := make(map[string]string)
+	for _, pol := range lbc.getAllPolicies() {
+		if pol.Spec.OIDC == nil {
+			continue
+		}
+		if err := lbc.validatePolicy(pol); err != nil {
+			continue
+		}
+		clientIDs[getResourceKey(&pol.ObjectMeta)] = pol.Spec.OIDC.ClientID
+	}
+	usage, utilization, err := lbc.configurator.CountOIDCSessions(clientIDs)
+	if err != nil {
+		glog.Warningf("Failed to count the OIDC sessions: %v", err)
+		return
+	}
+	for key, stats := range usage.Policies {
+		lbc.metricsCollector.SetOIDCSessions(key, stats.Sessions, stats.RefreshTokens, stats.PendingRefreshes)
+	}
+	for key, lockouts := range usage.LoginLockouts {
+		lbc.metricsCollector.SetOIDCLoginLockouts(key, lockouts.FailingClients, lockouts.LockedOutClients)
+	}
+	for zone, entries := range usage.Entries {
+		lbc.metricsCollector.SetOIDCKeyValZone(zone, entries)
+	}
+	for zone, u := range utilization {
+		lbc.metricsCollector.SetOIDCKeyValZoneMemoryUtilization(zone, u)
+	}
+}
+
+// hasOIDCSessionStore checks if the sessions of the OIDC policy are stored in Redis.
+func hasOIDCSessionStore(pol *conf_v1.Policy) bool {
+	return pol.Spec.OIDC != nil && pol.Spec.OIDC.SessionStore != nil && pol.Spec.OIDC.SessionStore.Type == "redis"
+}
+
+// createOIDCSessionStore creates the Redis session store of the OIDC policy. The sessions of every policy
+// are stored under their own key prefix, so that policies can share a server.
+func (lbc *LoadBalancerController) createOIDCSessionStore(pol *conf_v1.Policy) (session.Store, error) {
+	redis := pol.Spec.OIDC.SessionStore.Redis
+	config := session.RedisConfig{
+		Address:   redis.Address,
+		Database:  redis.Database,
+		KeyPrefix: fmt.Sprintf("nginx-oidc:%v/%v:", pol.Namespace, pol.Name),
+		TTL:       oidcSessionTTL,
+	}
+	// Remembered sessions are kept as long as their persistent cookies
+	rememberMe := time.Duration(configs.OIDCRememberMeSeconds(pol.Spec.OIDC.RememberMe)) * time.Second
+	config.TTL = max(config.TTL, rememberMe)
+
+	if redis.AuthSecret != "" {
+		secretRef := lbc.secretStore.GetSecret(fmt.Sprintf("%v/%v", pol.Namespace, redis.AuthSecret))
+		if secretRef.Error != nil {
+			return nil, secretRef.Error
+		}
+		if secretRef.Secret.Type != api_v1.SecretTypeBasicAuth {
+			return nil, fmt.Errorf("auth secret %v must be of the type %v", redis.AuthSecret, api_v1.SecretTypeBasicAuth)
+		}
+		config.Username = string(secretRef.Secret.Data[api_v1.BasicAuthUsernameKey])
+		config.Password = string(secretRef.Secret.Data[api_v1.BasicAuthPasswordKey])
+	}
+
+	if redis.TLS != nil && redis.TLS.Enable {
+		config.TLSConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			ServerName: redis.TLS.ServerName,
+		}
+		if redis.TLS.CASecret != "" {
+			secretRef := lbc.secretStore.GetSecret(fmt.Sprintf("%v/%v", pol.Namespace, redis.TLS.CASecret))
+			if secretRef.Error != nil {
+				return nil, secretRef.Error
+			}
+			if secretRef.Secret.Type != secrets.SecretTypeCA {
+				return nil, fmt.Errorf("CA secret %v must be of the type %v", redis.TLS.CASecret, secrets.SecretTypeCA)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(secretRef.Secret.Data[secrets.CAKey]) {
+				return nil, fmt.Errorf("CA secret %v has no valid certificates", redis.TLS.CASecret)
+			}
+			config.TLSConfig.RootCAs = pool
+		}
+	}
+
+	return session.NewRedisStore(config), nil
+}
+
+// syncOIDCPolicy enqueues the OIDC policy with the key after the Refresher fetched its discovery document.
+func (lbc *LoadBalancerController) syncOIDCPolicy(key string) {
+	ns, _, _ := cache.SplitMetaNamespaceKey(key)
+	nsi := lbc.getNamespacedInformer(ns)
+	if nsi == nil {
+		return
+	}
+	obj, exists, err := nsi.policyLister.GetByKey(key)
+	if err != nil || !exists {
+		return
+	}
+	lbc.AddSyncQueue(obj)
+}
+
+// oidcProvidersConfigMapName returns the name of the ConfigMap where the leader publishes the provider documents.
+func (lbc *LoadBalancerController) oidcProvidersConfigMapName() string {
+	return lbc.leaderElectionLockName + "-oidc-providers"
+}
+
+// oidcProviderDataKeys returns the keys of the discovery document and the JWK Set of the OIDC policy with the key
+// in the ConfigMap of the provider documents.
+func oidcProviderDataKeys(key string) (string, string) {
+	prefix := strings.Replace(key, "/", "_", 1)
+	return prefix + ".discovery.json", prefix + ".jwks.json"
+}
+
+// publishOIDCProviderDocuments stores the provider documents of the OIDC policy with the key in the ConfigMap
+// that the followers watch. Empty documents are removed from the ConfigMap.
+func (lbc *LoadBalancerController) publishOIDCProviderDocuments(key string, docs oidc.Documents) error {
+	discoveryKey, jwksKey := oidcProviderDataKeys(key)
+	setData := func(cm *api_v1.ConfigMap) {
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+		for dataKey, value := range map[string][]byte{discoveryKey: docs.Discovery, jwksKey: docs.JWKS} {
+			if value == nil {
+				delete(cm.Data, dataKey)
+			} else {
+				cm.Data[dataKey] = string(value)
+			}
+		}
+	}
+
+	configMaps := lbc.client.CoreV1().ConfigMaps(lbc.controllerNamespace)
+	isRetriable := func(err error) bool {
+		return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+	}
+	return retry.OnError(retry.DefaultRetry, isRetriable, func() error {
+		cm, err := configMaps.Get(context.TODO(), lbc.oidcProvidersConfigMapName(), meta_v1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			cm = &api_v1.ConfigMap{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      lbc.oidcProvidersConfigMapName(),
+					Namespace: lbc.controllerNamespace,
+				},
+			}
+			setData(cm)
+			_, err = configMaps.Create(context.TODO(), cm, meta_v1.CreateOptions{})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		cm = cm.DeepCopy()
+		setData(cm)
+		_, err = configMaps.Update(context.TODO(), cm, meta_v1.UpdateOptions{})
+		return err
+	})
+}
+
+// applyOIDCProviderDocuments applies the provider documents that the leader published for the OIDC policy with the key,
+// or for all OIDC policies if the key is empty.
+func (lbc *LoadBalancerController) applyOIDCProviderDocuments(key string) {
+	if lbc.oidcProvidersLister == nil {
+		return
+	}
+	obj, exists, err := lbc.oidcProvidersLister.GetByKey(lbc.controllerNamespace + "/" + lbc.oidcProvidersConfigMapName())
+	if err != nil || !exists {
+		return
+	}
+	cm := obj.(*api_v1.ConfigMap)
+
+	docs := make(map[string]oidc.Documents)
+	for dataKey, value := range cm.Data {
+		if prefix, found := strings.CutSuffix(dataKey, ".discovery.json"); found {
+			polKey := strings.Replace(prefix, "_", "/", 1)
+			d := docs[polKey]
+			d.Discovery = []byte(value)
+			docs[polKey] = d
+		} else if prefix, found := strings.CutSuffix(dataKey, ".jwks.json"); found {
+			polKey := strings.Replace(prefix, "_", "/", 1)
+			d := docs[polKey]
+			d.JWKS = []byte(value)
+			docs[polKey] = d
+		}
+	}
+	for polKey, d := range docs {
+		if key == "" || polKey == key {
+			lbc.oidcRefresher.Apply(polKey, d)
+		}
+	}
+}
+
+// unpublishOIDCProviderDocuments removes the provider documents of the OIDC policy with the key from the ConfigMap,
+// if the controller is the leader and the documents were published.
+func (lbc *LoadBalancerController) unpublishOIDCProviderDocuments(key string) {
+	if lbc.oidcProvidersLister == nil || !lbc.leaderElector.IsLeader() {
+		return
+	}
+	obj, exists, err := lbc.oidcProvidersLister.GetByKey(lbc.controllerNamespace + "/" + lbc.oidcProvidersConfigMapName())
+	if err != nil || !exists {
+		return
+	}
+	discoveryKey, jwksKey := oidcProviderDataKeys(key)
+	data := obj.(*api_v1.ConfigMap).Data
+	_, hasDiscovery := data[discoveryKey]
+	_, hasJWKS := data[jwksKey]
+	if !hasDiscovery && !hasJWKS {
+		return
+	}
+	if err := lbc.publishOIDCProviderDocuments(key, oidc.Documents{}); err != nil {
+		glog.Warningf("Failed to remove the provider documents of OIDC policy %v: %v", key, err)
+	}
+}
+
+// reportOIDCProviderError emits a warning event on the OIDC policy with the key after the Refresher failed to
+// refresh its provider metadata or to reach its token endpoint.
+func (lbc *LoadBalancerController) reportOIDCProviderError(key string, err error) {
+	ns, _, _ := cache.SplitMetaNamespaceKey(key)
+	nsi := lbc.getNamespacedInformer(ns)
+	if nsi == nil {
+		return
+	}
+	obj, exists, getErr := nsi.policyLister.GetByKey(key)
+	if getErr != nil || !exists {
+		return
+	}
+	lbc.recorder.Eventf(obj.(*conf_v1.Policy), api_v1.EventTypeWarning, "ProviderError", "OIDC provider of Policy %v failed: %v", key, err)
+}
+
+// updateOIDCHealthCheck probes the token endpoint and the JWKS URI of the OIDC policy. The endpoints that the policy
+// doesn't configure come from the discovery document of the provider, once it is fetched.
+func (lbc *LoadBalancerController) updateOIDCHealthCheck(key string, pol *conf_v1.Policy) {
+	tokenEndpoint := pol.Spec.OIDC.TokenEndpoint
+	jwksURI := pol.Spec.OIDC.JWKSURI
+	if metadata, exists := lbc.oidcRefresher.Metadata(key); exists {
+		if tokenEndpoint == "" {
+			tokenEndpoint = metadata.TokenEndpoint
+		}
+		if jwksURI == "" {
+			jwksURI = metadata.JwksURI
+		}
+	}
+	lbc.oidcHealthChecker.Update(key, tokenEndpoint, jwksURI)
+}
+
+// updateOIDCProxy sets the forward proxy and the headers of the requests to the provider of the OIDC policy, or
+// removes them when the policy has no httpProxy and no requestHeaders.
+func (lbc *LoadBalancerController) updateOIDCProxy(key string, pol *conf_v1.Policy) {
+	if pol.Spec.OIDC.HTTPProxy == "" && len(pol.Spec.OIDC.RequestHeaders) == 0 {
+		lbc.oidcProxies.Remove(key)
+		return
+	}
+	headers := make(http.Header)
+	for _, h := range pol.Spec.OIDC.RequestHeaders {
+		headers.Set(h.Name, h.Value)
+	}
+	// the proxy is validated in the policy
+	err := lbc.oidcProxies.Set(key, oidc.Egress{HTTPProxy: pol.Spec.OIDC.HTTPProxy, NoProxy: pol.Spec.OIDC.NoProxy, Headers: headers})
+	if err != nil {
+		glog.Warningf("Failed to set the forward proxy of OIDC policy %v: %v", key, err)
+	}
+}
+
+// reportOIDCProviderHealth reports the reachability of the provider of the OIDC policy with the key
+// in the metrics, in the status of the policy and, when the provider is unreachable, with a warning event.
+func (lbc *LoadBalancerController) reportOIDCProviderHealth(key string, h oidc.Health) {
+	lbc.metricsCollector.SetOIDCProviderReachable(key, "token", h.TokenEndpointReachable)
+	lbc.metricsCollector.SetOIDCProviderReachable(key, "jwks", h.JWKSReachable)
+
+	ns, _, _ := cache.SplitMetaNamespaceKey(key)
+	nsi := lbc.getNamespacedInformer(ns)
+	if nsi == nil {
+		return
+	}
+	obj, exists, err := nsi.policyLister.GetByKey(key)
+	if err != nil || !exists {
+		return
+	}
+	pol := obj.(*conf_v1.Policy)
+
+	if !h.Reachable() {
+		lbc.recorder.Eventf(pol, api_v1.EventTypeWarning, "ProviderUnreachable", "OIDC provider of Policy %v is unreachable: %v", key, h.Message)
+	}
+
+	if lbc.reportCustomResourceStatusEnabled() {
+		idp := &conf_v1.PolicyIdPStatus{
+			TokenEndpointReachable: h.TokenEndpointReachable,
+			JWKSReachable:          h.JWKSReachable,
+			Message:                h.Message,
+			LastTransitionTime:     meta_v1.Now(),
+		}
+		if err := lbc.statusUpdater.UpdatePolicyIdPStatus(pol, idp); err != nil {
+			glog.V(3).Infof("Failed to update the provider status of policy %s: %v", key, err)
+		}
+	}
+}
+
+// notifyOIDCKeyRotation notifies the webhook of the OIDC anomalies that the provider of the policy with the key
+// rotated its keys.
+func (lbc *LoadBalancerController) notifyOIDCKeyRotation(key string) {
+	lbc.oidcWebhook.Notify(webhook.Notification{
+		Type:    webhook.JWKSRotation,
+		Policy:  key,
+		Message: "the provider rotated the keys of its JWK Set",
+	})
+}
+
+// reportOIDCSecretErrors emits a warning event on the OIDC policy for every referenced Secret that doesn't exist or is invalid.
+func (lbc *LoadBalancerController) reportOIDCSecretErrors(pol *conf_v1.Policy) {
+	for _, secretKey := range oidcPolicySecretKeys(pol) {
+		secretRef := lbc.secretStore.GetSecret(secretKey)
+		if secretRef.Error != nil {
+			lbc.recorder.Eventf(pol, api_v1.EventTypeWarning, "SecretInvalid", "Secret %v of Policy %v/%v is invalid: %v", secretKey, pol.Namespace, pol.Name, secretRef.Error)
+		} else if err := secrets.ValidateReferenceGrant(secretRef.Secret, pol.Namespace); err != nil {
+			lbc.recorder.Eventf(pol, api_v1.EventTypeWarning, "SecretNotAllowed", "Secret %v of Policy %v/%v can't be referenced: %v", secretKey, pol.Namespace, pol.Name, err)
+		}
+	}
 }
 
 func (lbc *LoadBalancerController) syncTransportServer(task task) {
@@ -2642,10 +3423,12 @@ func (lbc *LoadBalancerController) syncSecret(task task) {
 
 	resources := lbc.configuration.FindResourcesForSecret(namespace, name)
 
+	var secretPols []*conf_v1.Policy
 	if lbc.areCustomResourcesEnabled {
-		secretPols := lbc.getPoliciesForSecret(namespace, name)
+		secretPols = lbc.getPoliciesForSecret(namespace, name)
 		for _, pol := range secretPols {
 			resources = append(resources, lbc.configuration.FindResourcesForPolicy(pol.Namespace, pol.Name)...)
+			resources = append(resources, lbc.findVirtualServersSelectedByPolicy(pol.Namespace+"/"+pol.Name)...)
 		}
 
 		resources = removeDuplicateResources(resources)
@@ -2655,9 +3438,15 @@ func (lbc *LoadBalancerController) syncSecret(task task) {
 
 	if !secrExists {
 		lbc.secretStore.DeleteSecret(key)
+		delete(lbc.oidcPreviousSecrets, key)
 
 		glog.V(2).Infof("Deleting Secret: %v\n", key)
 
+		for _, pol := range secretPols {
+			lbc.reportOIDCSecretErrors(pol)
+		}
+		lbc.refreshOIDCSessionStores(secretPols)
+		lbc.refreshOIDCIntrospection(secretPols)
 		if len(resources) > 0 {
 			lbc.handleRegularSecretDeletion(resources)
 		}
@@ -2671,7 +3460,13 @@ func (lbc *LoadBalancerController) syncSecret(task task) {
 
 	secret := obj.(*api_v1.Secret)
 
+	lbc.rememberRotatedOIDCSecret(key, secret)
 	lbc.secretStore.AddOrUpdateSecret(secret)
+	for _, pol := range secretPols {
+		lbc.reportOIDCSecretErrors(pol)
+	}
+	lbc.refreshOIDCSessionStores(secretPols)
+	lbc.refreshOIDCIntrospection(secretPols)
 
 	if lbc.isSpecialSecret(key) {
 		lbc.handleSpecialSecretUpdate(secret)
@@ -2683,6 +3478,28 @@ func (lbc *LoadBalancerController) syncSecret(task task) {
 	}
 }
 
+// rememberRotatedOIDCSecret keeps the previous version of a rotated OIDC client secret for the lifetime of the
+// login state, so that the logins started before the rotation are not rejected.
+func (lbc *LoadBalancerController) rememberRotatedOIDCSecret(key string, secret *api_v1.Secret) {
+	if lbc.oidcPreviousSecrets == nil || secret.Type != secrets.SecretTypeOIDC {
+		return
+	}
+
+	secretRef, exists := lbc.secretStore.GetSecretReferenceMap()[key]
+	if !exists || secretRef.Error != nil || reflect.DeepEqual(secretRef.Secret.Data, secret.Data) {
+		return
+	}
+
+	lbc.oidcPreviousSecrets[key] = previousSecret{
+		secret: secretRef.Secret,
+		expiry: time.Now().Add(oidcStateLifetime),
+	}
+	// regenerate the configuration without the previous version once it expired
+	time.AfterFunc(oidcStateLifetime, func() {
+		lbc.AddSyncQueue(secret)
+	})
+}
+
 func removeDuplicateResources(resources []Resource) []Resource {
 	encountered := make(map[string]bool)
 	var uniqueResources []Resource
@@ -2717,7 +3534,9 @@ func (lbc *LoadBalancerController) handleSecretUpdate(secret *api_v1.Secret, res
 
 	resourceExes := lbc.createExtendedResources(resources)
 
-	warnings, addOrUpdateErr = lbc.configurator.AddOrUpdateResources(resourceExes, !lbc.configurator.DynamicSSLReloadEnabled())
+	// JWKs are loaded by auth_jwt_key_file when NGINX reloads, unlike the certificates of the dynamic SSL reload
+	reloadIfUnchanged := !lbc.configurator.DynamicSSLReloadEnabled() || secret.Type == secrets.SecretTypeJWK
+	warnings, addOrUpdateErr = lbc.configurator.AddOrUpdateResources(resourceExes, reloadIfUnchanged)
 	if addOrUpdateErr != nil {
 		glog.Errorf("Error when updating Secret %v: %v", secretNsName, addOrUpdateErr)
 		lbc.recorder.Eventf(secret, api_v1.EventTypeWarning, "UpdatedWithError", "%v was updated, but not applied: %v", secretNsName, addOrUpdateErr)
@@ -2860,7 +3679,7 @@ func (lbc *LoadBalancerController) updatePoliciesStatus() error {
 		for _, obj := range nsi.policyLister.List() {
 			pol := obj.(*conf_v1.Policy)
 
-			err := validation.ValidatePolicy(pol, lbc.isNginxPlus, lbc.enableOIDC, lbc.appProtectEnabled)
+			err := lbc.validatePolicy(pol)
 			if err != nil {
 				msg := fmt.Sprintf("Policy %v/%v is invalid and was rejected: %v", pol.Namespace, pol.Name, err)
 				err = lbc.statusUpdater.UpdatePolicyStatus(pol, conf_v1.StateInvalid, "Rejected", msg)
@@ -3248,6 +4067,10 @@ func (lbc *LoadBalancerController) createVirtualServerEx(virtualServer *conf_v1.
 	if err != nil {
 		glog.Warningf("Error getting APIKey secrets for VirtualServer %v/%v: %v", virtualServer.Namespace, virtualServer.Name, err)
 	}
+	err = lbc.addClientCredentialsSecretRefs(virtualServerEx.SecretRefs, policies)
+	if err != nil {
+		glog.Warningf("Error getting Client Credentials secrets for VirtualServer %v/%v: %v", virtualServer.Namespace, virtualServer.Name, err)
+	}
 
 	err = lbc.addWAFPolicyRefs(virtualServerEx.ApPolRefs, virtualServerEx.LogConfRefs, policies)
 	if err != nil {
@@ -3376,6 +4199,10 @@ func (lbc *LoadBalancerController) createVirtualServerEx(virtualServer *conf_v1.
 		if err != nil {
 			glog.Warningf("Error getting APIKey secrets for VirtualServer %v/%v: %v", virtualServer.Namespace, virtualServer.Name, err)
 		}
+		err = lbc.addClientCredentialsSecretRefs(virtualServerEx.SecretRefs, vsRoutePolicies)
+		if err != nil {
+			glog.Warningf("Error getting Client Credentials secrets for VirtualServer %v/%v: %v", virtualServer.Namespace, virtualServer.Name, err)
+		}
 
 	}
 
@@ -3411,6 +4238,10 @@ func (lbc *LoadBalancerController) createVirtualServerEx(virtualServer *conf_v1.
 			if err != nil {
 				glog.Warningf("Error getting APIKey secrets for VirtualServerRoute %v/%v: %v", vsr.Namespace, vsr.Name, err)
 			}
+			err = lbc.addClientCredentialsSecretRefs(virtualServerEx.SecretRefs, vsrSubroutePolicies)
+			if err != nil {
+				glog.Warningf("Error getting Client Credentials secrets for VirtualServerRoute %v/%v: %v", vsr.Namespace, vsr.Name, err)
+			}
 
 			err = lbc.addWAFPolicyRefs(virtualServerEx.ApPolRefs, virtualServerEx.LogConfRefs, vsrSubroutePolicies)
 			if err != nil {
@@ -3472,10 +4303,22 @@ func (lbc *LoadBalancerController) createVirtualServerEx(virtualServer *conf_v1.
 		}
 	}
 
+	if implicitPol := lbc.getImplicitOIDCPolicy(virtualServer, policies); implicitPol != nil {
+		virtualServerEx.SelectedPolicies = []conf_v1.PolicyReference{{Name: implicitPol.Name, Namespace: implicitPol.Namespace}}
+		policies = append(policies, implicitPol)
+
+		err := lbc.addOIDCSecretRefs(virtualServerEx.SecretRefs, []*conf_v1.Policy{implicitPol})
+		if err != nil {
+			glog.Warningf("Error getting OIDC secrets for VirtualServer %v/%v: %v", virtualServer.Namespace, virtualServer.Name, err)
+		}
+	}
+
 	virtualServerEx.Endpoints = endpoints
 	virtualServerEx.VirtualServerRoutes = virtualServerRoutes
 	virtualServerEx.ExternalNameSvcs = externalNameSvcs
 	virtualServerEx.Policies = createPolicyMap(policies)
+	virtualServerEx.ConfigMapRefs = lbc.getOIDCConfigMapRefs(policies)
+	virtualServerEx.OIDCProviders = lbc.getOIDCProviders(policies)
 	virtualServerEx.PodsByIP = podsByIP
 
 	return &virtualServerEx
@@ -3492,14 +4335,29 @@ func createPolicyMap(policies []*conf_v1.Policy) map[string]*conf_v1.Policy {
 	return result
 }
 
+// validatePolicy validates the policy, and in the FIPS mode of OIDC that an OIDC policy only uses the FIPS 140-3
+// approved algorithms.
+func (lbc *LoadBalancerController) validatePolicy(pol *conf_v1.Policy) error {
+	if err := validation.ValidatePolicy(pol, lbc.isNginxPlus, lbc.enableOIDC, lbc.appProtectEnabled); err != nil {
+		return err
+	}
+	if lbc.oidcFIPSMode {
+		return validation.ValidatePolicyFIPS(pol)
+	}
+	return nil
+}
+
 func (lbc *LoadBalancerController) getAllPolicies() []*conf_v1.Policy {
 	var policies []*conf_v1.Policy
 
 	for _, nsi := range lbc.namespacedInformers {
 		for _, obj := range nsi.policyLister.List() {
 			pol := obj.(*conf_v1.Policy)
+			if pol.DeletionTimestamp != nil {
+				continue
+			}
 
-			err := validation.ValidatePolicy(pol, lbc.isNginxPlus, lbc.enableOIDC, lbc.appProtectEnabled)
+			err := lbc.validatePolicy(pol)
 			if err != nil {
 				glog.V(3).Infof("Skipping invalid Policy %s/%s: %v", pol.Namespace, pol.Name, err)
 				continue
@@ -3541,12 +4399,17 @@ func (lbc *LoadBalancerController) getPolicies(policies []conf_v1.PolicyReferenc
 
 		policy := policyObj.(*conf_v1.Policy)
 
+		if policy.DeletionTimestamp != nil {
+			errors = append(errors, fmt.Errorf("policy %s is being deleted", policyKey))
+			continue
+		}
+
 		if !lbc.HasCorrectIngressClass(policy) {
 			errors = append(errors, fmt.Errorf("referenced policy %s has incorrect ingress class: %s (controller ingress class: %s)", policyKey, policy.Spec.IngressClass, lbc.ingressClass))
 			continue
 		}
 
-		err = validation.ValidatePolicy(policy, lbc.isNginxPlus, lbc.enableOIDC, lbc.appProtectEnabled)
+		err = lbc.validatePolicy(policy)
 		if err != nil {
 			errors = append(errors, fmt.Errorf("policy %s is invalid: %w", policyKey, err))
 			continue
@@ -3648,12 +4511,146 @@ func (lbc *LoadBalancerController) addEgressMTLSSecretRefs(secretRefs map[string
 }
 
 func (lbc *LoadBalancerController) addOIDCSecretRefs(secretRefs map[string]*secrets.SecretReference, policies []*conf_v1.Policy) error {
+	for _, pol := range policies {
+		for _, secretKey := range oidcPolicySecretKeys(pol) {
+			secretRef := lbc.secretStore.GetSecret(secretKey)
+
+			secretRefs[secretKey] = secretRef
+
+			if secretRef.Error != nil {
+				return secretRef.Error
+			}
+		}
+		// the Ingress Controller creates the Secret of the managed keys after the policy, so the policy waits for it
+		// instead of failing
+		if name := oidcManagedKeysSecret(pol); name != "" {
+			secretKey := fmt.Sprintf("%v/%v", pol.Namespace, name)
+			secretRefs[secretKey] = lbc.secretStore.GetSecret(secretKey)
+		}
+	}
+	return nil
+}
+
+// oidcPolicySecretKeys returns the keys of the Secrets referenced by the OIDC policy: the client secrets of
+// the policy and of its additional IdPs, which can be in another namespace, the JAR key secret when JAR is
+// enabled, the JWE key secret and the secrets of the session store.
+func oidcPolicySecretKeys(pol *conf_v1.Policy) []string {
+	if pol.Spec.OIDC == nil {
+		return nil
+	}
+
+	secretKeys := []string{secrets.GetReferenceKey(pol.Namespace, pol.Spec.OIDC.ClientSecret)}
+	secretKeys = append(secretKeys, oidcIdPSecretKeys(pol)...)
+	if pol.Spec.OIDC.JAREnable {
+		secretKeys = append(secretKeys, fmt.Sprintf("%v/%v", pol.Namespace, pol.Spec.OIDC.JARKeySecret))
+	}
+	if pol.Spec.OIDC.JWEKeySecret != "" {
+		secretKeys = append(secretKeys, fmt.Sprintf("%v/%v", pol.Namespace, pol.Spec.OIDC.JWEKeySecret))
+	}
+	for _, name := range oidcSessionStoreSecrets(pol) {
+		secretKeys = append(secretKeys, fmt.Sprintf("%v/%v", pol.Namespace, name))
+	}
+	return secretKeys
+}
+
+// oidcIdPSecretKeys returns the keys of the client secrets of the additional IdPs of the OIDC policy.
+func oidcIdPSecretKeys(pol *conf_v1.Policy) []string {
+	var secretKeys []string
+	for _, idp := range pol.Spec.OIDC.IdPs {
+		secretKeys = append(secretKeys, secrets.GetReferenceKey(pol.Namespace, idp.ClientSecret))
+	}
+	return secretKeys
+}
+
+// oidcSessionStoreSecrets returns the names of the secrets of the session store of the policy: the auth secret
+// and the CA secret of a Redis session store, or the key secret of a cookie session store.
+func oidcSessionStoreSecrets(pol *conf_v1.Policy) []string {
+	if pol.Spec.OIDC == nil || pol.Spec.OIDC.SessionStore == nil {
+		return nil
+	}
+	store := pol.Spec.OIDC.SessionStore
+	var names []string
+	switch {
+	case store.Type == "redis" && store.Redis != nil:
+		if store.Redis.AuthSecret != "" {
+			names = append(names, store.Redis.AuthSecret)
+		}
+		if store.Redis.TLS != nil && store.Redis.TLS.Enable && store.Redis.TLS.CASecret != "" {
+			names = append(names, store.Redis.TLS.CASecret)
+		}
+	case store.Type == "cookie" && store.Cookie != nil && store.Cookie.KeySecret != "":
+		names = append(names, store.Cookie.KeySecret)
+	}
+	return names
+}
+
+// getOIDCProviders returns the state of the OIDC policies kept by the Ingress Controller.
+func (lbc *LoadBalancerController) getOIDCProviders(policies []*conf_v1.Policy) map[string]*configs.OIDCProvider {
+	providers := make(map[string]*configs.OIDCProvider)
+	if lbc.oidcRefresher == nil {
+		return providers
+	}
+
 	for _, pol := range policies {
 		if pol.Spec.OIDC == nil {
 			continue
 		}
 
-		secretKey := fmt.Sprintf("%v/%v", pol.Namespace, pol.Spec.OIDC.ClientSecret)
+		polKey := fmt.Sprintf("%v/%v", pol.Namespace, pol.Name)
+		provider := &configs.OIDCProvider{
+			JwksFile: lbc.oidcRefresher.JWKSFile(polKey),
+		}
+		if metadata, exists := lbc.oidcRefresher.Metadata(polKey); exists {
+			provider.JwksURI = metadata.JwksURI
+			provider.RevocationEndpoint = metadata.RevocationEndpoint
+			provider.EndSessionEndpoint = metadata.EndSessionEndpoint
+			provider.UserinfoEndpoint = metadata.UserinfoEndpoint
+		}
+		secretKey := secrets.GetReferenceKey(pol.Namespace, pol.Spec.OIDC.ClientSecret)
+		if previous, exists := lbc.oidcPreviousSecrets[secretKey]; exists && time.Now().Before(previous.expiry) {
+			provider.PreviousSecret = previous.secret
+		}
+		providers[polKey] = provider
+	}
+
+	return providers
+}
+
+// getOIDCConfigMapRefs returns the error pages ConfigMaps of the OIDC policies that exist.
+func (lbc *LoadBalancerController) getOIDCConfigMapRefs(policies []*conf_v1.Policy) map[string]*api_v1.ConfigMap {
+	configMapRefs := make(map[string]*api_v1.ConfigMap)
+
+	for _, pol := range policies {
+		if pol.Spec.OIDC == nil || pol.Spec.OIDC.ErrorPages == "" {
+			continue
+		}
+
+		nsi := lbc.getNamespacedInformer(pol.Namespace)
+		if nsi == nil || nsi.configMapLister == nil {
+			continue
+		}
+
+		configMapKey := fmt.Sprintf("%v/%v", pol.Namespace, pol.Spec.OIDC.ErrorPages)
+		obj, exists, err := nsi.configMapLister.GetByKey(configMapKey)
+		if err != nil {
+			glog.Warningf("Error getting ConfigMap %v of Policy %v/%v: %v", configMapKey, pol.Namespace, pol.Name, err)
+			continue
+		}
+		if exists {
+			configMapRefs[configMapKey] = obj.(*api_v1.ConfigMap)
+		}
+	}
+
+	return configMapRefs
+}
+
+func (lbc *LoadBalancerController) addClientCredentialsSecretRefs(secretRefs map[string]*secrets.SecretReference, policies []*conf_v1.Policy) error {
+	for _, pol := range policies {
+		if pol.Spec.ClientCredentials == nil {
+			continue
+		}
+
+		secretKey := fmt.Sprintf("%v/%v", pol.Namespace, pol.Spec.ClientCredentials.ClientSecret)
 		secretRef := lbc.secretStore.GetSecret(secretKey)
 
 		secretRefs[secretKey] = secretRef
@@ -3760,10 +4757,38 @@ func findPoliciesForSecret(policies []*conf_v1.Policy, secretNamespace string, s
 			res = append(res, pol)
 		} else if pol.Spec.EgressMTLS != nil && pol.Spec.EgressMTLS.TrustedCertSecret == secretName && pol.Namespace == secretNamespace {
 			res = append(res, pol)
-		} else if pol.Spec.OIDC != nil && pol.Spec.OIDC.ClientSecret == secretName && pol.Namespace == secretNamespace {
+		} else if pol.Spec.OIDC != nil && secrets.GetReferenceKey(pol.Namespace, pol.Spec.OIDC.ClientSecret) == secretNamespace+"/"+secretName {
+			res = append(res, pol)
+		} else if pol.Spec.OIDC != nil && slices.Contains(oidcIdPSecretKeys(pol), secretNamespace+"/"+secretName) {
+			res = append(res, pol)
+		} else if pol.Spec.OIDC != nil && pol.Spec.OIDC.JAREnable && pol.Spec.OIDC.JARKeySecret == secretName && pol.Namespace == secretNamespace {
+			res = append(res, pol)
+		} else if pol.Spec.OIDC != nil && pol.Spec.OIDC.JWEKeySecret != "" && pol.Spec.OIDC.JWEKeySecret == secretName && pol.Namespace == secretNamespace {
+			res = append(res, pol)
+		} else if pol.Namespace == secretNamespace && slices.Contains(oidcSessionStoreSecrets(pol), secretName) {
+			res = append(res, pol)
+		} else if pol.Namespace == secretNamespace && oidcManagedKeysSecret(pol) == secretName && secretName != "" {
 			res = append(res, pol)
 		} else if pol.Spec.APIKey != nil && pol.Spec.APIKey.ClientSecret == secretName && pol.Namespace == secretNamespace {
 			res = append(res, pol)
+		} else if pol.Spec.ClientCredentials != nil && pol.Spec.ClientCredentials.ClientSecret == secretName && pol.Namespace == secretNamespace {
+			res = append(res, pol)
+		}
+	}
+
+	return res
+}
+
+func (lbc *LoadBalancerController) getPoliciesForConfigMap(configMapNamespace string, configMapName string) []*conf_v1.Policy {
+	return findPoliciesForConfigMap(lbc.getAllPolicies(), configMapNamespace, configMapName)
+}
+
+func findPoliciesForConfigMap(policies []*conf_v1.Policy, configMapNamespace string, configMapName string) []*conf_v1.Policy {
+	var res []*conf_v1.Policy
+
+	for _, pol := range policies {
+		if pol.Spec.OIDC != nil && pol.Spec.OIDC.ErrorPages == configMapName && pol.Namespace == configMapNamespace {
+			res = append(res, pol)
 		}
 	}
 
@@ -4493,6 +5518,15 @@ func (lbc *LoadBalancerController) IsNginxReady() bool {
 	return lbc.isNginxReady
 }
 
+// AreOIDCProvidersReady returns false if the readiness depends on the OIDC providers and the providers
+// of all the OIDC policies are unreachable.
+func (lbc *LoadBalancerController) AreOIDCProvidersReady() bool {
+	if !lbc.oidcProviderReadiness || lbc.oidcHealthChecker == nil {
+		return true
+	}
+	return !lbc.oidcHealthChecker.AllUnreachable()
+}
+
 func (lbc *LoadBalancerController) addInternalRouteServer() {
 	if lbc.internalRoutesEnabled {
 		if err := lbc.configurator.AddInternalRouteConfig(); err != nil {
@@ -1,8 +1,11 @@
 package k8s
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"os"
 	"reflect"
 	"sort"
 	"strings"
@@ -21,14 +24,18 @@ import (
 	"github.com/nginxinc/kubernetes-ingress/internal/k8s/secrets"
 	"github.com/nginxinc/kubernetes-ingress/internal/metrics/collectors"
 	"github.com/nginxinc/kubernetes-ingress/internal/nginx"
+	"github.com/nginxinc/kubernetes-ingress/internal/oidc"
 	conf_v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1"
+	fake_v1 "github.com/nginxinc/kubernetes-ingress/pkg/client/clientset/versioned/fake"
 	api_v1 "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 )
 
 func TestHasCorrectIngressClass(t *testing.T) {
@@ -2040,6 +2047,19 @@ func TestGetPolicies(t *testing.T) {
 		Spec: conf_v1.PolicySpec{},
 	}
 
+	deletedPolicy := &conf_v1.Policy{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:              "deleted-policy",
+			Namespace:         "default",
+			DeletionTimestamp: &meta_v1.Time{Time: time.Now()},
+		},
+		Spec: conf_v1.PolicySpec{
+			AccessControl: &conf_v1.AccessControl{
+				Allow: []string{"127.0.0.1"},
+			},
+		},
+	}
+
 	policyLister := &cache.FakeCustomStore{
 		GetByKeyFunc: func(key string) (item interface{}, exists bool, err error) {
 			switch key {
@@ -2049,6 +2069,8 @@ func TestGetPolicies(t *testing.T) {
 				return validPolicyIngressClass, true, nil
 			case "default/invalid-policy":
 				return invalidPolicy, true, nil
+			case "default/deleted-policy":
+				return deletedPolicy, true, nil
 			case "nginx-ingress/valid-policy":
 				return nil, false, nil
 			default:
@@ -2086,14 +2108,19 @@ func TestGetPolicies(t *testing.T) {
 			Name:      "valid-policy-ingress-class",
 			Namespace: "default",
 		},
+		{
+			Name:      "deleted-policy",
+			Namespace: "default",
+		},
 	}
 
 	expectedPolicies := []*conf_v1.Policy{validPolicy}
 	expectedErrors := []error{
-		errors.New("policy default/invalid-policy is invalid: spec: Invalid value: \"\": must specify exactly one of: `accessControl`, `rateLimit`, `ingressMTLS`, `egressMTLS`, `basicAuth`, `apiKey`, `jwt`, `oidc`, `waf`"),
+		errors.New("policy default/invalid-policy is invalid: spec: Invalid value: \"\": must specify exactly one of: `accessControl`, `rateLimit`, `ingressMTLS`, `egressMTLS`, `basicAuth`, `apiKey`, `jwt`, `oidc`, `waf`, `clientCredentials`"),
 		errors.New("policy nginx-ingress/valid-policy doesn't exist"),
 		errors.New("failed to get policy nginx-ingress/some-policy: GetByKey error"),
 		errors.New("referenced policy default/valid-policy-ingress-class has incorrect ingress class: test-class (controller ingress class: )"),
+		errors.New("policy default/deleted-policy is being deleted"),
 	}
 
 	result, errors := lbc.getPolicies(policyRefs, "default")
@@ -2105,6 +2132,133 @@ func TestGetPolicies(t *testing.T) {
 	}
 }
 
+func TestGetImplicitOIDCPolicy(t *testing.T) {
+	t.Parallel()
+	newOIDCPolicy := func(namespace, name string) *conf_v1.Policy {
+		return &conf_v1.Policy{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Spec: conf_v1.PolicySpec{
+				OIDC: &conf_v1.OIDC{
+					AuthEndpoint:  "https://idp.example.com/auth",
+					TokenEndpoint: "https://idp.example.com/token",
+					JWKSURI:       "https://idp.example.com/certs",
+					ClientID:      "client",
+					ClientSecret:  "oidc-secret",
+					VirtualServerSelector: &meta_v1.LabelSelector{
+						MatchLabels: map[string]string{"sso": "required"},
+					},
+				},
+			},
+		}
+	}
+	ssoPolicy := newOIDCPolicy("platform", "sso-policy")
+	otherSSOPolicy := newOIDCPolicy("platform", "z-sso-policy")
+	defaultPolicy := newOIDCPolicy("platform", "default-policy")
+	defaultPolicy.Spec.OIDC.VirtualServerSelector = nil
+
+	policyLister := &cache.FakeCustomStore{
+		GetByKeyFunc: func(key string) (item interface{}, exists bool, err error) {
+			switch key {
+			case "platform/sso-policy":
+				return ssoPolicy, true, nil
+			case "platform/z-sso-policy":
+				return otherSSOPolicy, true, nil
+			case "platform/default-policy":
+				return defaultPolicy, true, nil
+			default:
+				return nil, false, nil
+			}
+		},
+	}
+	nsi := make(map[string]*namespacedInformer)
+	nsi[""] = &namespacedInformer{policyLister: policyLister}
+
+	lbc := LoadBalancerController{
+		isNginxPlus:         true,
+		enableOIDC:          true,
+		namespacedInformers: nsi,
+		oidcPolicySelectors: make(map[string]labels.Selector),
+		defaultOIDCPolicy:   "platform/default-policy",
+		configuration:       createTestConfiguration(),
+	}
+	lbc.updateOIDCPolicySelector("platform/z-sso-policy", otherSSOPolicy)
+	lbc.updateOIDCPolicySelector("platform/sso-policy", ssoPolicy)
+
+	selectedVS := &conf_v1.VirtualServer{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "cafe",
+			Namespace: "default",
+			Labels:    map[string]string{"sso": "required"},
+		},
+	}
+	otherVS := &conf_v1.VirtualServer{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "tea",
+			Namespace: "default",
+			Labels:    map[string]string{"sso": "optional"},
+		},
+	}
+	optOutVS := &conf_v1.VirtualServer{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "coffee",
+			Namespace:   "default",
+			Annotations: map[string]string{"nginx.org/disable-default-oidc-policy": "true"},
+		},
+	}
+	referencedOIDCPolicy := newOIDCPolicy("default", "oidc-policy")
+	referencedOIDCPolicy.Spec.OIDC.VirtualServerSelector = nil
+
+	tests := []struct {
+		vs       *conf_v1.VirtualServer
+		policies []*conf_v1.Policy
+		expected *conf_v1.Policy
+		msg      string
+	}{
+		{
+			vs:       selectedVS,
+			expected: ssoPolicy,
+			msg:      "selected by two policies",
+		},
+		{
+			vs:       otherVS,
+			expected: defaultPolicy,
+			msg:      "not selected",
+		},
+		{
+			vs:       optOutVS,
+			expected: nil,
+			msg:      "not selected and opted out of the default policy",
+		},
+		{
+			vs:       selectedVS,
+			policies: []*conf_v1.Policy{referencedOIDCPolicy},
+			expected: nil,
+			msg:      "references an OIDC policy",
+		},
+	}
+
+	for _, test := range tests {
+		result := lbc.getImplicitOIDCPolicy(test.vs, test.policies)
+		if result != test.expected {
+			t.Errorf("getImplicitOIDCPolicy() returned %v but expected %v for the case of %s", result, test.expected, test.msg)
+		}
+	}
+
+	lbc.updateOIDCPolicySelector("platform/sso-policy", nil)
+	lbc.updateOIDCPolicySelector("platform/z-sso-policy", nil)
+	if result := lbc.getImplicitOIDCPolicy(selectedVS, nil); result != defaultPolicy {
+		t.Errorf("getImplicitOIDCPolicy() returned %v but expected the default policy after the selectors were removed", result)
+	}
+
+	defaultPolicy.Spec.OIDC.VirtualServerSelector = &meta_v1.LabelSelector{}
+	if result := lbc.getImplicitOIDCPolicy(selectedVS, nil); result != nil {
+		t.Errorf("getImplicitOIDCPolicy() returned %v for a default policy with a virtualServerSelector", result)
+	}
+}
+
 func TestCreatePolicyMap(t *testing.T) {
 	t.Parallel()
 	policies := []*conf_v1.Policy{
@@ -2438,6 +2592,89 @@ func TestFindPoliciesForSecret(t *testing.T) {
 			},
 		},
 	}
+	clientCredentialsPol := &conf_v1.Policy{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "client-credentials-policy",
+			Namespace: "default",
+		},
+		Spec: conf_v1.PolicySpec{
+			ClientCredentials: &conf_v1.ClientCredentials{
+				ClientSecret: "client-credentials-secret",
+			},
+		},
+	}
+	oidcJARPol := &conf_v1.Policy{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "oidc-jar-policy",
+			Namespace: "default",
+		},
+		Spec: conf_v1.PolicySpec{
+			OIDC: &conf_v1.OIDC{
+				ClientSecret: "oidc-secret",
+				JAREnable:    true,
+				JARKeySecret: "jar-key-secret",
+			},
+		},
+	}
+	oidcJWEPol := &conf_v1.Policy{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "oidc-jwe-policy",
+			Namespace: "default",
+		},
+		Spec: conf_v1.PolicySpec{
+			OIDC: &conf_v1.OIDC{
+				ClientSecret: "oidc-secret",
+				JWEKeySecret: "jwe-key-secret",
+			},
+		},
+	}
+	oidcRedisPol := &conf_v1.Policy{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "oidc-redis-policy",
+			Namespace: "default",
+		},
+		Spec: conf_v1.PolicySpec{
+			OIDC: &conf_v1.OIDC{
+				ClientSecret: "oidc-secret",
+				SessionStore: &conf_v1.OIDCSessionStore{
+					Type: "redis",
+					Redis: &conf_v1.OIDCRedisSessionStore{
+						Address:    "redis:6379",
+						AuthSecret: "redis-auth",
+						TLS:        &conf_v1.OIDCRedisTLS{Enable: true, CASecret: "redis-ca"},
+					},
+				},
+			},
+		},
+	}
+
+	oidcCookiePol := &conf_v1.Policy{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "oidc-cookie-policy",
+			Namespace: "default",
+		},
+		Spec: conf_v1.PolicySpec{
+			OIDC: &conf_v1.OIDC{
+				ClientSecret: "oidc-secret",
+				SessionStore: &conf_v1.OIDCSessionStore{
+					Type:   "cookie",
+					Cookie: &conf_v1.OIDCCookieSessionStore{KeySecret: "session-key"},
+				},
+			},
+		},
+	}
+
+	oidcSharedSecretPol := &conf_v1.Policy{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "oidc-shared-secret-policy",
+			Namespace: "default",
+		},
+		Spec: conf_v1.PolicySpec{
+			OIDC: &conf_v1.OIDC{
+				ClientSecret: "idp/oidc-secret",
+			},
+		},
+	}
 
 	tests := []struct {
 		policies        []*conf_v1.Policy
@@ -2530,6 +2767,27 @@ func TestFindPoliciesForSecret(t *testing.T) {
 			expected:        []*conf_v1.Policy{egTLSPol2},
 			msg:             "Find policy in default ns, ignore other types",
 		},
+		{
+			policies:        []*conf_v1.Policy{oidcPol, oidcRedisPol},
+			secretNamespace: "default",
+			secretName:      "redis-auth",
+			expected:        []*conf_v1.Policy{oidcRedisPol},
+			msg:             "Find policy with the auth secret of its session store",
+		},
+		{
+			policies:        []*conf_v1.Policy{oidcPol, oidcRedisPol},
+			secretNamespace: "default",
+			secretName:      "redis-ca",
+			expected:        []*conf_v1.Policy{oidcRedisPol},
+			msg:             "Find policy with the CA secret of its session store",
+		},
+		{
+			policies:        []*conf_v1.Policy{oidcPol, oidcRedisPol, oidcCookiePol},
+			secretNamespace: "default",
+			secretName:      "session-key",
+			expected:        []*conf_v1.Policy{oidcCookiePol},
+			msg:             "Find policy with the key secret of its session cookies",
+		},
 		{
 			policies:        []*conf_v1.Policy{oidcPol},
 			secretNamespace: "default",
@@ -2544,6 +2802,34 @@ func TestFindPoliciesForSecret(t *testing.T) {
 			expected:        []*conf_v1.Policy{oidcPol},
 			msg:             "Find policy in default ns, ignore other types",
 		},
+		{
+			policies:        []*conf_v1.Policy{oidcPol, oidcJARPol},
+			secretNamespace: "default",
+			secretName:      "jar-key-secret",
+			expected:        []*conf_v1.Policy{oidcJARPol},
+			msg:             "Find oidc policy by JAR key secret",
+		},
+		{
+			policies:        []*conf_v1.Policy{oidcPol, oidcJWEPol},
+			secretNamespace: "default",
+			secretName:      "jwe-key-secret",
+			expected:        []*conf_v1.Policy{oidcJWEPol},
+			msg:             "Find oidc policy by JWE key secret",
+		},
+		{
+			policies:        []*conf_v1.Policy{oidcPol, oidcSharedSecretPol},
+			secretNamespace: "idp",
+			secretName:      "oidc-secret",
+			expected:        []*conf_v1.Policy{oidcSharedSecretPol},
+			msg:             "Find oidc policy by client secret in another namespace",
+		},
+		{
+			policies:        []*conf_v1.Policy{oidcPol, clientCredentialsPol},
+			secretNamespace: "default",
+			secretName:      "client-credentials-secret",
+			expected:        []*conf_v1.Policy{clientCredentialsPol},
+			msg:             "Find client credentials policy",
+		},
 	}
 	for _, test := range tests {
 		result := findPoliciesForSecret(test.policies, test.secretNamespace, test.secretName)
@@ -2553,6 +2839,256 @@ func TestFindPoliciesForSecret(t *testing.T) {
 	}
 }
 
+func TestRememberRotatedOIDCSecret(t *testing.T) {
+	t.Parallel()
+	oidcSecret := &api_v1.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "oidc-secret",
+			Namespace: "default",
+		},
+		Type: secrets.SecretTypeOIDC,
+		Data: map[string][]byte{
+			"client-secret": []byte("previous"),
+		},
+	}
+	lbc := LoadBalancerController{
+		secretStore: secrets.NewFakeSecretsStore(map[string]*secrets.SecretReference{
+			"default/oidc-secret": {
+				Secret: oidcSecret,
+			},
+		}),
+		oidcPreviousSecrets: make(map[string]previousSecret),
+	}
+
+	lbc.rememberRotatedOIDCSecret("default/oidc-secret", oidcSecret.DeepCopy())
+	if _, exists := lbc.oidcPreviousSecrets["default/oidc-secret"]; exists {
+		t.Errorf("rememberRotatedOIDCSecret() kept an unchanged secret")
+	}
+
+	rotatedSecret := oidcSecret.DeepCopy()
+	rotatedSecret.Data["client-secret"] = []byte("current")
+	lbc.rememberRotatedOIDCSecret("default/oidc-secret", rotatedSecret)
+	previous, exists := lbc.oidcPreviousSecrets["default/oidc-secret"]
+	if !exists || previous.secret != oidcSecret {
+		t.Errorf("rememberRotatedOIDCSecret() kept %+v, want the previous version of the secret", previous)
+	}
+	if time.Until(previous.expiry) > oidcStateLifetime {
+		t.Errorf("rememberRotatedOIDCSecret() kept the previous version until %v, longer than the lifetime of the state", previous.expiry)
+	}
+}
+
+func TestDistributeOIDCProviderDocuments(t *testing.T) {
+	t.Parallel()
+	client := fake.NewSimpleClientset()
+	leader := LoadBalancerController{
+		client:                 client,
+		controllerNamespace:    "nginx-ingress",
+		leaderElectionLockName: "nginx-ingress-leader",
+	}
+
+	discovery := `{"issuer":"https://idp.example.com","jwks_uri":"https://idp.example.com/certs"}`
+	jwks := `{"keys":[{"kty":"RSA","kid":"1"}]}`
+	err := leader.publishOIDCProviderDocuments("default/oidc-policy", oidc.Documents{Discovery: []byte(discovery), JWKS: []byte(jwks)})
+	if err != nil {
+		t.Fatalf("publishOIDCProviderDocuments() returned unexpected error: %v", err)
+	}
+	err = leader.publishOIDCProviderDocuments("cafe/oidc.policy", oidc.Documents{JWKS: []byte(jwks)})
+	if err != nil {
+		t.Fatalf("publishOIDCProviderDocuments() returned unexpected error: %v", err)
+	}
+
+	cm, err := client.CoreV1().ConfigMaps("nginx-ingress").Get(context.Background(), "nginx-ingress-leader-oidc-providers", meta_v1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get the ConfigMap of the provider documents: %v", err)
+	}
+	expectedData := map[string]string{
+		"default_oidc-policy.discovery.json": discovery,
+		"default_oidc-policy.jwks.json":      jwks,
+		"cafe_oidc.policy.jwks.json":         jwks,
+	}
+	if diff := cmp.Diff(expectedData, cm.Data); diff != "" {
+		t.Errorf("publishOIDCProviderDocuments() mismatch (-want +got):\n%s", diff)
+	}
+
+	follower := LoadBalancerController{
+		controllerNamespace:    "nginx-ingress",
+		leaderElectionLockName: "nginx-ingress-leader",
+		oidcProvidersLister:    cache.NewStore(cache.MetaNamespaceKeyFunc),
+	}
+	var changes []string
+	follower.oidcRefresher = oidc.NewRefresher(&http.Client{}, t.TempDir(), func(key string) { changes = append(changes, key) }, func(string, error) {})
+	follower.oidcRefresher.EnableDistribution(func(string, oidc.Documents) error { return nil })
+	follower.oidcRefresher.Update("default/oidc-policy", "https://idp.example.com/.well-known/openid-configuration", "", "", false)
+	follower.oidcRefresher.Update("cafe/oidc.policy", "", "https://idp.example.com/certs", "", false)
+	if err := follower.oidcProvidersLister.Add(cm); err != nil {
+		t.Fatal(err)
+	}
+
+	follower.applyOIDCProviderDocuments("")
+	if diff := cmp.Diff([]string{"default/oidc-policy"}, changes); diff != "" {
+		t.Errorf("applyOIDCProviderDocuments() reported changes mismatch (-want +got):\n%s", diff)
+	}
+	for _, key := range []string{"default/oidc-policy", "cafe/oidc.policy"} {
+		content, err := os.ReadFile(follower.oidcRefresher.JWKSFile(key))
+		if err != nil || string(content) != jwks {
+			t.Errorf("applyOIDCProviderDocuments() wrote %q, %v for %v, want %q", content, err, key, jwks)
+		}
+	}
+
+	err = leader.publishOIDCProviderDocuments("default/oidc-policy", oidc.Documents{})
+	if err != nil {
+		t.Fatalf("publishOIDCProviderDocuments() returned unexpected error: %v", err)
+	}
+	cm, _ = client.CoreV1().ConfigMaps("nginx-ingress").Get(context.Background(), "nginx-ingress-leader-oidc-providers", meta_v1.GetOptions{})
+	if diff := cmp.Diff(map[string]string{"cafe_oidc.policy.jwks.json": jwks}, cm.Data); diff != "" {
+		t.Errorf("publishOIDCProviderDocuments() with empty documents mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestOIDCPolicyFinalizer(t *testing.T) {
+	t.Parallel()
+	pol := &conf_v1.Policy{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "oidc-policy",
+			Namespace: "default",
+		},
+		Spec: conf_v1.PolicySpec{
+			OIDC: &conf_v1.OIDC{
+				ClientID:     "nginx-plus",
+				ClientSecret: "oidc-secret",
+			},
+		},
+	}
+	confClient := fake_v1.NewSimpleClientset(pol)
+	lbc := LoadBalancerController{
+		confClient: confClient,
+		configurator: configs.NewConfigurator(configs.ConfiguratorParams{
+			NginxManager:    nginx.NewFakeManager("/etc/nginx"),
+			StaticCfgParams: &configs.StaticConfigParams{},
+			Config:          &configs.ConfigParams{},
+			IsPlus:          true,
+		}),
+		recorder: record.NewFakeRecorder(10),
+		// Replicas that aren't the leader remove the finalizer too.
+		isLeaderElectionEnabled: true,
+	}
+
+	lbc.addOIDCPolicyFinalizer(pol)
+	updated, err := confClient.K8sV1().Policies("default").Get(context.Background(), "oidc-policy", meta_v1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(updated.Finalizers, []string{oidcPolicyFinalizer}) {
+		t.Errorf("addOIDCPolicyFinalizer() set the finalizers %v, want %v", updated.Finalizers, []string{oidcPolicyFinalizer})
+	}
+
+	if err := lbc.finalizeOIDCPolicy(updated); err != nil {
+		t.Fatalf("finalizeOIDCPolicy() returned an unexpected error: %v", err)
+	}
+	finalized, err := confClient.K8sV1().Policies("default").Get(context.Background(), "oidc-policy", meta_v1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(finalized.Finalizers) != 0 {
+		t.Errorf("finalizeOIDCPolicy() kept the finalizers %v", finalized.Finalizers)
+	}
+}
+
+func TestReportOIDCSecretErrors(t *testing.T) {
+	t.Parallel()
+	pol := &conf_v1.Policy{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "oidc-policy",
+			Namespace: "default",
+		},
+		Spec: conf_v1.PolicySpec{
+			OIDC: &conf_v1.OIDC{
+				ClientSecret: "oidc-secret",
+				JWEKeySecret: "jwe-secret",
+			},
+		},
+	}
+	recorder := record.NewFakeRecorder(10)
+	lbc := LoadBalancerController{
+		secretStore: secrets.NewFakeSecretsStore(map[string]*secrets.SecretReference{
+			"default/oidc-secret": {
+				Error: errors.New("OIDC secret must have the data field client-secret"),
+			},
+		}),
+		recorder: recorder,
+	}
+
+	lbc.reportOIDCSecretErrors(pol)
+	close(recorder.Events)
+	var events []string
+	for event := range recorder.Events {
+		events = append(events, event)
+	}
+	expected := []string{
+		"Warning SecretInvalid Secret default/oidc-secret of Policy default/oidc-policy is invalid: OIDC secret must have the data field client-secret",
+		"Warning SecretInvalid Secret default/jwe-secret of Policy default/oidc-policy is invalid: secret doesn't exist",
+	}
+	if diff := cmp.Diff(expected, events); diff != "" {
+		t.Errorf("reportOIDCSecretErrors() emitted unexpected events (-want +got):\n%s", diff)
+	}
+}
+
+func TestFindPoliciesForConfigMap(t *testing.T) {
+	t.Parallel()
+	oidcPol := &conf_v1.Policy{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "oidc-policy",
+			Namespace: "default",
+		},
+		Spec: conf_v1.PolicySpec{
+			OIDC: &conf_v1.OIDC{
+				ClientSecret: "oidc-secret",
+				ErrorPages:   "oidc-error-pages",
+			},
+		},
+	}
+	otherOIDCPol := &conf_v1.Policy{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "other-oidc-policy",
+			Namespace: "default",
+		},
+		Spec: conf_v1.PolicySpec{
+			OIDC: &conf_v1.OIDC{
+				ClientSecret: "oidc-secret",
+			},
+		},
+	}
+
+	tests := []struct {
+		policies           []*conf_v1.Policy
+		configMapNamespace string
+		configMapName      string
+		expected           []*conf_v1.Policy
+		msg                string
+	}{
+		{
+			policies:           []*conf_v1.Policy{oidcPol, otherOIDCPol},
+			configMapNamespace: "default",
+			configMapName:      "oidc-error-pages",
+			expected:           []*conf_v1.Policy{oidcPol},
+			msg:                "Find oidc policy by error pages ConfigMap",
+		},
+		{
+			policies:           []*conf_v1.Policy{oidcPol, otherOIDCPol},
+			configMapNamespace: "ns-1",
+			configMapName:      "oidc-error-pages",
+			expected:           nil,
+			msg:                "Ignore ConfigMap in other namespace",
+		},
+	}
+	for _, test := range tests {
+		result := findPoliciesForConfigMap(test.policies, test.configMapNamespace, test.configMapName)
+		if diff := cmp.Diff(test.expected, result); diff != "" {
+			t.Errorf("findPoliciesForConfigMap() '%v' mismatch (-want +got):\n%s", test.msg, diff)
+		}
+	}
+}
+
 func errorComparer(e1, e2 error) bool {
 	if e1 == nil || e2 == nil {
 		return errors.Is(e1, e2)
@@ -3181,7 +3717,7 @@ func TestAddOidcSecret(t *testing.T) {
 			Namespace: "default",
 		},
 		Data: map[string][]byte{
-			"client-secret": nil,
+			"client-secret": []byte("secret"),
 		},
 		Type: secrets.SecretTypeOIDC,
 	}
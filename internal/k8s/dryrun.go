@@ -0,0 +1,226 @@
+package k8s
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/nginxinc/kubernetes-ingress/internal/configs"
+	"github.com/nginxinc/kubernetes-ingress/internal/k8s/secrets"
+	conf_v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1"
+	conf_v2 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v2"
+	"golang.org/x/exp/maps"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// policyDryRunPath is the path of the policy dry-run endpoint.
+const policyDryRunPath = "/dry-run/policy"
+
+// maxPolicySize is the maximum size of a Policy in a dry-run request.
+const maxPolicySize = 1 << 20
+
+// dryRunSecretVariables matches the variables of the rendered configuration that contain the secrets of the policies.
+var dryRunSecretVariables = regexp.MustCompile(`(?m)^(\s*set \$(?:oidc_client_secret|oidc_hmac_key|oidc_previous_hmac_key|oidc_state_key|oidc_previous_state_key|oidc_session_cookie_keys|client_credentials_client_secret) )"[^"]+";`)
+
+// runPolicyDryRun starts the policy dry-run server. The server listens only on localhost,
+// because the VirtualServers and the Secrets of every namespace can be rendered.
+func (lbc *LoadBalancerController) runPolicyDryRun() {
+	mux := http.NewServeMux()
+	mux.HandleFunc(policyDryRunPath, lbc.servePolicyDryRun)
+	srv := &http.Server{
+		Addr:         "127.0.0.1:" + strconv.Itoa(lbc.policyDryRunPort),
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+	glog.Infof("Starting the policy dry-run server on: %v", srv.Addr)
+	glog.Fatal(srv.ListenAndServe())
+}
+
+// servePolicyDryRun renders the NGINX configuration of the VirtualServer in the virtualserver query parameter
+// as if the Policy in the request body was added or updated, without applying it.
+func (lbc *LoadBalancerController) servePolicyDryRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	vsKey := r.URL.Query().Get("virtualserver")
+	vsNamespace, _, err := ParseNamespaceName(vsKey)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid virtualserver parameter: %v", err), http.StatusBadRequest)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPolicySize))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error reading the request: %v", err), http.StatusBadRequest)
+		return
+	}
+	pol, err := decodeDryRunPolicy(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if pol.Namespace == "" {
+		pol.Namespace = vsNamespace
+	} else if pol.Namespace != vsNamespace {
+		http.Error(w, fmt.Sprintf("policy %v/%v must be in the namespace %v of the VirtualServer", pol.Namespace, pol.Name, vsNamespace), http.StatusBadRequest)
+		return
+	}
+	if err := lbc.validatePolicy(pol); err != nil {
+		http.Error(w, fmt.Sprintf("policy %v/%v is invalid: %v", pol.Namespace, pol.Name, err), http.StatusBadRequest)
+		return
+	}
+
+	lbc.syncLock.Lock()
+	defer lbc.syncLock.Unlock()
+
+	vsc := lbc.findVirtualServerConfiguration(vsKey)
+	if vsc == nil {
+		http.Error(w, fmt.Sprintf("VirtualServer %v doesn't exist or is invalid", vsKey), http.StatusNotFound)
+		return
+	}
+	if err := lbc.validateDryRunSecretGrants(pol); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	content, err := lbc.renderVirtualServerWithPolicy(vsc, pol)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if _, err := w.Write(redactDryRunSecrets(content)); err != nil {
+		glog.Errorf("Error writing the policy dry-run response: %v", err)
+	}
+}
+
+// decodeDryRunPolicy decodes a Policy in YAML or JSON of the k8s.nginx.org/v1 or k8s.nginx.org/v2 version.
+func decodeDryRunPolicy(data []byte) (*conf_v1.Policy, error) {
+	raw, err := yaml.ToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding the Policy: %w", err)
+	}
+	var typeMeta meta_v1.TypeMeta
+	if err := json.Unmarshal(raw, &typeMeta); err != nil {
+		return nil, fmt.Errorf("error decoding the Policy: %w", err)
+	}
+	if typeMeta.Kind != "Policy" {
+		return nil, fmt.Errorf("unexpected kind %q, the request body must be a Policy", typeMeta.Kind)
+	}
+
+	switch typeMeta.APIVersion {
+	case conf_v1.SchemeGroupVersion.String():
+		var pol conf_v1.Policy
+		if err := json.Unmarshal(raw, &pol); err != nil {
+			return nil, fmt.Errorf("error decoding the Policy: %w", err)
+		}
+		return &pol, nil
+	case conf_v2.SchemeGroupVersion.String():
+		var pol conf_v2.Policy
+		if err := json.Unmarshal(raw, &pol); err != nil {
+			return nil, fmt.Errorf("error decoding the Policy: %w", err)
+		}
+		return pol.ConvertToV1(), nil
+	}
+	return nil, fmt.Errorf("unsupported apiVersion %q of the Policy", typeMeta.APIVersion)
+}
+
+// validateDryRunSecretGrants validates that the Secrets in other namespaces that the policy references allow
+// references from the namespace of the policy, like the configuration of the policy does.
+func (lbc *LoadBalancerController) validateDryRunSecretGrants(pol *conf_v1.Policy) error {
+	for _, secretKey := range oidcPolicySecretKeys(pol) {
+		secretRef := lbc.secretStore.GetSecret(secretKey)
+		if secretRef.Error != nil {
+			continue
+		}
+		if err := secrets.ValidateReferenceGrant(secretRef.Secret, pol.Namespace); err != nil {
+			return fmt.Errorf("policy %v/%v can't reference the Secret %v: %w", pol.Namespace, pol.Name, secretKey, err)
+		}
+	}
+	return nil
+}
+
+// redactDryRunSecrets replaces the values of the variables with the secrets of the policies in the rendered configuration.
+func redactDryRunSecrets(content []byte) []byte {
+	return dryRunSecretVariables.ReplaceAll(content, []byte(`${1}"<redacted>";`))
+}
+
+// findVirtualServerConfiguration finds the configuration of the valid VirtualServer with the key.
+func (lbc *LoadBalancerController) findVirtualServerConfiguration(key string) *VirtualServerConfiguration {
+	for _, r := range lbc.configuration.GetResourcesWithFilter(resourceFilter{VirtualServers: true}) {
+		vsc := r.(*VirtualServerConfiguration)
+		if getResourceKey(&vsc.VirtualServer.ObjectMeta) == key {
+			return vsc
+		}
+	}
+	return nil
+}
+
+// renderVirtualServerWithPolicy renders the NGINX configuration of the VirtualServer as if the policy was added or updated.
+// A policy that the VirtualServer doesn't reference is applied like a selected OIDC policy. The warnings of the
+// configuration are prepended as comments.
+func (lbc *LoadBalancerController) renderVirtualServerWithPolicy(vsc *VirtualServerConfiguration, pol *conf_v1.Policy) ([]byte, error) {
+	vsEx := lbc.createVirtualServerEx(vsc.VirtualServer, vsc.VirtualServerRoutes)
+
+	polKey := fmt.Sprintf("%s/%s", pol.Namespace, pol.Name)
+	if _, exists := vsEx.Policies[polKey]; !exists {
+		if pol.Spec.OIDC == nil {
+			return nil, fmt.Errorf("VirtualServer %v/%v doesn't reference the policy %v", vsc.VirtualServer.Namespace, vsc.VirtualServer.Name, polKey)
+		}
+		for _, ref := range vsEx.SelectedPolicies {
+			delete(vsEx.Policies, fmt.Sprintf("%s/%s", ref.Namespace, ref.Name))
+		}
+		vsEx.SelectedPolicies = []conf_v1.PolicyReference{{Name: pol.Name, Namespace: pol.Namespace}}
+	}
+	vsEx.Policies[polKey] = pol
+
+	var secretErrs []string
+	policies := []*conf_v1.Policy{pol}
+	for _, addSecretRefs := range []func(map[string]*secrets.SecretReference, []*conf_v1.Policy) error{
+		lbc.addJWTSecretRefs,
+		lbc.addBasicSecretRefs,
+		lbc.addIngressMTLSSecretRefs,
+		lbc.addEgressMTLSSecretRefs,
+		lbc.addOIDCSecretRefs,
+		lbc.addAPIKeySecretRefs,
+		lbc.addClientCredentialsSecretRefs,
+	} {
+		if err := addSecretRefs(vsEx.SecretRefs, policies); err != nil {
+			secretErrs = append(secretErrs, err.Error())
+		}
+	}
+	allPolicies := maps.Values(vsEx.Policies)
+	vsEx.ConfigMapRefs = lbc.getOIDCConfigMapRefs(allPolicies)
+	vsEx.OIDCProviders = lbc.getOIDCProviders(allPolicies)
+
+	content, warnings, err := lbc.configurator.RenderVirtualServer(vsEx)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for _, msg := range append(secretErrs, collectWarnings(warnings)...) {
+		fmt.Fprintf(&buf, "# Warning: %s\n", msg)
+	}
+	buf.Write(content)
+	return buf.Bytes(), nil
+}
+
+// collectWarnings returns the sorted messages of the warnings.
+func collectWarnings(warnings configs.Warnings) []string {
+	var msgs []string
+	for _, objWarnings := range warnings {
+		msgs = append(msgs, objWarnings...)
+	}
+	sort.Strings(msgs)
+	return msgs
+}
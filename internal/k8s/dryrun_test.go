@@ -0,0 +1,209 @@
+package k8s
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-ingress/internal/k8s/secrets"
+	conf_v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1"
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDecodeDryRunPolicy(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		data             string
+		expectedClientID string
+		msg              string
+	}{
+		{
+			data: `apiVersion: k8s.nginx.org/v1
+kind: Policy
+metadata:
+  name: oidc-policy
+spec:
+  oidc:
+    clientID: nginx-plus
+    cookieSameSite: lax
+`,
+			expectedClientID: "nginx-plus",
+			msg:              "v1 Policy in YAML",
+		},
+		{
+			data:             `{"apiVersion":"k8s.nginx.org/v2","kind":"Policy","metadata":{"name":"oidc-policy"},"spec":{"oidc":{"clientID":"nginx-plus","cookie":{"sameSite":"lax"}}}}`,
+			expectedClientID: "nginx-plus",
+			msg:              "v2 Policy in JSON",
+		},
+	}
+
+	for _, test := range tests {
+		pol, err := decodeDryRunPolicy([]byte(test.data))
+		if err != nil {
+			t.Errorf("decodeDryRunPolicy() returned unexpected error %v for the case of %s", err, test.msg)
+			continue
+		}
+		if pol.Name != "oidc-policy" || pol.Spec.OIDC == nil || pol.Spec.OIDC.ClientID != test.expectedClientID {
+			t.Errorf("decodeDryRunPolicy() returned %+v for the case of %s", pol, test.msg)
+			continue
+		}
+		if pol.Spec.OIDC.CookieSameSite != "lax" {
+			t.Errorf("decodeDryRunPolicy() returned cookieSameSite %q but expected %q for the case of %s", pol.Spec.OIDC.CookieSameSite, "lax", test.msg)
+		}
+	}
+}
+
+func TestDecodeDryRunPolicyFails(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		data string
+		msg  string
+	}{
+		{
+			data: `apiVersion: v1
+kind: Secret
+metadata:
+  name: oidc-secret
+`,
+			msg: "not a Policy",
+		},
+		{
+			data: `apiVersion: k8s.nginx.org/v3
+kind: Policy
+`,
+			msg: "unsupported version",
+		},
+		{
+			data: `{"apiVersion":`,
+			msg:  "invalid JSON",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := decodeDryRunPolicy([]byte(test.data))
+		if err == nil {
+			t.Errorf("decodeDryRunPolicy() returned no error for the case of %s", test.msg)
+		}
+	}
+}
+
+func TestServePolicyDryRunRejectsInvalidRequests(t *testing.T) {
+	t.Parallel()
+	lbc := LoadBalancerController{
+		isNginxPlus: true,
+		enableOIDC:  true,
+	}
+
+	tests := []struct {
+		method         string
+		target         string
+		body           string
+		expectedStatus int
+		msg            string
+	}{
+		{
+			method:         http.MethodGet,
+			target:         "/dry-run/policy?virtualserver=default/cafe",
+			expectedStatus: http.StatusMethodNotAllowed,
+			msg:            "GET request",
+		},
+		{
+			method:         http.MethodPost,
+			target:         "/dry-run/policy",
+			body:           `{"apiVersion":"k8s.nginx.org/v1","kind":"Policy","metadata":{"name":"oidc-policy"}}`,
+			expectedStatus: http.StatusBadRequest,
+			msg:            "missing virtualserver parameter",
+		},
+		{
+			method:         http.MethodPost,
+			target:         "/dry-run/policy?virtualserver=default/cafe",
+			body:           `{"apiVersion":"k8s.nginx.org/v1","kind":"Policy","metadata":{"name":"oidc-policy"},"spec":{"oidc":{"clientID":"nginx-plus"}}}`,
+			expectedStatus: http.StatusBadRequest,
+			msg:            "invalid Policy",
+		},
+		{
+			method:         http.MethodPost,
+			target:         "/dry-run/policy?virtualserver=default/cafe",
+			body:           `{"apiVersion":"k8s.nginx.org/v1","kind":"Policy","metadata":{"name":"oidc-policy","namespace":"idp"}}`,
+			expectedStatus: http.StatusBadRequest,
+			msg:            "Policy in another namespace",
+		},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.target, strings.NewReader(test.body))
+		rec := httptest.NewRecorder()
+		lbc.servePolicyDryRun(rec, req)
+		if rec.Code != test.expectedStatus {
+			t.Errorf("servePolicyDryRun() returned status %v but expected %v for the case of %s: %s", rec.Code, test.expectedStatus, test.msg, rec.Body.String())
+		}
+	}
+}
+
+func TestRedactDryRunSecrets(t *testing.T) {
+	t.Parallel()
+	content := `server {
+    set $oidc_client_secret "client-secret";
+    set $oidc_hmac_key "hmac-key";
+    set $oidc_previous_hmac_key "";
+    set $oidc_state_key "state-key";
+    set $oidc_client "nginx-plus";
+    location / {
+        set $client_credentials_client_secret "cc-secret";
+    }
+}
+`
+	expected := `server {
+    set $oidc_client_secret "<redacted>";
+    set $oidc_hmac_key "<redacted>";
+    set $oidc_previous_hmac_key "";
+    set $oidc_state_key "<redacted>";
+    set $oidc_client "nginx-plus";
+    location / {
+        set $client_credentials_client_secret "<redacted>";
+    }
+}
+`
+	if got := string(redactDryRunSecrets([]byte(content))); got != expected {
+		t.Errorf("redactDryRunSecrets() returned\n%s\nbut expected\n%s", got, expected)
+	}
+}
+
+func TestValidateDryRunSecretGrants(t *testing.T) {
+	t.Parallel()
+	lbc := LoadBalancerController{
+		secretStore: secrets.NewFakeSecretsStore(map[string]*secrets.SecretReference{
+			"idp/oidc-secret": {
+				Secret: &api_v1.Secret{
+					ObjectMeta: meta_v1.ObjectMeta{
+						Name:        "oidc-secret",
+						Namespace:   "idp",
+						Annotations: map[string]string{secrets.AllowedNamespacesAnnotation: "team"},
+					},
+				},
+			},
+		}),
+	}
+	pol := func(namespace string) *conf_v1.Policy {
+		return &conf_v1.Policy{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      "oidc-policy",
+				Namespace: namespace,
+			},
+			Spec: conf_v1.PolicySpec{
+				OIDC: &conf_v1.OIDC{
+					ClientSecret: "idp/oidc-secret",
+				},
+			},
+		}
+	}
+
+	if err := lbc.validateDryRunSecretGrants(pol("team")); err != nil {
+		t.Errorf("validateDryRunSecretGrants() returned unexpected error %v for a granted namespace", err)
+	}
+	if err := lbc.validateDryRunSecretGrants(pol("app")); err == nil {
+		t.Error("validateDryRunSecretGrants() returned no error for a namespace that isn't granted")
+	}
+}
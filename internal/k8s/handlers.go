@@ -61,6 +61,61 @@ func createConfigMapHandlers(lbc *LoadBalancerController, name string) cache.Res
 	}
 }
 
+// createOIDCProvidersHandlers builds the handler funcs for the ConfigMap where the leader publishes the provider
+// documents of the OIDC policies. The followers apply the documents right away, the Refresher enqueues the
+// policies whose discovery document changed.
+func createOIDCProvidersHandlers(lbc *LoadBalancerController) cache.ResourceEventHandlerFuncs {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(_ interface{}) {
+			glog.V(3).Info("Adding the provider documents of OIDC policies")
+			lbc.applyOIDCProviderDocuments("")
+		},
+		UpdateFunc: func(old, cur interface{}) {
+			if !reflect.DeepEqual(old.(*v1.ConfigMap).Data, cur.(*v1.ConfigMap).Data) {
+				glog.V(3).Info("The provider documents of OIDC policies changed")
+				lbc.applyOIDCProviderDocuments("")
+			}
+		},
+	}
+}
+
+// createOIDCConfigMapHandlers builds the handler funcs for the ConfigMaps with the error pages of OIDC policies.
+// A change of a ConfigMap enqueues the policies that reference it.
+func createOIDCConfigMapHandlers(lbc *LoadBalancerController) cache.ResourceEventHandlerFuncs {
+	syncPolicies := func(configMap *v1.ConfigMap) {
+		for _, pol := range lbc.getPoliciesForConfigMap(configMap.Namespace, configMap.Name) {
+			glog.V(3).Infof("ConfigMap %v/%v of Policy %v changed, syncing", configMap.Namespace, configMap.Name, pol.Name)
+			lbc.AddSyncQueue(pol)
+		}
+	}
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			syncPolicies(obj.(*v1.ConfigMap))
+		},
+		DeleteFunc: func(obj interface{}) {
+			configMap, isConfigMap := obj.(*v1.ConfigMap)
+			if !isConfigMap {
+				deletedState, ok := obj.(cache.DeletedFinalStateUnknown)
+				if !ok {
+					glog.V(3).Infof("Error received unexpected object: %v", obj)
+					return
+				}
+				configMap, ok = deletedState.Obj.(*v1.ConfigMap)
+				if !ok {
+					glog.V(3).Infof("Error DeletedFinalStateUnknown contained non-ConfigMap object: %v", deletedState.Obj)
+					return
+				}
+			}
+			syncPolicies(configMap)
+		},
+		UpdateFunc: func(old, cur interface{}) {
+			if !reflect.DeepEqual(old, cur) {
+				syncPolicies(cur.(*v1.ConfigMap))
+			}
+		},
+	}
+}
+
 // createEndpointSliceHandlers builds the handler funcs for EndpointSlices
 func createEndpointSliceHandlers(lbc *LoadBalancerController) cache.ResourceEventHandlerFuncs {
 	return cache.ResourceEventHandlerFuncs{
@@ -328,14 +383,17 @@ func createVirtualServerHandlers(lbc *LoadBalancerController) cache.ResourceEven
 				zeroOutVirtualServerSplitWeights(&curVsCopy)
 				zeroOutVirtualServerSplitWeights(&oldVsCopy)
 
-				if reflect.DeepEqual(oldVsCopy.Spec, curVsCopy.Spec) {
+				if reflect.DeepEqual(oldVsCopy.Spec, curVsCopy.Spec) && reflect.DeepEqual(oldVs.Labels, curVs.Labels) &&
+					reflect.DeepEqual(oldVs.Annotations, curVs.Annotations) {
 					lbc.processVSWeightChangesDynamicReload(oldVs, curVs)
 					return
 				}
 
 			}
 
-			if !reflect.DeepEqual(oldVs.Spec, curVs.Spec) {
+			// Labels and annotations can change which OIDC policies apply to the VirtualServer
+			if !reflect.DeepEqual(oldVs.Spec, curVs.Spec) || !reflect.DeepEqual(oldVs.Labels, curVs.Labels) ||
+				!reflect.DeepEqual(oldVs.Annotations, curVs.Annotations) {
 				glog.V(3).Infof("VirtualServer %v changed, syncing", curVs.Name)
 				lbc.AddSyncQueue(curVs)
 			}
@@ -498,7 +556,7 @@ func createPolicyHandlers(lbc *LoadBalancerController) cache.ResourceEventHandle
 		UpdateFunc: func(old, cur interface{}) {
 			curPol := cur.(*conf_v1.Policy)
 			oldPol := old.(*conf_v1.Policy)
-			if !reflect.DeepEqual(oldPol.Spec, curPol.Spec) {
+			if !reflect.DeepEqual(oldPol.Spec, curPol.Spec) || !reflect.DeepEqual(oldPol.DeletionTimestamp, curPol.DeletionTimestamp) {
 				glog.V(3).Infof("Policy %v changed, syncing", curPol.Name)
 				lbc.AddSyncQueue(curPol)
 			}
@@ -58,6 +58,9 @@ func createLeaderHandler(lbc *LoadBalancerController) leaderelection.LeaderCallb
 	return leaderelection.LeaderCallbacks{
 		OnStartedLeading: func(ctx context.Context) {
 			glog.V(3).Info("started leading")
+			if lbc.oidcRefresher != nil {
+				lbc.oidcRefresher.SetLeader(true)
+			}
 			// Closing this channel allows the leader to start the telemetry reporting process
 			if lbc.telemetryChan != nil {
 				close(lbc.telemetryChan)
@@ -99,6 +102,9 @@ func createLeaderHandler(lbc *LoadBalancerController) leaderelection.LeaderCallb
 		},
 		OnStoppedLeading: func() {
 			glog.V(3).Info("stopped leading")
+			if lbc.oidcRefresher != nil {
+				lbc.oidcRefresher.SetLeader(false)
+			}
 		},
 	}
 }
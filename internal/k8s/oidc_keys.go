@@ -0,0 +1,155 @@
+package k8s
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/nginxinc/kubernetes-ingress/internal/configs"
+	"github.com/nginxinc/kubernetes-ingress/internal/k8s/secrets"
+	conf_v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1"
+	api_v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// oidcKeyRotationCheckInterval is how often the leader checks whether the managed keys of the OIDC policies are
+// due for rotation.
+const oidcKeyRotationCheckInterval = time.Minute
+
+// oidcManagedKeyFields are the fields of the Secret of the managed keys of an OIDC policy, with the fields of their
+// previous keys.
+var oidcManagedKeyFields = []struct {
+	current  string
+	previous string
+}{
+	{current: secrets.OIDCSessionKey, previous: secrets.OIDCPreviousSessionKey},
+	{current: secrets.OIDCStateKey, previous: secrets.OIDCPreviousStateKey},
+	{current: secrets.OIDCHMACKey, previous: secrets.OIDCPreviousHMACKey},
+}
+
+// oidcManagedKeysSecret returns the name of the Secret of the managed keys of the OIDC policy, or an empty string
+// if the policy doesn't manage its keys.
+func oidcManagedKeysSecret(pol *conf_v1.Policy) string {
+	if pol.Spec.OIDC == nil || pol.Spec.OIDC.ManagedKeys == nil || !pol.Spec.OIDC.ManagedKeys.Enable {
+		return ""
+	}
+	return configs.OIDCManagedKeysSecretName(pol.Name)
+}
+
+// runOIDCKeyRotation periodically rotates the managed keys of the OIDC policies.
+func (lbc *LoadBalancerController) runOIDCKeyRotation(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(oidcKeyRotationCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			for _, pol := range lbc.getAllPolicies() {
+				if oidcManagedKeysSecret(pol) == "" {
+					continue
+				}
+				if err := lbc.validatePolicy(pol); err != nil {
+					continue
+				}
+				lbc.updateOIDCManagedKeys(pol)
+			}
+		}
+	}
+}
+
+// updateOIDCManagedKeys generates the managed keys of the OIDC policy if its Secret doesn't exist, or rotates them
+// when they are older than the rotationInterval of the policy. Only the leader updates the Secret, which the other
+// replicas get like any other Secret.
+func (lbc *LoadBalancerController) updateOIDCManagedKeys(pol *conf_v1.Policy) {
+	if !lbc.reportCustomResourceStatusEnabled() {
+		return
+	}
+	if err := lbc.ensureOIDCManagedKeys(pol, time.Now()); err != nil {
+		glog.Warningf("Failed to update the managed keys of Policy %v/%v: %v", pol.Namespace, pol.Name, err)
+		lbc.recorder.Eventf(pol, api_v1.EventTypeWarning, "ManagedKeysError", "Managed keys of Policy %v/%v can't be updated: %v", pol.Namespace, pol.Name, err)
+	}
+}
+
+func (lbc *LoadBalancerController) ensureOIDCManagedKeys(pol *conf_v1.Policy, now time.Time) error {
+	name := oidcManagedKeysSecret(pol)
+	secretsClient := lbc.client.CoreV1().Secrets(pol.Namespace)
+	secret, err := secretsClient.Get(context.TODO(), name, meta_v1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		secret = &api_v1.Secret{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      name,
+				Namespace: pol.Namespace,
+				OwnerReferences: []meta_v1.OwnerReference{
+					*meta_v1.NewControllerRef(pol, conf_v1.SchemeGroupVersion.WithKind("Policy")),
+				},
+			},
+			Type: secrets.SecretTypeOIDCSessionKey,
+			Data: make(map[string][]byte),
+		}
+		if err := rotateOIDCManagedKeys(secret, now); err != nil {
+			return err
+		}
+		_, err = secretsClient.Create(context.TODO(), secret, meta_v1.CreateOptions{})
+		if err == nil {
+			glog.V(3).Infof("Generated the managed keys of Policy %v/%v in Secret %v", pol.Namespace, pol.Name, name)
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	// the annotation alone can be copied to any Secret, so the Secret must also be owned by the policy
+	rotatedAt, exists := secret.Annotations[secrets.OIDCKeysRotatedAtAnnotation]
+	if !exists || !meta_v1.IsControlledBy(secret, pol) {
+		return fmt.Errorf("secret %v/%v exists and is not managed by the Ingress Controller", pol.Namespace, name)
+	}
+	interval := pol.Spec.OIDC.ManagedKeys.RotationInterval
+	if interval == "" {
+		return nil
+	}
+	// the interval is validated in the policy
+	seconds, _ := configs.ParseTimeSeconds(interval)
+	last, err := time.Parse(time.RFC3339, rotatedAt)
+	if err == nil && now.Before(last.Add(time.Duration(seconds)*time.Second)) {
+		return nil
+	}
+
+	secret = secret.DeepCopy()
+	if err := rotateOIDCManagedKeys(secret, now); err != nil {
+		return err
+	}
+	_, err = secretsClient.Update(context.TODO(), secret, meta_v1.UpdateOptions{})
+	if err == nil {
+		glog.V(3).Infof("Rotated the managed keys of Policy %v/%v in Secret %v", pol.Namespace, pol.Name, name)
+	}
+	return err
+}
+
+// rotateOIDCManagedKeys replaces the managed keys of the Secret with new random keys, and keeps the current keys as
+// the previous keys, so that the cookies and the logins of the current keys remain valid until the next rotation.
+func rotateOIDCManagedKeys(secret *api_v1.Secret, now time.Time) error {
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+	for _, field := range oidcManagedKeyFields {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return err
+		}
+		if current, exists := secret.Data[field.current]; exists {
+			secret.Data[field.previous] = current
+		}
+		secret.Data[field.current] = []byte(hex.EncodeToString(key))
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+	secret.Annotations[secrets.OIDCKeysRotatedAtAnnotation] = now.UTC().Format(time.RFC3339)
+	return nil
+}
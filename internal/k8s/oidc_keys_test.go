@@ -0,0 +1,108 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nginxinc/kubernetes-ingress/internal/k8s/secrets"
+	conf_v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1"
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestEnsureOIDCManagedKeys(t *testing.T) {
+	t.Parallel()
+	pol := &conf_v1.Policy{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "oidc-policy", Namespace: "default", UID: "uid"},
+		Spec: conf_v1.PolicySpec{
+			OIDC: &conf_v1.OIDC{
+				ClientID:    "nginx-plus",
+				ManagedKeys: &conf_v1.OIDCManagedKeys{Enable: true, RotationInterval: "24h"},
+			},
+		},
+	}
+	client := fake.NewSimpleClientset()
+	lbc := &LoadBalancerController{client: client}
+	getSecret := func() *api_v1.Secret {
+		secret, err := client.CoreV1().Secrets("default").Get(context.Background(), "oidc-policy-oidc-keys", meta_v1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return secret
+	}
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := lbc.ensureOIDCManagedKeys(pol, now); err != nil {
+		t.Fatalf("ensureOIDCManagedKeys() returned an unexpected error: %v", err)
+	}
+	generated := getSecret()
+	if generated.Type != secrets.SecretTypeOIDCSessionKey || len(generated.OwnerReferences) != 1 || generated.OwnerReferences[0].UID != "uid" {
+		t.Errorf("ensureOIDCManagedKeys() created the Secret %+v, want a session key Secret owned by the policy", generated)
+	}
+	if err := secrets.ValidateOIDCSessionKeySecret(generated); err != nil {
+		t.Errorf("ensureOIDCManagedKeys() created an invalid Secret: %v", err)
+	}
+
+	// the keys aren't rotated before the rotation interval
+	if err := lbc.ensureOIDCManagedKeys(pol, now.Add(time.Hour)); err != nil {
+		t.Fatalf("ensureOIDCManagedKeys() returned an unexpected error: %v", err)
+	}
+	if secret := getSecret(); !bytes.Equal(secret.Data[secrets.OIDCHMACKey], generated.Data[secrets.OIDCHMACKey]) {
+		t.Errorf("ensureOIDCManagedKeys() rotated the keys before the rotation interval")
+	}
+
+	if err := lbc.ensureOIDCManagedKeys(pol, now.Add(24*time.Hour)); err != nil {
+		t.Fatalf("ensureOIDCManagedKeys() returned an unexpected error: %v", err)
+	}
+	rotated := getSecret()
+	for _, field := range oidcManagedKeyFields {
+		if bytes.Equal(rotated.Data[field.current], generated.Data[field.current]) {
+			t.Errorf("ensureOIDCManagedKeys() didn't rotate the key %v", field.current)
+		}
+		if !bytes.Equal(rotated.Data[field.previous], generated.Data[field.current]) {
+			t.Errorf("ensureOIDCManagedKeys() set %v %q, want the key before the rotation", field.previous, rotated.Data[field.previous])
+		}
+	}
+	if got := rotated.Annotations[secrets.OIDCKeysRotatedAtAnnotation]; got != "2024-01-02T00:00:00Z" {
+		t.Errorf("ensureOIDCManagedKeys() set the rotation time %q, want %q", got, "2024-01-02T00:00:00Z")
+	}
+
+	// a Secret that the Ingress Controller didn't create is never overwritten
+	pol.Name = "other-policy"
+	_, err := client.CoreV1().Secrets("default").Create(context.Background(), &api_v1.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "other-policy-oidc-keys", Namespace: "default"},
+	}, meta_v1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lbc.ensureOIDCManagedKeys(pol, now); err == nil {
+		t.Errorf("ensureOIDCManagedKeys() returned no error for a Secret without the annotation of the managed keys")
+	}
+
+	// nor a Secret with the annotation of the managed keys that isn't owned by the policy
+	pol.Name = "another-policy"
+	_, err = client.CoreV1().Secrets("default").Create(context.Background(), &api_v1.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "another-policy-oidc-keys",
+			Namespace:   "default",
+			Annotations: map[string]string{secrets.OIDCKeysRotatedAtAnnotation: "2023-01-01T00:00:00Z"},
+		},
+		Data: map[string][]byte{"tls.key": []byte("key")},
+	}, meta_v1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lbc.ensureOIDCManagedKeys(pol, now); err == nil {
+		t.Errorf("ensureOIDCManagedKeys() returned no error for a Secret that isn't owned by the policy")
+	}
+	secret, err := client.CoreV1().Secrets("default").Get(context.Background(), "another-policy-oidc-keys", meta_v1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secret.Data) != 1 || string(secret.Data["tls.key"]) != "key" {
+		t.Errorf("ensureOIDCManagedKeys() overwrote the Secret %+v that isn't owned by the policy", secret)
+	}
+}
@@ -3,9 +3,13 @@ package secrets
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/pem"
 	"fmt"
 	"regexp"
+	"sort"
+	"strings"
+	"unicode"
 
 	api_v1 "k8s.io/api/core/v1"
 )
@@ -19,6 +23,40 @@ const CAKey = "ca.crt"
 // ClientSecretKey is the key of the data field of a Secret where the OIDC client secret must be stored.
 const ClientSecretKey = "client-secret"
 
+// OIDCStateKey is the key of the data field of a Secret where the key that signs the OIDC state can be stored.
+const OIDCStateKey = "state-key"
+
+// OIDCSessionKey is the key of the data field of a Secret where the key that encrypts the OIDC session cookies must be stored.
+const OIDCSessionKey = "key"
+
+// OIDCPreviousSessionKey is the key of the data field of a Secret where the previous key that encrypted the OIDC
+// session cookies can be stored, so that the cookies encrypted before a key rotation can be decrypted.
+const OIDCPreviousSessionKey = "previous-key"
+
+// OIDCPreviousStateKey is the key of the data field of a Secret of the managed keys of an OIDC policy where the
+// previous key that signed the OIDC state is stored, so that the logins started before a key rotation can complete.
+const OIDCPreviousStateKey = "previous-state-key"
+
+// OIDCHMACKey is the key of the data field of a Secret of the managed keys of an OIDC policy where the key that
+// hashes the nonces of the OIDC logins is stored.
+const OIDCHMACKey = "hmac-key"
+
+// OIDCPreviousHMACKey is the key of the data field of a Secret of the managed keys of an OIDC policy where the
+// previous key that hashed the nonces of the OIDC logins is stored.
+const OIDCPreviousHMACKey = "previous-hmac-key"
+
+// minOIDCSessionKeyLength is the minimum length of the keys of the OIDC session cookies.
+const minOIDCSessionKeyLength = 32
+
+// AllowedNamespacesAnnotation is the annotation of a Secret that lists the namespaces, separated by commas,
+// of the Policies that can reference the Secret from another namespace. The value "*" allows all namespaces.
+const AllowedNamespacesAnnotation = "nginx.org/allowed-namespaces"
+
+// OIDCKeysRotatedAtAnnotation is the annotation of a Secret with the keys of an OIDC policy with the time, in the
+// RFC 3339 format, when the keys were rotated. The Ingress Controller sets it on the Secrets of the managed keys,
+// and only manages the Secrets with the annotation.
+const OIDCKeysRotatedAtAnnotation = "nginx.org/oidc-keys-rotated-at"
+
 // HtpasswdFileKey is the key of the data field of a Secret where the HTTP basic authorization list must be stored
 const HtpasswdFileKey = "htpasswd"
 
@@ -37,6 +75,9 @@ const SecretTypeHtpasswd api_v1.SecretType = "nginx.org/htpasswd" // #nosec G101
 // SecretTypeAPIKey contains a list of client ID and key for API key authorization.. #nosec G101
 const SecretTypeAPIKey api_v1.SecretType = "nginx.org/apikey" // #nosec G101
 
+// SecretTypeOIDCSessionKey contains the keys that encrypt the OIDC session cookies. #nosec G101
+const SecretTypeOIDCSessionKey api_v1.SecretType = "nginx.org/oidc-session-key" // #nosec G101
+
 // ValidateTLSSecret validates the secret. If it is valid, the function returns nil.
 func ValidateTLSSecret(secret *api_v1.Secret) error {
 	if secret.Type != api_v1.SecretTypeTLS {
@@ -101,17 +142,87 @@ func ValidateOIDCSecret(secret *api_v1.Secret) error {
 		return fmt.Errorf("OIDC secret must be of the type %v", SecretTypeOIDC)
 	}
 
+	if err := validateOIDCSecretFieldNames(secret); err != nil {
+		return err
+	}
+
 	clientSecret, exists := secret.Data[ClientSecretKey]
 	if !exists {
 		return fmt.Errorf("OIDC secret must have the data field %v", ClientSecretKey)
 	}
+	if err := validateOIDCSecretValue(clientSecret); err != nil {
+		return fmt.Errorf("OIDC client secret is invalid: %w", err)
+	}
 
-	if msg, ok := isValidClientSecretValue(string(clientSecret)); !ok {
-		return fmt.Errorf("OIDC client secret is invalid: %s", msg)
+	if stateKey, exists := secret.Data[OIDCStateKey]; exists {
+		if err := validateOIDCSecretValue(stateKey); err != nil {
+			return fmt.Errorf("OIDC state key is invalid: %w", err)
+		}
 	}
 	return nil
 }
 
+// oidcSecretFieldName returns the name of a data field of an OIDC secret without case, dashes, underscores and
+// spaces, so that the misspellings of the fields, like client_secret or clientSecret, have the same name.
+func oidcSecretFieldName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, name)
+}
+
+// validateOIDCSecretFieldNames rejects the data fields of an OIDC secret that are misspellings of its fields. The
+// Ingress Controller ignores the other fields, so a client secret under client_secret wouldn't be used.
+func validateOIDCSecretFieldNames(secret *api_v1.Secret) error {
+	names := make([]string, 0, len(secret.Data))
+	for name := range secret.Data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, field := range []string{ClientSecretKey, OIDCStateKey} {
+			if name != field && oidcSecretFieldName(name) == oidcSecretFieldName(field) {
+				return fmt.Errorf("OIDC secret has the data field %q, which must be named %v", name, field)
+			}
+		}
+	}
+	return nil
+}
+
+// validateOIDCSecretValue validates the value of a data field of an OIDC secret. The providers compare the client
+// secret byte by byte, so the whitespace that the tools add to a value, like the newline of echo or of a file,
+// breaks every login without an error of NGINX. The errors explain the common causes.
+func validateOIDCSecretValue(value []byte) error {
+	s := string(value)
+	if s == "" {
+		return fmt.Errorf("must not be empty")
+	}
+	if strings.HasSuffix(s, "\n") || strings.HasSuffix(s, "\r") {
+		return fmt.Errorf("must not end with a newline, create the secret from a literal or with echo -n")
+	}
+	if strings.TrimSpace(s) != s {
+		return fmt.Errorf("must not start or end with whitespace")
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(s); err == nil && strings.HasSuffix(string(decoded), "\n") && isPrintable(string(decoded)) {
+		return fmt.Errorf("is base64 encoded twice, the value decodes to a secret with a newline: set the secret in stringData, or encode it once in data with echo -n")
+	}
+	if msg, ok := isValidClientSecretValue(s); !ok {
+		return fmt.Errorf("%s", msg)
+	}
+	return nil
+}
+
+func isPrintable(s string) bool {
+	for _, r := range strings.TrimRight(s, "\r\n") {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
 // ValidateAPIKeySecret validates the secret. If it is valid, the function returns nil.
 func ValidateAPIKeySecret(secret *api_v1.Secret) error {
 	if secret.Type != SecretTypeAPIKey {
@@ -145,6 +256,52 @@ func ValidateHtpasswdSecret(secret *api_v1.Secret) error {
 	return nil
 }
 
+// ValidateBasicAuthSecret validates the secret. If it is valid, the function returns nil.
+func ValidateBasicAuthSecret(secret *api_v1.Secret) error {
+	if secret.Type != api_v1.SecretTypeBasicAuth {
+		return fmt.Errorf("basic auth secret must be of the type %v", api_v1.SecretTypeBasicAuth)
+	}
+
+	if _, exists := secret.Data[api_v1.BasicAuthPasswordKey]; !exists {
+		return fmt.Errorf("basic auth secret must have the data field %v", api_v1.BasicAuthPasswordKey)
+	}
+
+	return nil
+}
+
+// ValidateOIDCSessionKeySecret validates the secret. If it is valid, the function returns nil.
+func ValidateOIDCSessionKeySecret(secret *api_v1.Secret) error {
+	if secret.Type != SecretTypeOIDCSessionKey {
+		return fmt.Errorf("OIDC session key secret must be of the type %v", SecretTypeOIDCSessionKey)
+	}
+
+	key, exists := secret.Data[OIDCSessionKey]
+	if !exists {
+		return fmt.Errorf("OIDC session key secret must have the data field %v", OIDCSessionKey)
+	}
+	if len(key) < minOIDCSessionKeyLength {
+		return fmt.Errorf("OIDC session key must be at least %d bytes long", minOIDCSessionKeyLength)
+	}
+
+	if previousKey, exists := secret.Data[OIDCPreviousSessionKey]; exists && len(previousKey) < minOIDCSessionKeyLength {
+		return fmt.Errorf("OIDC previous session key must be at least %d bytes long", minOIDCSessionKeyLength)
+	}
+
+	for _, field := range []string{OIDCStateKey, OIDCPreviousStateKey, OIDCHMACKey, OIDCPreviousHMACKey} {
+		key, exists := secret.Data[field]
+		if !exists {
+			continue
+		}
+		if len(key) < minOIDCSessionKeyLength {
+			return fmt.Errorf("OIDC %v must be at least %d bytes long", field, minOIDCSessionKeyLength)
+		}
+		if msg, ok := isValidClientSecretValue(string(key)); !ok {
+			return fmt.Errorf("OIDC %v is invalid: %s", field, msg)
+		}
+	}
+	return nil
+}
+
 // IsSupportedSecretType checks if the secret type is supported.
 func IsSupportedSecretType(secretType api_v1.SecretType) bool {
 	return secretType == api_v1.SecretTypeTLS ||
@@ -152,7 +309,9 @@ func IsSupportedSecretType(secretType api_v1.SecretType) bool {
 		secretType == SecretTypeJWK ||
 		secretType == SecretTypeOIDC ||
 		secretType == SecretTypeHtpasswd ||
-		secretType == SecretTypeAPIKey
+		secretType == SecretTypeAPIKey ||
+		secretType == api_v1.SecretTypeBasicAuth ||
+		secretType == SecretTypeOIDCSessionKey
 }
 
 // ValidateSecret validates the secret. If it is valid, the function returns nil.
@@ -170,11 +329,40 @@ func ValidateSecret(secret *api_v1.Secret) error {
 		return ValidateHtpasswdSecret(secret)
 	case SecretTypeAPIKey:
 		return ValidateAPIKeySecret(secret)
+	case api_v1.SecretTypeBasicAuth:
+		return ValidateBasicAuthSecret(secret)
+	case SecretTypeOIDCSessionKey:
+		return ValidateOIDCSessionKeySecret(secret)
 	}
 
 	return fmt.Errorf("secret is of the unsupported type %v", secret.Type)
 }
 
+// GetReferenceKey returns the key <namespace>/<name> of a Secret referenced from the namespace. The reference is
+// the name of a Secret in the same namespace or <namespace>/<name> of a Secret in another namespace.
+func GetReferenceKey(namespace string, reference string) string {
+	if strings.Contains(reference, "/") {
+		return reference
+	}
+	return namespace + "/" + reference
+}
+
+// ValidateReferenceGrant validates that the secret can be referenced from the namespace. A Secret can always be
+// referenced from its own namespace, and from another namespace only if the namespace is listed in the
+// AllowedNamespacesAnnotation of the Secret.
+func ValidateReferenceGrant(secret *api_v1.Secret, namespace string) error {
+	if secret.Namespace == namespace {
+		return nil
+	}
+	for _, allowed := range strings.Split(secret.Annotations[AllowedNamespacesAnnotation], ",") {
+		allowed = strings.TrimSpace(allowed)
+		if allowed == "*" || allowed == namespace {
+			return nil
+		}
+	}
+	return fmt.Errorf("secret %v/%v doesn't allow references from the namespace %v, the namespace must be listed in the annotation %v", secret.Namespace, secret.Name, namespace, AllowedNamespacesAnnotation)
+}
+
 var clientSecretValueFmtRegexp = regexp.MustCompile(`^([^"$\\\s]|\\[^$])*$`)
 
 func isValidClientSecretValue(s string) (string, bool) {
@@ -202,6 +202,180 @@ func TestValidateHtpasswdSecretFails(t *testing.T) {
 	}
 }
 
+func TestValidateBasicAuthSecret(t *testing.T) {
+	t.Parallel()
+	secret := &v1.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "redis-auth",
+			Namespace: "default",
+		},
+		Type: v1.SecretTypeBasicAuth,
+		Data: map[string][]byte{
+			"username": []byte("nginx"),
+			"password": []byte("secret"),
+		},
+	}
+
+	err := ValidateBasicAuthSecret(secret)
+	if err != nil {
+		t.Errorf("ValidateBasicAuthSecret() returned error %v", err)
+	}
+}
+
+func TestValidateBasicAuthSecretFails(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		secret *v1.Secret
+		msg    string
+	}{
+		{
+			secret: &v1.Secret{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "redis-auth",
+					Namespace: "default",
+				},
+				Type: "some-type",
+				Data: map[string][]byte{
+					"password": []byte("secret"),
+				},
+			},
+			msg: "Incorrect type for basic auth secret",
+		},
+		{
+			secret: &v1.Secret{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "redis-auth",
+					Namespace: "default",
+				},
+				Type: v1.SecretTypeBasicAuth,
+				Data: map[string][]byte{
+					"username": []byte("nginx"),
+				},
+			},
+			msg: "Missing password for basic auth secret",
+		},
+	}
+
+	for _, test := range tests {
+		err := ValidateBasicAuthSecret(test.secret)
+		if err == nil {
+			t.Errorf("ValidateBasicAuthSecret() returned no error for the case of %s", test.msg)
+		}
+	}
+}
+
+func TestValidateOIDCSessionKeySecret(t *testing.T) {
+	t.Parallel()
+	secret := &v1.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "oidc-session-key",
+			Namespace: "default",
+		},
+		Type: SecretTypeOIDCSessionKey,
+		Data: map[string][]byte{
+			"key":          []byte("Nw2Xy7w3RbRzP3o0k4Gq8Tt5Qd9Vv1Ls"),
+			"previous-key": []byte("Kc8Jm2Pq5Rs7Tu9Vw1Xy3Za5Bc7De9Fg"),
+		},
+	}
+
+	err := ValidateOIDCSessionKeySecret(secret)
+	if err != nil {
+		t.Errorf("ValidateOIDCSessionKeySecret() returned error %v", err)
+	}
+
+	// The managed keys of a policy
+	secret.Data["state-key"] = []byte("Qm4Rt6Yu8Io0Pa2Sd4Fg6Hj8Kl0Zx2Cv")
+	secret.Data["previous-state-key"] = []byte("Bn3Mq5We7Rt9Yu1Io3Pa5Sd7Fg9Hj1Kl")
+	secret.Data["hmac-key"] = []byte("Zx4Cv6Bn8Mq0We2Rt4Yu6Io8Pa0Sd2Fg")
+	err = ValidateOIDCSessionKeySecret(secret)
+	if err != nil {
+		t.Errorf("ValidateOIDCSessionKeySecret() returned error %v for the managed keys of a policy", err)
+	}
+}
+
+func TestValidateOIDCSessionKeySecretFails(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		secret *v1.Secret
+		msg    string
+	}{
+		{
+			secret: &v1.Secret{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "oidc-session-key",
+					Namespace: "default",
+				},
+				Type: "some-type",
+				Data: map[string][]byte{
+					"key": []byte("Nw2Xy7w3RbRzP3o0k4Gq8Tt5Qd9Vv1Ls"),
+				},
+			},
+			msg: "Incorrect type for OIDC session key secret",
+		},
+		{
+			secret: &v1.Secret{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "oidc-session-key",
+					Namespace: "default",
+				},
+				Type: SecretTypeOIDCSessionKey,
+				Data: map[string][]byte{
+					"previous-key": []byte("Kc8Jm2Pq5Rs7Tu9Vw1Xy3Za5Bc7De9Fg"),
+				},
+			},
+			msg: "Missing key for OIDC session key secret",
+		},
+		{
+			secret: &v1.Secret{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "oidc-session-key",
+					Namespace: "default",
+				},
+				Type: SecretTypeOIDCSessionKey,
+				Data: map[string][]byte{
+					"key": []byte("short"),
+				},
+			},
+			msg: "Short key for OIDC session key secret",
+		},
+		{
+			secret: &v1.Secret{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "oidc-session-key",
+					Namespace: "default",
+				},
+				Type: SecretTypeOIDCSessionKey,
+				Data: map[string][]byte{
+					"key":          []byte("Nw2Xy7w3RbRzP3o0k4Gq8Tt5Qd9Vv1Ls"),
+					"previous-key": []byte("short"),
+				},
+			},
+			msg: "Short previous key for OIDC session key secret",
+		},
+		{
+			secret: &v1.Secret{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "oidc-policy-oidc-keys",
+					Namespace: "default",
+				},
+				Type: SecretTypeOIDCSessionKey,
+				Data: map[string][]byte{
+					"key":      []byte("Nw2Xy7w3RbRzP3o0k4Gq8Tt5Qd9Vv1Ls"),
+					"hmac-key": []byte("short"),
+				},
+			},
+			msg: "Short HMAC key for OIDC session key secret",
+		},
+	}
+
+	for _, test := range tests {
+		err := ValidateOIDCSessionKeySecret(test.secret)
+		if err == nil {
+			t.Errorf("ValidateOIDCSessionKeySecret() returned no error for the case of %s", test.msg)
+		}
+	}
+}
+
 func TestValidateCASecret(t *testing.T) {
 	t.Parallel()
 	secret := &v1.Secret{
@@ -382,7 +556,7 @@ func TestValidateOIDCSecret(t *testing.T) {
 		},
 		Type: SecretTypeOIDC,
 		Data: map[string][]byte{
-			"client-secret": nil,
+			"client-secret": []byte("secret"),
 		},
 	}
 
@@ -390,6 +564,20 @@ func TestValidateOIDCSecret(t *testing.T) {
 	if err != nil {
 		t.Errorf("ValidateOIDCSecret() returned error %v", err)
 	}
+
+	secret.Data["state-key"] = []byte("state_secret")
+	err = ValidateOIDCSecret(secret)
+	if err != nil {
+		t.Errorf("ValidateOIDCSecret() returned error %v for a secret with a state key", err)
+	}
+
+	// a client secret that is valid base64 is only rejected if it decodes to a value with a newline
+	secret.Data["client-secret"] = []byte("c2VjcmV0")
+	secret.Data["client-id"] = []byte("nginx-plus")
+	err = ValidateOIDCSecret(secret)
+	if err != nil {
+		t.Errorf("ValidateOIDCSecret() returned error %v for a base64 client secret", err)
+	}
 }
 
 func TestValidateOIDCSecretFails(t *testing.T) {
@@ -447,6 +635,113 @@ func TestValidateOIDCSecretFails(t *testing.T) {
 			},
 			msg: "Invalid newline in OIDC client secret",
 		},
+		{
+			secret: &v1.Secret{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "oidc-secret",
+					Namespace: "default",
+				},
+				Type: SecretTypeOIDC,
+				Data: map[string][]byte{
+					"client-secret": []byte("hello"),
+					"state-key":     []byte(""),
+				},
+			},
+			msg: "Empty OIDC state key",
+		},
+		{
+			secret: &v1.Secret{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "oidc-secret",
+					Namespace: "default",
+				},
+				Type: SecretTypeOIDC,
+				Data: map[string][]byte{
+					"client-secret": []byte("hello"),
+					"state-key":     []byte("state$key"),
+				},
+			},
+			msg: "Invalid characters in OIDC state key",
+		},
+		{
+			secret: &v1.Secret{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "oidc-secret",
+					Namespace: "default",
+				},
+				Type: SecretTypeOIDC,
+				Data: map[string][]byte{
+					"client-secret": []byte(""),
+				},
+			},
+			msg: "Empty OIDC client secret",
+		},
+		{
+			secret: &v1.Secret{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "oidc-secret",
+					Namespace: "default",
+				},
+				Type: SecretTypeOIDC,
+				Data: map[string][]byte{
+					"client-secret": []byte("hello\n"),
+				},
+			},
+			msg: "Trailing newline in OIDC client secret",
+		},
+		{
+			secret: &v1.Secret{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "oidc-secret",
+					Namespace: "default",
+				},
+				Type: SecretTypeOIDC,
+				Data: map[string][]byte{
+					"client-secret": []byte(" hello"),
+				},
+			},
+			msg: "Leading space in OIDC client secret",
+		},
+		{
+			secret: &v1.Secret{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "oidc-secret",
+					Namespace: "default",
+				},
+				Type: SecretTypeOIDC,
+				Data: map[string][]byte{
+					"client-secret": []byte("aGVsbG8K"),
+				},
+			},
+			msg: "Base64 encoded twice OIDC client secret",
+		},
+		{
+			secret: &v1.Secret{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "oidc-secret",
+					Namespace: "default",
+				},
+				Type: SecretTypeOIDC,
+				Data: map[string][]byte{
+					"client_secret": []byte("hello"),
+				},
+			},
+			msg: "Misspelled client-secret for OIDC secret",
+		},
+		{
+			secret: &v1.Secret{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "oidc-secret",
+					Namespace: "default",
+				},
+				Type: SecretTypeOIDC,
+				Data: map[string][]byte{
+					"client-secret": []byte("hello"),
+					"stateKey":      []byte("state_secret"),
+				},
+			},
+			msg: "Misspelled state-key for OIDC secret",
+		},
 	}
 
 	for _, test := range tests {
@@ -524,7 +819,7 @@ func TestValidateSecret(t *testing.T) {
 				},
 				Type: SecretTypeOIDC,
 				Data: map[string][]byte{
-					"client-secret": nil,
+					"client-secret": []byte("secret"),
 				},
 			},
 			msg: "Valid OIDC secret",
@@ -655,6 +950,14 @@ func TestHasCorrectSecretType(t *testing.T) {
 			secretType: SecretTypeAPIKey,
 			expected:   true,
 		},
+		{
+			secretType: v1.SecretTypeBasicAuth,
+			expected:   true,
+		},
+		{
+			secretType: SecretTypeOIDCSessionKey,
+			expected:   true,
+		},
 		{
 			secretType: "some-type",
 			expected:   false,
@@ -669,6 +972,72 @@ func TestHasCorrectSecretType(t *testing.T) {
 	}
 }
 
+func TestValidateReferenceGrant(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		allowedNamespaces string
+		namespace         string
+		expected          bool
+		msg               string
+	}{
+		{
+			namespace: "idp",
+			expected:  true,
+			msg:       "same namespace",
+		},
+		{
+			namespace: "app",
+			expected:  false,
+			msg:       "no annotation",
+		},
+		{
+			allowedNamespaces: "team, app",
+			namespace:         "app",
+			expected:          true,
+			msg:               "allowed namespace",
+		},
+		{
+			allowedNamespaces: "team,application",
+			namespace:         "app",
+			expected:          false,
+			msg:               "other namespaces",
+		},
+		{
+			allowedNamespaces: "*",
+			namespace:         "app",
+			expected:          true,
+			msg:               "all namespaces",
+		},
+	}
+
+	for _, test := range tests {
+		secret := &v1.Secret{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:        "oidc-secret",
+				Namespace:   "idp",
+				Annotations: map[string]string{},
+			},
+		}
+		if test.allowedNamespaces != "" {
+			secret.Annotations[AllowedNamespacesAnnotation] = test.allowedNamespaces
+		}
+		err := ValidateReferenceGrant(secret, test.namespace)
+		if (err == nil) != test.expected {
+			t.Errorf("ValidateReferenceGrant() returned %v for the case of %s", err, test.msg)
+		}
+	}
+}
+
+func TestGetReferenceKey(t *testing.T) {
+	t.Parallel()
+	if key := GetReferenceKey("app", "oidc-secret"); key != "app/oidc-secret" {
+		t.Errorf("GetReferenceKey() returned %q, want %q", key, "app/oidc-secret")
+	}
+	if key := GetReferenceKey("app", "idp/oidc-secret"); key != "idp/oidc-secret" {
+		t.Errorf("GetReferenceKey() returned %q, want %q", key, "idp/oidc-secret")
+	}
+}
+
 var (
 	validCert = []byte(`-----BEGIN CERTIFICATE-----
 MIIDLjCCAhYCCQDAOF9tLsaXWjANBgkqhkiG9w0BAQsFADBaMQswCQYDVQQGEwJV
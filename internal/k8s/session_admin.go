@@ -0,0 +1,236 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/nginxinc/kubernetes-ingress/internal/k8s/secrets"
+	"github.com/nginxinc/kubernetes-ingress/internal/oidc"
+	"github.com/nginxinc/kubernetes-ingress/internal/oidc/audit"
+	"github.com/nginxinc/kubernetes-ingress/internal/oidc/session"
+	"github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration"
+	conf_v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1"
+	authn_v1 "k8s.io/api/authentication/v1"
+	authz_v1 "k8s.io/api/authorization/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// oidcSessionsPath is the path of the OIDC session administration endpoint, followed by the namespace and
+// the name of the policy, and the session ID to revoke a session.
+const oidcSessionsPath = "/oidc/sessions/"
+
+// oidcSessionAdminTimeout limits the reviews of the caller and the access to the session store.
+const oidcSessionAdminTimeout = 10 * time.Second
+
+// runOIDCSessionAdmin starts the OIDC session administration server. The server listens only on localhost,
+// and authorizes every request with the Kubernetes credentials of the caller.
+func (lbc *LoadBalancerController) runOIDCSessionAdmin() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+oidcSessionsPath+"{namespace}/{name}", lbc.serveListOIDCSessions)
+	mux.HandleFunc("DELETE "+oidcSessionsPath+"{namespace}/{name}/{id}", lbc.serveRevokeOIDCSession)
+	srv := &http.Server{
+		Addr:         "127.0.0.1:" + strconv.Itoa(lbc.oidcSessionAdminPort),
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+	glog.Infof("Starting the OIDC session administration server on: %v", srv.Addr)
+	glog.Fatal(srv.ListenAndServe())
+}
+
+// serveListOIDCSessions returns the sessions of the OIDC policy in the keyval zones of NGINX.
+// The caller needs the permission to get the policy.
+func (lbc *LoadBalancerController) serveListOIDCSessions(w http.ResponseWriter, r *http.Request) {
+	pol, ok := lbc.authorizeOIDCSessionAdmin(w, r, "get")
+	if !ok {
+		return
+	}
+
+	sessions, err := lbc.configurator.ListOIDCSessions(pol.Namespace + "/" + pol.Name)
+	if err != nil {
+		glog.Warningf("Failed to list the sessions of OIDC policy %v/%v: %v", pol.Namespace, pol.Name, err)
+		http.Error(w, "failed to list the sessions", http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(struct {
+		Sessions []session.Info `json:"sessions"`
+	}{Sessions: sessions})
+}
+
+// serveRevokeOIDCSession revokes a session of the OIDC policy: the session is deleted from the keyval zones
+// and the session store of the policy, and NGINX treats it as logged out. The tokens of the session are revoked
+// at the revocation endpoint of the policy. The caller needs the permission to update the policy.
+func (lbc *LoadBalancerController) serveRevokeOIDCSession(w http.ResponseWriter, r *http.Request) {
+	pol, ok := lbc.authorizeOIDCSessionAdmin(w, r, "update")
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+
+	found := true
+	sess, err := lbc.configurator.RevokeOIDCSession(pol.Namespace+"/"+pol.Name, id)
+	if errors.Is(err, session.ErrNotFound) {
+		found = false
+	} else if err != nil {
+		glog.Warningf("Failed to revoke session %v of OIDC policy %v/%v: %v", id, pol.Namespace, pol.Name, err)
+		http.Error(w, "failed to revoke the session", http.StatusBadGateway)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), oidcSessionAdminTimeout)
+	defer cancel()
+
+	if store, exists := lbc.oidcSessionStore(pol); exists {
+		stored, err := store.Get(ctx, id)
+		if err == nil {
+			err = store.Delete(ctx, id)
+			if !found {
+				sess, found = stored, true
+			}
+		}
+		if err != nil && !errors.Is(err, session.ErrNotFound) {
+			glog.Warningf("Failed to revoke session %v of OIDC policy %v/%v in the session store: %v", id, pol.Namespace, pol.Name, err)
+			http.Error(w, "failed to revoke the session in the session store", http.StatusBadGateway)
+			return
+		}
+	}
+
+	if !found {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	glog.Infof("Revoked session %v of OIDC policy %v/%v", id, pol.Namespace, pol.Name)
+	clientIP, _, _ := net.SplitHostPort(r.RemoteAddr)
+	lbc.configurator.AuditOIDCEvent(audit.Event{
+		Event:    "session_revocation",
+		Result:   "success",
+		Policy:   getResourceKey(&pol.ObjectMeta),
+		SubHash:  audit.HashSub(sess.Subject()),
+		ClientIP: clientIP,
+		Reason:   "session_admin",
+	})
+
+	// The session is already logged out in NGINX, a failure of the provider doesn't fail the revocation
+	if err := lbc.revokeOIDCTokens(ctx, pol, sess); err != nil {
+		glog.Warningf("Failed to revoke the tokens of session %v of OIDC policy %v/%v: %v", id, pol.Namespace, pol.Name, err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// revokeOIDCTokens revokes the tokens of the session at the revocation endpoint of the policy, which is the
+// revocationEndpoint of the policy or the revocation_endpoint of its discovery document. Does nothing if the
+// policy has no revocation endpoint.
+func (lbc *LoadBalancerController) revokeOIDCTokens(ctx context.Context, pol *conf_v1.Policy, sess session.Session) error {
+	if lbc.oidcRefresher == nil {
+		return nil
+	}
+	polKey := getResourceKey(&pol.ObjectMeta)
+	endpoint := pol.Spec.OIDC.RevocationEndpoint
+	if metadata, exists := lbc.oidcRefresher.Metadata(polKey); exists && endpoint == "" {
+		endpoint = metadata.RevocationEndpoint
+	}
+	if endpoint == "" {
+		return nil
+	}
+
+	secretRef := lbc.secretStore.GetSecret(secrets.GetReferenceKey(pol.Namespace, pol.Spec.OIDC.ClientSecret))
+	if secretRef.Error != nil {
+		return fmt.Errorf("client secret: %w", secretRef.Error)
+	}
+	return lbc.oidcRefresher.RevokeTokens(oidc.WithPolicy(ctx, polKey), oidc.Revocation{
+		Endpoint:     endpoint,
+		ClientID:     pol.Spec.OIDC.ClientID,
+		ClientSecret: string(secretRef.Secret.Data[secrets.ClientSecretKey]),
+		RefreshToken: sess.RefreshToken,
+		AccessToken:  sess.AccessToken,
+	})
+}
+
+// oidcSessionStore returns the Redis session store of the policy, if it has one.
+func (lbc *LoadBalancerController) oidcSessionStore(pol *conf_v1.Policy) (session.Store, bool) {
+	if lbc.oidcSessionServer == nil || !hasOIDCSessionStore(pol) {
+		return nil, false
+	}
+	return lbc.oidcSessionServer.Store(getResourceKey(&pol.ObjectMeta))
+}
+
+// authorizeOIDCSessionAdmin authenticates the bearer token of the request with a TokenReview, and checks with a
+// SubjectAccessReview that its user can perform the verb on the policy of the request. It returns the policy,
+// or responds with an error and returns false.
+func (lbc *LoadBalancerController) authorizeOIDCSessionAdmin(w http.ResponseWriter, r *http.Request, verb string) (*conf_v1.Policy, bool) {
+	namespace, name := r.PathValue("namespace"), r.PathValue("name")
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found || token == "" {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "missing bearer token", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), oidcSessionAdminTimeout)
+	defer cancel()
+
+	review, err := lbc.client.AuthenticationV1().TokenReviews().Create(ctx, &authn_v1.TokenReview{
+		Spec: authn_v1.TokenReviewSpec{Token: token},
+	}, meta_v1.CreateOptions{})
+	if err != nil {
+		glog.Warningf("Failed to review the token of an OIDC session administration request: %v", err)
+		http.Error(w, "failed to review the token", http.StatusInternalServerError)
+		return nil, false
+	}
+	if !review.Status.Authenticated {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "invalid bearer token", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	user := review.Status.User
+	extra := make(map[string]authz_v1.ExtraValue, len(user.Extra))
+	for key, value := range user.Extra {
+		extra[key] = authz_v1.ExtraValue(value)
+	}
+	access, err := lbc.client.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authz_v1.SubjectAccessReview{
+		Spec: authz_v1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			Groups: user.Groups,
+			UID:    user.UID,
+			Extra:  extra,
+			ResourceAttributes: &authz_v1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      verb,
+				Group:     configuration.GroupName,
+				Resource:  "policies",
+				Name:      name,
+			},
+		},
+	}, meta_v1.CreateOptions{})
+	if err != nil {
+		glog.Warningf("Failed to review the access of %v to the sessions of OIDC policy %v/%v: %v", user.Username, namespace, name, err)
+		http.Error(w, "failed to review the access", http.StatusInternalServerError)
+		return nil, false
+	}
+	if !access.Status.Allowed {
+		http.Error(w, fmt.Sprintf("user %v can't %v policy %v/%v", user.Username, verb, namespace, name), http.StatusForbidden)
+		return nil, false
+	}
+
+	nsi := lbc.getNamespacedInformer(namespace)
+	if nsi == nil {
+		http.Error(w, "OIDC policy not found", http.StatusNotFound)
+		return nil, false
+	}
+	obj, exists, err := nsi.policyLister.GetByKey(namespace + "/" + name)
+	if err != nil || !exists || obj.(*conf_v1.Policy).Spec.OIDC == nil {
+		http.Error(w, "OIDC policy not found", http.StatusNotFound)
+		return nil, false
+	}
+	return obj.(*conf_v1.Policy), true
+}
@@ -0,0 +1,211 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-ingress/internal/configs"
+	"github.com/nginxinc/kubernetes-ingress/internal/k8s/secrets"
+	"github.com/nginxinc/kubernetes-ingress/internal/nginx"
+	"github.com/nginxinc/kubernetes-ingress/internal/oidc"
+	"github.com/nginxinc/kubernetes-ingress/internal/oidc/session"
+	conf_v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1"
+	authn_v1 "k8s.io/api/authentication/v1"
+	authz_v1 "k8s.io/api/authorization/v1"
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+)
+
+// newOIDCSessionAdminController returns a controller with the OIDC policy default/oidc-policy and the access policy
+// default/access-policy. The token "admin-token" authenticates the user admin, who can get and update the policies,
+// and the token "viewer-token" the user viewer, who can only get them.
+func newOIDCSessionAdminController() *LoadBalancerController {
+	policies := map[string]*conf_v1.Policy{
+		"default/oidc-policy": {
+			ObjectMeta: meta_v1.ObjectMeta{Name: "oidc-policy", Namespace: "default"},
+			Spec:       conf_v1.PolicySpec{OIDC: &conf_v1.OIDC{ClientID: "nginx-plus"}},
+		},
+		"default/access-policy": {
+			ObjectMeta: meta_v1.ObjectMeta{Name: "access-policy", Namespace: "default"},
+			Spec:       conf_v1.PolicySpec{AccessControl: &conf_v1.AccessControl{Allow: []string{"127.0.0.1"}}},
+		},
+	}
+	policyLister := &cache.FakeCustomStore{
+		GetByKeyFunc: func(key string) (interface{}, bool, error) {
+			pol, exists := policies[key]
+			return pol, exists, nil
+		},
+	}
+
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authn_v1.TokenReview)
+		switch review.Spec.Token {
+		case "admin-token":
+			review.Status = authn_v1.TokenReviewStatus{Authenticated: true, User: authn_v1.UserInfo{Username: "admin"}}
+		case "viewer-token":
+			review.Status = authn_v1.TokenReviewStatus{Authenticated: true, User: authn_v1.UserInfo{Username: "viewer"}}
+		}
+		return true, review, nil
+	})
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authz_v1.SubjectAccessReview)
+		attrs := review.Spec.ResourceAttributes
+		review.Status.Allowed = attrs.Group == "k8s.nginx.org" && attrs.Resource == "policies" &&
+			(review.Spec.User == "admin" || (review.Spec.User == "viewer" && attrs.Verb == "get"))
+		return true, review, nil
+	})
+
+	return &LoadBalancerController{
+		client:              client,
+		namespacedInformers: map[string]*namespacedInformer{"": {policyLister: policyLister}},
+		configurator: configs.NewConfigurator(configs.ConfiguratorParams{
+			NginxManager:    nginx.NewFakeManager("/etc/nginx"),
+			StaticCfgParams: &configs.StaticConfigParams{},
+			Config:          &configs.ConfigParams{},
+			IsPlus:          true,
+		}),
+	}
+}
+
+func TestServeListOIDCSessions(t *testing.T) {
+	t.Parallel()
+	lbc := newOIDCSessionAdminController()
+
+	req := httptest.NewRequest(http.MethodGet, "/oidc/sessions/default/oidc-policy", nil)
+	req.SetPathValue("namespace", "default")
+	req.SetPathValue("name", "oidc-policy")
+	req.Header.Set("Authorization", "Bearer viewer-token")
+	rec := httptest.NewRecorder()
+	lbc.serveListOIDCSessions(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("serveListOIDCSessions() returned status %v but expected %v: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var body struct {
+		Sessions []interface{} `json:"sessions"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body.Sessions == nil {
+		t.Errorf("serveListOIDCSessions() returned %q, want an empty list of sessions", rec.Body.String())
+	}
+}
+
+func TestOIDCSessionAdminRejectsUnauthorizedRequests(t *testing.T) {
+	t.Parallel()
+	lbc := newOIDCSessionAdminController()
+
+	tests := []struct {
+		method         string
+		policy         string
+		token          string
+		expectedStatus int
+		msg            string
+	}{
+		{
+			method:         http.MethodGet,
+			policy:         "oidc-policy",
+			expectedStatus: http.StatusUnauthorized,
+			msg:            "request without a token",
+		},
+		{
+			method:         http.MethodGet,
+			policy:         "oidc-policy",
+			token:          "expired-token",
+			expectedStatus: http.StatusUnauthorized,
+			msg:            "request with an invalid token",
+		},
+		{
+			method:         http.MethodDelete,
+			policy:         "oidc-policy",
+			token:          "viewer-token",
+			expectedStatus: http.StatusForbidden,
+			msg:            "revocation by a user who can't update the policy",
+		},
+		{
+			method:         http.MethodGet,
+			policy:         "access-policy",
+			token:          "admin-token",
+			expectedStatus: http.StatusNotFound,
+			msg:            "policy without OIDC",
+		},
+		{
+			method:         http.MethodDelete,
+			policy:         "oidc-policy",
+			token:          "admin-token",
+			expectedStatus: http.StatusNotFound,
+			msg:            "revocation of a session that doesn't exist",
+		},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, "/oidc/sessions/default/"+test.policy+"/session-1", nil)
+		req.SetPathValue("namespace", "default")
+		req.SetPathValue("name", test.policy)
+		req.SetPathValue("id", "session-1")
+		if test.token != "" {
+			req.Header.Set("Authorization", "Bearer "+test.token)
+		}
+		rec := httptest.NewRecorder()
+		if test.method == http.MethodGet {
+			lbc.serveListOIDCSessions(rec, req)
+		} else {
+			lbc.serveRevokeOIDCSession(rec, req)
+		}
+		if rec.Code != test.expectedStatus {
+			t.Errorf("%s returned status %v but expected %v for the case of %s: %s", test.method, rec.Code, test.expectedStatus, test.msg, rec.Body.String())
+		}
+	}
+}
+
+func TestRevokeOIDCTokens(t *testing.T) {
+	t.Parallel()
+	var revoked []string
+	ts := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		if r.FormValue("client_secret") != "super_secret_123" {
+			t.Errorf("revocation request has client secret %q, want the client secret of the policy", r.FormValue("client_secret"))
+		}
+		revoked = append(revoked, r.FormValue("token"))
+	}))
+	defer ts.Close()
+
+	lbc := newOIDCSessionAdminController()
+	lbc.oidcRefresher = oidc.NewRefresher(ts.Client(), t.TempDir(), func(string) {}, func(string, error) {})
+	lbc.secretStore = secrets.NewFakeSecretsStore(map[string]*secrets.SecretReference{
+		"default/oidc-secret": {
+			Secret: &api_v1.Secret{
+				Type: secrets.SecretTypeOIDC,
+				Data: map[string][]byte{secrets.ClientSecretKey: []byte("super_secret_123")},
+			},
+		},
+	})
+	pol := &conf_v1.Policy{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "oidc-policy", Namespace: "default"},
+		Spec: conf_v1.PolicySpec{OIDC: &conf_v1.OIDC{
+			ClientID:           "nginx-plus",
+			ClientSecret:       "oidc-secret",
+			RevocationEndpoint: ts.URL + "/revoke",
+		}},
+	}
+	sess := session.Session{IDToken: "id-token", AccessToken: "access-token", RefreshToken: "refresh-token"}
+
+	if err := lbc.revokeOIDCTokens(context.Background(), pol, sess); err != nil {
+		t.Fatalf("revokeOIDCTokens() returned %v", err)
+	}
+	if len(revoked) != 2 || revoked[0] != "refresh-token" || revoked[1] != "access-token" {
+		t.Errorf("revokeOIDCTokens() revoked %v, want the refresh token and the access token", revoked)
+	}
+
+	// a policy without a revocation endpoint
+	revoked = nil
+	pol.Spec.OIDC.RevocationEndpoint = ""
+	if err := lbc.revokeOIDCTokens(context.Background(), pol, sess); err != nil || len(revoked) != 0 {
+		t.Errorf("revokeOIDCTokens() returned %v and revoked %v, want no revocation", err, revoked)
+	}
+}
@@ -716,6 +716,39 @@ func (su *statusUpdater) UpdatePolicyStatus(pol *conf_v1.Policy, state string, r
 	return nil
 }
 
+// UpdatePolicyIdPStatus updates the reachability of the provider of an OIDC policy in the status of the Policy.
+func (su *statusUpdater) UpdatePolicyIdPStatus(pol *conf_v1.Policy, idp *conf_v1.PolicyIdPStatus) error {
+	polLatest, exists, err := su.getNamespacedInformer(pol.Namespace).policyLister.Get(pol)
+	if err != nil {
+		glog.V(3).Infof("error getting policy from Store: %v", err)
+		return err
+	}
+	if !exists {
+		glog.V(3).Infof("Policy doesn't exist in Store")
+		return nil
+	}
+
+	if !su.hasCorrectIngressClass(polLatest) {
+		glog.V(3).Infof("ignoring policy with incorrect ingress class")
+		return nil
+	}
+
+	polCopy := polLatest.(*conf_v1.Policy).DeepCopy()
+	if current := polCopy.Status.IdP; current != nil && idp != nil &&
+		current.TokenEndpointReachable == idp.TokenEndpointReachable && current.JWKSReachable == idp.JWKSReachable && current.Message == idp.Message {
+		return nil
+	}
+	polCopy.Status.IdP = idp
+
+	_, err = su.confClient.K8sV1().Policies(polCopy.Namespace).UpdateStatus(context.TODO(), polCopy, metav1.UpdateOptions{})
+	if err != nil {
+		glog.V(3).Infof("error setting Policy %v/%v status, retrying: %v", polCopy.Namespace, polCopy.Name, err)
+		return su.retryUpdatePolicyStatus(polCopy)
+	}
+
+	return nil
+}
+
 func (su *statusUpdater) retryUpdatePolicyStatus(polCopy *conf_v1.Policy) error {
 	pol, err := su.confClient.K8sV1().Policies(polCopy.Namespace).Get(context.TODO(), polCopy.Name, metav1.GetOptions{})
 	if err != nil {
@@ -10,6 +10,14 @@ type ControllerCollector interface {
 	SetVirtualServers(count int)
 	SetVirtualServerRoutes(count int)
 	SetTransportServers(tlsPassthroughCount, tcpCount, udpCount int)
+	AddOIDCSessionEntriesSwept(zone string, count int)
+	SetOIDCProviderReachable(policy string, endpoint string, reachable bool)
+	DeleteOIDCProvider(policy string)
+	SetOIDCSessions(policy string, sessions int, refreshTokens int, pendingRefreshes int)
+	DeleteOIDCSessions(policy string)
+	SetOIDCLoginLockouts(policy string, failingClients int, lockedOutClients int)
+	SetOIDCKeyValZone(zone string, entries int)
+	SetOIDCKeyValZoneMemoryUtilization(zone string, utilization float64)
 	Register(registry *prometheus.Registry) error
 }
 
@@ -20,6 +28,15 @@ type ControllerMetricsCollector struct {
 	virtualServersTotal      prometheus.Gauge
 	virtualServerRoutesTotal prometheus.Gauge
 	transportServersTotal    *prometheus.GaugeVec
+	oidcSessionEntriesSwept  *prometheus.CounterVec
+	oidcProviderReachable    *prometheus.GaugeVec
+	oidcSessions             *prometheus.GaugeVec
+	oidcRefreshTokens        *prometheus.GaugeVec
+	oidcPendingRefreshes     *prometheus.GaugeVec
+	oidcLoginFailingClients  *prometheus.GaugeVec
+	oidcLoginLockouts        *prometheus.GaugeVec
+	oidcKeyValZoneEntries    *prometheus.GaugeVec
+	oidcKeyValZoneMemory     *prometheus.GaugeVec
 }
 
 // NewControllerMetricsCollector creates a new ControllerMetricsCollector
@@ -67,12 +84,111 @@ func NewControllerMetricsCollector(crdsEnabled bool, constLabels map[string]stri
 		)
 	}
 
+	oidcSessionEntriesSwept := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:        "oidc_session_entries_swept_total",
+			Namespace:   metricsNamespace,
+			Help:        "Number of expired entries deleted from the OIDC keyval zones",
+			ConstLabels: constLabels,
+		},
+		[]string{"zone"},
+	)
+
+	oidcProviderReachable := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "oidc_provider_reachable",
+			Namespace:   metricsNamespace,
+			Help:        "Reachability of the endpoints of the provider of an OIDC policy, 1 if reachable, 0 otherwise",
+			ConstLabels: constLabels,
+		},
+		[]string{"policy", "endpoint"},
+	)
+
+	oidcSessions := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "oidc_sessions",
+			Namespace:   metricsNamespace,
+			Help:        "Number of logged in sessions of an OIDC policy in the keyval zones",
+			ConstLabels: constLabels,
+		},
+		[]string{"policy"},
+	)
+
+	oidcRefreshTokens := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "oidc_session_refresh_tokens",
+			Namespace:   metricsNamespace,
+			Help:        "Number of sessions of an OIDC policy with a refresh token in the keyval zones",
+			ConstLabels: constLabels,
+		},
+		[]string{"policy"},
+	)
+
+	oidcPendingRefreshes := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "oidc_session_pending_refreshes",
+			Namespace:   metricsNamespace,
+			Help:        "Number of sessions of an OIDC policy that are being refreshed",
+			ConstLabels: constLabels,
+		},
+		[]string{"policy"},
+	)
+
+	oidcLoginFailingClients := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "oidc_login_failing_clients",
+			Namespace:   metricsNamespace,
+			Help:        "Number of client IP addresses with failed logins of an OIDC policy with a login lockout that aren't locked out",
+			ConstLabels: constLabels,
+		},
+		[]string{"policy"},
+	)
+
+	oidcLoginLockouts := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "oidc_login_lockouts",
+			Namespace:   metricsNamespace,
+			Help:        "Number of client IP addresses locked out of the logins of an OIDC policy",
+			ConstLabels: constLabels,
+		},
+		[]string{"policy"},
+	)
+
+	oidcKeyValZoneEntries := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "oidc_keyval_zone_entries",
+			Namespace:   metricsNamespace,
+			Help:        "Number of entries of an OIDC keyval zone",
+			ConstLabels: constLabels,
+		},
+		[]string{"zone"},
+	)
+
+	oidcKeyValZoneMemory := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "oidc_keyval_zone_memory_utilization",
+			Namespace:   metricsNamespace,
+			Help:        "Share of the memory pages of an OIDC keyval zone that are used, from 0 to 1",
+			ConstLabels: constLabels,
+		},
+		[]string{"zone"},
+	)
+
 	c := &ControllerMetricsCollector{
 		crdsEnabled:              crdsEnabled,
 		ingressesTotal:           ingResTotal,
 		virtualServersTotal:      vsResTotal,
 		virtualServerRoutesTotal: vsrResTotal,
 		transportServersTotal:    tsResTotal,
+		oidcSessionEntriesSwept:  oidcSessionEntriesSwept,
+		oidcProviderReachable:    oidcProviderReachable,
+		oidcSessions:             oidcSessions,
+		oidcRefreshTokens:        oidcRefreshTokens,
+		oidcPendingRefreshes:     oidcPendingRefreshes,
+		oidcLoginFailingClients:  oidcLoginFailingClients,
+		oidcLoginLockouts:        oidcLoginLockouts,
+		oidcKeyValZoneEntries:    oidcKeyValZoneEntries,
+		oidcKeyValZoneMemory:     oidcKeyValZoneMemory,
 	}
 
 	// if we don't set to 0 metrics with the label type, the metrics will not be created initially
@@ -110,9 +226,70 @@ func (cc *ControllerMetricsCollector) SetTransportServers(tlsPassthroughCount, t
 	cc.transportServersTotal.WithLabelValues("udp").Set(float64(udpCount))
 }
 
+// AddOIDCSessionEntriesSwept adds the number of entries deleted from an OIDC keyval zone by the session sweeper
+func (cc *ControllerMetricsCollector) AddOIDCSessionEntriesSwept(zone string, count int) {
+	cc.oidcSessionEntriesSwept.WithLabelValues(zone).Add(float64(count))
+}
+
+// SetOIDCProviderReachable sets the reachability of an endpoint of the provider of an OIDC policy
+func (cc *ControllerMetricsCollector) SetOIDCProviderReachable(policy string, endpoint string, reachable bool) {
+	value := 0.0
+	if reachable {
+		value = 1
+	}
+	cc.oidcProviderReachable.WithLabelValues(policy, endpoint).Set(value)
+}
+
+// DeleteOIDCProvider deletes the reachability metrics of the provider of an OIDC policy
+func (cc *ControllerMetricsCollector) DeleteOIDCProvider(policy string) {
+	cc.oidcProviderReachable.DeletePartialMatch(prometheus.Labels{"policy": policy})
+}
+
+// SetOIDCSessions sets the number of sessions of an OIDC policy in the keyval zones
+func (cc *ControllerMetricsCollector) SetOIDCSessions(policy string, sessions int, refreshTokens int, pendingRefreshes int) {
+	cc.oidcSessions.WithLabelValues(policy).Set(float64(sessions))
+	cc.oidcRefreshTokens.WithLabelValues(policy).Set(float64(refreshTokens))
+	cc.oidcPendingRefreshes.WithLabelValues(policy).Set(float64(pendingRefreshes))
+}
+
+// DeleteOIDCSessions deletes the session metrics of an OIDC policy
+func (cc *ControllerMetricsCollector) DeleteOIDCSessions(policy string) {
+	cc.oidcSessions.DeleteLabelValues(policy)
+	cc.oidcRefreshTokens.DeleteLabelValues(policy)
+	cc.oidcPendingRefreshes.DeleteLabelValues(policy)
+	cc.oidcLoginFailingClients.DeleteLabelValues(policy)
+	cc.oidcLoginLockouts.DeleteLabelValues(policy)
+}
+
+// SetOIDCLoginLockouts sets the number of the clients with failed logins and of the locked out clients of an
+// OIDC policy
+func (cc *ControllerMetricsCollector) SetOIDCLoginLockouts(policy string, failingClients int, lockedOutClients int) {
+	cc.oidcLoginFailingClients.WithLabelValues(policy).Set(float64(failingClients))
+	cc.oidcLoginLockouts.WithLabelValues(policy).Set(float64(lockedOutClients))
+}
+
+// SetOIDCKeyValZone sets the number of entries of an OIDC keyval zone
+func (cc *ControllerMetricsCollector) SetOIDCKeyValZone(zone string, entries int) {
+	cc.oidcKeyValZoneEntries.WithLabelValues(zone).Set(float64(entries))
+}
+
+// SetOIDCKeyValZoneMemoryUtilization sets the memory utilization of an OIDC keyval zone
+func (cc *ControllerMetricsCollector) SetOIDCKeyValZoneMemoryUtilization(zone string, utilization float64) {
+	cc.oidcKeyValZoneMemory.WithLabelValues(zone).Set(utilization)
+}
+
 // Describe implements prometheus.Collector interface Describe method
 func (cc *ControllerMetricsCollector) Describe(ch chan<- *prometheus.Desc) {
 	cc.ingressesTotal.Describe(ch)
+	cc.oidcSessionEntriesSwept.Describe(ch)
+	cc.oidcProviderReachable.Describe(ch)
+	cc.oidcSessions.Describe(ch)
+	cc.oidcRefreshTokens.Describe(ch)
+	cc.oidcPendingRefreshes.Describe(ch)
+	cc.oidcLoginFailingClients.Describe(ch)
+	cc.oidcLoginLockouts.Describe(ch)
+	cc.oidcKeyValZoneEntries.Describe(ch)
+	cc.oidcKeyValZoneMemory.Describe(ch)
 	if cc.crdsEnabled {
 		cc.virtualServersTotal.Describe(ch)
 		cc.virtualServerRoutesTotal.Describe(ch)
@@ -123,6 +300,15 @@ func (cc *ControllerMetricsCollector) Describe(ch chan<- *prometheus.Desc) {
 // Collect implements the prometheus.Collector interface Collect method
 func (cc *ControllerMetricsCollector) Collect(ch chan<- prometheus.Metric) {
 	cc.ingressesTotal.Collect(ch)
+	cc.oidcSessionEntriesSwept.Collect(ch)
+	cc.oidcProviderReachable.Collect(ch)
+	cc.oidcSessions.Collect(ch)
+	cc.oidcRefreshTokens.Collect(ch)
+	cc.oidcPendingRefreshes.Collect(ch)
+	cc.oidcLoginFailingClients.Collect(ch)
+	cc.oidcLoginLockouts.Collect(ch)
+	cc.oidcKeyValZoneEntries.Collect(ch)
+	cc.oidcKeyValZoneMemory.Collect(ch)
 	if cc.crdsEnabled {
 		cc.virtualServersTotal.Collect(ch)
 		cc.virtualServerRoutesTotal.Collect(ch)
@@ -157,3 +343,27 @@ func (cc *ControllerFakeCollector) SetVirtualServerRoutes(_ int) {}
 
 // SetTransportServers implements a fake SetTransportServers
 func (cc *ControllerFakeCollector) SetTransportServers(int, int, int) {}
+
+// AddOIDCSessionEntriesSwept implements a fake AddOIDCSessionEntriesSwept
+func (cc *ControllerFakeCollector) AddOIDCSessionEntriesSwept(string, int) {}
+
+// SetOIDCProviderReachable implements a fake SetOIDCProviderReachable
+func (cc *ControllerFakeCollector) SetOIDCProviderReachable(string, string, bool) {}
+
+// DeleteOIDCProvider implements a fake DeleteOIDCProvider
+func (cc *ControllerFakeCollector) DeleteOIDCProvider(string) {}
+
+// SetOIDCSessions implements a fake SetOIDCSessions
+func (cc *ControllerFakeCollector) SetOIDCSessions(string, int, int, int) {}
+
+// DeleteOIDCSessions implements a fake DeleteOIDCSessions
+func (cc *ControllerFakeCollector) DeleteOIDCSessions(string) {}
+
+// SetOIDCLoginLockouts implements a fake SetOIDCLoginLockouts
+func (cc *ControllerFakeCollector) SetOIDCLoginLockouts(string, int, int) {}
+
+// SetOIDCKeyValZone implements a fake SetOIDCKeyValZone
+func (cc *ControllerFakeCollector) SetOIDCKeyValZone(string, int) {}
+
+// SetOIDCKeyValZoneMemoryUtilization implements a fake SetOIDCKeyValZoneMemoryUtilization
+func (cc *ControllerFakeCollector) SetOIDCKeyValZoneMemoryUtilization(string, float64) {}
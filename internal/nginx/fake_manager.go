@@ -78,6 +78,24 @@ func (*FakeManager) CreateTLSPassthroughHostsConfig(_ []byte) bool {
 	return false
 }
 
+// ReadOIDCConfig provides a fake implementation of ReadOIDCConfig.
+func (*FakeManager) ReadOIDCConfig() ([]byte, error) {
+	glog.V(3).Info("Reading OIDC config")
+	return []byte{}, nil
+}
+
+// CreateOIDCConfig provides a fake implementation of CreateOIDCConfig.
+func (*FakeManager) CreateOIDCConfig(name string, content []byte) bool {
+	glog.V(3).Infof("Writing OIDC config %v", name)
+	glog.V(3).Info(string(content))
+	return true
+}
+
+// DeleteOIDCConfig provides a fake implementation of DeleteOIDCConfig.
+func (*FakeManager) DeleteOIDCConfig(name string) {
+	glog.V(3).Infof("Deleting OIDC config %v", name)
+}
+
 // CreateSecret provides a fake implementation of CreateSecret.
 func (fm *FakeManager) CreateSecret(name string, _ []byte, _ os.FileMode) string {
 	glog.V(3).Infof("Writing secret %v", name)
@@ -203,3 +221,26 @@ func (fm *FakeManager) UpsertSplitClientsKeyVal(_ string, _ string, _ string) {
 func (fm *FakeManager) DeleteKeyValStateFiles(_ string) {
 	glog.V(3).Infof("Deleting keyval state files")
 }
+
+// GetKeyValPairs is a fake implementation of GetKeyValPairs
+func (fm *FakeManager) GetKeyValPairs(_ string) (map[string]string, error) {
+	glog.V(3).Infof("Getting key value pairs")
+	return map[string]string{}, nil
+}
+
+// UpsertKeyValPair is a fake implementation of UpsertKeyValPair
+func (fm *FakeManager) UpsertKeyValPair(_ string, _ string, _ string) error {
+	glog.V(3).Infof("Upserting key value pair")
+	return nil
+}
+
+// DeleteKeyValPair is a fake implementation of DeleteKeyValPair
+func (fm *FakeManager) DeleteKeyValPair(_ string, _ string) {
+	glog.V(3).Infof("Deleting key value pair")
+}
+
+// GetZoneMemoryUtilization is a fake implementation of GetZoneMemoryUtilization
+func (fm *FakeManager) GetZoneMemoryUtilization(_ []string) (map[string]float64, error) {
+	glog.V(3).Infof("Getting the memory utilization of the zones")
+	return map[string]float64{}, nil
+}
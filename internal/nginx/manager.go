@@ -71,6 +71,9 @@ type Manager interface {
 	CreateStreamConfig(name string, content []byte) bool
 	DeleteStreamConfig(name string)
 	CreateTLSPassthroughHostsConfig(content []byte) bool
+	ReadOIDCConfig() ([]byte, error)
+	CreateOIDCConfig(name string, content []byte) bool
+	DeleteOIDCConfig(name string)
 	CreateSecret(name string, content []byte, mode os.FileMode) string
 	DeleteSecret(name string)
 	CreateAppProtectResourceFile(name string, content []byte)
@@ -98,6 +101,10 @@ type Manager interface {
 	GetSecretsDir() string
 	UpsertSplitClientsKeyVal(zoneName string, key string, value string)
 	DeleteKeyValStateFiles(virtualServerName string)
+	GetKeyValPairs(zoneName string) (map[string]string, error)
+	UpsertKeyValPair(zoneName string, key string, value string) error
+	DeleteKeyValPair(zoneName string, key string)
+	GetZoneMemoryUtilization(zoneNames []string) (map[string]float64, error)
 }
 
 // LocalManager updates NGINX configuration, starts, reloads and quits NGINX,
@@ -112,6 +119,7 @@ type LocalManager struct {
 	debug                        bool
 	dhparamFilename              string
 	tlsPassthroughHostsFilename  string
+	oidcPath                     string
 	verifyConfigGenerator        *verifyConfigGenerator
 	verifyClient                 *verifyClient
 	configVersion                int
@@ -140,6 +148,7 @@ func NewLocalManager(confPath string, debug bool, mc collectors.ManagerCollector
 		mainConfFilename:            path.Join(confPath, "nginx.conf"),
 		configVersionFilename:       path.Join(confPath, "config-version.conf"),
 		tlsPassthroughHostsFilename: path.Join(confPath, "tls-passthrough-hosts.conf"),
+		oidcPath:                    path.Join(confPath, "oidc"),
 		debug:                       debug,
 		verifyConfigGenerator:       verifyConfigGenerator,
 		configVersion:               0,
@@ -220,6 +229,22 @@ func (lm *LocalManager) CreateTLSPassthroughHostsConfig(content []byte) bool {
 	return createConfig(lm.tlsPassthroughHostsFilename, content)
 }
 
+// ReadOIDCConfig reads oidc.conf, the configuration file with the OIDC locations.
+func (lm *LocalManager) ReadOIDCConfig() ([]byte, error) {
+	return os.ReadFile(path.Join(lm.oidcPath, "oidc.conf"))
+}
+
+// CreateOIDCConfig creates a configuration file with OIDC locations in the oidc folder, next to oidc.conf.
+// If the file already exists, it will be overridden.
+func (lm *LocalManager) CreateOIDCConfig(name string, content []byte) bool {
+	return createConfig(path.Join(lm.oidcPath, name+".conf"), content)
+}
+
+// DeleteOIDCConfig deletes the configuration file with OIDC locations in the oidc folder.
+func (lm *LocalManager) DeleteOIDCConfig(name string) {
+	deleteConfig(path.Join(lm.oidcPath, name+".conf"))
+}
+
 // CreateSecret creates a secret file with the specified name, content and mode. If the file already exists,
 // it will be overridden.
 func (lm *LocalManager) CreateSecret(name string, content []byte, mode os.FileMode) string {
@@ -669,6 +694,54 @@ func (lm *LocalManager) tryModifyKeyValPair(zoneName, key, value string) {
 	}
 }
 
+// GetKeyValPairs returns the key value pairs of the keyval zone.
+func (lm *LocalManager) GetKeyValPairs(zoneName string) (map[string]string, error) {
+	keyValPairs, err := lm.plusClient.GetKeyValPairs(zoneName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get key value pairs of zone %v: %w", zoneName, err)
+	}
+	return keyValPairs, nil
+}
+
+// UpsertKeyValPair modifies the key value pair with the key in the keyval zone, or adds it if it doesn't exist.
+func (lm *LocalManager) UpsertKeyValPair(zoneName, key, value string) error {
+	if err := lm.plusClient.ModifyKeyValPair(zoneName, key, value); err == nil {
+		return nil
+	}
+	if err := lm.plusClient.AddKeyValPair(zoneName, key, value); err != nil {
+		return fmt.Errorf("failed to upsert key value pair in zone %v: %w", zoneName, err)
+	}
+	return nil
+}
+
+// DeleteKeyValPair deletes the key value pair with the key from the keyval zone.
+func (lm *LocalManager) DeleteKeyValPair(zoneName, key string) {
+	err := lm.plusClient.DeleteKeyValuePair(zoneName, key)
+	if err != nil {
+		glog.Warningf("Failed to delete key value pair: %v", err)
+	} else {
+		glog.V(3).Infof("Deleted key value pair for key: %v", key)
+	}
+}
+
+// GetZoneMemoryUtilization returns the share of the memory pages of every shared memory zone that are used,
+// from 0 to 1. The zones that NGINX doesn't report are omitted.
+func (lm *LocalManager) GetZoneMemoryUtilization(zoneNames []string) (map[string]float64, error) {
+	slabs, err := lm.plusClient.GetSlabs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the memory usage of the zones: %w", err)
+	}
+	utilization := make(map[string]float64)
+	for _, zoneName := range zoneNames {
+		slab, exists := (*slabs)[zoneName]
+		if !exists || slab.Pages.Used+slab.Pages.Free == 0 {
+			continue
+		}
+		utilization[zoneName] = float64(slab.Pages.Used) / float64(slab.Pages.Used+slab.Pages.Free)
+	}
+	return utilization, nil
+}
+
 // DeleteKeyValStateFiles deletes the state files in the /etc/nginx/state_files folder for the given virtual server.
 func (lm *LocalManager) DeleteKeyValStateFiles(virtualServerName string) {
 	files, err := os.ReadDir(lm.stateFilesPath)
@@ -0,0 +1,131 @@
+// Package audit writes the audit log of the OIDC authentication events, for the ingestion by a SIEM.
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/syslog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const timeFormat = "2006-01-02T15:04:05.000Z07:00" // Like Date.toISOString() in openid_connect.js
+
+// Event is an OIDC authentication event of the audit log. NGINX logs the events of the authentication flow
+// with the same fields, see audit() in openid_connect.js.
+type Event struct {
+	Time      string `json:"time"`
+	Event     string `json:"event"`
+	Result    string `json:"result"`
+	Policy    string `json:"policy,omitempty"`
+	SubHash   string `json:"sub_hash,omitempty"`
+	ClientIP  string `json:"client_ip,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// HashSub returns the SHA-256 hash of the subject in hex, so that the events of a user can be correlated
+// without logging the subject.
+func HashSub(sub string) string {
+	if sub == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(sub))
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidateDestination validates the destination of the audit log, which is stderr, the absolute path of a file
+// or a syslog server as syslog:server=<host>:<port>.
+func ValidateDestination(dest string) error {
+	if dest == "stderr" || strings.HasPrefix(dest, "/") {
+		return nil
+	}
+	addr, ok := strings.CutPrefix(dest, "syslog:server=")
+	if !ok {
+		return fmt.Errorf("destination must be stderr, an absolute path or syslog:server=<host>:<port>, got %q", dest)
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host == "" || strings.ContainsAny(addr, ", ") {
+		return fmt.Errorf("invalid syslog server %q", addr)
+	}
+	if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+		return fmt.Errorf("invalid port of syslog server %q", addr)
+	}
+	return nil
+}
+
+// Logger writes the events to a destination, which is opened at the first event and opened again when it changes.
+// The zero value is ready to use.
+type Logger struct {
+	mu   sync.Mutex
+	dest string
+	w    io.WriteCloser
+}
+
+// Log writes the event to the destination as a JSON line. The time of the event is set if it is empty.
+func (l *Logger) Log(dest string, e Event) error {
+	if e.Time == "" {
+		e.Time = time.Now().UTC().Format(timeFormat)
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.w == nil || l.dest != dest {
+		if l.w != nil {
+			_ = l.w.Close()
+			l.w = nil
+		}
+		w, err := open(dest)
+		if err != nil {
+			return fmt.Errorf("failed to open the audit log %v: %w", dest, err)
+		}
+		l.w, l.dest = w, dest
+	}
+	_, err = l.w.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the destination.
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.w == nil {
+		return nil
+	}
+	err := l.w.Close()
+	l.w = nil
+	return err
+}
+
+func open(dest string) (io.WriteCloser, error) {
+	if err := ValidateDestination(dest); err != nil {
+		return nil, err
+	}
+	if dest == "stderr" {
+		return nopCloser{os.Stderr}, nil
+	}
+	if addr, ok := strings.CutPrefix(dest, "syslog:server="); ok {
+		// Like the access_log of NGINX, which logs to the syslog server over UDP with the local7 facility
+		return syslog.Dial("udp", addr, syslog.LOG_INFO|syslog.LOG_LOCAL7, "nginx-ingress")
+	}
+	return os.OpenFile(dest, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
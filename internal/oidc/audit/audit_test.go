@@ -0,0 +1,86 @@
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateDestination(t *testing.T) {
+	t.Parallel()
+	for _, dest := range []string{
+		"stderr",
+		"/var/log/nginx/oidc-audit.log",
+		"syslog:server=localhost:514",
+		"syslog:server=10.0.0.1:5144",
+		"syslog:server=syslog.example.com:514",
+	} {
+		if err := ValidateDestination(dest); err != nil {
+			t.Errorf("ValidateDestination(%q) returned %v", dest, err)
+		}
+	}
+
+	for _, dest := range []string{
+		"",
+		"stdout",
+		"var/log/oidc-audit.log",
+		"syslog:server=localhost",
+		"syslog:server=:514",
+		"syslog:server=localhost:0",
+		"syslog:server=localhost:65536",
+		"syslog:server=localhost:514,facility=auth",
+	} {
+		if err := ValidateDestination(dest); err == nil {
+			t.Errorf("ValidateDestination(%q) returned no error", dest)
+		}
+	}
+}
+
+func TestHashSub(t *testing.T) {
+	t.Parallel()
+	// echo -n alice | sha256sum
+	if got, want := HashSub("alice"), "2bd806c97f0e00af1a1fc3328fa763a9269723c8db8fac4f93af71db186d6e90"; got != want {
+		t.Errorf("HashSub() returned %v, want %v", got, want)
+	}
+	if got := HashSub(""); got != "" {
+		t.Errorf("HashSub() returned %v for an empty subject, want an empty hash", got)
+	}
+}
+
+func TestLoggerWritesJSONLines(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	var l Logger
+	defer l.Close()
+
+	first := filepath.Join(dir, "first.log")
+	second := filepath.Join(dir, "second.log")
+	event := Event{Event: "session_revocation", Result: "success", Policy: "default/oidc-policy", SubHash: HashSub("alice")}
+	for _, dest := range []string{first, first, second} {
+		if err := l.Log(dest, event); err != nil {
+			t.Fatalf("Log() returned %v", err)
+		}
+	}
+
+	content, err := os.ReadFile(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Log() wrote %d lines to the first destination, want 2", len(lines))
+	}
+	var got Event
+	if err := json.Unmarshal([]byte(lines[0]), &got); err != nil {
+		t.Fatalf("Log() wrote %q, which is not JSON: %v", lines[0], err)
+	}
+	if got.Time == "" || got.Event != event.Event || got.SubHash != event.SubHash || got.ClientIP != "" {
+		t.Errorf("Log() wrote %+v, want %+v with the time of the event", got, event)
+	}
+
+	if content, err := os.ReadFile(second); err != nil || strings.Count(string(content), "\n") != 1 {
+		t.Errorf("Log() wrote %q to the second destination, want one line", content)
+	}
+}
@@ -0,0 +1,203 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// DefaultHealthCheckInterval is the interval of the probes of the providers.
+const DefaultHealthCheckInterval = 30 * time.Second
+
+// Health is the reachability of the endpoints of the provider of an OIDC policy, from the last probe.
+type Health struct {
+	TokenEndpointReachable bool
+	JWKSReachable          bool
+	// Message describes the failures of the unreachable endpoints.
+	Message string
+}
+
+// Reachable returns true if all the endpoints of the provider are reachable.
+func (h Health) Reachable() bool {
+	return h.TokenEndpointReachable && h.JWKSReachable
+}
+
+// HealthChecker probes the token endpoint and the JWKS URI of every OIDC policy. Unlike the Refresher, it runs on
+// every replica, so that the readiness of a replica reflects its own connectivity to the providers. The probes
+// don't go through the ProviderLimiter, they are already spaced by the interval.
+type HealthChecker struct {
+	httpClient *http.Client
+	interval   time.Duration
+	onChange   func(key string, h Health)
+	ctx        context.Context
+	cancel     context.CancelFunc
+	lock       sync.Mutex
+	targets    map[string]*healthTarget
+}
+
+type healthTarget struct {
+	tokenEndpoint string
+	jwksURI       string
+	cancel        context.CancelFunc
+	health        *Health
+}
+
+// NewHealthChecker creates a HealthChecker that probes the providers every interval and calls onChange with the
+// key of a policy after the first probe of the policy and when its health changes.
+func NewHealthChecker(httpClient *http.Client, interval time.Duration, onChange func(key string, h Health)) *HealthChecker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &HealthChecker{
+		httpClient: httpClient,
+		interval:   interval,
+		onChange:   onChange,
+		ctx:        ctx,
+		cancel:     cancel,
+		targets:    make(map[string]*healthTarget),
+	}
+}
+
+// Run blocks until stopCh is closed and stops probing the providers.
+func (c *HealthChecker) Run(stopCh <-chan struct{}) {
+	<-stopCh
+	c.cancel()
+}
+
+// Update starts probing the endpoints of the policy with the key, or restarts it when the endpoints changed.
+// An empty endpoint isn't probed, it is considered reachable.
+func (c *HealthChecker) Update(key string, tokenEndpoint string, jwksURI string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if t, exists := c.targets[key]; exists {
+		if t.tokenEndpoint == tokenEndpoint && t.jwksURI == jwksURI {
+			return
+		}
+		t.cancel()
+	}
+
+	ctx, cancel := context.WithCancel(WithPolicy(c.ctx, key))
+	t := &healthTarget{tokenEndpoint: tokenEndpoint, jwksURI: jwksURI, cancel: cancel}
+	c.targets[key] = t
+	go c.probeLoop(ctx, key, t)
+}
+
+// Remove stops probing the endpoints of the policy with the key.
+func (c *HealthChecker) Remove(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if t, exists := c.targets[key]; exists {
+		t.cancel()
+		delete(c.targets, key)
+	}
+}
+
+// Health returns the health of the policy with the key from the last probe. It returns false until the
+// endpoints of the policy were probed.
+func (c *HealthChecker) Health(key string) (Health, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	t, exists := c.targets[key]
+	if !exists || t.health == nil {
+		return Health{}, false
+	}
+	return *t.health, true
+}
+
+// AllUnreachable returns true if the providers of all the probed policies are unreachable. It returns false
+// when no policy was probed yet.
+func (c *HealthChecker) AllUnreachable() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	probed := 0
+	for _, t := range c.targets {
+		if t.health == nil {
+			continue
+		}
+		if t.health.Reachable() {
+			return false
+		}
+		probed++
+	}
+	return probed > 0
+}
+
+func (c *HealthChecker) probeLoop(ctx context.Context, key string, t *healthTarget) {
+	for {
+		h := c.probe(ctx, t)
+		if ctx.Err() != nil {
+			return
+		}
+
+		c.lock.Lock()
+		changed := t.health == nil || *t.health != h
+		t.health = &h
+		c.lock.Unlock()
+
+		if changed {
+			if !h.Reachable() {
+				glog.Warningf("The provider of OIDC policy %v is unreachable: %v", key, h.Message)
+			}
+			c.onChange(key, h)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.interval):
+		}
+	}
+}
+
+// probe probes the endpoints of the target. The token endpoint is reachable if it responds to a token request
+// without parameters, even with an error. The JWKS URI is reachable if it responds with the JWK Set.
+func (c *HealthChecker) probe(ctx context.Context, t *healthTarget) Health {
+	var failures []string
+	h := Health{TokenEndpointReachable: true, JWKSReachable: true}
+
+	if t.tokenEndpoint != "" {
+		if err := c.probeEndpoint(ctx, http.MethodPost, t.tokenEndpoint, func(status int) bool {
+			return status != http.StatusNotFound && status < http.StatusInternalServerError
+		}); err != nil {
+			h.TokenEndpointReachable = false
+			failures = append(failures, fmt.Sprintf("token endpoint %v: %v", t.tokenEndpoint, err))
+		}
+	}
+	if t.jwksURI != "" {
+		if err := c.probeEndpoint(ctx, http.MethodGet, t.jwksURI, func(status int) bool {
+			return status == http.StatusOK
+		}); err != nil {
+			h.JWKSReachable = false
+			failures = append(failures, fmt.Sprintf("JWKS URI %v: %v", t.jwksURI, err))
+		}
+	}
+	h.Message = strings.Join(failures, "; ")
+	return h
+}
+
+func (c *HealthChecker) probeEndpoint(ctx context.Context, method string, url string, ok func(status int) bool) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return err
+	}
+	if method == http.MethodPost {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	if !ok(resp.StatusCode) {
+		return fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return nil
+}
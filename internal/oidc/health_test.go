@@ -0,0 +1,64 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHealthCheckerProbe(t *testing.T) {
+	t.Parallel()
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+	})
+	mux.HandleFunc("/certs", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"keys":[]}`))
+	})
+	mux.HandleFunc("/failing", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	c := NewHealthChecker(ts.Client(), DefaultHealthCheckInterval, func(string, Health) {})
+
+	h := c.probe(context.Background(), &healthTarget{tokenEndpoint: ts.URL + "/token", jwksURI: ts.URL + "/certs"})
+	if !h.Reachable() || h.Message != "" {
+		t.Errorf("probe() returned %+v, want a reachable provider for a token endpoint that rejects the probe", h)
+	}
+
+	h = c.probe(context.Background(), &healthTarget{tokenEndpoint: ts.URL + "/failing", jwksURI: ts.URL + "/missing"})
+	if h.TokenEndpointReachable || h.JWKSReachable {
+		t.Errorf("probe() returned %+v, want unreachable endpoints", h)
+	}
+	if !strings.Contains(h.Message, "token endpoint") || !strings.Contains(h.Message, "JWKS URI") {
+		t.Errorf("probe() returned message %q, want the failures of both endpoints", h.Message)
+	}
+
+	h = c.probe(context.Background(), &healthTarget{})
+	if !h.Reachable() {
+		t.Errorf("probe() returned %+v, want a reachable provider without endpoints", h)
+	}
+}
+
+func TestHealthCheckerAllUnreachable(t *testing.T) {
+	t.Parallel()
+	c := NewHealthChecker(http.DefaultClient, DefaultHealthCheckInterval, func(string, Health) {})
+	if c.AllUnreachable() {
+		t.Errorf("AllUnreachable() returned true without policies")
+	}
+
+	c.targets["default/up"] = &healthTarget{}
+	c.targets["default/down"] = &healthTarget{health: &Health{TokenEndpointReachable: false, JWKSReachable: true}}
+	if !c.AllUnreachable() {
+		t.Errorf("AllUnreachable() returned false, want true when the only probed provider is unreachable")
+	}
+
+	c.targets["default/up"].health = &Health{TokenEndpointReachable: true, JWKSReachable: true}
+	if c.AllUnreachable() {
+		t.Errorf("AllUnreachable() returned true, want false when a provider is reachable")
+	}
+}
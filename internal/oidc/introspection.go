@@ -0,0 +1,280 @@
+package oidc
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+
+	"github.com/nginxinc/kubernetes-ingress/internal/oidc/session"
+)
+
+// DefaultIntrospectionCacheTTL is the time an introspection result is cached for, unless the policy sets it.
+const DefaultIntrospectionCacheTTL = time.Minute
+
+// DefaultIntrospectionCacheMaxEntries is the number of introspection results a policy caches, unless the policy
+// sets it.
+const DefaultIntrospectionCacheMaxEntries = 10000
+
+// maxIntrospectionResponseSize is the maximum size of a response of an introspection endpoint.
+const maxIntrospectionResponseSize = 64 << 10
+
+// errNoIntrospection is returned for the tokens of a policy without token introspection.
+var errNoIntrospection = errors.New("no token introspection")
+
+// Introspection holds the introspection settings of an OIDC policy. Without an Endpoint, the tokens are
+// introspected at the introspection_endpoint of the discovery document of the policy.
+type Introspection struct {
+	Endpoint        string
+	ClientID        string
+	ClientSecret    string
+	CacheTTL        time.Duration
+	CacheMaxEntries int
+}
+
+// Introspector introspects the opaque access tokens that API clients send to the OIDC policies with token
+// introspection, as per https://www.rfc-editor.org/rfc/rfc7662, and caches the results, so that the provider
+// isn't requested on every request of the clients. The results are cached by the SHA-256 hash of the token:
+// an active token until it expires or for the cache TTL, whichever is shorter, and an inactive token for the
+// cache TTL. When the cache of a policy is full, its least recently used result is evicted.
+//
+// It serves the requests of NGINX, as the introspection handler of the session server:
+//
+//	GET /introspect/<namespace>/<name> with the Authorization header of the client returns No Content if the
+//	bearer token is active, Unauthorized if it isn't, and Bad Gateway if the provider fails.
+type Introspector struct {
+	refresher *Refresher
+	now       func() time.Time
+	lock      sync.Mutex
+	policies  map[string]*introspectionPolicy
+}
+
+type introspectionPolicy struct {
+	settings Introspection
+	entries  map[string]*list.Element
+	lru      *list.List
+}
+
+type introspectionEntry struct {
+	hash    string
+	active  bool
+	expires time.Time
+}
+
+// introspectionResponse is the part of the response of an introspection endpoint that the Introspector uses.
+type introspectionResponse struct {
+	Active bool  `json:"active"`
+	Exp    int64 `json:"exp"`
+}
+
+// NewIntrospector creates an Introspector without policies, which sends its requests with the HTTP client and
+// through the limiter of the Refresher and takes the introspection endpoints of the policies from it.
+func NewIntrospector(refresher *Refresher) *Introspector {
+	return &Introspector{
+		refresher: refresher,
+		now:       time.Now,
+		policies:  make(map[string]*introspectionPolicy),
+	}
+}
+
+// Set sets the introspection settings of the policy with the key. The cached results of the policy are kept,
+// unless the provider of the policy changes.
+func (i *Introspector) Set(key string, settings Introspection) {
+	if settings.CacheTTL <= 0 {
+		settings.CacheTTL = DefaultIntrospectionCacheTTL
+	}
+	if settings.CacheMaxEntries <= 0 {
+		settings.CacheMaxEntries = DefaultIntrospectionCacheMaxEntries
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	p, exists := i.policies[key]
+	if !exists || !p.settings.sameClient(settings) {
+		p = &introspectionPolicy{entries: make(map[string]*list.Element), lru: list.New()}
+		i.policies[key] = p
+	}
+	p.settings = settings
+	for p.lru.Len() > settings.CacheMaxEntries {
+		p.evict(p.lru.Back())
+	}
+}
+
+// Remove removes the introspection settings and the cached results of the policy.
+func (i *Introspector) Remove(key string) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	delete(i.policies, key)
+}
+
+// Introspect returns true if the token is active for the provider of the policy with the key, from the cache
+// of the policy if it has the result.
+func (i *Introspector) Introspect(ctx context.Context, key string, token string) (bool, error) {
+	sum := sha256.Sum256([]byte(token))
+	hash := hex.EncodeToString(sum[:])
+
+	settings, active, cached, exists := i.lookup(key, hash)
+	if !exists {
+		return false, errNoIntrospection
+	}
+	if cached {
+		return active, nil
+	}
+
+	request := settings
+	if request.Endpoint == "" {
+		metadata, _ := i.refresher.Metadata(key)
+		request.Endpoint = metadata.IntrospectionEndpoint
+	}
+	if request.Endpoint == "" {
+		return false, fmt.Errorf("the provider of OIDC policy %v has no introspection endpoint", key)
+	}
+	resp, err := i.introspect(WithPolicy(ctx, key), request, token)
+	if err != nil {
+		return false, err
+	}
+
+	ttl := settings.CacheTTL
+	if resp.Active && resp.Exp > 0 {
+		ttl = min(ttl, time.Unix(resp.Exp, 0).Sub(i.now()))
+	}
+	if ttl > 0 {
+		i.store(key, settings, hash, resp.Active, ttl)
+	}
+	return resp.Active, nil
+}
+
+// lookup returns the settings of the policy and the cached result of the token hash, if it hasn't expired.
+func (i *Introspector) lookup(key string, hash string) (settings Introspection, active bool, cached bool, exists bool) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	p, exists := i.policies[key]
+	if !exists {
+		return Introspection{}, false, false, false
+	}
+	elem, cached := p.entries[hash]
+	if !cached {
+		return p.settings, false, false, true
+	}
+	entry := elem.Value.(*introspectionEntry)
+	if !i.now().Before(entry.expires) {
+		p.evict(elem)
+		return p.settings, false, false, true
+	}
+	p.lru.MoveToFront(elem)
+	return p.settings, entry.active, true, true
+}
+
+// store caches the result of the token hash for the policy, unless the endpoint or the client of the policy changed
+// while the token was introspected.
+func (i *Introspector) store(key string, settings Introspection, hash string, active bool, ttl time.Duration) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	p, exists := i.policies[key]
+	if !exists || !p.settings.sameClient(settings) {
+		return
+	}
+	entry := &introspectionEntry{hash: hash, active: active, expires: i.now().Add(ttl)}
+	if elem, exists := p.entries[hash]; exists {
+		elem.Value = entry
+		p.lru.MoveToFront(elem)
+		return
+	}
+	p.entries[hash] = p.lru.PushFront(entry)
+	for p.lru.Len() > p.settings.CacheMaxEntries {
+		p.evict(p.lru.Back())
+	}
+}
+
+// sameClient returns true if the tokens are introspected at the same endpoint by the same client with both
+// settings, so that the results cached with one are valid for the other.
+func (s Introspection) sameClient(other Introspection) bool {
+	return s.Endpoint == other.Endpoint && s.ClientID == other.ClientID
+}
+
+func (p *introspectionPolicy) evict(elem *list.Element) {
+	p.lru.Remove(elem)
+	delete(p.entries, elem.Value.(*introspectionEntry).hash)
+}
+
+func (i *Introspector) introspect(ctx context.Context, settings Introspection, token string) (introspectionResponse, error) {
+	form := url.Values{
+		"token":           {token},
+		"token_type_hint": {"access_token"},
+		"client_id":       {settings.ClientID},
+		"client_secret":   {settings.ClientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, settings.Endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return introspectionResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := i.refresher.limiter.Do(i.refresher.httpClient, req)
+	if err != nil {
+		return introspectionResponse{}, fmt.Errorf("introspection endpoint %v is unreachable: %w", settings.Endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxIntrospectionResponseSize))
+	if err != nil {
+		return introspectionResponse{}, fmt.Errorf("introspection endpoint %v: %w", settings.Endpoint, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return introspectionResponse{}, fmt.Errorf("introspection endpoint %v: unexpected response status %d: %.512s", settings.Endpoint, resp.StatusCode, body)
+	}
+	var result introspectionResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return introspectionResponse{}, fmt.Errorf("introspection endpoint %v: invalid response: %w", settings.Endpoint, err)
+	}
+	return result, nil
+}
+
+// ServeHTTP introspects the bearer token of a request of an API client for NGINX.
+func (i *Introspector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, session.IntrospectionPath), "/")
+	if !strings.HasPrefix(r.URL.Path, session.IntrospectionPath) || len(parts) != 2 || parts[1] == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	key := parts[0] + "/" + parts[1]
+
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	token = strings.TrimSpace(token)
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	active, err := i.Introspect(r.Context(), key, token)
+	if errors.Is(err, errNoIntrospection) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		glog.Warningf("Failed to introspect a token of OIDC policy %v: %v", key, err)
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	if !active {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
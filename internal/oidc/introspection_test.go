@@ -0,0 +1,230 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newIntrospectionServer returns a provider that introspects the tokens "active" and "expiring", which expires at
+// exp, and counts its requests.
+func newIntrospectionServer(t *testing.T, exp time.Time) (*httptest.Server, func() int) {
+	t.Helper()
+	var lock sync.Mutex
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		lock.Lock()
+		requests++
+		lock.Unlock()
+		if err := req.ParseForm(); err != nil {
+			t.Errorf("failed to parse the introspection request: %v", err)
+		}
+		if req.PostForm.Get("client_id") != "nginx-plus" || req.PostForm.Get("client_secret") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		resp := introspectionResponse{}
+		switch req.PostForm.Get("token") {
+		case "active":
+			resp = introspectionResponse{Active: true, Exp: exp.Add(time.Hour).Unix()}
+		case "expiring":
+			resp = introspectionResponse{Active: true, Exp: exp.Unix()}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return requests
+	}
+}
+
+func TestIntrospectCachesResults(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	ts, requests := newIntrospectionServer(t, now.Add(10*time.Second))
+
+	i := NewIntrospector(NewRefresher(ts.Client(), t.TempDir(), func(string) {}, func(string, error) {}))
+	i.now = func() time.Time { return now }
+	i.Set("default/oidc-policy", Introspection{
+		Endpoint:     ts.URL + "/introspect",
+		ClientID:     "nginx-plus",
+		ClientSecret: "secret",
+		CacheTTL:     time.Minute,
+	})
+
+	tests := []struct {
+		token    string
+		active   bool
+		requests int
+		msg      string
+	}{
+		{token: "active", active: true, requests: 1, msg: "an active token"},
+		{token: "active", active: true, requests: 1, msg: "a cached active token"},
+		{token: "revoked", active: false, requests: 2, msg: "an inactive token"},
+		{token: "revoked", active: false, requests: 2, msg: "a cached inactive token"},
+		{token: "expiring", active: true, requests: 3, msg: "a token that expires before the cache ttl"},
+		{token: "expiring", active: true, requests: 3, msg: "a cached token that expires before the cache ttl"},
+	}
+	for _, test := range tests {
+		active, err := i.Introspect(context.Background(), "default/oidc-policy", test.token)
+		if err != nil {
+			t.Fatalf("Introspect() returned %v for %s", err, test.msg)
+		}
+		if active != test.active {
+			t.Errorf("Introspect() returned %v for %s, want %v", active, test.msg, test.active)
+		}
+		if requests() != test.requests {
+			t.Errorf("Introspect() sent %d requests after %s, want %d", requests(), test.msg, test.requests)
+		}
+	}
+
+	// the expiring token is introspected again when it expires, the others when the cache ttl ends
+	now = now.Add(20 * time.Second)
+	if _, err := i.Introspect(context.Background(), "default/oidc-policy", "expiring"); err != nil || requests() != 4 {
+		t.Errorf("Introspect() returned %v and sent %d requests for an expired cached token, want 4 requests", err, requests())
+	}
+	if _, err := i.Introspect(context.Background(), "default/oidc-policy", "active"); err != nil || requests() != 4 {
+		t.Errorf("Introspect() returned %v and sent %d requests for a cached token, want 4 requests", err, requests())
+	}
+	now = now.Add(time.Minute)
+	if _, err := i.Introspect(context.Background(), "default/oidc-policy", "active"); err != nil || requests() != 5 {
+		t.Errorf("Introspect() returned %v and sent %d requests after the cache ttl, want 5 requests", err, requests())
+	}
+}
+
+func TestIntrospectEvictsLeastRecentlyUsedResults(t *testing.T) {
+	t.Parallel()
+	ts, requests := newIntrospectionServer(t, time.Now().Add(time.Hour))
+
+	i := NewIntrospector(NewRefresher(ts.Client(), t.TempDir(), func(string) {}, func(string, error) {}))
+	i.Set("default/oidc-policy", Introspection{
+		Endpoint:        ts.URL + "/introspect",
+		ClientID:        "nginx-plus",
+		ClientSecret:    "secret",
+		CacheMaxEntries: 2,
+	})
+
+	for _, token := range []string{"a", "b", "a", "c", "a", "b"} {
+		if _, err := i.Introspect(context.Background(), "default/oidc-policy", token); err != nil {
+			t.Fatalf("Introspect() returned %v", err)
+		}
+	}
+	// a is cached, b is evicted by c, and c by b
+	if requests() != 4 {
+		t.Errorf("Introspect() sent %d requests, want 4", requests())
+	}
+	if n := i.policies["default/oidc-policy"].lru.Len(); n != 2 {
+		t.Errorf("the cache has %d entries, want 2", n)
+	}
+
+	// a smaller cache evicts the least recently used results
+	i.Set("default/oidc-policy", Introspection{
+		Endpoint:        ts.URL + "/introspect",
+		ClientID:        "nginx-plus",
+		ClientSecret:    "secret",
+		CacheMaxEntries: 1,
+	})
+	if _, err := i.Introspect(context.Background(), "default/oidc-policy", "b"); err != nil || requests() != 4 {
+		t.Errorf("Introspect() returned %v and sent %d requests for the most recently used token, want 4 requests", err, requests())
+	}
+	if _, err := i.Introspect(context.Background(), "default/oidc-policy", "a"); err != nil || requests() != 5 {
+		t.Errorf("Introspect() returned %v and sent %d requests for an evicted token, want 5 requests", err, requests())
+	}
+}
+
+func TestIntrospectCachesResultsOfTheDiscoveredEndpoint(t *testing.T) {
+	t.Parallel()
+	ts, requests := newIntrospectionServer(t, time.Now().Add(time.Hour))
+
+	refresher := NewRefresher(ts.Client(), t.TempDir(), func(string) {}, func(string, error) {})
+	refresher.targets["default/oidc-policy"] = &target{
+		key:      "default/oidc-policy",
+		metadata: &ProviderMetadata{IntrospectionEndpoint: ts.URL + "/introspect"},
+		cancel:   func() {},
+	}
+	i := NewIntrospector(refresher)
+	i.Set("default/oidc-policy", Introspection{ClientID: "nginx-plus", ClientSecret: "secret"})
+
+	for n := 0; n < 2; n++ {
+		if active, err := i.Introspect(context.Background(), "default/oidc-policy", "active"); err != nil || !active {
+			t.Fatalf("Introspect() returned %v, %v, want true", active, err)
+		}
+	}
+	if requests() != 1 {
+		t.Errorf("Introspect() sent %d requests, want 1", requests())
+	}
+}
+
+func TestIntrospectDropsResultsOfAChangedEndpoint(t *testing.T) {
+	t.Parallel()
+	ts, requests := newIntrospectionServer(t, time.Now().Add(time.Hour))
+
+	var i *Introspector
+	previous := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		// the policy moves to another endpoint of the same client while the token is introspected
+		i.Set("default/oidc-policy", Introspection{Endpoint: ts.URL + "/introspect", ClientID: "nginx-plus", ClientSecret: "secret"})
+		_ = json.NewEncoder(w).Encode(introspectionResponse{Active: true})
+	}))
+	t.Cleanup(previous.Close)
+
+	i = NewIntrospector(NewRefresher(ts.Client(), t.TempDir(), func(string) {}, func(string, error) {}))
+	i.Set("default/oidc-policy", Introspection{Endpoint: previous.URL + "/introspect", ClientID: "nginx-plus", ClientSecret: "secret"})
+
+	if active, err := i.Introspect(context.Background(), "default/oidc-policy", "revoked"); err != nil || !active {
+		t.Fatalf("Introspect() returned %v, %v at the previous endpoint, want true", active, err)
+	}
+	if active, err := i.Introspect(context.Background(), "default/oidc-policy", "revoked"); err != nil || active || requests() != 1 {
+		t.Errorf("Introspect() returned %v, %v and sent %d requests to the new endpoint, want false and 1 request", active, err, requests())
+	}
+}
+
+func TestIntrospectorServeHTTP(t *testing.T) {
+	t.Parallel()
+	ts, _ := newIntrospectionServer(t, time.Now().Add(time.Hour))
+
+	i := NewIntrospector(NewRefresher(ts.Client(), t.TempDir(), func(string) {}, func(string, error) {}))
+	i.Set("default/oidc-policy", Introspection{Endpoint: ts.URL + "/introspect", ClientID: "nginx-plus", ClientSecret: "secret"})
+	i.Set("default/rejected-policy", Introspection{Endpoint: ts.URL + "/introspect", ClientID: "nginx-plus", ClientSecret: "wrong"})
+
+	tests := []struct {
+		path          string
+		authorization string
+		expected      int
+		msg           string
+	}{
+		{path: "/introspect/default/oidc-policy", authorization: "Bearer active", expected: http.StatusNoContent, msg: "an active token"},
+		{path: "/introspect/default/oidc-policy", authorization: "bearer active", expected: http.StatusNoContent, msg: "a lowercase scheme"},
+		{path: "/introspect/default/oidc-policy", authorization: "Bearer revoked", expected: http.StatusUnauthorized, msg: "an inactive token"},
+		{path: "/introspect/default/oidc-policy", authorization: "Basic dXNlcjpwYXNz", expected: http.StatusUnauthorized, msg: "basic credentials"},
+		{path: "/introspect/default/oidc-policy", expected: http.StatusUnauthorized, msg: "no token"},
+		{path: "/introspect/default/other-policy", authorization: "Bearer active", expected: http.StatusNotFound, msg: "a policy without introspection"},
+		{path: "/introspect/default", authorization: "Bearer active", expected: http.StatusNotFound, msg: "no policy name"},
+		{path: "/introspect/default/rejected-policy", authorization: "Bearer active", expected: http.StatusBadGateway, msg: "a rejected client"},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.path, nil)
+		if test.authorization != "" {
+			req.Header.Set("Authorization", test.authorization)
+		}
+		rec := httptest.NewRecorder()
+		i.ServeHTTP(rec, req)
+		if rec.Code != test.expected {
+			t.Errorf("ServeHTTP() returned %d for %s, want %d", rec.Code, test.msg, test.expected)
+		}
+	}
+
+	i.Remove("default/oidc-policy")
+	req := httptest.NewRequest(http.MethodGet, "/introspect/default/oidc-policy", nil)
+	req.Header.Set("Authorization", "Bearer active")
+	rec := httptest.NewRecorder()
+	i.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("ServeHTTP() returned %d for a removed policy, want %d", rec.Code, http.StatusNotFound)
+	}
+}
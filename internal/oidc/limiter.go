@@ -0,0 +1,171 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// circuitThreshold is the number of consecutive failures of a provider that opens its circuit.
+	circuitThreshold = 5
+	// providerRate is the number of requests per second that can be sent to a provider.
+	providerRate = 5
+	// providerBurst is the number of requests that can be sent to a provider at once.
+	providerBurst = 50
+)
+
+// CircuitOpenError is returned for a request to a provider whose circuit is open.
+type CircuitOpenError struct {
+	Host       string
+	RetryAfter time.Duration
+}
+
+// Error returns the message of the error. The message doesn't include the time to wait,
+// so that the same error isn't reported again while the provider stays unavailable.
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("provider %v is unavailable after %d consecutive failures", e.Host, circuitThreshold)
+}
+
+// ProviderLimiter limits the requests to the OpenID Connect providers, identified by the host of the URL.
+// Every provider has a budget of requests, so that many policies of the same provider don't cause a burst
+// of requests. The circuit of a provider opens after consecutive failures, and fails the requests without
+// sending them until the backoff expires. Then a single request is sent, which closes the circuit if it succeeds.
+type ProviderLimiter struct {
+	lock      sync.Mutex
+	providers map[string]*provider
+	rate      rate.Limit
+	burst     int
+}
+
+type provider struct {
+	budget    *rate.Limiter
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// NewProviderLimiter creates a ProviderLimiter.
+func NewProviderLimiter() *ProviderLimiter {
+	return &ProviderLimiter{
+		providers: make(map[string]*provider),
+		rate:      providerRate,
+		burst:     providerBurst,
+	}
+}
+
+// Do sends the request when the circuit of the provider is closed and its budget allows it. A network error,
+// a server error and Too Many Requests count as failures of the provider.
+func (l *ProviderLimiter) Do(client *http.Client, req *http.Request) (*http.Response, error) {
+	host := providerHost(req.URL)
+	if err := l.wait(req.Context(), host); err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		l.done(host, true, 0)
+		return nil, err
+	}
+	failed := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+	l.done(host, failed, retryAfter(resp.Header.Get("Retry-After")))
+	return resp, nil
+}
+
+// wait waits for the budget of the provider. It fails when the circuit of the provider is open, or when
+// another request is already probing the provider.
+func (l *ProviderLimiter) wait(ctx context.Context, host string) error {
+	l.lock.Lock()
+	p := l.provider(host)
+	now := time.Now()
+	if now.Before(p.openUntil) {
+		l.lock.Unlock()
+		return &CircuitOpenError{Host: host, RetryAfter: p.openUntil.Sub(now)}
+	}
+	if p.failures >= circuitThreshold {
+		if p.probing {
+			l.lock.Unlock()
+			return &CircuitOpenError{Host: host, RetryAfter: minBackoff}
+		}
+		p.probing = true
+	}
+	budget := p.budget
+	l.lock.Unlock()
+
+	if err := budget.Wait(ctx); err != nil {
+		l.lock.Lock()
+		p.probing = false
+		l.lock.Unlock()
+		return err
+	}
+	return nil
+}
+
+// done records the result of a request to the provider. The circuit stays open for at least the time
+// the provider asked to wait with the Retry-After header.
+func (l *ProviderLimiter) done(host string, failed bool, wait time.Duration) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	p := l.provider(host)
+	p.probing = false
+	if !failed {
+		if p.failures >= circuitThreshold {
+			glog.Infof("OIDC provider %v is available again", host)
+		}
+		p.failures = 0
+		p.openUntil = time.Time{}
+		return
+	}
+
+	p.failures++
+	if p.failures < circuitThreshold {
+		return
+	}
+	backoff := max(backoffDuration(p.failures-circuitThreshold+1), min(wait, maxBackoff))
+	p.openUntil = time.Now().Add(backoff)
+	glog.Warningf("OIDC provider %v failed %d consecutive requests, pausing the requests for %v", host, p.failures, backoff)
+}
+
+func (l *ProviderLimiter) provider(host string) *provider {
+	p, exists := l.providers[host]
+	if !exists {
+		p = &provider{budget: rate.NewLimiter(l.rate, l.burst)}
+		l.providers[host] = p
+	}
+	return p
+}
+
+func providerHost(u *url.URL) string {
+	if u.Host == "" {
+		return u.String()
+	}
+	return u.Host
+}
+
+// retryAfter parses the delay in seconds of a Retry-After header. The HTTP date form isn't supported.
+func retryAfter(header string) time.Duration {
+	seconds, err := strconv.Atoi(header)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// backoffDuration returns the jittered exponential backoff after the number of consecutive failures.
+func backoffDuration(failures int) time.Duration {
+	backoff := maxBackoff
+	if failures < 10 {
+		backoff = min(minBackoff<<(failures-1), maxBackoff)
+	}
+	// #nosec G404 -- the jitter doesn't need a secure random number
+	return backoff - time.Duration(rand.Int63n(int64(backoff/5)))
+}
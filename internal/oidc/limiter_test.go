@@ -0,0 +1,122 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProviderLimiterOpensCircuit(t *testing.T) {
+	t.Parallel()
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	l := NewProviderLimiter()
+	for i := 0; i < circuitThreshold+3; i++ {
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, ts.URL, nil)
+		resp, err := l.Do(ts.Client(), req)
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+	}
+	if got := requests.Load(); got != circuitThreshold {
+		t.Errorf("ProviderLimiter sent %d requests to a failing provider, want %d", got, circuitThreshold)
+	}
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, ts.URL, nil)
+	_, err := l.Do(ts.Client(), req)
+	var circuitErr *CircuitOpenError
+	if !errors.As(err, &circuitErr) {
+		t.Fatalf("ProviderLimiter.Do() returned %v, want a CircuitOpenError", err)
+	}
+	if circuitErr.RetryAfter <= 0 || circuitErr.RetryAfter > minBackoff {
+		t.Errorf("ProviderLimiter.Do() returned a retry after %v, want up to %v", circuitErr.RetryAfter, minBackoff)
+	}
+}
+
+func TestProviderLimiterClosesCircuit(t *testing.T) {
+	t.Parallel()
+	var failing atomic.Bool
+	failing.Store(true)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	l := NewProviderLimiter()
+	host := ts.Listener.Addr().String()
+	for i := 0; i < circuitThreshold; i++ {
+		l.done(host, true, 0)
+	}
+
+	// the circuit half-opens when the backoff expires
+	l.providers[host].openUntil = time.Now()
+	failing.Store(false)
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, ts.URL, nil)
+	resp, err := l.Do(ts.Client(), req)
+	if err != nil {
+		t.Fatalf("ProviderLimiter.Do() returned %v after the backoff expired, want no error", err)
+	}
+	_ = resp.Body.Close()
+	if p := l.providers[host]; p.failures != 0 || !p.openUntil.IsZero() {
+		t.Errorf("ProviderLimiter didn't close the circuit after a successful request: %+v", p)
+	}
+}
+
+func TestProviderLimiterHonorsRetryAfter(t *testing.T) {
+	t.Parallel()
+	l := NewProviderLimiter()
+	for i := 0; i < circuitThreshold; i++ {
+		l.done("idp.example.com", true, 5*time.Minute)
+	}
+	if wait := time.Until(l.providers["idp.example.com"].openUntil); wait < 4*time.Minute {
+		t.Errorf("ProviderLimiter opened the circuit for %v, want at least the Retry-After of 5m", wait)
+	}
+}
+
+func TestProviderLimiterAllowsSingleProbe(t *testing.T) {
+	t.Parallel()
+	l := NewProviderLimiter()
+	for i := 0; i < circuitThreshold; i++ {
+		l.done("idp.example.com", true, 0)
+	}
+	l.providers["idp.example.com"].openUntil = time.Now()
+
+	if err := l.wait(context.Background(), "idp.example.com"); err != nil {
+		t.Fatalf("wait() returned %v for the first request after the backoff, want no error", err)
+	}
+	var circuitErr *CircuitOpenError
+	if err := l.wait(context.Background(), "idp.example.com"); !errors.As(err, &circuitErr) {
+		t.Errorf("wait() returned %v while another request probes the provider, want a CircuitOpenError", err)
+	}
+}
+
+func TestProviderLimiterBudget(t *testing.T) {
+	t.Parallel()
+	l := NewProviderLimiter()
+	l.rate = 1
+	l.burst = 1
+
+	if err := l.wait(context.Background(), "idp.example.com"); err != nil {
+		t.Fatalf("wait() returned %v within the budget, want no error", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := l.wait(ctx, "idp.example.com"); err == nil {
+		t.Errorf("wait() returned no error after the budget was exhausted, want an error")
+	}
+	if err := l.wait(context.Background(), "other.example.com"); err != nil {
+		t.Errorf("wait() returned %v for another provider, want no error", err)
+	}
+}
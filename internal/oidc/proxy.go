@@ -0,0 +1,183 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/golang/glog"
+
+	"github.com/nginxinc/kubernetes-ingress/internal/oidc/session"
+)
+
+type policyContextKey struct{}
+
+// WithPolicy returns a context for the requests to the provider of the OIDC policy with the key, which the
+// transport of the Proxies sends with the egress settings of the policy.
+func WithPolicy(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, policyContextKey{}, key)
+}
+
+// Proxies holds the egress settings of the OIDC policies with an httpProxy or requestHeaders. Its transport sends
+// the requests to the provider of a policy, whose context is set with WithPolicy, with the headers of the policy
+// through the proxy of the policy, and the other requests through the proxy of the HTTPS_PROXY, HTTP_PROXY and
+// NO_PROXY environment variables.
+//
+// NGINX can't send its requests through a forward proxy nor with the headers of a policy, so Proxies also relays
+// them, as the egress handler of the session server:
+//
+//	<method> /egress/<namespace>/<name>/<url> sends the request to the URL through the proxy of the policy.
+type Proxies struct {
+	lock      sync.RWMutex
+	proxies   map[string]*forwardProxy
+	transport *http.Transport
+}
+
+// Egress holds the egress settings of an OIDC policy: the forward proxy, the hosts that are requested without
+// it, and the headers of the requests to the provider.
+type Egress struct {
+	HTTPProxy string
+	NoProxy   []string
+	Headers   http.Header
+}
+
+type forwardProxy struct {
+	url     *url.URL
+	noProxy []string
+	headers http.Header
+}
+
+// NewProxies creates Proxies without proxies.
+func NewProxies() *Proxies {
+	p := &Proxies{
+		proxies: make(map[string]*forwardProxy),
+	}
+	p.transport = http.DefaultTransport.(*http.Transport).Clone()
+	p.transport.Proxy = p.proxy
+	return p
+}
+
+// Set sets the egress settings of the policy with the key. Without an HTTPProxy, the requests to the provider
+// go through the proxy of the environment variables.
+func (p *Proxies) Set(key string, egress Egress) error {
+	fp := &forwardProxy{noProxy: egress.NoProxy, headers: egress.Headers}
+	if egress.HTTPProxy != "" {
+		u, err := url.Parse(egress.HTTPProxy)
+		if err != nil {
+			return err
+		}
+		if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("invalid forward proxy %q", egress.HTTPProxy)
+		}
+		fp.url = u
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.proxies[key] = fp
+	return nil
+}
+
+// Remove removes the egress settings of the policy.
+func (p *Proxies) Remove(key string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	delete(p.proxies, key)
+}
+
+// Transport returns the transport of the requests to the providers.
+func (p *Proxies) Transport() http.RoundTripper {
+	return p
+}
+
+// RoundTrip sends the request with the headers of its policy.
+func (p *Proxies) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, _ := req.Context().Value(policyContextKey{}).(string)
+	if fp, exists := p.get(key); exists && len(fp.headers) > 0 {
+		req = req.Clone(req.Context())
+		for name, values := range fp.headers {
+			req.Header[name] = values
+		}
+	}
+	return p.transport.RoundTrip(req)
+}
+
+func (p *Proxies) get(key string) (*forwardProxy, bool) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	fp, exists := p.proxies[key]
+	return fp, exists
+}
+
+func (p *Proxies) proxy(req *http.Request) (*url.URL, error) {
+	key, _ := req.Context().Value(policyContextKey{}).(string)
+	fp, exists := p.get(key)
+	if !exists || fp.url == nil {
+		return http.ProxyFromEnvironment(req)
+	}
+	if fp.bypass(req.URL.Hostname()) {
+		return nil, nil
+	}
+	return fp.url, nil
+}
+
+// bypass returns true if the host is requested without the proxy: noProxy has the entry *, the host or a parent
+// domain of the host, with or without a leading dot or *., or a CIDR with the IP address of the host.
+func (fp *forwardProxy) bypass(host string) bool {
+	host = strings.ToLower(host)
+	ip := net.ParseIP(host)
+	for _, entry := range fp.noProxy {
+		if entry == "*" {
+			return true
+		}
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		domain := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(entry), "*"), ".")
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeHTTP relays a request of NGINX to the provider of a policy.
+func (p *Proxies) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.EscapedPath(), session.EgressPath), "/", 3)
+	if !strings.HasPrefix(r.URL.Path, session.EgressPath) || len(parts) != 3 {
+		http.NotFound(w, r)
+		return
+	}
+	key := parts[0] + "/" + parts[1]
+	if _, exists := p.get(key); !exists {
+		http.NotFound(w, r)
+		return
+	}
+	target, err := url.Parse(parts[2])
+	if err != nil || target.Scheme != "http" && target.Scheme != "https" || target.Host == "" {
+		http.Error(w, "invalid endpoint", http.StatusBadRequest)
+		return
+	}
+	target.RawQuery = r.URL.RawQuery
+
+	relay := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.Out.URL = target
+			pr.Out.Host = ""
+		},
+		Transport: p,
+		ErrorHandler: func(w http.ResponseWriter, _ *http.Request, err error) {
+			glog.Warningf("Request of NGINX to the provider of OIDC policy %v failed: %v", key, err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+	relay.ServeHTTP(w, r.WithContext(WithPolicy(r.Context(), key)))
+}
@@ -0,0 +1,164 @@
+package oidc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProxiesProxy(t *testing.T) {
+	t.Parallel()
+	p := NewProxies()
+	if err := p.Set("default/oidc-policy", Egress{
+		HTTPProxy: "http://proxy.corp.example.com:3128",
+		NoProxy:   []string{".svc.cluster.local", "idp.corp.example.com", "10.0.0.0/8"},
+	}); err != nil {
+		t.Fatalf("Set() returned error: %v", err)
+	}
+	if err := p.Set("default/invalid", Egress{HTTPProxy: "proxy.corp.example.com:3128"}); err == nil {
+		t.Errorf("Set() returned no error for a proxy without a scheme")
+	}
+
+	tests := []struct {
+		key  string
+		url  string
+		want string
+	}{
+		{key: "default/oidc-policy", url: "https://idp.example.com/token", want: "http://proxy.corp.example.com:3128"},
+		{key: "default/oidc-policy", url: "https://IDP.corp.example.com/token", want: ""},
+		{key: "default/oidc-policy", url: "http://keycloak.default.svc.cluster.local/certs", want: ""},
+		{key: "default/oidc-policy", url: "https://10.1.2.3/token", want: ""},
+		{key: "default/oidc-policy", url: "https://192.168.1.1/token", want: "http://proxy.corp.example.com:3128"},
+		{key: "default/other", url: "https://idp.example.com/token", want: ""},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequestWithContext(WithPolicy(context.Background(), test.key), http.MethodGet, test.url, nil)
+		u, err := p.proxy(req)
+		if err != nil {
+			t.Errorf("proxy() returned error for %v of %v: %v", test.url, test.key, err)
+			continue
+		}
+		got := ""
+		if u != nil {
+			got = u.String()
+		}
+		if got != test.want {
+			t.Errorf("proxy() returned %q for %v of %v, want %q", got, test.url, test.key, test.want)
+		}
+	}
+
+	if err := p.Set("default/headers", Egress{Headers: http.Header{"X-Api-Key": {"key"}}}); err != nil {
+		t.Fatalf("Set() returned error: %v", err)
+	}
+	req, _ := http.NewRequestWithContext(WithPolicy(context.Background(), "default/headers"), http.MethodGet, "https://idp.example.com/token", nil)
+	if u, _ := p.proxy(req); u != nil {
+		t.Errorf("proxy() returned %v for a policy without a forward proxy, want no proxy", u)
+	}
+
+	p.Remove("default/oidc-policy")
+	req, _ = http.NewRequestWithContext(WithPolicy(context.Background(), "default/oidc-policy"), http.MethodGet, "https://idp.example.com/token", nil)
+	if u, _ := p.proxy(req); u != nil {
+		t.Errorf("proxy() returned %v after Remove(), want no proxy", u)
+	}
+}
+
+func TestProxiesTransport(t *testing.T) {
+	t.Parallel()
+	var apiKeys []string
+	ts := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		apiKeys = append(apiKeys, r.Header.Get("X-Api-Key"))
+	}))
+	defer ts.Close()
+
+	p := NewProxies()
+	if err := p.Set("default/oidc-policy", Egress{Headers: http.Header{"X-Api-Key": {"key"}}}); err != nil {
+		t.Fatalf("Set() returned error: %v", err)
+	}
+	client := &http.Client{Transport: p.Transport()}
+	for _, key := range []string{"default/oidc-policy", "default/other"} {
+		req, _ := http.NewRequestWithContext(WithPolicy(context.Background(), key), http.MethodGet, ts.URL, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request of %v failed: %v", key, err)
+		}
+		_ = resp.Body.Close()
+	}
+	if want := []string{"key", ""}; len(apiKeys) != 2 || apiKeys[0] != want[0] || apiKeys[1] != want[1] {
+		t.Errorf("the provider got the API keys %q, want %q", apiKeys, want)
+	}
+}
+
+func TestForwardProxyBypass(t *testing.T) {
+	t.Parallel()
+	fp := &forwardProxy{noProxy: []string{"*.corp.example.com", "example.org", "fd00::/8"}}
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{host: "corp.example.com", want: true},
+		{host: "idp.corp.example.com", want: true},
+		{host: "example.org", want: true},
+		{host: "login.example.org", want: true},
+		{host: "badexample.org", want: false},
+		{host: "fd00::10", want: true},
+		{host: "idp.example.com", want: false},
+	}
+	for _, test := range tests {
+		if got := fp.bypass(test.host); got != test.want {
+			t.Errorf("bypass(%q) returned %v, want %v", test.host, got, test.want)
+		}
+	}
+
+	fp = &forwardProxy{noProxy: []string{"*"}}
+	if !fp.bypass("idp.example.com") {
+		t.Errorf("bypass() returned false with noProxy *, want true")
+	}
+}
+
+func TestProxiesServeHTTP(t *testing.T) {
+	t.Parallel()
+	var proxied []string
+	forwardProxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		proxied = append(proxied, r.Method+" "+r.URL.String()+" "+r.Header.Get("X-Api-Key")+" "+string(body))
+		_, _ = w.Write([]byte(`{"access_token":"token"}`))
+	}))
+	defer forwardProxy.Close()
+
+	p := NewProxies()
+	if err := p.Set("default/oidc-policy", Egress{HTTPProxy: forwardProxy.URL, Headers: http.Header{"X-Api-Key": {"key"}}}); err != nil {
+		t.Fatalf("Set() returned error: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/egress/default/oidc-policy/http://idp.example.com/token?tenant=a",
+		strings.NewReader("grant_type=authorization_code")))
+	if rec.Code != http.StatusOK || rec.Body.String() != `{"access_token":"token"}` {
+		t.Errorf("ServeHTTP() returned %d %q, want the response of the provider", rec.Code, rec.Body.String())
+	}
+	want := "POST http://idp.example.com/token?tenant=a key grant_type=authorization_code"
+	if len(proxied) != 1 || proxied[0] != want {
+		t.Errorf("the forward proxy got %v, want [%v]", proxied, want)
+	}
+
+	for _, path := range []string{
+		"/egress/default/other/http://idp.example.com/token",
+		"/egress/default/oidc-policy",
+		"/sessions/default/oidc-policy/id",
+	} {
+		rec = httptest.NewRecorder()
+		p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("ServeHTTP() returned %d for %v, want %d", rec.Code, path, http.StatusNotFound)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/egress/default/oidc-policy/file:///etc/passwd", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("ServeHTTP() returned %d for a file URL, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
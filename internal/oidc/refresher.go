@@ -0,0 +1,583 @@
+// Package oidc refreshes the provider metadata of the OIDC policies.
+//
+// The Refresher periodically fetches the discovery document and the JWK Set of every OIDC policy.
+// The JWK Set is written to a file that NGINX reads when it validates the ID tokens, so that keys
+// rotated by the provider are used without a reload. A change of the discovery document is reported
+// to the controller, which regenerates the configuration of the policy. The Refresher also checks that the
+// token endpoint of every policy is reachable, and reports the failures to the controller. The requests to
+// the providers, including the revocations of the tokens of the sessions that administrators revoke, go through
+// a ProviderLimiter, so that a failing provider isn't flooded with retries.
+//
+// With distribution enabled, only the leader replica fetches the documents and publishes them. The other
+// replicas are followers, which apply the documents published by the leader instead of fetching them.
+//
+// The HealthChecker probes the token endpoint and the JWKS URI of every policy on every replica, for the metrics
+// and the status of the policies, and optionally the readiness of the replica.
+package oidc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+const (
+	// DefaultJWKSDir is the directory where the JWK Sets of the policies are written.
+	DefaultJWKSDir = "/var/lib/nginx/oidc/jwks"
+
+	defaultRefreshInterval = time.Hour
+	minRefreshInterval     = time.Minute
+	maxRefreshInterval     = 24 * time.Hour
+	minBackoff             = 10 * time.Second
+	maxBackoff             = 10 * time.Minute
+	maxResponseSize        = 1 << 20
+)
+
+// ProviderMetadata holds the fields of a discovery document used by the policies.
+type ProviderMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+	EndSessionEndpoint    string `json:"end_session_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	RevocationEndpoint    string `json:"revocation_endpoint"`
+	IntrospectionEndpoint string `json:"introspection_endpoint"`
+}
+
+// Documents are the provider documents of a policy that the leader publishes to the followers.
+type Documents struct {
+	Discovery []byte
+	JWKS      []byte
+}
+
+// Refresher periodically fetches the discovery documents and the JWK Sets of the OIDC policies.
+type Refresher struct {
+	httpClient *http.Client
+	limiter    *ProviderLimiter
+	jwksDir    string
+	onChange   func(key string)
+	onError    func(key string, err error)
+	publish    func(key string, docs Documents) error
+	onRotation func(key string)
+	follower   bool
+	ctx        context.Context
+	cancel     context.CancelFunc
+	lock       sync.Mutex
+	targets    map[string]*target
+}
+
+// NewRefresher creates a Refresher that writes the JWK Sets to jwksDir and calls onChange with the key
+// of a policy when its discovery document changes. It calls onError with the key of a policy when a refresh
+// of the policy fails with a different error than the previous one.
+func NewRefresher(httpClient *http.Client, jwksDir string, onChange func(key string), onError func(key string, err error)) *Refresher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Refresher{
+		httpClient: httpClient,
+		limiter:    NewProviderLimiter(),
+		jwksDir:    jwksDir,
+		onChange:   onChange,
+		onError:    onError,
+		ctx:        ctx,
+		cancel:     cancel,
+		targets:    make(map[string]*target),
+	}
+}
+
+// EnableDistribution makes the Refresher a follower until SetLeader is called. The leader calls publish with
+// the key of a policy when it fetched new documents of the policy, and retries it with the next refresh if it fails.
+func (r *Refresher) EnableDistribution(publish func(key string, docs Documents) error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.publish = publish
+	r.follower = true
+}
+
+// SetRotationHandler sets the handler that is called with the key of a policy when the provider rotated the keys
+// of its JWK Set. It is called by the replica that fetches the documents, and must be set before Run.
+func (r *Refresher) SetRotationHandler(onRotation func(key string)) {
+	r.onRotation = onRotation
+}
+
+// SetLeader starts fetching the documents of the policies when leader is true, and stops fetching them when
+// it is false. It must only be called with distribution enabled.
+func (r *Refresher) SetLeader(leader bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.publish == nil || r.follower == !leader {
+		return
+	}
+	r.follower = !leader
+
+	// the targets are replaced, so that a stopped goroutine can't change the state of the new target
+	for key, t := range r.targets {
+		t.cancel()
+		r.start(&target{
+			key:               t.key,
+			discoveryEndpoint: t.discoveryEndpoint,
+			jwksURI:           t.jwksURI,
+			tokenEndpoint:     t.tokenEndpoint,
+			jwksFile:          t.jwksFile,
+			metadata:          t.metadata,
+			discoveryDoc:      resource{body: t.discoveryDoc.body},
+			jwks:              resource{body: t.jwks.body},
+		})
+		glog.V(3).Infof("OIDC policy %v switched to leader %v", key, leader)
+	}
+}
+
+// Apply applies the documents of the policy with the key that the leader published. It is ignored by the leader.
+func (r *Refresher) Apply(key string, docs Documents) {
+	r.lock.Lock()
+	t, exists := r.targets[key]
+	if !r.follower || !exists {
+		r.lock.Unlock()
+		return
+	}
+
+	changed := false
+	if docs.Discovery != nil && !bytes.Equal(t.discoveryDoc.body, docs.Discovery) {
+		metadata, err := parseProviderMetadata(docs.Discovery, t.relaxed)
+		if err != nil {
+			glog.Warningf("Invalid discovery document of OIDC policy %v from the leader: %v", key, err)
+		} else {
+			t.discoveryDoc.body = docs.Discovery
+			t.metadata = &metadata
+			changed = true
+		}
+	}
+	if docs.JWKS != nil && !bytes.Equal(t.jwks.body, docs.JWKS) {
+		if err := writeFileAtomically(t.jwksFile, docs.JWKS); err != nil {
+			glog.Warningf("Failed to write the JWK Set of OIDC policy %v from the leader: %v", key, err)
+		} else {
+			t.jwks.body = docs.JWKS
+		}
+	}
+	r.lock.Unlock()
+
+	if changed {
+		glog.V(3).Infof("The discovery document of OIDC policy %v changed", key)
+		r.onChange(key)
+	}
+}
+
+// Run blocks until stopCh is closed and stops refreshing the policies.
+func (r *Refresher) Run(stopCh <-chan struct{}) {
+	<-stopCh
+	r.cancel()
+}
+
+// Update starts refreshing the discovery document and the JWK Set of the policy with the key, or restarts it
+// when the endpoints of the policy changed. An empty discoveryEndpoint disables the discovery. relaxed accepts
+// the discovery documents of providers that deviate from the spec, see parseProviderMetadata.
+func (r *Refresher) Update(key string, discoveryEndpoint string, jwksURI string, tokenEndpoint string, relaxed bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if t, exists := r.targets[key]; exists {
+		if t.discoveryEndpoint == discoveryEndpoint && t.jwksURI == jwksURI && t.tokenEndpoint == tokenEndpoint &&
+			t.relaxed == relaxed {
+			return
+		}
+		t.cancel()
+	}
+
+	r.start(&target{
+		key:               key,
+		discoveryEndpoint: discoveryEndpoint,
+		jwksURI:           jwksURI,
+		tokenEndpoint:     tokenEndpoint,
+		relaxed:           relaxed,
+		jwksFile:          r.JWKSFile(key),
+	})
+}
+
+// start adds the target and starts refreshing it, unless the Refresher is a follower. It must be called with the lock held.
+func (r *Refresher) start(t *target) {
+	ctx, cancel := context.WithCancel(WithPolicy(r.ctx, t.key))
+	t.cancel = cancel
+	r.targets[t.key] = t
+	if !r.follower {
+		go r.refresh(ctx, t)
+	}
+}
+
+// Remove stops refreshing the policy with the key and removes its JWK Set.
+func (r *Refresher) Remove(key string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	t, exists := r.targets[key]
+	if !exists {
+		return
+	}
+	t.cancel()
+	delete(r.targets, key)
+
+	if err := os.Remove(t.jwksFile); err != nil && !os.IsNotExist(err) {
+		glog.Warningf("Failed to remove the JWK Set of OIDC policy %v: %v", key, err)
+	}
+}
+
+// Metadata returns the last discovery document of the policy with the key.
+func (r *Refresher) Metadata(key string) (ProviderMetadata, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	t, exists := r.targets[key]
+	if !exists || t.metadata == nil {
+		return ProviderMetadata{}, false
+	}
+	return *t.metadata, true
+}
+
+// JWKSFile returns the file of the JWK Set of the policy with the key. The file doesn't exist until the
+// JWK Set was fetched.
+func (r *Refresher) JWKSFile(key string) string {
+	return filepath.Join(r.jwksDir, strings.ReplaceAll(key, "/", "_")+".json")
+}
+
+type target struct {
+	key               string
+	discoveryEndpoint string
+	jwksURI           string
+	tokenEndpoint     string
+	relaxed           bool
+	jwksFile          string
+	cancel            context.CancelFunc
+
+	// the fields below are only used by the goroutine of the target, except metadata, which is guarded
+	// by the lock of the Refresher. A follower has no goroutine, and its fields are guarded by the lock.
+	metadata      *ProviderMetadata
+	discoveryDoc  resource
+	jwks          resource
+	discoveryNext time.Time
+	jwksNext      time.Time
+	failures      int
+	lastError     string
+	published     Documents
+}
+
+// resource is the state of a document fetched with conditional requests.
+type resource struct {
+	etag         string
+	lastModified string
+	body         []byte
+}
+
+func (r *Refresher) refresh(ctx context.Context, t *target) {
+	for {
+		wait := r.refreshTarget(ctx, t)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// refreshTarget fetches the documents of the target that are due and returns the time to wait for the next fetch.
+func (r *Refresher) refreshTarget(ctx context.Context, t *target) time.Duration {
+	now := time.Now()
+	err := r.refreshDiscovery(ctx, t, now)
+	if err == nil {
+		err = r.refreshJWKS(ctx, t, now)
+	}
+	if err == nil {
+		err = r.checkTokenEndpoint(ctx, t)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0
+		}
+		t.failures++
+		backoff := backoffDuration(t.failures)
+		var circuitErr *CircuitOpenError
+		if errors.As(err, &circuitErr) {
+			backoff = max(backoff, circuitErr.RetryAfter)
+		}
+		glog.Warningf("Failed to refresh the provider metadata of OIDC policy %v, retrying in %v: %v", t.key, backoff, err)
+		if err.Error() != t.lastError {
+			t.lastError = err.Error()
+			r.onError(t.key, err)
+		}
+		return backoff
+	}
+	t.failures = 0
+	t.lastError = ""
+	r.publishTarget(t)
+
+	next := t.jwksNext
+	if t.discoveryEndpoint != "" && t.discoveryNext.Before(next) {
+		next = t.discoveryNext
+	}
+	return time.Until(next)
+}
+
+// publishTarget publishes the documents of the target if they changed since they were last published.
+func (r *Refresher) publishTarget(t *target) {
+	if r.publish == nil {
+		return
+	}
+	docs := Documents{Discovery: t.discoveryDoc.body, JWKS: t.jwks.body}
+	if bytes.Equal(docs.Discovery, t.published.Discovery) && bytes.Equal(docs.JWKS, t.published.JWKS) {
+		return
+	}
+	if err := r.publish(t.key, docs); err != nil {
+		glog.Warningf("Failed to publish the provider documents of OIDC policy %v: %v", t.key, err)
+		return
+	}
+	t.published = docs
+}
+
+func (r *Refresher) refreshDiscovery(ctx context.Context, t *target, now time.Time) error {
+	if t.discoveryEndpoint == "" || now.Before(t.discoveryNext) {
+		return nil
+	}
+
+	changed, maxAge, err := r.fetch(ctx, t.discoveryEndpoint, &t.discoveryDoc, func(body []byte) error {
+		metadata, err := parseProviderMetadata(body, t.relaxed)
+		if err != nil {
+			return fmt.Errorf("invalid discovery document: %w", err)
+		}
+		if metadata.JwksURI == "" && (!t.relaxed || t.jwksURI == "") {
+			return fmt.Errorf("discovery document has no jwks_uri")
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("discovery document %v: %w", t.discoveryEndpoint, err)
+	}
+	t.discoveryNext = now.Add(maxAge)
+	if !changed {
+		return nil
+	}
+
+	metadata, _ := parseProviderMetadata(t.discoveryDoc.body, t.relaxed)
+
+	r.lock.Lock()
+	t.metadata = &metadata
+	r.lock.Unlock()
+
+	// fetch the JWK Set now, its URI might have changed
+	t.jwksNext = time.Time{}
+	glog.V(3).Infof("The discovery document of OIDC policy %v changed", t.key)
+	r.onChange(t.key)
+	return nil
+}
+
+func (r *Refresher) refreshJWKS(ctx context.Context, t *target, now time.Time) error {
+	if now.Before(t.jwksNext) {
+		return nil
+	}
+
+	jwksURI := t.jwksURI
+	r.lock.Lock()
+	if t.metadata != nil && t.metadata.JwksURI != "" {
+		jwksURI = t.metadata.JwksURI
+	}
+	r.lock.Unlock()
+	if jwksURI == "" {
+		t.jwksNext = now.Add(maxRefreshInterval)
+		return nil
+	}
+
+	fetched := t.jwks.body != nil
+	changed, maxAge, err := r.fetch(ctx, jwksURI, &t.jwks, func(body []byte) error {
+		var jwks struct {
+			Keys []json.RawMessage `json:"keys"`
+		}
+		if err := json.Unmarshal(body, &jwks); err != nil {
+			return fmt.Errorf("invalid JWK Set: %w", err)
+		}
+		if len(jwks.Keys) == 0 {
+			return fmt.Errorf("JWK Set has no keys")
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("JWK Set %v: %w", jwksURI, err)
+	}
+	if changed {
+		if ctx.Err() != nil {
+			// the policy was removed or updated while the JWK Set was fetched
+			return ctx.Err()
+		}
+		if err := writeFileAtomically(t.jwksFile, t.jwks.body); err != nil {
+			return err
+		}
+		glog.V(3).Infof("The JWK Set of OIDC policy %v changed", t.key)
+		if fetched && r.onRotation != nil {
+			r.onRotation(t.key)
+		}
+	}
+	t.jwksNext = now.Add(maxAge)
+	return nil
+}
+
+// checkTokenEndpoint checks that the token endpoint of the target is reachable. The request has no parameters,
+// so any response other than Not Found or a server error means that the endpoint works.
+func (r *Refresher) checkTokenEndpoint(ctx context.Context, t *target) error {
+	if t.tokenEndpoint == "" {
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.tokenEndpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := r.limiter.Do(r.httpClient, req)
+	if err != nil {
+		return fmt.Errorf("token endpoint %v is unreachable: %w", t.tokenEndpoint, err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("token endpoint %v: unexpected response status %d", t.tokenEndpoint, resp.StatusCode)
+	}
+	return nil
+}
+
+// fetch fetches the document at the URL with a conditional request and updates the resource when the
+// document is valid. It returns whether the document changed and how long it can be cached.
+func (r *Refresher) fetch(ctx context.Context, url string, res *resource, validate func([]byte) error) (bool, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if res.etag != "" {
+		req.Header.Set("If-None-Match", res.etag)
+	}
+	if res.lastModified != "" {
+		req.Header.Set("If-Modified-Since", res.lastModified)
+	}
+
+	resp, err := r.limiter.Do(r.httpClient, req)
+	if err != nil {
+		return false, 0, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	maxAge := cacheMaxAge(resp.Header.Get("Cache-Control"))
+	if resp.StatusCode == http.StatusNotModified {
+		return false, maxAge, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, 0, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to read the response body: %w", err)
+	}
+	if err := validate(body); err != nil {
+		return false, 0, err
+	}
+
+	changed := !bytes.Equal(res.body, body)
+	res.etag = resp.Header.Get("ETag")
+	res.lastModified = resp.Header.Get("Last-Modified")
+	res.body = body
+	return changed, maxAge, nil
+}
+
+// utf8BOM is the byte order mark that some providers write before their discovery documents.
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// parseProviderMetadata parses a discovery document. A relaxed document, e.g. the metadata of ADFS, can start with
+// a byte order mark, and its fields of another type than in the spec are ignored instead of failing the document.
+func parseProviderMetadata(body []byte, relaxed bool) (ProviderMetadata, error) {
+	var metadata ProviderMetadata
+	if !relaxed {
+		err := json.Unmarshal(body, &metadata)
+		return metadata, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(bytes.TrimPrefix(body, utf8BOM), &fields); err != nil {
+		return metadata, err
+	}
+	for name, value := range map[string]*string{
+		"issuer":                 &metadata.Issuer,
+		"authorization_endpoint": &metadata.AuthorizationEndpoint,
+		"token_endpoint":         &metadata.TokenEndpoint,
+		"jwks_uri":               &metadata.JwksURI,
+		"end_session_endpoint":   &metadata.EndSessionEndpoint,
+		"userinfo_endpoint":      &metadata.UserinfoEndpoint,
+		"revocation_endpoint":    &metadata.RevocationEndpoint,
+	} {
+		if raw, exists := fields[name]; exists {
+			_ = json.Unmarshal(raw, value)
+		}
+	}
+	return metadata, nil
+}
+
+// cacheMaxAge returns how long a response can be cached according to its Cache-Control header,
+// bounded by the minimum and maximum refresh intervals.
+func cacheMaxAge(cacheControl string) time.Duration {
+	maxAge := defaultRefreshInterval
+	for _, directive := range strings.Split(cacheControl, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-cache", "no-store":
+			return minRefreshInterval
+		case "max-age":
+			seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+			if err == nil {
+				maxAge = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+
+	if maxAge < minRefreshInterval {
+		return minRefreshInterval
+	}
+	if maxAge > maxRefreshInterval {
+		return maxRefreshInterval
+	}
+	return maxAge
+}
+
+func writeFileAtomically(name string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return fmt.Errorf("failed to create the directory of %v: %w", name, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name))
+	if err != nil {
+		return fmt.Errorf("failed to create a temporary file for %v: %w", name, err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write %v: %w", tmp.Name(), err)
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to change the mode of %v: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close %v: %w", tmp.Name(), err)
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return fmt.Errorf("failed to rename %v to %v: %w", tmp.Name(), name, err)
+	}
+	return nil
+}
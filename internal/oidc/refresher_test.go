@@ -0,0 +1,339 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRefreshTarget(t *testing.T) {
+	t.Parallel()
+	var jwks atomic.Value
+	jwks.Store(`{"keys":[{"kty":"RSA","kid":"1"}]}`)
+	var jwksRequests, notModified atomic.Int32
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=7200")
+		_, _ = w.Write([]byte(`{"issuer":"` + ts.URL + `","jwks_uri":"` + ts.URL + `/certs"}`))
+	})
+	mux.HandleFunc("/certs", func(w http.ResponseWriter, r *http.Request) {
+		jwksRequests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` && jwks.Load() == `{"keys":[{"kty":"RSA","kid":"1"}]}` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", "max-age=300")
+		_, _ = w.Write([]byte(jwks.Load().(string)))
+	})
+
+	var changes []string
+	var rotations []string
+	r := NewRefresher(ts.Client(), t.TempDir(), func(key string) { changes = append(changes, key) }, func(string, error) {})
+	r.SetRotationHandler(func(key string) { rotations = append(rotations, key) })
+	target := &target{
+		key:               "default/oidc-policy",
+		discoveryEndpoint: ts.URL + "/.well-known/openid-configuration",
+		jwksFile:          r.JWKSFile("default/oidc-policy"),
+		cancel:            func() {},
+	}
+	r.targets[target.key] = target
+
+	wait := r.refreshTarget(context.Background(), target)
+	if wait <= 4*time.Minute || wait > 5*time.Minute {
+		t.Errorf("refreshTarget() returned %v, want the max-age of the JWK Set", wait)
+	}
+	if len(changes) != 1 {
+		t.Errorf("refreshTarget() reported %d changes of the discovery document, want 1", len(changes))
+	}
+	if metadata, exists := r.Metadata(target.key); !exists || metadata.JwksURI != ts.URL+"/certs" {
+		t.Errorf("Metadata() returned %+v, %v, want the jwks_uri of the discovery document", metadata, exists)
+	}
+	content, err := os.ReadFile(target.jwksFile)
+	if err != nil || string(content) != jwks.Load() {
+		t.Errorf("refreshTarget() wrote %q, %v, want %q", content, err, jwks.Load())
+	}
+
+	// the discovery document is not due, the JWK Set is not modified
+	target.jwksNext = time.Time{}
+	r.refreshTarget(context.Background(), target)
+	if jwksRequests.Load() != 2 || notModified.Load() != 1 {
+		t.Errorf("refreshTarget() sent %d JWK Set requests with %d not modified, want 2 and 1", jwksRequests.Load(), notModified.Load())
+	}
+	if len(changes) != 1 {
+		t.Errorf("refreshTarget() reported %d changes of the discovery document, want 1", len(changes))
+	}
+
+	// the keys were rotated
+	jwks.Store(`{"keys":[{"kty":"RSA","kid":"2"}]}`)
+	target.jwksNext = time.Time{}
+	r.refreshTarget(context.Background(), target)
+	content, err = os.ReadFile(target.jwksFile)
+	if err != nil || string(content) != jwks.Load() {
+		t.Errorf("refreshTarget() wrote %q, %v, want %q", content, err, jwks.Load())
+	}
+	if len(rotations) != 1 {
+		t.Errorf("refreshTarget() reported %d rotations of the keys, want 1", len(rotations))
+	}
+
+	r.Remove(target.key)
+	if _, err := os.Stat(target.jwksFile); !os.IsNotExist(err) {
+		t.Errorf("Remove() kept the JWK Set: %v", err)
+	}
+}
+
+func TestRefreshTargetBacksOffOnFailure(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"keys":[]}`))
+	}))
+	defer ts.Close()
+
+	var errs []error
+	r := NewRefresher(ts.Client(), t.TempDir(), func(string) {}, func(_ string, err error) { errs = append(errs, err) })
+	target := &target{
+		key:      "default/oidc-policy",
+		jwksURI:  ts.URL,
+		jwksFile: r.JWKSFile("default/oidc-policy"),
+	}
+
+	var last time.Duration
+	for i := 0; i < 3; i++ {
+		wait := r.refreshTarget(context.Background(), target)
+		if wait <= last {
+			t.Errorf("refreshTarget() returned %v after %d failures, want more than %v", wait, i+1, last)
+		}
+		last = wait
+	}
+	if _, err := os.Stat(target.jwksFile); !os.IsNotExist(err) {
+		t.Errorf("refreshTarget() wrote an invalid JWK Set: %v", err)
+	}
+	if len(errs) != 1 {
+		t.Errorf("refreshTarget() reported %d errors after the same failure, want 1", len(errs))
+	}
+}
+
+func TestRefreshTargetChecksTokenEndpoint(t *testing.T) {
+	t.Parallel()
+	var tokenStatus atomic.Int32
+	tokenStatus.Store(http.StatusNotFound)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/certs", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"keys":[{"kty":"RSA","kid":"1"}]}`))
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("the token endpoint received a %v request, want POST", r.Method)
+		}
+		w.WriteHeader(int(tokenStatus.Load()))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	var errs []error
+	r := NewRefresher(ts.Client(), t.TempDir(), func(string) {}, func(_ string, err error) { errs = append(errs, err) })
+	target := &target{
+		key:           "default/oidc-policy",
+		jwksURI:       ts.URL + "/certs",
+		tokenEndpoint: ts.URL + "/token",
+		jwksFile:      r.JWKSFile("default/oidc-policy"),
+	}
+
+	r.refreshTarget(context.Background(), target)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "unexpected response status 404") {
+		t.Errorf("refreshTarget() reported the errors %v, want the status of the token endpoint", errs)
+	}
+
+	// the token endpoint rejects the request without the parameters
+	tokenStatus.Store(http.StatusBadRequest)
+	r.refreshTarget(context.Background(), target)
+	if target.failures != 0 || len(errs) != 1 {
+		t.Errorf("refreshTarget() failed %d times with the errors %v, want no new failure", target.failures, errs)
+	}
+
+	target.tokenEndpoint = "http://127.0.0.1:1/token"
+	r.refreshTarget(context.Background(), target)
+	if len(errs) != 2 || !strings.Contains(errs[1].Error(), "is unreachable") {
+		t.Errorf("refreshTarget() reported the errors %v, want an unreachable token endpoint", errs)
+	}
+}
+
+func TestParseProviderMetadata(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		body     string
+		relaxed  bool
+		expected ProviderMetadata
+		err      bool
+		msg      string
+	}{
+		{
+			body:     `{"issuer":"https://idp.example.com","jwks_uri":"https://idp.example.com/certs"}`,
+			expected: ProviderMetadata{Issuer: "https://idp.example.com", JwksURI: "https://idp.example.com/certs"},
+			msg:      "standard document",
+		},
+		{
+			body: "\xef\xbb\xbf" + `{"issuer":"https://adfs.example.com/adfs","jwks_uri":"https://adfs.example.com/adfs/discovery/keys"}`,
+			err:  true,
+			msg:  "byte order mark",
+		},
+		{
+			body:     "\xef\xbb\xbf" + `{"issuer":"https://adfs.example.com/adfs","jwks_uri":"https://adfs.example.com/adfs/discovery/keys"}`,
+			relaxed:  true,
+			expected: ProviderMetadata{Issuer: "https://adfs.example.com/adfs", JwksURI: "https://adfs.example.com/adfs/discovery/keys"},
+			msg:      "relaxed byte order mark",
+		},
+		{
+			body: `{"issuer":"https://adfs.example.com/adfs","end_session_endpoint":["https://adfs.example.com/adfs/oauth2/logout"]}`,
+			err:  true,
+			msg:  "field of another type",
+		},
+		{
+			body:     `{"issuer":"https://adfs.example.com/adfs","end_session_endpoint":["https://adfs.example.com/adfs/oauth2/logout"]}`,
+			relaxed:  true,
+			expected: ProviderMetadata{Issuer: "https://adfs.example.com/adfs"},
+			msg:      "relaxed field of another type",
+		},
+		{
+			body:    `["https://adfs.example.com/adfs"]`,
+			relaxed: true,
+			err:     true,
+			msg:     "relaxed document that is not an object",
+		},
+	}
+	for _, test := range tests {
+		metadata, err := parseProviderMetadata([]byte(test.body), test.relaxed)
+		if (err != nil) != test.err {
+			t.Errorf("parseProviderMetadata() returned error %v for the case of %s", err, test.msg)
+			continue
+		}
+		if err == nil && metadata != test.expected {
+			t.Errorf("parseProviderMetadata() returned %+v, want %+v for the case of %s", metadata, test.expected, test.msg)
+		}
+	}
+}
+
+func TestCacheMaxAge(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		cacheControl string
+		expected     time.Duration
+	}{
+		{
+			cacheControl: "",
+			expected:     time.Hour,
+		},
+		{
+			cacheControl: "public, max-age=3600, must-revalidate",
+			expected:     time.Hour,
+		},
+		{
+			cacheControl: "max-age=5",
+			expected:     time.Minute,
+		},
+		{
+			cacheControl: "max-age=604800",
+			expected:     24 * time.Hour,
+		},
+		{
+			cacheControl: "no-store",
+			expected:     time.Minute,
+		},
+	}
+	for _, test := range tests {
+		if got := cacheMaxAge(test.cacheControl); got != test.expected {
+			t.Errorf("cacheMaxAge(%q) returned %v, want %v", test.cacheControl, got, test.expected)
+		}
+	}
+}
+
+func TestBackoffDuration(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		failures int
+		max      time.Duration
+	}{
+		{failures: 1, max: 10 * time.Second},
+		{failures: 3, max: 40 * time.Second},
+		{failures: 100, max: 10 * time.Minute},
+	}
+	for _, test := range tests {
+		got := backoffDuration(test.failures)
+		if got > test.max || got < test.max*4/5 {
+			t.Errorf("backoffDuration(%d) returned %v, want between %v and %v", test.failures, got, test.max*4/5, test.max)
+		}
+	}
+}
+
+func TestRefresherDistribution(t *testing.T) {
+	t.Parallel()
+	discovery := `{"issuer":"https://idp.example.com","jwks_uri":"https://idp.example.com/certs"}`
+	jwks := `{"keys":[{"kty":"RSA","kid":"1"}]}`
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"issuer":"https://idp.example.com","jwks_uri":"` + ts.URL + `/certs"}`))
+	})
+	mux.HandleFunc("/certs", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(jwks))
+	})
+
+	// the leader publishes the documents once, until they change
+	var published []Documents
+	leader := NewRefresher(ts.Client(), t.TempDir(), func(string) {}, func(string, error) {})
+	leader.EnableDistribution(func(_ string, docs Documents) error {
+		published = append(published, docs)
+		return nil
+	})
+	leader.SetLeader(true)
+	leaderTarget := &target{
+		key:               "default/oidc-policy",
+		discoveryEndpoint: ts.URL + "/.well-known/openid-configuration",
+		jwksFile:          leader.JWKSFile("default/oidc-policy"),
+		cancel:            func() {},
+	}
+	leader.targets[leaderTarget.key] = leaderTarget
+
+	leader.refreshTarget(context.Background(), leaderTarget)
+	leaderTarget.jwksNext = time.Time{}
+	leader.refreshTarget(context.Background(), leaderTarget)
+	if len(published) != 1 || string(published[0].JWKS) != jwks {
+		t.Fatalf("refreshTarget() published %q, want the JWK Set once", published)
+	}
+
+	// the follower applies the documents of the leader without fetching them
+	var changes []string
+	follower := NewRefresher(&http.Client{}, t.TempDir(), func(key string) { changes = append(changes, key) }, func(string, error) {})
+	follower.EnableDistribution(func(string, Documents) error { return nil })
+	follower.Update("default/oidc-policy", "https://idp.example.com/.well-known/openid-configuration", "", "", false)
+
+	follower.Apply("default/oidc-policy", Documents{Discovery: []byte(discovery), JWKS: []byte(jwks)})
+	follower.Apply("default/oidc-policy", Documents{Discovery: []byte(discovery), JWKS: []byte(jwks)})
+	follower.Apply("default/other-policy", Documents{Discovery: []byte(discovery), JWKS: []byte(jwks)})
+	if len(changes) != 1 {
+		t.Errorf("Apply() reported %d changes of the discovery document, want 1", len(changes))
+	}
+	if metadata, exists := follower.Metadata("default/oidc-policy"); !exists || metadata.JwksURI != "https://idp.example.com/certs" {
+		t.Errorf("Metadata() returned %+v, %v, want the jwks_uri of the published discovery document", metadata, exists)
+	}
+	content, err := os.ReadFile(follower.JWKSFile("default/oidc-policy"))
+	if err != nil || string(content) != jwks {
+		t.Errorf("Apply() wrote %q, %v, want %q", content, err, jwks)
+	}
+
+	// the metadata is kept when the follower becomes the leader
+	follower.SetLeader(true)
+	defer follower.Remove("default/oidc-policy")
+	if _, exists := follower.Metadata("default/oidc-policy"); !exists {
+		t.Error("Metadata() returned no metadata after SetLeader()")
+	}
+}
@@ -0,0 +1,67 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Revocation holds the tokens of a session to revoke at the revocation endpoint of its policy.
+type Revocation struct {
+	Endpoint     string
+	ClientID     string
+	ClientSecret string
+	RefreshToken string
+	AccessToken  string
+}
+
+// RevokeTokens revokes the refresh token and the access token of the revocation, as per
+// https://www.rfc-editor.org/rfc/rfc7009. The refresh token is revoked first, because providers may also
+// revoke the access tokens issued with it. The requests go through the limiter of the providers.
+func (r *Refresher) RevokeTokens(ctx context.Context, rev Revocation) error {
+	for _, token := range []struct {
+		value string
+		hint  string
+	}{
+		{value: rev.RefreshToken, hint: "refresh_token"},
+		{value: rev.AccessToken, hint: "access_token"},
+	} {
+		if token.value == "" || token.value == "-" {
+			continue
+		}
+		if err := r.revokeToken(ctx, rev, token.value, token.hint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Refresher) revokeToken(ctx context.Context, rev Revocation, token string, hint string) error {
+	form := url.Values{
+		"token":           {token},
+		"token_type_hint": {hint},
+		"client_id":       {rev.ClientID},
+		"client_secret":   {rev.ClientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rev.Endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := r.limiter.Do(r.httpClient, req)
+	if err != nil {
+		return fmt.Errorf("revocation endpoint %v is unreachable: %w", rev.Endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	// The provider responds with 200 also for tokens that are invalid or already revoked
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("revocation endpoint %v: unexpected response status %d for the %v: %s", rev.Endpoint, resp.StatusCode, hint, body)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRevokeTokens(t *testing.T) {
+	t.Parallel()
+	var hints []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := req.ParseForm(); err != nil {
+			t.Errorf("failed to parse the revocation request: %v", err)
+		}
+		if req.PostForm.Get("client_id") != "nginx-plus" || req.PostForm.Get("client_secret") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		hints = append(hints, req.PostForm.Get("token_type_hint")+"="+req.PostForm.Get("token"))
+	}))
+	defer ts.Close()
+
+	r := NewRefresher(ts.Client(), t.TempDir(), func(string) {}, func(string, error) {})
+	rev := Revocation{
+		Endpoint:     ts.URL + "/revoke",
+		ClientID:     "nginx-plus",
+		ClientSecret: "secret",
+		RefreshToken: "refresh+token",
+		AccessToken:  "access-token",
+	}
+	if err := r.RevokeTokens(context.Background(), rev); err != nil {
+		t.Fatalf("RevokeTokens() returned %v", err)
+	}
+	expected := []string{"refresh_token=refresh+token", "access_token=access-token"}
+	if !reflect.DeepEqual(hints, expected) {
+		t.Errorf("RevokeTokens() revoked %v, want %v", hints, expected)
+	}
+
+	// a session without a refresh token
+	hints = nil
+	rev.RefreshToken = "-"
+	if err := r.RevokeTokens(context.Background(), rev); err != nil {
+		t.Fatalf("RevokeTokens() returned %v", err)
+	}
+	if !reflect.DeepEqual(hints, []string{"access_token=access-token"}) {
+		t.Errorf("RevokeTokens() revoked %v, want only the access token", hints)
+	}
+
+	rev.ClientSecret = "wrong"
+	if err := r.RevokeTokens(context.Background(), rev); err == nil {
+		t.Error("RevokeTokens() returned no error for a rejected client")
+	}
+}
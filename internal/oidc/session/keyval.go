@@ -0,0 +1,237 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+const (
+	idTokensZone      = "oidc_id_tokens"
+	accessTokensZone  = "oidc_access_tokens"
+	refreshTokensZone = "refresh_tokens"
+	dpopKeysZone      = "oidc_dpop_keys"
+	groupsZone        = "oidc_groups"
+	idpsZone          = "oidc_session_idps"
+	policiesZone      = "oidc_session_policies"
+)
+
+// sessionZones are the keyval zones where the sessions are stored by the session cookie.
+var sessionZones = []string{idTokensZone, accessTokensZone, refreshTokensZone, dpopKeysZone, groupsZone, idpsZone, policiesZone}
+
+// exchangedTokenZones are the keyval zones of the exchanged tokens, stored by the session cookie and the audience.
+var exchangedTokenZones = []string{"oidc_exchanged_tokens", "oidc_exchanged_tokens_expiry"}
+
+// KeyValClient accesses the keyval zones of NGINX.
+type KeyValClient interface {
+	GetKeyValPairs(zoneName string) (map[string]string, error)
+	UpsertKeyValPair(zoneName string, key string, value string) error
+	DeleteKeyValPair(zoneName string, key string)
+}
+
+// KeyValStore is the Store of the sessions in the keyval zones of NGINX. The zones are shared by all policies,
+// so the sessions of a policy are recognized by the namespace and the name of the policy, which NGINX keeps in
+// the oidc_session_policies zone. The policies with the same client ID have different sessions.
+type KeyValStore struct {
+	client KeyValClient
+	policy string
+}
+
+// NewKeyValStore creates a KeyValStore of the sessions of the policy with the key, its namespace and name.
+func NewKeyValStore(client KeyValClient, policy string) *KeyValStore {
+	return &KeyValStore{
+		client: client,
+		policy: policy,
+	}
+}
+
+// Get returns the session with the ID.
+func (s *KeyValStore) Get(_ context.Context, id string) (Session, error) {
+	var sess Session
+	for _, field := range []struct {
+		zone  string
+		value *string
+	}{
+		{zone: idTokensZone, value: &sess.IDToken},
+		{zone: accessTokensZone, value: &sess.AccessToken},
+		{zone: refreshTokensZone, value: &sess.RefreshToken},
+		{zone: dpopKeysZone, value: &sess.DPoPKey},
+		{zone: groupsZone, value: &sess.Groups},
+		{zone: idpsZone, value: &sess.IdP},
+	} {
+		keyValPairs, err := s.client.GetKeyValPairs(field.zone)
+		if err != nil {
+			return Session{}, err
+		}
+		*field.value = keyValPairs[id]
+	}
+	if sess.IDToken == "" || sess.IDToken == "-" {
+		return Session{}, ErrNotFound
+	}
+	policies, err := s.client.GetKeyValPairs(policiesZone)
+	if err != nil {
+		return Session{}, err
+	}
+	if policies[id] != s.policy {
+		return Session{}, ErrNotFound
+	}
+	return sess, nil
+}
+
+// Save adds or replaces the session with the ID.
+func (s *KeyValStore) Save(_ context.Context, id string, sess Session) error {
+	for zone, value := range map[string]string{
+		idTokensZone:      sess.IDToken,
+		accessTokensZone:  sess.AccessToken,
+		refreshTokensZone: sess.RefreshToken,
+		dpopKeysZone:      sess.DPoPKey,
+		groupsZone:        sess.Groups,
+		idpsZone:          sess.IdP,
+		policiesZone:      s.policy,
+	} {
+		if value == "" {
+			continue
+		}
+		if err := s.client.UpsertKeyValPair(zone, id, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete deletes the session with the ID and its exchanged tokens.
+func (s *KeyValStore) Delete(_ context.Context, id string) error {
+	return s.deleteSessions(map[string]bool{id: true})
+}
+
+// DeleteAll deletes the sessions of the policy and their exchanged tokens.
+func (s *KeyValStore) DeleteAll(_ context.Context) (int, error) {
+	policies, err := s.client.GetKeyValPairs(policiesZone)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the policies of the sessions: %w", err)
+	}
+	ids := make(map[string]bool)
+	for id, policy := range policies {
+		if policy == s.policy {
+			ids[id] = true
+		}
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	return len(ids), s.deleteSessions(ids)
+}
+
+// List returns the sessions of the policy, ordered by their ID.
+func (s *KeyValStore) List(_ context.Context) ([]Info, error) {
+	idTokens, err := s.client.GetKeyValPairs(idTokensZone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the sessions: %w", err)
+	}
+	refreshTokens, err := s.client.GetKeyValPairs(refreshTokensZone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the refresh tokens: %w", err)
+	}
+	policies, err := s.client.GetKeyValPairs(policiesZone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the policies of the sessions: %w", err)
+	}
+
+	sessions := []Info{}
+	for id, idToken := range idTokens {
+		if idToken == "-" || policies[id] != s.policy {
+			continue
+		}
+		info := newInfo(id, idToken)
+		info.Refreshable = refreshTokens[id] != "" && refreshTokens[id] != "-"
+		sessions = append(sessions, info)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ID < sessions[j].ID })
+	return sessions, nil
+}
+
+// Revoke deletes the session with the ID like Delete, and marks the session as logged out, like a logout does,
+// so that NGINX doesn't load the session again from a session store or a session cookie. It returns the revoked
+// session, or ErrNotFound if the session doesn't exist or doesn't belong to the policy.
+func (s *KeyValStore) Revoke(ctx context.Context, id string) (Session, error) {
+	sess, err := s.Get(ctx, id)
+	if err != nil {
+		return Session{}, err
+	}
+	if err := s.Delete(ctx, id); err != nil {
+		return Session{}, err
+	}
+	for _, zone := range []string{idTokensZone, accessTokensZone, refreshTokensZone} {
+		if err := s.client.UpsertKeyValPair(zone, id, "-"); err != nil {
+			return Session{}, fmt.Errorf("failed to mark the session as logged out in zone %v: %w", zone, err)
+		}
+	}
+	return sess, nil
+}
+
+// Close does nothing, the keyval zones are owned by NGINX.
+func (s *KeyValStore) Close() error {
+	return nil
+}
+
+func (s *KeyValStore) deleteSessions(ids map[string]bool) error {
+	for _, zone := range sessionZones {
+		s.deleteKeyValPairs(zone, func(key string) bool { return ids[key] })
+	}
+	for _, zone := range exchangedTokenZones {
+		s.deleteKeyValPairs(zone, func(key string) bool {
+			id, _, _ := strings.Cut(key, ":")
+			return ids[id]
+		})
+	}
+	return nil
+}
+
+func (s *KeyValStore) deleteKeyValPairs(zoneName string, match func(key string) bool) {
+	keyValPairs, err := s.client.GetKeyValPairs(zoneName)
+	if err != nil {
+		glog.Warningf("Failed to get the key value pairs of zone %v: %v", zoneName, err)
+		return
+	}
+	for key := range keyValPairs {
+		if match(key) {
+			s.client.DeleteKeyValPair(zoneName, key)
+		}
+	}
+}
+
+// newInfo returns the Info of the session with the ID token. The signature of the token is not verified,
+// NGINX already did that when it stored the token.
+func newInfo(id string, idToken string) Info {
+	info := Info{ID: id}
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return info
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return info
+	}
+	var claims struct {
+		Sub string  `json:"sub"`
+		Iat float64 `json:"iat"`
+		Exp float64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return info
+	}
+	info.Subject = claims.Sub
+	if claims.Iat > 0 {
+		info.IssuedAt = time.Unix(int64(claims.Iat), 0).UTC()
+	}
+	if claims.Exp > 0 {
+		info.ExpiresAt = time.Unix(int64(claims.Exp), 0).UTC()
+	}
+	return info
+}
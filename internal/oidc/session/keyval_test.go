@@ -0,0 +1,122 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeKeyValClient is a KeyValClient with the keyval zones in memory.
+type fakeKeyValClient struct {
+	zones map[string]map[string]string
+}
+
+func (c *fakeKeyValClient) GetKeyValPairs(zoneName string) (map[string]string, error) {
+	return c.zones[zoneName], nil
+}
+
+func (c *fakeKeyValClient) UpsertKeyValPair(zoneName string, key string, value string) error {
+	if c.zones[zoneName] == nil {
+		c.zones[zoneName] = make(map[string]string)
+	}
+	c.zones[zoneName][key] = value
+	return nil
+}
+
+func (c *fakeKeyValClient) DeleteKeyValPair(zoneName string, key string) {
+	delete(c.zones[zoneName], key)
+}
+
+func testIDToken(claims string) string {
+	return "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".c2lnbmF0dXJl"
+}
+
+func newTestKeyValStore() (*KeyValStore, *fakeKeyValClient) {
+	client := &fakeKeyValClient{zones: map[string]map[string]string{
+		idTokensZone: {
+			"session-1": testIDToken(`{"sub":"alice","aud":"app","iat":1700000000,"exp":1700003600}`),
+			"session-2": testIDToken(`{"sub":"bob","aud":"app","iat":1700000100,"exp":1700003700}`),
+			"session-3": testIDToken(`{"sub":"carol","aud":"app","iat":1700000200,"exp":1700003800}`),
+			"session-4": "-",
+		},
+		accessTokensZone:        {"session-1": "access-token-1", "session-2": "access-token-2"},
+		refreshTokensZone:       {"session-1": "refresh-token-1", "session-2": "-"},
+		"oidc_exchanged_tokens": {"session-1:https://api.example.com": "exchanged-token"},
+		// session-3 is of another policy with the same client ID
+		policiesZone: {"session-1": "default/oidc-policy", "session-2": "default/oidc-policy", "session-3": "default/other-policy", "session-4": "default/oidc-policy"},
+	}}
+	store := NewKeyValStore(client, "default/oidc-policy")
+	return store, client
+}
+
+func TestKeyValStoreList(t *testing.T) {
+	t.Parallel()
+	store, _ := newTestKeyValStore()
+
+	got, err := store.List(context.Background())
+	if err != nil {
+		t.Fatalf("List() returned %v", err)
+	}
+	want := []Info{
+		{ID: "session-1", Subject: "alice", IssuedAt: time.Unix(1700000000, 0).UTC(), ExpiresAt: time.Unix(1700003600, 0).UTC(), Refreshable: true},
+		{ID: "session-2", Subject: "bob", IssuedAt: time.Unix(1700000100, 0).UTC(), ExpiresAt: time.Unix(1700003700, 0).UTC()},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("List() returned %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("List() returned %+v, want %+v", got[i], want[i])
+		}
+	}
+}
+
+func TestKeyValStoreRevoke(t *testing.T) {
+	t.Parallel()
+	store, client := newTestKeyValStore()
+	ctx := context.Background()
+
+	sess, err := store.Revoke(ctx, "session-1")
+	if err != nil {
+		t.Fatalf("Revoke() returned %v", err)
+	}
+	if sess.AccessToken != "access-token-1" || sess.RefreshToken != "refresh-token-1" {
+		t.Errorf("Revoke() returned %+v, want the tokens of the revoked session", sess)
+	}
+	for _, zone := range []string{idTokensZone, accessTokensZone, refreshTokensZone} {
+		if value := client.zones[zone]["session-1"]; value != "-" {
+			t.Errorf("Revoke() left %q in zone %v, want the session marked as logged out", value, zone)
+		}
+	}
+	if _, exists := client.zones["oidc_exchanged_tokens"]["session-1:https://api.example.com"]; exists {
+		t.Errorf("Revoke() didn't delete the exchanged tokens of the session")
+	}
+
+	if _, err := store.Revoke(ctx, "session-3"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Revoke() returned %v for a session of another policy, want ErrNotFound", err)
+	}
+	if _, err := store.Revoke(ctx, "session-1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Revoke() returned %v for a revoked session, want ErrNotFound", err)
+	}
+}
+
+func TestKeyValStoreDeleteAll(t *testing.T) {
+	t.Parallel()
+	store, client := newTestKeyValStore()
+
+	deleted, err := store.DeleteAll(context.Background())
+	if err != nil {
+		t.Fatalf("DeleteAll() returned %v", err)
+	}
+	if deleted != 3 {
+		t.Errorf("DeleteAll() deleted %d sessions, want 3", deleted)
+	}
+	if _, exists := client.zones[idTokensZone]["session-3"]; !exists {
+		t.Errorf("DeleteAll() deleted the session of another policy with the same client ID")
+	}
+	if _, exists := client.zones[idTokensZone]["session-1"]; exists {
+		t.Errorf("DeleteAll() didn't delete a session of the policy")
+	}
+}
@@ -0,0 +1,396 @@
+package session
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	redisTimeout      = 5 * time.Second
+	redisMaxIdleConns = 8
+	redisScanCount    = "100"
+)
+
+// RedisConfig configures a RedisStore.
+type RedisConfig struct {
+	// Address is the host and the port of the Redis or Valkey server.
+	Address  string
+	Database int
+	Username string
+	Password string
+	// TLSConfig enables TLS when it is set.
+	TLSConfig *tls.Config
+	// KeyPrefix is the prefix of the keys of the sessions, which separates the sessions of the policies.
+	KeyPrefix string
+	// TTL is how long a session is kept after it was last saved.
+	TTL time.Duration
+}
+
+// RedisStore is the Store of the sessions in Redis or Valkey. Every session is a hash with the tokens
+// of the session, which expires after the TTL.
+type RedisStore struct {
+	config RedisConfig
+	lock   sync.Mutex
+	idle   []*redisConn
+	closed bool
+}
+
+// NewRedisStore creates a RedisStore. The connections are opened when they are needed.
+func NewRedisStore(config RedisConfig) *RedisStore {
+	return &RedisStore{config: config}
+}
+
+// Get returns the session with the ID.
+func (s *RedisStore) Get(ctx context.Context, id string) (Session, error) {
+	reply, err := s.do(ctx, "HGETALL", s.config.KeyPrefix+id)
+	if err != nil {
+		return Session{}, err
+	}
+	fields, ok := reply.([]interface{})
+	if !ok {
+		return Session{}, fmt.Errorf("unexpected reply %T to HGETALL", reply)
+	}
+	if len(fields) == 0 {
+		return Session{}, ErrNotFound
+	}
+
+	var sess Session
+	for i := 0; i+1 < len(fields); i += 2 {
+		name, _ := fields[i].(string)
+		value, _ := fields[i+1].(string)
+		switch name {
+		case "id_token":
+			sess.IDToken = value
+		case "access_token":
+			sess.AccessToken = value
+		case "refresh_token":
+			sess.RefreshToken = value
+		case "dpop_key":
+			sess.DPoPKey = value
+		case "groups":
+			sess.Groups = value
+		case "idp":
+			sess.IdP = value
+		}
+	}
+	return sess, nil
+}
+
+// Save replaces the session with the ID and resets its expiry in a transaction.
+func (s *RedisStore) Save(ctx context.Context, id string, sess Session) error {
+	key := s.config.KeyPrefix + id
+	return s.withConn(ctx, func(c *redisConn) error {
+		for _, args := range [][]string{
+			{"MULTI"},
+			{"HSET", key, "id_token", sess.IDToken, "access_token", sess.AccessToken, "refresh_token", sess.RefreshToken, "dpop_key", sess.DPoPKey, "groups", sess.Groups, "idp", sess.IdP},
+			{"PEXPIRE", key, strconv.FormatInt(s.config.TTL.Milliseconds(), 10)},
+		} {
+			if _, err := c.do(args...); err != nil {
+				_, _ = c.do("DISCARD")
+				return err
+			}
+		}
+		reply, err := c.do("EXEC")
+		if err != nil {
+			return err
+		}
+		return checkExecReply(reply, 2)
+	})
+}
+
+// checkExecReply returns the first error reply of the commands of a transaction, or an error if the transaction
+// was aborted or the reply has not one element per command.
+func checkExecReply(reply interface{}, commands int) error {
+	if reply == nil {
+		return errors.New("the transaction was aborted")
+	}
+	results, ok := reply.([]interface{})
+	if !ok || len(results) != commands {
+		return fmt.Errorf("unexpected reply %v to EXEC", reply)
+	}
+	for _, result := range results {
+		if err, ok := result.(redisError); ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete deletes the session with the ID.
+func (s *RedisStore) Delete(ctx context.Context, id string) error {
+	_, err := s.do(ctx, "DEL", s.config.KeyPrefix+id)
+	return err
+}
+
+// DeleteAll deletes the sessions with the key prefix of the store.
+func (s *RedisStore) DeleteAll(ctx context.Context) (int, error) {
+	deleted := 0
+	err := s.withConn(ctx, func(c *redisConn) error {
+		cursor := "0"
+		for {
+			reply, err := c.do("SCAN", cursor, "MATCH", escapePattern(s.config.KeyPrefix)+"*", "COUNT", redisScanCount)
+			if err != nil {
+				return err
+			}
+			page, ok := reply.([]interface{})
+			if !ok || len(page) != 2 {
+				return fmt.Errorf("unexpected reply %v to SCAN", reply)
+			}
+			cursor, _ = page[0].(string)
+			keys, _ := page[1].([]interface{})
+			if len(keys) > 0 {
+				args := make([]string, 0, len(keys)+1)
+				args = append(args, "DEL")
+				for _, key := range keys {
+					k, _ := key.(string)
+					args = append(args, k)
+				}
+				n, err := c.do(args...)
+				if err != nil {
+					return err
+				}
+				count, _ := n.(int64)
+				deleted += int(count)
+			}
+			if cursor == "0" || cursor == "" {
+				return nil
+			}
+		}
+	})
+	return deleted, err
+}
+
+// Close closes the idle connections. The connections in use are closed when they are returned.
+func (s *RedisStore) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.closed = true
+	for _, c := range s.idle {
+		_ = c.conn.Close()
+	}
+	s.idle = nil
+	return nil
+}
+
+func (s *RedisStore) do(ctx context.Context, args ...string) (interface{}, error) {
+	var reply interface{}
+	err := s.withConn(ctx, func(c *redisConn) error {
+		var err error
+		reply, err = c.do(args...)
+		return err
+	})
+	return reply, err
+}
+
+// withConn calls f with an idle or a new connection. A connection that failed with a network error is discarded.
+func (s *RedisStore) withConn(ctx context.Context, f func(c *redisConn) error) error {
+	c, err := s.conn(ctx)
+	if err != nil {
+		return err
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(redisTimeout)
+	}
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		_ = c.conn.Close()
+		return err
+	}
+
+	err = f(c)
+	var redisErr redisError
+	if err != nil && !errors.As(err, &redisErr) {
+		_ = c.conn.Close()
+		return err
+	}
+	s.release(c)
+	return err
+}
+
+func (s *RedisStore) conn(ctx context.Context) (*redisConn, error) {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return nil, errors.New("the session store is closed")
+	}
+	if n := len(s.idle); n > 0 {
+		c := s.idle[n-1]
+		s.idle = s.idle[:n-1]
+		s.lock.Unlock()
+		return c, nil
+	}
+	s.lock.Unlock()
+	return s.dial(ctx)
+}
+
+func (s *RedisStore) release(c *redisConn) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed || len(s.idle) >= redisMaxIdleConns {
+		_ = c.conn.Close()
+		return
+	}
+	s.idle = append(s.idle, c)
+}
+
+// dial opens a connection, authenticates and selects the database.
+func (s *RedisStore) dial(ctx context.Context) (*redisConn, error) {
+	dialer := &net.Dialer{Timeout: redisTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.config.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %v: %w", s.config.Address, err)
+	}
+	if s.config.TLSConfig != nil {
+		tlsConn := tls.Client(conn, s.config.TLSConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("failed the TLS handshake with %v: %w", s.config.Address, err)
+		}
+		conn = tlsConn
+	}
+	if err := conn.SetDeadline(time.Now().Add(redisTimeout)); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	c := &redisConn{conn: conn, rd: bufio.NewReader(conn)}
+	if s.config.Password != "" {
+		args := []string{"AUTH", s.config.Password}
+		if s.config.Username != "" {
+			args = []string{"AUTH", s.config.Username, s.config.Password}
+		}
+		if _, err := c.do(args...); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("failed to authenticate with %v: %w", s.config.Address, err)
+		}
+	}
+	if s.config.Database != 0 {
+		if _, err := c.do("SELECT", strconv.Itoa(s.config.Database)); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("failed to select database %d of %v: %w", s.config.Database, s.config.Address, err)
+		}
+	}
+	return c, nil
+}
+
+// redisError is an error reply of the server. The connection can be used after it.
+type redisError string
+
+func (e redisError) Error() string {
+	return string(e)
+}
+
+// redisConn sends commands with the RESP protocol, see https://redis.io/docs/latest/develop/reference/protocol-spec/.
+type redisConn struct {
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+	if _, err := c.conn.Write(buf); err != nil {
+		return nil, err
+	}
+	return c.readReply()
+}
+
+// readReply reads a reply. Simple and bulk strings are returned as strings, integers as int64,
+// a null bulk string as nil and arrays as []interface{}. An error element of an array, like the reply of
+// a failed command of a transaction, is returned as a redisError in the array, so that the rest of the array
+// is read.
+func (c *redisConn) readReply() (interface{}, error) {
+	line, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(c.rd, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			item, err := c.readReply()
+			var redisErr redisError
+			if errors.As(err, &redisErr) {
+				items[i] = redisErr
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unexpected reply %q", line)
+}
+
+func (c *redisConn) readLine() (string, error) {
+	line, err := c.rd.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("malformed reply %q", line)
+	}
+	return line[:len(line)-2], nil
+}
+
+// escapePattern escapes the special characters of a glob-style pattern of the SCAN command.
+func escapePattern(s string) string {
+	buf := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '*', '?', '[', ']', '\\':
+			buf = append(buf, '\\')
+		}
+		buf = append(buf, s[i])
+	}
+	return string(buf)
+}
@@ -0,0 +1,306 @@
+package session
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis is a Redis server with the commands used by the RedisStore.
+type fakeRedis struct {
+	listener net.Listener
+	password string
+	lock     sync.Mutex
+	hashes   map[string]map[string]string
+	ttls     map[string]time.Duration
+	// stringKeys are the keys that hold a string instead of a hash
+	stringKeys map[string]bool
+	// abortExec makes EXEC reply with a null array, like after a failed WATCH
+	abortExec bool
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &fakeRedis{
+		listener:   listener,
+		password:   password,
+		hashes:     make(map[string]map[string]string),
+		ttls:       make(map[string]time.Duration),
+		stringKeys: make(map[string]bool),
+	}
+	go r.serve()
+	t.Cleanup(func() { _ = listener.Close() })
+	return r
+}
+
+func (r *fakeRedis) serve() {
+	for {
+		conn, err := r.listener.Accept()
+		if err != nil {
+			return
+		}
+		go r.serveConn(conn)
+	}
+}
+
+func (r *fakeRedis) serveConn(conn net.Conn) {
+	defer conn.Close() //nolint:errcheck
+	rd := bufio.NewReader(conn)
+	authenticated := r.password == ""
+	var queued [][]string
+	inMulti := false
+	for {
+		args, err := readCommand(rd)
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(args[0])
+		switch {
+		case cmd == "AUTH":
+			authenticated = args[len(args)-1] == r.password
+			if !authenticated {
+				fmt.Fprint(conn, "-WRONGPASS invalid password\r\n")
+				continue
+			}
+			fmt.Fprint(conn, "+OK\r\n")
+		case !authenticated:
+			fmt.Fprint(conn, "-NOAUTH Authentication required.\r\n")
+		case cmd == "MULTI":
+			inMulti = true
+			fmt.Fprint(conn, "+OK\r\n")
+		case cmd == "EXEC" && r.execAborted():
+			fmt.Fprint(conn, "*-1\r\n")
+			queued, inMulti = nil, false
+		case cmd == "EXEC":
+			fmt.Fprintf(conn, "*%d\r\n", len(queued))
+			for _, q := range queued {
+				fmt.Fprint(conn, r.exec(q))
+			}
+			queued, inMulti = nil, false
+		case inMulti:
+			queued = append(queued, args)
+			fmt.Fprint(conn, "+QUEUED\r\n")
+		default:
+			fmt.Fprint(conn, r.exec(args))
+		}
+	}
+}
+
+func (r *fakeRedis) execAborted() bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.abortExec
+}
+
+func (r *fakeRedis) exec(args []string) string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "SELECT":
+		return "+OK\r\n"
+	case "HSET":
+		if r.stringKeys[args[1]] {
+			return "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"
+		}
+		hash := r.hashes[args[1]]
+		if hash == nil {
+			hash = make(map[string]string)
+			r.hashes[args[1]] = hash
+		}
+		for i := 2; i+1 < len(args); i += 2 {
+			hash[args[i]] = args[i+1]
+		}
+		return ":4\r\n"
+	case "PEXPIRE":
+		ms, _ := strconv.Atoi(args[2])
+		r.ttls[args[1]] = time.Duration(ms) * time.Millisecond
+		return ":1\r\n"
+	case "HGETALL":
+		hash := r.hashes[args[1]]
+		var b strings.Builder
+		fmt.Fprintf(&b, "*%d\r\n", 2*len(hash))
+		for name, value := range hash {
+			fmt.Fprintf(&b, "$%d\r\n%s\r\n$%d\r\n%s\r\n", len(name), name, len(value), value)
+		}
+		return b.String()
+	case "DEL":
+		deleted := 0
+		for _, key := range args[1:] {
+			if _, exists := r.hashes[key]; exists {
+				delete(r.hashes, key)
+				deleted++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", deleted)
+	case "SCAN":
+		prefix := strings.TrimSuffix(strings.ReplaceAll(args[3], `\`, ""), "*")
+		var keys []string
+		for key := range r.hashes {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "*2\r\n$1\r\n0\r\n*%d\r\n", len(keys))
+		for _, key := range keys {
+			fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(key), key)
+		}
+		return b.String()
+	}
+	return "-ERR unknown command\r\n"
+}
+
+func readCommand(rd *bufio.Reader) ([]string, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if _, err := rd.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := rd.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
+
+func TestRedisStore(t *testing.T) {
+	t.Parallel()
+	redis := newFakeRedis(t, "secret")
+	store := NewRedisStore(RedisConfig{
+		Address:   redis.listener.Addr().String(),
+		Database:  1,
+		Username:  "nginx",
+		Password:  "secret",
+		KeyPrefix: "nginx-oidc:default/oidc-policy:",
+		TTL:       8 * time.Hour,
+	})
+	defer store.Close() //nolint:errcheck
+	ctx := context.Background()
+
+	sess := Session{IDToken: "id-token", AccessToken: "access-token", RefreshToken: "refresh-token", Groups: `["group-1"]`, IdP: "partners"}
+	if err := store.Save(ctx, "session-1", sess); err != nil {
+		t.Fatalf("Save() returned %v", err)
+	}
+	if ttl := redis.ttls["nginx-oidc:default/oidc-policy:session-1"]; ttl != 8*time.Hour {
+		t.Errorf("Save() set the expiry to %v, want 8h", ttl)
+	}
+
+	got, err := store.Get(ctx, "session-1")
+	if err != nil {
+		t.Fatalf("Get() returned %v", err)
+	}
+	if got != sess {
+		t.Errorf("Get() returned %+v, want %+v", got, sess)
+	}
+
+	if err := store.Delete(ctx, "session-1"); err != nil {
+		t.Fatalf("Delete() returned %v", err)
+	}
+	if _, err := store.Get(ctx, "session-1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() returned %v for a deleted session, want ErrNotFound", err)
+	}
+}
+
+func TestRedisStoreDeleteAll(t *testing.T) {
+	t.Parallel()
+	redis := newFakeRedis(t, "")
+	redis.hashes["nginx-oidc:default/other-policy:session-3"] = map[string]string{"id_token": "id-token"}
+	store := NewRedisStore(RedisConfig{
+		Address:   redis.listener.Addr().String(),
+		KeyPrefix: "nginx-oidc:default/oidc-policy:",
+		TTL:       time.Hour,
+	})
+	defer store.Close() //nolint:errcheck
+	ctx := context.Background()
+
+	for _, id := range []string{"session-1", "session-2"} {
+		if err := store.Save(ctx, id, Session{IDToken: "id-token"}); err != nil {
+			t.Fatalf("Save() returned %v", err)
+		}
+	}
+	deleted, err := store.DeleteAll(ctx)
+	if err != nil {
+		t.Fatalf("DeleteAll() returned %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("DeleteAll() deleted %d sessions, want 2", deleted)
+	}
+	if _, exists := redis.hashes["nginx-oidc:default/other-policy:session-3"]; !exists {
+		t.Errorf("DeleteAll() deleted a session of another policy")
+	}
+}
+
+func TestRedisStoreSaveFailsWithFailedTransaction(t *testing.T) {
+	t.Parallel()
+	redis := newFakeRedis(t, "")
+	redis.stringKeys["nginx-oidc:default/oidc-policy:session-1"] = true
+	store := NewRedisStore(RedisConfig{
+		Address:   redis.listener.Addr().String(),
+		KeyPrefix: "nginx-oidc:default/oidc-policy:",
+		TTL:       time.Hour,
+	})
+	defer store.Close() //nolint:errcheck
+	ctx := context.Background()
+
+	err := store.Save(ctx, "session-1", Session{IDToken: "id-token"})
+	if err == nil || !strings.HasPrefix(err.Error(), "WRONGTYPE") {
+		t.Errorf("Save() returned %v for a failed command of the transaction, want the WRONGTYPE error", err)
+	}
+	// the connection is kept after the error reply and reads the next reply
+	if err := store.Save(ctx, "session-2", Session{IDToken: "id-token"}); err != nil {
+		t.Errorf("Save() returned %v after a failed transaction", err)
+	}
+
+	redis.lock.Lock()
+	redis.abortExec = true
+	redis.lock.Unlock()
+	if err := store.Save(ctx, "session-3", Session{IDToken: "id-token"}); err == nil {
+		t.Errorf("Save() returned no error for an aborted transaction")
+	}
+}
+
+func TestRedisStoreFailsWithWrongPassword(t *testing.T) {
+	t.Parallel()
+	redis := newFakeRedis(t, "secret")
+	store := NewRedisStore(RedisConfig{
+		Address:  redis.listener.Addr().String(),
+		Password: "wrong",
+		TTL:      time.Hour,
+	})
+	defer store.Close() //nolint:errcheck
+
+	if _, err := store.Get(context.Background(), "session-1"); err == nil {
+		t.Errorf("Get() returned no error with a wrong password")
+	}
+}
+
+func TestEscapePattern(t *testing.T) {
+	t.Parallel()
+	got := escapePattern(`nginx-oidc:default/a*b?[c]\:`)
+	want := `nginx-oidc:default/a\*b\?\[c\]\\:`
+	if got != want {
+		t.Errorf("escapePattern() returned %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,244 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+
+	"github.com/nginxinc/kubernetes-ingress/internal/oidc/audit"
+)
+
+// DefaultSocket is the socket where the Server listens for the requests of NGINX.
+const DefaultSocket = "/var/lib/nginx/oidc-sessions.sock"
+
+// sessionsPath is the path of the sessions, followed by the namespace and the name of the policy and the session ID.
+const sessionsPath = "/sessions/"
+
+// eventsPath is the path of the authentication events of NGINX.
+const eventsPath = "/events"
+
+// EgressPath is the path of the requests of NGINX to the providers of the policies with a forward proxy, which
+// the egress handler relays.
+const EgressPath = "/egress/"
+
+// maxEventSize is the maximum size of an authentication event of NGINX.
+const maxEventSize = 4 << 10
+
+// IntrospectionPath is the path of the requests of NGINX to introspect the bearer tokens of the API clients of the
+// policies with token introspection, which the introspection handler serves.
+const IntrospectionPath = "/introspect/"
+
+// maxSessionSize is the maximum size of a session saved by NGINX.
+const maxSessionSize = 64 << 10
+
+// Server serves the sessions of the policies with a session store to NGINX:
+//
+//	GET /sessions/<namespace>/<name>/<id> returns the session as JSON, or Not Found.
+//	PUT /sessions/<namespace>/<name>/<id> saves the session in the body.
+//	DELETE /sessions/<namespace>/<name>/<id> deletes the session.
+//
+// It also receives the authentication events of NGINX:
+//
+//	POST /events passes the audit event in the body to the event handler.
+//
+// And the requests of NGINX to the providers of the policies with a forward proxy:
+//
+//	<method> /egress/<namespace>/<name>/<url> passes the request to the egress handler.
+//
+// And the requests of NGINX to introspect the bearer tokens of the API clients of the policies:
+//
+//	GET /introspect/<namespace>/<name> passes the request to the introspection handler.
+type Server struct {
+	lock          sync.RWMutex
+	stores        map[string]Store
+	onEvent       func(e audit.Event)
+	egress        http.Handler
+	introspection http.Handler
+}
+
+// NewServer creates a Server without stores.
+func NewServer() *Server {
+	return &Server{
+		stores: make(map[string]Store),
+	}
+}
+
+// Set sets the store of the policy with the key <namespace>/<name>, and closes its previous store.
+func (s *Server) Set(key string, store Store) {
+	s.lock.Lock()
+	previous := s.stores[key]
+	s.stores[key] = store
+	s.lock.Unlock()
+
+	if previous != nil {
+		_ = previous.Close()
+	}
+}
+
+// Remove removes and closes the store of the policy.
+func (s *Server) Remove(key string) {
+	s.lock.Lock()
+	previous := s.stores[key]
+	delete(s.stores, key)
+	s.lock.Unlock()
+
+	if previous != nil {
+		_ = previous.Close()
+	}
+}
+
+// SetEventHandler sets the handler of the authentication events of NGINX.
+func (s *Server) SetEventHandler(onEvent func(e audit.Event)) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.onEvent = onEvent
+}
+
+// SetEgressHandler sets the handler of the requests of NGINX to the providers of the policies with a forward proxy.
+func (s *Server) SetEgressHandler(h http.Handler) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.egress = h
+}
+
+// SetIntrospectionHandler sets the handler of the requests of NGINX to introspect the bearer tokens of the API
+// clients of the policies with token introspection.
+func (s *Server) SetIntrospectionHandler(h http.Handler) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.introspection = h
+}
+
+// Store returns the store of the policy.
+func (s *Server) Store(key string) (Store, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	store, exists := s.stores[key]
+	return store, exists
+}
+
+// ListenAndServe serves the sessions on the Unix socket.
+func (s *Server) ListenAndServe(socket string) error {
+	if err := os.Remove(socket); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Handler:      s,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+	glog.Infof("Starting the OIDC session store server on: %v", socket)
+	return srv.Serve(listener)
+}
+
+// ServeHTTP serves a request for a session.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == eventsPath {
+		s.serveEvent(w, r)
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, EgressPath) {
+		s.lock.RLock()
+		egress := s.egress
+		s.lock.RUnlock()
+		if egress == nil {
+			http.NotFound(w, r)
+			return
+		}
+		egress.ServeHTTP(w, r)
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, IntrospectionPath) {
+		s.lock.RLock()
+		introspection := s.introspection
+		s.lock.RUnlock()
+		if introspection == nil {
+			http.NotFound(w, r)
+			return
+		}
+		introspection.ServeHTTP(w, r)
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, sessionsPath), "/")
+	if !strings.HasPrefix(r.URL.Path, sessionsPath) || len(parts) != 3 || parts[2] == "" {
+		http.NotFound(w, r)
+		return
+	}
+	store, exists := s.Store(parts[0] + "/" + parts[1])
+	if !exists {
+		http.NotFound(w, r)
+		return
+	}
+	id := parts[2]
+
+	switch r.Method {
+	case http.MethodGet:
+		sess, err := store.Get(r.Context(), id)
+		if errors.Is(err, ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			s.serveError(w, parts, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(sess)
+	case http.MethodPut:
+		var sess Session
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxSessionSize)).Decode(&sess); err != nil || sess.IDToken == "" {
+			http.Error(w, "invalid session", http.StatusBadRequest)
+			return
+		}
+		if err := store.Save(r.Context(), id, sess); err != nil {
+			s.serveError(w, parts, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		if err := store.Delete(r.Context(), id); err != nil {
+			s.serveError(w, parts, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) serveEvent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var e audit.Event
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxEventSize)).Decode(&e); err != nil || e.Event == "" {
+		http.Error(w, "invalid event", http.StatusBadRequest)
+		return
+	}
+
+	s.lock.RLock()
+	onEvent := s.onEvent
+	s.lock.RUnlock()
+	if onEvent != nil {
+		onEvent(e)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) serveError(w http.ResponseWriter, parts []string, err error) {
+	glog.Warningf("Session store of OIDC policy %v/%v failed: %v", parts[0], parts[1], err)
+	http.Error(w, "session store unavailable", http.StatusBadGateway)
+}
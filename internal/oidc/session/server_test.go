@@ -0,0 +1,205 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-ingress/internal/oidc/audit"
+)
+
+// memoryStore is a Store in memory.
+type memoryStore struct {
+	sessions map[string]Session
+	closed   bool
+}
+
+func (s *memoryStore) Get(_ context.Context, id string) (Session, error) {
+	sess, exists := s.sessions[id]
+	if !exists {
+		return Session{}, ErrNotFound
+	}
+	return sess, nil
+}
+
+func (s *memoryStore) Save(_ context.Context, id string, sess Session) error {
+	s.sessions[id] = sess
+	return nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, id string) error {
+	delete(s.sessions, id)
+	return nil
+}
+
+func (s *memoryStore) DeleteAll(_ context.Context) (int, error) {
+	n := len(s.sessions)
+	s.sessions = make(map[string]Session)
+	return n, nil
+}
+
+func (s *memoryStore) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+	store := &memoryStore{sessions: make(map[string]Session)}
+	srv := NewServer()
+	srv.Set("default/oidc-policy", store)
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/sessions/default/oidc-policy/session-1",
+		strings.NewReader(`{"id_token":"id-token","refresh_token":"refresh-token"}`)))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("PUT returned %d, want %d", rec.Code, http.StatusNoContent)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sessions/default/oidc-policy/session-1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET returned %d, want %d", rec.Code, http.StatusOK)
+	}
+	var sess Session
+	if err := json.NewDecoder(rec.Body).Decode(&sess); err != nil {
+		t.Fatal(err)
+	}
+	if want := (Session{IDToken: "id-token", RefreshToken: "refresh-token"}); sess != want {
+		t.Errorf("GET returned %+v, want %+v", sess, want)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/sessions/default/oidc-policy/session-1", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE returned %d, want %d", rec.Code, http.StatusNoContent)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sessions/default/oidc-policy/session-1", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET returned %d for a deleted session, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestServerRejectsInvalidRequests(t *testing.T) {
+	t.Parallel()
+	srv := NewServer()
+	srv.Set("default/oidc-policy", &memoryStore{sessions: make(map[string]Session)})
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		want   int
+		msg    string
+	}{
+		{method: http.MethodGet, path: "/sessions/default/other-policy/session-1", want: http.StatusNotFound, msg: "policy without a store"},
+		{method: http.MethodGet, path: "/sessions/default/oidc-policy/", want: http.StatusNotFound, msg: "no session ID"},
+		{method: http.MethodGet, path: "/other/default/oidc-policy/session-1", want: http.StatusNotFound, msg: "unknown path"},
+		{method: http.MethodPut, path: "/sessions/default/oidc-policy/session-1", body: `{}`, want: http.StatusBadRequest, msg: "session without an ID token"},
+		{method: http.MethodPost, path: "/sessions/default/oidc-policy/session-1", want: http.StatusMethodNotAllowed, msg: "unsupported method"},
+		{method: http.MethodPost, path: "/events", body: `{}`, want: http.StatusBadRequest, msg: "event without a type"},
+		{method: http.MethodGet, path: "/events", want: http.StatusMethodNotAllowed, msg: "unsupported method of the events"},
+	}
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(test.method, test.path, strings.NewReader(test.body)))
+		if rec.Code != test.want {
+			t.Errorf("%s %s returned %d, want %d for the case of %s", test.method, test.path, rec.Code, test.want, test.msg)
+		}
+	}
+}
+
+func TestServerClosesReplacedStores(t *testing.T) {
+	t.Parallel()
+	srv := NewServer()
+	first := &memoryStore{sessions: make(map[string]Session)}
+	second := &memoryStore{sessions: make(map[string]Session)}
+
+	srv.Set("default/oidc-policy", first)
+	srv.Set("default/oidc-policy", second)
+	if !first.closed {
+		t.Errorf("Set() didn't close the replaced store")
+	}
+	srv.Remove("default/oidc-policy")
+	if !second.closed {
+		t.Errorf("Remove() didn't close the removed store")
+	}
+	if _, exists := srv.Store("default/oidc-policy"); exists {
+		t.Errorf("Store() returned a removed store")
+	}
+}
+
+func TestServerPassesEvents(t *testing.T) {
+	t.Parallel()
+	srv := NewServer()
+	var events []audit.Event
+	srv.SetEventHandler(func(e audit.Event) {
+		events = append(events, e)
+	})
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/events",
+		strings.NewReader(`{"event":"login","result":"failure","policy":"default/oidc-policy","client_ip":"10.0.0.1"}`)))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("POST returned %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	want := audit.Event{Event: "login", Result: "failure", Policy: "default/oidc-policy", ClientIP: "10.0.0.1"}
+	if len(events) != 1 || events[0] != want {
+		t.Errorf("the event handler got %+v, want %+v", events, want)
+	}
+}
+
+func TestServerPassesEgressRequests(t *testing.T) {
+	t.Parallel()
+	srv := NewServer()
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/egress/default/oidc-policy/https://idp.example.com/token", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("POST without an egress handler returned %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	var paths []string
+	srv.SetEgressHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/egress/default/oidc-policy/https://idp.example.com/token", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST returned %d, want %d", rec.Code, http.StatusOK)
+	}
+	if want := []string{"/egress/default/oidc-policy/https://idp.example.com/token"}; len(paths) != 1 || paths[0] != want[0] {
+		t.Errorf("the egress handler got %v, want %v", paths, want)
+	}
+}
+
+func TestServerPassesIntrospectionRequests(t *testing.T) {
+	t.Parallel()
+	srv := NewServer()
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/introspect/default/oidc-policy", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("GET without an introspection handler returned %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	var paths []string
+	srv.SetIntrospectionHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/introspect/default/oidc-policy", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("GET returned %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if len(paths) != 1 || paths[0] != "/introspect/default/oidc-policy" {
+		t.Errorf("the introspection handler got %v, want [/introspect/default/oidc-policy]", paths)
+	}
+}
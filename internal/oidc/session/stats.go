@@ -0,0 +1,138 @@
+package session
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	revokedSubjectsZone = "oidc_revoked_subjects"
+	refreshingZone      = "oidc_refreshing"
+	idpOutagesZone      = "oidc_idp_outages"
+	loginFailuresZone   = "oidc_login_failures"
+)
+
+// Zones are the names of the keyval zones of the OIDC policies, see ZoneConfigs.
+var Zones = zoneNames(ZoneConfigs)
+
+func zoneNames(zones []ZoneConfig) []string {
+	names := make([]string, 0, len(zones))
+	for _, zone := range zones {
+		names = append(names, zone.Name)
+	}
+	return names
+}
+
+// Stats are the sessions of a policy in the keyval zones.
+type Stats struct {
+	// Sessions is the number of the sessions that are logged in.
+	Sessions int
+	// RefreshTokens is the number of the sessions with a refresh token.
+	RefreshTokens int
+	// PendingRefreshes is the number of the sessions that are being refreshed.
+	PendingRefreshes int
+}
+
+// Usage is the usage of the keyval zones of the OIDC policies.
+type Usage struct {
+	// Policies are the sessions of every policy.
+	Policies map[string]Stats
+	// Entries is the number of entries of every zone.
+	Entries map[string]int
+	// LoginLockouts are the clients with failed logins of every policy.
+	LoginLockouts map[string]LoginLockouts
+}
+
+// LoginLockouts are the client IP addresses with failed logins of a policy with a login lockout.
+type LoginLockouts struct {
+	// FailingClients is the number of the clients with failed logins that aren't locked out.
+	FailingClients int
+	// LockedOutClients is the number of the clients that are locked out.
+	LockedOutClients int
+}
+
+// Count counts the entries of every zone and the sessions of every policy of policies, which recognizes the
+// sessions of a policy by their ID token. A session whose ID token belongs to several policies is counted
+// for each of them.
+func Count(client KeyValClient, policies map[string]func(idToken string) bool) (Usage, error) {
+	zones := make(map[string]map[string]string)
+	usage := Usage{
+		Policies: make(map[string]Stats),
+		Entries:  make(map[string]int),
+	}
+	for _, zone := range Zones {
+		keyValPairs, err := client.GetKeyValPairs(zone)
+		if err != nil {
+			return Usage{}, fmt.Errorf("failed to get the key value pairs of zone %v: %w", zone, err)
+		}
+		zones[zone] = keyValPairs
+		usage.Entries[zone] = len(keyValPairs)
+	}
+
+	for key := range policies {
+		usage.Policies[key] = Stats{}
+	}
+	refreshTokens, refreshing := zones[refreshTokensZone], zones[refreshingZone]
+	for id, idToken := range zones[idTokensZone] {
+		if idToken == "-" {
+			continue
+		}
+		for key, belongs := range policies {
+			if !belongs(idToken) {
+				continue
+			}
+			stats := usage.Policies[key]
+			stats.Sessions++
+			if refreshTokens[id] != "" && refreshTokens[id] != "-" {
+				stats.RefreshTokens++
+			}
+			if refreshing[id] != "" {
+				stats.PendingRefreshes++
+			}
+			usage.Policies[key] = stats
+		}
+	}
+	return usage, nil
+}
+
+// CountLoginLockouts counts the clients with failed logins of every policy of clientIDs, the client IDs of the
+// policies. NGINX keeps the failed logins of a client in the oidc_login_failures zone under the client ID and the
+// IP address of the client, as "<failures> <time of the first failure> <locked out until>", see loginFailures() in
+// openid_connect.js.
+func CountLoginLockouts(client KeyValClient, clientIDs map[string]string, now time.Time) (map[string]LoginLockouts, error) {
+	keyValPairs, err := client.GetKeyValPairs(loginFailuresZone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the key value pairs of zone %v: %w", loginFailuresZone, err)
+	}
+	lockouts := make(map[string]LoginLockouts)
+	for key := range clientIDs {
+		lockouts[key] = LoginLockouts{}
+	}
+	for clientKey, failures := range keyValPairs {
+		clientID, _, found := strings.Cut(clientKey, " ")
+		fields := strings.Fields(failures)
+		if !found || len(fields) != 3 {
+			continue
+		}
+		lockedUntil, _ := strconv.ParseInt(fields[2], 10, 64)
+		lockedOut := lockedUntil > now.Unix()
+		if !lockedOut && fields[0] == "0" {
+			continue
+		}
+		for key, id := range clientIDs {
+			if id != clientID {
+				continue
+			}
+			l := lockouts[key]
+			if lockedOut {
+				l.LockedOutClients++
+			} else {
+				l.FailingClients++
+			}
+			lockouts[key] = l
+		}
+	}
+	return lockouts, nil
+}
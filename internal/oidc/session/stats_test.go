@@ -0,0 +1,80 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCount(t *testing.T) {
+	t.Parallel()
+	appIDToken := testIDToken(`{"sub":"alice","aud":"app"}`)
+	adminIDToken := testIDToken(`{"sub":"bob","aud":"admin"}`)
+	client := &fakeKeyValClient{zones: map[string]map[string]string{
+		idTokensZone: {
+			"session-1":  appIDToken,
+			"session-2":  appIDToken,
+			"session-3":  adminIDToken,
+			"logged-out": "-",
+		},
+		refreshTokensZone: {"session-1": "refresh-1", "session-2": "-", "session-3": "refresh-3", "logged-out": "-"},
+		refreshingZone:    {"session-1": "1"},
+	}}
+
+	usage, err := Count(client, map[string]func(string) bool{
+		"default/app":   func(idToken string) bool { return idToken == appIDToken },
+		"default/admin": func(idToken string) bool { return idToken == adminIDToken },
+		"default/other": func(string) bool { return false },
+	})
+	if err != nil {
+		t.Fatalf("Count() returned %v", err)
+	}
+
+	expectedPolicies := map[string]Stats{
+		"default/app":   {Sessions: 2, RefreshTokens: 1, PendingRefreshes: 1},
+		"default/admin": {Sessions: 1, RefreshTokens: 1},
+		"default/other": {},
+	}
+	if !reflect.DeepEqual(usage.Policies, expectedPolicies) {
+		t.Errorf("Count() returned the sessions %v, want %v", usage.Policies, expectedPolicies)
+	}
+	if usage.Entries[idTokensZone] != 4 || usage.Entries[refreshTokensZone] != 4 || usage.Entries[refreshingZone] != 1 || usage.Entries[dpopKeysZone] != 0 {
+		t.Errorf("Count() returned the entries %v", usage.Entries)
+	}
+	if len(usage.Entries) != len(Zones) {
+		t.Errorf("Count() returned the entries of %d zones, want %d", len(usage.Entries), len(Zones))
+	}
+}
+
+func TestCountLoginLockouts(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1700000000, 0)
+	client := &fakeKeyValClient{zones: map[string]map[string]string{
+		loginFailuresZone: {
+			"app 10.0.0.1":   "2 1699999900 0",
+			"app 10.0.0.2":   "0 1699999900 1700000600",
+			"app 10.0.0.3":   "0 1699990000 1699999000", // The lockout expired
+			"app fd00::1":    "1 1699999900 1699999000",
+			"admin 10.0.0.1": "0 1699999900 1700000600",
+			"invalid":        "1 1699999900 0",
+		},
+	}}
+
+	lockouts, err := CountLoginLockouts(client, map[string]string{
+		"default/app":   "app",
+		"default/admin": "admin",
+		"default/other": "other",
+	}, now)
+	if err != nil {
+		t.Fatalf("CountLoginLockouts() returned %v", err)
+	}
+
+	expected := map[string]LoginLockouts{
+		"default/app":   {FailingClients: 2, LockedOutClients: 1},
+		"default/admin": {LockedOutClients: 1},
+		"default/other": {},
+	}
+	if !reflect.DeepEqual(lockouts, expected) {
+		t.Errorf("CountLoginLockouts() returned %v, want %v", lockouts, expected)
+	}
+}
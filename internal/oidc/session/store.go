@@ -0,0 +1,61 @@
+// Package session persists the sessions of the OIDC policies.
+//
+// NGINX keeps the sessions in its keyval zones. A policy with a Redis session store also persists its sessions
+// in Redis or Valkey through the Server, so that the sessions survive restarts of NGINX and are not limited by
+// the size of the keyval zones. NGINX saves a session to the store when it creates or refreshes the session,
+// and loads it from the store when the session isn't in the keyval zones.
+package session
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned for a session that doesn't exist in the store.
+var ErrNotFound = errors.New("session not found")
+
+// Session holds the tokens of a session, identified by the session cookie.
+type Session struct {
+	IDToken      string `json:"id_token"`
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	DPoPKey      string `json:"dpop_key,omitempty"`
+	// Groups are the groups of an Azure AD user with too many groups for the ID token, a JSON array.
+	Groups string `json:"groups,omitempty"`
+	// IdP is the name of the IdP that issued the tokens, for a policy with several IdPs.
+	IdP string `json:"idp,omitempty"`
+}
+
+// Subject returns the sub claim of the ID token of the session.
+func (s Session) Subject() string {
+	return newInfo("", s.IDToken).Subject
+}
+
+// Info describes a session for the administrators, without its tokens.
+type Info struct {
+	// ID is the value of the session cookie.
+	ID string `json:"id"`
+	// Subject is the sub claim of the ID token.
+	Subject string `json:"sub"`
+	// IssuedAt is the time the ID token was issued, at the login or the last refresh.
+	IssuedAt time.Time `json:"issuedAt"`
+	// ExpiresAt is the time the ID token expires. A session with a refresh token is refreshed after that.
+	ExpiresAt time.Time `json:"expiresAt"`
+	// Refreshable is true if the session has a refresh token.
+	Refreshable bool `json:"refreshable"`
+}
+
+// Store persists the sessions of a policy.
+type Store interface {
+	// Get returns the session with the ID, or ErrNotFound if it doesn't exist.
+	Get(ctx context.Context, id string) (Session, error)
+	// Save adds or replaces the session with the ID.
+	Save(ctx context.Context, id string, s Session) error
+	// Delete deletes the session with the ID.
+	Delete(ctx context.Context, id string) error
+	// DeleteAll deletes all sessions of the policy and returns the number of deleted sessions.
+	DeleteAll(ctx context.Context) (int, error)
+	// Close releases the resources of the store.
+	Close() error
+}
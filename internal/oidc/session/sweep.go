@@ -0,0 +1,115 @@
+package session
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	accessTokensExpiryZone      = "oidc_access_tokens_expiry"
+	userSessionsZone            = "oidc_user_sessions"
+	clientCredentialsZone       = "oidc_client_credentials"
+	clientCredentialsExpiryZone = "oidc_client_credentials_expiry"
+)
+
+// Sweep deletes the entries of the keyval zones that NGINX can't use anymore, before the timeout of their zone:
+//   - the sessions whose ID token expired and that can't be refreshed,
+//   - the tokens, DPoP keys, groups, IdPs and policies of the sessions that logged out or no longer exist,
+//   - the exchanged tokens and the client credentials access tokens that expired,
+//   - the sessions that logged out or no longer exist in the index of the sessions of every user.
+//
+// The ID tokens of the sessions that logged out are kept, because they prevent NGINX from loading the sessions
+// again from a session store or a session cookie. Sweep returns the number of deleted entries of every zone.
+func Sweep(client KeyValClient, now time.Time) (map[string]int, error) {
+	zones := make(map[string]map[string]string)
+	for _, zone := range []string{
+		idTokensZone, accessTokensZone, accessTokensExpiryZone, refreshTokensZone, dpopKeysZone, groupsZone, idpsZone, policiesZone,
+		exchangedTokenZones[0], exchangedTokenZones[1], clientCredentialsZone, clientCredentialsExpiryZone, userSessionsZone,
+	} {
+		keyValPairs, err := client.GetKeyValPairs(zone)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the key value pairs of zone %v: %w", zone, err)
+		}
+		zones[zone] = keyValPairs
+	}
+
+	swept := make(map[string]int)
+	deleteKey := func(zone string, key string) {
+		client.DeleteKeyValPair(zone, key)
+		delete(zones[zone], key)
+		swept[zone]++
+	}
+	idTokens, refreshTokens := zones[idTokensZone], zones[refreshTokensZone]
+	refreshable := func(id string) bool {
+		return refreshTokens[id] != "" && refreshTokens[id] != "-"
+	}
+	// A session without an ID token in the zone is refreshed by its next request if it has a refresh token
+	loggedIn := func(id string) bool {
+		return (idTokens[id] != "" && idTokens[id] != "-") || (idTokens[id] == "" && refreshable(id))
+	}
+	expired := func(expiresAt string) bool {
+		seconds, err := strconv.ParseInt(expiresAt, 10, 64)
+		return err == nil && seconds < now.Unix()
+	}
+
+	for id, idToken := range idTokens {
+		if idToken == "-" || refreshable(id) {
+			continue
+		}
+		if expiresAt := newInfo(id, idToken).ExpiresAt; !expiresAt.IsZero() && expiresAt.Before(now) {
+			deleteKey(idTokensZone, id)
+		}
+	}
+	for id, refreshToken := range refreshTokens {
+		if refreshToken == "-" {
+			deleteKey(refreshTokensZone, id)
+		}
+	}
+	for _, zone := range []string{accessTokensZone, accessTokensExpiryZone, dpopKeysZone, groupsZone, idpsZone, policiesZone} {
+		for id := range zones[zone] {
+			if !loggedIn(id) {
+				deleteKey(zone, id)
+			}
+		}
+	}
+
+	exchangedTokensExpiry := exchangedTokenZones[1]
+	for _, zone := range exchangedTokenZones {
+		for key := range zones[zone] {
+			id, _, _ := strings.Cut(key, ":")
+			if !loggedIn(id) || expired(zones[exchangedTokensExpiry][key]) {
+				deleteKey(zone, key)
+			}
+		}
+	}
+
+	for key, expiresAt := range zones[clientCredentialsExpiryZone] {
+		if expired(expiresAt) {
+			deleteKey(clientCredentialsExpiryZone, key)
+			if _, exists := zones[clientCredentialsZone][key]; exists {
+				deleteKey(clientCredentialsZone, key)
+			}
+		}
+	}
+
+	// The index of the sessions of a user is kept like by limitUserSessions() in openid_connect.js
+	for key, value := range zones[userSessionsZone] {
+		ids := strings.Fields(value)
+		var active []string
+		for _, id := range ids {
+			if idTokens[id] != "" && idTokens[id] != "-" {
+				active = append(active, id)
+			}
+		}
+		if len(active) == 0 {
+			deleteKey(userSessionsZone, key)
+		} else if len(active) < len(ids) {
+			if err := client.UpsertKeyValPair(userSessionsZone, key, strings.Join(active, " ")); err != nil {
+				return swept, fmt.Errorf("failed to update the sessions of %v: %w", key, err)
+			}
+		}
+	}
+	return swept, nil
+}
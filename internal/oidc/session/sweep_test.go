@@ -0,0 +1,85 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSweep(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1700010000, 0)
+	expiredIDToken := testIDToken(`{"sub":"alice","aud":"app","iat":1700000000,"exp":1700003600}`)
+	validIDToken := testIDToken(`{"sub":"bob","aud":"app","iat":1700009000,"exp":1700012600}`)
+	client := &fakeKeyValClient{zones: map[string]map[string]string{
+		idTokensZone: {
+			"expired":     expiredIDToken,
+			"refreshable": expiredIDToken,
+			"valid":       validIDToken,
+			"logged-out":  "-",
+		},
+		accessTokensZone:       {"expired": "access-1", "refreshable": "access-2", "valid": "access-3", "logged-out": "-", "gone": "access-4"},
+		accessTokensExpiryZone: {"valid": "1700013600", "gone": "1700003600"},
+		refreshTokensZone:      {"refreshable": "refresh-2", "valid": "-", "logged-out": "-", "no-id-token": "refresh-5"},
+		dpopKeysZone:           {"refreshable": "key-2", "gone": "key-4"},
+		groupsZone:             {"valid": `["group-3"]`, "logged-out": `["group-4"]`},
+		idpsZone:               {"valid": "partners", "gone": "partners"},
+		"oidc_exchanged_tokens": {
+			"valid:orders":      "exchanged-1",
+			"valid:inventory":   "exchanged-2",
+			"logged-out:orders": "exchanged-3",
+		},
+		"oidc_exchanged_tokens_expiry": {
+			"valid:orders":      "1700013600",
+			"valid:inventory":   "1700009000",
+			"logged-out:orders": "1700013600",
+		},
+		clientCredentialsZone:       {"policy-1": "token-1", "policy-2": "token-2"},
+		clientCredentialsExpiryZone: {"policy-1": "1700013600", "policy-2": "1700009000"},
+		userSessionsZone: {
+			"app:alice": "expired logged-out",
+			"app:bob":   "logged-out valid",
+		},
+	}}
+
+	swept, err := Sweep(client, now)
+	if err != nil {
+		t.Fatalf("Sweep() returned %v", err)
+	}
+
+	expectedZones := map[string]map[string]string{
+		idTokensZone:                   {"refreshable": expiredIDToken, "valid": validIDToken, "logged-out": "-"},
+		accessTokensZone:               {"refreshable": "access-2", "valid": "access-3"},
+		accessTokensExpiryZone:         {"valid": "1700013600"},
+		refreshTokensZone:              {"refreshable": "refresh-2", "no-id-token": "refresh-5"},
+		dpopKeysZone:                   {"refreshable": "key-2"},
+		groupsZone:                     {"valid": `["group-3"]`},
+		idpsZone:                       {"valid": "partners"},
+		"oidc_exchanged_tokens":        {"valid:orders": "exchanged-1"},
+		"oidc_exchanged_tokens_expiry": {"valid:orders": "1700013600"},
+		clientCredentialsZone:          {"policy-1": "token-1"},
+		clientCredentialsExpiryZone:    {"policy-1": "1700013600"},
+		userSessionsZone:               {"app:bob": "valid"},
+	}
+	if !reflect.DeepEqual(client.zones, expectedZones) {
+		t.Errorf("Sweep() left zones %v, want %v", client.zones, expectedZones)
+	}
+
+	expectedSwept := map[string]int{
+		idTokensZone:                   1,
+		accessTokensZone:               3,
+		accessTokensExpiryZone:         1,
+		refreshTokensZone:              2,
+		dpopKeysZone:                   1,
+		groupsZone:                     1,
+		idpsZone:                       1,
+		"oidc_exchanged_tokens":        2,
+		"oidc_exchanged_tokens_expiry": 2,
+		clientCredentialsZone:          1,
+		clientCredentialsExpiryZone:    1,
+		userSessionsZone:               1,
+	}
+	if !reflect.DeepEqual(swept, expectedSwept) {
+		t.Errorf("Sweep() returned %v, want %v", swept, expectedSwept)
+	}
+}
@@ -0,0 +1,39 @@
+package session
+
+import "time"
+
+// Lifetime is how long the keyval zones keep a session after it was last refreshed, the validity period of the
+// refresh tokens.
+const Lifetime = 8 * time.Hour
+
+// ZoneConfig is a keyval zone of the OIDC policies, which the main configuration declares.
+type ZoneConfig struct {
+	// Name is the name of the zone.
+	Name string
+	// Size is the size of the shared memory of the zone, e.g. 1M.
+	Size string
+	// Timeout is how long the zone keeps an entry after it was last set.
+	Timeout time.Duration
+}
+
+// ZoneConfigs are the keyval zones of the OIDC policies. The timeouts are at least the validity period of what the
+// zones keep: an hour for the ID tokens and the access tokens, the Lifetime for the sessions and what outlives them.
+var ZoneConfigs = []ZoneConfig{
+	{Name: idTokensZone, Size: "1M", Timeout: time.Hour},
+	{Name: accessTokensZone, Size: "1M", Timeout: time.Hour},
+	{Name: refreshTokensZone, Size: "1M", Timeout: Lifetime},
+	{Name: revokedSubjectsZone, Size: "1M", Timeout: Lifetime}, // Outlives every session that was revoked
+	{Name: clientCredentialsZone, Size: "1M", Timeout: time.Hour},
+	{Name: clientCredentialsExpiryZone, Size: "128K", Timeout: time.Hour},
+	{Name: exchangedTokenZones[0], Size: "1M", Timeout: time.Hour},
+	{Name: exchangedTokenZones[1], Size: "128K", Timeout: time.Hour},
+	{Name: dpopKeysZone, Size: "1M", Timeout: Lifetime},
+	{Name: userSessionsZone, Size: "1M", Timeout: Lifetime},
+	{Name: accessTokensExpiryZone, Size: "128K", Timeout: time.Hour},
+	{Name: refreshingZone, Size: "128K", Timeout: 30 * time.Second}, // Until the refresh completes or fails
+	{Name: idpOutagesZone, Size: "128K", Timeout: 30 * time.Second}, // Until the IdP is tried again
+	{Name: loginFailuresZone, Size: "1M", Timeout: time.Hour},       // The longest window and duration of a lockout
+	{Name: groupsZone, Size: "4M", Timeout: Lifetime},
+	{Name: idpsZone, Size: "1M", Timeout: Lifetime},
+	{Name: policiesZone, Size: "1M", Timeout: Lifetime},
+}
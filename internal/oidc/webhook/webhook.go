@@ -0,0 +1,201 @@
+// Package webhook notifies a webhook of the anomalies of the OIDC authentication, so that security tooling can
+// react to them without scraping the logs.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+
+	"github.com/nginxinc/kubernetes-ingress/internal/oidc/audit"
+)
+
+const (
+	// DefaultLoginFailures is the default number of failed logins from one client IP within the
+	// LoginFailuresWindow that is notified.
+	DefaultLoginFailures = 5
+	// LoginFailuresWindow is the window of the failed logins from one client IP.
+	LoginFailuresWindow = 5 * time.Minute
+
+	queueSize = 100
+)
+
+// The types of the notifications.
+const (
+	LoginFailures     = "login_failures"
+	RefreshTokenReuse = "refresh_token_reuse"
+	JWKSRotation      = "jwks_rotation"
+)
+
+// Notification is an anomaly of the OIDC authentication, which is posted to the webhook as JSON.
+type Notification struct {
+	Time     string `json:"time"`
+	Type     string `json:"type"`
+	Policy   string `json:"policy,omitempty"`
+	SubHash  string `json:"sub_hash,omitempty"`
+	ClientIP string `json:"client_ip,omitempty"`
+	Count    int    `json:"count,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// ValidateURL validates the URL of the webhook, which must be an absolute http or https URL.
+func ValidateURL(webhookURL string) error {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("URL must be an absolute http or https URL, got %q", webhookURL)
+	}
+	return nil
+}
+
+// Notifier detects the anomalies in the OIDC authentication events and posts them to the webhook.
+// The notifications are queued and posted by Run, so that a slow webhook doesn't delay the authentication.
+type Notifier struct {
+	httpClient *http.Client
+	config     func() (webhookURL string, loginFailures int)
+	queue      chan Notification
+	lock       sync.Mutex
+	failures   map[string]*loginFailures
+	pruned     time.Time
+	now        func() time.Time
+}
+
+type loginFailures struct {
+	start    time.Time
+	count    int
+	notified bool
+}
+
+// NewNotifier creates a Notifier that gets the URL of the webhook and the number of failed logins from one client
+// IP to notify from config. No notification is posted while the URL is empty.
+func NewNotifier(httpClient *http.Client, config func() (webhookURL string, loginFailures int)) *Notifier {
+	return &Notifier{
+		httpClient: httpClient,
+		config:     config,
+		queue:      make(chan Notification, queueSize),
+		failures:   make(map[string]*loginFailures),
+		now:        time.Now,
+	}
+}
+
+// Run posts the queued notifications until stopCh is closed.
+func (n *Notifier) Run(stopCh <-chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			return
+		case nt := <-n.queue:
+			if err := n.post(nt); err != nil {
+				glog.Warningf("Failed to post the OIDC %v notification to the webhook: %v", nt.Type, err)
+			}
+		}
+	}
+}
+
+// Event detects the anomalies in an authentication event of NGINX: the repeated failed logins from one
+// client IP and the rejected refresh tokens that were already used.
+func (n *Notifier) Event(e audit.Event) {
+	switch {
+	case e.Event == "login" && e.Result == "failure" && e.ClientIP != "":
+		if count, ok := n.loginFailed(e.ClientIP); ok {
+			n.Notify(Notification{
+				Type:     LoginFailures,
+				Policy:   e.Policy,
+				ClientIP: e.ClientIP,
+				Count:    count,
+				Message:  fmt.Sprintf("%d failed logins from %v within %v", count, e.ClientIP, LoginFailuresWindow),
+			})
+		}
+	case e.Event == "refresh" && e.Result == "failure" && e.Reason == RefreshTokenReuse:
+		n.Notify(Notification{
+			Type:     RefreshTokenReuse,
+			Policy:   e.Policy,
+			SubHash:  e.SubHash,
+			ClientIP: e.ClientIP,
+			Message:  "the provider rejected a refresh token that was already used",
+		})
+	}
+}
+
+// Notify queues the notification. It is dropped if the webhook isn't configured or the queue is full.
+func (n *Notifier) Notify(nt Notification) {
+	if webhookURL, _ := n.config(); webhookURL == "" {
+		return
+	}
+	if nt.Time == "" {
+		nt.Time = n.now().UTC().Format(time.RFC3339)
+	}
+	select {
+	case n.queue <- nt:
+	default:
+		glog.Warningf("Dropped the OIDC %v notification, the queue of the webhook is full", nt.Type)
+	}
+}
+
+// loginFailed counts a failed login from the client IP, and returns the count and true when it reaches the
+// number of failed logins to notify, once per window.
+func (n *Notifier) loginFailed(clientIP string) (int, bool) {
+	_, threshold := n.config()
+	if threshold <= 0 {
+		threshold = DefaultLoginFailures
+	}
+	now := n.now()
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if now.Sub(n.pruned) > LoginFailuresWindow {
+		for ip, f := range n.failures {
+			if now.Sub(f.start) > LoginFailuresWindow {
+				delete(n.failures, ip)
+			}
+		}
+		n.pruned = now
+	}
+
+	f, exists := n.failures[clientIP]
+	if !exists || now.Sub(f.start) > LoginFailuresWindow {
+		f = &loginFailures{start: now}
+		n.failures[clientIP] = f
+	}
+	f.count++
+	if f.count < threshold || f.notified {
+		return f.count, false
+	}
+	f.notified = true
+	return f.count, true
+}
+
+func (n *Notifier) post(nt Notification) error {
+	webhookURL, _ := n.config()
+	if webhookURL == "" {
+		return nil
+	}
+	body, err := json.Marshal(nt)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return nil
+}
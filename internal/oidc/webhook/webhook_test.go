@@ -0,0 +1,90 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nginxinc/kubernetes-ingress/internal/oidc/audit"
+)
+
+func TestValidateURL(t *testing.T) {
+	t.Parallel()
+	for _, u := range []string{"https://siem.example.com/hooks/oidc", "http://10.0.0.1:8080/"} {
+		if err := ValidateURL(u); err != nil {
+			t.Errorf("ValidateURL(%q) returned %v", u, err)
+		}
+	}
+	for _, u := range []string{"", "siem.example.com/hooks", "ftp://siem.example.com/", "https://"} {
+		if err := ValidateURL(u); err == nil {
+			t.Errorf("ValidateURL(%q) returned no error", u)
+		}
+	}
+}
+
+func TestNotifierLoginFailures(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC)
+	n := NewNotifier(http.DefaultClient, func() (string, int) { return "http://siem.example.com/", 3 })
+	n.now = func() time.Time { return now }
+
+	failure := audit.Event{Event: "login", Result: "failure", Policy: "default/oidc-policy", ClientIP: "10.0.0.1"}
+	for i := 0; i < 4; i++ {
+		n.Event(failure)
+	}
+	n.Event(audit.Event{Event: "login", Result: "failure", ClientIP: "10.0.0.2"})
+	if len(n.queue) != 1 {
+		t.Fatalf("Event() queued %d notifications, want 1 after repeated failed logins from one client IP", len(n.queue))
+	}
+	if nt := <-n.queue; nt.Type != LoginFailures || nt.ClientIP != "10.0.0.1" || nt.Count != 3 {
+		t.Errorf("Event() queued %+v, want a %v notification of 3 failed logins from 10.0.0.1", nt, LoginFailures)
+	}
+
+	now = now.Add(LoginFailuresWindow + time.Second)
+	for i := 0; i < 3; i++ {
+		n.Event(failure)
+	}
+	if len(n.queue) != 1 {
+		t.Errorf("Event() queued %d notifications, want 1 for the failed logins of the next window", len(n.queue))
+	}
+}
+
+func TestNotifierPostsNotifications(t *testing.T) {
+	t.Parallel()
+	received := make(chan Notification, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var nt Notification
+		if err := json.NewDecoder(r.Body).Decode(&nt); err != nil || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("the webhook got an invalid notification: %v", err)
+		}
+		received <- nt
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	n := NewNotifier(ts.Client(), func() (string, int) { return ts.URL, 0 })
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go n.Run(stopCh)
+
+	n.Event(audit.Event{Event: "refresh", Result: "failure", Policy: "default/oidc-policy", Reason: RefreshTokenReuse})
+	select {
+	case nt := <-received:
+		if nt.Type != RefreshTokenReuse || nt.Policy != "default/oidc-policy" || nt.Time == "" {
+			t.Errorf("the webhook got %+v, want a %v notification of default/oidc-policy", nt, RefreshTokenReuse)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the webhook got no notification")
+	}
+}
+
+func TestNotifierWithoutURL(t *testing.T) {
+	t.Parallel()
+	n := NewNotifier(http.DefaultClient, func() (string, int) { return "", 0 })
+	n.Notify(Notification{Type: JWKSRotation, Policy: "default/oidc-policy"})
+	if len(n.queue) != 0 {
+		t.Errorf("Notify() queued a notification without the URL of the webhook")
+	}
+}
@@ -0,0 +1,127 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/golang/glog"
+	conf_v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1"
+	conf_v1alpha1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1alpha1"
+	conf_v2 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v2"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ConversionPath is the path where the webhook converts Policies between the API versions.
+const ConversionPath = "/convert-policy"
+
+// conversionReview is the apiextensions.k8s.io/v1 ConversionReview, limited to the fields the webhook uses.
+type conversionReview struct {
+	meta_v1.TypeMeta `json:",inline"`
+	Request          *conversionRequest  `json:"request,omitempty"`
+	Response         *conversionResponse `json:"response,omitempty"`
+}
+
+type conversionRequest struct {
+	UID               types.UID              `json:"uid"`
+	DesiredAPIVersion string                 `json:"desiredAPIVersion"`
+	Objects           []runtime.RawExtension `json:"objects"`
+}
+
+type conversionResponse struct {
+	UID              types.UID              `json:"uid"`
+	ConvertedObjects []runtime.RawExtension `json:"convertedObjects"`
+	Result           meta_v1.Status         `json:"result"`
+}
+
+// ServeConversion handles a ConversionReview of Policies between the v1alpha1, v1 and v2 API versions.
+func ServeConversion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error reading the request: %v", err), http.StatusBadRequest)
+		return
+	}
+	var review conversionReview
+	if err := json.Unmarshal(body, &review); err != nil {
+		http.Error(w, fmt.Sprintf("error decoding the ConversionReview: %v", err), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		http.Error(w, "the ConversionReview has no request", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = &conversionResponse{UID: review.Request.UID}
+	converted, err := convertPolicies(review.Request.Objects, review.Request.DesiredAPIVersion)
+	if err != nil {
+		review.Response.Result = meta_v1.Status{Status: meta_v1.StatusFailure, Message: err.Error()}
+	} else {
+		review.Response.ConvertedObjects = converted
+		review.Response.Result = meta_v1.Status{Status: meta_v1.StatusSuccess}
+	}
+	review.Request = nil
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		glog.Errorf("Error writing the ConversionReview response: %v", err)
+	}
+}
+
+func convertPolicies(objects []runtime.RawExtension, desiredAPIVersion string) ([]runtime.RawExtension, error) {
+	converted := make([]runtime.RawExtension, 0, len(objects))
+	for _, obj := range objects {
+		raw, err := convertPolicy(obj.Raw, desiredAPIVersion)
+		if err != nil {
+			return nil, err
+		}
+		converted = append(converted, runtime.RawExtension{Raw: raw})
+	}
+	return converted, nil
+}
+
+// convertPolicy converts a Policy through v1, the storage version. The v1alpha1 version is
+// converted like with the None conversion strategy, only its apiVersion changes.
+func convertPolicy(raw []byte, desiredAPIVersion string) ([]byte, error) {
+	var typeMeta meta_v1.TypeMeta
+	if err := json.Unmarshal(raw, &typeMeta); err != nil {
+		return nil, fmt.Errorf("error decoding the object: %w", err)
+	}
+	if typeMeta.Kind != "Policy" {
+		return nil, fmt.Errorf("unexpected kind %q, only Policies are converted", typeMeta.Kind)
+	}
+	if typeMeta.APIVersion == desiredAPIVersion {
+		return raw, nil
+	}
+
+	var pol conf_v1.Policy
+	switch typeMeta.APIVersion {
+	case conf_v1.SchemeGroupVersion.String(), conf_v1alpha1.SchemeGroupVersion.String():
+		if err := json.Unmarshal(raw, &pol); err != nil {
+			return nil, fmt.Errorf("error decoding the Policy: %w", err)
+		}
+	case conf_v2.SchemeGroupVersion.String():
+		var v2Pol conf_v2.Policy
+		if err := json.Unmarshal(raw, &v2Pol); err != nil {
+			return nil, fmt.Errorf("error decoding the Policy: %w", err)
+		}
+		pol = *v2Pol.ConvertToV1()
+	default:
+		return nil, fmt.Errorf("unsupported conversion of a Policy from %s", typeMeta.APIVersion)
+	}
+
+	switch desiredAPIVersion {
+	case conf_v1.SchemeGroupVersion.String(), conf_v1alpha1.SchemeGroupVersion.String():
+		pol.APIVersion = desiredAPIVersion
+		return json.Marshal(pol)
+	case conf_v2.SchemeGroupVersion.String():
+		return json.Marshal(conf_v2.ConvertFromV1(&pol))
+	}
+	return nil, fmt.Errorf("unsupported conversion of a Policy to %s", desiredAPIVersion)
+}
@@ -0,0 +1,116 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	conf_v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1"
+	conf_v2 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v2"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func serveConversion(t *testing.T, desiredAPIVersion string, objects ...string) *conversionResponse {
+	t.Helper()
+	var raw []json.RawMessage
+	for _, obj := range objects {
+		raw = append(raw, json.RawMessage(obj))
+	}
+	review := map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "ConversionReview",
+		"request": map[string]interface{}{
+			"uid":               "1",
+			"desiredAPIVersion": desiredAPIVersion,
+			"objects":           raw,
+		},
+	}
+	body, err := json.Marshal(review)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	ServeConversion(w, httptest.NewRequest(http.MethodPost, ConversionPath, bytes.NewReader(body)))
+
+	var got conversionReview
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("ServeConversion() returned an invalid ConversionReview: %v", err)
+	}
+	if got.Response == nil || got.Response.UID != "1" {
+		t.Fatalf("ServeConversion() returned response %+v, want a response for UID 1", got.Response)
+	}
+	return got.Response
+}
+
+func TestServeConversionToV2(t *testing.T) {
+	t.Parallel()
+	v1Policy := `{"apiVersion":"k8s.nginx.org/v1","kind":"Policy","metadata":{"name":"oidc-policy","namespace":"default"},
+		"spec":{"oidc":{"clientID":"client","cookieSameSite":"Strict","claimRules":[{"claim":"groups","values":["admins"]}]}}}`
+
+	resp := serveConversion(t, "k8s.nginx.org/v2", v1Policy)
+	if resp.Result.Status != meta_v1.StatusSuccess || len(resp.ConvertedObjects) != 1 {
+		t.Fatalf("ServeConversion() returned %+v, want one converted object", resp)
+	}
+	var pol conf_v2.Policy
+	if err := json.Unmarshal(resp.ConvertedObjects[0].Raw, &pol); err != nil {
+		t.Fatal(err)
+	}
+	if pol.APIVersion != "k8s.nginx.org/v2" || pol.Name != "oidc-policy" {
+		t.Errorf("ServeConversion() returned %s %s, want k8s.nginx.org/v2 oidc-policy", pol.APIVersion, pol.Name)
+	}
+	if pol.Spec.OIDC.Cookie == nil || pol.Spec.OIDC.Cookie.SameSite != "Strict" || len(pol.Spec.OIDC.ClaimRules) != 1 {
+		t.Errorf("ServeConversion() returned OIDC %+v, want the cookie block and the claim rules", pol.Spec.OIDC)
+	}
+}
+
+func TestServeConversionToV1(t *testing.T) {
+	t.Parallel()
+	v2Policy := `{"apiVersion":"k8s.nginx.org/v2","kind":"Policy","metadata":{"name":"oidc-policy","namespace":"default"},
+		"spec":{"oidc":{"clientID":"client","cookie":{"sameSite":"None","domain":"example.com"}}}}`
+	v1Policy := `{"apiVersion":"k8s.nginx.org/v1","kind":"Policy","metadata":{"name":"rate-limit-policy"},"spec":{"rateLimit":{"rate":"10r/s"}}}`
+
+	resp := serveConversion(t, "k8s.nginx.org/v1", v2Policy, v1Policy)
+	if resp.Result.Status != meta_v1.StatusSuccess || len(resp.ConvertedObjects) != 2 {
+		t.Fatalf("ServeConversion() returned %+v, want two converted objects", resp)
+	}
+	var pol conf_v1.Policy
+	if err := json.Unmarshal(resp.ConvertedObjects[0].Raw, &pol); err != nil {
+		t.Fatal(err)
+	}
+	if pol.APIVersion != "k8s.nginx.org/v1" || pol.Spec.OIDC.CookieSameSite != "None" || pol.Spec.OIDC.CookieDomain != "example.com" {
+		t.Errorf("ServeConversion() returned %s with OIDC %+v, want the v1 cookie fields", pol.APIVersion, pol.Spec.OIDC)
+	}
+	if string(resp.ConvertedObjects[1].Raw) != v1Policy {
+		t.Errorf("ServeConversion() changed a Policy of the desired version: %s", resp.ConvertedObjects[1].Raw)
+	}
+}
+
+func TestServeConversionFromV1alpha1(t *testing.T) {
+	t.Parallel()
+	v1alpha1Policy := `{"apiVersion":"k8s.nginx.org/v1alpha1","kind":"Policy","metadata":{"name":"policy"},"spec":{"rateLimit":{"rate":"10r/s"}}}`
+
+	resp := serveConversion(t, "k8s.nginx.org/v2", v1alpha1Policy)
+	if resp.Result.Status != meta_v1.StatusSuccess || len(resp.ConvertedObjects) != 1 {
+		t.Fatalf("ServeConversion() returned %+v, want one converted object", resp)
+	}
+	var pol conf_v2.Policy
+	if err := json.Unmarshal(resp.ConvertedObjects[0].Raw, &pol); err != nil {
+		t.Fatal(err)
+	}
+	if pol.APIVersion != "k8s.nginx.org/v2" || pol.Spec.RateLimit == nil || pol.Spec.RateLimit.Rate != "10r/s" {
+		t.Errorf("ServeConversion() returned %s with spec %+v, want the v2 rate limit policy", pol.APIVersion, pol.Spec)
+	}
+}
+
+func TestServeConversionFailsOnUnsupportedVersion(t *testing.T) {
+	t.Parallel()
+	v3Policy := `{"apiVersion":"k8s.nginx.org/v3","kind":"Policy","metadata":{"name":"policy"}}`
+
+	resp := serveConversion(t, "k8s.nginx.org/v2", v3Policy)
+	if resp.Result.Status != meta_v1.StatusFailure || len(resp.ConvertedObjects) != 0 {
+		t.Errorf("ServeConversion() returned %+v, want a failure", resp)
+	}
+}
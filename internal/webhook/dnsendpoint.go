@@ -0,0 +1,141 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+
+	extdns_v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/externaldns/v1"
+	extdns_validation "github.com/nginxinc/kubernetes-ingress/pkg/apis/externaldns/validation"
+	admission_v1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// DNSEndpointPath is the path where the webhook validates DNSEndpoints.
+const DNSEndpointPath = "/validate-dnsendpoint"
+
+var dnsEndpointGroupKind = schema.GroupKind{Group: "externaldns.nginx.org", Kind: "DNSEndpoint"}
+
+// DNSEndpointValidator validates DNSEndpoints at admission time.
+// It runs the same validation as the VirtualServers with the externalDNS field.
+type DNSEndpointValidator struct {
+	opts []extdns_validation.Option
+}
+
+// NewDNSEndpointValidator creates a DNSEndpointValidator.
+func NewDNSEndpointValidator(opts ...extdns_validation.Option) *DNSEndpointValidator {
+	return &DNSEndpointValidator{opts: opts}
+}
+
+// ServeHTTP handles an AdmissionReview of a DNSEndpoint.
+func (v *DNSEndpointValidator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveAdmissionReview(w, r, v.review)
+}
+
+func (v *DNSEndpointValidator) review(_ context.Context, req *admission_v1.AdmissionRequest) *admission_v1.AdmissionResponse {
+	if req.Operation != admission_v1.Create && req.Operation != admission_v1.Update {
+		return &admission_v1.AdmissionResponse{Allowed: true}
+	}
+
+	var dep extdns_v1.DNSEndpoint
+	if err := json.Unmarshal(req.Object.Raw, &dep); err != nil {
+		return badRequest(fmt.Sprintf("error decoding the DNSEndpoint: %v", err))
+	}
+
+	allErrs := extdns_validation.ValidateDNSEndpoint(&dep, v.opts...)
+	if len(allErrs) == 0 {
+		return &admission_v1.AdmissionResponse{Allowed: true}
+	}
+	status := apierrors.NewInvalid(dnsEndpointGroupKind, dep.Name, allErrs).ErrStatus
+	return &admission_v1.AdmissionResponse{Result: &status}
+}
+
+// DNSEndpointDefaultingPath is the path where the webhook sets the defaults of DNSEndpoints.
+const DNSEndpointDefaultingPath = "/mutate-dnsendpoint"
+
+// DNSEndpointDefaulter sets the defaults of the records of DNSEndpoints at admission time:
+// the record type from the targets, and the TTL of the records without a TTL.
+type DNSEndpointDefaulter struct {
+	defaultTTL extdns_v1.TTL
+}
+
+// NewDNSEndpointDefaulter creates a DNSEndpointDefaulter. A defaultTTL of 0 keeps the records without a TTL.
+func NewDNSEndpointDefaulter(defaultTTL extdns_v1.TTL) *DNSEndpointDefaulter {
+	return &DNSEndpointDefaulter{defaultTTL: defaultTTL}
+}
+
+// ServeHTTP handles an AdmissionReview of a DNSEndpoint.
+func (d *DNSEndpointDefaulter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveAdmissionReview(w, r, d.review)
+}
+
+func (d *DNSEndpointDefaulter) review(_ context.Context, req *admission_v1.AdmissionRequest) *admission_v1.AdmissionResponse {
+	if req.Operation != admission_v1.Create && req.Operation != admission_v1.Update {
+		return &admission_v1.AdmissionResponse{Allowed: true}
+	}
+
+	var dep extdns_v1.DNSEndpoint
+	if err := json.Unmarshal(req.Object.Raw, &dep); err != nil {
+		return badRequest(fmt.Sprintf("error decoding the DNSEndpoint: %v", err))
+	}
+
+	patch := d.defaultsPatch(&dep)
+	if len(patch) == 0 {
+		return &admission_v1.AdmissionResponse{Allowed: true}
+	}
+	raw, err := json.Marshal(patch)
+	if err != nil {
+		return badRequest(fmt.Sprintf("error encoding the patch of the DNSEndpoint: %v", err))
+	}
+	patchType := admission_v1.PatchTypeJSONPatch
+	return &admission_v1.AdmissionResponse{Allowed: true, Patch: raw, PatchType: &patchType}
+}
+
+// patchOperation is an operation of a JSON patch.
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// defaultsPatch returns the JSON patch that sets the defaults of the records of the DNSEndpoint.
+func (d *DNSEndpointDefaulter) defaultsPatch(dep *extdns_v1.DNSEndpoint) []patchOperation {
+	var patch []patchOperation
+	for i, e := range dep.Spec.Endpoints {
+		if e == nil {
+			continue
+		}
+		if e.RecordType == "" {
+			if recordType := recordTypeOf(e.Targets); recordType != "" {
+				patch = append(patch, patchOperation{Op: "add", Path: fmt.Sprintf("/spec/endpoints/%d/recordType", i), Value: recordType})
+			}
+		}
+		if e.RecordTTL == 0 && d.defaultTTL != 0 {
+			patch = append(patch, patchOperation{Op: "add", Path: fmt.Sprintf("/spec/endpoints/%d/recordTTL", i), Value: d.defaultTTL})
+		}
+	}
+	return patch
+}
+
+// recordTypeOf returns the record type of the targets: A for IPv4 addresses, AAAA for IPv6 addresses and CNAME for
+// hostnames. It returns an empty string for targets of different kinds, which are left to the validation.
+func recordTypeOf(targets extdns_v1.Targets) string {
+	var recordType string
+	for _, target := range targets {
+		t := "CNAME"
+		if ip := net.ParseIP(target); ip != nil {
+			t = "AAAA"
+			if ip.To4() != nil {
+				t = "A"
+			}
+		}
+		if recordType != "" && recordType != t {
+			return ""
+		}
+		recordType = t
+	}
+	return recordType
+}
@@ -0,0 +1,133 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	extdns_v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/externaldns/v1"
+	extdns_validation "github.com/nginxinc/kubernetes-ingress/pkg/apis/externaldns/validation"
+	admission_v1 "k8s.io/api/admission/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func reviewDNSEndpoint(t *testing.T, h http.Handler, dep *extdns_v1.DNSEndpoint) *admission_v1.AdmissionResponse {
+	t.Helper()
+	raw, err := json.Marshal(dep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	review := admission_v1.AdmissionReview{
+		TypeMeta: meta_v1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request: &admission_v1.AdmissionRequest{
+			UID:       types.UID("705ab4f5-6393-11e8-b7cc-42010a800002"),
+			Operation: admission_v1.Create,
+			Namespace: "default",
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+	body, err := json.Marshal(review)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, DNSEndpointPath, bytes.NewReader(body)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("ServeHTTP() returned status %d, want %d", w.Code, http.StatusOK)
+	}
+	var got admission_v1.AdmissionReview
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("ServeHTTP() returned an invalid AdmissionReview: %v", err)
+	}
+	if got.Response == nil || got.Response.UID != review.Request.UID {
+		t.Fatalf("ServeHTTP() returned response %+v, want a response for UID %s", got.Response, review.Request.UID)
+	}
+	return got.Response
+}
+
+func TestDNSEndpointValidatorServeHTTP(t *testing.T) {
+	t.Parallel()
+	dep := &extdns_v1.DNSEndpoint{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "cafe", Namespace: "default"},
+		Spec: extdns_v1.DNSEndpointSpec{
+			Endpoints: []*extdns_v1.Endpoint{
+				{
+					DNSName:    "cafe.example.com",
+					Targets:    extdns_v1.Targets{"10.2.2.3"},
+					RecordType: "A",
+					RecordTTL:  30,
+				},
+			},
+		},
+	}
+
+	if resp := reviewDNSEndpoint(t, NewDNSEndpointValidator(), dep); !resp.Allowed {
+		t.Errorf("ServeHTTP() returned response %+v, want an allowed response", resp)
+	}
+
+	resp := reviewDNSEndpoint(t, NewDNSEndpointValidator(extdns_validation.Provider("cloudflare")), dep)
+	if resp.Allowed || resp.Result == nil || resp.Result.Details == nil || len(resp.Result.Details.Causes) != 1 ||
+		resp.Result.Details.Causes[0].Field != "spec.endpoints[0].recordTTL" {
+		t.Errorf("ServeHTTP() returned response %+v, want a denial with a cause for the field spec.endpoints[0].recordTTL", resp)
+	}
+}
+
+func TestDNSEndpointDefaulterServeHTTP(t *testing.T) {
+	t.Parallel()
+	dep := &extdns_v1.DNSEndpoint{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "cafe", Namespace: "default"},
+		Spec: extdns_v1.DNSEndpointSpec{
+			Endpoints: []*extdns_v1.Endpoint{
+				{
+					DNSName: "cafe.example.com",
+					Targets: extdns_v1.Targets{"10.2.2.3", "10.2.2.4"},
+				},
+				{
+					DNSName: "cafe.example.com",
+					Targets: extdns_v1.Targets{"2001:db8::2:1"},
+				},
+				{
+					DNSName:   "www.cafe.example.com",
+					Targets:   extdns_v1.Targets{"cafe.example.com"},
+					RecordTTL: 60,
+				},
+				{
+					DNSName:    "cafe.example.com",
+					Targets:    extdns_v1.Targets{`"v=spf1 -all"`},
+					RecordType: "TXT",
+				},
+				{
+					DNSName: "mixed.example.com",
+					Targets: extdns_v1.Targets{"10.2.2.3", "cafe.example.com"},
+				},
+			},
+		},
+	}
+
+	resp := reviewDNSEndpoint(t, NewDNSEndpointDefaulter(300), dep)
+	if !resp.Allowed || resp.PatchType == nil || *resp.PatchType != admission_v1.PatchTypeJSONPatch {
+		t.Fatalf("ServeHTTP() returned response %+v, want an allowed response with a JSON patch", resp)
+	}
+	expected := `[{"op":"add","path":"/spec/endpoints/0/recordType","value":"A"},` +
+		`{"op":"add","path":"/spec/endpoints/0/recordTTL","value":300},` +
+		`{"op":"add","path":"/spec/endpoints/1/recordType","value":"AAAA"},` +
+		`{"op":"add","path":"/spec/endpoints/1/recordTTL","value":300},` +
+		`{"op":"add","path":"/spec/endpoints/2/recordType","value":"CNAME"},` +
+		`{"op":"add","path":"/spec/endpoints/3/recordTTL","value":300},` +
+		`{"op":"add","path":"/spec/endpoints/4/recordTTL","value":300}]`
+	if string(resp.Patch) != expected {
+		t.Errorf("ServeHTTP() returned patch %s, want %s", resp.Patch, expected)
+	}
+
+	// without a default TTL, the records with a record type are not patched
+	dep.Spec.Endpoints = dep.Spec.Endpoints[3:4]
+	if resp := reviewDNSEndpoint(t, NewDNSEndpointDefaulter(0), dep); !resp.Allowed || resp.Patch != nil {
+		t.Errorf("ServeHTTP() returned response %+v, want an allowed response without a patch", resp)
+	}
+}
@@ -0,0 +1,239 @@
+// Package webhook provides the validating admission webhooks for Policies and DNSEndpoints and the conversion webhook for Policies.
+package webhook
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/nginxinc/kubernetes-ingress/internal/k8s/secrets"
+	conf_v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1"
+	"github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/validation"
+	admission_v1 "k8s.io/api/admission/v1"
+	api_v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// PolicyPath is the path where the webhook validates Policies.
+const PolicyPath = "/validate-policy"
+
+// maxRequestSize limits the size of an AdmissionReview.
+const maxRequestSize = 3 * 1024 * 1024
+
+var policyGroupKind = schema.GroupKind{Group: "k8s.nginx.org", Kind: "Policy"}
+
+// SecretGetter gets a Secret by its namespace and name.
+type SecretGetter func(ctx context.Context, namespace, name string) (*api_v1.Secret, error)
+
+// PolicyValidator validates Policies at admission time.
+// It runs the same validation as the controller and also checks
+// that the Secrets referenced by OIDC policies exist and are valid.
+type PolicyValidator struct {
+	getSecret        SecretGetter
+	isPlus           bool
+	enableOIDC       bool
+	oidcFIPSMode     bool
+	enableAppProtect bool
+}
+
+// NewPolicyValidator creates a PolicyValidator. With oidcFIPSMode, the OIDC policies must only use the FIPS 140-3
+// approved algorithms.
+func NewPolicyValidator(getSecret SecretGetter, isPlus, enableOIDC, oidcFIPSMode, enableAppProtect bool) *PolicyValidator {
+	return &PolicyValidator{
+		getSecret:        getSecret,
+		isPlus:           isPlus,
+		enableOIDC:       enableOIDC,
+		oidcFIPSMode:     oidcFIPSMode,
+		enableAppProtect: enableAppProtect,
+	}
+}
+
+// RunPolicyWebhook starts the webhook server, which validates Policies on PolicyPath and
+// converts them between the API versions on ConversionPath. If dnsEndpointValidator and dnsEndpointDefaulter are
+// not nil, the server also validates DNSEndpoints on DNSEndpointPath and sets their defaults on
+// DNSEndpointDefaultingPath. The Secret must be a valid TLS Secret, because the API server only calls webhooks
+// over HTTPS.
+func RunPolicyWebhook(port int, validator *PolicyValidator, dnsEndpointValidator *DNSEndpointValidator, dnsEndpointDefaulter *DNSEndpointDefaulter, secret *api_v1.Secret) {
+	cert, err := tls.X509KeyPair(secret.Data[api_v1.TLSCertKey], secret.Data[api_v1.TLSPrivateKeyKey])
+	if err != nil {
+		glog.Fatalf("Unable to create the TLS certificate of the policy webhook: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle(PolicyPath, validator)
+	mux.HandleFunc(ConversionPath, ServeConversion)
+	if dnsEndpointValidator != nil {
+		mux.Handle(DNSEndpointPath, dnsEndpointValidator)
+	}
+	if dnsEndpointDefaulter != nil {
+		mux.Handle(DNSEndpointDefaultingPath, dnsEndpointDefaulter)
+	}
+	srv := &http.Server{
+		Addr:         ":" + strconv.Itoa(port),
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		},
+	}
+	glog.Infof("Starting the policy webhook on: %v", srv.Addr)
+	glog.Fatal(srv.ListenAndServeTLS("", ""))
+}
+
+// ServeHTTP handles an AdmissionReview of a Policy.
+func (v *PolicyValidator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveAdmissionReview(w, r, v.review)
+}
+
+// serveAdmissionReview decodes an AdmissionReview and responds with the response of review to its request.
+func serveAdmissionReview(w http.ResponseWriter, r *http.Request, review func(context.Context, *admission_v1.AdmissionRequest) *admission_v1.AdmissionResponse) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error reading the request: %v", err), http.StatusBadRequest)
+		return
+	}
+	var ar admission_v1.AdmissionReview
+	if err := json.Unmarshal(body, &ar); err != nil {
+		http.Error(w, fmt.Sprintf("error decoding the AdmissionReview: %v", err), http.StatusBadRequest)
+		return
+	}
+	if ar.Request == nil {
+		http.Error(w, "the AdmissionReview has no request", http.StatusBadRequest)
+		return
+	}
+
+	ar.Response = review(r.Context(), ar.Request)
+	ar.Response.UID = ar.Request.UID
+	ar.Request = nil
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(ar); err != nil {
+		glog.Errorf("Error writing the AdmissionReview response: %v", err)
+	}
+}
+
+func (v *PolicyValidator) review(ctx context.Context, req *admission_v1.AdmissionRequest) *admission_v1.AdmissionResponse {
+	if req.Operation != admission_v1.Create && req.Operation != admission_v1.Update {
+		return &admission_v1.AdmissionResponse{Allowed: true}
+	}
+
+	var pol conf_v1.Policy
+	if err := json.Unmarshal(req.Object.Raw, &pol); err != nil {
+		return badRequest(fmt.Sprintf("error decoding the Policy: %v", err))
+	}
+	if pol.Namespace == "" {
+		pol.Namespace = req.Namespace
+	}
+
+	allErrs := v.Validate(ctx, &pol)
+	if len(allErrs) == 0 {
+		return &admission_v1.AdmissionResponse{Allowed: true}
+	}
+	status := apierrors.NewInvalid(policyGroupKind, pol.Name, allErrs).ErrStatus
+	return &admission_v1.AdmissionResponse{Result: &status}
+}
+
+// badRequest returns a response that denies a request that can't be decoded.
+func badRequest(msg string) *admission_v1.AdmissionResponse {
+	return &admission_v1.AdmissionResponse{
+		Result: &meta_v1.Status{
+			Status:  meta_v1.StatusFailure,
+			Code:    http.StatusBadRequest,
+			Reason:  meta_v1.StatusReasonBadRequest,
+			Message: msg,
+		},
+	}
+}
+
+// Validate validates a Policy and returns the errors with their field paths.
+func (v *PolicyValidator) Validate(ctx context.Context, pol *conf_v1.Policy) field.ErrorList {
+	allErrs := toErrorList(validation.ValidatePolicy(pol, v.isPlus, v.enableOIDC, v.enableAppProtect))
+	if len(allErrs) > 0 {
+		return allErrs
+	}
+	if v.oidcFIPSMode {
+		allErrs = toErrorList(validation.ValidatePolicyFIPS(pol))
+		if len(allErrs) > 0 {
+			return allErrs
+		}
+	}
+	if pol.Spec.OIDC != nil {
+		allErrs = append(allErrs, v.validateOIDCSecrets(ctx, pol.Namespace, pol.Spec.OIDC, field.NewPath("spec", "oidc"))...)
+	}
+	return allErrs
+}
+
+func (v *PolicyValidator) validateOIDCSecrets(ctx context.Context, namespace string, oidc *conf_v1.OIDC, fieldPath *field.Path) field.ErrorList {
+	allErrs := v.validateSecret(ctx, namespace, oidc.ClientSecret, secrets.SecretTypeOIDC, fieldPath.Child("clientSecret"))
+	if oidc.JARKeySecret != "" {
+		allErrs = append(allErrs, v.validateSecret(ctx, namespace, oidc.JARKeySecret, secrets.SecretTypeJWK, fieldPath.Child("jarKeySecret"))...)
+	}
+	if oidc.JWEKeySecret != "" {
+		allErrs = append(allErrs, v.validateSecret(ctx, namespace, oidc.JWEKeySecret, secrets.SecretTypeJWK, fieldPath.Child("jweKeySecret"))...)
+	}
+	if store := oidc.SessionStore; store != nil && store.Type == "cookie" {
+		allErrs = append(allErrs, v.validateSecret(ctx, namespace, store.Cookie.KeySecret, secrets.SecretTypeOIDCSessionKey, fieldPath.Child("sessionStore", "cookie", "keySecret"))...)
+	}
+	return allErrs
+}
+
+// validateSecret validates the Secret referenced from the namespace, either by its name or by <namespace>/<name>.
+func (v *PolicyValidator) validateSecret(ctx context.Context, namespace, reference string, secretType api_v1.SecretType, fieldPath *field.Path) field.ErrorList {
+	secretNamespace, name, _ := strings.Cut(secrets.GetReferenceKey(namespace, reference), "/")
+	secret, err := v.getSecret(ctx, secretNamespace, name)
+	if apierrors.IsNotFound(err) {
+		return field.ErrorList{field.NotFound(fieldPath, reference)}
+	}
+	if err != nil {
+		return field.ErrorList{field.InternalError(fieldPath, fmt.Errorf("error getting secret %s/%s: %w", secretNamespace, name, err))}
+	}
+	if err := secrets.ValidateReferenceGrant(secret, namespace); err != nil {
+		return field.ErrorList{field.Forbidden(fieldPath, err.Error())}
+	}
+	if secret.Type != secretType {
+		msg := fmt.Sprintf("secret of a wrong type '%s', must be '%s'", secret.Type, secretType)
+		return field.ErrorList{field.Invalid(fieldPath, reference, msg)}
+	}
+	if err := secrets.ValidateSecret(secret); err != nil {
+		return field.ErrorList{field.Invalid(fieldPath, reference, err.Error())}
+	}
+	return nil
+}
+
+// toErrorList converts the aggregate error of the validation back to the field errors.
+func toErrorList(err error) field.ErrorList {
+	if err == nil {
+		return nil
+	}
+	var agg utilerrors.Aggregate
+	if !errors.As(err, &agg) {
+		return field.ErrorList{field.InternalError(field.NewPath("spec"), err)}
+	}
+	allErrs := field.ErrorList{}
+	for _, e := range agg.Errors() {
+		var fieldErr *field.Error
+		if errors.As(e, &fieldErr) {
+			allErrs = append(allErrs, fieldErr)
+		} else {
+			allErrs = append(allErrs, field.InternalError(field.NewPath("spec"), e))
+		}
+	}
+	return allErrs
+}
@@ -0,0 +1,231 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-ingress/internal/k8s/secrets"
+	conf_v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1"
+	admission_v1 "k8s.io/api/admission/v1"
+	api_v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newTestValidator() *PolicyValidator {
+	secretList := map[string]*api_v1.Secret{
+		"default/oidc-secret": {
+			ObjectMeta: meta_v1.ObjectMeta{Name: "oidc-secret", Namespace: "default"},
+			Type:       secrets.SecretTypeOIDC,
+			Data:       map[string][]byte{secrets.ClientSecretKey: []byte("c2VjcmV0")},
+		},
+		"idp/shared-secret": {
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:        "shared-secret",
+				Namespace:   "idp",
+				Annotations: map[string]string{secrets.AllowedNamespacesAnnotation: "default"},
+			},
+			Type: secrets.SecretTypeOIDC,
+			Data: map[string][]byte{secrets.ClientSecretKey: []byte("c2VjcmV0")},
+		},
+		"idp/private-secret": {
+			ObjectMeta: meta_v1.ObjectMeta{Name: "private-secret", Namespace: "idp"},
+			Type:       secrets.SecretTypeOIDC,
+			Data:       map[string][]byte{secrets.ClientSecretKey: []byte("c2VjcmV0")},
+		},
+		"default/tls-secret": {
+			ObjectMeta: meta_v1.ObjectMeta{Name: "tls-secret", Namespace: "default"},
+			Type:       api_v1.SecretTypeTLS,
+		},
+		"default/session-key": {
+			ObjectMeta: meta_v1.ObjectMeta{Name: "session-key", Namespace: "default"},
+			Type:       secrets.SecretTypeOIDCSessionKey,
+			Data:       map[string][]byte{secrets.OIDCSessionKey: []byte("Nw2Xy7w3RbRzP3o0k4Gq8Tt5Qd9Vv1Ls")},
+		},
+	}
+	getSecret := func(_ context.Context, namespace, name string) (*api_v1.Secret, error) {
+		secret, exists := secretList[namespace+"/"+name]
+		if !exists {
+			return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, name)
+		}
+		return secret, nil
+	}
+	return NewPolicyValidator(getSecret, true, true, false, false)
+}
+
+func newOIDCPolicy() *conf_v1.Policy {
+	return &conf_v1.Policy{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "oidc-policy", Namespace: "default"},
+		Spec: conf_v1.PolicySpec{
+			OIDC: &conf_v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "nginx-plus",
+				ClientSecret:  "oidc-secret",
+				Scope:         "openid+profile",
+			},
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		modify   func(oidc *conf_v1.OIDC)
+		expected []string
+		msg      string
+	}{
+		{
+			modify: func(*conf_v1.OIDC) {},
+			msg:    "valid policy",
+		},
+		{
+			modify:   func(oidc *conf_v1.OIDC) { oidc.Scope = "profile+email" },
+			expected: []string{"spec.oidc.scope"},
+			msg:      "scope without openid",
+		},
+		{
+			modify:   func(oidc *conf_v1.OIDC) { oidc.AuthEndpoint = "idp.example.com/auth" },
+			expected: []string{"spec.oidc.authEndpoint"},
+			msg:      "auth endpoint without scheme",
+		},
+		{
+			modify:   func(oidc *conf_v1.OIDC) { oidc.RedirectURI = "https://app.example.com/callback" },
+			expected: []string{"spec.oidc.redirectURI"},
+			msg:      "redirect URI that is not a path",
+		},
+		{
+			modify:   func(oidc *conf_v1.OIDC) { oidc.ClientSecret = "missing-secret" },
+			expected: []string{"spec.oidc.clientSecret"},
+			msg:      "missing client secret",
+		},
+		{
+			modify: func(oidc *conf_v1.OIDC) {
+				oidc.ClientSecret = "tls-secret"
+				oidc.JWEKeySecret = "missing-secret"
+			},
+			expected: []string{"spec.oidc.clientSecret", "spec.oidc.jweKeySecret"},
+			msg:      "client secret of a wrong type and missing JWE key secret",
+		},
+		{
+			modify: func(oidc *conf_v1.OIDC) { oidc.ClientSecret = "idp/shared-secret" },
+			msg:    "client secret in another namespace that allows the namespace",
+		},
+		{
+			modify:   func(oidc *conf_v1.OIDC) { oidc.ClientSecret = "idp/private-secret" },
+			expected: []string{"spec.oidc.clientSecret"},
+			msg:      "client secret in another namespace that doesn't allow the namespace",
+		},
+		{
+			modify: func(oidc *conf_v1.OIDC) {
+				oidc.SessionStore = &conf_v1.OIDCSessionStore{Type: "cookie", Cookie: &conf_v1.OIDCCookieSessionStore{KeySecret: "session-key"}}
+			},
+			msg: "cookie session store",
+		},
+		{
+			modify: func(oidc *conf_v1.OIDC) {
+				oidc.SessionStore = &conf_v1.OIDCSessionStore{Type: "cookie", Cookie: &conf_v1.OIDCCookieSessionStore{KeySecret: "oidc-secret"}}
+			},
+			expected: []string{"spec.oidc.sessionStore.cookie.keySecret"},
+			msg:      "cookie session store with a key secret of a wrong type",
+		},
+	}
+
+	v := newTestValidator()
+	for _, test := range tests {
+		pol := newOIDCPolicy()
+		test.modify(pol.Spec.OIDC)
+
+		allErrs := v.Validate(context.Background(), pol)
+		var fields []string
+		for _, err := range allErrs {
+			fields = append(fields, err.Field)
+		}
+		if strings.Join(fields, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("Validate() returned errors %v for the case of %s, want errors for the fields %v", allErrs, test.msg, test.expected)
+		}
+	}
+}
+
+func TestValidateFIPS(t *testing.T) {
+	t.Parallel()
+	v := newTestValidator()
+	v.oidcFIPSMode = true
+
+	pol := newOIDCPolicy()
+	allErrs := v.Validate(context.Background(), pol)
+	if len(allErrs) != 1 || allErrs[0].Field != "spec.oidc.managedKeys.enable" {
+		t.Errorf("Validate() returned errors %v in FIPS mode, want an error for spec.oidc.managedKeys.enable", allErrs)
+	}
+
+	pol.Spec.OIDC.ManagedKeys = &conf_v1.OIDCManagedKeys{Enable: true}
+	if allErrs := v.Validate(context.Background(), pol); len(allErrs) != 0 {
+		t.Errorf("Validate() returned errors %v in FIPS mode for a policy with managed keys", allErrs)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	t.Parallel()
+	pol := newOIDCPolicy()
+	pol.Spec.OIDC.Scope = "profile"
+	raw, err := json.Marshal(pol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	review := admission_v1.AdmissionReview{
+		TypeMeta: meta_v1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request: &admission_v1.AdmissionRequest{
+			UID:       types.UID("705ab4f5-6393-11e8-b7cc-42010a800002"),
+			Operation: admission_v1.Create,
+			Namespace: "default",
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+	body, err := json.Marshal(review)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	newTestValidator().ServeHTTP(w, httptest.NewRequest(http.MethodPost, PolicyPath, bytes.NewReader(body)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("ServeHTTP() returned status %d, want %d", w.Code, http.StatusOK)
+	}
+	var got admission_v1.AdmissionReview
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("ServeHTTP() returned an invalid AdmissionReview: %v", err)
+	}
+	if got.Response == nil || got.Response.UID != review.Request.UID || got.Response.Allowed {
+		t.Fatalf("ServeHTTP() returned response %+v, want a denial for UID %s", got.Response, review.Request.UID)
+	}
+	result := got.Response.Result
+	if result == nil || result.Details == nil || len(result.Details.Causes) != 1 || result.Details.Causes[0].Field != "spec.oidc.scope" {
+		t.Errorf("ServeHTTP() returned result %+v, want a cause for the field spec.oidc.scope", result)
+	}
+}
+
+func TestServeHTTPAllowsDelete(t *testing.T) {
+	t.Parallel()
+	body := `{"apiVersion":"admission.k8s.io/v1","kind":"AdmissionReview","request":{"uid":"1","operation":"DELETE"}}`
+
+	w := httptest.NewRecorder()
+	newTestValidator().ServeHTTP(w, httptest.NewRequest(http.MethodPost, PolicyPath, strings.NewReader(body)))
+
+	var got admission_v1.AdmissionReview
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("ServeHTTP() returned an invalid AdmissionReview: %v", err)
+	}
+	if got.Response == nil || !got.Response.Allowed || got.Response.UID != "1" {
+		t.Errorf("ServeHTTP() returned response %+v, want an allowed response for UID 1", got.Response)
+	}
+}
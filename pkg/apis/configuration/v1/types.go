@@ -70,12 +70,31 @@ type ExternalDNS struct {
 	RecordType string `json:"recordType,omitempty"`
 	// TTL for the record
 	RecordTTL int64 `json:"recordTTL,omitempty"`
+	// Identifier to distinguish multiple records with the same name and type, required by the routing policies of Route53
+	// +optional
+	SetIdentifier string `json:"setIdentifier,omitempty"`
 	// Labels stores labels defined for the Endpoint
 	// +optional
 	Labels map[string]string `json:"labels,omitempty"`
 	// ProviderSpecific stores provider specific config
 	// +optional
 	ProviderSpecific ProviderSpecific `json:"providerSpecific,omitempty"`
+	// HealthCheck publishes only the external endpoints that pass the health check
+	// +optional
+	HealthCheck *ExternalDNSHealthCheck `json:"healthCheck,omitempty"`
+}
+
+// ExternalDNSHealthCheck defines the health check of the external endpoints of a virtual server.
+type ExternalDNSHealthCheck struct {
+	Enable bool `json:"enable"`
+	// Port of the external endpoints checked by the health check, 80 by default
+	Port int `json:"port,omitempty"`
+	// Path of the HTTP request of the health check. Without a path, the health check opens a TCP connection
+	Path string `json:"path,omitempty"`
+	// Timeout of the health check of an external endpoint, 5s by default
+	Timeout string `json:"timeout,omitempty"`
+	// Interval between the health checks, 30s by default
+	Interval string `json:"interval,omitempty"`
 }
 
 // ProviderSpecific is a list of properties.
@@ -191,6 +210,13 @@ type Route struct {
 	ErrorPages       []ErrorPage       `json:"errorPages"`
 	LocationSnippets string            `json:"location-snippets"`
 	Dos              string            `json:"dos"`
+	TokenExchange    *TokenExchange    `json:"tokenExchange"`
+	StepUpRequired   bool              `json:"stepUpRequired"`
+}
+
+// TokenExchange defines the token exchange of a route.
+type TokenExchange struct {
+	Audience string `json:"audience"`
 }
 
 // Action defines an action.
@@ -559,25 +585,35 @@ type Policy struct {
 
 // PolicyStatus is the status of the policy resource
 type PolicyStatus struct {
-	State   string `json:"state"`
-	Reason  string `json:"reason"`
-	Message string `json:"message"`
+	State   string           `json:"state"`
+	Reason  string           `json:"reason"`
+	Message string           `json:"message"`
+	IdP     *PolicyIdPStatus `json:"idp,omitempty"`
+}
+
+// PolicyIdPStatus is the reachability of the endpoints of the provider of an OIDC policy, probed by the Ingress Controller.
+type PolicyIdPStatus struct {
+	TokenEndpointReachable bool        `json:"tokenEndpointReachable"`
+	JWKSReachable          bool        `json:"jwksReachable"`
+	Message                string      `json:"message,omitempty"`
+	LastTransitionTime     metav1.Time `json:"lastTransitionTime,omitempty"`
 }
 
 // PolicySpec is the spec of the Policy resource.
 // The spec includes multiple fields, where each field represents a different policy.
 // Only one policy (field) is allowed.
 type PolicySpec struct {
-	IngressClass  string         `json:"ingressClassName"`
-	AccessControl *AccessControl `json:"accessControl"`
-	RateLimit     *RateLimit     `json:"rateLimit"`
-	JWTAuth       *JWTAuth       `json:"jwt"`
-	BasicAuth     *BasicAuth     `json:"basicAuth"`
-	IngressMTLS   *IngressMTLS   `json:"ingressMTLS"`
-	EgressMTLS    *EgressMTLS    `json:"egressMTLS"`
-	OIDC          *OIDC          `json:"oidc"`
-	WAF           *WAF           `json:"waf"`
-	APIKey        *APIKey        `json:"apiKey"`
+	IngressClass      string             `json:"ingressClassName"`
+	AccessControl     *AccessControl     `json:"accessControl"`
+	RateLimit         *RateLimit         `json:"rateLimit"`
+	JWTAuth           *JWTAuth           `json:"jwt"`
+	BasicAuth         *BasicAuth         `json:"basicAuth"`
+	IngressMTLS       *IngressMTLS       `json:"ingressMTLS"`
+	EgressMTLS        *EgressMTLS        `json:"egressMTLS"`
+	OIDC              *OIDC              `json:"oidc"`
+	WAF               *WAF               `json:"waf"`
+	APIKey            *APIKey            `json:"apiKey"`
+	ClientCredentials *ClientCredentials `json:"clientCredentials"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -612,11 +648,12 @@ type RateLimit struct {
 
 // JWTAuth holds JWT authentication configuration.
 type JWTAuth struct {
-	Realm    string `json:"realm"`
-	Secret   string `json:"secret"`
-	Token    string `json:"token"`
-	JwksURI  string `json:"jwksURI"`
-	KeyCache string `json:"keyCache"`
+	Realm      string      `json:"realm"`
+	Secret     string      `json:"secret"`
+	Token      string      `json:"token"`
+	JwksURI    string      `json:"jwksURI"`
+	KeyCache   string      `json:"keyCache"`
+	ClaimRules []ClaimRule `json:"claimRules"`
 }
 
 // BasicAuth holds HTTP Basic authentication configuration
@@ -649,16 +686,193 @@ type EgressMTLS struct {
 
 // OIDC defines an Open ID Connect policy.
 type OIDC struct {
-	AuthEndpoint      string   `json:"authEndpoint"`
-	TokenEndpoint     string   `json:"tokenEndpoint"`
-	JWKSURI           string   `json:"jwksURI"`
-	ClientID          string   `json:"clientID"`
-	ClientSecret      string   `json:"clientSecret"`
-	Scope             string   `json:"scope"`
-	RedirectURI       string   `json:"redirectURI"`
-	ZoneSyncLeeway    *int     `json:"zoneSyncLeeway"`
-	AuthExtraArgs     []string `json:"authExtraArgs"`
-	AccessTokenEnable bool     `json:"accessTokenEnable"`
+	AuthEndpoint          string                    `json:"authEndpoint"`
+	TokenEndpoint         string                    `json:"tokenEndpoint"`
+	JWKSURI               string                    `json:"jwksURI"`
+	ClientID              string                    `json:"clientID"`
+	ClientSecret          string                    `json:"clientSecret"`
+	Scope                 string                    `json:"scope"`
+	RedirectURI           string                    `json:"redirectURI"`
+	ZoneSyncLeeway        *int                      `json:"zoneSyncLeeway"`
+	AuthExtraArgs         []string                  `json:"authExtraArgs"`
+	AccessTokenEnable     bool                      `json:"accessTokenEnable"`
+	RetryOnUnauthorized   bool                      `json:"retryOnUnauthorized"`
+	ResponseMode          string                    `json:"responseMode"`
+	JAREnable             bool                      `json:"jarEnable"`
+	JARKeySecret          string                    `json:"jarKeySecret"`
+	JARMEnable            bool                      `json:"jarmEnable"`
+	DeviceAuthEndpoint    string                    `json:"deviceAuthEndpoint"`
+	DPoPEnable            bool                      `json:"dpopEnable"`
+	OAuth2UserEndpoint    string                    `json:"oauth2UserEndpoint"`
+	JWEKeySecret          string                    `json:"jweKeySecret"`
+	NonceEnforce          *bool                     `json:"nonceEnforce"`
+	Resources             []string                  `json:"resources"`
+	ErrorPages            string                    `json:"errorPages"`
+	DiscoveryEndpoint     string                    `json:"discoveryEndpoint"`
+	CookieSameSite        string                    `json:"cookieSameSite"`
+	CookieDomain          string                    `json:"cookieDomain"`
+	ClaimRules            []ClaimRule               `json:"claimRules"`
+	VirtualServerSelector *metav1.LabelSelector     `json:"virtualServerSelector"`
+	SessionStore          *OIDCSessionStore         `json:"sessionStore"`
+	MaxSessionsPerUser    int                       `json:"maxSessionsPerUser"`
+	SessionLimitAction    string                    `json:"sessionLimitAction"`
+	RefreshAheadSeconds   int                       `json:"refreshAheadSeconds"`
+	RevocationEndpoint    string                    `json:"revocationEndpoint"`
+	RememberMe            *OIDCRememberMe           `json:"rememberMe"`
+	StepUpMaxAge          *int                      `json:"stepUpMaxAge"`
+	IdPOutageBehavior     string                    `json:"idpOutageBehavior"`
+	LoginRedirectPaths    []string                  `json:"loginRedirectPaths"`
+	UnauthorizedBehavior  *OIDCUnauthorizedBehavior `json:"unauthorizedBehavior"`
+	SessionInfoClaims     []string                  `json:"sessionInfoClaims"`
+	LogoutCSRFEnable      bool                      `json:"logoutCSRFEnable"`
+	TerminateOnSessionEnd bool                      `json:"terminateOnSessionEnd"`
+	TracingEnable         bool                      `json:"tracingEnable"`
+	LogLevel              string                    `json:"logLevel"`
+	IdPType               string                    `json:"idpType"`
+	AllowedTenants        []string                  `json:"allowedTenants"`
+	AccessTokenAudience   string                    `json:"accessTokenAudience"`
+	Google                *OIDCGoogle               `json:"google"`
+	Issuer                string                    `json:"issuer"`
+	IdPs                  []OIDCIdP                 `json:"idps"`
+	IdPSelection          *OIDCIdPSelection         `json:"idpSelection"`
+	StrictSpecCompliance  bool                      `json:"strictSpecCompliance"`
+	TokenHashesRequired   bool                      `json:"tokenHashesRequired"`
+	Resolver              *OIDCResolver             `json:"resolver"`
+	HTTPProxy             string                    `json:"httpProxy"`
+	NoProxy               []string                  `json:"noProxy"`
+	RequestHeaders        []Header                  `json:"requestHeaders"`
+	ManagedKeys           *OIDCManagedKeys          `json:"managedKeys"`
+	PathPrefix            string                    `json:"pathPrefix"`
+	RateLimit             *OIDCRateLimit            `json:"rateLimit"`
+	LoginLockout          *OIDCLoginLockout         `json:"loginLockout"`
+	RedirectAllowlist     []string                  `json:"redirectAllowlist"`
+	SecurityHeaders       *OIDCSecurityHeaders      `json:"securityHeaders"`
+	Introspection         *OIDCIntrospection        `json:"introspection"`
+}
+
+// OIDCRememberMe defines the persistent session cookie of an OIDC policy.
+type OIDCRememberMe struct {
+	Enable   bool   `json:"enable"`
+	Duration string `json:"duration"`
+}
+
+// OIDCGoogle defines the Google Workspace options of an OIDC policy.
+type OIDCGoogle struct {
+	HostedDomain string `json:"hostedDomain"`
+}
+
+// OIDCResolver defines the DNS servers that resolve the hostnames of the IdP endpoints of an OIDC policy.
+type OIDCResolver struct {
+	Addresses []string `json:"addresses"`
+	Valid     string   `json:"valid"`
+	IPv6      *bool    `json:"ipv6"`
+}
+
+// OIDCManagedKeys defines the keys of an OIDC policy that the Ingress Controller generates and stores in a Secret:
+// the keys that sign the state and the nonce of the logins, and the key that encrypts the session cookies.
+type OIDCManagedKeys struct {
+	Enable           bool   `json:"enable"`
+	RotationInterval string `json:"rotationInterval"`
+}
+
+// OIDCRateLimit defines the rate limit of the requests of a client IP address that start a login, exchange an
+// authorization code or refresh the tokens of an OIDC policy.
+type OIDCRateLimit struct {
+	Rate     string `json:"rate"`
+	Burst    *int   `json:"burst"`
+	ZoneSize string `json:"zoneSize"`
+}
+
+// OIDCLoginLockout defines the lockout of the client IP addresses with repeated failed logins of an OIDC policy:
+// invalid states and authorization codes that the IdP rejects.
+type OIDCLoginLockout struct {
+	MaxFailures int    `json:"maxFailures"`
+	Window      string `json:"window"`
+	Duration    string `json:"duration"`
+	Action      string `json:"action"`
+	TarpitDelay string `json:"tarpitDelay"`
+}
+
+// OIDCSecurityHeaders defines the Content-Security-Policy, X-Frame-Options and Referrer-Policy headers of the
+// pages and the errors of the login and the logout of an OIDC policy.
+type OIDCSecurityHeaders struct {
+	ContentSecurityPolicy string `json:"contentSecurityPolicy"`
+	FrameOptions          string `json:"frameOptions"`
+	ReferrerPolicy        string `json:"referrerPolicy"`
+}
+
+// OIDCIntrospection defines the introspection of the opaque access tokens that API clients of an OIDC policy send
+// instead of a session cookie, and the cache of the results of the introspection.
+type OIDCIntrospection struct {
+	Enable          bool   `json:"enable"`
+	Endpoint        string `json:"endpoint"`
+	CacheTTL        string `json:"cacheTTL"`
+	CacheMaxEntries *int   `json:"cacheMaxEntries"`
+}
+
+// OIDCIdP defines an additional IdP of an OIDC policy. Its client secret is the name of a Secret of the
+// nginx.org/oidc type, like the client secret of the policy.
+type OIDCIdP struct {
+	Name               string   `json:"name"`
+	AuthEndpoint       string   `json:"authEndpoint"`
+	TokenEndpoint      string   `json:"tokenEndpoint"`
+	JWKSURI            string   `json:"jwksURI"`
+	ClientID           string   `json:"clientID"`
+	ClientSecret       string   `json:"clientSecret"`
+	Scope              string   `json:"scope"`
+	EndSessionEndpoint string   `json:"endSessionEndpoint"`
+	Hosts              []string `json:"hosts"`
+	EmailDomains       []string `json:"emailDomains"`
+}
+
+// OIDCIdPSelection defines how an OIDC policy with several IdPs selects the IdP of a login.
+type OIDCIdPSelection struct {
+	Type   string `json:"type"`
+	Header string `json:"header"`
+	Cookie string `json:"cookie"`
+}
+
+// OIDCUnauthorizedBehavior defines the requests of API clients that get a 401 response instead of a redirect to the
+// IdP.
+type OIDCUnauthorizedBehavior struct {
+	AcceptJSON bool     `json:"acceptJSON"`
+	Paths      []string `json:"paths"`
+}
+
+// ClaimRule defines a claim of the ID token of an OIDC policy or of the JWT of a JWT policy that must have one of the
+// values.
+type ClaimRule struct {
+	Claim  string   `json:"claim"`
+	Values []string `json:"values"`
+}
+
+// OIDCSessionStore defines where the sessions of an OIDC policy are stored.
+type OIDCSessionStore struct {
+	Type   string                  `json:"type"`
+	Redis  *OIDCRedisSessionStore  `json:"redis"`
+	Cookie *OIDCCookieSessionStore `json:"cookie"`
+}
+
+// OIDCCookieSessionStore defines the keys that encrypt the session cookies of an OIDC policy, and how long the
+// previous key decrypts the session cookies after a key rotation.
+type OIDCCookieSessionStore struct {
+	KeySecret           string `json:"keySecret"`
+	PreviousKeyLifetime string `json:"previousKeyLifetime"`
+}
+
+// OIDCRedisSessionStore defines a Redis or Valkey server that stores the sessions of an OIDC policy.
+type OIDCRedisSessionStore struct {
+	Address    string        `json:"address"`
+	Database   int           `json:"database"`
+	AuthSecret string        `json:"authSecret"`
+	TLS        *OIDCRedisTLS `json:"tls"`
+}
+
+// OIDCRedisTLS defines the TLS connection to a Redis or Valkey server.
+type OIDCRedisTLS struct {
+	Enable     bool   `json:"enable"`
+	CASecret   string `json:"caSecret"`
+	ServerName string `json:"serverName"`
 }
 
 // WAF defines an WAF policy.
@@ -684,6 +898,14 @@ type APIKey struct {
 	ClientSecret string      `json:"clientSecret"`
 }
 
+// ClientCredentials defines a Client Credentials policy.
+type ClientCredentials struct {
+	TokenEndpoint string `json:"tokenEndpoint"`
+	ClientID      string `json:"clientID"`
+	ClientSecret  string `json:"clientSecret"`
+	Scope         string `json:"scope"`
+}
+
 // SuppliedIn defines the locations API Key should be supplied in.
 type SuppliedIn struct {
 	Header []string `json:"header"`
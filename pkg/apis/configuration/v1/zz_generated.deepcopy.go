@@ -6,6 +6,7 @@
 package v1
 
 import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -183,6 +184,43 @@ func (in *BasicAuth) DeepCopy() *BasicAuth {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClaimRule) DeepCopyInto(out *ClaimRule) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClaimRule.
+func (in *ClaimRule) DeepCopy() *ClaimRule {
+	if in == nil {
+		return nil
+	}
+	out := new(ClaimRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientCredentials) DeepCopyInto(out *ClientCredentials) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientCredentials.
+func (in *ClientCredentials) DeepCopy() *ClientCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CertManager) DeepCopyInto(out *CertManager) {
 	*out = *in
@@ -321,6 +359,11 @@ func (in *ExternalDNS) DeepCopyInto(out *ExternalDNS) {
 		*out = make(ProviderSpecific, len(*in))
 		copy(*out, *in)
 	}
+	if in.HealthCheck != nil {
+		in, out := &in.HealthCheck, &out.HealthCheck
+		*out = new(ExternalDNSHealthCheck)
+		**out = **in
+	}
 	return
 }
 
@@ -334,6 +377,22 @@ func (in *ExternalDNS) DeepCopy() *ExternalDNS {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalDNSHealthCheck) DeepCopyInto(out *ExternalDNSHealthCheck) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalDNSHealthCheck.
+func (in *ExternalDNSHealthCheck) DeepCopy() *ExternalDNSHealthCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalDNSHealthCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExternalEndpoint) DeepCopyInto(out *ExternalEndpoint) {
 	*out = *in
@@ -502,6 +561,13 @@ func (in *IngressMTLS) DeepCopy() *IngressMTLS {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *JWTAuth) DeepCopyInto(out *JWTAuth) {
 	*out = *in
+	if in.ClaimRules != nil {
+		in, out := &in.ClaimRules, &out.ClaimRules
+		*out = make([]ClaimRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -577,6 +643,125 @@ func (in *OIDC) DeepCopyInto(out *OIDC) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.NonceEnforce != nil {
+		in, out := &in.NonceEnforce, &out.NonceEnforce
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClaimRules != nil {
+		in, out := &in.ClaimRules, &out.ClaimRules
+		*out = make([]ClaimRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VirtualServerSelector != nil {
+		in, out := &in.VirtualServerSelector, &out.VirtualServerSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SessionStore != nil {
+		in, out := &in.SessionStore, &out.SessionStore
+		*out = new(OIDCSessionStore)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RememberMe != nil {
+		in, out := &in.RememberMe, &out.RememberMe
+		*out = new(OIDCRememberMe)
+		**out = **in
+	}
+	if in.StepUpMaxAge != nil {
+		in, out := &in.StepUpMaxAge, &out.StepUpMaxAge
+		*out = new(int)
+		**out = **in
+	}
+	if in.LoginRedirectPaths != nil {
+		in, out := &in.LoginRedirectPaths, &out.LoginRedirectPaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.UnauthorizedBehavior != nil {
+		in, out := &in.UnauthorizedBehavior, &out.UnauthorizedBehavior
+		*out = new(OIDCUnauthorizedBehavior)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SessionInfoClaims != nil {
+		in, out := &in.SessionInfoClaims, &out.SessionInfoClaims
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedTenants != nil {
+		in, out := &in.AllowedTenants, &out.AllowedTenants
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Google != nil {
+		in, out := &in.Google, &out.Google
+		*out = new(OIDCGoogle)
+		**out = **in
+	}
+	if in.IdPs != nil {
+		in, out := &in.IdPs, &out.IdPs
+		*out = make([]OIDCIdP, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.IdPSelection != nil {
+		in, out := &in.IdPSelection, &out.IdPSelection
+		*out = new(OIDCIdPSelection)
+		**out = **in
+	}
+	if in.Resolver != nil {
+		in, out := &in.Resolver, &out.Resolver
+		*out = new(OIDCResolver)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NoProxy != nil {
+		in, out := &in.NoProxy, &out.NoProxy
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RequestHeaders != nil {
+		in, out := &in.RequestHeaders, &out.RequestHeaders
+		*out = make([]Header, len(*in))
+		copy(*out, *in)
+	}
+	if in.ManagedKeys != nil {
+		in, out := &in.ManagedKeys, &out.ManagedKeys
+		*out = new(OIDCManagedKeys)
+		**out = **in
+	}
+	if in.RateLimit != nil {
+		in, out := &in.RateLimit, &out.RateLimit
+		*out = new(OIDCRateLimit)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LoginLockout != nil {
+		in, out := &in.LoginLockout, &out.LoginLockout
+		*out = new(OIDCLoginLockout)
+		**out = **in
+	}
+	if in.RedirectAllowlist != nil {
+		in, out := &in.RedirectAllowlist, &out.RedirectAllowlist
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SecurityHeaders != nil {
+		in, out := &in.SecurityHeaders, &out.SecurityHeaders
+		*out = new(OIDCSecurityHeaders)
+		**out = **in
+	}
+	if in.Introspection != nil {
+		in, out := &in.Introspection, &out.Introspection
+		*out = new(OIDCIntrospection)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -590,13 +775,303 @@ func (in *OIDC) DeepCopy() *OIDC {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCCookieSessionStore) DeepCopyInto(out *OIDCCookieSessionStore) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCCookieSessionStore.
+func (in *OIDCCookieSessionStore) DeepCopy() *OIDCCookieSessionStore {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCCookieSessionStore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCGoogle) DeepCopyInto(out *OIDCGoogle) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCGoogle.
+func (in *OIDCGoogle) DeepCopy() *OIDCGoogle {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCGoogle)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCResolver) DeepCopyInto(out *OIDCResolver) {
+	*out = *in
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IPv6 != nil {
+		in, out := &in.IPv6, &out.IPv6
+		*out = new(bool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCResolver.
+func (in *OIDCResolver) DeepCopy() *OIDCResolver {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCResolver)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCIdP) DeepCopyInto(out *OIDCIdP) {
+	*out = *in
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.EmailDomains != nil {
+		in, out := &in.EmailDomains, &out.EmailDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCIdP.
+func (in *OIDCIdP) DeepCopy() *OIDCIdP {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCIdP)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCIdPSelection) DeepCopyInto(out *OIDCIdPSelection) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCIdPSelection.
+func (in *OIDCIdPSelection) DeepCopy() *OIDCIdPSelection {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCIdPSelection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCIntrospection) DeepCopyInto(out *OIDCIntrospection) {
+	*out = *in
+	if in.CacheMaxEntries != nil {
+		in, out := &in.CacheMaxEntries, &out.CacheMaxEntries
+		*out = new(int)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCIntrospection.
+func (in *OIDCIntrospection) DeepCopy() *OIDCIntrospection {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCIntrospection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCLoginLockout) DeepCopyInto(out *OIDCLoginLockout) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCLoginLockout.
+func (in *OIDCLoginLockout) DeepCopy() *OIDCLoginLockout {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCLoginLockout)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCManagedKeys) DeepCopyInto(out *OIDCManagedKeys) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCManagedKeys.
+func (in *OIDCManagedKeys) DeepCopy() *OIDCManagedKeys {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCManagedKeys)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCRateLimit) DeepCopyInto(out *OIDCRateLimit) {
+	*out = *in
+	if in.Burst != nil {
+		in, out := &in.Burst, &out.Burst
+		*out = new(int)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCRateLimit.
+func (in *OIDCRateLimit) DeepCopy() *OIDCRateLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCRateLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCRedisSessionStore) DeepCopyInto(out *OIDCRedisSessionStore) {
+	*out = *in
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(OIDCRedisTLS)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCRedisSessionStore.
+func (in *OIDCRedisSessionStore) DeepCopy() *OIDCRedisSessionStore {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCRedisSessionStore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCRedisTLS) DeepCopyInto(out *OIDCRedisTLS) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCRedisTLS.
+func (in *OIDCRedisTLS) DeepCopy() *OIDCRedisTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCRedisTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCRememberMe) DeepCopyInto(out *OIDCRememberMe) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCRememberMe.
+func (in *OIDCRememberMe) DeepCopy() *OIDCRememberMe {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCRememberMe)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCSecurityHeaders) DeepCopyInto(out *OIDCSecurityHeaders) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCSecurityHeaders.
+func (in *OIDCSecurityHeaders) DeepCopy() *OIDCSecurityHeaders {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCSecurityHeaders)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCSessionStore) DeepCopyInto(out *OIDCSessionStore) {
+	*out = *in
+	if in.Redis != nil {
+		in, out := &in.Redis, &out.Redis
+		*out = new(OIDCRedisSessionStore)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Cookie != nil {
+		in, out := &in.Cookie, &out.Cookie
+		*out = new(OIDCCookieSessionStore)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCSessionStore.
+func (in *OIDCSessionStore) DeepCopy() *OIDCSessionStore {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCSessionStore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCUnauthorizedBehavior) DeepCopyInto(out *OIDCUnauthorizedBehavior) {
+	*out = *in
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCUnauthorizedBehavior.
+func (in *OIDCUnauthorizedBehavior) DeepCopy() *OIDCUnauthorizedBehavior {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCUnauthorizedBehavior)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Policy) DeepCopyInto(out *Policy) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -618,6 +1093,23 @@ func (in *Policy) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyIdPStatus) DeepCopyInto(out *PolicyIdPStatus) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyIdPStatus.
+func (in *PolicyIdPStatus) DeepCopy() *PolicyIdPStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyIdPStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PolicyList) DeepCopyInto(out *PolicyList) {
 	*out = *in
@@ -683,7 +1175,7 @@ func (in *PolicySpec) DeepCopyInto(out *PolicySpec) {
 	if in.JWTAuth != nil {
 		in, out := &in.JWTAuth, &out.JWTAuth
 		*out = new(JWTAuth)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.BasicAuth != nil {
 		in, out := &in.BasicAuth, &out.BasicAuth
@@ -715,6 +1207,11 @@ func (in *PolicySpec) DeepCopyInto(out *PolicySpec) {
 		*out = new(APIKey)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ClientCredentials != nil {
+		in, out := &in.ClientCredentials, &out.ClientCredentials
+		*out = new(ClientCredentials)
+		**out = **in
+	}
 	return
 }
 
@@ -731,6 +1228,11 @@ func (in *PolicySpec) DeepCopy() *PolicySpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PolicyStatus) DeepCopyInto(out *PolicyStatus) {
 	*out = *in
+	if in.IdP != nil {
+		in, out := &in.IdP, &out.IdP
+		*out = new(PolicyIdPStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -917,6 +1419,11 @@ func (in *Route) DeepCopyInto(out *Route) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.TokenExchange != nil {
+		in, out := &in.TokenExchange, &out.TokenExchange
+		*out = new(TokenExchange)
+		**out = **in
+	}
 	return
 }
 
@@ -1072,6 +1579,22 @@ func (in *TLSRedirect) DeepCopy() *TLSRedirect {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TokenExchange) DeepCopyInto(out *TokenExchange) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TokenExchange.
+func (in *TokenExchange) DeepCopy() *TokenExchange {
+	if in == nil {
+		return nil
+	}
+	out := new(TokenExchange)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TransportServer) DeepCopyInto(out *TransportServer) {
 	*out = *in
@@ -0,0 +1,205 @@
+package v2
+
+import (
+	v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1"
+)
+
+// ConvertFromV1 converts a v1 Policy to a v2 Policy. The conversion is lossless.
+func ConvertFromV1(in *v1.Policy) *Policy {
+	in = in.DeepCopy()
+	out := &Policy{
+		TypeMeta:   in.TypeMeta,
+		ObjectMeta: in.ObjectMeta,
+		Spec: PolicySpec{
+			IngressClass:      in.Spec.IngressClass,
+			AccessControl:     in.Spec.AccessControl,
+			RateLimit:         in.Spec.RateLimit,
+			JWTAuth:           in.Spec.JWTAuth,
+			BasicAuth:         in.Spec.BasicAuth,
+			IngressMTLS:       in.Spec.IngressMTLS,
+			EgressMTLS:        in.Spec.EgressMTLS,
+			OIDC:              convertOIDCFromV1(in.Spec.OIDC),
+			WAF:               in.Spec.WAF,
+			APIKey:            in.Spec.APIKey,
+			ClientCredentials: in.Spec.ClientCredentials,
+		},
+		Status: in.Status,
+	}
+	out.APIVersion = SchemeGroupVersion.String()
+	out.Kind = "Policy"
+	return out
+}
+
+// ConvertToV1 converts the Policy to a v1 Policy. The conversion is lossless.
+func (in *Policy) ConvertToV1() *v1.Policy {
+	in = in.DeepCopy()
+	out := &v1.Policy{
+		TypeMeta:   in.TypeMeta,
+		ObjectMeta: in.ObjectMeta,
+		Spec: v1.PolicySpec{
+			IngressClass:      in.Spec.IngressClass,
+			AccessControl:     in.Spec.AccessControl,
+			RateLimit:         in.Spec.RateLimit,
+			JWTAuth:           in.Spec.JWTAuth,
+			BasicAuth:         in.Spec.BasicAuth,
+			IngressMTLS:       in.Spec.IngressMTLS,
+			EgressMTLS:        in.Spec.EgressMTLS,
+			OIDC:              in.Spec.OIDC.convertToV1(),
+			WAF:               in.Spec.WAF,
+			APIKey:            in.Spec.APIKey,
+			ClientCredentials: in.Spec.ClientCredentials,
+		},
+		Status: in.Status,
+	}
+	out.APIVersion = v1.SchemeGroupVersion.String()
+	out.Kind = "Policy"
+	return out
+}
+
+func convertOIDCFromV1(in *v1.OIDC) *OIDC {
+	if in == nil {
+		return nil
+	}
+	out := &OIDC{
+		DiscoveryEndpoint:     in.DiscoveryEndpoint,
+		AuthEndpoint:          in.AuthEndpoint,
+		TokenEndpoint:         in.TokenEndpoint,
+		JWKSURI:               in.JWKSURI,
+		ClientID:              in.ClientID,
+		ClientSecret:          in.ClientSecret,
+		Scope:                 in.Scope,
+		RedirectURI:           in.RedirectURI,
+		ZoneSyncLeeway:        in.ZoneSyncLeeway,
+		AuthExtraArgs:         in.AuthExtraArgs,
+		AccessTokenEnable:     in.AccessTokenEnable,
+		RetryOnUnauthorized:   in.RetryOnUnauthorized,
+		ResponseMode:          in.ResponseMode,
+		JAREnable:             in.JAREnable,
+		JARKeySecret:          in.JARKeySecret,
+		JARMEnable:            in.JARMEnable,
+		DeviceAuthEndpoint:    in.DeviceAuthEndpoint,
+		DPoPEnable:            in.DPoPEnable,
+		OAuth2UserEndpoint:    in.OAuth2UserEndpoint,
+		JWEKeySecret:          in.JWEKeySecret,
+		NonceEnforce:          in.NonceEnforce,
+		Resources:             in.Resources,
+		ErrorPages:            in.ErrorPages,
+		ClaimRules:            in.ClaimRules,
+		VirtualServerSelector: in.VirtualServerSelector,
+		SessionStore:          in.SessionStore,
+		MaxSessionsPerUser:    in.MaxSessionsPerUser,
+		SessionLimitAction:    in.SessionLimitAction,
+		RefreshAheadSeconds:   in.RefreshAheadSeconds,
+		RevocationEndpoint:    in.RevocationEndpoint,
+		RememberMe:            in.RememberMe,
+		StepUpMaxAge:          in.StepUpMaxAge,
+		IdPOutageBehavior:     in.IdPOutageBehavior,
+		LoginRedirectPaths:    in.LoginRedirectPaths,
+		UnauthorizedBehavior:  in.UnauthorizedBehavior,
+		SessionInfoClaims:     in.SessionInfoClaims,
+		LogoutCSRFEnable:      in.LogoutCSRFEnable,
+		TerminateOnSessionEnd: in.TerminateOnSessionEnd,
+		TracingEnable:         in.TracingEnable,
+		LogLevel:              in.LogLevel,
+		IdPType:               in.IdPType,
+		AllowedTenants:        in.AllowedTenants,
+		AccessTokenAudience:   in.AccessTokenAudience,
+		Google:                in.Google,
+		Issuer:                in.Issuer,
+		IdPs:                  in.IdPs,
+		IdPSelection:          in.IdPSelection,
+		StrictSpecCompliance:  in.StrictSpecCompliance,
+		TokenHashesRequired:   in.TokenHashesRequired,
+		Resolver:              in.Resolver,
+		HTTPProxy:             in.HTTPProxy,
+		NoProxy:               in.NoProxy,
+		RequestHeaders:        in.RequestHeaders,
+		ManagedKeys:           in.ManagedKeys,
+		PathPrefix:            in.PathPrefix,
+		RateLimit:             in.RateLimit,
+		LoginLockout:          in.LoginLockout,
+		RedirectAllowlist:     in.RedirectAllowlist,
+		SecurityHeaders:       in.SecurityHeaders,
+		Introspection:         in.Introspection,
+	}
+	if in.CookieSameSite != "" || in.CookieDomain != "" {
+		out.Cookie = &OIDCCookie{
+			SameSite: in.CookieSameSite,
+			Domain:   in.CookieDomain,
+		}
+	}
+	return out
+}
+
+func (in *OIDC) convertToV1() *v1.OIDC {
+	if in == nil {
+		return nil
+	}
+	out := &v1.OIDC{
+		DiscoveryEndpoint:     in.DiscoveryEndpoint,
+		AuthEndpoint:          in.AuthEndpoint,
+		TokenEndpoint:         in.TokenEndpoint,
+		JWKSURI:               in.JWKSURI,
+		ClientID:              in.ClientID,
+		ClientSecret:          in.ClientSecret,
+		Scope:                 in.Scope,
+		RedirectURI:           in.RedirectURI,
+		ZoneSyncLeeway:        in.ZoneSyncLeeway,
+		AuthExtraArgs:         in.AuthExtraArgs,
+		AccessTokenEnable:     in.AccessTokenEnable,
+		RetryOnUnauthorized:   in.RetryOnUnauthorized,
+		ResponseMode:          in.ResponseMode,
+		JAREnable:             in.JAREnable,
+		JARKeySecret:          in.JARKeySecret,
+		JARMEnable:            in.JARMEnable,
+		DeviceAuthEndpoint:    in.DeviceAuthEndpoint,
+		DPoPEnable:            in.DPoPEnable,
+		OAuth2UserEndpoint:    in.OAuth2UserEndpoint,
+		JWEKeySecret:          in.JWEKeySecret,
+		NonceEnforce:          in.NonceEnforce,
+		Resources:             in.Resources,
+		ErrorPages:            in.ErrorPages,
+		ClaimRules:            in.ClaimRules,
+		VirtualServerSelector: in.VirtualServerSelector,
+		SessionStore:          in.SessionStore,
+		MaxSessionsPerUser:    in.MaxSessionsPerUser,
+		SessionLimitAction:    in.SessionLimitAction,
+		RefreshAheadSeconds:   in.RefreshAheadSeconds,
+		RevocationEndpoint:    in.RevocationEndpoint,
+		RememberMe:            in.RememberMe,
+		StepUpMaxAge:          in.StepUpMaxAge,
+		IdPOutageBehavior:     in.IdPOutageBehavior,
+		LoginRedirectPaths:    in.LoginRedirectPaths,
+		UnauthorizedBehavior:  in.UnauthorizedBehavior,
+		SessionInfoClaims:     in.SessionInfoClaims,
+		LogoutCSRFEnable:      in.LogoutCSRFEnable,
+		TerminateOnSessionEnd: in.TerminateOnSessionEnd,
+		TracingEnable:         in.TracingEnable,
+		LogLevel:              in.LogLevel,
+		IdPType:               in.IdPType,
+		AllowedTenants:        in.AllowedTenants,
+		AccessTokenAudience:   in.AccessTokenAudience,
+		Google:                in.Google,
+		Issuer:                in.Issuer,
+		IdPs:                  in.IdPs,
+		IdPSelection:          in.IdPSelection,
+		StrictSpecCompliance:  in.StrictSpecCompliance,
+		TokenHashesRequired:   in.TokenHashesRequired,
+		Resolver:              in.Resolver,
+		HTTPProxy:             in.HTTPProxy,
+		NoProxy:               in.NoProxy,
+		RequestHeaders:        in.RequestHeaders,
+		ManagedKeys:           in.ManagedKeys,
+		PathPrefix:            in.PathPrefix,
+		RateLimit:             in.RateLimit,
+		LoginLockout:          in.LoginLockout,
+		RedirectAllowlist:     in.RedirectAllowlist,
+		SecurityHeaders:       in.SecurityHeaders,
+		Introspection:         in.Introspection,
+	}
+	if in.Cookie != nil {
+		out.CookieSameSite = in.Cookie.SameSite
+		out.CookieDomain = in.Cookie.Domain
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+package v2
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConvertRoundTrip(t *testing.T) {
+	t.Parallel()
+	leeway := 10
+	nonceEnforce := false
+	pol := &v1.Policy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "k8s.nginx.org/v1", Kind: "Policy"},
+		ObjectMeta: metav1.ObjectMeta{Name: "oidc-policy", Namespace: "default", ResourceVersion: "42"},
+		Spec: v1.PolicySpec{
+			IngressClass: "nginx",
+			OIDC: &v1.OIDC{
+				AuthEndpoint:        "https://idp.example.com/auth",
+				TokenEndpoint:       "https://idp.example.com/token",
+				JWKSURI:             "https://idp.example.com/certs",
+				ClientID:            "client",
+				ClientSecret:        "oidc-secret",
+				Scope:               "openid+profile",
+				RedirectURI:         "/callback",
+				ZoneSyncLeeway:      &leeway,
+				AuthExtraArgs:       []string{"prompt=login"},
+				AccessTokenEnable:   true,
+				RetryOnUnauthorized: true,
+				ResponseMode:        "form_post",
+				JAREnable:           true,
+				JARKeySecret:        "jar-secret",
+				JARMEnable:          true,
+				DeviceAuthEndpoint:  "https://idp.example.com/device",
+				DPoPEnable:          true,
+				JWEKeySecret:        "jwe-secret",
+				NonceEnforce:        &nonceEnforce,
+				Resources:           []string{"https://api.example.com"},
+				ErrorPages:          "oidc-error-pages",
+				DiscoveryEndpoint:   "https://idp.example.com/.well-known/openid-configuration",
+				CookieSameSite:      "Strict",
+				CookieDomain:        "example.com",
+				ClaimRules:          []v1.ClaimRule{{Claim: "groups", Values: []string{"admins"}}},
+			},
+		},
+		Status: v1.PolicyStatus{State: "Valid", Reason: "AddedOrUpdated"},
+	}
+
+	converted := ConvertFromV1(pol)
+	if converted.APIVersion != "k8s.nginx.org/v2" {
+		t.Errorf("ConvertFromV1() returned apiVersion %q, want %q", converted.APIVersion, "k8s.nginx.org/v2")
+	}
+	if converted.Spec.OIDC.Cookie == nil || *converted.Spec.OIDC.Cookie != (OIDCCookie{SameSite: "Strict", Domain: "example.com"}) {
+		t.Errorf("ConvertFromV1() returned cookie %+v", converted.Spec.OIDC.Cookie)
+	}
+
+	if diff := cmp.Diff(pol, converted.ConvertToV1()); diff != "" {
+		t.Errorf("ConvertToV1(ConvertFromV1()) mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestConvertCoversAllOIDCFields(t *testing.T) {
+	t.Parallel()
+	// The cookie fields of v1 are grouped in the cookie block of v2. A field added to
+	// one version must be added to the other version and to the conversion.
+	v1Fields := reflect.TypeOf(v1.OIDC{}).NumField()
+	v2Fields := reflect.TypeOf(OIDC{}).NumField()
+	if v1Fields != v2Fields+1 {
+		t.Errorf("v1 OIDC has %d fields and v2 OIDC has %d fields, want one more in v1", v1Fields, v2Fields)
+	}
+}
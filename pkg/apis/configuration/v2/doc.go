@@ -0,0 +1,5 @@
+// +k8s:deepcopy-gen=package
+// +groupName=k8s.nginx.org
+
+// Package v2 is the v2 version of the API.
+package v2
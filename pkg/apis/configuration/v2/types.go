@@ -0,0 +1,123 @@
+package v2
+
+import (
+	v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:validation:Optional
+// +kubebuilder:resource:shortName=pol
+// +kubebuilder:subresource:status
+// +kubebuilder:unservedversion
+// +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.state`,description="Current state of the Policy. If the resource has a valid status, it means it has been validated and accepted by the Ingress Controller."
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Policy defines a Policy for VirtualServer and VirtualServerRoute resources.
+// The v2 version differs from v1 only in the OIDC policy. It is converted to
+// and from v1, the storage version, by the conversion webhook of the Ingress Controller.
+type Policy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PolicySpec      `json:"spec"`
+	Status v1.PolicyStatus `json:"status"`
+}
+
+// PolicySpec is the spec of the Policy resource.
+// The spec includes multiple fields, where each field represents a different policy.
+// Only one policy (field) is allowed.
+type PolicySpec struct {
+	IngressClass      string                `json:"ingressClassName"`
+	AccessControl     *v1.AccessControl     `json:"accessControl"`
+	RateLimit         *v1.RateLimit         `json:"rateLimit"`
+	JWTAuth           *v1.JWTAuth           `json:"jwt"`
+	BasicAuth         *v1.BasicAuth         `json:"basicAuth"`
+	IngressMTLS       *v1.IngressMTLS       `json:"ingressMTLS"`
+	EgressMTLS        *v1.EgressMTLS        `json:"egressMTLS"`
+	OIDC              *OIDC                 `json:"oidc"`
+	WAF               *v1.WAF               `json:"waf"`
+	APIKey            *v1.APIKey            `json:"apiKey"`
+	ClientCredentials *v1.ClientCredentials `json:"clientCredentials"`
+}
+
+// OIDC defines an Open ID Connect policy.
+type OIDC struct {
+	DiscoveryEndpoint     string                       `json:"discoveryEndpoint"`
+	AuthEndpoint          string                       `json:"authEndpoint"`
+	TokenEndpoint         string                       `json:"tokenEndpoint"`
+	JWKSURI               string                       `json:"jwksURI"`
+	ClientID              string                       `json:"clientID"`
+	ClientSecret          string                       `json:"clientSecret"`
+	Scope                 string                       `json:"scope"`
+	RedirectURI           string                       `json:"redirectURI"`
+	ZoneSyncLeeway        *int                         `json:"zoneSyncLeeway"`
+	AuthExtraArgs         []string                     `json:"authExtraArgs"`
+	AccessTokenEnable     bool                         `json:"accessTokenEnable"`
+	RetryOnUnauthorized   bool                         `json:"retryOnUnauthorized"`
+	ResponseMode          string                       `json:"responseMode"`
+	JAREnable             bool                         `json:"jarEnable"`
+	JARKeySecret          string                       `json:"jarKeySecret"`
+	JARMEnable            bool                         `json:"jarmEnable"`
+	DeviceAuthEndpoint    string                       `json:"deviceAuthEndpoint"`
+	DPoPEnable            bool                         `json:"dpopEnable"`
+	OAuth2UserEndpoint    string                       `json:"oauth2UserEndpoint"`
+	JWEKeySecret          string                       `json:"jweKeySecret"`
+	NonceEnforce          *bool                        `json:"nonceEnforce"`
+	Resources             []string                     `json:"resources"`
+	ErrorPages            string                       `json:"errorPages"`
+	Cookie                *OIDCCookie                  `json:"cookie"`
+	ClaimRules            []v1.ClaimRule               `json:"claimRules"`
+	VirtualServerSelector *metav1.LabelSelector        `json:"virtualServerSelector"`
+	SessionStore          *v1.OIDCSessionStore         `json:"sessionStore"`
+	MaxSessionsPerUser    int                          `json:"maxSessionsPerUser"`
+	SessionLimitAction    string                       `json:"sessionLimitAction"`
+	RefreshAheadSeconds   int                          `json:"refreshAheadSeconds"`
+	RevocationEndpoint    string                       `json:"revocationEndpoint"`
+	RememberMe            *v1.OIDCRememberMe           `json:"rememberMe"`
+	StepUpMaxAge          *int                         `json:"stepUpMaxAge"`
+	IdPOutageBehavior     string                       `json:"idpOutageBehavior"`
+	LoginRedirectPaths    []string                     `json:"loginRedirectPaths"`
+	UnauthorizedBehavior  *v1.OIDCUnauthorizedBehavior `json:"unauthorizedBehavior"`
+	SessionInfoClaims     []string                     `json:"sessionInfoClaims"`
+	LogoutCSRFEnable      bool                         `json:"logoutCSRFEnable"`
+	TerminateOnSessionEnd bool                         `json:"terminateOnSessionEnd"`
+	TracingEnable         bool                         `json:"tracingEnable"`
+	LogLevel              string                       `json:"logLevel"`
+	IdPType               string                       `json:"idpType"`
+	AllowedTenants        []string                     `json:"allowedTenants"`
+	AccessTokenAudience   string                       `json:"accessTokenAudience"`
+	Google                *v1.OIDCGoogle               `json:"google"`
+	Issuer                string                       `json:"issuer"`
+	IdPs                  []v1.OIDCIdP                 `json:"idps"`
+	IdPSelection          *v1.OIDCIdPSelection         `json:"idpSelection"`
+	StrictSpecCompliance  bool                         `json:"strictSpecCompliance"`
+	TokenHashesRequired   bool                         `json:"tokenHashesRequired"`
+	Resolver              *v1.OIDCResolver             `json:"resolver"`
+	HTTPProxy             string                       `json:"httpProxy"`
+	NoProxy               []string                     `json:"noProxy"`
+	RequestHeaders        []v1.Header                  `json:"requestHeaders"`
+	ManagedKeys           *v1.OIDCManagedKeys          `json:"managedKeys"`
+	PathPrefix            string                       `json:"pathPrefix"`
+	RateLimit             *v1.OIDCRateLimit            `json:"rateLimit"`
+	LoginLockout          *v1.OIDCLoginLockout         `json:"loginLockout"`
+	RedirectAllowlist     []string                     `json:"redirectAllowlist"`
+	SecurityHeaders       *v1.OIDCSecurityHeaders      `json:"securityHeaders"`
+	Introspection         *v1.OIDCIntrospection        `json:"introspection"`
+}
+
+// OIDCCookie defines the session cookie of an OIDC policy.
+type OIDCCookie struct {
+	SameSite string `json:"sameSite"`
+	Domain   string `json:"domain"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// PolicyList is a list of the Policy resources.
+type PolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []Policy `json:"items"`
+}
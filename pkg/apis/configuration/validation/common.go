@@ -193,6 +193,31 @@ func validateSecretName(name string, fieldPath *field.Path) field.ErrorList {
 	return allErrs
 }
 
+// validateSecretReference validates the name of a Secret in the same namespace or <namespace>/<name> of a Secret in another namespace.
+func validateSecretReference(reference string, fieldPath *field.Path) field.ErrorList {
+	namespace, name, found := strings.Cut(reference, "/")
+	if !found {
+		return validateSecretName(reference, fieldPath)
+	}
+
+	allErrs := field.ErrorList{}
+	if name == "" || strings.Contains(name, "/") {
+		return append(allErrs, field.Invalid(fieldPath, reference, "must be the name of a secret or follow the format <namespace>/<name>"))
+	}
+	for _, msg := range validation.IsDNS1123Label(namespace) {
+		allErrs = append(allErrs, field.Invalid(fieldPath, reference, msg))
+	}
+	return append(allErrs, validateSecretName(name, fieldPath)...)
+}
+
+func validateConfigMapName(name string, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for _, msg := range validation.IsDNS1123Subdomain(name) {
+		allErrs = append(allErrs, field.Invalid(fieldPath, name, msg))
+	}
+	return allErrs
+}
+
 func mapToPrettyString(m map[string]bool) string {
 	var out []string
 
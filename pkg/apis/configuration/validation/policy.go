@@ -3,13 +3,17 @@ package validation
 import (
 	"fmt"
 	"net"
+	"net/http"
 	"net/url"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"unicode"
 
+	"github.com/nginxinc/kubernetes-ingress/internal/configs"
 	v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
 	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 )
@@ -20,6 +24,26 @@ func ValidatePolicy(policy *v1.Policy, isPlus, enableOIDC, enableAppProtect bool
 	return allErrs.ToAggregate()
 }
 
+// ValidatePolicyFIPS validates that a valid OIDC policy only uses the FIPS 140-3 approved algorithms with keys of
+// approved lengths, in the FIPS mode of the OIDC policies.
+func ValidatePolicyFIPS(policy *v1.Policy) error {
+	oidc := policy.Spec.OIDC
+	if oidc == nil {
+		return nil
+	}
+	fieldPath := field.NewPath("spec", "oidc")
+	allErrs := field.ErrorList{}
+	// Without managed keys, the HMAC keys of the state and the nonces are derived from the client secret and the
+	// name of the VirtualServer, which can be shorter than the 112 bits of an approved HMAC key
+	if oidc.ManagedKeys == nil || !oidc.ManagedKeys.Enable {
+		allErrs = append(allErrs, field.Required(fieldPath.Child("managedKeys", "enable"), "required in FIPS mode for the random HMAC keys of the state and the nonces"))
+	}
+	if oidc.OAuth2UserEndpoint != "" {
+		allErrs = append(allErrs, field.Forbidden(fieldPath.Child("oauth2UserEndpoint"), "not allowed in FIPS mode, the sessions are signed with HS256 and a key derived from the client secret"))
+	}
+	return allErrs.ToAggregate()
+}
+
 func validatePolicySpec(spec *v1.PolicySpec, fieldPath *field.Path, isPlus, enableOIDC, enableAppProtect bool) field.ErrorList {
 	allErrs := field.ErrorList{}
 
@@ -77,6 +101,19 @@ func validatePolicySpec(spec *v1.PolicySpec, fieldPath *field.Path, isPlus, enab
 		fieldCount++
 	}
 
+	if spec.ClientCredentials != nil {
+		if !enableOIDC {
+			allErrs = append(allErrs, field.Forbidden(fieldPath.Child("clientCredentials"),
+				"OIDC must be enabled via cli argument -enable-oidc to use Client Credentials policy"))
+		}
+		if !isPlus {
+			return append(allErrs, field.Forbidden(fieldPath.Child("clientCredentials"), "Client Credentials is only supported in NGINX Plus"))
+		}
+
+		allErrs = append(allErrs, validateClientCredentials(spec.ClientCredentials, fieldPath.Child("clientCredentials"))...)
+		fieldCount++
+	}
+
 	if spec.WAF != nil {
 		if !isPlus {
 			allErrs = append(allErrs, field.Forbidden(fieldPath.Child("waf"), "WAF is only supported in NGINX Plus"))
@@ -93,7 +130,7 @@ func validatePolicySpec(spec *v1.PolicySpec, fieldPath *field.Path, isPlus, enab
 	if fieldCount != 1 {
 		msg := "must specify exactly one of: `accessControl`, `rateLimit`, `ingressMTLS`, `egressMTLS`, `basicAuth`, `apiKey`"
 		if isPlus {
-			msg = fmt.Sprint(msg, ", `jwt`, `oidc`, `waf`")
+			msg = fmt.Sprint(msg, ", `jwt`, `oidc`, `waf`, `clientCredentials`")
 		}
 		allErrs = append(allErrs, field.Invalid(fieldPath, "", msg))
 	}
@@ -165,6 +202,9 @@ func validateJWT(jwt *v1.JWTAuth, fieldPath *field.Path) field.ErrorList {
 		return field.ErrorList{field.Required(fieldPath.Child("realm"), "realm field must be present")}
 	}
 	allErrs := validateRealm(jwt.Realm, fieldPath.Child("realm"))
+	for i, rule := range jwt.ClaimRules {
+		allErrs = append(allErrs, validateJWTClaimRule(rule, fieldPath.Child("claimRules").Index(i))...)
+	}
 
 	// Use either JWT Secret or JWKS URI, they are mutually exclusive.
 	if jwt.Secret == "" && jwt.JwksURI == "" {
@@ -206,6 +246,28 @@ func validateJWT(jwt *v1.JWTAuth, fieldPath *field.Path) field.ErrorList {
 	return allErrs
 }
 
+// jwtClaimNameRegexp matches the names of the top-level claims, which NGINX gets with the $jwt_claim_ variables.
+var jwtClaimNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// jwtClaimValueRegexp matches the values of the claim rules of a JWT policy, without the comma that NGINX joins the
+// values of an array claim with, and without the characters that end or escape the regex of the map of the rule.
+var jwtClaimValueRegexp = regexp.MustCompile(`^[^,"\\\x00-\x1f\x7f]+$`)
+
+// validateJWTClaimRule validates a claim rule of a JWT policy, which NGINX checks with the $jwt_claim_ variable of
+// the claim.
+func validateJWTClaimRule(rule v1.ClaimRule, fieldPath *field.Path) field.ErrorList {
+	allErrs := validateClaimRule(rule, fieldPath)
+	if rule.Claim != "" && !jwtClaimNameRegexp.MatchString(rule.Claim) {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("claim"), rule.Claim, "must be the name of a top-level claim, e.g. groups"))
+	}
+	for i, value := range rule.Values {
+		if !jwtClaimValueRegexp.MatchString(value) {
+			allErrs = append(allErrs, field.Invalid(fieldPath.Child("values").Index(i), value, "must not be empty or contain commas, double quotes or backslashes"))
+		}
+	}
+	return allErrs
+}
+
 func validateBasic(basic *v1.BasicAuth, fieldPath *field.Path) field.ErrorList {
 	if basic.Secret == "" {
 		return field.ErrorList{field.Required(fieldPath.Child("secret"), "")}
@@ -251,7 +313,9 @@ func validateOIDC(oidc *v1.OIDC, fieldPath *field.Path) field.ErrorList {
 	if oidc.TokenEndpoint == "" {
 		return field.ErrorList{field.Required(fieldPath.Child("tokenEndpoint"), "")}
 	}
-	if oidc.JWKSURI == "" {
+	// The JWKS URI of a Keycloak, Okta, Auth0 or ADFS policy is discovered from its realm, authorization server,
+	// tenant or ADFS
+	if oidc.JWKSURI == "" && oidc.OAuth2UserEndpoint == "" && oidc.DiscoveryEndpoint == "" && !discoveredIdPType(oidc.IdPType) {
 		return field.ErrorList{field.Required(fieldPath.Child("jwksURI"), "")}
 	}
 	if oidc.ClientID == "" {
@@ -262,7 +326,9 @@ func validateOIDC(oidc *v1.OIDC, fieldPath *field.Path) field.ErrorList {
 	}
 
 	allErrs := field.ErrorList{}
-	if oidc.Scope != "" {
+	if oidc.Scope != "" && oidc.OAuth2UserEndpoint != "" {
+		allErrs = append(allErrs, validateOAuth2Scope(oidc.Scope, fieldPath.Child("scope"))...)
+	} else if oidc.Scope != "" {
 		allErrs = append(allErrs, validateOIDCScope(oidc.Scope, fieldPath.Child("scope"))...)
 	}
 	if oidc.RedirectURI != "" {
@@ -274,14 +340,281 @@ func validateOIDC(oidc *v1.OIDC, fieldPath *field.Path) field.ErrorList {
 	if oidc.AuthExtraArgs != nil {
 		allErrs = append(allErrs, validateQueryString(strings.Join(oidc.AuthExtraArgs, "&"), fieldPath.Child("authExtraArgs"))...)
 	}
+	if oidc.ResponseMode != "" {
+		allErrs = append(allErrs, validateOIDCResponseMode(oidc.ResponseMode, fieldPath.Child("responseMode"))...)
+	}
+	if oidc.JAREnable {
+		if oidc.JARKeySecret == "" {
+			allErrs = append(allErrs, field.Required(fieldPath.Child("jarKeySecret"), "required when jarEnable is true"))
+		} else {
+			allErrs = append(allErrs, validateSecretName(oidc.JARKeySecret, fieldPath.Child("jarKeySecret"))...)
+		}
+	}
+	if oidc.DeviceAuthEndpoint != "" {
+		allErrs = append(allErrs, validateURL(oidc.DeviceAuthEndpoint, fieldPath.Child("deviceAuthEndpoint"))...)
+	}
+	if oidc.JWEKeySecret != "" {
+		allErrs = append(allErrs, validateSecretName(oidc.JWEKeySecret, fieldPath.Child("jweKeySecret"))...)
+	}
+	if oidc.ErrorPages != "" {
+		allErrs = append(allErrs, validateConfigMapName(oidc.ErrorPages, fieldPath.Child("errorPages"))...)
+	}
+	for i, resource := range oidc.Resources {
+		allErrs = append(allErrs, validateOIDCResource(resource, fieldPath.Child("resources").Index(i))...)
+	}
+	if oidc.DPoPEnable && !oidc.AccessTokenEnable {
+		allErrs = append(allErrs, field.Forbidden(fieldPath.Child("dpopEnable"), "requires accessTokenEnable to be true"))
+	}
+	if oidc.DiscoveryEndpoint != "" {
+		allErrs = append(allErrs, validateURL(oidc.DiscoveryEndpoint, fieldPath.Child("discoveryEndpoint"))...)
+	}
+	if oidc.RevocationEndpoint != "" {
+		allErrs = append(allErrs, validateURL(oidc.RevocationEndpoint, fieldPath.Child("revocationEndpoint"))...)
+	}
+	if oidc.CookieSameSite != "" {
+		allErrs = append(allErrs, validateOIDCCookieSameSite(oidc.CookieSameSite, fieldPath.Child("cookieSameSite"))...)
+	}
+	if oidc.CookieDomain != "" {
+		allErrs = append(allErrs, validateSSLName(oidc.CookieDomain, fieldPath.Child("cookieDomain"))...)
+	}
+	for i, rule := range oidc.ClaimRules {
+		allErrs = append(allErrs, validateClaimRule(rule, fieldPath.Child("claimRules").Index(i))...)
+	}
+	allErrs = append(allErrs, metav1validation.ValidateLabelSelector(oidc.VirtualServerSelector,
+		metav1validation.LabelSelectorValidationOptions{}, fieldPath.Child("virtualServerSelector"))...)
+	if oidc.SessionStore != nil {
+		managedKeys := oidc.ManagedKeys != nil && oidc.ManagedKeys.Enable
+		allErrs = append(allErrs, validateOIDCSessionStore(oidc.SessionStore, managedKeys, fieldPath.Child("sessionStore"))...)
+	}
+	if oidc.ManagedKeys != nil {
+		allErrs = append(allErrs, validateOIDCManagedKeys(oidc.ManagedKeys, fieldPath.Child("managedKeys"))...)
+	}
+	allErrs = append(allErrs, validatePositiveIntOrZero(oidc.MaxSessionsPerUser, fieldPath.Child("maxSessionsPerUser"))...)
+	if oidc.SessionLimitAction != "" {
+		if oidc.MaxSessionsPerUser == 0 {
+			allErrs = append(allErrs, field.Forbidden(fieldPath.Child("sessionLimitAction"), "requires maxSessionsPerUser"))
+		} else {
+			allErrs = append(allErrs, validateOIDCSessionLimitAction(oidc.SessionLimitAction, fieldPath.Child("sessionLimitAction"))...)
+		}
+	}
+	allErrs = append(allErrs, validatePositiveIntOrZero(oidc.RefreshAheadSeconds, fieldPath.Child("refreshAheadSeconds"))...)
+	if oidc.RefreshAheadSeconds > 0 && oidc.SessionStore != nil && oidc.SessionStore.Type == "cookie" {
+		// The tokens are refreshed in the background, where the session cookies can't be updated
+		allErrs = append(allErrs, field.Forbidden(fieldPath.Child("refreshAheadSeconds"), "can't be used with a cookie session store"))
+	}
+	if oidc.RememberMe != nil {
+		allErrs = append(allErrs, validateTime(oidc.RememberMe.Duration, fieldPath.Child("rememberMe", "duration"))...)
+	}
+	if oidc.StepUpMaxAge != nil {
+		allErrs = append(allErrs, validatePositiveIntOrZero(*oidc.StepUpMaxAge, fieldPath.Child("stepUpMaxAge"))...)
+	}
+	if oidc.IdPOutageBehavior != "" {
+		allErrs = append(allErrs, validateOIDCIdPOutageBehavior(oidc.IdPOutageBehavior, fieldPath.Child("idpOutageBehavior"))...)
+	}
+	if oidc.LogLevel != "" {
+		allErrs = append(allErrs, validateOIDCLogLevel(oidc.LogLevel, fieldPath.Child("logLevel"))...)
+	}
+	if oidc.IdPType != "" {
+		allErrs = append(allErrs, validateOIDCIdPType(oidc.IdPType, fieldPath.Child("idpType"))...)
+		if oidc.IdPType == "keycloak" && !keycloakEndpointRegexp.MatchString(oidc.AuthEndpoint) {
+			allErrs = append(allErrs, field.Invalid(fieldPath.Child("authEndpoint"), oidc.AuthEndpoint,
+				"must be the endpoint of a Keycloak realm for idpType keycloak, e.g. https://keycloak.example.com/realms/apps/protocol/openid-connect/auth"))
+		}
+		if oidc.IdPType == "okta" && !oktaAuthEndpointRegexp.MatchString(oidc.AuthEndpoint) {
+			allErrs = append(allErrs, field.Invalid(fieldPath.Child("authEndpoint"), oidc.AuthEndpoint,
+				"must be the authorization endpoint of an Okta authorization server for idpType okta, e.g. https://example.okta.com/oauth2/default/v1/authorize"))
+		}
+		if oidc.IdPType == "cognito" {
+			allErrs = append(allErrs, validateCognitoEndpoints(oidc, fieldPath)...)
+		}
+		if oidc.IdPType == "auth0" {
+			allErrs = append(allErrs, validateAuth0Endpoints(oidc, fieldPath)...)
+		}
+		if oidc.IdPType == "pingfederate" {
+			allErrs = append(allErrs, validatePingFederateEndpoints(oidc, fieldPath)...)
+		}
+		if oidc.IdPType == "forgerock" {
+			allErrs = append(allErrs, validateForgeRockEndpoints(oidc, fieldPath)...)
+		}
+		if oidc.IdPType == "adfs" {
+			allErrs = append(allErrs, validateADFSEndpoints(oidc, fieldPath)...)
+			// ADFS issues the access tokens for the relying party trust of a single resource
+			if len(oidc.Resources) != 1 {
+				allErrs = append(allErrs, field.Required(fieldPath.Child("resources"), "must be the identifier of one relying party trust for idpType adfs"))
+			}
+		}
+		if oidc.OAuth2UserEndpoint != "" {
+			allErrs = append(allErrs, field.Forbidden(fieldPath.Child("idpType"), "can't be used with oauth2UserEndpoint"))
+		}
+	}
+	if oidc.Google != nil && oidc.Google.HostedDomain != "" {
+		allErrs = append(allErrs, validateOIDCDomain(oidc.Google.HostedDomain, fieldPath.Child("google", "hostedDomain"))...)
+		if oidc.OAuth2UserEndpoint != "" {
+			allErrs = append(allErrs, field.Forbidden(fieldPath.Child("google", "hostedDomain"), "can't be used with oauth2UserEndpoint"))
+		}
+	}
+	if oidc.Issuer != "" {
+		allErrs = append(allErrs, validateOIDCIssuer(oidc.Issuer, fieldPath.Child("issuer"))...)
+	}
+	if oidc.Resolver != nil {
+		allErrs = append(allErrs, validateOIDCResolver(oidc.Resolver, fieldPath.Child("resolver"))...)
+	}
+	if oidc.HTTPProxy != "" {
+		allErrs = append(allErrs, validateOIDCHTTPProxy(oidc.HTTPProxy, fieldPath.Child("httpProxy"))...)
+	} else if len(oidc.NoProxy) > 0 {
+		allErrs = append(allErrs, field.Forbidden(fieldPath.Child("noProxy"), "requires httpProxy"))
+	}
+	for i, entry := range oidc.NoProxy {
+		allErrs = append(allErrs, validateOIDCNoProxy(entry, fieldPath.Child("noProxy").Index(i))...)
+	}
+	allErrs = append(allErrs, validateOIDCRequestHeaders(oidc.RequestHeaders, fieldPath.Child("requestHeaders"))...)
+	if oidc.PathPrefix != "" {
+		allErrs = append(allErrs, validateOIDCPathPrefix(oidc.PathPrefix, fieldPath.Child("pathPrefix"))...)
+	}
+	if oidc.RateLimit != nil {
+		allErrs = append(allErrs, validateOIDCRateLimit(oidc.RateLimit, fieldPath.Child("rateLimit"))...)
+	}
+	if oidc.LoginLockout != nil {
+		allErrs = append(allErrs, validateOIDCLoginLockout(oidc.LoginLockout, fieldPath.Child("loginLockout"))...)
+	}
+	if len(oidc.AllowedTenants) > 0 && oidc.IdPType != "azuread" && !strings.Contains(oidc.Issuer, oidcTenantIDPlaceholder) {
+		allErrs = append(allErrs, field.Forbidden(fieldPath.Child("allowedTenants"), "requires idpType azuread or an issuer with "+oidcTenantIDPlaceholder))
+	}
+	for i, tenant := range oidc.AllowedTenants {
+		allErrs = append(allErrs, validateOIDCTenantID(tenant, fieldPath.Child("allowedTenants").Index(i))...)
+	}
+	if oidc.Introspection != nil {
+		allErrs = append(allErrs, validateOIDCIntrospection(oidc.Introspection, fieldPath.Child("introspection"))...)
+	}
+	if oidc.AccessTokenAudience != "" {
+		// The access tokens of the Okta org authorization server are only meant for Okta
+		if m := oktaAuthEndpointRegexp.FindStringSubmatch(oidc.AuthEndpoint); oidc.IdPType != "okta" || m == nil || m[1] == "" {
+			allErrs = append(allErrs, field.Forbidden(fieldPath.Child("accessTokenAudience"), "requires idpType okta with a custom authorization server"))
+		}
+		allErrs = append(allErrs, validateOIDCAudience(oidc.AccessTokenAudience, fieldPath.Child("accessTokenAudience"))...)
+	}
+	for i, path := range oidc.LoginRedirectPaths {
+		allErrs = append(allErrs, validateOIDCPath(path, fieldPath.Child("loginRedirectPaths").Index(i))...)
+	}
+	if len(oidc.RedirectAllowlist) > 0 && len(oidc.LoginRedirectPaths) > 0 {
+		allErrs = append(allErrs, field.Forbidden(fieldPath.Child("redirectAllowlist"), "can't be used with loginRedirectPaths"))
+	}
+	for i, entry := range oidc.RedirectAllowlist {
+		allErrs = append(allErrs, validateOIDCRedirectAllowlistEntry(entry, fieldPath.Child("redirectAllowlist").Index(i))...)
+	}
+	if oidc.SecurityHeaders != nil {
+		allErrs = append(allErrs, validateOIDCSecurityHeaders(oidc.SecurityHeaders, fieldPath.Child("securityHeaders"))...)
+	}
+	for i, claim := range oidc.SessionInfoClaims {
+		allErrs = append(allErrs, validateOIDCClaimName(claim, fieldPath.Child("sessionInfoClaims").Index(i))...)
+	}
+	if oidc.UnauthorizedBehavior != nil {
+		for i, path := range oidc.UnauthorizedBehavior.Paths {
+			allErrs = append(allErrs, validateOIDCPath(path, fieldPath.Child("unauthorizedBehavior", "paths").Index(i))...)
+		}
+	}
+	if len(oidc.IdPs) > 0 {
+		allErrs = append(allErrs, validateOIDCIdPs(oidc, fieldPath)...)
+	} else if oidc.IdPSelection != nil {
+		allErrs = append(allErrs, field.Forbidden(fieldPath.Child("idpSelection"), "requires idps"))
+	}
+	if oidc.StrictSpecCompliance {
+		allErrs = append(allErrs, validateOIDCStrictSpecCompliance(oidc, fieldPath)...)
+	}
+	if oidc.OAuth2UserEndpoint != "" {
+		allErrs = append(allErrs, validateOAuth2Adapter(oidc, fieldPath)...)
+	} else if (oidc.DiscoveryEndpoint == "" && !discoveredIdPType(oidc.IdPType)) || oidc.JWKSURI != "" {
+		allErrs = append(allErrs, validateURL(oidc.JWKSURI, fieldPath.Child("jwksURI"))...)
+	}
 
 	allErrs = append(allErrs, validateURL(oidc.AuthEndpoint, fieldPath.Child("authEndpoint"))...)
 	allErrs = append(allErrs, validateURL(oidc.TokenEndpoint, fieldPath.Child("tokenEndpoint"))...)
-	allErrs = append(allErrs, validateURL(oidc.JWKSURI, fieldPath.Child("jwksURI"))...)
-	allErrs = append(allErrs, validateSecretName(oidc.ClientSecret, fieldPath.Child("clientSecret"))...)
+	allErrs = append(allErrs, validateSecretReference(oidc.ClientSecret, fieldPath.Child("clientSecret"))...)
 	return append(allErrs, validateClientID(oidc.ClientID, fieldPath.Child("clientID"))...)
 }
 
+// validateOIDCStrictSpecCompliance validates an OIDC policy that enforces the ID token validation of OIDC Core.
+// The claims that must be checked have to be known: the nonce is always checked, the issuer must be set, unless
+// the idpType checks it.
+func validateOIDCStrictSpecCompliance(oidc *v1.OIDC, fieldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if oidc.NonceEnforce != nil && !*oidc.NonceEnforce {
+		allErrs = append(allErrs, field.Forbidden(fieldPath.Child("nonceEnforce"), "can't be false with strictSpecCompliance"))
+	}
+	if oidc.Issuer == "" && oidc.IdPType != "okta" && oidc.IdPType != "azuread" {
+		allErrs = append(allErrs, field.Required(fieldPath.Child("issuer"), "must be set with strictSpecCompliance, unless idpType is okta or azuread"))
+	}
+	if len(oidc.IdPs) > 0 {
+		allErrs = append(allErrs, field.Forbidden(fieldPath.Child("strictSpecCompliance"), "can't be used with idps"))
+	}
+	if oidc.OAuth2UserEndpoint != "" {
+		allErrs = append(allErrs, field.Forbidden(fieldPath.Child("strictSpecCompliance"), "can't be used with oauth2UserEndpoint"))
+	}
+	return allErrs
+}
+
+// validateOIDCResource validates a resource indicator, which must be an absolute URI without
+// a fragment, as per https://www.rfc-editor.org/rfc/rfc8707#section-2.
+func validateOIDCResource(resource string, fieldPath *field.Path) field.ErrorList {
+	u, err := url.Parse(resource)
+	if err != nil {
+		return field.ErrorList{field.Invalid(fieldPath, resource, err.Error())}
+	}
+	if !u.IsAbs() {
+		return field.ErrorList{field.Invalid(fieldPath, resource, "must be an absolute URI")}
+	}
+	if strings.Contains(resource, "#") {
+		return field.ErrorList{field.Invalid(fieldPath, resource, "must not include a fragment")}
+	}
+	return nil
+}
+
+// validateOAuth2Adapter validates an OIDC policy for a plain OAuth 2.0 provider. The provider doesn't
+// issue ID tokens, so the features that validate them with the keys from jwksURI are not supported.
+func validateOAuth2Adapter(oidc *v1.OIDC, fieldPath *field.Path) field.ErrorList {
+	allErrs := validateURL(oidc.OAuth2UserEndpoint, fieldPath.Child("oauth2UserEndpoint"))
+	if oidc.JWKSURI != "" {
+		allErrs = append(allErrs, field.Forbidden(fieldPath.Child("jwksURI"), "can't be used with oauth2UserEndpoint"))
+	}
+	if oidc.DiscoveryEndpoint != "" {
+		allErrs = append(allErrs, field.Forbidden(fieldPath.Child("discoveryEndpoint"), "can't be used with oauth2UserEndpoint"))
+	}
+	if oidc.JARMEnable {
+		allErrs = append(allErrs, field.Forbidden(fieldPath.Child("jarmEnable"), "can't be used with oauth2UserEndpoint"))
+	}
+	if oidc.TokenHashesRequired {
+		allErrs = append(allErrs, field.Forbidden(fieldPath.Child("tokenHashesRequired"), "can't be used with oauth2UserEndpoint"))
+	}
+	if oidc.DeviceAuthEndpoint != "" {
+		allErrs = append(allErrs, field.Forbidden(fieldPath.Child("deviceAuthEndpoint"), "can't be used with oauth2UserEndpoint"))
+	}
+	if oidc.JWEKeySecret != "" {
+		allErrs = append(allErrs, field.Forbidden(fieldPath.Child("jweKeySecret"), "can't be used with oauth2UserEndpoint"))
+	}
+	return allErrs
+}
+
+func validateClientCredentials(clientCredentials *v1.ClientCredentials, fieldPath *field.Path) field.ErrorList {
+	if clientCredentials.TokenEndpoint == "" {
+		return field.ErrorList{field.Required(fieldPath.Child("tokenEndpoint"), "")}
+	}
+	if clientCredentials.ClientID == "" {
+		return field.ErrorList{field.Required(fieldPath.Child("clientID"), "")}
+	}
+	if clientCredentials.ClientSecret == "" {
+		return field.ErrorList{field.Required(fieldPath.Child("clientSecret"), "")}
+	}
+
+	allErrs := field.ErrorList{}
+	if clientCredentials.Scope != "" {
+		allErrs = append(allErrs, validateOAuth2Scope(clientCredentials.Scope, fieldPath.Child("scope"))...)
+	}
+
+	allErrs = append(allErrs, validateURL(clientCredentials.TokenEndpoint, fieldPath.Child("tokenEndpoint"))...)
+	allErrs = append(allErrs, validateSecretName(clientCredentials.ClientSecret, fieldPath.Child("clientSecret"))...)
+	return append(allErrs, validateClientID(clientCredentials.ClientID, fieldPath.Child("clientID"))...)
+}
+
 func validateAPIKey(apiKey *v1.APIKey, fieldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 	if apiKey.SuppliedIn.Query == nil && apiKey.SuppliedIn.Header == nil {
@@ -441,6 +774,770 @@ func validateOIDCScope(scope string, fieldPath *field.Path) field.ErrorList {
 	return nil
 }
 
+// validateOAuth2Scope validates the scope of a Client Credentials policy or of an OIDC policy
+// for a plain OAuth 2.0 provider. Unlike the OIDC scope, openid is not required.
+func validateOAuth2Scope(scope string, fieldPath *field.Path) field.ErrorList {
+	for _, token := range strings.Split(scope, "+") {
+		for _, v := range token {
+			if !unicode.Is(validOIDCScopeRanges, v) {
+				msg := fmt.Sprintf("not allowed character %v in scope %s", v, scope)
+				return field.ErrorList{field.Invalid(fieldPath, scope, msg)}
+			}
+		}
+	}
+	return nil
+}
+
+var validOIDCResponseModes = map[string]bool{
+	"query":     true,
+	"form_post": true,
+}
+
+func validateOIDCResponseMode(responseMode string, fieldPath *field.Path) field.ErrorList {
+	if !validOIDCResponseModes[responseMode] {
+		return field.ErrorList{field.Invalid(fieldPath, responseMode, fmt.Sprintf("Accepted values: %s",
+			mapToPrettyString(validOIDCResponseModes)))}
+	}
+	return nil
+}
+
+var validOIDCCookieSameSite = map[string]bool{
+	"Strict": true,
+	"Lax":    true,
+	"None":   true,
+}
+
+func validateOIDCCookieSameSite(sameSite string, fieldPath *field.Path) field.ErrorList {
+	if !validOIDCCookieSameSite[sameSite] {
+		return field.ErrorList{field.Invalid(fieldPath, sameSite, fmt.Sprintf("Accepted values: %s",
+			mapToPrettyString(validOIDCCookieSameSite)))}
+	}
+	return nil
+}
+
+// validateOIDCSessionLimitAction validates the action on a login of a user who has maxSessionsPerUser sessions:
+// reject the login, or evict the oldest session of the user.
+func validateOIDCSessionLimitAction(action string, fieldPath *field.Path) field.ErrorList {
+	if action != "reject" && action != "evict" {
+		return field.ErrorList{field.NotSupported(fieldPath, action, []string{"reject", "evict"})}
+	}
+	return nil
+}
+
+// validateOIDCIdPOutageBehavior validates the behavior of an OIDC policy while its IdP is down: keep serving the
+// sessions that don't need the IdP, or deny every request.
+func validateOIDCIdPOutageBehavior(behavior string, fieldPath *field.Path) field.ErrorList {
+	if behavior != "allowExistingSessions" && behavior != "denyAll" {
+		return field.ErrorList{field.NotSupported(fieldPath, behavior, []string{"allowExistingSessions", "denyAll"})}
+	}
+	return nil
+}
+
+// validateOIDCLogLevel validates the verbosity of the logs of an OIDC policy.
+func validateOIDCLogLevel(level string, fieldPath *field.Path) field.ErrorList {
+	switch level {
+	case "error", "info", "debug", "trace":
+		return nil
+	}
+	return field.ErrorList{field.NotSupported(fieldPath, level, []string{"error", "info", "debug", "trace"})}
+}
+
+// validateOIDCIdPType validates the profile of the IdP of an OIDC policy.
+func validateOIDCIdPType(idpType string, fieldPath *field.Path) field.ErrorList {
+	switch idpType {
+	case "adfs", "auth0", "azuread", "cognito", "forgerock", "keycloak", "okta", "pingfederate":
+		return nil
+	}
+	return field.ErrorList{field.NotSupported(fieldPath, idpType, []string{"adfs", "auth0", "azuread", "cognito", "forgerock", "keycloak", "okta", "pingfederate"})}
+}
+
+// validateOIDCDomain validates a domain of the users of an OIDC policy, e.g. the Google Workspace domain, the hd
+// claim of the ID tokens of its users, or the domain of their email addresses.
+func validateOIDCDomain(domain string, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for _, msg := range validation.IsDNS1123Subdomain(domain) {
+		allErrs = append(allErrs, field.Invalid(fieldPath, domain, msg))
+	}
+	if len(allErrs) == 0 && !strings.Contains(domain, ".") {
+		allErrs = append(allErrs, field.Invalid(fieldPath, domain, "must be a domain name, e.g. example.com"))
+	}
+	return allErrs
+}
+
+// validateOIDCResolver validates the DNS servers of an OIDC policy.
+func validateOIDCResolver(resolver *v1.OIDCResolver, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if len(resolver.Addresses) == 0 {
+		allErrs = append(allErrs, field.Required(fieldPath.Child("addresses"), "at least one DNS server is required"))
+	}
+	for i, address := range resolver.Addresses {
+		allErrs = append(allErrs, validateOIDCResolverAddress(address, fieldPath.Child("addresses").Index(i))...)
+	}
+	return append(allErrs, validateTime(resolver.Valid, fieldPath.Child("valid"))...)
+}
+
+// validateOIDCResolverAddress validates the address of a DNS server: an IP address or a hostname with an optional
+// port, e.g. 10.0.0.10, [fd00::10]:5353 or dns.corp.example.com.
+func validateOIDCResolverAddress(address string, fieldPath *field.Path) field.ErrorList {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+		if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+			host = host[1 : len(host)-1]
+		} else if strings.Contains(host, ":") {
+			return field.ErrorList{field.Invalid(fieldPath, address, "must be an IP address or a hostname with an optional port, an IPv6 address in brackets")}
+		}
+	} else if allErrs := validatePortNumber(port, fieldPath); len(allErrs) > 0 {
+		return allErrs
+	}
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+	if len(validation.IsDNS1123Subdomain(host)) > 0 {
+		return field.ErrorList{field.Invalid(fieldPath, address, "must be an IP address or a hostname with an optional port")}
+	}
+	return nil
+}
+
+// validateOIDCManagedKeys validates the managed keys of an OIDC policy. The keys are rotated at the rotationInterval,
+// at least an hour, or never without it.
+func validateOIDCManagedKeys(keys *v1.OIDCManagedKeys, fieldPath *field.Path) field.ErrorList {
+	if keys.RotationInterval == "" {
+		return nil
+	}
+	if !keys.Enable {
+		return field.ErrorList{field.Forbidden(fieldPath.Child("rotationInterval"), "requires enable")}
+	}
+	allErrs := validateTime(keys.RotationInterval, fieldPath.Child("rotationInterval"))
+	if len(allErrs) > 0 {
+		return allErrs
+	}
+	if seconds, _ := configs.ParseTimeSeconds(keys.RotationInterval); seconds < 3600 {
+		return field.ErrorList{field.Invalid(fieldPath.Child("rotationInterval"), keys.RotationInterval, "must be at least 1h")}
+	}
+	return nil
+}
+
+// oidcPathPrefixRegexp matches the path prefixes of the OIDC locations: one or more path segments without a trailing
+// slash, e.g. /oidc or /auth/oidc.
+var oidcPathPrefixRegexp = regexp.MustCompile(`^(/[A-Za-z0-9._~-]+)+$`)
+
+// validateOIDCPathPrefix validates the path prefix of the locations of an OIDC policy.
+func validateOIDCPathPrefix(prefix string, fieldPath *field.Path) field.ErrorList {
+	if !oidcPathPrefixRegexp.MatchString(prefix) {
+		return field.ErrorList{field.Invalid(fieldPath, prefix, "must be a path without a trailing slash, e.g. /oidc")}
+	}
+	for _, segment := range strings.Split(prefix[1:], "/") {
+		if segment == "." || segment == ".." {
+			return field.ErrorList{field.Invalid(fieldPath, prefix, "must not have . or .. segments")}
+		}
+	}
+	return nil
+}
+
+// validateOIDCRateLimit validates the rate limit of an OIDC policy. The zoneSize is optional.
+func validateOIDCRateLimit(rateLimit *v1.OIDCRateLimit, fieldPath *field.Path) field.ErrorList {
+	allErrs := validateRate(rateLimit.Rate, fieldPath.Child("rate"))
+	if rateLimit.Burst != nil {
+		allErrs = append(allErrs, validatePositiveInt(*rateLimit.Burst, fieldPath.Child("burst"))...)
+	}
+	if rateLimit.ZoneSize != "" {
+		allErrs = append(allErrs, validateRateLimitZoneSize(rateLimit.ZoneSize, fieldPath.Child("zoneSize"))...)
+	}
+	return allErrs
+}
+
+// oidcLoginLockoutMaxSeconds is the longest window and duration of a login lockout, the timeout of the
+// oidc_login_failures zone that keeps the failures.
+const oidcLoginLockoutMaxSeconds = 3600
+
+// validateOIDCLoginLockout validates the login lockout of an OIDC policy.
+func validateOIDCLoginLockout(lockout *v1.OIDCLoginLockout, fieldPath *field.Path) field.ErrorList {
+	allErrs := validatePositiveInt(lockout.MaxFailures, fieldPath.Child("maxFailures"))
+	for _, f := range []struct{ name, value string }{{"window", lockout.Window}, {"duration", lockout.Duration}} {
+		if f.value == "" {
+			continue
+		}
+		if errs := validateTime(f.value, fieldPath.Child(f.name)); len(errs) > 0 {
+			allErrs = append(allErrs, errs...)
+			continue
+		}
+		if seconds, _ := configs.ParseTimeSeconds(f.value); seconds < 1 || seconds > oidcLoginLockoutMaxSeconds {
+			allErrs = append(allErrs, field.Invalid(fieldPath.Child(f.name), f.value, "must be between 1s and 1h"))
+		}
+	}
+	switch lockout.Action {
+	case "", "block":
+		if lockout.TarpitDelay != "" {
+			allErrs = append(allErrs, field.Forbidden(fieldPath.Child("tarpitDelay"), "requires action tarpit"))
+		}
+	case "tarpit":
+		if lockout.TarpitDelay == "" {
+			break
+		}
+		if errs := validateTime(lockout.TarpitDelay, fieldPath.Child("tarpitDelay")); len(errs) > 0 {
+			allErrs = append(allErrs, errs...)
+		} else if seconds, _ := configs.ParseTimeSeconds(lockout.TarpitDelay); seconds < 1 || seconds > 60 {
+			allErrs = append(allErrs, field.Invalid(fieldPath.Child("tarpitDelay"), lockout.TarpitDelay, "must be between 1s and 60s"))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(fieldPath.Child("action"), lockout.Action, []string{"block", "tarpit"}))
+	}
+	return allErrs
+}
+
+// validateOIDCHTTPProxy validates the forward proxy of an OIDC policy: an http or https URL without a path,
+// e.g. http://proxy.corp.example.com:3128.
+func validateOIDCHTTPProxy(httpProxy string, fieldPath *field.Path) field.ErrorList {
+	u, err := url.Parse(httpProxy)
+	if err != nil || u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return field.ErrorList{field.Invalid(fieldPath, httpProxy, "must be an http or https URL")}
+	}
+	if strings.Trim(u.Path, "/") != "" || u.RawQuery != "" || u.Fragment != "" {
+		return field.ErrorList{field.Invalid(fieldPath, httpProxy, "must not have a path, a query or a fragment")}
+	}
+	if port := u.Port(); port != "" {
+		return validatePortNumber(port, fieldPath)
+	}
+	return nil
+}
+
+// validateOIDCNoProxy validates a host that is requested without the forward proxy of an OIDC policy: *, an IP
+// address, a CIDR, or a hostname or a domain with an optional leading dot or *., e.g. .corp.example.com.
+func validateOIDCNoProxy(entry string, fieldPath *field.Path) field.ErrorList {
+	if entry == "*" || net.ParseIP(entry) != nil {
+		return nil
+	}
+	if _, _, err := net.ParseCIDR(entry); err == nil {
+		return nil
+	}
+	domain := strings.TrimPrefix(strings.TrimPrefix(entry, "*"), ".")
+	if len(validation.IsDNS1123Subdomain(domain)) > 0 {
+		return field.ErrorList{field.Invalid(fieldPath, entry, "must be *, an IP address, a CIDR, or a hostname or a domain with an optional leading dot")}
+	}
+	return nil
+}
+
+// reservedOIDCRequestHeaders are the headers of the requests to the provider that NGINX or the Ingress Controller
+// set, in canonical form.
+var reservedOIDCRequestHeaders = map[string]bool{
+	"Accept":              true,
+	"Authorization":       true,
+	"Connection":          true,
+	"Content-Length":      true,
+	"Content-Type":        true,
+	"Cookie":              true,
+	"Dpop":                true,
+	"Host":                true,
+	"Proxy-Authorization": true,
+	"Te":                  true,
+	"Transfer-Encoding":   true,
+	"Upgrade":             true,
+	"User-Agent":          true,
+	"X-Request-Id":        true,
+}
+
+// validateOIDCRequestHeaders validates the headers of the requests to the provider of an OIDC policy: unique
+// names, other than the headers that NGINX or the Ingress Controller set, and values without control characters.
+func validateOIDCRequestHeaders(headers []v1.Header, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	names := make(map[string]bool)
+	for i, h := range headers {
+		idxPath := fieldPath.Index(i)
+		if h.Name == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("name"), ""))
+			continue
+		}
+		for _, msg := range validation.IsHTTPHeaderName(h.Name) {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("name"), h.Name, msg))
+		}
+		name := http.CanonicalHeaderKey(h.Name)
+		if reservedOIDCRequestHeaders[name] {
+			allErrs = append(allErrs, field.Forbidden(idxPath.Child("name"), fmt.Sprintf("%v is set by NGINX or the Ingress Controller", h.Name)))
+		}
+		if names[name] {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), h.Name))
+		}
+		names[name] = true
+		if strings.ContainsFunc(h.Value, func(r rune) bool { return unicode.IsControl(r) && r != '\t' }) {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("value"), h.Value, "must not contain control characters"))
+		}
+	}
+	return allErrs
+}
+
+// discoveredIdPType returns whether the endpoints of the providers of the profile are discovered without a
+// discoveryEndpoint.
+func discoveredIdPType(idpType string) bool {
+	switch idpType {
+	case "adfs", "auth0", "forgerock", "keycloak", "okta", "pingfederate":
+		return true
+	}
+	return false
+}
+
+// keycloakEndpointRegexp matches the endpoints of a Keycloak realm.
+var keycloakEndpointRegexp = regexp.MustCompile(`^https?://[^/?#]+(?:/[^?#]*)?/realms/[^/?#]+/protocol/openid-connect/[^?#]*$`)
+
+// oktaAuthEndpointRegexp matches the authorization endpoints of the Okta authorization servers, with the ID of a
+// custom authorization server in the first group.
+var oktaAuthEndpointRegexp = regexp.MustCompile(`^https://[^/?#]+/oauth2(?:/([^/?#]+))?/v1/authorize$`)
+
+// cognitoAuthEndpointRegexp matches the authorization endpoints of the hosted UI domains of Cognito, with the host
+// in the first group.
+var cognitoAuthEndpointRegexp = regexp.MustCompile(`^https://([^/?#]+)/oauth2/authorize$`)
+
+// cognitoPrefixDomainRegexp matches the prefix domains of the hosted UI of Cognito, e.g.
+// example.auth.us-east-1.amazoncognito.com or example.auth-fips.us-gov-west-1.amazoncognito.com.
+var cognitoPrefixDomainRegexp = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.auth(?:-fips)?\.[a-z]{2}(?:-[a-z]+)+-\d\.amazoncognito\.com$`)
+
+// validateCognitoEndpoints validates the endpoints of a Cognito policy: the authorization and token endpoints of
+// the same hosted UI domain, either a prefix domain of Cognito or a custom domain.
+func validateCognitoEndpoints(oidc *v1.OIDC, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	auth := cognitoAuthEndpointRegexp.FindStringSubmatch(oidc.AuthEndpoint)
+	if auth == nil {
+		return append(allErrs, field.Invalid(fieldPath.Child("authEndpoint"), oidc.AuthEndpoint,
+			"must be the authorization endpoint of a Cognito domain for idpType cognito, e.g. https://example.auth.us-east-1.amazoncognito.com/oauth2/authorize"))
+	}
+	if strings.HasSuffix(auth[1], ".amazoncognito.com") && !cognitoPrefixDomainRegexp.MatchString(auth[1]) {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("authEndpoint"), oidc.AuthEndpoint,
+			"must be on a Cognito domain <prefix>.auth.<region>.amazoncognito.com"))
+	}
+	if oidc.TokenEndpoint != "https://"+auth[1]+"/oauth2/token" {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("tokenEndpoint"), oidc.TokenEndpoint,
+			"must be the token endpoint of the Cognito domain of authEndpoint, https://"+auth[1]+"/oauth2/token"))
+	}
+	return allErrs
+}
+
+// auth0AuthEndpointRegexp matches the authorization endpoints of the Auth0 tenants, with the domain of the tenant in
+// the first group.
+var auth0AuthEndpointRegexp = regexp.MustCompile(`^https://([^/?#]+)/authorize$`)
+
+// validateAuth0Endpoints validates the endpoints of an Auth0 policy: the authorization and token endpoints of the
+// same domain of a tenant, either the domain of Auth0 or a custom domain.
+func validateAuth0Endpoints(oidc *v1.OIDC, fieldPath *field.Path) field.ErrorList {
+	auth := auth0AuthEndpointRegexp.FindStringSubmatch(oidc.AuthEndpoint)
+	if auth == nil {
+		return field.ErrorList{field.Invalid(fieldPath.Child("authEndpoint"), oidc.AuthEndpoint,
+			"must be the authorization endpoint of an Auth0 tenant for idpType auth0, e.g. https://example.us.auth0.com/authorize")}
+	}
+	if oidc.TokenEndpoint != "https://"+auth[1]+"/oauth/token" {
+		return field.ErrorList{field.Invalid(fieldPath.Child("tokenEndpoint"), oidc.TokenEndpoint,
+			"must be the token endpoint of the Auth0 tenant of authEndpoint, https://"+auth[1]+"/oauth/token")}
+	}
+	return nil
+}
+
+// adfsAuthEndpointRegexp matches the authorization endpoints of ADFS, with the URL of ADFS in the first group.
+var adfsAuthEndpointRegexp = regexp.MustCompile(`^(https://[^/?#]+/adfs)/oauth2/authorize/?$`)
+
+// validateADFSEndpoints validates the endpoints of an ADFS policy: the authorization and token endpoints of the
+// same ADFS.
+func validateADFSEndpoints(oidc *v1.OIDC, fieldPath *field.Path) field.ErrorList {
+	auth := adfsAuthEndpointRegexp.FindStringSubmatch(oidc.AuthEndpoint)
+	if auth == nil {
+		return field.ErrorList{field.Invalid(fieldPath.Child("authEndpoint"), oidc.AuthEndpoint,
+			"must be the authorization endpoint of ADFS for idpType adfs, e.g. https://adfs.example.com/adfs/oauth2/authorize/")}
+	}
+	if strings.TrimSuffix(oidc.TokenEndpoint, "/") != auth[1]+"/oauth2/token" {
+		return field.ErrorList{field.Invalid(fieldPath.Child("tokenEndpoint"), oidc.TokenEndpoint,
+			"must be the token endpoint of the ADFS of authEndpoint, "+auth[1]+"/oauth2/token/")}
+	}
+	return nil
+}
+
+// oidcDefaultIdPName is the name of the IdP of the endpoints of an OIDC policy with several IdPs.
+const oidcDefaultIdPName = "default"
+
+// oidcCookieNameRegexp matches the names of the cookies that NGINX can read with the $cookie_ variables.
+var oidcCookieNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// validateOIDCIdPs validates the additional IdPs of an OIDC policy and the selection of the IdP of a login. The
+// additional IdPs are generic OpenID Connect providers, so the policy can't use the options of the IdP profiles.
+func validateOIDCIdPs(oidc *v1.OIDC, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for _, option := range []struct {
+		name string
+		set  bool
+	}{
+		{name: "idpType", set: oidc.IdPType != ""},
+		{name: "issuer", set: oidc.Issuer != ""},
+		{name: "google", set: oidc.Google != nil},
+		{name: "oauth2UserEndpoint", set: oidc.OAuth2UserEndpoint != ""},
+	} {
+		if option.set {
+			allErrs = append(allErrs, field.Forbidden(fieldPath.Child(option.name), "can't be used with idps"))
+		}
+	}
+
+	selectionType := "picker"
+	if oidc.IdPSelection != nil && oidc.IdPSelection.Type != "" {
+		selectionType = oidc.IdPSelection.Type
+	}
+	names := map[string]bool{oidcDefaultIdPName: true}
+	hosts := make(map[string]bool)
+	emailDomains := make(map[string]bool)
+	for i, idp := range oidc.IdPs {
+		idpPath := fieldPath.Child("idps").Index(i)
+		allErrs = append(allErrs, validateOIDCIdP(idp, idpPath)...)
+		if names[idp.Name] {
+			allErrs = append(allErrs, field.Duplicate(idpPath.Child("name"), idp.Name))
+		}
+		names[idp.Name] = true
+		if len(idp.Hosts) > 0 && selectionType != "hostname" {
+			allErrs = append(allErrs, field.Forbidden(idpPath.Child("hosts"), "requires the hostname idpSelection"))
+		}
+		for j, host := range idp.Hosts {
+			for _, msg := range validation.IsDNS1123Subdomain(host) {
+				allErrs = append(allErrs, field.Invalid(idpPath.Child("hosts").Index(j), host, msg))
+			}
+			if hosts[host] {
+				allErrs = append(allErrs, field.Duplicate(idpPath.Child("hosts").Index(j), host))
+			}
+			hosts[host] = true
+		}
+		if len(idp.EmailDomains) > 0 && selectionType != "email" {
+			allErrs = append(allErrs, field.Forbidden(idpPath.Child("emailDomains"), "requires the email idpSelection"))
+		}
+		for j, domain := range idp.EmailDomains {
+			allErrs = append(allErrs, validateOIDCDomain(domain, idpPath.Child("emailDomains").Index(j))...)
+			if emailDomains[domain] {
+				allErrs = append(allErrs, field.Duplicate(idpPath.Child("emailDomains").Index(j), domain))
+			}
+			emailDomains[domain] = true
+		}
+	}
+
+	selectionPath := fieldPath.Child("idpSelection")
+	var header, cookie string
+	if oidc.IdPSelection != nil {
+		header, cookie = oidc.IdPSelection.Header, oidc.IdPSelection.Cookie
+	}
+	switch selectionType {
+	case "header":
+		if header == "" {
+			allErrs = append(allErrs, field.Required(selectionPath.Child("header"), "required for the header idpSelection"))
+		}
+		for _, msg := range validation.IsHTTPHeaderName(header) {
+			allErrs = append(allErrs, field.Invalid(selectionPath.Child("header"), header, msg))
+		}
+	case "cookie":
+		if !oidcCookieNameRegexp.MatchString(cookie) {
+			allErrs = append(allErrs, field.Invalid(selectionPath.Child("cookie"), cookie, "must be the name of a cookie of letters, digits and '_'"))
+		}
+	case "hostname":
+		if len(hosts) == 0 {
+			allErrs = append(allErrs, field.Required(fieldPath.Child("idps"), "the hosts of at least one IdP are required for the hostname idpSelection"))
+		}
+	case "email":
+		if len(emailDomains) == 0 {
+			allErrs = append(allErrs, field.Required(fieldPath.Child("idps"), "the email domains of at least one IdP are required for the email idpSelection"))
+		}
+	case "picker":
+	default:
+		return append(allErrs, field.NotSupported(selectionPath.Child("type"), selectionType, []string{"cookie", "email", "header", "hostname", "picker"}))
+	}
+	if header != "" && selectionType != "header" {
+		allErrs = append(allErrs, field.Forbidden(selectionPath.Child("header"), "requires the header idpSelection"))
+	}
+	if cookie != "" && selectionType != "cookie" {
+		allErrs = append(allErrs, field.Forbidden(selectionPath.Child("cookie"), "requires the cookie idpSelection"))
+	}
+	return allErrs
+}
+
+// validateOIDCIdP validates an additional IdP of an OIDC policy.
+func validateOIDCIdP(idp v1.OIDCIdP, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for _, msg := range validation.IsDNS1123Label(idp.Name) {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("name"), idp.Name, msg))
+	}
+	if idp.Name == oidcDefaultIdPName {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("name"), idp.Name, "is the name of the IdP of the policy"))
+	}
+	for _, endpoint := range []struct {
+		name  string
+		value string
+	}{
+		{name: "authEndpoint", value: idp.AuthEndpoint},
+		{name: "tokenEndpoint", value: idp.TokenEndpoint},
+		{name: "jwksURI", value: idp.JWKSURI},
+	} {
+		if endpoint.value == "" {
+			allErrs = append(allErrs, field.Required(fieldPath.Child(endpoint.name), ""))
+		} else {
+			allErrs = append(allErrs, validateURL(endpoint.value, fieldPath.Child(endpoint.name))...)
+		}
+	}
+	if idp.EndSessionEndpoint != "" {
+		allErrs = append(allErrs, validateURL(idp.EndSessionEndpoint, fieldPath.Child("endSessionEndpoint"))...)
+	}
+	if idp.ClientID == "" {
+		allErrs = append(allErrs, field.Required(fieldPath.Child("clientID"), ""))
+	} else {
+		allErrs = append(allErrs, validateClientID(idp.ClientID, fieldPath.Child("clientID"))...)
+	}
+	if idp.ClientSecret == "" {
+		allErrs = append(allErrs, field.Required(fieldPath.Child("clientSecret"), ""))
+	} else {
+		allErrs = append(allErrs, validateSecretReference(idp.ClientSecret, fieldPath.Child("clientSecret"))...)
+	}
+	if idp.Scope != "" {
+		allErrs = append(allErrs, validateOIDCScope(idp.Scope, fieldPath.Child("scope"))...)
+	}
+	return allErrs
+}
+
+// pingFederateAuthEndpointRegexp matches the authorization endpoint of PingFederate, with the URL of the server in
+// the first group.
+var pingFederateAuthEndpointRegexp = regexp.MustCompile(`^(https://[^/?#]+)/as/authorization\.oauth2$`)
+
+// validatePingFederateEndpoints validates the endpoints of a PingFederate policy: the authorization and token
+// endpoints of the same server.
+func validatePingFederateEndpoints(oidc *v1.OIDC, fieldPath *field.Path) field.ErrorList {
+	auth := pingFederateAuthEndpointRegexp.FindStringSubmatch(oidc.AuthEndpoint)
+	if auth == nil {
+		return field.ErrorList{field.Invalid(fieldPath.Child("authEndpoint"), oidc.AuthEndpoint,
+			"must be the authorization endpoint of PingFederate for idpType pingfederate, e.g. https://sso.example.com/as/authorization.oauth2")}
+	}
+	if oidc.TokenEndpoint != auth[1]+"/as/token.oauth2" {
+		return field.ErrorList{field.Invalid(fieldPath.Child("tokenEndpoint"), oidc.TokenEndpoint,
+			"must be the token endpoint of the PingFederate of authEndpoint, "+auth[1]+"/as/token.oauth2")}
+	}
+	return nil
+}
+
+// forgeRockAuthEndpointRegexp matches the authorization endpoints of the realms of ForgeRock AM, with the URL of the
+// realm in the first group.
+var forgeRockAuthEndpointRegexp = regexp.MustCompile(`^(https://[^/?#]+(?:/[^?#]*)?/oauth2(?:/realms/[^/?#]+)*)/authorize$`)
+
+// validateForgeRockEndpoints validates the endpoints of a ForgeRock policy: the authorization and token endpoints
+// of the same realm.
+func validateForgeRockEndpoints(oidc *v1.OIDC, fieldPath *field.Path) field.ErrorList {
+	auth := forgeRockAuthEndpointRegexp.FindStringSubmatch(oidc.AuthEndpoint)
+	if auth == nil {
+		return field.ErrorList{field.Invalid(fieldPath.Child("authEndpoint"), oidc.AuthEndpoint,
+			"must be the authorization endpoint of a ForgeRock AM realm for idpType forgerock, e.g. https://am.example.com/am/oauth2/realms/root/realms/alpha/authorize")}
+	}
+	if oidc.TokenEndpoint != auth[1]+"/access_token" {
+		return field.ErrorList{field.Invalid(fieldPath.Child("tokenEndpoint"), oidc.TokenEndpoint,
+			"must be the token endpoint of the ForgeRock AM realm of authEndpoint, "+auth[1]+"/access_token")}
+	}
+	return nil
+}
+
+// oidcAudienceRegexp matches the audiences without the characters that NGINX expands or splits in the set directive.
+var oidcAudienceRegexp = regexp.MustCompile(`^[A-Za-z0-9\-._~:/?#\[\]@!&()*+,;=%]+$`)
+
+func validateOIDCAudience(audience string, fieldPath *field.Path) field.ErrorList {
+	if !oidcAudienceRegexp.MatchString(audience) {
+		return field.ErrorList{field.Invalid(fieldPath, audience, "must be an audience of the access tokens, e.g. api://default")}
+	}
+	return nil
+}
+
+// oidcTenantIDRegexp matches the IDs of the Azure AD tenants, which are GUIDs.
+var oidcTenantIDRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// oidcTenantIDPlaceholder is replaced with the tid claim of the ID tokens in the issuer of a multi-tenant policy.
+const oidcTenantIDPlaceholder = "{tenantid}"
+
+// validateOIDCIssuer validates the issuer of the ID tokens of an OIDC policy, a URL with at most one {tenantid}.
+func validateOIDCIssuer(issuer string, fieldPath *field.Path) field.ErrorList {
+	if strings.Count(issuer, oidcTenantIDPlaceholder) > 1 {
+		return field.ErrorList{field.Invalid(fieldPath, issuer, "must contain "+oidcTenantIDPlaceholder+" at most once")}
+	}
+	u := strings.Replace(issuer, oidcTenantIDPlaceholder, "9188040d-6c67-4c5b-b112-36a304b66dad", 1)
+	if !oidcAudienceRegexp.MatchString(u) {
+		return field.ErrorList{field.Invalid(fieldPath, issuer, "must be a URL, optionally with "+oidcTenantIDPlaceholder+", e.g. https://login.microsoftonline.com/{tenantid}/v2.0")}
+	}
+	return validateURL(u, fieldPath)
+}
+
+func validateOIDCTenantID(tenant string, fieldPath *field.Path) field.ErrorList {
+	if !oidcTenantIDRegexp.MatchString(tenant) {
+		return field.ErrorList{field.Invalid(fieldPath, tenant, "must be the ID of an Azure AD tenant, e.g. 9188040d-6c67-4c5b-b112-36a304b66dad")}
+	}
+	return nil
+}
+
+// oidcPathRegexp matches the absolute paths without the characters that end the value of the auth_redir cookie or
+// that NGINX expands in the set directive.
+var oidcPathRegexp = regexp.MustCompile(`^/[A-Za-z0-9\-._~!&'()*+=:@%/]*$`)
+
+// validateOIDCPath validates a path prefix of an OIDC policy, e.g. a path allowed in the rd parameter of the /login
+// location. Only the paths of the host are allowed, so that /login can't redirect the user to another site.
+func validateOIDCPath(path string, fieldPath *field.Path) field.ErrorList {
+	if !oidcPathRegexp.MatchString(path) || strings.HasPrefix(path, "//") {
+		return field.ErrorList{field.Invalid(fieldPath, path, "must be an absolute path, e.g. /app/")}
+	}
+	return nil
+}
+
+// oidcRedirectURLRegexp matches the URLs of the redirectAllowlist of an OIDC policy: a scheme, a host without user
+// info and an optional port, and an optional path with the characters of oidcPathRegexp.
+var oidcRedirectURLRegexp = regexp.MustCompile(`^https?://[A-Za-z0-9.-]+(:[0-9]{1,5})?(/[A-Za-z0-9\-._~!&'()*+=:@%/]*)?$`)
+
+// validateOIDCRedirectAllowlistEntry validates an entry of the redirectAllowlist of an OIDC policy: a path of the
+// host, like the loginRedirectPaths, or the URL of another site, whose path is a prefix of the allowed paths.
+func validateOIDCRedirectAllowlistEntry(entry string, fieldPath *field.Path) field.ErrorList {
+	if strings.HasPrefix(entry, "/") {
+		return validateOIDCPath(entry, fieldPath)
+	}
+	if !oidcRedirectURLRegexp.MatchString(entry) || strings.Contains(strings.SplitN(entry, "://", 2)[1], "//") {
+		return field.ErrorList{field.Invalid(fieldPath, entry, "must be an absolute path or an http or https URL, e.g. /app/ or https://app.example.com/")}
+	}
+	return nil
+}
+
+// oidcCSPDirectiveRegexp matches a directive of a Content-Security-Policy, e.g. default-src 'self' https://cdn.example.com,
+// without the characters that NGINX expands or ends the value of the set directive with.
+var oidcCSPDirectiveRegexp = regexp.MustCompile(`^[a-z][a-z0-9-]*( +[^"\\$;,\x00-\x1f\x7f]+)*$`)
+
+// oidcReferrerPolicies are the values of the Referrer-Policy header.
+var oidcReferrerPolicies = []string{
+	"no-referrer", "no-referrer-when-downgrade", "origin", "origin-when-cross-origin", "same-origin",
+	"strict-origin", "strict-origin-when-cross-origin", "unsafe-url",
+}
+
+// validateOIDCSecurityHeaders validates the security headers of an OIDC policy.
+func validateOIDCSecurityHeaders(headers *v1.OIDCSecurityHeaders, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if headers.ContentSecurityPolicy != "" {
+		for _, directive := range strings.Split(headers.ContentSecurityPolicy, ";") {
+			directive = strings.TrimSpace(directive)
+			if directive != "" && !oidcCSPDirectiveRegexp.MatchString(directive) {
+				allErrs = append(allErrs, field.Invalid(fieldPath.Child("contentSecurityPolicy"), headers.ContentSecurityPolicy,
+					"must be directives separated by semicolons, e.g. default-src 'none'; frame-ancestors 'none'"))
+				break
+			}
+		}
+	}
+	switch headers.FrameOptions {
+	case "", "DENY", "SAMEORIGIN":
+	default:
+		allErrs = append(allErrs, field.NotSupported(fieldPath.Child("frameOptions"), headers.FrameOptions, []string{"DENY", "SAMEORIGIN"}))
+	}
+	if headers.ReferrerPolicy != "" && !slices.Contains(oidcReferrerPolicies, headers.ReferrerPolicy) {
+		allErrs = append(allErrs, field.NotSupported(fieldPath.Child("referrerPolicy"), headers.ReferrerPolicy, oidcReferrerPolicies))
+	}
+	return allErrs
+}
+
+// oidcClaimNameRegexp matches the names of claims, including the URLs of namespaced claims, without the characters
+// that NGINX expands or splits in the set directive.
+var oidcClaimNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_.:/-]+$`)
+
+func validateOIDCClaimName(claim string, fieldPath *field.Path) field.ErrorList {
+	if !oidcClaimNameRegexp.MatchString(claim) {
+		return field.ErrorList{field.Invalid(fieldPath, claim, "must be a claim name, e.g. email")}
+	}
+	return nil
+}
+
+func validateClaimRule(rule v1.ClaimRule, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if rule.Claim == "" {
+		allErrs = append(allErrs, field.Required(fieldPath.Child("claim"), ""))
+	}
+	if len(rule.Values) == 0 {
+		allErrs = append(allErrs, field.Required(fieldPath.Child("values"), "at least one value is required"))
+	}
+	return allErrs
+}
+
+// validateOIDCSessionStore validates the session store of an OIDC policy. The sessions are stored in the keyval
+// zones of NGINX by default, a Redis store must be configured when the type is redis. The cookies of a cookie store
+// are encrypted with the key of the keySecret, or with the managed keys of the policy.
+func validateOIDCSessionStore(store *v1.OIDCSessionStore, managedKeys bool, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if store.Redis != nil && store.Type != "redis" {
+		allErrs = append(allErrs, field.Forbidden(fieldPath.Child("redis"), "requires type to be redis"))
+	}
+	if store.Cookie != nil && store.Type != "cookie" {
+		allErrs = append(allErrs, field.Forbidden(fieldPath.Child("cookie"), "requires type to be cookie"))
+	}
+
+	switch store.Type {
+	case "", "keyval":
+		return allErrs
+	case "redis":
+		if store.Redis == nil {
+			return append(allErrs, field.Required(fieldPath.Child("redis"), "required when type is redis"))
+		}
+		return append(allErrs, validateOIDCRedisSessionStore(store.Redis, fieldPath.Child("redis"))...)
+	case "cookie":
+		if store.Cookie != nil && store.Cookie.PreviousKeyLifetime != "" {
+			allErrs = append(allErrs, validateTime(store.Cookie.PreviousKeyLifetime, fieldPath.Child("cookie", "previousKeyLifetime"))...)
+		}
+		if store.Cookie == nil || store.Cookie.KeySecret == "" {
+			if managedKeys {
+				return allErrs
+			}
+			return append(allErrs, field.Required(fieldPath.Child("cookie", "keySecret"), "required when type is cookie, unless managedKeys is enabled"))
+		}
+		return append(allErrs, validateSecretName(store.Cookie.KeySecret, fieldPath.Child("cookie", "keySecret"))...)
+	}
+	return append(allErrs, field.NotSupported(fieldPath.Child("type"), store.Type, []string{"keyval", "redis", "cookie"}))
+}
+
+func validateOIDCRedisSessionStore(redis *v1.OIDCRedisSessionStore, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if redis.Address == "" {
+		allErrs = append(allErrs, field.Required(fieldPath.Child("address"), ""))
+	} else if host, port, err := net.SplitHostPort(redis.Address); err != nil || host == "" {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("address"), redis.Address, "must be a host and a port"))
+	} else {
+		allErrs = append(allErrs, validatePortNumber(port, fieldPath.Child("address"))...)
+	}
+	if redis.Database < 0 {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("database"), redis.Database, "must be positive or zero"))
+	}
+	if redis.AuthSecret != "" {
+		allErrs = append(allErrs, validateSecretName(redis.AuthSecret, fieldPath.Child("authSecret"))...)
+	}
+	if redis.TLS != nil {
+		if !redis.TLS.Enable && (redis.TLS.CASecret != "" || redis.TLS.ServerName != "") {
+			allErrs = append(allErrs, field.Forbidden(fieldPath.Child("tls"), "requires enable to be true"))
+		}
+		if redis.TLS.CASecret != "" {
+			allErrs = append(allErrs, validateSecretName(redis.TLS.CASecret, fieldPath.Child("tls", "caSecret"))...)
+		}
+		if redis.TLS.ServerName != "" {
+			allErrs = append(allErrs, validateSSLName(redis.TLS.ServerName, fieldPath.Child("tls", "serverName"))...)
+		}
+	}
+	return allErrs
+}
+
+// oidcIntrospectionMaxCacheEntries is the largest number of introspection results that an OIDC policy can cache.
+const oidcIntrospectionMaxCacheEntries = 1000000
+
+// validateOIDCIntrospection validates the token introspection of an OIDC policy.
+func validateOIDCIntrospection(introspection *v1.OIDCIntrospection, fieldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if introspection.Endpoint != "" {
+		allErrs = append(allErrs, validateURL(introspection.Endpoint, fieldPath.Child("endpoint"))...)
+	}
+	if introspection.CacheTTL != "" {
+		if errs := validateTime(introspection.CacheTTL, fieldPath.Child("cacheTTL")); len(errs) > 0 {
+			allErrs = append(allErrs, errs...)
+		} else if seconds, _ := configs.ParseTimeSeconds(introspection.CacheTTL); seconds < 1 || seconds > 3600 {
+			allErrs = append(allErrs, field.Invalid(fieldPath.Child("cacheTTL"), introspection.CacheTTL, "must be between 1s and 1h"))
+		}
+	}
+	if n := introspection.CacheMaxEntries; n != nil {
+		if *n > oidcIntrospectionMaxCacheEntries {
+			allErrs = append(allErrs, field.Invalid(fieldPath.Child("cacheMaxEntries"), *n, fmt.Sprintf("must be at most %d", oidcIntrospectionMaxCacheEntries)))
+		} else {
+			allErrs = append(allErrs, validatePositiveInt(*n, fieldPath.Child("cacheMaxEntries"))...)
+		}
+	}
+	return allErrs
+}
+
 func validateURL(name string, fieldPath *field.Path) field.ErrorList {
 	u, err := url.Parse(name)
 	if err != nil {
@@ -552,10 +1649,13 @@ var rateLimitKeySpecialVariables = []string{"arg_", "http_", "cookie_"}
 
 // rateLimitKeyVariables includes NGINX variables allowed to be used in a rateLimit policy key.
 var rateLimitKeyVariables = map[string]bool{
-	"binary_remote_addr": true,
-	"request_uri":        true,
-	"uri":                true,
-	"args":               true,
+	"binary_remote_addr":   true,
+	"request_uri":          true,
+	"uri":                  true,
+	"args":                 true,
+	"identity_auth_method": true,
+	"identity_subject":     true,
+	"identity_issuer":      true,
 }
 
 func validateRateLimitKey(key string, fieldPath *field.Path, isPlus bool) field.ErrorList {
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
@@ -303,6 +304,21 @@ func TestValidatePolicy_PassesOnValidInput(t *testing.T) {
 			enableOIDC: true,
 			msg:        "use OIDC (plus only)",
 		},
+		{
+			policy: &v1.Policy{
+				Spec: v1.PolicySpec{
+					ClientCredentials: &v1.ClientCredentials{
+						TokenEndpoint: "https://foo.bar/token",
+						ClientID:      "random-string",
+						ClientSecret:  "random-secret",
+						Scope:         "orders.read",
+					},
+				},
+			},
+			isPlus:     true,
+			enableOIDC: true,
+			msg:        "use Client Credentials (plus only)",
+		},
 		{
 			policy: &v1.Policy{
 				Spec: v1.PolicySpec{
@@ -424,6 +440,34 @@ func TestValidatePolicy_FailsOnInvalidInput(t *testing.T) {
 			enableOIDC: true,
 			msg:        "OIDC policy in OSS",
 		},
+		{
+			policy: &v1.Policy{
+				Spec: v1.PolicySpec{
+					ClientCredentials: &v1.ClientCredentials{
+						TokenEndpoint: "https://foo.bar/token",
+						ClientID:      "random-string",
+						ClientSecret:  "random-secret",
+					},
+				},
+			},
+			isPlus:     false,
+			enableOIDC: true,
+			msg:        "Client Credentials policy in OSS",
+		},
+		{
+			policy: &v1.Policy{
+				Spec: v1.PolicySpec{
+					ClientCredentials: &v1.ClientCredentials{
+						TokenEndpoint: "https://foo.bar/token",
+						ClientID:      "random-string",
+						ClientSecret:  "random-secret",
+					},
+				},
+			},
+			isPlus:     true,
+			enableOIDC: false,
+			msg:        "Client Credentials policy with enable OIDC flag disabled",
+		},
 		{
 			policy: &v1.Policy{
 				Spec: v1.PolicySpec{
@@ -581,6 +625,14 @@ func TestValidateRateLimit_PassesOnValidInput(t *testing.T) {
 			},
 			msg: "ratelimit all fields set",
 		},
+		{
+			rateLimit: &v1.RateLimit{
+				Rate:     "10r/s",
+				ZoneSize: "10M",
+				Key:      "${identity_issuer}${identity_subject}",
+			},
+			msg: "ratelimit key with identity variables",
+		},
 	}
 
 	isPlus := false
@@ -621,6 +673,12 @@ func TestValidateRateLimit_FailsOnInvalidInput(t *testing.T) {
 			}),
 			msg: "invalid rateLimit key variable use",
 		},
+		{
+			rateLimit: createInvalidRateLimit(func(r *v1.RateLimit) {
+				r.Key = "${identity_claims}"
+			}),
+			msg: "rateLimit key with the claims of the identity",
+		},
 		{
 			rateLimit: createInvalidRateLimit(func(r *v1.RateLimit) {
 				r.Delay = createPointerFromInt(0)
@@ -693,6 +751,17 @@ func TestValidateJWT_PassesOnValidInput(t *testing.T) {
 			},
 			msg: "jwt with jwksURI",
 		},
+		{
+			jwt: &v1.JWTAuth{
+				Realm:  "My Product API",
+				Secret: "my-jwk",
+				ClaimRules: []v1.ClaimRule{
+					{Claim: "groups", Values: []string{"admins", "ops team"}},
+					{Claim: "tenant_id", Values: []string{"2f9a3b1c-5d4e"}},
+				},
+			},
+			msg: "jwt with claim rules",
+		},
 	}
 	for _, test := range tests {
 		allErrs := validateJWT(test.jwt, field.NewPath("jwt"))
@@ -714,6 +783,30 @@ func TestValidateJWT_FailsOnInvalidInput(t *testing.T) {
 			},
 			msg: "missing secret and jwksURI",
 		},
+		{
+			jwt: &v1.JWTAuth{
+				Realm:      "My Product API",
+				Secret:     "my-jwk",
+				ClaimRules: []v1.ClaimRule{{Claim: "realm_access.roles", Values: []string{"admin"}}},
+			},
+			msg: "claim rule of a nested claim",
+		},
+		{
+			jwt: &v1.JWTAuth{
+				Realm:      "My Product API",
+				Secret:     "my-jwk",
+				ClaimRules: []v1.ClaimRule{{Claim: "groups", Values: []string{"admins,ops"}}},
+			},
+			msg: "claim rule with a comma in a value",
+		},
+		{
+			jwt: &v1.JWTAuth{
+				Realm:      "My Product API",
+				Secret:     "my-jwk",
+				ClaimRules: []v1.ClaimRule{{Claim: "groups"}},
+			},
+			msg: "claim rule without values",
+		},
 		{
 			jwt: &v1.JWTAuth{
 				Realm:   "My Product API",
@@ -1283,220 +1376,2352 @@ func TestValidateOIDC_PassesOnValidOIDC(t *testing.T) {
 		},
 		{
 			oidc: &v1.OIDC{
-				AuthEndpoint:      "http://127.0.0.1:8080/auth/realms/master/protocol/openid-connect/auth",
-				TokenEndpoint:     "http://127.0.0.1:8080/auth/realms/master/protocol/openid-connect/token",
-				JWKSURI:           "http://127.0.0.1:8080/auth/realms/master/protocol/openid-connect/certs",
+				AuthEndpoint:  "https://login.microsoftonline.com/dd-fff-eee-1234-9be/oauth2/v2.0/authorize",
+				TokenEndpoint: "https://login.microsoftonline.com/dd-fff-eee-1234-9be/oauth2/v2.0/token",
+				JWKSURI:       "https://login.microsoftonline.com/dd-fff-eee-1234-9be/discovery/v2.0/keys",
+				ClientID:      "ff",
+				ClientSecret:  "ff",
+				ResponseMode:  "form_post",
+			},
+			msg: "form_post response mode",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				JAREnable:     true,
+				JARKeySecret:  "jar-key",
+				JARMEnable:    true,
+			},
+			msg: "jar and jarm",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:       "https://idp.example.com/auth",
+				TokenEndpoint:      "https://idp.example.com/token",
+				JWKSURI:            "https://idp.example.com/certs",
+				ClientID:           "client",
+				ClientSecret:       "secret",
+				DeviceAuthEndpoint: "https://idp.example.com/device/code",
+			},
+			msg: "device authorization endpoint",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:      "https://idp.example.com/auth",
+				TokenEndpoint:     "https://idp.example.com/token",
+				JWKSURI:           "https://idp.example.com/certs",
 				ClientID:          "client",
 				ClientSecret:      "secret",
-				Scope:             "openid+offline_access",
 				AccessTokenEnable: true,
+				DPoPEnable:        true,
 			},
-			msg: "offline access scope",
+			msg: "dpop",
 		},
-	}
-
-	for _, test := range tests {
-		allErrs := validateOIDC(test.oidc, field.NewPath("oidc"))
-		if len(allErrs) != 0 {
-			t.Errorf("validateOIDC() returned errors %v for valid input for the case of %v", allErrs, test.msg)
-		}
-	}
-}
-
-func TestValidateAPIKeyPolicy_PassOnValidInput(t *testing.T) {
-	t.Parallel()
-	tests := []struct {
-		apiKey *v1.APIKey
-		msg    string
-	}{
 		{
-			apiKey: &v1.APIKey{
-				SuppliedIn: &v1.SuppliedIn{
-					Header: []string{
-						"X-API-Key",
-					},
-				},
-				ClientSecret: "secret",
+			oidc: &v1.OIDC{
+				AuthEndpoint:       "https://github.com/login/oauth/authorize",
+				TokenEndpoint:      "https://github.com/login/oauth/access_token",
+				ClientID:           "client",
+				ClientSecret:       "secret",
+				Scope:              "read:user+user:email",
+				OAuth2UserEndpoint: "https://api.github.com/user",
 			},
+			msg: "oauth2 adapter",
 		},
-	}
-
-	for _, test := range tests {
-		allErrs := validateAPIKey(test.apiKey, field.NewPath("apiKey"))
-		if len(allErrs) != 0 {
-			t.Errorf("validateAPIKey() returned errors %v for valid input for the case of %v", allErrs, test.msg)
-		}
-	}
-}
-
-func TestValidateAPIKeyPolicy_FailsOnInvalidInput(t *testing.T) {
-	t.Parallel()
-	tests := []struct {
-		apiKey *v1.APIKey
-		msg    string
-	}{
 		{
-			apiKey: &v1.APIKey{
-				SuppliedIn: &v1.SuppliedIn{
-					Query: []string{
-						"api_key",
-					},
-				},
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				JWEKeySecret:  "jwe-key-secret",
 			},
-			msg: "missing secret",
+			msg: "jwe key secret",
 		},
 		{
-			apiKey: &v1.APIKey{
-				SuppliedIn:   &v1.SuppliedIn{},
-				ClientSecret: "secret",
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				ErrorPages:    "oidc-error-pages",
 			},
-			msg: "both  header and query are missing",
+			msg: "error pages",
 		},
 		{
-			apiKey: &v1.APIKey{
-				SuppliedIn: &v1.SuppliedIn{
-					Header: []string{
-						`api.key"`,
-					},
-				},
-				ClientSecret: "secret",
+			oidc: &v1.OIDC{
+				AuthEndpoint:      "https://idp.example.com/auth",
+				TokenEndpoint:     "https://idp.example.com/token",
+				DiscoveryEndpoint: "https://idp.example.com/.well-known/openid-configuration",
+				ClientID:          "client",
+				ClientSecret:      "secret",
 			},
-			msg: "invalid header",
+			msg: "discovery endpoint without jwks uri",
 		},
 		{
-			apiKey: &v1.APIKey{
-				SuppliedIn: &v1.SuppliedIn{
-					Query: []string{
-						`api_key\`,
-					},
+			oidc: &v1.OIDC{
+				AuthEndpoint:   "https://idp.example.com/auth",
+				TokenEndpoint:  "https://idp.example.com/token",
+				JWKSURI:        "https://idp.example.com/certs",
+				ClientID:       "client",
+				ClientSecret:   "secret",
+				CookieSameSite: "Strict",
+				CookieDomain:   "example.com",
+				ClaimRules: []v1.ClaimRule{
+					{Claim: "groups", Values: []string{"admins", "developers"}},
+					{Claim: "email_verified", Values: []string{"true"}},
 				},
-				ClientSecret: "secret",
 			},
-			msg: "invalid query",
+			msg: "cookie and claim rules",
 		},
 		{
-			apiKey: &v1.APIKey{
-				SuppliedIn: &v1.SuppliedIn{
-					Query: []string{
-						`api_key`,
-					},
-				},
-				ClientSecret: "secret_1",
+			oidc: &v1.OIDC{
+				AuthEndpoint:       "https://idp.example.com/auth",
+				TokenEndpoint:      "https://idp.example.com/token",
+				JWKSURI:            "https://idp.example.com/certs",
+				ClientID:           "client",
+				ClientSecret:       "secret",
+				MaxSessionsPerUser: 3,
+				SessionLimitAction: "reject",
 			},
-			msg: "invalid secret name",
+			msg: "session limit",
 		},
-	}
-
-	for _, test := range tests {
-		allErrs := validateAPIKey(test.apiKey, field.NewPath("apiKey"))
-		if len(allErrs) == 0 {
-			t.Errorf("validateAPIKey() returned no errors for invalid input for the case of %v", test.msg)
-		}
-	}
-}
-
-func TestValidateOIDCScope_ErrorsOnInvalidInput(t *testing.T) {
-	t.Parallel()
-
-	invalidInput := []string{
-		"",
-		" ",
-		"openid+scope\x5c",
-		"mycustom\x7fscope",
-		"openid+myscope\x20",
-		"openid+cus\x19tom",
-	}
-
-	for _, v := range invalidInput {
-		allErrs := validateOIDCScope(v, field.NewPath("scope"))
-		if len(allErrs) == 0 {
-			t.Error("want err on invalid scope, got no error")
-		}
-	}
-}
-
-func TestValidateOIDCScope_PassesOnValidInput(t *testing.T) {
-	t.Parallel()
-
-	validInput := []string{
-		"openid",
-		"validScope+openid",
-		"SecondScope+openid+CustomScope",
-		"validScope\x26+openid",
-		"openid+my\x33scope",
-	}
-	for _, v := range validInput {
-		allErrs := validateOIDCScope(v, field.NewPath("scope"))
-		if len(allErrs) != 0 {
-			t.Errorf("want no err, got %v", allErrs)
-		}
-	}
-}
-
-func TestValidateOIDC_FailsOnInvalidOIDC(t *testing.T) {
-	t.Parallel()
-	tests := []struct {
-		oidc *v1.OIDC
-		msg  string
-	}{
 		{
 			oidc: &v1.OIDC{
-				RedirectURI: "/foo",
+				AuthEndpoint:        "https://idp.example.com/auth",
+				TokenEndpoint:       "https://idp.example.com/token",
+				JWKSURI:             "https://idp.example.com/certs",
+				ClientID:            "client",
+				ClientSecret:        "secret",
+				RefreshAheadSeconds: 60,
+				RevocationEndpoint:  "https://idp.example.com/revoke",
 			},
-			msg: "missing required field auth",
+			msg: "refresh ahead and revocation endpoint",
 		},
 		{
 			oidc: &v1.OIDC{
-				AuthEndpoint:      "http://127.0.0.1:8080/auth/realms/master/protocol/openid-connect/auth",
-				TokenEndpoint:     "http://127.0.0.1:8080/auth/realms/master/protocol/openid-connect/token",
-				JWKSURI:           "http://127.0.0.1:8080/auth/realms/master/protocol/openid-connect/certs",
-				ClientID:          "client",
-				ClientSecret:      "secret",
-				Scope:             "bogus",
-				AccessTokenEnable: true,
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				RememberMe:    &v1.OIDCRememberMe{Enable: true, Duration: "14d"},
+				StepUpMaxAge:  createPointerFromInt(0),
 			},
-			msg: "missing openid in scope",
+			msg: "remember me and step-up max age",
 		},
 		{
 			oidc: &v1.OIDC{
-				AuthEndpoint:      "http://127.0.0.1:8080/auth/realms/master/protocol/openid-connect/auth",
-				TokenEndpoint:     "http://127.0.0.1:8080/auth/realms/master/protocol/openid-connect/token",
-				JWKSURI:           "http://127.0.0.1:8080/auth/realms/master/protocol/openid-connect/certs",
+				AuthEndpoint:      "https://idp.example.com/auth",
+				TokenEndpoint:     "https://idp.example.com/token",
+				JWKSURI:           "https://idp.example.com/certs",
 				ClientID:          "client",
 				ClientSecret:      "secret",
-				Scope:             "openid+bogus\x7f",
-				AccessTokenEnable: true,
+				IdPOutageBehavior: "allowExistingSessions",
 			},
-			msg: "invalid unicode in scope",
+			msg: "idp outage behavior",
 		},
 		{
 			oidc: &v1.OIDC{
-				AuthEndpoint:      "https://login.microsoftonline.com/dd-fff-eee-1234-9be/oauth2/v2.0/authorize",
-				JWKSURI:           "https://login.microsoftonline.com/dd-fff-eee-1234-9be/discovery/v2.0/keys",
-				ClientID:          "ff",
-				ClientSecret:      "ff",
-				Scope:             "openid+profile",
-				AccessTokenEnable: true,
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				LogLevel:      "debug",
 			},
-			msg: "missing required field token",
+			msg: "log level",
 		},
 		{
 			oidc: &v1.OIDC{
-				AuthEndpoint:      "https://login.microsoftonline.com/dd-fff-eee-1234-9be/oauth2/v2.0/authorize",
-				TokenEndpoint:     "https://login.microsoftonline.com/dd-fff-eee-1234-9be/oauth2/v2.0/token",
-				ClientID:          "ff",
-				ClientSecret:      "ff",
-				Scope:             "openid+profile",
-				AccessTokenEnable: true,
+				AuthEndpoint:   "https://login.microsoftonline.com/organizations/oauth2/v2.0/authorize",
+				TokenEndpoint:  "https://login.microsoftonline.com/organizations/oauth2/v2.0/token",
+				JWKSURI:        "https://login.microsoftonline.com/organizations/discovery/v2.0/keys",
+				ClientID:       "client",
+				ClientSecret:   "secret",
+				IdPType:        "azuread",
+				AllowedTenants: []string{"9188040d-6c67-4c5b-b112-36a304b66dad"},
 			},
-			msg: "missing required field jwk",
+			msg: "azure ad",
 		},
 		{
 			oidc: &v1.OIDC{
-				AuthEndpoint:      "https://login.microsoftonline.com/dd-fff-eee-1234-9be/oauth2/v2.0/authorize",
-				TokenEndpoint:     "https://login.microsoftonline.com/dd-fff-eee-1234-9be/oauth2/v2.0/token",
-				JWKSURI:           "https://login.microsoftonline.com/dd-fff-eee-1234-9be/discovery/v2.0/keys",
-				ClientSecret:      "ff",
+				AuthEndpoint:   "https://login.microsoftonline.com/organizations/oauth2/v2.0/authorize",
+				TokenEndpoint:  "https://login.microsoftonline.com/organizations/oauth2/v2.0/token",
+				JWKSURI:        "https://login.microsoftonline.com/organizations/discovery/v2.0/keys",
+				ClientID:       "client",
+				ClientSecret:   "secret",
+				Issuer:         "https://login.microsoftonline.com/{tenantid}/v2.0",
+				AllowedTenants: []string{"9188040d-6c67-4c5b-b112-36a304b66dad"},
+			},
+			msg: "issuer template with allowed tenants",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPs: []v1.OIDCIdP{
+					{
+						Name:          "partners",
+						AuthEndpoint:  "https://partners.example.com/auth",
+						TokenEndpoint: "https://partners.example.com/token",
+						JWKSURI:       "https://partners.example.com/keys",
+						ClientID:      "partners-client",
+						ClientSecret:  "identity/partners-secret",
+					},
+				},
+			},
+			msg: "idps with the idp picker",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPs: []v1.OIDCIdP{
+					{
+						Name:          "partners",
+						AuthEndpoint:  "https://partners.example.com/auth",
+						TokenEndpoint: "https://partners.example.com/token",
+						JWKSURI:       "https://partners.example.com/keys",
+						ClientID:      "partners-client",
+						ClientSecret:  "identity/partners-secret",
+						Hosts:         []string{"partners.example.com"},
+					},
+				},
+				IdPSelection: &v1.OIDCIdPSelection{Type: "hostname"},
+			},
+			msg: "idps selected by hostname",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPs: []v1.OIDCIdP{
+					{
+						Name:          "partners",
+						AuthEndpoint:  "https://partners.example.com/auth",
+						TokenEndpoint: "https://partners.example.com/token",
+						JWKSURI:       "https://partners.example.com/keys",
+						ClientID:      "partners-client",
+						ClientSecret:  "identity/partners-secret",
+					},
+				},
+				IdPSelection: &v1.OIDCIdPSelection{Type: "header", Header: "X-Login-IdP"},
+			},
+			msg: "idps selected by header",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPs: []v1.OIDCIdP{
+					{
+						Name:          "partners",
+						AuthEndpoint:  "https://partners.example.com/auth",
+						TokenEndpoint: "https://partners.example.com/token",
+						JWKSURI:       "https://partners.example.com/keys",
+						ClientID:      "partners-client",
+						ClientSecret:  "identity/partners-secret",
+						EmailDomains:  []string{"partner.com", "partner.example.org"},
+					},
+				},
+				IdPSelection: &v1.OIDCIdPSelection{Type: "email"},
+			},
+			msg: "idps selected by email domain",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				Issuer:        "https://idp.example.com/",
+			},
+			msg: "issuer",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://keycloak.example.com/realms/apps/protocol/openid-connect/auth",
+				TokenEndpoint: "https://keycloak.example.com/realms/apps/protocol/openid-connect/token",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPType:       "keycloak",
+			},
+			msg: "keycloak without jwks uri",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://example.okta.com/oauth2/v1/authorize",
+				TokenEndpoint: "https://example.okta.com/oauth2/v1/token",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPType:       "okta",
+			},
+			msg: "okta org authorization server",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:        "https://example.okta.com/oauth2/default/v1/authorize",
+				TokenEndpoint:       "https://example.okta.com/oauth2/default/v1/token",
+				ClientID:            "client",
+				ClientSecret:        "secret",
+				IdPType:             "okta",
+				AccessTokenAudience: "api://default",
+			},
+			msg: "okta custom authorization server with access token audience",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:      "https://example.auth.us-east-1.amazoncognito.com/oauth2/authorize",
+				TokenEndpoint:     "https://example.auth.us-east-1.amazoncognito.com/oauth2/token",
+				DiscoveryEndpoint: "https://cognito-idp.us-east-1.amazonaws.com/us-east-1_Example/.well-known/openid-configuration",
+				ClientID:          "client",
+				ClientSecret:      "secret",
+				IdPType:           "cognito",
+			},
+			msg: "cognito prefix domain",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://auth.example.com/oauth2/authorize",
+				TokenEndpoint: "https://auth.example.com/oauth2/token",
+				JWKSURI:       "https://cognito-idp.us-east-1.amazonaws.com/us-east-1_Example/.well-known/jwks.json",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPType:       "cognito",
+			},
+			msg: "cognito custom domain",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://example.us.auth0.com/authorize",
+				TokenEndpoint: "https://example.us.auth0.com/oauth/token",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPType:       "auth0",
+			},
+			msg: "auth0 without jwks uri",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://adfs.example.com/adfs/oauth2/authorize/",
+				TokenEndpoint: "https://adfs.example.com/adfs/oauth2/token/",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPType:       "adfs",
+				Resources:     []string{"urn:microsoft:userinfo"},
+			},
+			msg: "adfs with resource",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://sso.example.com/as/authorization.oauth2",
+				TokenEndpoint: "https://sso.example.com/as/token.oauth2",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPType:       "pingfederate",
+			},
+			msg: "pingfederate without jwks uri",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:         "https://idp.example.com/auth",
+				TokenEndpoint:        "https://idp.example.com/token",
+				JWKSURI:              "https://idp.example.com/certs",
+				ClientID:             "client",
+				ClientSecret:         "secret",
+				Issuer:               "https://idp.example.com/realms/apps",
+				StrictSpecCompliance: true,
+			},
+			msg: "strict spec compliance with issuer",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:         "https://example.okta.com/oauth2/default/v1/authorize",
+				TokenEndpoint:        "https://example.okta.com/oauth2/default/v1/token",
+				ClientID:             "client",
+				ClientSecret:         "secret",
+				IdPType:              "okta",
+				StrictSpecCompliance: true,
+			},
+			msg: "strict spec compliance with okta",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://am.example.com/am/oauth2/realms/root/realms/alpha/authorize",
+				TokenEndpoint: "https://am.example.com/am/oauth2/realms/root/realms/alpha/access_token",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPType:       "forgerock",
+			},
+			msg: "forgerock without jwks uri",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://accounts.google.com/o/oauth2/v2/auth",
+				TokenEndpoint: "https://oauth2.googleapis.com/token",
+				JWKSURI:       "https://www.googleapis.com/oauth2/v3/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				Google:        &v1.OIDCGoogle{HostedDomain: "example.com"},
+			},
+			msg: "google hosted domain",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:       "https://idp.example.com/auth",
+				TokenEndpoint:      "https://idp.example.com/token",
+				JWKSURI:            "https://idp.example.com/certs",
+				ClientID:           "client",
+				ClientSecret:       "secret",
+				LoginRedirectPaths: []string{"/app/", "/account"},
+			},
+			msg: "login redirect paths",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:         "https://idp.example.com/auth",
+				TokenEndpoint:        "https://idp.example.com/token",
+				JWKSURI:              "https://idp.example.com/certs",
+				ClientID:             "client",
+				ClientSecret:         "secret",
+				UnauthorizedBehavior: &v1.OIDCUnauthorizedBehavior{AcceptJSON: true, Paths: []string{"/api/"}},
+			},
+			msg: "unauthorized behavior",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:      "https://idp.example.com/auth",
+				TokenEndpoint:     "https://idp.example.com/token",
+				JWKSURI:           "https://idp.example.com/certs",
+				ClientID:          "client",
+				ClientSecret:      "secret",
+				SessionInfoClaims: []string{"sub", "preferred_username", "https://example.com/roles"},
+			},
+			msg: "session info claims",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				Resources:     []string{"https://api.example.com/orders", "urn:example:inventory"},
+			},
+			msg: "resources",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:      "http://127.0.0.1:8080/auth/realms/master/protocol/openid-connect/auth",
+				TokenEndpoint:     "http://127.0.0.1:8080/auth/realms/master/protocol/openid-connect/token",
+				JWKSURI:           "http://127.0.0.1:8080/auth/realms/master/protocol/openid-connect/certs",
+				ClientID:          "client",
+				ClientSecret:      "secret",
+				Scope:             "openid+offline_access",
+				AccessTokenEnable: true,
+			},
+			msg: "offline access scope",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "idp/oidc-secret",
+				Scope:         "openid",
+			},
+			msg: "client secret in another namespace",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "oidc-secret",
+				Scope:         "openid",
+				VirtualServerSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"sso": "required"},
+				},
+			},
+			msg: "virtual server selector",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "oidc-secret",
+				Scope:         "openid",
+				SessionStore: &v1.OIDCSessionStore{
+					Type: "redis",
+					Redis: &v1.OIDCRedisSessionStore{
+						Address:    "redis.default.svc:6379",
+						Database:   1,
+						AuthSecret: "redis-auth",
+						TLS:        &v1.OIDCRedisTLS{Enable: true, CASecret: "redis-ca", ServerName: "redis.example.com"},
+					},
+				},
+			},
+			msg: "redis session store",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "oidc-secret",
+				Scope:         "openid",
+				SessionStore:  &v1.OIDCSessionStore{Type: "cookie", Cookie: &v1.OIDCCookieSessionStore{KeySecret: "session-keys"}},
+			},
+			msg: "cookie session store",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "oidc-secret",
+				Scope:         "openid",
+				SessionStore:  &v1.OIDCSessionStore{Type: "cookie", Cookie: &v1.OIDCCookieSessionStore{KeySecret: "session-keys", PreviousKeyLifetime: "24h"}},
+			},
+			msg: "cookie session store with a previous key lifetime",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.corp.example.com/auth",
+				TokenEndpoint: "https://idp.corp.example.com/token",
+				JWKSURI:       "https://idp.corp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "oidc-secret",
+				Resolver: &v1.OIDCResolver{
+					Addresses: []string{"10.0.0.10", "[fd00::10]:5353", "dns.corp.example.com"},
+					Valid:     "30s",
+					IPv6:      createPointerFromBool(false),
+				},
+			},
+			msg: "resolver",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "oidc-secret",
+				HTTPProxy:     "http://proxy.corp.example.com:3128",
+				NoProxy:       []string{"*.svc.cluster.local", ".corp.example.com", "idp.corp.example.com", "10.0.0.0/8", "fd00::10"},
+			},
+			msg: "forward proxy",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "oidc-secret",
+				PathPrefix:    "/auth/oidc-v2",
+			},
+			msg: "path prefix",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "oidc-secret",
+				RateLimit:     &v1.OIDCRateLimit{Rate: "10r/m", Burst: createPointerFromInt(5), ZoneSize: "1m"},
+			},
+			msg: "rate limit",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "oidc-secret",
+				LoginLockout:  &v1.OIDCLoginLockout{MaxFailures: 5, Window: "10m", Duration: "15m"},
+			},
+			msg: "login lockout",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "oidc-secret",
+				LoginLockout:  &v1.OIDCLoginLockout{MaxFailures: 5, Action: "tarpit", TarpitDelay: "10s"},
+			},
+			msg: "login lockout with a tarpit",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "oidc-secret",
+				Introspection: &v1.OIDCIntrospection{
+					Enable:          true,
+					Endpoint:        "https://idp.example.com/introspect",
+					CacheTTL:        "2m",
+					CacheMaxEntries: createPointerFromInt(5000),
+				},
+			},
+			msg: "token introspection",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "oidc-secret",
+				SecurityHeaders: &v1.OIDCSecurityHeaders{
+					ContentSecurityPolicy: "default-src 'none'; style-src 'self' https://cdn.example.com; frame-ancestors 'none';",
+					FrameOptions:          "DENY",
+					ReferrerPolicy:        "no-referrer",
+				},
+			},
+			msg: "security headers",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:      "https://idp.example.com/auth",
+				TokenEndpoint:     "https://idp.example.com/token",
+				JWKSURI:           "https://idp.example.com/certs",
+				ClientID:          "client",
+				ClientSecret:      "oidc-secret",
+				RedirectAllowlist: []string{"/app/", "https://App.example.com", "http://portal.example.com:8080/home/"},
+			},
+			msg: "redirect allowlist",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:   "https://idp.example.com/auth",
+				TokenEndpoint:  "https://idp.example.com/token",
+				JWKSURI:        "https://idp.example.com/certs",
+				ClientID:       "client",
+				ClientSecret:   "oidc-secret",
+				RequestHeaders: []v1.Header{{Name: "X-API-Key", Value: "$ecret"}, {Name: "X-Tenant", Value: "example"}},
+			},
+			msg: "request headers",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "oidc-secret",
+				SessionStore:  &v1.OIDCSessionStore{Type: "cookie"},
+				ManagedKeys:   &v1.OIDCManagedKeys{Enable: true, RotationInterval: "30d"},
+			},
+			msg: "cookie session store with managed keys",
+		},
+	}
+
+	for _, test := range tests {
+		allErrs := validateOIDC(test.oidc, field.NewPath("oidc"))
+		if len(allErrs) != 0 {
+			t.Errorf("validateOIDC() returned errors %v for valid input for the case of %v", allErrs, test.msg)
+		}
+	}
+}
+
+func TestValidateAPIKeyPolicy_PassOnValidInput(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		apiKey *v1.APIKey
+		msg    string
+	}{
+		{
+			apiKey: &v1.APIKey{
+				SuppliedIn: &v1.SuppliedIn{
+					Header: []string{
+						"X-API-Key",
+					},
+				},
+				ClientSecret: "secret",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		allErrs := validateAPIKey(test.apiKey, field.NewPath("apiKey"))
+		if len(allErrs) != 0 {
+			t.Errorf("validateAPIKey() returned errors %v for valid input for the case of %v", allErrs, test.msg)
+		}
+	}
+}
+
+func TestValidateAPIKeyPolicy_FailsOnInvalidInput(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		apiKey *v1.APIKey
+		msg    string
+	}{
+		{
+			apiKey: &v1.APIKey{
+				SuppliedIn: &v1.SuppliedIn{
+					Query: []string{
+						"api_key",
+					},
+				},
+			},
+			msg: "missing secret",
+		},
+		{
+			apiKey: &v1.APIKey{
+				SuppliedIn:   &v1.SuppliedIn{},
+				ClientSecret: "secret",
+			},
+			msg: "both  header and query are missing",
+		},
+		{
+			apiKey: &v1.APIKey{
+				SuppliedIn: &v1.SuppliedIn{
+					Header: []string{
+						`api.key"`,
+					},
+				},
+				ClientSecret: "secret",
+			},
+			msg: "invalid header",
+		},
+		{
+			apiKey: &v1.APIKey{
+				SuppliedIn: &v1.SuppliedIn{
+					Query: []string{
+						`api_key\`,
+					},
+				},
+				ClientSecret: "secret",
+			},
+			msg: "invalid query",
+		},
+		{
+			apiKey: &v1.APIKey{
+				SuppliedIn: &v1.SuppliedIn{
+					Query: []string{
+						`api_key`,
+					},
+				},
+				ClientSecret: "secret_1",
+			},
+			msg: "invalid secret name",
+		},
+	}
+
+	for _, test := range tests {
+		allErrs := validateAPIKey(test.apiKey, field.NewPath("apiKey"))
+		if len(allErrs) == 0 {
+			t.Errorf("validateAPIKey() returned no errors for invalid input for the case of %v", test.msg)
+		}
+	}
+}
+
+func TestValidateClientCredentials_PassesOnValidInput(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		clientCredentials *v1.ClientCredentials
+		msg               string
+	}{
+		{
+			clientCredentials: &v1.ClientCredentials{
+				TokenEndpoint: "https://idp.example.com/token",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+			},
+			msg: "no scope",
+		},
+		{
+			clientCredentials: &v1.ClientCredentials{
+				TokenEndpoint: "https://idp.example.com/token",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				Scope:         "orders.read+orders.write",
+			},
+			msg: "scope without openid",
+		},
+	}
+
+	for _, test := range tests {
+		allErrs := validateClientCredentials(test.clientCredentials, field.NewPath("clientCredentials"))
+		if len(allErrs) != 0 {
+			t.Errorf("validateClientCredentials() returned errors %v for valid input for the case of %v", allErrs, test.msg)
+		}
+	}
+}
+
+func TestValidateClientCredentials_FailsOnInvalidInput(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		clientCredentials *v1.ClientCredentials
+		msg               string
+	}{
+		{
+			clientCredentials: &v1.ClientCredentials{
+				ClientID:     "client",
+				ClientSecret: "secret",
+			},
+			msg: "missing token endpoint",
+		},
+		{
+			clientCredentials: &v1.ClientCredentials{
+				TokenEndpoint: "https://idp.example.com/token",
+				ClientSecret:  "secret",
+			},
+			msg: "missing client ID",
+		},
+		{
+			clientCredentials: &v1.ClientCredentials{
+				TokenEndpoint: "https://idp.example.com/token",
+				ClientID:      "client",
+			},
+			msg: "missing client secret",
+		},
+		{
+			clientCredentials: &v1.ClientCredentials{
+				TokenEndpoint: "idp.example.com/token",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+			},
+			msg: "token endpoint without scheme",
+		},
+		{
+			clientCredentials: &v1.ClientCredentials{
+				TokenEndpoint: "https://idp.example.com/token",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				Scope:         "orders read",
+			},
+			msg: "invalid scope",
+		},
+	}
+
+	for _, test := range tests {
+		allErrs := validateClientCredentials(test.clientCredentials, field.NewPath("clientCredentials"))
+		if len(allErrs) == 0 {
+			t.Errorf("validateClientCredentials() returned no errors for invalid input for the case of %v", test.msg)
+		}
+	}
+}
+
+func TestValidateOIDCScope_ErrorsOnInvalidInput(t *testing.T) {
+	t.Parallel()
+
+	invalidInput := []string{
+		"",
+		" ",
+		"openid+scope\x5c",
+		"mycustom\x7fscope",
+		"openid+myscope\x20",
+		"openid+cus\x19tom",
+	}
+
+	for _, v := range invalidInput {
+		allErrs := validateOIDCScope(v, field.NewPath("scope"))
+		if len(allErrs) == 0 {
+			t.Error("want err on invalid scope, got no error")
+		}
+	}
+}
+
+func TestValidateOIDCScope_PassesOnValidInput(t *testing.T) {
+	t.Parallel()
+
+	validInput := []string{
+		"openid",
+		"validScope+openid",
+		"SecondScope+openid+CustomScope",
+		"validScope\x26+openid",
+		"openid+my\x33scope",
+	}
+	for _, v := range validInput {
+		allErrs := validateOIDCScope(v, field.NewPath("scope"))
+		if len(allErrs) != 0 {
+			t.Errorf("want no err, got %v", allErrs)
+		}
+	}
+}
+
+func TestValidateOIDC_FailsOnInvalidOIDC(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		oidc *v1.OIDC
+		msg  string
+	}{
+		{
+			oidc: &v1.OIDC{
+				RedirectURI: "/foo",
+			},
+			msg: "missing required field auth",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:      "http://127.0.0.1:8080/auth/realms/master/protocol/openid-connect/auth",
+				TokenEndpoint:     "http://127.0.0.1:8080/auth/realms/master/protocol/openid-connect/token",
+				JWKSURI:           "http://127.0.0.1:8080/auth/realms/master/protocol/openid-connect/certs",
+				ClientID:          "client",
+				ClientSecret:      "secret",
+				Scope:             "bogus",
+				AccessTokenEnable: true,
+			},
+			msg: "missing openid in scope",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:      "http://127.0.0.1:8080/auth/realms/master/protocol/openid-connect/auth",
+				TokenEndpoint:     "http://127.0.0.1:8080/auth/realms/master/protocol/openid-connect/token",
+				JWKSURI:           "http://127.0.0.1:8080/auth/realms/master/protocol/openid-connect/certs",
+				ClientID:          "client",
+				ClientSecret:      "secret",
+				Scope:             "openid+bogus\x7f",
+				AccessTokenEnable: true,
+			},
+			msg: "invalid unicode in scope",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "http://127.0.0.1:8080/auth/realms/master/protocol/openid-connect/auth",
+				TokenEndpoint: "http://127.0.0.1:8080/auth/realms/master/protocol/openid-connect/token",
+				JWKSURI:       "http://127.0.0.1:8080/auth/realms/master/protocol/openid-connect/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				ResponseMode:  "fragment",
+			},
+			msg: "unsupported response mode",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				JAREnable:     true,
+			},
+			msg: "jar enabled without key secret",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:       "https://idp.example.com/auth",
+				TokenEndpoint:      "https://idp.example.com/token",
+				JWKSURI:            "https://idp.example.com/certs",
+				ClientID:           "client",
+				ClientSecret:       "secret",
+				DeviceAuthEndpoint: "idp.example.com/device/code",
+			},
+			msg: "device authorization endpoint without scheme",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				DPoPEnable:    true,
+			},
+			msg: "dpop without access token",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:       "https://github.com/login/oauth/authorize",
+				TokenEndpoint:      "https://github.com/login/oauth/access_token",
+				ClientID:           "client",
+				ClientSecret:       "secret",
+				OAuth2UserEndpoint: "api.github.com/user",
+			},
+			msg: "oauth2 user endpoint without scheme",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:       "https://github.com/login/oauth/authorize",
+				TokenEndpoint:      "https://github.com/login/oauth/access_token",
+				JWKSURI:            "https://idp.example.com/certs",
+				ClientID:           "client",
+				ClientSecret:       "secret",
+				OAuth2UserEndpoint: "https://api.github.com/user",
+			},
+			msg: "oauth2 adapter with jwks uri",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:       "https://github.com/login/oauth/authorize",
+				TokenEndpoint:      "https://github.com/login/oauth/access_token",
+				ClientID:           "client",
+				ClientSecret:       "secret",
+				JARMEnable:         true,
+				OAuth2UserEndpoint: "https://api.github.com/user",
+			},
+			msg: "oauth2 adapter with jarm",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:        "https://github.com/login/oauth/authorize",
+				TokenEndpoint:       "https://github.com/login/oauth/access_token",
+				ClientID:            "client",
+				ClientSecret:        "secret",
+				TokenHashesRequired: true,
+				OAuth2UserEndpoint:  "https://api.github.com/user",
+			},
+			msg: "oauth2 adapter with token hashes required",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				JWEKeySecret:  "jwe_key",
+			},
+			msg: "invalid jwe key secret name",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				ErrorPages:    "oidc_error_pages",
+			},
+			msg: "invalid error pages configmap name",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				Resolver:      &v1.OIDCResolver{Valid: "30s"},
+			},
+			msg: "resolver without addresses",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				Resolver:      &v1.OIDCResolver{Addresses: []string{"fd00::10"}},
+			},
+			msg: "resolver with an IPv6 address without brackets",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				Resolver:      &v1.OIDCResolver{Addresses: []string{"10.0.0.10:99999"}},
+			},
+			msg: "resolver with an invalid port",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				Resolver:      &v1.OIDCResolver{Addresses: []string{"10.0.0.10 valid=1s"}},
+			},
+			msg: "resolver with an invalid address",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				Resolver:      &v1.OIDCResolver{Addresses: []string{"10.0.0.10"}, Valid: "30 seconds"},
+			},
+			msg: "resolver with an invalid validity",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				HTTPProxy:     "proxy.corp.example.com:3128",
+			},
+			msg: "forward proxy without a scheme",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				PathPrefix:    "/oidc/",
+			},
+			msg: "path prefix with a trailing slash",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				PathPrefix:    "oidc",
+			},
+			msg: "path prefix without a leading slash",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				PathPrefix:    "/oidc;{",
+			},
+			msg: "path prefix with invalid characters",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				PathPrefix:    "/oidc/..",
+			},
+			msg: "path prefix with a .. segment",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				RateLimit:     &v1.OIDCRateLimit{Burst: createPointerFromInt(5)},
+			},
+			msg: "rate limit without a rate",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				RateLimit:     &v1.OIDCRateLimit{Rate: "10r/m", Burst: createPointerFromInt(0)},
+			},
+			msg: "rate limit with a zero burst",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				RateLimit:     &v1.OIDCRateLimit{Rate: "10r/m", ZoneSize: "16k"},
+			},
+			msg: "rate limit with a too small zone",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				LoginLockout:  &v1.OIDCLoginLockout{Window: "10m"},
+			},
+			msg: "login lockout without maxFailures",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				LoginLockout:  &v1.OIDCLoginLockout{MaxFailures: 5, Duration: "2h"},
+			},
+			msg: "login lockout longer than 1h",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				LoginLockout:  &v1.OIDCLoginLockout{MaxFailures: 5, Action: "captcha"},
+			},
+			msg: "login lockout with an invalid action",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				LoginLockout:  &v1.OIDCLoginLockout{MaxFailures: 5, TarpitDelay: "10s"},
+			},
+			msg: "login lockout with a tarpitDelay without tarpit",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:    "https://idp.example.com/auth",
+				TokenEndpoint:   "https://idp.example.com/token",
+				JWKSURI:         "https://idp.example.com/certs",
+				ClientID:        "client",
+				ClientSecret:    "secret",
+				SecurityHeaders: &v1.OIDCSecurityHeaders{ContentSecurityPolicy: "default-src 'none'; script-src \"$nonce\""},
+			},
+			msg: "content security policy with quotes and a variable",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:    "https://idp.example.com/auth",
+				TokenEndpoint:   "https://idp.example.com/token",
+				JWKSURI:         "https://idp.example.com/certs",
+				ClientID:        "client",
+				ClientSecret:    "secret",
+				SecurityHeaders: &v1.OIDCSecurityHeaders{ContentSecurityPolicy: "default-src 'none', frame-ancestors 'none'"},
+			},
+			msg: "content security policy with a comma",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:    "https://idp.example.com/auth",
+				TokenEndpoint:   "https://idp.example.com/token",
+				JWKSURI:         "https://idp.example.com/certs",
+				ClientID:        "client",
+				ClientSecret:    "secret",
+				SecurityHeaders: &v1.OIDCSecurityHeaders{FrameOptions: "ALLOW-FROM https://app.example.com"},
+			},
+			msg: "invalid frame options",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:    "https://idp.example.com/auth",
+				TokenEndpoint:   "https://idp.example.com/token",
+				JWKSURI:         "https://idp.example.com/certs",
+				ClientID:        "client",
+				ClientSecret:    "secret",
+				SecurityHeaders: &v1.OIDCSecurityHeaders{ReferrerPolicy: "never"},
+			},
+			msg: "invalid referrer policy",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:      "https://idp.example.com/auth",
+				TokenEndpoint:     "https://idp.example.com/token",
+				JWKSURI:           "https://idp.example.com/certs",
+				ClientID:          "client",
+				ClientSecret:      "secret",
+				RedirectAllowlist: []string{"https://user@app.example.com/"},
+			},
+			msg: "redirect allowlist with user info",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:      "https://idp.example.com/auth",
+				TokenEndpoint:     "https://idp.example.com/token",
+				JWKSURI:           "https://idp.example.com/certs",
+				ClientID:          "client",
+				ClientSecret:      "secret",
+				RedirectAllowlist: []string{"javascript:alert(1)"},
+			},
+			msg: "redirect allowlist with an invalid scheme",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:      "https://idp.example.com/auth",
+				TokenEndpoint:     "https://idp.example.com/token",
+				JWKSURI:           "https://idp.example.com/certs",
+				ClientID:          "client",
+				ClientSecret:      "secret",
+				RedirectAllowlist: []string{"https://app.example.com//evil.example.com/"},
+			},
+			msg: "redirect allowlist with an empty path segment",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:      "https://idp.example.com/auth",
+				TokenEndpoint:     "https://idp.example.com/token",
+				JWKSURI:           "https://idp.example.com/certs",
+				ClientID:          "client",
+				ClientSecret:      "secret",
+				RedirectAllowlist: []string{"//evil.example.com/"},
+			},
+			msg: "redirect allowlist with a protocol-relative URL",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:       "https://idp.example.com/auth",
+				TokenEndpoint:      "https://idp.example.com/token",
+				JWKSURI:            "https://idp.example.com/certs",
+				ClientID:           "client",
+				ClientSecret:       "secret",
+				LoginRedirectPaths: []string{"/app/"},
+				RedirectAllowlist:  []string{"/app/"},
+			},
+			msg: "redirect allowlist with loginRedirectPaths",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				HTTPProxy:     "socks5://proxy.corp.example.com:1080",
+			},
+			msg: "forward proxy with an invalid scheme",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				HTTPProxy:     "http://proxy.corp.example.com:3128/path",
+			},
+			msg: "forward proxy with a path",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				HTTPProxy:     "http://proxy.corp.example.com:99999",
+			},
+			msg: "forward proxy with an invalid port",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				NoProxy:       []string{".corp.example.com"},
+			},
+			msg: "noProxy without a forward proxy",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				HTTPProxy:     "http://proxy.corp.example.com:3128",
+				NoProxy:       []string{"corp example com"},
+			},
+			msg: "forward proxy with an invalid noProxy host",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:   "https://idp.example.com/auth",
+				TokenEndpoint:  "https://idp.example.com/token",
+				JWKSURI:        "https://idp.example.com/certs",
+				ClientID:       "client",
+				ClientSecret:   "secret",
+				RequestHeaders: []v1.Header{{Name: "X API Key", Value: "key"}},
+			},
+			msg: "request header with an invalid name",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:   "https://idp.example.com/auth",
+				TokenEndpoint:  "https://idp.example.com/token",
+				JWKSURI:        "https://idp.example.com/certs",
+				ClientID:       "client",
+				ClientSecret:   "secret",
+				RequestHeaders: []v1.Header{{Name: "authorization", Value: "Basic a2V5"}},
+			},
+			msg: "request header set by NGINX",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:   "https://idp.example.com/auth",
+				TokenEndpoint:  "https://idp.example.com/token",
+				JWKSURI:        "https://idp.example.com/certs",
+				ClientID:       "client",
+				ClientSecret:   "secret",
+				RequestHeaders: []v1.Header{{Name: "X-Tenant", Value: "a"}, {Name: "x-tenant", Value: "b"}},
+			},
+			msg: "duplicate request headers",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:   "https://idp.example.com/auth",
+				TokenEndpoint:  "https://idp.example.com/token",
+				JWKSURI:        "https://idp.example.com/certs",
+				ClientID:       "client",
+				ClientSecret:   "secret",
+				RequestHeaders: []v1.Header{{Name: "X-Tenant", Value: "a\r\nX-Injected: b"}},
+			},
+			msg: "request header with control characters",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:   "https://idp.example.com/auth",
+				TokenEndpoint:  "https://idp.example.com/token",
+				JWKSURI:        "https://idp.example.com/certs",
+				ClientID:       "client",
+				ClientSecret:   "secret",
+				RequestHeaders: []v1.Header{{Value: "key"}},
+			},
+			msg: "request header without a name",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				ManagedKeys:   &v1.OIDCManagedKeys{RotationInterval: "30d"},
+			},
+			msg: "rotation interval without managed keys",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				ManagedKeys:   &v1.OIDCManagedKeys{Enable: true, RotationInterval: "30 days"},
+			},
+			msg: "invalid rotation interval",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				ManagedKeys:   &v1.OIDCManagedKeys{Enable: true, RotationInterval: "10m"},
+			},
+			msg: "too short rotation interval",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:      "https://idp.example.com/auth",
+				TokenEndpoint:     "https://idp.example.com/token",
+				DiscoveryEndpoint: "idp.example.com/.well-known/openid-configuration",
+				ClientID:          "client",
+				ClientSecret:      "secret",
+			},
+			msg: "invalid discovery endpoint",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:       "https://github.com/login/oauth/authorize",
+				TokenEndpoint:      "https://github.com/login/oauth/access_token",
+				DiscoveryEndpoint:  "https://github.com/.well-known/openid-configuration",
+				ClientID:           "client",
+				ClientSecret:       "secret",
+				OAuth2UserEndpoint: "https://api.github.com/user",
+			},
+			msg: "oauth2 adapter with discovery endpoint",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:   "https://idp.example.com/auth",
+				TokenEndpoint:  "https://idp.example.com/token",
+				JWKSURI:        "https://idp.example.com/certs",
+				ClientID:       "client",
+				ClientSecret:   "secret",
+				CookieSameSite: "lax",
+			},
+			msg: "invalid cookie samesite",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:       "https://idp.example.com/auth",
+				TokenEndpoint:      "https://idp.example.com/token",
+				JWKSURI:            "https://idp.example.com/certs",
+				ClientID:           "client",
+				ClientSecret:       "secret",
+				MaxSessionsPerUser: -1,
+			},
+			msg: "negative max sessions per user",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:       "https://idp.example.com/auth",
+				TokenEndpoint:      "https://idp.example.com/token",
+				JWKSURI:            "https://idp.example.com/certs",
+				ClientID:           "client",
+				ClientSecret:       "secret",
+				MaxSessionsPerUser: 3,
+				SessionLimitAction: "logout",
+			},
+			msg: "invalid session limit action",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:       "https://idp.example.com/auth",
+				TokenEndpoint:      "https://idp.example.com/token",
+				JWKSURI:            "https://idp.example.com/certs",
+				ClientID:           "client",
+				ClientSecret:       "secret",
+				SessionLimitAction: "evict",
+			},
+			msg: "session limit action without max sessions per user",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:        "https://idp.example.com/auth",
+				TokenEndpoint:       "https://idp.example.com/token",
+				JWKSURI:             "https://idp.example.com/certs",
+				ClientID:            "client",
+				ClientSecret:        "secret",
+				RefreshAheadSeconds: -60,
+			},
+			msg: "negative refresh ahead seconds",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:       "https://idp.example.com/auth",
+				TokenEndpoint:      "https://idp.example.com/token",
+				JWKSURI:            "https://idp.example.com/certs",
+				ClientID:           "client",
+				ClientSecret:       "secret",
+				RevocationEndpoint: "idp.example.com/revoke",
+			},
+			msg: "invalid revocation endpoint",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				RememberMe:    &v1.OIDCRememberMe{Enable: true, Duration: "2 weeks"},
+			},
+			msg: "invalid remember me duration",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				StepUpMaxAge:  createPointerFromInt(-1),
+			},
+			msg: "negative step-up max age",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:      "https://idp.example.com/auth",
+				TokenEndpoint:     "https://idp.example.com/token",
+				JWKSURI:           "https://idp.example.com/certs",
+				ClientID:          "client",
+				ClientSecret:      "secret",
+				IdPOutageBehavior: "allowAll",
+			},
+			msg: "invalid idp outage behavior",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				LogLevel:      "warn",
+			},
+			msg: "invalid log level",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				Issuer:        "https://idp.example.com/{tenantid}/{tenantid}",
+			},
+			msg: "issuer with two tenant ids",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				Issuer:        "https://idp.example.com/{tenant}/v2.0",
+			},
+			msg: "issuer with unknown placeholder",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				Issuer:        "idp.example.com",
+			},
+			msg: "issuer without scheme",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:   "https://idp.example.com/auth",
+				TokenEndpoint:  "https://idp.example.com/token",
+				JWKSURI:        "https://idp.example.com/certs",
+				ClientID:       "client",
+				ClientSecret:   "secret",
+				Issuer:         "https://idp.example.com/",
+				AllowedTenants: []string{"9188040d-6c67-4c5b-b112-36a304b66dad"},
+			},
+			msg: "allowed tenants with issuer without tenant id",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPs: []v1.OIDCIdP{
+					{
+						Name:          "partners",
+						AuthEndpoint:  "https://partners.example.com/auth",
+						TokenEndpoint: "https://partners.example.com/token",
+						JWKSURI:       "https://partners.example.com/keys",
+						ClientID:      "partners-client",
+						ClientSecret:  "identity/partners-secret",
+						Scope:         "email",
+					},
+				},
+			},
+			msg: "idp scope without openid",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPs: []v1.OIDCIdP{
+					{
+						Name:          "partners",
+						AuthEndpoint:  "https://partners.example.com/auth",
+						TokenEndpoint: "https://partners.example.com/token",
+						JWKSURI:       "https://partners.example.com/keys",
+						ClientID:      "partners-client",
+						ClientSecret:  "identity/partners-secret",
+					},
+				},
+				IdPSelection: &v1.OIDCIdPSelection{Type: "hostname"},
+			},
+			msg: "hostname selection without hosts",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPs: []v1.OIDCIdP{
+					{
+						Name:          "partners",
+						AuthEndpoint:  "https://partners.example.com/auth",
+						TokenEndpoint: "https://partners.example.com/token",
+						JWKSURI:       "https://partners.example.com/keys",
+						ClientID:      "partners-client",
+						ClientSecret:  "identity/partners-secret",
+						Hosts:         []string{"partners.example.com"},
+					},
+				},
+			},
+			msg: "idp hosts with the idp picker",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPs: []v1.OIDCIdP{
+					{
+						Name:          "partners",
+						AuthEndpoint:  "https://partners.example.com/auth",
+						TokenEndpoint: "https://partners.example.com/token",
+						JWKSURI:       "https://partners.example.com/keys",
+						ClientID:      "partners-client",
+						ClientSecret:  "identity/partners-secret",
+					},
+				},
+				IdPSelection: &v1.OIDCIdPSelection{Type: "cookie", Cookie: "login-idp"},
+			},
+			msg: "invalid selection cookie",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPs: []v1.OIDCIdP{
+					{
+						Name:          "partners",
+						AuthEndpoint:  "https://partners.example.com/auth",
+						TokenEndpoint: "https://partners.example.com/token",
+						JWKSURI:       "https://partners.example.com/keys",
+						ClientID:      "partners-client",
+						ClientSecret:  "identity/partners-secret",
+					},
+				},
+				IdPSelection: &v1.OIDCIdPSelection{Type: "query"},
+			},
+			msg: "unknown idp selection",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPs: []v1.OIDCIdP{
+					{
+						Name:          "partners",
+						AuthEndpoint:  "https://partners.example.com/auth",
+						TokenEndpoint: "https://partners.example.com/token",
+						JWKSURI:       "https://partners.example.com/keys",
+						ClientID:      "partners-client",
+						ClientSecret:  "identity/partners-secret",
+					},
+				},
+				IdPType: "azuread",
+			},
+			msg: "idps with idp type",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPs: []v1.OIDCIdP{
+					{
+						Name:          "partners",
+						AuthEndpoint:  "https://partners.example.com/auth",
+						TokenEndpoint: "https://partners.example.com/token",
+						JWKSURI:       "https://partners.example.com/keys",
+						ClientID:      "partners-client",
+						ClientSecret:  "identity/partners-secret",
+					},
+				},
+				IdPSelection: &v1.OIDCIdPSelection{Type: "email"},
+			},
+			msg: "email selection without email domains",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPs: []v1.OIDCIdP{
+					{
+						Name:          "partners",
+						AuthEndpoint:  "https://partners.example.com/auth",
+						TokenEndpoint: "https://partners.example.com/token",
+						JWKSURI:       "https://partners.example.com/keys",
+						ClientID:      "partners-client",
+						ClientSecret:  "identity/partners-secret",
+						EmailDomains:  []string{"partner.com"},
+					},
+				},
+			},
+			msg: "idp email domains with the idp picker",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPs: []v1.OIDCIdP{
+					{
+						Name:          "partners",
+						AuthEndpoint:  "https://partners.example.com/auth",
+						TokenEndpoint: "https://partners.example.com/token",
+						JWKSURI:       "https://partners.example.com/keys",
+						ClientID:      "partners-client",
+						ClientSecret:  "identity/partners-secret",
+						EmailDomains:  []string{"partner"},
+					},
+				},
+				IdPSelection: &v1.OIDCIdPSelection{Type: "email"},
+			},
+			msg: "idp email domain without a dot",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPs: []v1.OIDCIdP{
+					{
+						Name:          "partners",
+						AuthEndpoint:  "https://partners.example.com/auth",
+						TokenEndpoint: "https://partners.example.com/token",
+						JWKSURI:       "https://partners.example.com/keys",
+						ClientID:      "partners-client",
+						ClientSecret:  "identity/partners-secret",
+						EmailDomains:  []string{"Partner.com"},
+					},
+				},
+				IdPSelection: &v1.OIDCIdPSelection{Type: "email"},
+			},
+			msg: "idp email domain with uppercase letters",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPs: []v1.OIDCIdP{
+					{
+						Name:          "partners",
+						AuthEndpoint:  "https://partners.example.com/auth",
+						TokenEndpoint: "https://partners.example.com/token",
+						JWKSURI:       "https://partners.example.com/keys",
+						ClientID:      "partners-client",
+						ClientSecret:  "identity/partners-secret",
+						EmailDomains:  []string{"partner.com", "partner.com"},
+					},
+				},
+				IdPSelection: &v1.OIDCIdPSelection{Type: "email"},
+			},
+			msg: "duplicate idp email domains",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPs: []v1.OIDCIdP{
+					{Name: "default", AuthEndpoint: "https://a.example.com/auth", TokenEndpoint: "https://a.example.com/token", JWKSURI: "https://a.example.com/keys", ClientID: "a", ClientSecret: "a"},
+				},
+			},
+			msg: "idp with the name of the idp of the policy",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPs: []v1.OIDCIdP{
+					{Name: "partners", AuthEndpoint: "https://a.example.com/auth", TokenEndpoint: "https://a.example.com/token", JWKSURI: "https://a.example.com/keys", ClientID: "a", ClientSecret: "a"},
+					{Name: "partners", AuthEndpoint: "https://b.example.com/auth", TokenEndpoint: "https://b.example.com/token", JWKSURI: "https://b.example.com/keys", ClientID: "b", ClientSecret: "b"},
+				},
+			},
+			msg: "duplicate idp names",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPs: []v1.OIDCIdP{
+					{Name: "partners", AuthEndpoint: "https://a.example.com/auth", TokenEndpoint: "https://a.example.com/token", ClientID: "a", ClientSecret: "a"},
+				},
+			},
+			msg: "idp without jwks uri",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPSelection:  &v1.OIDCIdPSelection{Type: "picker"},
+			},
+			msg: "idp selection without idps",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPType:       "okta",
+			},
+			msg: "invalid idp type",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:   "https://idp.example.com/auth",
+				TokenEndpoint:  "https://idp.example.com/token",
+				JWKSURI:        "https://idp.example.com/certs",
+				ClientID:       "client",
+				ClientSecret:   "secret",
+				AllowedTenants: []string{"9188040d-6c67-4c5b-b112-36a304b66dad"},
+			},
+			msg: "allowed tenants without idp type azuread",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:   "https://idp.example.com/auth",
+				TokenEndpoint:  "https://idp.example.com/token",
+				JWKSURI:        "https://idp.example.com/certs",
+				ClientID:       "client",
+				ClientSecret:   "secret",
+				IdPType:        "azuread",
+				AllowedTenants: []string{"contoso.onmicrosoft.com"},
+			},
+			msg: "invalid tenant id",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPType:       "keycloak",
+			},
+			msg: "idp type keycloak without realm endpoint",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://example.okta.com/oauth2/default/v1/token",
+				TokenEndpoint: "https://example.okta.com/oauth2/default/v1/token",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPType:       "okta",
+			},
+			msg: "idp type okta without authorization endpoint",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://example.auth.us-east-1.amazoncognito.com/login",
+				TokenEndpoint: "https://example.auth.us-east-1.amazoncognito.com/oauth2/token",
+				JWKSURI:       "https://cognito-idp.us-east-1.amazonaws.com/us-east-1_Example/.well-known/jwks.json",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPType:       "cognito",
+			},
+			msg: "idp type cognito without authorization endpoint",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://example.us-east-1.amazoncognito.com/oauth2/authorize",
+				TokenEndpoint: "https://example.us-east-1.amazoncognito.com/oauth2/token",
+				JWKSURI:       "https://cognito-idp.us-east-1.amazonaws.com/us-east-1_Example/.well-known/jwks.json",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPType:       "cognito",
+			},
+			msg: "idp type cognito with invalid cognito domain",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://example.auth.us-east-1.amazoncognito.com/oauth2/authorize",
+				TokenEndpoint: "https://other.auth.us-east-1.amazoncognito.com/oauth2/token",
+				JWKSURI:       "https://cognito-idp.us-east-1.amazonaws.com/us-east-1_Example/.well-known/jwks.json",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPType:       "cognito",
+			},
+			msg: "idp type cognito with token endpoint of another domain",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://example.auth.us-east-1.amazoncognito.com/oauth2/authorize",
+				TokenEndpoint: "https://example.auth.us-east-1.amazoncognito.com/oauth2/token",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPType:       "cognito",
+			},
+			msg: "idp type cognito without jwks uri",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://example.us.auth0.com/oauth/authorize",
+				TokenEndpoint: "https://example.us.auth0.com/oauth/token",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPType:       "auth0",
+			},
+			msg: "idp type auth0 without authorization endpoint",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://example.us.auth0.com/authorize",
+				TokenEndpoint: "https://login.example.com/oauth/token",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPType:       "auth0",
+			},
+			msg: "idp type auth0 with token endpoint of another domain",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://adfs.example.com/oauth2/authorize/",
+				TokenEndpoint: "https://adfs.example.com/oauth2/token/",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPType:       "adfs",
+				Resources:     []string{"https://api.example.com"},
+			},
+			msg: "idp type adfs without adfs endpoint",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://adfs.example.com/adfs/oauth2/authorize/",
+				TokenEndpoint: "https://adfs.example.com/adfs/oauth2/token/",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPType:       "adfs",
+			},
+			msg: "idp type adfs without resource",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://sso.example.com/oauth2/authorize",
+				TokenEndpoint: "https://sso.example.com/as/token.oauth2",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPType:       "pingfederate",
+			},
+			msg: "idp type pingfederate without pingfederate endpoint",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:         "https://idp.example.com/auth",
+				TokenEndpoint:        "https://idp.example.com/token",
+				JWKSURI:              "https://idp.example.com/certs",
+				ClientID:             "client",
+				ClientSecret:         "secret",
+				StrictSpecCompliance: true,
+			},
+			msg: "strict spec compliance without issuer",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:         "https://idp.example.com/auth",
+				TokenEndpoint:        "https://idp.example.com/token",
+				JWKSURI:              "https://idp.example.com/certs",
+				ClientID:             "client",
+				ClientSecret:         "secret",
+				Issuer:               "https://idp.example.com/realms/apps",
+				NonceEnforce:         createPointerFromBool(false),
+				StrictSpecCompliance: true,
+			},
+			msg: "strict spec compliance without nonce enforcement",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://sso.example.com/as/authorization.oauth2",
+				TokenEndpoint: "https://other.example.com/as/token.oauth2",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPType:       "pingfederate",
+			},
+			msg: "idp type pingfederate with token endpoint of another server",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://am.example.com/am/oauth2/realms/root/realms/alpha/authorize",
+				TokenEndpoint: "https://am.example.com/am/oauth2/realms/root/access_token",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				IdPType:       "forgerock",
+			},
+			msg: "idp type forgerock with token endpoint of another realm",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://accounts.google.com/o/oauth2/v2/auth",
+				TokenEndpoint: "https://oauth2.googleapis.com/token",
+				JWKSURI:       "https://www.googleapis.com/oauth2/v3/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				Google:        &v1.OIDCGoogle{HostedDomain: "Example.com&prompt=none"},
+			},
+			msg: "invalid google hosted domain",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://accounts.google.com/o/oauth2/v2/auth",
+				TokenEndpoint: "https://oauth2.googleapis.com/token",
+				JWKSURI:       "https://www.googleapis.com/oauth2/v3/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				Google:        &v1.OIDCGoogle{HostedDomain: "localhost"},
+			},
+			msg: "google hosted domain without a dot",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:        "https://example.okta.com/oauth2/v1/authorize",
+				TokenEndpoint:       "https://example.okta.com/oauth2/v1/token",
+				ClientID:            "client",
+				ClientSecret:        "secret",
+				IdPType:             "okta",
+				AccessTokenAudience: "api://default",
+			},
+			msg: "access token audience with okta org authorization server",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:        "https://idp.example.com/auth",
+				TokenEndpoint:       "https://idp.example.com/token",
+				JWKSURI:             "https://idp.example.com/certs",
+				ClientID:            "client",
+				ClientSecret:        "secret",
+				AccessTokenAudience: "api://default",
+			},
+			msg: "access token audience without idp type okta",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:        "https://example.okta.com/oauth2/default/v1/authorize",
+				TokenEndpoint:       "https://example.okta.com/oauth2/default/v1/token",
+				ClientID:            "client",
+				ClientSecret:        "secret",
+				IdPType:             "okta",
+				AccessTokenAudience: "api://$host",
+			},
+			msg: "invalid access token audience",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+			},
+			msg: "missing jwks uri without idp type keycloak",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:       "https://github.com/login/oauth/authorize",
+				TokenEndpoint:      "https://github.com/login/oauth/access_token",
+				OAuth2UserEndpoint: "https://api.github.com/user",
+				ClientID:           "client",
+				ClientSecret:       "secret",
+				IdPType:            "azuread",
+			},
+			msg: "idp type azuread with oauth2 user endpoint",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:       "https://idp.example.com/auth",
+				TokenEndpoint:      "https://idp.example.com/token",
+				JWKSURI:            "https://idp.example.com/certs",
+				ClientID:           "client",
+				ClientSecret:       "secret",
+				LoginRedirectPaths: []string{"https://app.example.com/"},
+			},
+			msg: "login redirect path with a host",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:       "https://idp.example.com/auth",
+				TokenEndpoint:      "https://idp.example.com/token",
+				JWKSURI:            "https://idp.example.com/certs",
+				ClientID:           "client",
+				ClientSecret:       "secret",
+				LoginRedirectPaths: []string{"//app.example.com/"},
+			},
+			msg: "protocol-relative login redirect path",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:       "https://idp.example.com/auth",
+				TokenEndpoint:      "https://idp.example.com/token",
+				JWKSURI:            "https://idp.example.com/certs",
+				ClientID:           "client",
+				ClientSecret:       "secret",
+				LoginRedirectPaths: []string{"/app; Domain=example.com"},
+			},
+			msg: "login redirect path with a cookie attribute",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:         "https://idp.example.com/auth",
+				TokenEndpoint:        "https://idp.example.com/token",
+				JWKSURI:              "https://idp.example.com/certs",
+				ClientID:             "client",
+				ClientSecret:         "secret",
+				UnauthorizedBehavior: &v1.OIDCUnauthorizedBehavior{Paths: []string{"api/"}},
+			},
+			msg: "relative unauthorized behavior path",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:      "https://idp.example.com/auth",
+				TokenEndpoint:     "https://idp.example.com/token",
+				JWKSURI:           "https://idp.example.com/certs",
+				ClientID:          "client",
+				ClientSecret:      "secret",
+				SessionInfoClaims: []string{"given name"},
+			},
+			msg: "session info claim with a space",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:        "https://idp.example.com/auth",
+				TokenEndpoint:       "https://idp.example.com/token",
+				JWKSURI:             "https://idp.example.com/certs",
+				ClientID:            "client",
+				ClientSecret:        "secret",
+				RefreshAheadSeconds: 60,
+				SessionStore: &v1.OIDCSessionStore{
+					Type:   "cookie",
+					Cookie: &v1.OIDCCookieSessionStore{KeySecret: "oidc-session-key"},
+				},
+			},
+			msg: "refresh ahead with a cookie session store",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				CookieDomain:  ".example.com",
+			},
+			msg: "invalid cookie domain",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				ClaimRules:    []v1.ClaimRule{{Claim: "groups"}},
+			},
+			msg: "claim rule without values",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				Resources:     []string{"api.example.com/orders"},
+			},
+			msg: "relative resource",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				Resources:     []string{"https://api.example.com/orders#v1"},
+			},
+			msg: "resource with fragment",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:      "https://login.microsoftonline.com/dd-fff-eee-1234-9be/oauth2/v2.0/authorize",
+				JWKSURI:           "https://login.microsoftonline.com/dd-fff-eee-1234-9be/discovery/v2.0/keys",
+				ClientID:          "ff",
+				ClientSecret:      "ff",
+				Scope:             "openid+profile",
+				AccessTokenEnable: true,
+			},
+			msg: "missing required field token",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:      "https://login.microsoftonline.com/dd-fff-eee-1234-9be/oauth2/v2.0/authorize",
+				TokenEndpoint:     "https://login.microsoftonline.com/dd-fff-eee-1234-9be/oauth2/v2.0/token",
+				ClientID:          "ff",
+				ClientSecret:      "ff",
+				Scope:             "openid+profile",
+				AccessTokenEnable: true,
+			},
+			msg: "missing required field jwk",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:      "https://login.microsoftonline.com/dd-fff-eee-1234-9be/oauth2/v2.0/authorize",
+				TokenEndpoint:     "https://login.microsoftonline.com/dd-fff-eee-1234-9be/oauth2/v2.0/token",
+				JWKSURI:           "https://login.microsoftonline.com/dd-fff-eee-1234-9be/discovery/v2.0/keys",
+				ClientSecret:      "ff",
 				Scope:             "openid+profile",
 				AccessTokenEnable: true,
 			},
@@ -1575,6 +3800,196 @@ func TestValidateOIDC_FailsOnInvalidOIDC(t *testing.T) {
 			},
 			msg: "invalid zoneSyncLeeway value",
 		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "idp/oidc/secret",
+				Scope:         "openid",
+			},
+			msg: "invalid client secret reference",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "IdP/oidc-secret",
+				Scope:         "openid",
+			},
+			msg: "invalid client secret namespace",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "oidc-secret",
+				Scope:         "openid",
+				VirtualServerSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "sso", Operator: metav1.LabelSelectorOpIn},
+					},
+				},
+			},
+			msg: "virtual server selector with an In expression without values",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "oidc-secret",
+				Scope:         "openid",
+				SessionStore:  &v1.OIDCSessionStore{Type: "redis"},
+			},
+			msg: "redis session store without redis",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "oidc-secret",
+				Scope:         "openid",
+				SessionStore:  &v1.OIDCSessionStore{Type: "memcached"},
+			},
+			msg: "unsupported session store type",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "oidc-secret",
+				Scope:         "openid",
+				SessionStore:  &v1.OIDCSessionStore{Redis: &v1.OIDCRedisSessionStore{Address: "redis:6379"}},
+			},
+			msg: "redis for a keyval session store",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "oidc-secret",
+				Scope:         "openid",
+				SessionStore:  &v1.OIDCSessionStore{Type: "redis", Redis: &v1.OIDCRedisSessionStore{Address: "redis"}},
+			},
+			msg: "redis address without a port",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "oidc-secret",
+				Scope:         "openid",
+				SessionStore:  &v1.OIDCSessionStore{Type: "redis", Redis: &v1.OIDCRedisSessionStore{Address: "redis:6379", TLS: &v1.OIDCRedisTLS{CASecret: "redis-ca"}}},
+			},
+			msg: "redis CA secret without TLS",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "oidc-secret",
+				Scope:         "openid",
+				SessionStore:  &v1.OIDCSessionStore{Type: "cookie"},
+			},
+			msg: "cookie session store without a key secret",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "oidc-secret",
+				Scope:         "openid",
+				SessionStore:  &v1.OIDCSessionStore{Type: "cookie", Cookie: &v1.OIDCCookieSessionStore{KeySecret: "Session_Keys"}},
+			},
+			msg: "invalid cookie key secret name",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "oidc-secret",
+				Scope:         "openid",
+				SessionStore:  &v1.OIDCSessionStore{Type: "cookie", Cookie: &v1.OIDCCookieSessionStore{KeySecret: "session-keys", PreviousKeyLifetime: "one day"}},
+			},
+			msg: "invalid cookie previous key lifetime",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "oidc-secret",
+				Scope:         "openid",
+				SessionStore:  &v1.OIDCSessionStore{Type: "redis", Redis: &v1.OIDCRedisSessionStore{Address: "redis:6379"}, Cookie: &v1.OIDCCookieSessionStore{KeySecret: "session-keys"}},
+			},
+			msg: "cookie for a redis session store",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				Introspection: &v1.OIDCIntrospection{Enable: true, Endpoint: "idp.example.com/introspect"},
+			},
+			msg: "introspection endpoint without a scheme",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				Introspection: &v1.OIDCIntrospection{Enable: true, CacheTTL: "2h"},
+			},
+			msg: "introspection cache ttl longer than 1h",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				Introspection: &v1.OIDCIntrospection{Enable: true, CacheMaxEntries: createPointerFromInt(0)},
+			},
+			msg: "introspection cache without entries",
+		},
+		{
+			oidc: &v1.OIDC{
+				AuthEndpoint:  "https://idp.example.com/auth",
+				TokenEndpoint: "https://idp.example.com/token",
+				JWKSURI:       "https://idp.example.com/certs",
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				Introspection: &v1.OIDCIntrospection{Enable: true, CacheMaxEntries: createPointerFromInt(2000000)},
+			},
+			msg: "introspection cache with too many entries",
+		},
 	}
 
 	for _, test := range tests {
@@ -2023,3 +4438,56 @@ func TestValidateWAF_FailsOnInvalidApLogBundle(t *testing.T) {
 		})
 	}
 }
+
+func TestValidatePolicyFIPS(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		oidc  *v1.OIDC
+		valid bool
+		msg   string
+	}{
+		{
+			oidc: &v1.OIDC{
+				ClientID:     "client",
+				ClientSecret: "oidc-secret",
+				ManagedKeys:  &v1.OIDCManagedKeys{Enable: true},
+			},
+			valid: true,
+			msg:   "managed keys",
+		},
+		{
+			oidc: &v1.OIDC{
+				ClientID:     "client",
+				ClientSecret: "oidc-secret",
+			},
+			msg: "no managed keys",
+		},
+		{
+			oidc: &v1.OIDC{
+				ClientID:     "client",
+				ClientSecret: "oidc-secret",
+				ManagedKeys:  &v1.OIDCManagedKeys{Enable: false},
+			},
+			msg: "disabled managed keys",
+		},
+		{
+			oidc: &v1.OIDC{
+				ClientID:           "client",
+				ClientSecret:       "oidc-secret",
+				OAuth2UserEndpoint: "https://api.github.com/user",
+				ManagedKeys:        &v1.OIDCManagedKeys{Enable: true},
+			},
+			msg: "oauth2 user endpoint",
+		},
+	}
+	for _, test := range tests {
+		err := ValidatePolicyFIPS(&v1.Policy{Spec: v1.PolicySpec{OIDC: test.oidc}})
+		if (err == nil) != test.valid {
+			t.Errorf("ValidatePolicyFIPS() returned %v for the case of %s, want valid %v", err, test.msg, test.valid)
+		}
+	}
+
+	if err := ValidatePolicyFIPS(&v1.Policy{Spec: v1.PolicySpec{RateLimit: &v1.RateLimit{Rate: "10r/s", Key: "${uri}", ZoneSize: "10M"}}}); err != nil {
+		t.Errorf("ValidatePolicyFIPS() returned %v for a policy without OIDC", err)
+	}
+}
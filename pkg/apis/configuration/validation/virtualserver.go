@@ -9,6 +9,8 @@ import (
 	"github.com/dlclark/regexp2"
 	"github.com/nginxinc/kubernetes-ingress/internal/configs"
 	v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1"
+	extdnsapi "github.com/nginxinc/kubernetes-ingress/pkg/apis/externaldns/v1"
+	extdnsvalidation "github.com/nginxinc/kubernetes-ingress/pkg/apis/externaldns/validation"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
@@ -23,6 +25,7 @@ type VirtualServerValidator struct {
 	isDosEnabled         bool
 	isCertManagerEnabled bool
 	isExternalDNSEnabled bool
+	externalDNSOptions   []extdnsvalidation.Option
 }
 
 // IsPlus modifies the VirtualServerValidator to set the isPlus option.
@@ -53,6 +56,14 @@ func IsExternalDNSEnabled(ed bool) VsvOption {
 	}
 }
 
+// ExternalDNSOptions modifies the VirtualServerValidator to set the options of the validation of the externalDNS
+// records, e.g. the TTL bounds of the DNS provider.
+func ExternalDNSOptions(opts ...extdnsvalidation.Option) VsvOption {
+	return func(v *VirtualServerValidator) {
+		v.externalDNSOptions = opts
+	}
+}
+
 // NewVirtualServerValidator creates a new VirtualServerValidator.
 func NewVirtualServerValidator(opts ...VsvOption) *VirtualServerValidator {
 	vsv := VirtualServerValidator{
@@ -205,6 +216,47 @@ func (vsv *VirtualServerValidator) validateExternalDNS(ed *v1.ExternalDNS, field
 	if !vsv.isExternalDNSEnabled {
 		return field.ErrorList{field.Forbidden(fieldPath, "field requires externalDNS enablement")}
 	}
+	// The properties are copied to the DNSEndpoint of the VirtualServer, external-dns ignores the unknown ones
+	properties := make(extdnsapi.ProviderSpecific, 0, len(ed.ProviderSpecific))
+	for _, p := range ed.ProviderSpecific {
+		properties = append(properties, extdnsapi.ProviderSpecificProperty{Name: p.Name, Value: p.Value})
+	}
+	allErrs := extdnsvalidation.ValidateTTL(extdnsapi.TTL(ed.RecordTTL), fieldPath.Child("recordTTL"), vsv.externalDNSOptions...)
+	allErrs = append(allErrs, extdnsvalidation.ValidateProviderSpecific(properties, fieldPath.Child("providerSpecific"), vsv.externalDNSOptions...)...)
+	allErrs = append(allErrs, extdnsvalidation.ValidateSetIdentifier(ed.SetIdentifier, properties, fieldPath)...)
+	allErrs = append(allErrs, extdnsvalidation.ValidateLabels(ed.Labels, fieldPath.Child("labels"))...)
+	return append(allErrs, validateExternalDNSHealthCheck(ed.HealthCheck, fieldPath.Child("healthCheck"))...)
+}
+
+func validateExternalDNSHealthCheck(hc *v1.ExternalDNSHealthCheck, fieldPath *field.Path) field.ErrorList {
+	if hc == nil || !hc.Enable {
+		return nil
+	}
+	allErrs := field.ErrorList{}
+	if hc.Port != 0 {
+		for _, msg := range validation.IsValidPortNum(hc.Port) {
+			allErrs = append(allErrs, field.Invalid(fieldPath.Child("port"), hc.Port, msg))
+		}
+	}
+	if hc.Path != "" {
+		allErrs = append(allErrs, validatePath(hc.Path, fieldPath.Child("path"))...)
+	}
+	allErrs = append(allErrs, validatePositiveTime(hc.Timeout, fieldPath.Child("timeout"))...)
+	return append(allErrs, validatePositiveTime(hc.Interval, fieldPath.Child("interval"))...)
+}
+
+// validatePositiveTime validates a time of at least one second.
+func validatePositiveTime(time string, fieldPath *field.Path) field.ErrorList {
+	if time == "" {
+		return nil
+	}
+	seconds, err := configs.ParseTimeSeconds(time)
+	if err != nil {
+		return field.ErrorList{field.Invalid(fieldPath, time, err.Error())}
+	}
+	if seconds < 1 {
+		return field.ErrorList{field.Invalid(fieldPath, time, "must be at least 1s")}
+	}
 	return nil
 }
 
@@ -781,10 +833,31 @@ func (vsv *VirtualServerValidator) validateRoute(route v1.Route, fieldPath *fiel
 	}
 
 	allErrs = append(allErrs, validateDos(vsv.isDosEnabled, route.Dos, fieldPath.Child("dos"))...)
+	allErrs = append(allErrs, validateTokenExchange(vsv.isPlus, route.TokenExchange, fieldPath.Child("tokenExchange"))...)
+	if route.StepUpRequired && !vsv.isPlus {
+		allErrs = append(allErrs, field.Forbidden(fieldPath.Child("stepUpRequired"), "step-up authentication is only supported in NGINX Plus"))
+	}
 
 	return allErrs
 }
 
+func validateTokenExchange(isPlus bool, tokenExchange *v1.TokenExchange, fieldPath *field.Path) field.ErrorList {
+	if tokenExchange == nil {
+		return nil
+	}
+	if !isPlus {
+		return field.ErrorList{field.Forbidden(fieldPath, "token exchange is only supported in NGINX Plus")}
+	}
+	if tokenExchange.Audience == "" {
+		return field.ErrorList{field.Required(fieldPath.Child("audience"), "")}
+	}
+	allErrs := field.ErrorList{}
+	for _, msg := range isValidHeaderValue(tokenExchange.Audience) {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("audience"), tokenExchange.Audience, msg))
+	}
+	return allErrs
+}
+
 func errorPageHasRequiredFields(errorPage v1.ErrorPage) bool {
 	var count int
 
@@ -1146,6 +1219,11 @@ var actionProxyHeaderVariables = map[string]bool{
 	"ssl_server_name":         true,
 	"ssl_session_id":          true,
 	"ssl_session_reused":      true,
+	"identity_auth_method":    true,
+	"identity_subject":        true,
+	"identity_issuer":         true,
+	"identity_session":        true,
+	"identity_claims":         true,
 }
 
 var actionProxyHeaderSpecialVariables = []string{"arg_", "http_", "cookie_", "jwt_claim_", "jwt_header_"}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1"
+	extdnsvalidation "github.com/nginxinc/kubernetes-ingress/pkg/apis/externaldns/validation"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
@@ -277,6 +278,42 @@ func TestValidateDosDisabled(t *testing.T) {
 	}
 }
 
+func TestValidateTokenExchange(t *testing.T) {
+	t.Parallel()
+	validAudiences := []string{
+		"orders-api",
+		"https://orders.example.com",
+	}
+
+	for _, aud := range validAudiences {
+		allErrs := validateTokenExchange(true, &v1.TokenExchange{Audience: aud}, field.NewPath("tokenExchange"))
+		if len(allErrs) > 0 {
+			t.Errorf("validateTokenExchange(%q) returned errors %v for valid input", aud, allErrs)
+		}
+	}
+
+	invalidAudiences := []string{
+		"",
+		"orders-$api",
+		`orders"api`,
+	}
+
+	for _, aud := range invalidAudiences {
+		allErrs := validateTokenExchange(true, &v1.TokenExchange{Audience: aud}, field.NewPath("tokenExchange"))
+		if len(allErrs) == 0 {
+			t.Errorf("validateTokenExchange(%q) returned no errors for invalid input", aud)
+		}
+	}
+}
+
+func TestValidateTokenExchangeFailsOnOSS(t *testing.T) {
+	t.Parallel()
+	allErrs := validateTokenExchange(false, &v1.TokenExchange{Audience: "orders-api"}, field.NewPath("tokenExchange"))
+	if len(allErrs) == 0 {
+		t.Error("validateTokenExchange() returned no errors for NGINX OSS")
+	}
+}
+
 func TestValidatePolicies(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -513,6 +550,98 @@ func TestValidateExternalDNSEnabled(t *testing.T) {
 	}
 }
 
+func TestValidateExternalDNSRecordTTL(t *testing.T) {
+	t.Parallel()
+	vsv := NewVirtualServerValidator(IsExternalDNSEnabled(true), ExternalDNSOptions(extdnsvalidation.Provider("cloudflare")))
+
+	extDNS := &v1.ExternalDNS{
+		Enable:    true,
+		RecordTTL: 300,
+	}
+	allErrs := vsv.validateExternalDNS(extDNS, field.NewPath("externalDNS"))
+	if len(allErrs) > 0 {
+		t.Errorf("validateExternalDNS() returned errors %v for valid input %v", allErrs, extDNS)
+	}
+
+	extDNS.RecordTTL = 30
+	allErrs = vsv.validateExternalDNS(extDNS, field.NewPath("externalDNS"))
+	if len(allErrs) != 1 {
+		t.Errorf("validateExternalDNS() returned errors %v for a TTL below the minimum of the provider, want 1 error", allErrs)
+	}
+}
+
+func TestValidateExternalDNSProviderSpecific(t *testing.T) {
+	t.Parallel()
+	vsv := &VirtualServerValidator{isPlus: false, isExternalDNSEnabled: true}
+
+	extDNS := &v1.ExternalDNS{
+		Enable:        true,
+		SetIdentifier: "blue",
+		ProviderSpecific: v1.ProviderSpecific{
+			{Name: "aws/weight", Value: "10"},
+			{Name: "external-dns.alpha.kubernetes.io/cloudflare-proxied", Value: "true"},
+			{Name: "other-provider/property", Value: "value"},
+		},
+	}
+	allErrs := vsv.validateExternalDNS(extDNS, field.NewPath("externalDNS"))
+	if len(allErrs) > 0 {
+		t.Errorf("validateExternalDNS() returned errors %v for valid input %v", allErrs, extDNS)
+	}
+
+	extDNS.SetIdentifier = ""
+	allErrs = vsv.validateExternalDNS(extDNS, field.NewPath("externalDNS"))
+	if len(allErrs) != 1 {
+		t.Errorf("validateExternalDNS() returned errors %v for a weighted record without a set identifier, want 1 error", allErrs)
+	}
+
+	extDNS.ProviderSpecific = v1.ProviderSpecific{
+		{Name: "aws/wieght", Value: "10"},
+		{Name: "aws/evaluate-target-health", Value: "yes"},
+	}
+	allErrs = vsv.validateExternalDNS(extDNS, field.NewPath("externalDNS"))
+	if len(allErrs) != 2 {
+		t.Errorf("validateExternalDNS() returned errors %v for invalid input %v, want 2 errors", allErrs, extDNS)
+	}
+}
+
+func TestValidateExternalDNSHealthCheck(t *testing.T) {
+	t.Parallel()
+	vsv := &VirtualServerValidator{isPlus: false, isExternalDNSEnabled: true}
+
+	extDNS := &v1.ExternalDNS{
+		Enable: true,
+		HealthCheck: &v1.ExternalDNSHealthCheck{
+			Enable:   true,
+			Port:     8080,
+			Path:     "/healthz",
+			Timeout:  "2s",
+			Interval: "1m",
+		},
+	}
+	allErrs := vsv.validateExternalDNS(extDNS, field.NewPath("externalDNS"))
+	if len(allErrs) > 0 {
+		t.Errorf("validateExternalDNS() returned errors %v for valid input %v", allErrs, extDNS)
+	}
+
+	extDNS.HealthCheck = &v1.ExternalDNSHealthCheck{
+		Enable:   true,
+		Port:     70000,
+		Path:     "healthz",
+		Timeout:  "500ms",
+		Interval: "often",
+	}
+	allErrs = vsv.validateExternalDNS(extDNS, field.NewPath("externalDNS"))
+	if len(allErrs) != 4 {
+		t.Errorf("validateExternalDNS() returned errors %v for invalid input %v, want 4 errors", allErrs, extDNS)
+	}
+
+	extDNS.HealthCheck.Enable = false
+	allErrs = vsv.validateExternalDNS(extDNS, field.NewPath("externalDNS"))
+	if len(allErrs) > 0 {
+		t.Errorf("validateExternalDNS() returned errors %v for a disabled health check", allErrs)
+	}
+}
+
 func TestValidateUpstreams(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -1206,6 +1335,20 @@ func TestValidateRouteFails(t *testing.T) {
 			isRouteFieldForbidden: true,
 			msg:                   "route field exists but is forbidden",
 		},
+		{
+			route: v1.Route{
+				Path: "/",
+				Action: &v1.Action{
+					Pass: "test",
+				},
+				StepUpRequired: true,
+			},
+			upstreamNames: map[string]sets.Empty{
+				"test": {},
+			},
+			isRouteFieldForbidden: false,
+			msg:                   "step-up authentication in NGINX OSS",
+		},
 	}
 
 	vsv := &VirtualServerValidator{isPlus: false}
@@ -3911,6 +4054,10 @@ func TestValidateActionProxyRequestHeaders(t *testing.T) {
 				Name:  "user",
 				Value: "${http_user}",
 			},
+			{
+				Name:  "X-User",
+				Value: "${identity_subject}",
+			},
 		},
 	}
 
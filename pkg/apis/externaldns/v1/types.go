@@ -17,13 +17,59 @@ type DNSEndpoint struct {
 	Status DNSEndpointStatus `json:"status,omitempty"`
 }
 
-// DNSEndpointStatus represents generation observed by the external dns controller.
+// DNSEndpointStatus represents the generation observed by the external dns controller, the conditions and the sync
+// state of the records.
 type DNSEndpointStatus struct {
 	// The generation observed by by the external-dns controller.
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions of the DNSEndpoint: Valid, whether the records are valid, and Programmed, whether external-dns
+	// synced the records of the current generation to the DNS provider.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Records is the sync state of every record: Invalid, when the record failed the validation and is skipped by
+	// external-dns, otherwise Synced or Pending, whether external-dns observed the current generation.
+	// +optional
+	Records []RecordStatus `json:"records,omitempty"`
+}
+
+// RecordStatus represents the sync state of a record of the DNSEndpoint.
+type RecordStatus struct {
+	// The hostname of the record
+	DNSName string `json:"dnsName"`
+
+	// The type of the record
+	RecordType string `json:"recordType"`
+
+	// The identifier of the record
+	// +optional
+	SetIdentifier string `json:"setIdentifier,omitempty"`
+
+	// State of the record: Synced, Pending or Invalid
+	State string `json:"state"`
+
+	// Message lists the validation errors of an invalid record
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
+// States of the records of a DNSEndpoint.
+const (
+	RecordStateSynced  = "Synced"
+	RecordStatePending = "Pending"
+	RecordStateInvalid = "Invalid"
+)
+
+// Conditions of a DNSEndpoint.
+const (
+	ConditionValid      = "Valid"
+	ConditionProgrammed = "Programmed"
+)
+
 // DNSEndpointSpec holds information about endpoints.
 type DNSEndpointSpec struct {
 	Endpoints []*Endpoint `json:"endpoints,omitempty"`
@@ -43,6 +89,10 @@ type Endpoint struct {
 	// TTL for the record
 	RecordTTL TTL `json:"recordTTL,omitempty"`
 
+	// Identifier to distinguish multiple records with the same name and type (e.g. Route53 records with routing policies other than simple)
+	// +optional
+	SetIdentifier string `json:"setIdentifier,omitempty"`
+
 	// Labels stores labels defined for the Endpoint
 	// +optional
 	Labels Labels `json:"labels,omitempty"`
@@ -1,8 +1,11 @@
 package validation
 
 import (
-	"errors"
 	"fmt"
+	"net/netip"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"golang.org/x/exp/slices"
@@ -12,98 +15,696 @@ import (
 	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
+// Option modifies the validation of a DNSEndpoint.
+type Option func(*options)
+
+type options struct {
+	allowWildcards         bool
+	strictProviderSpecific bool
+	provider               string
+	minTTL                 v1.TTL
+	maxTTL                 v1.TTL
+}
+
+const (
+	// OwnerLabel is the label of an endpoint with the owner ID of the external-dns instance that manages its records.
+	OwnerLabel = "owner"
+	// ResourceLabel is the label of an endpoint with the resource of the endpoint, set by external-dns.
+	ResourceLabel = "resource"
+)
+
+const (
+	// maxTXTStringLength is the maximum length of a string of a TXT record.
+	maxTXTStringLength = 255
+	// maxTXTRecordLength is the maximum length of all the strings of a TXT record.
+	maxTXTRecordLength = 4000
+)
+
+const (
+	// DefaultMinTTL is the minimum TTL of the records, unless the DNS provider requires a longer TTL.
+	DefaultMinTTL v1.TTL = 1
+	// DefaultMaxTTL is the maximum TTL of the records, a week, unless the DNS provider limits the TTL further.
+	DefaultMaxTTL v1.TTL = 604800
+)
+
+// AllowWildcards sets whether the DNS names can start with a wildcard label, e.g. *.example.com.
+// Wildcards are allowed by default.
+func AllowWildcards(allow bool) Option {
+	return func(o *options) {
+		o.allowWildcards = allow
+	}
+}
+
+// StrictProviderSpecific sets whether the provider specific properties are limited to the properties of the known
+// providers. By default, the properties of other providers are allowed, and only the properties of the known
+// providers are validated.
+func StrictProviderSpecific(strict bool) Option {
+	return func(o *options) {
+		o.strictProviderSpecific = strict
+	}
+}
+
+// Provider sets the TTL bounds of the records to the bounds of the DNS provider, e.g. cloudflare, rejects the
+// ALIAS records for the providers that create alias records otherwise, and, for the known providers, rejects the
+// provider specific properties of the other known providers, which the provider ignores.
+// The providers without known bounds use DefaultMinTTL and DefaultMaxTTL.
+func Provider(name string) Option {
+	return func(o *options) {
+		o.provider = name
+		o.minTTL, o.maxTTL = DefaultMinTTL, DefaultMaxTTL
+		if bounds, exists := providerTTLBounds[name]; exists {
+			o.minTTL, o.maxTTL = bounds[0], bounds[1]
+		}
+	}
+}
+
+// TTLBounds sets the minimum and the maximum TTL of the records. A bound of 0 keeps the bound of the provider.
+func TTLBounds(minTTL v1.TTL, maxTTL v1.TTL) Option {
+	return func(o *options) {
+		if minTTL > 0 {
+			o.minTTL = minTTL
+		}
+		if maxTTL > 0 {
+			o.maxTTL = maxTTL
+		}
+	}
+}
+
 // ValidateDNSEndpoint validates if all DNSEndpoint fields are valid.
-func ValidateDNSEndpoint(dnsendpoint *v1.DNSEndpoint) error {
-	return validateDNSEndpointSpec(&dnsendpoint.Spec)
+// It returns the errors of all the fields of all the endpoints.
+func ValidateDNSEndpoint(dnsendpoint *v1.DNSEndpoint, opts ...Option) field.ErrorList {
+	return validateDNSEndpointSpec(&dnsendpoint.Spec, field.NewPath("spec"), newOptions(opts))
 }
 
-func validateDNSEndpointSpec(es *v1.DNSEndpointSpec) error {
+func newOptions(opts []Option) options {
+	o := options{
+		allowWildcards: true,
+		minTTL:         DefaultMinTTL,
+		maxTTL:         DefaultMaxTTL,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func validateDNSEndpointSpec(es *v1.DNSEndpointSpec, fieldPath *field.Path, o options) field.ErrorList {
 	if len(es.Endpoints) == 0 {
-		return fmt.Errorf("%w: no endpoints supplied, expected a list of endpoints", ErrTypeRequired)
+		return field.ErrorList{field.Required(fieldPath.Child("endpoints"), "expected a list of endpoints")}
+	}
+	allErrs := field.ErrorList{}
+	// The DNS provider keeps one record for every name, type and set identifier, the duplicates overwrite each other
+	type recordKey struct {
+		dnsName       string
+		recordType    string
+		setIdentifier string
 	}
-	for _, endpoint := range es.Endpoints {
-		if err := validateEndpoint(endpoint); err != nil {
-			return err
+	records := make(map[recordKey]int)
+	names := make(map[string][]int)
+	for i, endpoint := range es.Endpoints {
+		idxPath := fieldPath.Child("endpoints").Index(i)
+		if endpoint == nil {
+			allErrs = append(allErrs, field.Required(idxPath, ""))
+			continue
+		}
+		allErrs = append(allErrs, validateEndpoint(endpoint, idxPath, o)...)
+
+		key := recordKey{
+			dnsName:       endpoint.DNSName,
+			recordType:    endpoint.RecordType,
+			setIdentifier: endpoint.SetIdentifier,
+		}
+		if first, exists := records[key]; exists {
+			allErrs = append(allErrs, field.Duplicate(idxPath, fmt.Sprintf("the record of endpoint %d with dnsName %s, recordType %s and setIdentifier %q",
+				first, endpoint.DNSName, endpoint.RecordType, endpoint.SetIdentifier)))
+			continue
+		}
+		records[key] = i
+
+		for _, j := range names[endpoint.DNSName] {
+			if err := validateCoexistingRecords(endpoint, j, es.Endpoints[j], idxPath.Child("recordType")); err != nil {
+				allErrs = append(allErrs, err)
+				break
+			}
 		}
+		names[endpoint.DNSName] = append(names[endpoint.DNSName], i)
 	}
-	return nil
+	return allErrs
 }
 
-func validateEndpoint(e *v1.Endpoint) error {
-	if err := validateDNSName(e.DNSName); err != nil {
-		return err
+// Kinds of records for the records of the same name.
+const (
+	recordKindCNAME   = "CNAME"
+	recordKindAlias   = "alias"
+	recordKindAddress = "address"
+	recordKindOther   = "other"
+)
+
+// recordKind returns the kind of a record: a CNAME record, an alias record, which is an ALIAS record or a CNAME record
+// with the alias provider specific property, an address record or another record.
+func recordKind(e *v1.Endpoint) string {
+	switch e.RecordType {
+	case "ALIAS":
+		return recordKindAlias
+	case "CNAME":
+		for _, property := range e.ProviderSpecific {
+			if property.Name == "alias" && property.Value == "true" {
+				return recordKindAlias
+			}
+		}
+		return recordKindCNAME
+	case "A", "AAAA":
+		return recordKindAddress
 	}
-	if err := validateTargets(e.Targets); err != nil {
-		return err
+	return recordKindOther
+}
+
+// validateCoexistingRecords validates that the record of an endpoint can coexist with the record of another endpoint
+// with the same name. A CNAME record can't coexist with other records (RFC 1034), and an alias record, which the DNS
+// provider resolves to the addresses of its target, e.g. at the apex of a zone, can't coexist with address records or
+// other alias records. The records with a set identifier of the same kind can coexist, they are the records of
+// a routing policy.
+func validateCoexistingRecords(e *v1.Endpoint, otherIndex int, other *v1.Endpoint, fieldPath *field.Path) *field.Error {
+	kind, otherKind := recordKind(e), recordKind(other)
+	if kind == otherKind && e.SetIdentifier != "" && other.SetIdentifier != "" {
+		return nil
 	}
-	if err := validateDNSRecordType(e.RecordType); err != nil {
-		return err
+	conflict := kind == recordKindCNAME || otherKind == recordKindCNAME ||
+		(kind == recordKindAlias && (otherKind == recordKindAlias || otherKind == recordKindAddress)) ||
+		(otherKind == recordKindAlias && kind == recordKindAddress)
+	if !conflict {
+		return nil
 	}
-	return validateTTL(e.RecordTTL)
+	return field.Invalid(fieldPath, e.RecordType, fmt.Sprintf("a %s record can't coexist with the %s record of endpoint %d with the same dnsName %s",
+		describeRecord(e, kind), describeRecord(other, otherKind), otherIndex, e.DNSName))
 }
 
-func validateDNSName(name string) error {
-	if issues := validation.IsDNS1123Subdomain(name); len(issues) > 0 {
-		return fmt.Errorf("%w: name %s, %s", ErrTypeInvalid, name, strings.Join(issues, ", "))
+func describeRecord(e *v1.Endpoint, kind string) string {
+	if kind == recordKindAlias && e.RecordType == "CNAME" {
+		return "CNAME alias"
 	}
-	return nil
+	return e.RecordType
 }
 
-func validateTargets(targets v1.Targets) error {
-	for _, target := range targets {
-		switch {
-		case strings.Contains(target, ":"):
-			if errMsg := validation.IsValidIP(field.NewPath(""), target); len(errMsg) > 0 {
-				return fmt.Errorf("%w: target %q is invalid: %s", ErrTypeInvalid, target, errMsg[0])
+func validateEndpoint(e *v1.Endpoint, fieldPath *field.Path, o options) field.ErrorList {
+	allErrs := validateDNSName(e.RecordType, e.DNSName, fieldPath.Child("dnsName"), o.allowWildcards)
+	// The targets are only validated for a supported record type, their format depends on the type
+	if err := validateDNSRecordType(e.RecordType, fieldPath.Child("recordType")); err != nil {
+		allErrs = append(allErrs, err)
+	} else if msg, unsupported := unsupportedAliasProviders[o.provider]; e.RecordType == "ALIAS" && unsupported {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("recordType"), e.RecordType, msg))
+	} else {
+		allErrs = append(allErrs, validateTargets(e.RecordType, e.Targets, fieldPath.Child("targets"))...)
+	}
+	allErrs = append(allErrs, validateTTL(e.RecordTTL, fieldPath.Child("recordTTL"), o)...)
+	allErrs = append(allErrs, validateProviderSpecific(e.ProviderSpecific, fieldPath.Child("providerSpecific"), o)...)
+	allErrs = append(allErrs, ValidateLabels(e.Labels, fieldPath.Child("labels"))...)
+	return append(allErrs, ValidateSetIdentifier(e.SetIdentifier, e.ProviderSpecific, fieldPath)...)
+}
+
+// ValidateLabels validates the labels of an endpoint. external-dns stores the labels in the TXT records of its
+// registry as comma separated key=value pairs, so the keys are qualified names and the values contain neither commas
+// nor equal signs. The owner label, which tells which external-dns instance manages the records, must be a label
+// value, and the resource label is reserved for external-dns, which sets it to the resource of the endpoint.
+func ValidateLabels(labels v1.Labels, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := labels[key]
+		keyPath := fieldPath.Key(key)
+		for _, msg := range validation.IsQualifiedName(key) {
+			allErrs = append(allErrs, field.Invalid(keyPath, key, msg))
+		}
+		switch key {
+		case OwnerLabel:
+			for _, msg := range validation.IsValidLabelValue(value) {
+				allErrs = append(allErrs, field.Invalid(keyPath, value, msg))
+			}
+			if value == "" {
+				allErrs = append(allErrs, field.Required(keyPath, "the owner of the records"))
 			}
+		case ResourceLabel:
+			allErrs = append(allErrs, field.Forbidden(keyPath, "the label is set by external-dns"))
 		default:
-			if err := isFullyQualifiedDomainName(target); err != nil {
-				return fmt.Errorf("%w: target %q is invalid, it should be a valid IP address or hostname", ErrTypeInvalid, target)
+			if strings.ContainsAny(value, ",=") {
+				allErrs = append(allErrs, field.Invalid(keyPath, value, "must not contain ',' or '='"))
+			}
+		}
+	}
+	return allErrs
+}
+
+// ValidateSetIdentifier validates the set identifier of an endpoint together with the Route53 routing policy of its
+// provider specific properties: a record with a weighted, latency, failover, geolocation or multivalue answer
+// routing policy needs a set identifier, a set identifier needs a routing policy, and a record has only one
+// routing policy. The fieldPath is the path of the endpoint.
+func ValidateSetIdentifier(setIdentifier string, properties v1.ProviderSpecific, fieldPath *field.Path) field.ErrorList {
+	var policies []string
+	for _, property := range properties {
+		if policy, exists := routingPolicies[property.Name]; exists && !slices.Contains(policies, policy) {
+			policies = append(policies, policy)
+		}
+	}
+
+	allErrs := field.ErrorList{}
+	switch {
+	case len(policies) > 1:
+		allErrs = append(allErrs, field.Forbidden(fieldPath.Child("providerSpecific"),
+			fmt.Sprintf("a record can have only one routing policy, got %s", strings.Join(policies, ", "))))
+	case len(policies) == 1 && setIdentifier == "":
+		allErrs = append(allErrs, field.Required(fieldPath.Child("setIdentifier"),
+			fmt.Sprintf("required for a record with a %s routing policy", policies[0])))
+	case len(policies) == 0 && setIdentifier != "":
+		allErrs = append(allErrs, field.Forbidden(fieldPath.Child("setIdentifier"),
+			"requires a routing policy in the provider specific properties, e.g. aws/weight"))
+	}
+	return allErrs
+}
+
+// ValidateProviderSpecific validates the provider specific properties of an endpoint. The names of the properties
+// of the known providers, e.g. aws/weight, must be properties of the provider, so that a typo is not ignored by
+// external-dns, and their values must be valid for the provider. Azure has no provider specific properties.
+func ValidateProviderSpecific(properties v1.ProviderSpecific, fieldPath *field.Path, opts ...Option) field.ErrorList {
+	return validateProviderSpecific(properties, fieldPath, newOptions(opts))
+}
+
+func validateProviderSpecific(properties v1.ProviderSpecific, fieldPath *field.Path, o options) field.ErrorList {
+	allErrs := field.ErrorList{}
+	names := make(map[string]bool)
+	for i, property := range properties {
+		idxPath := fieldPath.Index(i)
+		if property.Name == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("name"), ""))
+			continue
+		}
+		if names[property.Name] {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), property.Name))
+			continue
+		}
+		names[property.Name] = true
+
+		validateValue, known := providerSpecificProperties[property.Name]
+		if !known {
+			if o.strictProviderSpecific || propertyProvider(property.Name) != "" {
+				allErrs = append(allErrs, field.NotSupported(idxPath.Child("name"), property.Name, providerSpecificNames()))
 			}
+			continue
+		}
+		if provider := propertyProvider(property.Name); slices.Contains(knownProviders, o.provider) && provider != o.provider {
+			allErrs = append(allErrs, field.Forbidden(idxPath.Child("name"),
+				fmt.Sprintf("a property of the %s provider is ignored by the %s provider", provider, o.provider)))
+			continue
+		}
+		if msg := validateValue(property.Value); msg != "" {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("value"), property.Value, msg))
 		}
 	}
-	return isUnique(targets)
+	return allErrs
 }
 
-func isUnique(targets v1.Targets) error {
+// propertyProvider returns the known provider of a provider specific property, or an empty string for the
+// properties of other providers.
+func propertyProvider(name string) string {
+	if name == "alias" {
+		return "aws"
+	}
+	for prefix, provider := range providerSpecificPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return provider
+		}
+	}
+	return ""
+}
+
+func providerSpecificNames() []string {
+	names := make([]string, 0, len(providerSpecificProperties))
+	for name := range providerSpecificProperties {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func validateBoolValue(value string) string {
+	if value != "true" && value != "false" {
+		return "must be true or false"
+	}
+	return ""
+}
+
+func validateNonEmptyValue(value string) string {
+	if value == "" {
+		return "must not be empty"
+	}
+	return ""
+}
+
+func validateAnyValue(string) string {
+	return ""
+}
+
+func validateDNSName(record string, name string, fieldPath *field.Path, allowWildcards bool) field.ErrorList {
+	if record == "PTR" {
+		if err := validateReverseName(name); err != nil {
+			return field.ErrorList{field.Invalid(fieldPath, name, err.Error())}
+		}
+		return nil
+	}
+	subdomain := name
+	// A single leading wildcard label, e.g. *.example.com, matches the names of the subdomain
+	if rest, found := strings.CutPrefix(name, "*."); found {
+		if !allowWildcards {
+			return field.ErrorList{field.Forbidden(fieldPath, "wildcard names are not allowed")}
+		}
+		subdomain = rest
+	} else if record == "SRV" {
+		// The name of an SRV record starts with the service and the protocol, e.g. _sip._udp.example.com
+		labels := strings.SplitN(name, ".", 3)
+		if len(labels) != 3 || !isServiceLabel(labels[0]) || !isServiceLabel(labels[1]) {
+			return field.ErrorList{field.Invalid(fieldPath, name, "the name of an SRV record should start with the service and the protocol, e.g. _sip._udp.example.com")}
+		}
+		subdomain = labels[2]
+	} else if record == "TXT" {
+		// The name of a TXT record can contain underscore labels, e.g. _dmarc.example.com or
+		// selector._domainkey.example.com
+		labels := strings.Split(name, ".")
+		for i, label := range labels {
+			if isServiceLabel(label) {
+				labels[i] = label[1:]
+			}
+		}
+		subdomain = strings.Join(labels, ".")
+	}
+	if issues := validation.IsDNS1123Subdomain(subdomain); len(issues) > 0 {
+		return field.ErrorList{field.Invalid(fieldPath, name, strings.Join(issues, ", "))}
+	}
+	return nil
+}
+
+// validateReverseName validates the name of a PTR record, the name of an IPv4 address in in-addr.arpa with a
+// decimal byte in every label, e.g. 3.2.2.10.in-addr.arpa, or of an IPv6 address in ip6.arpa with a hexadecimal digit
+// in every label. The names of networks, e.g. 2.10.in-addr.arpa, have fewer labels.
+func validateReverseName(name string) error {
+	name = strings.TrimSuffix(name, ".")
+	if address, found := strings.CutSuffix(name, ".in-addr.arpa"); found {
+		labels := strings.Split(address, ".")
+		if len(labels) > 4 {
+			return fmt.Errorf("name %s has more than 4 labels before in-addr.arpa", name)
+		}
+		for _, label := range labels {
+			if n, err := strconv.ParseUint(label, 10, 8); err != nil || strconv.FormatUint(n, 10) != label {
+				return fmt.Errorf("label %q of name %s must be a number between 0 and 255", label, name)
+			}
+		}
+		return nil
+	}
+	if address, found := strings.CutSuffix(name, ".ip6.arpa"); found {
+		labels := strings.Split(address, ".")
+		if len(labels) > 32 {
+			return fmt.Errorf("name %s has more than 32 labels before ip6.arpa", name)
+		}
+		for _, label := range labels {
+			if _, err := strconv.ParseUint(label, 16, 4); err != nil || len(label) != 1 {
+				return fmt.Errorf("label %q of name %s must be a hexadecimal digit", label, name)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("the name of a PTR record must be in in-addr.arpa or ip6.arpa, e.g. 3.2.2.10.in-addr.arpa")
+}
+
+func isServiceLabel(label string) bool {
+	service, found := strings.CutPrefix(label, "_")
+	return found && len(validation.IsDNS1123Label(service)) == 0
+}
+
+// validateTargets validates the targets of a record: A records point to IPv4 addresses, AAAA records to IPv6
+// addresses, CNAME, NS, ALIAS and PTR records to hostnames, and the SRV, MX, CAA, NAPTR and TXT records to targets in
+// their format.
+func validateTargets(record string, targets v1.Targets, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
 	occurred := make(map[string]bool)
-	for _, target := range targets {
+	for i, target := range targets {
+		idxPath := fieldPath.Index(i)
 		if occurred[target] {
-			return fmt.Errorf("%w: target %s, expected unique targets", ErrTypeDuplicated, target)
+			allErrs = append(allErrs, field.Duplicate(idxPath, target))
+			continue
 		}
 		occurred[target] = true
+
+		var err *field.Error
+		switch record {
+		case "A":
+			if addr, parseErr := netip.ParseAddr(target); parseErr != nil || !addr.Is4() {
+				err = field.Invalid(idxPath, target, "must be a valid IPv4 address for an A record")
+			}
+		case "AAAA":
+			if addr, parseErr := netip.ParseAddr(target); parseErr != nil || !addr.Is6() || addr.Is4In6() || addr.Zone() != "" {
+				err = field.Invalid(idxPath, target, "must be a valid IPv6 address for an AAAA record")
+			}
+		case "SRV":
+			err = validateSRVTarget(target, idxPath)
+		case "MX":
+			err = validateMXTarget(target, idxPath)
+		case "CAA":
+			err = validateCAATarget(target, idxPath)
+		case "NAPTR":
+			err = validateNAPTRTarget(target, idxPath)
+		case "TXT":
+			err = validateTXTTarget(target, idxPath)
+		default:
+			err = validateTargetHost(record, target, target, idxPath)
+		}
+		if err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+	return allErrs
+}
+
+// validateSRVTarget validates an SRV target, e.g. "10 5 5060 sip.example.com". The priority, weight and port are
+// 16-bit unsigned integers, and the host is a hostname, or "." if the service is not available (RFC 2782).
+func validateSRVTarget(target string, fieldPath *field.Path) *field.Error {
+	fields := strings.Fields(target)
+	if len(fields) != 4 {
+		return field.Invalid(fieldPath, target, `must be in the format "priority weight port host" for an SRV record`)
+	}
+	if err := validateTargetNumbers(target, fields, fieldPath, "priority", "weight", "port"); err != nil {
+		return err
+	}
+	if fields[3] == "." {
+		return nil
+	}
+	return validateTargetHost("SRV", target, fields[3], fieldPath)
+}
+
+// validateMXTarget validates an MX target, e.g. "10 mail.example.com". The preference is a 16-bit unsigned integer,
+// and the host is a hostname (RFC 1035).
+func validateMXTarget(target string, fieldPath *field.Path) *field.Error {
+	fields := strings.Fields(target)
+	if len(fields) != 2 {
+		return field.Invalid(fieldPath, target, `must be in the format "preference host" for an MX record`)
+	}
+	if err := validateTargetNumbers(target, fields, fieldPath, "preference"); err != nil {
+		return err
+	}
+	return validateTargetHost("MX", target, fields[1], fieldPath)
+}
+
+// validateCAATarget validates a CAA target, e.g. `0 issue "letsencrypt.org"`. The flags are an 8-bit unsigned
+// integer, the tag is made of up to 15 letters and digits, and the value is a quoted string (RFC 8659).
+func validateCAATarget(target string, fieldPath *field.Path) *field.Error {
+	fields := strings.SplitN(strings.TrimSpace(target), " ", 3)
+	if len(fields) != 3 {
+		return field.Invalid(fieldPath, target, `must be in the format "flags tag value" for a CAA record`)
+	}
+	if _, err := strconv.ParseUint(fields[0], 10, 8); err != nil {
+		return field.Invalid(fieldPath, target, fmt.Sprintf("invalid flags %q, must be a number between 0 and 255", fields[0]))
+	}
+	if !caaTagRegexp.MatchString(fields[1]) {
+		return field.Invalid(fieldPath, target, fmt.Sprintf("invalid tag %q, must be made of up to 15 letters and digits", fields[1]))
+	}
+	if !isQuoted(fields[2]) {
+		return field.Invalid(fieldPath, target, fmt.Sprintf("invalid value %s, must be a quoted string", fields[2]))
+	}
+	return nil
+}
+
+// validateNAPTRTarget validates a NAPTR target, e.g. `100 10 "S" "SIP+D2U" "" _sip._udp.example.com`. The order and
+// the preference are 16-bit unsigned integers, the flags, the service and the regexp are quoted strings, and the
+// replacement is a domain name, or "." if the regexp is used instead (RFC 3403).
+func validateNAPTRTarget(target string, fieldPath *field.Path) *field.Error {
+	fields := strings.Fields(target)
+	if len(fields) != 6 {
+		return field.Invalid(fieldPath, target, `must be in the format "order preference flags service regexp replacement" for a NAPTR record`)
+	}
+	if err := validateTargetNumbers(target, fields, fieldPath, "order", "preference"); err != nil {
+		return err
+	}
+	for i, name := range []string{"flags", "service", "regexp"} {
+		if !isQuoted(fields[i+2]) {
+			return field.Invalid(fieldPath, target, fmt.Sprintf("invalid %s %s, must be a quoted string", name, fields[i+2]))
+		}
+	}
+	replacement := fields[5]
+	if replacement == "." {
+		return nil
+	}
+	// The replacement often starts with the service and the protocol, like the name of an SRV record
+	for strings.HasPrefix(replacement, "_") {
+		label, rest, _ := strings.Cut(replacement, ".")
+		if !isServiceLabel(label) {
+			return field.Invalid(fieldPath, target, "invalid replacement, must be a valid domain name")
+		}
+		replacement = rest
+	}
+	return validateTargetHost("NAPTR", target, replacement, fieldPath)
+}
+
+// validateTXTTarget validates a TXT target, which is a text, e.g. "v=spf1 include:_spf.example.com ~all", or a list of
+// quoted strings, e.g. `"v=DKIM1; k=rsa; p=MIIB..." "...IDAQAB"`. Every string is printable ASCII of up to 255 characters
+// (RFC 1035), and the strings of the record are up to 4000 characters together, the limit of Route53.
+func validateTXTTarget(target string, fieldPath *field.Path) *field.Error {
+	chunks := []string{target}
+	if strings.HasPrefix(target, `"`) {
+		var err error
+		if chunks, err = splitTXTStrings(target); err != nil {
+			return field.Invalid(fieldPath, target, err.Error())
+		}
+	}
+	size := 0
+	for _, chunk := range chunks {
+		if len(chunk) > maxTXTStringLength {
+			return field.Invalid(fieldPath, target, fmt.Sprintf("a string of a TXT record must be at most %d characters, split it into quoted strings", maxTXTStringLength))
+		}
+		for _, c := range chunk {
+			if c < ' ' || c > '~' {
+				return field.Invalid(fieldPath, target, fmt.Sprintf("invalid character %q, a TXT record must be printable ASCII", c))
+			}
+		}
+		size += len(chunk)
+	}
+	if size > maxTXTRecordLength {
+		return field.Invalid(fieldPath, target, fmt.Sprintf("a TXT record must be at most %d characters", maxTXTRecordLength))
 	}
 	return nil
 }
 
-func validateDNSRecordType(record string) error {
+// splitTXTStrings splits a list of quoted strings separated by spaces, in which quotes and backslashes are escaped
+// with a backslash, into the unquoted strings.
+func splitTXTStrings(target string) ([]string, error) {
+	var chunks []string
+	rest := target
+	for rest != "" {
+		if rest[0] != '"' {
+			return nil, fmt.Errorf("the strings of a TXT record must be quoted and separated by spaces")
+		}
+		var chunk strings.Builder
+		i := 1
+		for ; i < len(rest) && rest[i] != '"'; i++ {
+			if rest[i] == '\\' && i+1 < len(rest) {
+				i++
+			}
+			chunk.WriteByte(rest[i])
+		}
+		if i == len(rest) {
+			return nil, fmt.Errorf("unterminated quoted string")
+		}
+		chunks = append(chunks, chunk.String())
+		rest = rest[i+1:]
+		trimmed := strings.TrimLeft(rest, " ")
+		if trimmed != "" && trimmed == rest {
+			return nil, fmt.Errorf("the strings of a TXT record must be separated by spaces")
+		}
+		rest = trimmed
+	}
+	return chunks, nil
+}
+
+// validateTargetNumbers validates the leading fields of a target, which are 16-bit unsigned integers.
+func validateTargetNumbers(target string, fields []string, fieldPath *field.Path, names ...string) *field.Error {
+	for i, name := range names {
+		if _, err := strconv.ParseUint(fields[i], 10, 16); err != nil {
+			return field.Invalid(fieldPath, target, fmt.Sprintf("invalid %s %q, must be a number between 0 and 65535", name, fields[i]))
+		}
+	}
+	return nil
+}
+
+// validateTargetHost validates the host of a target, which is a hostname and not an IP address.
+func validateTargetHost(record string, target string, host string, fieldPath *field.Path) *field.Error {
+	if _, err := netip.ParseAddr(host); err == nil {
+		return field.Invalid(fieldPath, target, fmt.Sprintf("the host must be a hostname and not an IP address for the %s record", record))
+	}
+	if err := isFullyQualifiedDomainName(host); err != nil {
+		return field.Invalid(fieldPath, target, err.Error())
+	}
+	return nil
+}
+
+func validateAWSWeight(value string) string {
+	if _, err := strconv.ParseUint(value, 10, 8); err != nil {
+		return "must be a number between 0 and 255"
+	}
+	return ""
+}
+
+func validateAWSFailover(value string) string {
+	if value != "PRIMARY" && value != "SECONDARY" {
+		return "must be PRIMARY or SECONDARY"
+	}
+	return ""
+}
+
+func isQuoted(value string) bool {
+	return len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`)
+}
+
+func validateDNSRecordType(record string, fieldPath *field.Path) *field.Error {
 	if !slices.Contains(validRecords, record) {
-		return fmt.Errorf("%w: record %s, %s", ErrTypeNotSupported, record, strings.Join(validRecords, ","))
+		return field.NotSupported(fieldPath, record, validRecords)
 	}
 	return nil
 }
 
-func validateTTL(ttl v1.TTL) error {
-	if ttl < 0 {
-		return fmt.Errorf("%w: ttl %d, ttl value should be > 0", ErrTypeNotInRange, ttl)
+// ValidateTTL validates the TTL of a record against the TTL bounds of the options. A TTL of 0 is valid,
+// the record gets the default TTL of the DNS provider.
+func ValidateTTL(ttl v1.TTL, fieldPath *field.Path, opts ...Option) field.ErrorList {
+	return validateTTL(ttl, fieldPath, newOptions(opts))
+}
+
+func validateTTL(ttl v1.TTL, fieldPath *field.Path, o options) field.ErrorList {
+	switch {
+	case ttl < 0:
+		return field.ErrorList{field.Invalid(fieldPath, ttl, "must be greater than or equal to 0")}
+	case ttl == 0:
+		return nil
+	case ttl < o.minTTL:
+		return field.ErrorList{field.Invalid(fieldPath, ttl, fmt.Sprintf("must be at least %d seconds, or 0 for the default TTL of the DNS provider", o.minTTL))}
+	case ttl > o.maxTTL:
+		return field.ErrorList{field.Invalid(fieldPath, ttl, fmt.Sprintf("must be at most %d seconds", o.maxTTL))}
 	}
 	return nil
 }
 
 func isFullyQualifiedDomainName(name string) error {
 	if name == "" {
-		return fmt.Errorf("%w: name not provided", ErrTypeInvalid)
+		return fmt.Errorf("name not provided")
 	}
 	name = strings.TrimSuffix(name, ".")
 	if issues := validation.IsDNS1123Subdomain(name); len(issues) > 0 {
-		return fmt.Errorf("%w: name %s is not valid subdomain, %s", ErrTypeInvalid, name, strings.Join(issues, ", "))
+		return fmt.Errorf("name %s is not valid subdomain, %s", name, strings.Join(issues, ", "))
 	}
 	if len(strings.Split(name, ".")) < 2 {
-		return fmt.Errorf("%w: name %s should be a domain with at least two segments separated by dots", ErrTypeInvalid, name)
+		return fmt.Errorf("name %s should be a domain with at least two segments separated by dots", name)
 	}
 	for _, label := range strings.Split(name, ".") {
 		if issues := validation.IsDNS1123Label(label); len(issues) > 0 {
-			return fmt.Errorf("%w: label %s should conform to the definition of label in DNS (RFC1123), %s", ErrTypeInvalid, label, strings.Join(issues, ", "))
+			return fmt.Errorf("label %s should conform to the definition of label in DNS (RFC1123), %s", label, strings.Join(issues, ", "))
 		}
 	}
 	return nil
@@ -114,20 +715,57 @@ var (
 	//
 	// NGINX Ingress Controller at the moment supports
 	// a subset of DNS record types listed in the external-dns project.
-	validRecords = []string{"A", "CNAME", "AAAA"}
+	validRecords = []string{"A", "CNAME", "AAAA", "NS", "SRV", "MX", "CAA", "NAPTR", "TXT", "ALIAS", "PTR"}
 
-	// ErrTypeNotSupported indicates that provided value is not currently supported.
-	ErrTypeNotSupported = errors.New("type not supported")
+	// unsupportedAliasProviders are the providers that create alias records from CNAME records instead of ALIAS records.
+	unsupportedAliasProviders = map[string]string{
+		"aws":        "not supported by aws, use a CNAME record with the provider specific property alias set to true",
+		"cloudflare": "not supported by cloudflare, use a CNAME record, which cloudflare flattens at the apex of the zone",
+	}
 
-	// ErrTypeInvalid indicates that provided value is invalid.
-	ErrTypeInvalid = errors.New("type invalid")
+	// knownProviders are the providers whose provider specific properties are known. The azure provider has none.
+	knownProviders = []string{"aws", "azure", "cloudflare"}
 
-	// ErrTypeDuplicated indicates that provided values must be unique.
-	ErrTypeDuplicated = errors.New("type duplicated")
+	// providerSpecificPrefixes maps the prefixes of the provider specific properties of the known providers to the provider.
+	providerSpecificPrefixes = map[string]string{
+		"aws/": "aws",
+		"external-dns.alpha.kubernetes.io/cloudflare-": "cloudflare",
+	}
 
-	// ErrTypeRequired indicates that value is not provided but it's mandatory.
-	ErrTypeRequired = errors.New("type required")
+	// providerSpecificProperties maps the provider specific properties of the known providers,
+	// as set by external-dns, to the validation of their values.
+	providerSpecificProperties = map[string]func(string) string{
+		"alias":                            validateBoolValue,
+		"aws/evaluate-target-health":       validateBoolValue,
+		"aws/weight":                       validateAWSWeight,
+		"aws/region":                       validateNonEmptyValue,
+		"aws/failover":                     validateAWSFailover,
+		"aws/geolocation-continent-code":   validateNonEmptyValue,
+		"aws/geolocation-country-code":     validateNonEmptyValue,
+		"aws/geolocation-subdivision-code": validateNonEmptyValue,
+		"aws/multi-value-answer":           validateAnyValue,
+		"aws/health-check-id":              validateNonEmptyValue,
+		"external-dns.alpha.kubernetes.io/cloudflare-proxied":    validateBoolValue,
+		"external-dns.alpha.kubernetes.io/cloudflare-region-key": validateNonEmptyValue,
+	}
+
+	// routingPolicies maps the provider specific properties that set the Route53 routing policy of a record to the policy.
+	routingPolicies = map[string]string{
+		"aws/weight":                       "weighted",
+		"aws/region":                       "latency",
+		"aws/failover":                     "failover",
+		"aws/geolocation-continent-code":   "geolocation",
+		"aws/geolocation-country-code":     "geolocation",
+		"aws/geolocation-subdivision-code": "geolocation",
+		"aws/multi-value-answer":           "multivalue answer",
+	}
+
+	// providerTTLBounds are the minimum and the maximum TTL of the records of the DNS providers
+	// that limit the TTL further than DefaultMinTTL and DefaultMaxTTL.
+	providerTTLBounds = map[string][2]v1.TTL{
+		"cloudflare": {60, 86400},
+	}
 
-	// ErrTypeNotInRange indicates that provided value is outside of defined range.
-	ErrTypeNotInRange = errors.New("type not in range")
+	// caaTagRegexp matches the tag of a CAA record, e.g. issue, issuewild or iodef.
+	caaTagRegexp = regexp.MustCompile(`^[a-zA-Z0-9]{1,15}$`)
 )
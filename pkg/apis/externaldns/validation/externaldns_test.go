@@ -1,11 +1,13 @@
 package validation_test
 
 import (
-	"errors"
+	"reflect"
+	"strings"
 	"testing"
 
 	v1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/externaldns/v1"
 	"github.com/nginxinc/kubernetes-ingress/pkg/apis/externaldns/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
 func TestValidateDNSEndpoint(t *testing.T) {
@@ -37,7 +39,7 @@ func TestValidateDNSEndpoint(t *testing.T) {
 						{
 							DNSName:    "example.com",
 							Targets:    v1.Targets{"2001:db8:0:0:0:0:2:1"},
-							RecordType: "A",
+							RecordType: "AAAA",
 							RecordTTL:  600,
 						},
 					},
@@ -57,7 +59,7 @@ func TestValidateDNSEndpoint(t *testing.T) {
 						},
 						{
 							DNSName:    "example.co.uk",
-							Targets:    v1.Targets{"10.2.2.3"},
+							Targets:    v1.Targets{"example.com."},
 							RecordType: "CNAME",
 							RecordTTL:  900,
 						},
@@ -92,13 +94,136 @@ func TestValidateDNSEndpoint(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "with endpoints of every record type",
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "example.com",
+							Targets:    v1.Targets{"10.2.2.3", "192.123.23.4"},
+							RecordType: "A",
+							RecordTTL:  600,
+						},
+						{
+							DNSName:    "example.com",
+							Targets:    v1.Targets{"2001:db8::2:1", "2001:db8::2:2"},
+							RecordType: "AAAA",
+							RecordTTL:  600,
+						},
+						{
+							DNSName:    "www.example.com",
+							Targets:    v1.Targets{"lb.example.net"},
+							RecordType: "CNAME",
+							RecordTTL:  600,
+						},
+						{
+							DNSName:    "sub.example.com",
+							Targets:    v1.Targets{"ns1.example.net", "ns2.example.net."},
+							RecordType: "NS",
+							RecordTTL:  3600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "with SRV targets",
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "_sip._udp.example.com",
+							Targets:    v1.Targets{"10 5 5060 sip1.example.com", "20 0 65535 sip2.example.com."},
+							RecordType: "SRV",
+							RecordTTL:  600,
+						},
+						{
+							DNSName:    "_ldap._tcp.example.com",
+							Targets:    v1.Targets{"0 0 0 ."},
+							RecordType: "SRV",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "with MX, CAA and NAPTR targets",
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "example.com",
+							Targets:    v1.Targets{"10 mail1.example.com", "20 mail2.example.com."},
+							RecordType: "MX",
+							RecordTTL:  600,
+						},
+						{
+							DNSName:    "example.com",
+							Targets:    v1.Targets{`0 issue "letsencrypt.org"`, `0 issuewild ";"`, `128 iodef "mailto:security@example.com"`},
+							RecordType: "CAA",
+							RecordTTL:  600,
+						},
+						{
+							DNSName:    "example.com",
+							Targets:    v1.Targets{`100 10 "S" "SIP+D2U" "" _sip._udp.example.com.`, `100 20 "U" "E2U+sip" "!^.*$!sip:info@example.com!" .`},
+							RecordType: "NAPTR",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "with PTR records",
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "3.2.2.10.in-addr.arpa",
+							Targets:    v1.Targets{"cafe.example.com"},
+							RecordType: "PTR",
+							RecordTTL:  600,
+						},
+						{
+							DNSName:    "1.0.0.0.2.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.",
+							Targets:    v1.Targets{"cafe.example.com."},
+							RecordType: "PTR",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "with TXT targets",
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "example.com",
+							Targets:    v1.Targets{"v=spf1 include:_spf.example.com ~all", "google-site-verification=6P08Ow5E-8Q0m6vQ7FMAqAYIDprkVV8fUf_7hZ4Qvc8"},
+							RecordType: "TXT",
+							RecordTTL:  600,
+						},
+						{
+							DNSName:    "selector._domainkey.example.com",
+							Targets:    v1.Targets{`"v=DKIM1; k=rsa; p=` + strings.Repeat("A", 200) + `" "` + strings.Repeat("B", 200) + `\"QAB"`},
+							RecordType: "TXT",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, tc := range tt {
 		tc := tc // address gosec G601
 		t.Run(tc.name, func(t *testing.T) {
-			if err := validation.ValidateDNSEndpoint(&tc.endpoint); err != nil {
-				t.Errorf("want no error on %v, got %v", tc.endpoint, err)
+			if errs := validation.ValidateDNSEndpoint(&tc.endpoint); len(errs) > 0 {
+				t.Errorf("want no error on %v, got %v", tc.endpoint, errs)
 			}
 		})
 	}
@@ -108,12 +233,12 @@ func TestValidateDNSEndpoint_ReturnsErrorOn(t *testing.T) {
 	t.Parallel()
 	tt := []struct {
 		name     string
-		want     error
+		want     field.ErrorType
 		endpoint v1.DNSEndpoint
 	}{
 		{
 			name: "not supported DNS record type",
-			want: validation.ErrTypeNotSupported,
+			want: field.ErrorTypeNotSupported,
 			endpoint: v1.DNSEndpoint{
 				Spec: v1.DNSEndpointSpec{
 					Endpoints: []*v1.Endpoint{
@@ -129,7 +254,7 @@ func TestValidateDNSEndpoint_ReturnsErrorOn(t *testing.T) {
 		},
 		{
 			name: "bogus target hostname",
-			want: validation.ErrTypeInvalid,
+			want: field.ErrorTypeInvalid,
 			endpoint: v1.DNSEndpoint{
 				Spec: v1.DNSEndpointSpec{
 					Endpoints: []*v1.Endpoint{
@@ -145,7 +270,7 @@ func TestValidateDNSEndpoint_ReturnsErrorOn(t *testing.T) {
 		},
 		{
 			name: "bogus target IPv6 address",
-			want: validation.ErrTypeInvalid,
+			want: field.ErrorTypeInvalid,
 			endpoint: v1.DNSEndpoint{
 				Spec: v1.DNSEndpointSpec{
 					Endpoints: []*v1.Endpoint{
@@ -160,14 +285,14 @@ func TestValidateDNSEndpoint_ReturnsErrorOn(t *testing.T) {
 			},
 		},
 		{
-			name: "duplicated target",
-			want: validation.ErrTypeDuplicated,
+			name: "IPv6 target of an A record",
+			want: field.ErrorTypeInvalid,
 			endpoint: v1.DNSEndpoint{
 				Spec: v1.DNSEndpointSpec{
 					Endpoints: []*v1.Endpoint{
 						{
 							DNSName:    "example.com",
-							Targets:    v1.Targets{"acme.com", "10.2.2.3", "acme.com"},
+							Targets:    v1.Targets{"2001:db8::2:1"},
 							RecordType: "A",
 							RecordTTL:  600,
 						},
@@ -176,119 +301,1122 @@ func TestValidateDNSEndpoint_ReturnsErrorOn(t *testing.T) {
 			},
 		},
 		{
-			name: "bogus ttl record",
-			want: validation.ErrTypeNotInRange,
+			name: "hostname target of an A record",
+			want: field.ErrorTypeInvalid,
 			endpoint: v1.DNSEndpoint{
 				Spec: v1.DNSEndpointSpec{
 					Endpoints: []*v1.Endpoint{
 						{
 							DNSName:    "example.com",
-							Targets:    v1.Targets{"10.2.2.3", "acme.com"},
+							Targets:    v1.Targets{"acme.com"},
 							RecordType: "A",
-							RecordTTL:  -1,
+							RecordTTL:  600,
 						},
 					},
 				},
 			},
 		},
 		{
-			name: "bogus dns name",
-			want: validation.ErrTypeInvalid,
+			name: "IPv4 target of an AAAA record",
+			want: field.ErrorTypeInvalid,
 			endpoint: v1.DNSEndpoint{
 				Spec: v1.DNSEndpointSpec{
 					Endpoints: []*v1.Endpoint{
 						{
-							DNSName:    "bogusDNSName",
-							Targets:    v1.Targets{"acme.com"},
-							RecordType: "A",
-							RecordTTL:  1800,
+							DNSName:    "example.com",
+							Targets:    v1.Targets{"10.2.2.3"},
+							RecordType: "AAAA",
+							RecordTTL:  600,
 						},
 					},
 				},
 			},
 		},
 		{
-			name: "empty dns name",
-			want: validation.ErrTypeInvalid,
+			name: "IPv4-mapped IPv6 target of an AAAA record",
+			want: field.ErrorTypeInvalid,
 			endpoint: v1.DNSEndpoint{
 				Spec: v1.DNSEndpointSpec{
 					Endpoints: []*v1.Endpoint{
 						{
-							DNSName:    "",
-							Targets:    v1.Targets{"acme.com"},
-							RecordType: "A",
-							RecordTTL:  1800,
+							DNSName:    "example.com",
+							Targets:    v1.Targets{"::ffff:10.2.2.3"},
+							RecordType: "AAAA",
+							RecordTTL:  600,
 						},
 					},
 				},
 			},
 		},
 		{
-			name: "bogus target name",
-			want: validation.ErrTypeInvalid,
+			name: "IP target of a CNAME record",
+			want: field.ErrorTypeInvalid,
 			endpoint: v1.DNSEndpoint{
 				Spec: v1.DNSEndpointSpec{
 					Endpoints: []*v1.Endpoint{
 						{
 							DNSName:    "example.com",
-							Targets:    v1.Targets{"acme."},
-							RecordType: "A",
-							RecordTTL:  1800,
+							Targets:    v1.Targets{"10.2.2.3"},
+							RecordType: "CNAME",
+							RecordTTL:  600,
 						},
 					},
 				},
 			},
 		},
 		{
-			name: "empty target name",
-			want: validation.ErrTypeInvalid,
+			name: "IPv6 target of an NS record among valid endpoints",
+			want: field.ErrorTypeInvalid,
 			endpoint: v1.DNSEndpoint{
 				Spec: v1.DNSEndpointSpec{
 					Endpoints: []*v1.Endpoint{
 						{
 							DNSName:    "example.com",
-							Targets:    v1.Targets{""},
+							Targets:    v1.Targets{"10.2.2.3"},
 							RecordType: "A",
-							RecordTTL:  1800,
+							RecordTTL:  600,
+						},
+						{
+							DNSName:    "sub.example.com",
+							Targets:    v1.Targets{"ns1.example.net", "2001:db8::53"},
+							RecordType: "NS",
+							RecordTTL:  3600,
 						},
 					},
 				},
 			},
 		},
 		{
-			name: "bogus target name",
-			want: validation.ErrTypeInvalid,
+			name: "SRV target without a port",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "_sip._udp.example.com",
+							Targets:    v1.Targets{"10 5 sip.example.com"},
+							RecordType: "SRV",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "SRV target with a port out of range",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "_sip._udp.example.com",
+							Targets:    v1.Targets{"10 5 65536 sip.example.com"},
+							RecordType: "SRV",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "SRV target with a negative priority",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "_sip._udp.example.com",
+							Targets:    v1.Targets{"-1 5 5060 sip.example.com"},
+							RecordType: "SRV",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "SRV target with an IP host",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "_sip._udp.example.com",
+							Targets:    v1.Targets{"10 5 5060 10.2.2.3"},
+							RecordType: "SRV",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "SRV target with an invalid host",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "_sip._udp.example.com",
+							Targets:    v1.Targets{"10 5 5060 sip"},
+							RecordType: "SRV",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "SRV record name without a service",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "sip.example.com",
+							Targets:    v1.Targets{"10 5 5060 sip.example.com"},
+							RecordType: "SRV",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "MX target without a preference",
+			want: field.ErrorTypeInvalid,
 			endpoint: v1.DNSEndpoint{
 				Spec: v1.DNSEndpointSpec{
 					Endpoints: []*v1.Endpoint{
 						{
 							DNSName:    "example.com",
-							Targets:    v1.Targets{"&$$.*&^"},
-							RecordType: "A",
-							RecordTTL:  1800,
+							Targets:    v1.Targets{"mail.example.com"},
+							RecordType: "MX",
+							RecordTTL:  600,
 						},
 					},
 				},
 			},
 		},
 		{
-			name: "empty slice of endpoints",
-			want: validation.ErrTypeRequired,
+			name: "MX target with a preference out of range",
+			want: field.ErrorTypeInvalid,
 			endpoint: v1.DNSEndpoint{
 				Spec: v1.DNSEndpointSpec{
-					Endpoints: []*v1.Endpoint{},
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "example.com",
+							Targets:    v1.Targets{"65536 mail.example.com"},
+							RecordType: "MX",
+							RecordTTL:  600,
+						},
+					},
 				},
 			},
 		},
-	}
-
-	for _, tc := range tt {
-		tc := tc // address gosec G601
-		t.Run(tc.name, func(t *testing.T) {
-			err := validation.ValidateDNSEndpoint(&tc.endpoint)
-			if !errors.Is(err, tc.want) {
-				t.Errorf("want %s, got %v", tc.want, err)
-			}
-		})
+		{
+			name: "MX target with an IP host",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "example.com",
+							Targets:    v1.Targets{"10 10.2.2.3"},
+							RecordType: "MX",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "CAA target with flags out of range",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "example.com",
+							Targets:    v1.Targets{"256 issue \"letsencrypt.org\""},
+							RecordType: "CAA",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "CAA target with an invalid tag",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "example.com",
+							Targets:    v1.Targets{"0 issue-wild \"letsencrypt.org\""},
+							RecordType: "CAA",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "CAA target with an unquoted value",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "example.com",
+							Targets:    v1.Targets{"0 issue letsencrypt.org"},
+							RecordType: "CAA",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "NAPTR target with an unquoted service",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "example.com",
+							Targets:    v1.Targets{"100 10 \"S\" SIP+D2U \"\" _sip._udp.example.com"},
+							RecordType: "NAPTR",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "NAPTR target with an invalid replacement",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "example.com",
+							Targets:    v1.Targets{"100 10 \"S\" \"SIP+D2U\" \"\" 10.2.2.3"},
+							RecordType: "NAPTR",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "TXT target with a string longer than 255 characters",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "example.com",
+							Targets:    v1.Targets{strings.Repeat("a", 256)},
+							RecordType: "TXT",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "TXT target with a control character",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "example.com",
+							Targets:    v1.Targets{"v=spf1\t-all"},
+							RecordType: "TXT",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "TXT target with a non ASCII character",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "example.com",
+							Targets:    v1.Targets{"caf\u00e9"},
+							RecordType: "TXT",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "TXT target with an unterminated quoted string",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "example.com",
+							Targets:    v1.Targets{`"v=spf1 -all" "more`},
+							RecordType: "TXT",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "TXT target with strings not separated by spaces",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "example.com",
+							Targets:    v1.Targets{`"v=spf1""-all"`},
+							RecordType: "TXT",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "TXT target longer than 4000 characters",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "example.com",
+							Targets:    v1.Targets{strings.Repeat(`"`+strings.Repeat("a", 250)+`" `, 17)},
+							RecordType: "TXT",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "PTR record with a forward name",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "cafe.example.com",
+							Targets:    v1.Targets{"cafe.example.com"},
+							RecordType: "PTR",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "PTR record with an invalid in-addr.arpa name",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "256.2.2.10.in-addr.arpa",
+							Targets:    v1.Targets{"cafe.example.com"},
+							RecordType: "PTR",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "PTR record with too many in-addr.arpa labels",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "1.3.2.2.10.in-addr.arpa",
+							Targets:    v1.Targets{"cafe.example.com"},
+							RecordType: "PTR",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "PTR record with an invalid ip6.arpa name",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "10.8.b.d.0.1.0.0.2.ip6.arpa",
+							Targets:    v1.Targets{"cafe.example.com"},
+							RecordType: "PTR",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "PTR record with an IP target",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "3.2.2.10.in-addr.arpa",
+							Targets:    v1.Targets{"10.2.2.3"},
+							RecordType: "PTR",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "duplicated target",
+			want: field.ErrorTypeDuplicate,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "example.com",
+							Targets:    v1.Targets{"10.2.2.4", "10.2.2.3", "10.2.2.4"},
+							RecordType: "A",
+							RecordTTL:  600,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "bogus ttl record",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "example.com",
+							Targets:    v1.Targets{"10.2.2.3", "10.2.2.4"},
+							RecordType: "A",
+							RecordTTL:  -1,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "bogus dns name",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "bogusDNSName",
+							Targets:    v1.Targets{"10.2.2.3"},
+							RecordType: "A",
+							RecordTTL:  1800,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "empty dns name",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "",
+							Targets:    v1.Targets{"10.2.2.3"},
+							RecordType: "A",
+							RecordTTL:  1800,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "bogus target name",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "example.com",
+							Targets:    v1.Targets{"acme."},
+							RecordType: "A",
+							RecordTTL:  1800,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "empty target name",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "example.com",
+							Targets:    v1.Targets{""},
+							RecordType: "A",
+							RecordTTL:  1800,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "bogus target name",
+			want: field.ErrorTypeInvalid,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    "example.com",
+							Targets:    v1.Targets{"&$$.*&^"},
+							RecordType: "A",
+							RecordTTL:  1800,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "empty slice of endpoints",
+			want: field.ErrorTypeRequired,
+			endpoint: v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc // address gosec G601
+		t.Run(tc.name, func(t *testing.T) {
+			errs := validation.ValidateDNSEndpoint(&tc.endpoint)
+			if len(errs) != 1 || errs[0].Type != tc.want {
+				t.Errorf("want one error of type %s, got %v", tc.want, errs)
+			}
+		})
+	}
+}
+
+func TestValidateDNSEndpoint_Wildcards(t *testing.T) {
+	t.Parallel()
+	tt := []struct {
+		name    string
+		dnsName string
+		opts    []validation.Option
+		want    field.ErrorType
+	}{
+		{
+			name:    "wildcard name",
+			dnsName: "*.example.com",
+		},
+		{
+			name:    "wildcard name with wildcards allowed",
+			dnsName: "*.apps.example.com",
+			opts:    []validation.Option{validation.AllowWildcards(true)},
+		},
+		{
+			name:    "wildcard name with wildcards disallowed",
+			dnsName: "*.example.com",
+			opts:    []validation.Option{validation.AllowWildcards(false)},
+			want:    field.ErrorTypeForbidden,
+		},
+		{
+			name:    "wildcard in the middle of the name",
+			dnsName: "apps.*.example.com",
+			want:    field.ErrorTypeInvalid,
+		},
+		{
+			name:    "several wildcard labels",
+			dnsName: "*.*.example.com",
+			want:    field.ErrorTypeInvalid,
+		},
+		{
+			name:    "wildcard without a subdomain",
+			dnsName: "*.",
+			want:    field.ErrorTypeInvalid,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc // address gosec G601
+		t.Run(tc.name, func(t *testing.T) {
+			endpoint := v1.DNSEndpoint{
+				Spec: v1.DNSEndpointSpec{
+					Endpoints: []*v1.Endpoint{
+						{
+							DNSName:    tc.dnsName,
+							Targets:    v1.Targets{"10.2.2.3"},
+							RecordType: "A",
+							RecordTTL:  600,
+						},
+					},
+				},
+			}
+			errs := validation.ValidateDNSEndpoint(&endpoint, tc.opts...)
+			if tc.want == "" && len(errs) > 0 {
+				t.Errorf("want no error, got %v", errs)
+			}
+			if tc.want != "" && (len(errs) != 1 || errs[0].Type != tc.want) {
+				t.Errorf("want one error of type %s, got %v", tc.want, errs)
+			}
+		})
+	}
+}
+
+func TestValidateProviderSpecific(t *testing.T) {
+	t.Parallel()
+	tt := []struct {
+		name       string
+		properties v1.ProviderSpecific
+		opts       []validation.Option
+		want       []string
+	}{
+		{
+			name: "properties of known providers",
+			properties: v1.ProviderSpecific{
+				{Name: "alias", Value: "true"},
+				{Name: "aws/evaluate-target-health", Value: "false"},
+				{Name: "aws/weight", Value: "100"},
+				{Name: "aws/failover", Value: "PRIMARY"},
+				{Name: "aws/multi-value-answer"},
+				{Name: "external-dns.alpha.kubernetes.io/cloudflare-proxied", Value: "true"},
+			},
+		},
+		{
+			name:       "property of another provider",
+			properties: v1.ProviderSpecific{{Name: "example.com/property", Value: "value"}},
+		},
+		{
+			name:       "property of another provider in strict mode",
+			properties: v1.ProviderSpecific{{Name: "example.com/property", Value: "value"}},
+			opts:       []validation.Option{validation.StrictProviderSpecific(true)},
+			want:       []string{"providerSpecific[0].name"},
+		},
+		{
+			name: "typos in the properties of known providers",
+			properties: v1.ProviderSpecific{
+				{Name: "aws/wieght", Value: "100"},
+				{Name: "external-dns.alpha.kubernetes.io/cloudflare-proxy", Value: "true"},
+			},
+			want: []string{"providerSpecific[0].name", "providerSpecific[1].name"},
+		},
+		{
+			name: "invalid values",
+			properties: v1.ProviderSpecific{
+				{Name: "alias", Value: "yes"},
+				{Name: "aws/weight", Value: "256"},
+				{Name: "aws/failover", Value: "primary"},
+				{Name: "aws/region"},
+			},
+			want: []string{"providerSpecific[0].value", "providerSpecific[1].value", "providerSpecific[2].value", "providerSpecific[3].value"},
+		},
+		{
+			name: "properties of the provider",
+			properties: v1.ProviderSpecific{
+				{Name: "external-dns.alpha.kubernetes.io/cloudflare-proxied", Value: "true"},
+				{Name: "example.com/property", Value: "value"},
+			},
+			opts: []validation.Option{validation.Provider("cloudflare")},
+		},
+		{
+			name: "properties of other known providers",
+			properties: v1.ProviderSpecific{
+				{Name: "alias", Value: "true"},
+				{Name: "external-dns.alpha.kubernetes.io/cloudflare-proxied", Value: "true"},
+			},
+			opts: []validation.Option{validation.Provider("aws")},
+			want: []string{"providerSpecific[1].name"},
+		},
+		{
+			name: "properties of known providers for azure",
+			properties: v1.ProviderSpecific{
+				{Name: "aws/weight", Value: "10"},
+				{Name: "external-dns.alpha.kubernetes.io/cloudflare-proxied", Value: "true"},
+				{Name: "example.com/property", Value: "value"},
+			},
+			opts: []validation.Option{validation.Provider("azure")},
+			want: []string{"providerSpecific[0].name", "providerSpecific[1].name"},
+		},
+		{
+			name:       "property of another provider for azure in strict mode",
+			properties: v1.ProviderSpecific{{Name: "example.com/property", Value: "value"}},
+			opts:       []validation.Option{validation.Provider("azure"), validation.StrictProviderSpecific(true)},
+			want:       []string{"providerSpecific[0].name"},
+		},
+		{
+			name:       "properties of known providers for an unknown provider",
+			properties: v1.ProviderSpecific{{Name: "aws/weight", Value: "10"}},
+			opts:       []validation.Option{validation.Provider("rfc2136")},
+		},
+		{
+			name: "duplicated and unnamed properties",
+			properties: v1.ProviderSpecific{
+				{Name: "aws/weight", Value: "10"},
+				{Name: "aws/weight", Value: "20"},
+				{Value: "value"},
+			},
+			want: []string{"providerSpecific[1].name", "providerSpecific[2].name"},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc // address gosec G601
+		t.Run(tc.name, func(t *testing.T) {
+			errs := validation.ValidateProviderSpecific(tc.properties, field.NewPath("providerSpecific"), tc.opts...)
+			var got []string
+			for _, err := range errs {
+				got = append(got, err.Field)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want errors for fields %v, got %v", tc.want, errs)
+			}
+		})
+	}
+}
+
+func TestValidateLabels(t *testing.T) {
+	t.Parallel()
+	tt := []struct {
+		name   string
+		labels v1.Labels
+		want   []string
+	}{
+		{
+			name:   "owner and custom labels",
+			labels: v1.Labels{"owner": "cluster-1", "team": "cafe", "example.com/tier": "frontend"},
+		},
+		{
+			name:   "invalid keys",
+			labels: v1.Labels{"bad key": "value", "-team": "cafe"},
+			want:   []string{"labels[-team]", "labels[bad key]"},
+		},
+		{
+			name:   "values that break the TXT registry",
+			labels: v1.Labels{"team": "cafe,tea", "tier": "a=b"},
+			want:   []string{"labels[team]", "labels[tier]"},
+		},
+		{
+			name:   "empty owner",
+			labels: v1.Labels{"owner": ""},
+			want:   []string{"labels[owner]"},
+		},
+		{
+			name:   "invalid owner",
+			labels: v1.Labels{"owner": "cluster 1"},
+			want:   []string{"labels[owner]"},
+		},
+		{
+			name:   "reserved resource label",
+			labels: v1.Labels{"resource": "crd/default/cafe"},
+			want:   []string{"labels[resource]"},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc // address gosec G601
+		t.Run(tc.name, func(t *testing.T) {
+			errs := validation.ValidateLabels(tc.labels, field.NewPath("labels"))
+			var got []string
+			for _, err := range errs {
+				got = append(got, err.Field)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want errors for fields %v, got %v", tc.want, errs)
+			}
+		})
+	}
+}
+
+func TestValidateSetIdentifier(t *testing.T) {
+	t.Parallel()
+	tt := []struct {
+		name          string
+		setIdentifier string
+		properties    v1.ProviderSpecific
+		want          []string
+	}{
+		{
+			name: "simple record",
+		},
+		{
+			name:          "weighted record",
+			setIdentifier: "blue",
+			properties:    v1.ProviderSpecific{{Name: "aws/weight", Value: "0"}, {Name: "aws/evaluate-target-health", Value: "true"}},
+		},
+		{
+			name:          "geolocation record",
+			setIdentifier: "europe",
+			properties:    v1.ProviderSpecific{{Name: "aws/geolocation-continent-code", Value: "EU"}, {Name: "aws/geolocation-country-code", Value: "IE"}},
+		},
+		{
+			name:       "weighted record without a set identifier",
+			properties: v1.ProviderSpecific{{Name: "aws/weight", Value: "10"}},
+			want:       []string{"spec.endpoints[0].setIdentifier"},
+		},
+		{
+			name:          "set identifier without a routing policy",
+			setIdentifier: "blue",
+			properties:    v1.ProviderSpecific{{Name: "alias", Value: "true"}},
+			want:          []string{"spec.endpoints[0].setIdentifier"},
+		},
+		{
+			name:          "weighted and latency record",
+			setIdentifier: "blue",
+			properties:    v1.ProviderSpecific{{Name: "aws/weight", Value: "10"}, {Name: "aws/region", Value: "eu-west-1"}},
+			want:          []string{"spec.endpoints[0].providerSpecific"},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc // address gosec G601
+		t.Run(tc.name, func(t *testing.T) {
+			errs := validation.ValidateSetIdentifier(tc.setIdentifier, tc.properties, field.NewPath("spec", "endpoints").Index(0))
+			var got []string
+			for _, err := range errs {
+				got = append(got, err.Field)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want errors for fields %v, got %v", tc.want, errs)
+			}
+		})
+	}
+}
+
+func TestValidateTTL(t *testing.T) {
+	t.Parallel()
+	tt := []struct {
+		name  string
+		ttl   v1.TTL
+		opts  []validation.Option
+		valid bool
+	}{
+		{name: "default TTL of the provider", ttl: 0, valid: true},
+		{name: "TTL within the default bounds", ttl: 300, valid: true},
+		{name: "negative TTL", ttl: -1},
+		{name: "TTL above the default maximum", ttl: validation.DefaultMaxTTL + 1},
+		{name: "TTL below the minimum of the provider", ttl: 30, opts: []validation.Option{validation.Provider("cloudflare")}},
+		{name: "TTL above the maximum of the provider", ttl: 86401, opts: []validation.Option{validation.Provider("cloudflare")}},
+		{name: "TTL within the bounds of the provider", ttl: 60, opts: []validation.Option{validation.Provider("cloudflare")}, valid: true},
+		{name: "TTL of a provider without known bounds", ttl: 1, opts: []validation.Option{validation.Provider("aws")}, valid: true},
+		{
+			name:  "TTL within the configured bounds",
+			ttl:   30,
+			opts:  []validation.Option{validation.Provider("cloudflare"), validation.TTLBounds(30, 0)},
+			valid: true,
+		},
+		{
+			name: "TTL above the configured maximum",
+			ttl:  3601,
+			opts: []validation.Option{validation.Provider("cloudflare"), validation.TTLBounds(0, 3600)},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc // address gosec G601
+		t.Run(tc.name, func(t *testing.T) {
+			errs := validation.ValidateTTL(tc.ttl, field.NewPath("recordTTL"), tc.opts...)
+			if tc.valid && len(errs) > 0 {
+				t.Errorf("want no error, got %v", errs)
+			}
+			if !tc.valid && len(errs) != 1 {
+				t.Errorf("want one error, got %v", errs)
+			}
+		})
+	}
+}
+
+func TestValidateDNSEndpoint_DuplicateEndpoints(t *testing.T) {
+	t.Parallel()
+	endpoint := v1.DNSEndpoint{
+		Spec: v1.DNSEndpointSpec{
+			Endpoints: []*v1.Endpoint{
+				{
+					DNSName:    "example.com",
+					Targets:    v1.Targets{"10.2.2.3"},
+					RecordType: "A",
+				},
+				{
+					DNSName:    "example.com",
+					Targets:    v1.Targets{"2001:db8::2:1"},
+					RecordType: "AAAA",
+				},
+				{
+					DNSName:          "example.com",
+					Targets:          v1.Targets{"10.2.2.4"},
+					RecordType:       "A",
+					SetIdentifier:    "blue",
+					ProviderSpecific: v1.ProviderSpecific{{Name: "aws/weight", Value: "10"}},
+				},
+				{
+					DNSName:    "example.com",
+					Targets:    v1.Targets{"10.2.2.5"},
+					RecordType: "A",
+				},
+			},
+		},
+	}
+
+	errs := validation.ValidateDNSEndpoint(&endpoint)
+	if len(errs) != 1 || errs[0].Type != field.ErrorTypeDuplicate || errs[0].Field != "spec.endpoints[3]" {
+		t.Errorf("want one duplicate error for spec.endpoints[3], got %v", errs)
+	}
+}
+
+func TestValidateDNSEndpoint_CoexistingRecords(t *testing.T) {
+	t.Parallel()
+	alias := v1.ProviderSpecific{{Name: "alias", Value: "true"}}
+	tt := []struct {
+		name      string
+		endpoints []*v1.Endpoint
+		opts      []validation.Option
+		want      []string
+	}{
+		{
+			name: "ALIAS record at the apex with MX and TXT records",
+			endpoints: []*v1.Endpoint{
+				{DNSName: "example.com", Targets: v1.Targets{"lb-1.elb.example.net"}, RecordType: "ALIAS"},
+				{DNSName: "example.com", Targets: v1.Targets{"10 mail.example.com"}, RecordType: "MX"},
+				{DNSName: "example.com", Targets: v1.Targets{"v=spf1 -all"}, RecordType: "TXT"},
+			},
+		},
+		{
+			name: "weighted CNAME alias records",
+			endpoints: []*v1.Endpoint{
+				{DNSName: "example.com", Targets: v1.Targets{"lb-1.elb.example.net"}, RecordType: "CNAME", SetIdentifier: "blue", ProviderSpecific: append(v1.ProviderSpecific{{Name: "aws/weight", Value: "10"}}, alias...)},
+				{DNSName: "example.com", Targets: v1.Targets{"lb-2.elb.example.net"}, RecordType: "CNAME", SetIdentifier: "green", ProviderSpecific: append(v1.ProviderSpecific{{Name: "aws/weight", Value: "90"}}, alias...)},
+			},
+		},
+		{
+			name: "CNAME record with an A record",
+			endpoints: []*v1.Endpoint{
+				{DNSName: "www.example.com", Targets: v1.Targets{"10.2.2.3"}, RecordType: "A"},
+				{DNSName: "www.example.com", Targets: v1.Targets{"example.com"}, RecordType: "CNAME"},
+			},
+			want: []string{"spec.endpoints[1].recordType"},
+		},
+		{
+			name: "CNAME record with a TXT record",
+			endpoints: []*v1.Endpoint{
+				{DNSName: "www.example.com", Targets: v1.Targets{"example.com"}, RecordType: "CNAME"},
+				{DNSName: "www.example.com", Targets: v1.Targets{"verification"}, RecordType: "TXT"},
+			},
+			want: []string{"spec.endpoints[1].recordType"},
+		},
+		{
+			name: "ALIAS record with an AAAA record",
+			endpoints: []*v1.Endpoint{
+				{DNSName: "example.com", Targets: v1.Targets{"lb-1.elb.example.net"}, RecordType: "ALIAS"},
+				{DNSName: "example.com", Targets: v1.Targets{"2001:db8::2:1"}, RecordType: "AAAA"},
+			},
+			want: []string{"spec.endpoints[1].recordType"},
+		},
+		{
+			name: "CNAME alias record with an ALIAS record",
+			endpoints: []*v1.Endpoint{
+				{DNSName: "example.com", Targets: v1.Targets{"lb-1.elb.example.net"}, RecordType: "CNAME", ProviderSpecific: alias},
+				{DNSName: "example.com", Targets: v1.Targets{"lb-2.elb.example.net"}, RecordType: "ALIAS"},
+			},
+			want: []string{"spec.endpoints[1].recordType"},
+		},
+		{
+			name: "ALIAS record of a provider with CNAME alias records",
+			endpoints: []*v1.Endpoint{
+				{DNSName: "example.com", Targets: v1.Targets{"lb-1.elb.example.net"}, RecordType: "ALIAS"},
+			},
+			opts: []validation.Option{validation.Provider("aws")},
+			want: []string{"spec.endpoints[0].recordType"},
+		},
+		{
+			name: "ALIAS record with an IP target",
+			endpoints: []*v1.Endpoint{
+				{DNSName: "example.com", Targets: v1.Targets{"10.2.2.3"}, RecordType: "ALIAS"},
+			},
+			want: []string{"spec.endpoints[0].targets[0]"},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc // address gosec G601
+		t.Run(tc.name, func(t *testing.T) {
+			errs := validation.ValidateDNSEndpoint(&v1.DNSEndpoint{Spec: v1.DNSEndpointSpec{Endpoints: tc.endpoints}}, tc.opts...)
+			var got []string
+			for _, err := range errs {
+				got = append(got, err.Field)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want errors for fields %v, got %v", tc.want, errs)
+			}
+		})
+	}
+}
+
+func TestValidateDNSEndpoint_ReturnsAllErrors(t *testing.T) {
+	t.Parallel()
+	endpoint := v1.DNSEndpoint{
+		Spec: v1.DNSEndpointSpec{
+			Endpoints: []*v1.Endpoint{
+				{
+					DNSName:    "example.com",
+					Targets:    v1.Targets{"10.2.2.3"},
+					RecordType: "A",
+					RecordTTL:  600,
+				},
+				{
+					DNSName:    "bogus_name.com",
+					Targets:    v1.Targets{"10.2.2.3", "2001:db8::2:1", "10.2.2.3"},
+					RecordType: "A",
+					RecordTTL:  -1,
+				},
+				{
+					DNSName:    "example.ie",
+					Targets:    v1.Targets{"example.com"},
+					RecordType: "HINFO",
+					RecordTTL:  600,
+				},
+			},
+		},
+	}
+
+	want := []struct {
+		errType field.ErrorType
+		field   string
+	}{
+		{errType: field.ErrorTypeInvalid, field: "spec.endpoints[1].dnsName"},
+		{errType: field.ErrorTypeInvalid, field: "spec.endpoints[1].targets[1]"},
+		{errType: field.ErrorTypeDuplicate, field: "spec.endpoints[1].targets[2]"},
+		{errType: field.ErrorTypeInvalid, field: "spec.endpoints[1].recordTTL"},
+		{errType: field.ErrorTypeNotSupported, field: "spec.endpoints[2].recordType"},
+	}
+	errs := validation.ValidateDNSEndpoint(&endpoint)
+	if len(errs) != len(want) {
+		t.Fatalf("want %d errors, got %v", len(want), errs)
+	}
+	for i, w := range want {
+		if errs[i].Type != w.errType || errs[i].Field != w.field {
+			t.Errorf("want error %d of type %s for field %s, got %v", i, w.errType, w.field, errs[i])
+		}
 	}
 }
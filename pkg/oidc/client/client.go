@@ -0,0 +1,237 @@
+// Package client provides a typed client for the OIDC admin endpoints of NGINX Ingress Controller.
+//
+// The revocation and the metrics endpoints are served on the NGINX Plus API unix socket inside the Ingress
+// Controller pod. The session administration and the policy dry-run endpoints are served on localhost ports of the
+// pod, when they are enabled with the -enable-oidc-session-admin and -enable-policy-dry-run flags. The endpoints are
+// described by the OpenAPI specification in openapi.yaml.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultSocketPath is the path of the NGINX Plus API socket in the Ingress Controller pod.
+	DefaultSocketPath = "/var/lib/nginx/nginx-plus-api.sock"
+
+	// APIVersion is the version of the NGINX Plus API used to read the OIDC metrics.
+	APIVersion = 9
+
+	// DefaultSessionAdminEndpoint is the endpoint of the OIDC session administration server in the Ingress
+	// Controller pod, with the default -oidc-session-admin-listen-port.
+	DefaultSessionAdminEndpoint = "http://127.0.0.1:9117"
+
+	// DefaultPolicyDryRunEndpoint is the endpoint of the policy dry-run server in the Ingress Controller pod,
+	// with the default -policy-dry-run-listen-port.
+	DefaultPolicyDryRunEndpoint = "http://127.0.0.1:9116"
+
+	oidcZonePrefix = "OIDC "
+)
+
+// Client is a client for the OIDC admin endpoints.
+type Client struct {
+	httpClient *http.Client
+	endpoint   string
+	token      string
+}
+
+// NewClient returns a new client for the OIDC admin endpoints served at the endpoint,
+// for example http://nginx-plus-api.
+func NewClient(httpClient *http.Client, endpoint string) *Client {
+	return &Client{
+		httpClient: httpClient,
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+	}
+}
+
+// NewSocketClient returns a new client for the OIDC admin endpoints served on the unix socket.
+func NewSocketClient(sockPath string) *Client {
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
+				return net.Dial("unix", sockPath)
+			},
+		},
+	}
+	return NewClient(httpClient, "http://nginx-plus-api")
+}
+
+// WithBearerToken returns a copy of the client that sends the Kubernetes bearer token, for example the token of a
+// service account, with its requests. The session administration endpoint authorizes the user of the token.
+func (c *Client) WithBearerToken(token string) *Client {
+	client := *c
+	client.token = token
+	return &client
+}
+
+// Error is returned when an endpoint responds with an unexpected status code.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("unexpected response status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("unexpected response status %d: %s", e.StatusCode, e.Message)
+}
+
+// RevokeSessions revokes every session of the subject, identified by the iss and sub claims of its ID token,
+// on all Ingress Controller pods.
+func (c *Client) RevokeSessions(ctx context.Context, iss string, sub string) error {
+	if iss == "" {
+		return fmt.Errorf("iss must not be empty")
+	}
+	if sub == "" {
+		return fmt.Errorf("sub must not be empty")
+	}
+	query := url.Values{"iss": []string{iss}, "sub": []string{sub}}
+	return c.do(ctx, http.MethodPost, "/oidc/revoke-sessions?"+query.Encode(), http.StatusNoContent, nil)
+}
+
+// Session holds a session of an OIDC policy.
+type Session struct {
+	// ID is the value of the session cookie.
+	ID string `json:"id"`
+	// Subject is the sub claim of the ID token.
+	Subject string `json:"sub"`
+	// IssuedAt is the time the ID token was issued, at the login or the last refresh.
+	IssuedAt time.Time `json:"issuedAt"`
+	// ExpiresAt is the time the ID token expires. A session with a refresh token is refreshed after that.
+	ExpiresAt time.Time `json:"expiresAt"`
+	// Refreshable is true if the session has a refresh token.
+	Refreshable bool `json:"refreshable"`
+}
+
+// ListSessions returns the sessions of the OIDC policy. The user of the bearer token of the client needs the
+// permission to get the policy.
+func (c *Client) ListSessions(ctx context.Context, namespace string, name string) ([]Session, error) {
+	if namespace == "" || name == "" {
+		return nil, fmt.Errorf("namespace and name must not be empty")
+	}
+	var list struct {
+		Sessions []Session `json:"sessions"`
+	}
+	if err := c.do(ctx, http.MethodGet, sessionsPath(namespace, name), http.StatusOK, &list); err != nil {
+		return nil, err
+	}
+	return list.Sessions, nil
+}
+
+// RevokeSession revokes a session of the OIDC policy and its tokens. The user of the bearer token of the client
+// needs the permission to update the policy.
+func (c *Client) RevokeSession(ctx context.Context, namespace string, name string, id string) error {
+	if namespace == "" || name == "" || id == "" {
+		return fmt.Errorf("namespace, name and id must not be empty")
+	}
+	return c.do(ctx, http.MethodDelete, sessionsPath(namespace, name)+"/"+url.PathEscape(id), http.StatusNoContent, nil)
+}
+
+func sessionsPath(namespace string, name string) string {
+	return "/oidc/sessions/" + url.PathEscape(namespace) + "/" + url.PathEscape(name)
+}
+
+// DryRunPolicy returns the NGINX configuration of the VirtualServer, in the namespace/name format, as if the
+// Policy, in YAML or JSON, was added or updated. The Policy is not applied, and the secrets of the policies are
+// redacted from the configuration.
+func (c *Client) DryRunPolicy(ctx context.Context, virtualServer string, policy []byte) (string, error) {
+	if virtualServer == "" {
+		return "", fmt.Errorf("virtualServer must not be empty")
+	}
+	query := url.Values{"virtualserver": []string{virtualServer}}
+	body, err := c.doRequest(ctx, http.MethodPost, "/dry-run/policy?"+query.Encode(), bytes.NewReader(policy), http.StatusOK)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// Responses holds the number of responses per status class.
+type Responses struct {
+	Responses1xx uint64 `json:"1xx"`
+	Responses2xx uint64 `json:"2xx"`
+	Responses3xx uint64 `json:"3xx"`
+	Responses4xx uint64 `json:"4xx"`
+	Responses5xx uint64 `json:"5xx"`
+	Total        uint64 `json:"total"`
+}
+
+// ZoneMetrics holds the metrics of an OIDC status zone, for example "OIDC start" or "OIDC code exchange".
+type ZoneMetrics struct {
+	Requests  uint64    `json:"requests"`
+	Responses Responses `json:"responses"`
+	Discarded uint64    `json:"discarded"`
+	Received  uint64    `json:"received"`
+	Sent      uint64    `json:"sent"`
+}
+
+// Metrics returns the metrics of the OIDC status zones, keyed by the zone name.
+func (c *Client) Metrics(ctx context.Context) (map[string]ZoneMetrics, error) {
+	var zones map[string]ZoneMetrics
+	path := fmt.Sprintf("/api/%d/http/location_zones", APIVersion)
+	if err := c.do(ctx, http.MethodGet, path, http.StatusOK, &zones); err != nil {
+		return nil, err
+	}
+
+	metrics := make(map[string]ZoneMetrics)
+	for name, zone := range zones {
+		if strings.HasPrefix(name, oidcZonePrefix) {
+			metrics[name] = zone
+		}
+	}
+	return metrics, nil
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, expectedStatus int, data interface{}) error {
+	body, err := c.doRequest(ctx, method, path, nil, expectedStatus)
+	if err != nil {
+		return err
+	}
+
+	if data == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, data); err != nil {
+		return fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) doRequest(ctx context.Context, method string, path string, reqBody io.Reader, expectedStatus int) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoint+path, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	if reqBody != nil {
+		req.Header.Set("Content-Type", "application/yaml")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the response body: %w", err)
+	}
+
+	if resp.StatusCode != expectedStatus {
+		return nil, &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+	}
+	return body, nil
+}
@@ -0,0 +1,214 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRevokeSessions(t *testing.T) {
+	t.Parallel()
+	var gotMethod, gotPath, gotIss, gotSub string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.Path
+		gotIss = r.URL.Query().Get("iss")
+		gotSub = r.URL.Query().Get("sub")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	c := NewClient(ts.Client(), ts.URL)
+	if err := c.RevokeSessions(context.Background(), "https://idp.example.com", "user@example.com"); err != nil {
+		t.Fatalf("RevokeSessions() returned unexpected error: %v", err)
+	}
+	if gotMethod != http.MethodPost {
+		t.Errorf("RevokeSessions() sent method %q, want %q", gotMethod, http.MethodPost)
+	}
+	if gotPath != "/oidc/revoke-sessions" {
+		t.Errorf("RevokeSessions() sent path %q, want %q", gotPath, "/oidc/revoke-sessions")
+	}
+	if gotIss != "https://idp.example.com" {
+		t.Errorf("RevokeSessions() sent iss %q, want %q", gotIss, "https://idp.example.com")
+	}
+	if gotSub != "user@example.com" {
+		t.Errorf("RevokeSessions() sent sub %q, want %q", gotSub, "user@example.com")
+	}
+}
+
+func TestRevokeSessions_FailsOnEmptySub(t *testing.T) {
+	t.Parallel()
+	c := NewClient(http.DefaultClient, "http://nginx-plus-api")
+	if err := c.RevokeSessions(context.Background(), "https://idp.example.com", ""); err == nil {
+		t.Error("RevokeSessions() returned no error for empty sub")
+	}
+}
+
+func TestRevokeSessions_FailsOnEmptyIss(t *testing.T) {
+	t.Parallel()
+	c := NewClient(http.DefaultClient, "http://nginx-plus-api")
+	if err := c.RevokeSessions(context.Background(), "", "user@example.com"); err == nil {
+		t.Error("RevokeSessions() returned no error for empty iss")
+	}
+}
+
+func TestRevokeSessions_ReturnsErrorOnUnexpectedStatus(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "missing sub argument", http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	c := NewClient(ts.Client(), ts.URL)
+	err := c.RevokeSessions(context.Background(), "https://idp.example.com", "user")
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("RevokeSessions() returned %v, want *Error", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.Message != "missing sub argument" {
+		t.Errorf("RevokeSessions() returned %+v, want status 400 and message %q", apiErr, "missing sub argument")
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/9/http/location_zones" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{
+			"OIDC start": {"requests": 10, "responses": {"3xx": 10, "total": 10}, "received": 100, "sent": 200},
+			"OIDC code exchange": {"requests": 4, "responses": {"3xx": 3, "5xx": 1, "total": 4}},
+			"tea-svc": {"requests": 42}
+		}`))
+	}))
+	defer ts.Close()
+
+	c := NewClient(ts.Client(), ts.URL)
+	metrics, err := c.Metrics(context.Background())
+	if err != nil {
+		t.Fatalf("Metrics() returned unexpected error: %v", err)
+	}
+
+	if len(metrics) != 2 {
+		t.Fatalf("Metrics() returned %d zones, want 2: %+v", len(metrics), metrics)
+	}
+	start := metrics["OIDC start"]
+	if start.Requests != 10 || start.Responses.Responses3xx != 10 || start.Received != 100 || start.Sent != 200 {
+		t.Errorf("Metrics() returned %+v for zone OIDC start", start)
+	}
+	if metrics["OIDC code exchange"].Responses.Responses5xx != 1 {
+		t.Errorf("Metrics() returned %+v for zone OIDC code exchange", metrics["OIDC code exchange"])
+	}
+}
+
+func TestListSessions(t *testing.T) {
+	t.Parallel()
+	var gotMethod, gotPath, gotAuthorization string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.Path
+		gotAuthorization = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{"sessions": [
+			{"id": "abc", "sub": "user@example.com", "issuedAt": "2024-05-01T10:00:00Z", "expiresAt": "2024-05-01T11:00:00Z", "refreshable": true}
+		]}`))
+	}))
+	defer ts.Close()
+
+	c := NewClient(ts.Client(), ts.URL).WithBearerToken("token")
+	sessions, err := c.ListSessions(context.Background(), "default", "oidc-policy")
+	if err != nil {
+		t.Fatalf("ListSessions() returned unexpected error: %v", err)
+	}
+	if gotMethod != http.MethodGet || gotPath != "/oidc/sessions/default/oidc-policy" {
+		t.Errorf("ListSessions() sent %s %s, want GET /oidc/sessions/default/oidc-policy", gotMethod, gotPath)
+	}
+	if gotAuthorization != "Bearer token" {
+		t.Errorf("ListSessions() sent the Authorization header %q, want %q", gotAuthorization, "Bearer token")
+	}
+	want := Session{
+		ID:          "abc",
+		Subject:     "user@example.com",
+		IssuedAt:    time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		ExpiresAt:   time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC),
+		Refreshable: true,
+	}
+	if len(sessions) != 1 || sessions[0] != want {
+		t.Errorf("ListSessions() returned %+v, want [%+v]", sessions, want)
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	t.Parallel()
+	var gotMethod, gotPath string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	c := NewClient(ts.Client(), ts.URL).WithBearerToken("token")
+	if err := c.RevokeSession(context.Background(), "default", "oidc-policy", "abc"); err != nil {
+		t.Fatalf("RevokeSession() returned unexpected error: %v", err)
+	}
+	if gotMethod != http.MethodDelete || gotPath != "/oidc/sessions/default/oidc-policy/abc" {
+		t.Errorf("RevokeSession() sent %s %s, want DELETE /oidc/sessions/default/oidc-policy/abc", gotMethod, gotPath)
+	}
+
+	if err := c.RevokeSession(context.Background(), "default", "oidc-policy", ""); err == nil {
+		t.Error("RevokeSession() returned no error for an empty id")
+	}
+}
+
+func TestDryRunPolicy(t *testing.T) {
+	t.Parallel()
+	policy := "apiVersion: k8s.nginx.org/v1\nkind: Policy\nmetadata:\n  name: oidc-policy\n"
+	var gotMethod, gotPath, gotVirtualServer, gotBody string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.Path
+		gotVirtualServer = r.URL.Query().Get("virtualserver")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		_, _ = w.Write([]byte("server {\n}\n"))
+	}))
+	defer ts.Close()
+
+	c := NewClient(ts.Client(), ts.URL)
+	config, err := c.DryRunPolicy(context.Background(), "default/cafe", []byte(policy))
+	if err != nil {
+		t.Fatalf("DryRunPolicy() returned unexpected error: %v", err)
+	}
+	if gotMethod != http.MethodPost || gotPath != "/dry-run/policy" || gotVirtualServer != "default/cafe" {
+		t.Errorf("DryRunPolicy() sent %s %s?virtualserver=%s, want POST /dry-run/policy?virtualserver=default/cafe", gotMethod, gotPath, gotVirtualServer)
+	}
+	if gotBody != policy {
+		t.Errorf("DryRunPolicy() sent the body %q, want %q", gotBody, policy)
+	}
+	if config != "server {\n}\n" {
+		t.Errorf("DryRunPolicy() returned %q, want the configuration of the response", config)
+	}
+}
+
+func TestDryRunPolicy_ReturnsErrorOnUnexpectedStatus(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "VirtualServer default/cafe doesn't exist or is invalid", http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	c := NewClient(ts.Client(), ts.URL)
+	_, err := c.DryRunPolicy(context.Background(), "default/cafe", []byte("kind: Policy"))
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("DryRunPolicy() returned %v, want *Error with status 404", err)
+	}
+}